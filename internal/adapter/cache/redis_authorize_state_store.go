@@ -59,3 +59,31 @@ func (s *RedisAuthorizeStateStore) DeleteState(ctx context.Context, key string)
 	}
 	return nil
 }
+
+// SavePushedRequest stores a pushed authorization request with TTL.
+func (s *RedisAuthorizeStateStore) SavePushedRequest(ctx context.Context, key string, data oauth.PushedAuthorizationRequest, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal pushed request: %w", err)
+	}
+	if err := s.client.Set(ctx, key, payload, ttl).Err(); err != nil {
+		return fmt.Errorf("persist pushed request: %w", err)
+	}
+	return nil
+}
+
+// TakePushedRequest atomically loads and deletes a pushed authorization request.
+func (s *RedisAuthorizeStateStore) TakePushedRequest(ctx context.Context, key string) (*oauth.PushedAuthorizationRequest, error) {
+	bytes, err := s.client.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("load pushed request: %w", err)
+	}
+	var req oauth.PushedAuthorizationRequest
+	if err := json.Unmarshal(bytes, &req); err != nil {
+		return nil, fmt.Errorf("decode pushed request: %w", err)
+	}
+	return &req, nil
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// RedisCooldownStore implements CooldownStore backed by Redis.
+type RedisCooldownStore struct {
+	client redis.UniversalClient
+}
+
+var _ repository.CooldownStore = (*RedisCooldownStore)(nil)
+
+// NewRedisCooldownStore constructs a Redis-backed cooldown store.
+func NewRedisCooldownStore(client redis.UniversalClient) *RedisCooldownStore {
+	return &RedisCooldownStore{client: client}
+}
+
+// Acquire sets the key with TTL only if it does not exist yet.
+func (s *RedisCooldownStore) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("acquire cooldown: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// RedisMagicLinkStore implements MagicLinkStore backed by Redis.
+type RedisMagicLinkStore struct {
+	client redis.UniversalClient
+}
+
+var _ repository.MagicLinkStore = (*RedisMagicLinkStore)(nil)
+
+// NewRedisMagicLinkStore constructs a Redis-backed magic-link store.
+func NewRedisMagicLinkStore(client redis.UniversalClient) *RedisMagicLinkStore {
+	return &RedisMagicLinkStore{client: client}
+}
+
+// SaveLink stores the encoded link payload with TTL.
+func (s *RedisMagicLinkStore) SaveLink(ctx context.Context, key string, data domain.MagicLink, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal magic link: %w", err)
+	}
+	if err := s.client.Set(ctx, key, payload, ttl).Err(); err != nil {
+		return fmt.Errorf("persist magic link: %w", err)
+	}
+	return nil
+}
+
+// GetLink loads and decodes the link payload without consuming it.
+func (s *RedisMagicLinkStore) GetLink(ctx context.Context, key string) (*domain.MagicLink, error) {
+	bytes, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("load magic link: %w", err)
+	}
+	var link domain.MagicLink
+	if err := json.Unmarshal(bytes, &link); err != nil {
+		return nil, fmt.Errorf("decode magic link: %w", err)
+	}
+	return &link, nil
+}
+
+// ConsumeLink deletes the key and reports whether it still existed. DEL is
+// atomic, so concurrent redemptions of the same link see true at most once.
+func (s *RedisMagicLinkStore) ConsumeLink(ctx context.Context, key string) (bool, error) {
+	removed, err := s.client.Del(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("consume magic link: %w", err)
+	}
+	return removed == 1, nil
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// attemptsScript counts a failed attempt and keeps the counter's expiry in
+// step with the challenge it belongs to.
+var attemptsScript = redis.NewScript(`
+local attempts = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return attempts
+`)
+
+// RedisMFAChallengeStore implements MFAChallengeStore backed by Redis.
+type RedisMFAChallengeStore struct {
+	client redis.UniversalClient
+}
+
+var _ repository.MFAChallengeStore = (*RedisMFAChallengeStore)(nil)
+
+// NewRedisMFAChallengeStore constructs a Redis-backed MFA challenge store.
+func NewRedisMFAChallengeStore(client redis.UniversalClient) *RedisMFAChallengeStore {
+	return &RedisMFAChallengeStore{client: client}
+}
+
+// SaveChallenge stores the encoded challenge payload with TTL.
+func (s *RedisMFAChallengeStore) SaveChallenge(ctx context.Context, key string, data domain.MFAChallenge, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal mfa challenge: %w", err)
+	}
+	if err := s.client.Set(ctx, key, payload, ttl).Err(); err != nil {
+		return fmt.Errorf("persist mfa challenge: %w", err)
+	}
+	return nil
+}
+
+// GetChallenge loads and decodes the challenge payload.
+func (s *RedisMFAChallengeStore) GetChallenge(ctx context.Context, key string) (*domain.MFAChallenge, error) {
+	bytes, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("load mfa challenge: %w", err)
+	}
+	var challenge domain.MFAChallenge
+	if err := json.Unmarshal(bytes, &challenge); err != nil {
+		return nil, fmt.Errorf("decode mfa challenge: %w", err)
+	}
+	return &challenge, nil
+}
+
+// IncrementAttempts counts a failed attempt in a counter beside the
+// challenge, so concurrent failures are never lost to a read-modify-write of
+// the payload.
+func (s *RedisMFAChallengeStore) IncrementAttempts(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	attempts, err := attemptsScript.Run(ctx, s.client, []string{attemptsKey(key)}, ttl.Milliseconds()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("count mfa attempt: %w", err)
+	}
+	return attempts, nil
+}
+
+// DeleteChallenge removes the persisted challenge key and its attempt
+// counter.
+func (s *RedisMFAChallengeStore) DeleteChallenge(ctx context.Context, key string) error {
+	// Separate DELs: the two keys may live on different cluster slots.
+	for _, k := range []string{key, attemptsKey(key)} {
+		if err := s.client.Del(ctx, k).Err(); err != nil && err != redis.Nil {
+			return fmt.Errorf("delete mfa challenge: %w", err)
+		}
+	}
+	return nil
+}
+
+func attemptsKey(key string) string {
+	return key + ":attempts"
+}
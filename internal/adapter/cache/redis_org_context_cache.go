@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+const (
+	orgContextKeyPrefix   = "orgctx:"
+	orgContextIndexPrefix = "orgctx:index:"
+	// orgContextVersionKey counts invalidations across all instances.
+	orgContextVersionKey = "orgctx:version"
+	// OrgContextInvalidationChannel carries the IDs of orgs whose cached
+	// context must be dropped by every instance.
+	OrgContextInvalidationChannel = "orgctx:invalidate"
+)
+
+// RedisOrgContextCache is a two-tier org context cache: an in-process LRU in
+// front of a shared Redis copy. Invalidations are broadcast over Redis pub/sub
+// so every instance drops its local entries.
+type RedisOrgContextCache struct {
+	client redis.UniversalClient
+	local  *org.LocalCache
+	ttl    time.Duration
+	logger *zap.Logger
+	hits   metric.Int64Counter
+}
+
+var _ org.Cache = (*RedisOrgContextCache)(nil)
+
+// setOrgContextScript stores an entry and indexes it by org only while the
+// version still matches the one taken before the entry was loaded.
+//
+// KEYS[1] version key, KEYS[2] entry key, KEYS[3] org index key.
+// ARGV[1] expected version, ARGV[2] payload, ARGV[3] ttl in ms, ARGV[4] entry
+// key without prefix. Returns 1 when stored, 0 when the version moved on.
+var setOrgContextScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1]) or "0"
+if current ~= ARGV[1] then
+  return 0
+end
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+redis.call("SADD", KEYS[3], ARGV[4])
+redis.call("PEXPIRE", KEYS[3], ARGV[3])
+return 1
+`)
+
+// NewRedisOrgContextCache constructs a two-tier cache. Entries live in Redis
+// for ttl and in the local LRU for the local cache's own TTL.
+func NewRedisOrgContextCache(client redis.UniversalClient, local *org.LocalCache, ttl time.Duration, logger *zap.Logger) *RedisOrgContextCache {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	hits, _ := otel.Meter("github.com/smallbiznis/railzway-auth/internal/adapter/cache").Int64Counter(
+		"org_context.cache.tier_hits",
+		metric.WithDescription("Org context cache hits by tier (local or redis)."),
+	)
+	return &RedisOrgContextCache{client: client, local: local, ttl: ttl, logger: logger, hits: hits}
+}
+
+// Get checks the local LRU first, then Redis. Redis failures are treated as
+// misses so resolution falls back to the database.
+func (c *RedisOrgContextCache) Get(ctx context.Context, key string) (*org.Context, bool) {
+	if value, ok := c.local.Get(ctx, key); ok {
+		c.hits.Add(ctx, 1, metric.WithAttributes(attribute.String("tier", "local")))
+		return value, true
+	}
+
+	localVersion := c.local.Version(ctx)
+	payload, err := c.client.Get(ctx, orgContextKeyPrefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			c.logger.Warn("load org context from redis", zap.String("key", key), zap.Error(err))
+		}
+		return nil, false
+	}
+	var value org.Context
+	if err := json.Unmarshal(payload, &value); err != nil {
+		c.logger.Warn("decode org context", zap.String("key", key), zap.Error(err))
+		return nil, false
+	}
+	c.local.Set(ctx, key, &value, localVersion)
+	c.hits.Add(ctx, 1, metric.WithAttributes(attribute.String("tier", "redis")))
+	return &value, true
+}
+
+// Version returns the shared invalidation count. When Redis is unreachable
+// it returns 0, which only matches before the first invalidation; Set then
+// falls back to the local tier's own version.
+func (c *RedisOrgContextCache) Version(ctx context.Context) uint64 {
+	version, err := c.client.Get(ctx, orgContextVersionKey).Uint64()
+	if err != nil && err != redis.Nil {
+		c.logger.Warn("load org context version", zap.Error(err))
+	}
+	return version
+}
+
+// Set stores value in both tiers and indexes the key by org so it can be
+// invalidated later. Nothing is stored if any instance invalidated the cache
+// after version was taken.
+func (c *RedisOrgContextCache) Set(ctx context.Context, key string, value *org.Context, version uint64) {
+	if value == nil {
+		return
+	}
+	// Taken before Redis is written: an invalidation that Redis no longer
+	// sees is published afterwards, and advances this version.
+	localVersion := c.local.Version(ctx)
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		c.logger.Warn("encode org context", zap.String("key", key), zap.Error(err))
+		return
+	}
+	indexKey := orgContextIndexPrefix + strconv.FormatInt(value.Org.ID, 10)
+	stored, err := setOrgContextScript.Run(ctx, c.client,
+		[]string{orgContextVersionKey, orgContextKeyPrefix + key, indexKey},
+		version, payload, c.ttl.Milliseconds(), key,
+	).Int()
+	if err != nil {
+		c.logger.Warn("persist org context to redis", zap.String("key", key), zap.Error(err))
+	} else if stored == 0 {
+		return
+	}
+	c.local.Set(ctx, key, value, localVersion)
+}
+
+// Invalidate removes the org's entries from Redis and the local LRU, then
+// notifies other instances.
+func (c *RedisOrgContextCache) Invalidate(ctx context.Context, orgID int64) error {
+	_ = c.local.Invalidate(ctx, orgID)
+
+	// Advance the version first so loads already in flight are not stored.
+	if err := c.client.Incr(ctx, orgContextVersionKey).Err(); err != nil {
+		return fmt.Errorf("advance org context version: %w", err)
+	}
+
+	indexKey := orgContextIndexPrefix + strconv.FormatInt(orgID, 10)
+	keys, err := c.client.SMembers(ctx, indexKey).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("load org context index: %w", err)
+	}
+	toDelete := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		toDelete = append(toDelete, orgContextKeyPrefix+key)
+	}
+	toDelete = append(toDelete, indexKey)
+	if err := c.client.Del(ctx, toDelete...).Err(); err != nil {
+		return fmt.Errorf("delete org context: %w", err)
+	}
+	if err := c.client.Publish(ctx, OrgContextInvalidationChannel, strconv.FormatInt(orgID, 10)).Err(); err != nil {
+		return fmt.Errorf("publish org context invalidation: %w", err)
+	}
+	return nil
+}
+
+// Subscribe drops local entries whenever another instance publishes an
+// invalidation. It blocks until ctx is cancelled.
+func (c *RedisOrgContextCache) Subscribe(ctx context.Context) {
+	sub := c.client.Subscribe(ctx, OrgContextInvalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			orgID, err := strconv.ParseInt(msg.Payload, 10, 64)
+			if err != nil {
+				c.logger.Warn("invalid org context invalidation", zap.String("payload", msg.Payload))
+				continue
+			}
+			_ = c.local.Invalidate(ctx, orgID)
+		}
+	}
+}
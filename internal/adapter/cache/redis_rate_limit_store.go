@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// gcraScript implements GCRA atomically, in microseconds of the Redis clock
+// so replicas with skewed clocks agree. The key holds the theoretical
+// arrival time of the next request and expires once the limit has fully
+// recovered.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000000 + tonumber(clock[2])
+local interval = window / limit
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then
+  tat = now
+end
+local next_tat = tat + interval
+local allow_at = next_tat - window
+if now < allow_at then
+  return {0, 0, math.ceil(tat - now), math.ceil(allow_at - now)}
+end
+redis.call('SET', KEYS[1], string.format('%.0f', next_tat), 'PX', math.ceil((next_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), math.ceil(next_tat - now), 0}
+`)
+
+// RedisRateLimitStore implements RateLimitStore backed by Redis, so limits
+// hold across every replica.
+type RedisRateLimitStore struct {
+	client redis.UniversalClient
+}
+
+var _ repository.RateLimitStore = (*RedisRateLimitStore)(nil)
+
+// NewRedisRateLimitStore constructs a Redis-backed rate limit store.
+func NewRedisRateLimitStore(client redis.UniversalClient) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// Allow counts a request against key.
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (domain.RateLimitResult, error) {
+	values, err := gcraScript.Run(ctx, s.client, []string{key}, limit, window.Microseconds()).Int64Slice()
+	if err != nil {
+		return domain.RateLimitResult{}, fmt.Errorf("rate limit: %w", err)
+	}
+	if len(values) != 4 {
+		return domain.RateLimitResult{}, fmt.Errorf("rate limit: unexpected script result %v", values)
+	}
+	return domain.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
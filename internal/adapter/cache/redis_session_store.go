@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// RedisSessionStore implements SessionStore backed by Redis.
+type RedisSessionStore struct {
+	client redis.UniversalClient
+}
+
+var _ repository.SessionStore = (*RedisSessionStore)(nil)
+
+// NewRedisSessionStore constructs a Redis-backed session store.
+func NewRedisSessionStore(client redis.UniversalClient) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+// SaveSession stores the encoded session payload with TTL.
+func (s *RedisSessionStore) SaveSession(ctx context.Context, key string, data domain.Session, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	if err := s.client.Set(ctx, key, payload, ttl).Err(); err != nil {
+		return fmt.Errorf("persist session: %w", err)
+	}
+	return nil
+}
+
+// RefreshSession rewrites the session only if the key still exists (SET XX).
+func (s *RedisSessionStore) RefreshSession(ctx context.Context, key string, data domain.Session, ttl time.Duration) (bool, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return false, fmt.Errorf("marshal session: %w", err)
+	}
+	ok, err := s.client.SetXX(ctx, key, payload, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("refresh session: %w", err)
+	}
+	return ok, nil
+}
+
+// GetSession loads and decodes the session payload.
+func (s *RedisSessionStore) GetSession(ctx context.Context, key string) (*domain.Session, error) {
+	bytes, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	var session domain.Session
+	if err := json.Unmarshal(bytes, &session); err != nil {
+		return nil, fmt.Errorf("decode session: %w", err)
+	}
+	return &session, nil
+}
+
+// DeleteSession removes the persisted session key.
+func (s *RedisSessionStore) DeleteSession(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
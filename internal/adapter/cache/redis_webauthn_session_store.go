@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// RedisWebAuthnSessionStore implements WebAuthnSessionStore backed by Redis.
+type RedisWebAuthnSessionStore struct {
+	client redis.UniversalClient
+}
+
+var _ repository.WebAuthnSessionStore = (*RedisWebAuthnSessionStore)(nil)
+
+// NewRedisWebAuthnSessionStore constructs a Redis-backed WebAuthn ceremony store.
+func NewRedisWebAuthnSessionStore(client redis.UniversalClient) *RedisWebAuthnSessionStore {
+	return &RedisWebAuthnSessionStore{client: client}
+}
+
+// SaveSession stores the encoded session payload with TTL.
+func (s *RedisWebAuthnSessionStore) SaveSession(ctx context.Context, key string, data domain.WebAuthnSession, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal webauthn session: %w", err)
+	}
+	if err := s.client.Set(ctx, key, payload, ttl).Err(); err != nil {
+		return fmt.Errorf("persist webauthn session: %w", err)
+	}
+	return nil
+}
+
+// GetSession loads and decodes the session payload.
+func (s *RedisWebAuthnSessionStore) GetSession(ctx context.Context, key string) (*domain.WebAuthnSession, error) {
+	bytes, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("load webauthn session: %w", err)
+	}
+	var session domain.WebAuthnSession
+	if err := json.Unmarshal(bytes, &session); err != nil {
+		return nil, fmt.Errorf("decode webauthn session: %w", err)
+	}
+	return &session, nil
+}
+
+// DeleteSession removes the persisted session key.
+func (s *RedisWebAuthnSessionStore) DeleteSession(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("delete webauthn session: %w", err)
+	}
+	return nil
+}
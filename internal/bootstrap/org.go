@@ -35,14 +35,16 @@ func ensureOrg(ctx context.Context, cfg config.Config, orgs repository.OrgReposi
 		return nil
 	}
 
-	// Create default org
+	// Create default org. It is the platform org, whose admins manage all
+	// other orgs.
 	orgID := int64(2016070718164307968)
 	newOrg := domain.Org{
 		ID:        orgID,
 		Name:      "Default Organization",
 		Slug:      fmt.Sprintf("org-%d", orgID),
-		Status:    "ACTIVE",
-		Type:      "organization",
+		Status:    domain.OrgStatusActive,
+		Type:      domain.OrgTypePlatform,
+		IsDefault: true,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
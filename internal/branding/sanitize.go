@@ -0,0 +1,231 @@
+// Package branding renders an org's custom branding into the login UI. The
+// login pages collect credentials, so custom CSS and HTML are sanitized, and
+// custom JS only runs behind a per-response CSP nonce once a platform admin
+// has enabled it for the org.
+package branding
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	cssCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssAtRulePattern  = regexp.MustCompile(`(?i)@(import|charset|namespace)[^;]*;?`)
+	cssURLPattern     = regexp.MustCompile(`(?i)url\(\s*(['"]?)([^'")]*)(['"]?)\s*\)`)
+	// cssDangerPattern matches constructs that execute script or load
+	// bindings in some browsers.
+	cssDangerPattern = regexp.MustCompile(`(?i)(expression\s*\(|javascript:|vbscript:|behavior\s*:|-moz-binding)`)
+	safeDataImage    = regexp.MustCompile(`(?i)^data:image/(png|gif|jpeg|webp);base64,[a-z0-9+/=]+$`)
+)
+
+// SanitizeCSS makes custom CSS safe to inline in a style element. It drops
+// comments, escapes, @import and similar rules, script-bearing constructs
+// and url() values other than raster data URIs and https URLs on one of
+// assetOrigins (see AssetOrigins). Arbitrary hosts are refused because
+// attribute selectors plus url() can leak what users type.
+func SanitizeCSS(css string, assetOrigins []string) string {
+	css = cssCommentPattern.ReplaceAllString(css, "")
+	// Escapes can spell out anything the checks below look for.
+	css = strings.ReplaceAll(css, `\`, "")
+	css = strings.ReplaceAll(css, "<", "")
+	css = cssAtRulePattern.ReplaceAllString(css, "")
+	css = cssURLPattern.ReplaceAllStringFunc(css, func(match string) string {
+		parts := cssURLPattern.FindStringSubmatch(match)
+		if parts[1] != parts[3] || !safeAssetURL(parts[2], assetOrigins) {
+			return "none"
+		}
+		return `url("` + parts[2] + `")`
+	})
+	// Removing one match can join the text around it into another.
+	for {
+		cleaned := cssDangerPattern.ReplaceAllString(css, "")
+		if cleaned == css {
+			return strings.TrimSpace(css)
+		}
+		css = cleaned
+	}
+}
+
+// safeAssetURL reports whether raw is a raster data URI or an https URL on
+// one of origins.
+func safeAssetURL(raw string, origins []string) bool {
+	raw = strings.TrimSpace(raw)
+	if safeDataImage.MatchString(raw) {
+		return true
+	}
+	origin, ok := httpsOrigin(raw)
+	if !ok {
+		return false
+	}
+	for _, allowed := range origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// httpsOrigin returns the scheme and host of an https URL.
+func httpsOrigin(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", false
+	}
+	return "https://" + strings.ToLower(u.Host), true
+}
+
+func safeCSSURL(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if safeDataImage.MatchString(raw) {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// allowedElements are the elements custom header and footer HTML may use.
+var allowedElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Br: true, atom.Code: true,
+	atom.Div: true, atom.Em: true, atom.Footer: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.I: true, atom.Img: true, atom.Li: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Section: true, atom.Small: true, atom.Span: true,
+	atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.U: true, atom.Ul: true,
+}
+
+// droppedElements are removed together with their content.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Template: true, atom.Noscript: true, atom.Textarea: true,
+	atom.Title: true, atom.Xmp: true, atom.Noembed: true, atom.Noframes: true,
+	atom.Svg: true, atom.Math: true, atom.Select: true, atom.Frameset: true,
+	atom.Plaintext: true, atom.Form: true,
+}
+
+// allowedAttributes are allowed on any allowed element. id is left out so
+// custom HTML cannot shadow the login UI's elements.
+var allowedAttributes = map[string]bool{
+	"class": true, "title": true, "role": true, "aria-label": true, "aria-hidden": true,
+}
+
+// SanitizeHTML keeps the allow-listed elements and attributes of custom
+// header or footer HTML and escapes everything else. Links must be https,
+// mailto or same-origin paths, and images https or raster data URIs.
+func SanitizeHTML(fragment string) string {
+	var out bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	var open []atom.Atom
+	skipDepth := 0
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return ""
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				out.WriteString("</" + open[i].String() + ">")
+			}
+			return out.String()
+		case html.TextToken:
+			if skipDepth == 0 {
+				out.WriteString(html.EscapeString(string(z.Text())))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if droppedElements[tok.DataAtom] {
+				if tt == html.StartTagToken && !isVoid(tok.DataAtom) {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 || !allowedElements[tok.DataAtom] {
+				continue
+			}
+			out.WriteString(renderStartTag(tok))
+			if tt == html.StartTagToken && !isVoid(tok.DataAtom) {
+				open = append(open, tok.DataAtom)
+			}
+		case html.EndTagToken:
+			tok := z.Token()
+			if droppedElements[tok.DataAtom] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 || !allowedElements[tok.DataAtom] {
+				continue
+			}
+			// Close up to the matching element so the output stays balanced.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.DataAtom {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j].String() + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+func renderStartTag(tok html.Token) string {
+	var b strings.Builder
+	b.WriteString("<" + tok.DataAtom.String())
+	external := false
+	for _, attr := range tok.Attr {
+		key := strings.ToLower(attr.Key)
+		value := strings.TrimSpace(attr.Val)
+		switch {
+		case allowedAttributes[key]:
+		case tok.DataAtom == atom.A && key == "href" && safeLinkURL(value):
+			external = strings.HasPrefix(strings.ToLower(value), "https:")
+		case tok.DataAtom == atom.Img && key == "src" && safeCSSURL(value):
+		case tok.DataAtom == atom.Img && (key == "alt" || key == "width" || key == "height"):
+		default:
+			continue
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+	}
+	if external {
+		b.WriteString(` target="_blank" rel="noopener noreferrer"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func safeLinkURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return u.Host != ""
+	case "mailto":
+		return true
+	case "":
+		// Same-origin paths only; browsers read "//host" and "/\host" as
+		// another origin.
+		return u.Host == "" && strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") && !strings.Contains(raw, `\`)
+	}
+	return false
+}
+
+func isVoid(a atom.Atom) bool {
+	switch a {
+	case atom.Br, atom.Hr, atom.Img, atom.Embed:
+		return true
+	}
+	return false
+}
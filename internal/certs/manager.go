@@ -0,0 +1,165 @@
+// Package certs obtains and renews TLS certificates for org domains through
+// ACME, so custom domains can be served without terminating TLS elsewhere.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// ErrHostNotAllowed is returned for hosts that are unknown or unverified.
+var ErrHostNotAllowed = errors.New("certs: host not allowed")
+
+// Manager issues certificates on the first TLS handshake for a verified
+// domain, renews them ahead of expiry and records the outcome in
+// domains.certificate_status.
+type Manager struct {
+	acme    *autocert.Manager
+	orgs    repository.OrgRepository
+	domains repository.DomainRepository
+	logger  *zap.Logger
+
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// NewManager creates a Manager that keeps its ACME state in cache.
+func NewManager(cfg config.Config, cache autocert.Cache, orgs repository.OrgRepository, domains repository.DomainRepository, logger *zap.Logger) *Manager {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	m := &Manager{
+		orgs:    orgs,
+		domains: domains,
+		logger:  logger,
+		failed:  make(map[string]bool),
+	}
+	m.acme = &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       &statusCache{Cache: cache, manager: m},
+		HostPolicy:  m.HostPolicy,
+		RenewBefore: cfg.ACMERenewBefore,
+		Email:       cfg.ACMEEmail,
+		Client:      &acme.Client{DirectoryURL: cfg.ACMEDirectoryURL},
+	}
+	return m
+}
+
+// HostPolicy allows certificates only for hosts of verified domains.
+func (m *Manager) HostPolicy(ctx context.Context, host string) error {
+	host = normalizeHost(host)
+	if host == "" || net.ParseIP(host) != nil {
+		return ErrHostNotAllowed
+	}
+	d, err := m.orgs.GetDomainByHost(ctx, host)
+	if err != nil || !d.Verified {
+		return fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+	return nil
+}
+
+// TLSConfig returns the configuration for the HTTPS listener. It answers
+// TLS-ALPN-01 challenges and serves issued certificates.
+func (m *Manager) TLSConfig() *tls.Config {
+	cfg := m.acme.TLSConfig()
+	cfg.GetCertificate = m.getCertificate
+	return cfg
+}
+
+// HTTPHandler answers HTTP-01 challenges under /.well-known/acme-challenge/.
+func (m *Manager) HTTPHandler() http.Handler {
+	return m.acme.HTTPHandler(nil)
+}
+
+func (m *Manager) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, err := m.acme.GetCertificate(hello)
+	host := normalizeHost(hello.ServerName)
+	if err == nil || host == "" || errors.Is(err, ErrHostNotAllowed) || isChallenge(hello) {
+		return cert, err
+	}
+	m.logger.Warn("obtain certificate", zap.String("host", host), zap.Error(err))
+
+	m.mu.Lock()
+	seen := m.failed[host]
+	m.failed[host] = true
+	m.mu.Unlock()
+	if !seen {
+		m.setStatus(host, domain.DomainCertificateFailed)
+	}
+	return nil, err
+}
+
+func (m *Manager) issued(host string) {
+	m.mu.Lock()
+	delete(m.failed, host)
+	m.mu.Unlock()
+	m.setStatus(host, domain.DomainCertificateActive)
+}
+
+func (m *Manager) setStatus(host, status string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.domains.UpdateCertificateStatus(ctx, host, status, time.Now().UTC()); err != nil {
+		m.logger.Warn("update certificate status", zap.String("host", host), zap.String("status", status), zap.Error(err))
+	}
+}
+
+// statusCache marks a domain's certificate active whenever autocert stores a
+// newly issued or renewed certificate for it.
+type statusCache struct {
+	autocert.Cache
+	manager *Manager
+}
+
+func (c *statusCache) Put(ctx context.Context, key string, data []byte) error {
+	if err := c.Cache.Put(ctx, key, data); err != nil {
+		return err
+	}
+	if host, ok := certificateHost(key); ok {
+		c.manager.issued(host)
+	}
+	return nil
+}
+
+// certificateHost reports the host of a certificate cache key. autocert
+// stores certificates under the host name (with a "+rsa" suffix for RSA
+// certificates); accounts and challenge tokens use other "+" suffixes.
+func certificateHost(key string) (string, bool) {
+	host := strings.TrimSuffix(key, "+rsa")
+	if host == "" || strings.Contains(host, "+") {
+		return "", false
+	}
+	return host, true
+}
+
+func isChallenge(hello *tls.ClientHelloInfo) bool {
+	for _, proto := range hello.SupportedProtos {
+		if proto == acme.ALPNProto {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,90 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+func TestHostPolicyOnlyAllowsVerifiedDomains(t *testing.T) {
+	m := newTestManager(t, "http://127.0.0.1:1/directory")
+	ctx := context.Background()
+
+	require.NoError(t, m.HostPolicy(ctx, "login.example.com"))
+	require.NoError(t, m.HostPolicy(ctx, "Login.Example.com:443"))
+	require.ErrorIs(t, m.HostPolicy(ctx, "pending.example.com"), ErrHostNotAllowed)
+	require.ErrorIs(t, m.HostPolicy(ctx, "unknown.example.com"), ErrHostNotAllowed)
+	require.ErrorIs(t, m.HostPolicy(ctx, "203.0.113.7"), ErrHostNotAllowed)
+}
+
+func TestCertificateStatusFollowsIssuance(t *testing.T) {
+	acmeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Not retried by the ACME client, so issuance fails immediately.
+		http.NotFound(w, r)
+	}))
+	defer acmeServer.Close()
+
+	m := newTestManager(t, acmeServer.URL)
+	domains := m.domains.(*fakeDomains)
+
+	_, err := m.getCertificate(&tls.ClientHelloInfo{ServerName: "pending.example.com"})
+	require.ErrorIs(t, err, ErrHostNotAllowed)
+	require.Empty(t, domains.status, "refused hosts are not recorded")
+
+	_, err = m.getCertificate(&tls.ClientHelloInfo{ServerName: "login.example.com"})
+	require.Error(t, err)
+	require.Equal(t, domain.DomainCertificateFailed, domains.status["login.example.com"])
+
+	cache := m.acme.Cache
+	require.NoError(t, cache.Put(context.Background(), "acme_account+key", []byte("key")))
+	require.NoError(t, cache.Put(context.Background(), "login.example.com+http-01", []byte("token")))
+	require.Equal(t, domain.DomainCertificateFailed, domains.status["login.example.com"])
+
+	require.NoError(t, cache.Put(context.Background(), "login.example.com", []byte("cert")))
+	require.Equal(t, domain.DomainCertificateActive, domains.status["login.example.com"])
+}
+
+func newTestManager(t *testing.T, directoryURL string) *Manager {
+	t.Helper()
+	orgs := &fakeOrgs{domains: map[string]domain.Domain{
+		"login.example.com":   {ID: 1, OrgID: 1, Host: "login.example.com", Verified: true},
+		"pending.example.com": {ID: 2, OrgID: 1, Host: "pending.example.com"},
+	}}
+	cfg := config.Config{ACMEDirectoryURL: directoryURL, ACMERenewBefore: 30 * 24 * time.Hour}
+	return NewManager(cfg, autocert.DirCache(t.TempDir()), orgs, &fakeDomains{status: map[string]string{}}, zap.NewNop())
+}
+
+type fakeOrgs struct {
+	repository.OrgRepository
+	domains map[string]domain.Domain
+}
+
+func (f *fakeOrgs) GetDomainByHost(_ context.Context, host string) (domain.Domain, error) {
+	d, ok := f.domains[host]
+	if !ok {
+		return domain.Domain{}, pgx.ErrNoRows
+	}
+	return d, nil
+}
+
+type fakeDomains struct {
+	repository.DomainRepository
+	status map[string]string
+}
+
+func (f *fakeDomains) UpdateCertificateStatus(_ context.Context, host, status string, _ time.Time) error {
+	f.status[host] = status
+	return nil
+}
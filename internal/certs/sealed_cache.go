@@ -0,0 +1,57 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/smallbiznis/railzway-auth/internal/secretbox"
+)
+
+// sealedCache encrypts entries before they reach the shared cache, since
+// they include the ACME account key and certificate private keys.
+type sealedCache struct {
+	cache  autocert.Cache
+	sealer *secretbox.Sealer
+}
+
+// NewSealedCache wraps cache so entries are stored encrypted with sealer,
+// which must have a key. Entries written before encryption was enabled are
+// read as-is and sealed in place.
+func NewSealedCache(cache autocert.Cache, sealer *secretbox.Sealer) (autocert.Cache, error) {
+	if !sealer.Enabled() {
+		return nil, fmt.Errorf("acme cache: %w", secretbox.ErrNoKey)
+	}
+	return &sealedCache{cache: cache, sealer: sealer}, nil
+}
+
+func (c *sealedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !secretbox.IsSealed(string(data)) {
+		if err := c.Put(ctx, key, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	plaintext, err := c.sealer.Open(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("acme cache entry %s: %w", key, err)
+	}
+	return plaintext, nil
+}
+
+func (c *sealedCache) Put(ctx context.Context, key string, data []byte) error {
+	sealed, err := c.sealer.Seal(data)
+	if err != nil {
+		return fmt.Errorf("acme cache entry %s: %w", key, err)
+	}
+	return c.cache.Put(ctx, key, []byte(sealed))
+}
+
+func (c *sealedCache) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, key)
+}
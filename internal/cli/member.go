@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+var memberCmd = &cobra.Command{
+	Use:   "member",
+	Short: "Manage who administers an organization",
+	Long:  "Changes run as the operator, who may do anything an owner may. Use them to grant an org its first owner.",
+}
+
+var listMembersCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List an organization's members",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMemberService(func(members *service.MemberService) error {
+			orgID, _ := cmd.Flags().GetInt64("org-id")
+			limit, _ := cmd.Flags().GetInt("limit")
+			offset, _ := cmd.Flags().GetInt("offset")
+
+			page, err := members.ListMembers(context.Background(), orgID, limit, offset)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "USER ID\tEMAIL\tROLE\tSTATUS")
+			for _, m := range page.Members {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", m.UserID, m.Email, m.Role, m.Status)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("Showing %d of %d (offset %d)\n", len(page.Members), page.Total, page.Offset)
+			return nil
+		})
+	},
+}
+
+var addMemberCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Give an existing user of the organization a role",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMemberService(func(members *service.MemberService) error {
+			orgID, _ := cmd.Flags().GetInt64("org-id")
+			var input service.MemberInput
+			input.Email, _ = cmd.Flags().GetString("email")
+			input.Role, _ = cmd.Flags().GetString("role")
+
+			m, err := members.AddMember(context.Background(), service.OperatorMember, orgID, input)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Added %s (user %d) as %s\n", m.Email, m.UserID, m.Role)
+			return nil
+		})
+	},
+}
+
+var updateMemberCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Change a member's role or status",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMemberService(func(members *service.MemberService) error {
+			orgID, _ := cmd.Flags().GetInt64("org-id")
+			userID, _ := cmd.Flags().GetInt64("user-id")
+			var update service.MemberUpdate
+			update.Role = changedFlag(cmd, "role")
+			update.Status = changedFlag(cmd, "status")
+
+			m, err := members.UpdateMember(context.Background(), service.OperatorMember, orgID, userID, update)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Member %s (user %d) is now %s, %s\n", m.Email, m.UserID, m.Role, m.Status)
+			return nil
+		})
+	},
+}
+
+var removeMemberCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a member; the user account is kept",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMemberService(func(members *service.MemberService) error {
+			orgID, _ := cmd.Flags().GetInt64("org-id")
+			userID, _ := cmd.Flags().GetInt64("user-id")
+			if err := members.RemoveMember(context.Background(), service.OperatorMember, orgID, userID); err != nil {
+				return err
+			}
+			fmt.Printf("Removed user %d from org %d\n", userID, orgID)
+			return nil
+		})
+	},
+}
+
+var inviteMemberCmd = &cobra.Command{
+	Use:   "invite",
+	Short: "Email an invitation to join an organization",
+	Long:  "The invitee accepts through the login UI at --issuer and can create an account there even when the organization does not allow sign-up.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMemberService(func(members *service.MemberService) error {
+			orgCtx, err := cliOrgContext(cmd)
+			if err != nil {
+				return err
+			}
+			var input service.InvitationInput
+			input.Email, _ = cmd.Flags().GetString("email")
+			input.Role, _ = cmd.Flags().GetString("role")
+			issuer, _ := cmd.Flags().GetString("issuer")
+
+			inv, err := members.InviteMember(context.Background(), service.OperatorMember, orgCtx, input, issuer)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Invited %s as %s (invitation %d, expires %s)\n", inv.Email, inv.Role, inv.ID, inv.ExpiresAt.Format(time.RFC3339))
+			return nil
+		})
+	},
+}
+
+// cliOrgContext loads the org named by --org-id for commands that need more
+// than its ID.
+func cliOrgContext(cmd *cobra.Command) (*org.Context, error) {
+	cfg, err := newConfig()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := newPGXPool(nil, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	orgID, _ := cmd.Flags().GetInt64("org-id")
+	o, err := newOrgRepository(pool, newQueries(pool)).GetOrg(context.Background(), orgID)
+	if err != nil {
+		return nil, fmt.Errorf("load org %d: %w", orgID, err)
+	}
+	return &org.Context{Org: o}, nil
+}
+
+// withMemberService runs fn with a MemberService backed by the configured
+// database. Memberships are read on every admin request, so changes take
+// effect immediately.
+func withMemberService(fn func(*service.MemberService) error) error {
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+
+	pool, err := newPGXPool(nil, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	snowflakeNode, err := newSnowflake()
+	if err != nil {
+		return err
+	}
+
+	logger := zap.NewNop()
+	return fn(service.NewMemberService(newMembershipRepository(pool), newUserRepository(pool), newMailer(cfg, logger), snowflakeNode, cfg, logger))
+}
+
+func init() {
+	rootCmd.AddCommand(memberCmd)
+	memberCmd.AddCommand(listMembersCmd, addMemberCmd, updateMemberCmd, removeMemberCmd, inviteMemberCmd)
+
+	listMembersCmd.Flags().Int("limit", 50, "Page size")
+	listMembersCmd.Flags().Int("offset", 0, "Number of members to skip")
+
+	addMemberCmd.Flags().String("email", "", "Email of an existing user of the organization")
+	addMemberCmd.Flags().String("role", "", "Role (owner, admin, staff or viewer)")
+	addMemberCmd.MarkFlagRequired("email")
+	addMemberCmd.MarkFlagRequired("role")
+
+	inviteMemberCmd.Flags().String("email", "", "Email to invite")
+	inviteMemberCmd.Flags().String("role", "", "Role (owner, admin, staff or viewer)")
+	inviteMemberCmd.Flags().String("issuer", "", "Base URL of the organization's login UI, e.g. https://acme.example.com")
+	inviteMemberCmd.MarkFlagRequired("email")
+	inviteMemberCmd.MarkFlagRequired("role")
+	inviteMemberCmd.MarkFlagRequired("issuer")
+
+	updateMemberCmd.Flags().String("role", "", "Role (owner, admin, staff or viewer)")
+	updateMemberCmd.Flags().String("status", "", "Status (active or disabled)")
+
+	for _, cmd := range []*cobra.Command{updateMemberCmd, removeMemberCmd} {
+		cmd.Flags().Int64("user-id", 0, "User ID")
+		cmd.MarkFlagRequired("user-id")
+	}
+	for _, cmd := range []*cobra.Command{listMembersCmd, addMemberCmd, updateMemberCmd, removeMemberCmd, inviteMemberCmd} {
+		cmd.Flags().Int64("org-id", 0, "Organization ID")
+		cmd.MarkFlagRequired("org-id")
+	}
+}
@@ -3,10 +3,18 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 
-	"github.com/smallbiznis/railzway-auth/internal/domain"
+	cacheadapter "github.com/smallbiznis/railzway-auth/internal/adapter/cache"
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/service"
 )
 
 var orgCmd = &cobra.Command{
@@ -17,56 +25,301 @@ var orgCmd = &cobra.Command{
 var createOrgCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new organization",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			var input service.OrgInput
+			input.Name, _ = cmd.Flags().GetString("name")
+			input.Slug, _ = cmd.Flags().GetString("slug")
+			input.Type, _ = cmd.Flags().GetString("type")
+			input.Code, _ = cmd.Flags().GetString("code")
+			input.ExternalID, _ = cmd.Flags().GetString("external-id")
+			input.CountryCode, _ = cmd.Flags().GetString("country")
+			input.Timezone, _ = cmd.Flags().GetString("timezone")
+
+			created, err := orgs.CreateOrg(context.Background(), input)
+			if err != nil {
+				return fmt.Errorf("create org: %w", err)
+			}
+
+			fmt.Printf("Created Org: %s (ID: %d, Slug: %s)\n", created.Name, created.ID, created.Slug)
+			return nil
+		})
+	},
+}
+
+var listOrgsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List organizations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			status, _ := cmd.Flags().GetString("status")
+			limit, _ := cmd.Flags().GetInt("limit")
+			offset, _ := cmd.Flags().GetInt("offset")
+
+			page, err := orgs.ListOrgs(context.Background(), status, limit, offset)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSLUG\tNAME\tTYPE\tSTATUS\tCREATED")
+			for _, o := range page.Orgs {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", o.ID, o.Slug, o.Name, o.Type, o.Status, o.CreatedAt.Format(time.RFC3339))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("Showing %d of %d (offset %d)\n", len(page.Orgs), page.Total, page.Offset)
+			return nil
+		})
+	},
+}
+
+var getOrgCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show an organization",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			orgID, _ := cmd.Flags().GetInt64("id")
+			o, err := orgs.GetOrg(context.Background(), orgID)
+			if err != nil {
+				return err
+			}
+			printOrg(o)
+			return nil
+		})
+	},
+}
+
+var updateOrgCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update an organization's details",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			orgID, _ := cmd.Flags().GetInt64("id")
+			var update service.OrgUpdate
+			update.Name = changedFlag(cmd, "name")
+			update.Code = changedFlag(cmd, "code")
+			update.Slug = changedFlag(cmd, "slug")
+			update.ExternalID = changedFlag(cmd, "external-id")
+			update.CountryCode = changedFlag(cmd, "country")
+			update.Timezone = changedFlag(cmd, "timezone")
+
+			o, err := orgs.UpdateOrg(context.Background(), orgID, update)
+			if err != nil {
+				return err
+			}
+			printOrg(o)
+			return nil
+		})
+	},
+}
+
+var suspendOrgCmd = &cobra.Command{
+	Use:   "suspend",
+	Short: "Block sign-in and token issuance for an organization",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			orgID, _ := cmd.Flags().GetInt64("id")
+			o, err := orgs.SuspendOrg(context.Background(), 0, orgID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Suspended org %d (%s)\n", o.ID, o.Slug)
+			return nil
+		})
+	},
+}
+
+var activateOrgCmd = &cobra.Command{
+	Use:   "activate",
+	Short: "Activate a pending or suspended organization",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			orgID, _ := cmd.Flags().GetInt64("id")
+			o, err := orgs.ActivateOrg(context.Background(), orgID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Activated org %d (%s)\n", o.ID, o.Slug)
+			return nil
+		})
+	},
+}
+
+var deleteOrgCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Soft-delete an organization",
+	Long:  "The organization stops being served immediately and is purged with all of its data after ORG_DELETION_RETENTION unless restored.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			orgID, _ := cmd.Flags().GetInt64("id")
+			o, err := orgs.DeleteOrg(context.Background(), 0, orgID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Deleted org %d (%s); purged after %s\n", o.ID, o.Slug, o.PurgeAfter.Format(time.RFC3339))
+			return nil
+		})
+	},
+}
+
+var restoreOrgCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a deleted organization before it is purged",
+	Long:  "Restored organizations come back suspended; activate them once reviewed.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			orgID, _ := cmd.Flags().GetInt64("id")
+			o, err := orgs.RestoreOrg(context.Background(), orgID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored org %d (%s) as %s\n", o.ID, o.Slug, o.Status)
+			return nil
+		})
+	},
+}
+
+var purgeOrgsCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove organizations deleted longer than the retention period",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withOrgService(func(orgs *service.OrgService) error {
+			purged, err := orgs.PurgeDeletedOrgs(context.Background())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Purged %d org(s)\n", purged)
+			return nil
+		})
+	},
+}
+
+var invalidateOrgCacheCmd = &cobra.Command{
+	Use:   "invalidate-cache",
+	Short: "Drop the cached context of an organization on every instance",
+	Long:  "Run after changing org domains, branding, auth providers or login configs directly in the database.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := newConfig()
 		if err != nil {
 			return err
 		}
 
-		pool, err := newPGXPool(nil, cfg) // Lifecycle is nil, careful with cleanup, but for CLI 1-off it's ok-ish or use dummy
+		client, err := newRedisClient(nil, cfg)
 		if err != nil {
 			return err
 		}
-		defer pool.Close()
+		defer client.Close()
 
-		q := newQueries(pool)
-		orgRepo := newOrgRepository(pool, q)
-		// We can add simple ID generation if we want, or rely on DB defaults (if sequence).
-		// Currently Org ID is int64 (Snowflake usually).
-		snowflakeNode, err := newSnowflake()
-		if err != nil {
+		orgID, _ := cmd.Flags().GetInt64("id")
+		local := org.NewLocalCache(1, cfg.OrgCacheLocalTTL)
+		orgCache := cacheadapter.NewRedisOrgContextCache(client, local, cfg.OrgCacheTTL, zap.NewNop())
+		if err := orgCache.Invalidate(context.Background(), orgID); err != nil {
 			return err
 		}
 
-		name, _ := cmd.Flags().GetString("name")
-		slug, _ := cmd.Flags().GetString("slug")
-		
-		id := snowflakeNode.Generate().Int64()
-
-		org := domain.Org{
-			ID:     id,
-			Name:   name,
-			Slug:   slug,
-			Status: "ACTIVE",
-			Type:   "organization", // Default
-		}
+		fmt.Printf("Invalidated cached context for org %d\n", orgID)
+		return nil
+	},
+}
 
-		created, err := orgRepo.Create(context.Background(), org)
-		if err != nil {
-			return fmt.Errorf("create org: %w", err)
-		}
+// withOrgService runs fn with an OrgService backed by the configured database.
+// Changes are published to running instances through Redis when it is
+// reachable; otherwise they take effect once cached contexts expire.
+func withOrgService(fn func(*service.OrgService) error) error {
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+
+	pool, err := newPGXPool(nil, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	snowflakeNode, err := newSnowflake()
+	if err != nil {
+		return err
+	}
 
-		fmt.Printf("Created Org: %s (ID: %d, Slug: %s)\n", created.Name, created.ID, created.Slug)
+	orgRepo := newOrgRepository(pool, newQueries(pool))
+	resolver, closeCache := cliOrgResolver(cfg, orgRepo)
+	defer closeCache()
+
+	return fn(service.NewOrgService(orgRepo, resolver, snowflakeNode, cfg, zap.NewNop()))
+}
+
+// cliOrgResolver returns a resolver whose invalidations reach running
+// instances through Redis when it is reachable; otherwise changes take effect
+// once cached contexts expire.
+func cliOrgResolver(cfg config.Config, orgRepo repository.OrgRepository) (*org.Resolver, func()) {
+	resolver := org.NewResolver(orgRepo)
+	client, err := newRedisClient(nil, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; running instances pick up changes within ORG_CACHE_TTL\n", err)
+		return resolver, func() {}
+	}
+	local := org.NewLocalCache(1, cfg.OrgCacheLocalTTL)
+	resolver.WithCache(cacheadapter.NewRedisOrgContextCache(client, local, cfg.OrgCacheTTL, zap.NewNop()))
+	return resolver, func() { client.Close() }
+}
+
+func changedFlag(cmd *cobra.Command, name string) *string {
+	if !cmd.Flags().Changed(name) {
 		return nil
-	},
+	}
+	value, _ := cmd.Flags().GetString(name)
+	return &value
+}
+
+func printOrg(o service.OrgInfo) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%d\n", o.ID)
+	fmt.Fprintf(w, "Name:\t%s\n", o.Name)
+	fmt.Fprintf(w, "Slug:\t%s\n", o.Slug)
+	fmt.Fprintf(w, "Code:\t%s\n", o.Code)
+	fmt.Fprintf(w, "Type:\t%s\n", o.Type)
+	fmt.Fprintf(w, "Status:\t%s\n", o.Status)
+	fmt.Fprintf(w, "External ID:\t%s\n", o.ExternalID)
+	fmt.Fprintf(w, "Country:\t%s\n", o.CountryCode)
+	fmt.Fprintf(w, "Timezone:\t%s\n", o.Timezone)
+	fmt.Fprintf(w, "Created:\t%s\n", o.CreatedAt.Format(time.RFC3339))
+	if o.DeletedAt != nil {
+		fmt.Fprintf(w, "Deleted:\t%s\n", o.DeletedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "Purge after:\t%s\n", o.PurgeAfter.Format(time.RFC3339))
+	}
+	_ = w.Flush()
 }
 
 func init() {
 	rootCmd.AddCommand(orgCmd)
-	orgCmd.AddCommand(createOrgCmd)
+	orgCmd.AddCommand(createOrgCmd, listOrgsCmd, getOrgCmd, updateOrgCmd, suspendOrgCmd, activateOrgCmd, deleteOrgCmd, restoreOrgCmd, purgeOrgsCmd, invalidateOrgCacheCmd)
 
 	createOrgCmd.Flags().String("name", "", "Organization Name")
 	createOrgCmd.Flags().String("slug", "", "Organization Slug")
+	createOrgCmd.Flags().String("type", "company", "Organization Type (personal or company)")
+	createOrgCmd.Flags().String("code", "", "Organization Code (defaults to the upper-cased slug)")
+	createOrgCmd.Flags().String("external-id", "", "External ID")
+	createOrgCmd.Flags().String("country", "", "Country Code")
+	createOrgCmd.Flags().String("timezone", "", "IANA Timezone")
 	createOrgCmd.MarkFlagRequired("name")
 	createOrgCmd.MarkFlagRequired("slug")
+
+	listOrgsCmd.Flags().String("status", "", "Filter by status (pending, active, suspended, deleted)")
+	listOrgsCmd.Flags().Int("limit", 50, "Page size")
+	listOrgsCmd.Flags().Int("offset", 0, "Number of orgs to skip")
+
+	updateOrgCmd.Flags().String("name", "", "Organization Name")
+	updateOrgCmd.Flags().String("slug", "", "Organization Slug")
+	updateOrgCmd.Flags().String("code", "", "Organization Code")
+	updateOrgCmd.Flags().String("external-id", "", "External ID")
+	updateOrgCmd.Flags().String("country", "", "Country Code")
+	updateOrgCmd.Flags().String("timezone", "", "IANA Timezone")
+
+	for _, cmd := range []*cobra.Command{getOrgCmd, updateOrgCmd, suspendOrgCmd, activateOrgCmd, deleteOrgCmd, restoreOrgCmd, invalidateOrgCacheCmd} {
+		cmd.Flags().Int64("id", 0, "Organization ID")
+		cmd.MarkFlagRequired("id")
+	}
 }
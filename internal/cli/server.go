@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -15,14 +17,20 @@ import (
 	cacheadapter "github.com/smallbiznis/railzway-auth/internal/adapter/cache"
 	oauthadapter "github.com/smallbiznis/railzway-auth/internal/adapter/oauth"
 	"github.com/smallbiznis/railzway-auth/internal/bootstrap"
+	"github.com/smallbiznis/railzway-auth/internal/certs"
 	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domainverify"
 	httptransport "github.com/smallbiznis/railzway-auth/internal/http"
 	"github.com/smallbiznis/railzway-auth/internal/http/handler"
 	httpmiddleware "github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
 	apimiddleware "github.com/smallbiznis/railzway-auth/internal/middleware"
 	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/ratelimit"
 	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/secretbox"
 	"github.com/smallbiznis/railzway-auth/internal/server"
 	"github.com/smallbiznis/railzway-auth/internal/service"
 	authservice "github.com/smallbiznis/railzway-auth/internal/service/auth"
@@ -58,16 +66,38 @@ func runServer() {
 			newKeyRepository,
 			newOAuthClientRepository,
 			newOAuthAppRepository,
+			newMFARepository,
+			newWebAuthnCredentialRepository,
+			newSessionRepository,
+			newBackchannelLogoutRepository,
+			newDomainRepository,
+			newOrgConfigRepository,
+			newMembershipRepository,
+			newAPIResourceRepository,
 			newOAuthProviderConfigRepository,
 			newRedisClient,
 			newOAuthStateStore,
 			newAuthorizeStateStore,
+			newMFAChallengeStore,
+			newWebAuthnSessionStore,
+			newMagicLinkStore,
+			newMailer,
+			newCooldownStore,
+			newSessionStore,
 			newOAuthProviderClient,
+			newIssuerResolver,
+			newRateLimitStore,
 			newRateLimiter,
-			org.NewResolver,
+			newOrgResolver,
 			newKeyManager,
 			newTokenGenerator,
-			service.NewAuthService,
+			newAuthService,
+			newDomainVerifier,
+			service.NewDomainService,
+			service.NewOrgService,
+			service.NewOrgConfigService,
+			service.NewMemberService,
+			newSignInPolicy,
 			authservice.NewOAuthService,
 			newDiscoveryService,
 			handler.NewAuthHandler,
@@ -75,10 +105,12 @@ func runServer() {
 			handler.NewHealthHandler,
 			newAuthMiddleware,
 			newAdminMiddleware,
+			newCertManager,
+			newTLSConfig,
 			httptransport.NewRouter,
 			server.NewHTTPServer,
 		),
-		fx.Invoke(useTelemetry, bootstrap.EnsureOrg, startHTTPServer),
+		fx.Invoke(useTelemetry, bootstrap.EnsureOrg, startDomainProvisioner, startOrgPurger, startBackchannelLogoutWorker, startHTTPServer),
 	)
 
 	app.Run()
@@ -187,6 +219,38 @@ func newOAuthAppRepository(pool *pgxpool.Pool) repository.OAuthAppRepository {
 	return repository.NewPostgresOAuthAppRepo(pool)
 }
 
+func newMFARepository(pool *pgxpool.Pool) repository.MFARepository {
+	return repository.NewPostgresMFARepo(pool)
+}
+
+func newWebAuthnCredentialRepository(pool *pgxpool.Pool) repository.WebAuthnCredentialRepository {
+	return repository.NewPostgresWebAuthnRepo(pool)
+}
+
+func newSessionRepository(pool *pgxpool.Pool) repository.SessionRepository {
+	return repository.NewPostgresSessionRepo(pool)
+}
+
+func newBackchannelLogoutRepository(pool *pgxpool.Pool) repository.BackchannelLogoutRepository {
+	return repository.NewPostgresBackchannelLogoutRepo(pool)
+}
+
+func newDomainRepository(pool *pgxpool.Pool) repository.DomainRepository {
+	return repository.NewPostgresDomainRepo(pool)
+}
+
+func newOrgConfigRepository(pool *pgxpool.Pool) repository.OrgConfigRepository {
+	return repository.NewPostgresOrgConfigRepo(pool)
+}
+
+func newMembershipRepository(pool *pgxpool.Pool) repository.MembershipRepository {
+	return repository.NewPostgresMembershipRepo(pool)
+}
+
+func newAPIResourceRepository(pool *pgxpool.Pool) repository.APIResourceRepository {
+	return repository.NewPostgresAPIResourceRepo(pool)
+}
+
 func newOAuthProviderConfigRepository(q *sqlc.Queries) repository.OAuthProviderConfigRepo {
 	return repository.NewPostgresOAuthProviderConfigRepo(q)
 }
@@ -203,11 +267,13 @@ func newRedisClient(lc fx.Lifecycle, cfg config.Config) (redis.UniversalClient,
 		_ = client.Close()
 		return nil, fmt.Errorf("redis ping: %w", err)
 	}
-	lc.Append(fx.Hook{
-		OnStop: func(context.Context) error {
-			return client.Close()
-		},
-	})
+	if lc != nil {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return client.Close()
+			},
+		})
+	}
 	return client, nil
 }
 
@@ -219,32 +285,231 @@ func newAuthorizeStateStore(client redis.UniversalClient) repository.AuthorizeSt
 	return cacheadapter.NewRedisAuthorizeStateStore(client)
 }
 
+func newMFAChallengeStore(client redis.UniversalClient) repository.MFAChallengeStore {
+	return cacheadapter.NewRedisMFAChallengeStore(client)
+}
+
+func newWebAuthnSessionStore(client redis.UniversalClient) repository.WebAuthnSessionStore {
+	return cacheadapter.NewRedisWebAuthnSessionStore(client)
+}
+
+func newMagicLinkStore(client redis.UniversalClient) repository.MagicLinkStore {
+	return cacheadapter.NewRedisMagicLinkStore(client)
+}
+
+func newCooldownStore(client redis.UniversalClient) repository.CooldownStore {
+	return cacheadapter.NewRedisCooldownStore(client)
+}
+
+func newSessionStore(client redis.UniversalClient) repository.SessionStore {
+	return cacheadapter.NewRedisSessionStore(client)
+}
+
+// authServiceParams lets fx fill service.AuthDeps by type.
+type authServiceParams struct {
+	fx.In
+
+	Users              repository.UserRepository
+	Tokens             repository.TokenRepository
+	Codes              repository.CodeRepository
+	Clients            repository.OAuthClientRepository
+	Apps               repository.OAuthAppRepository
+	Orgs               repository.OrgRepository
+	MFA                repository.MFARepository
+	MFAChallenges      repository.MFAChallengeStore
+	Passkeys           repository.WebAuthnCredentialRepository
+	WebAuthnSessions   repository.WebAuthnSessionStore
+	Links              repository.MagicLinkStore
+	Mailer             mailer.Mailer
+	Cooldowns          repository.CooldownStore
+	Sessions           repository.SessionRepository
+	SessionStore       repository.SessionStore
+	Memberships        repository.MembershipRepository
+	Resources          repository.APIResourceRepository
+	BackchannelLogouts repository.BackchannelLogoutRepository
+	Snowflake          *snowflake.Node
+	Generator          *jwt.Generator
+	Keys               *jwt.KeyManager
+	Config             config.Config
+	Logger             *zap.Logger
+}
+
+func newAuthService(p authServiceParams) (*service.AuthService, error) {
+	return service.NewAuthService(service.AuthDeps{
+		Users:              p.Users,
+		Tokens:             p.Tokens,
+		Codes:              p.Codes,
+		Clients:            p.Clients,
+		Apps:               p.Apps,
+		Orgs:               p.Orgs,
+		MFA:                p.MFA,
+		MFAChallenges:      p.MFAChallenges,
+		Passkeys:           p.Passkeys,
+		WebAuthnSessions:   p.WebAuthnSessions,
+		Links:              p.Links,
+		Mailer:             p.Mailer,
+		Cooldowns:          p.Cooldowns,
+		Sessions:           p.Sessions,
+		SessionStore:       p.SessionStore,
+		Memberships:        p.Memberships,
+		Resources:          p.Resources,
+		BackchannelLogouts: p.BackchannelLogouts,
+		Snowflake:          p.Snowflake,
+		Generator:          p.Generator,
+		Keys:               p.Keys,
+		Config:             p.Config,
+		Logger:             p.Logger,
+	})
+}
+
+func newOrgResolver(lc fx.Lifecycle, cfg config.Config, repo repository.OrgRepository, client redis.UniversalClient, logger *zap.Logger) *org.Resolver {
+	resolver := org.NewResolver(repo)
+	if cfg.OrgCacheSize <= 0 {
+		return resolver
+	}
+
+	local := org.NewLocalCache(cfg.OrgCacheSize, cfg.OrgCacheLocalTTL)
+	orgCache := cacheadapter.NewRedisOrgContextCache(client, local, cfg.OrgCacheTTL, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go orgCache.Subscribe(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return resolver.WithCache(orgCache)
+}
+
+func newDomainVerifier() *domainverify.Verifier {
+	return domainverify.New(nil, nil)
+}
+
+// startDomainProvisioner advances custom domain provisioning in the background.
+func startDomainProvisioner(lc fx.Lifecycle, domains *service.DomainService, cfg config.Config) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go domains.RunProvisioner(ctx, cfg.DomainProvisionInterval)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// startOrgPurger removes deleted orgs whose retention period has passed.
+func startOrgPurger(lc fx.Lifecycle, orgs *service.OrgService, cfg config.Config) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go orgs.RunPurger(ctx, cfg.OrgPurgeInterval)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// startBackchannelLogoutWorker delivers queued back-channel logout tokens.
+func startBackchannelLogoutWorker(lc fx.Lifecycle, auth *service.AuthService, cfg config.Config) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go auth.RunBackchannelLogoutWorker(ctx, cfg.BackchannelLogoutInterval)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// newCertManager returns nil unless the built-in HTTPS listener is enabled.
+// ACME state holds private keys, so it refuses to start without
+// ACME_ENCRYPTION_KEY.
+func newCertManager(cfg config.Config, pool *pgxpool.Pool, orgs repository.OrgRepository, domains repository.DomainRepository, logger *zap.Logger) (*certs.Manager, error) {
+	if !cfg.ACMEEnabled {
+		return nil, nil
+	}
+	sealer, err := secretbox.New(cfg.ACMEEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := certs.NewSealedCache(repository.NewPostgresCertCache(pool), sealer)
+	if err != nil {
+		return nil, fmt.Errorf("ACME_ENCRYPTION_KEY is required with ACME_ENABLED: %w", err)
+	}
+	return certs.NewManager(cfg, cache, orgs, domains, logger), nil
+}
+
+func newTLSConfig(manager *certs.Manager) *tls.Config {
+	if manager == nil {
+		return nil
+	}
+	return manager.TLSConfig()
+}
+
+func newMailer(cfg config.Config, logger *zap.Logger) mailer.Mailer {
+	if cfg.SMTPHost == "" {
+		logger.Warn("SMTP_HOST not set; outgoing email will be logged instead of sent")
+		return mailer.NewLogMailer(logger)
+	}
+	return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+}
+
 func newOAuthProviderClient() oauthadapter.ProviderClient {
 	return oauthadapter.NewHTTPProviderClient(nil)
 }
 
-func newRateLimiter(cfg config.Config) *apimiddleware.RateLimiter {
-	return apimiddleware.NewRateLimiter(cfg.RateLimitRPM)
+func newIssuerResolver(cfg config.Config) (*issuer.Resolver, error) {
+	return issuer.NewResolver(cfg.TrustedProxies, cfg.IssuerPinPrimaryDomain)
+}
+
+func newRateLimitStore(client redis.UniversalClient) repository.RateLimitStore {
+	return cacheadapter.NewRedisRateLimitStore(client)
+}
+
+func newRateLimiter(cfg config.Config, store repository.RateLimitStore, issuers *issuer.Resolver, logger *zap.Logger) (*apimiddleware.RateLimiter, error) {
+	policies, err := ratelimit.ParsePolicies(cfg.RateLimitRPM, cfg.RateLimitPolicies)
+	if err != nil {
+		return nil, err
+	}
+	return apimiddleware.NewRateLimiter(store, policies, issuers, logger), nil
 }
 
 func newKeyManager(repo repository.KeyRepository) *jwt.KeyManager {
 	return jwt.NewKeyManager(repo)
 }
 
-func newTokenGenerator(manager *jwt.KeyManager, cfg config.Config) *jwt.Generator {
-	return jwt.NewGenerator(manager, cfg.AccessTokenTTL)
+func newTokenGenerator(manager *jwt.KeyManager, cfg config.Config, members *service.MemberService) *jwt.Generator {
+	return jwt.NewGenerator(manager, cfg.AccessTokenTTL).WithRoles(members)
+}
+
+// newSignInPolicy lets federated sign-in apply the same email verification and
+// MFA policies as password login.
+func newSignInPolicy(authService *service.AuthService) authservice.SignInPolicy {
+	return authService
 }
 
-func newDiscoveryService() *service.DiscoveryService {
-	return &service.DiscoveryService{}
+func newDiscoveryService(issuers *issuer.Resolver) *service.DiscoveryService {
+	return &service.DiscoveryService{Issuers: issuers}
 }
 
-func newAuthMiddleware(authService *service.AuthService) *httpmiddleware.Auth {
-	return &httpmiddleware.Auth{AuthService: authService}
+func newAuthMiddleware(authService *service.AuthService, issuers *issuer.Resolver) *httpmiddleware.Auth {
+	return &httpmiddleware.Auth{AuthService: authService, Issuers: issuers}
 }
 
-func newAdminMiddleware(authService *service.AuthService) *httpmiddleware.Admin {
-	return httpmiddleware.NewAdmin(authService)
+func newAdminMiddleware(authService *service.AuthService, members *service.MemberService, issuers *issuer.Resolver) *httpmiddleware.Admin {
+	return httpmiddleware.NewAdmin(authService, members, issuers)
 }
 
 func startHTTPServer(lc fx.Lifecycle, srv *server.HTTPServer, cfg config.Config, logger *zap.Logger) {
@@ -262,10 +527,27 @@ func startHTTPServer(lc fx.Lifecycle, srv *server.HTTPServer, cfg config.Config,
 			cancel = stop
 			done = make(chan struct{})
 
+			var wg sync.WaitGroup
+			wg.Add(1)
 			go func() {
+				defer wg.Done()
 				if err := srv.Run(runCtx, addr); err != nil {
 					logger.Error("http server stopped", zap.Error(err))
 				}
+			}()
+			if srv.TLSConfig != nil {
+				tlsAddr := ":" + cfg.HTTPSPort
+				logger.Info("Starting HTTPS server", zap.String("addr", tlsAddr))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := srv.RunTLS(runCtx, tlsAddr); err != nil {
+						logger.Error("https server stopped", zap.Error(err))
+					}
+				}()
+			}
+			go func() {
+				wg.Wait()
 				close(done)
 			}()
 
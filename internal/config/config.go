@@ -23,14 +23,17 @@ type Config struct {
 	AdminEmail    string
 	AdminPassword string
 
-	RedisAddr            string
-	RedisPassword        string
-	RedisDB              int
-	AccessTokenTTL       time.Duration
-	RefreshTokenTTL      time.Duration
-	RefreshTokenBytes    int
-	ServiceName          string
-	RateLimitRPM         int
+	RedisAddr         string
+	RedisPassword     string
+	RedisDB           int
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	RefreshTokenBytes int
+	ServiceName       string
+	RateLimitRPM      int
+	// RateLimitPolicies override the built-in rate limit policies, each as
+	// "name=limit/window" (e.g. "auth=20/1m").
+	RateLimitPolicies    []string
 	OTLPEndpoint         string
 	OTLPInsecure         bool
 	CORSAllowedOrigins   []string
@@ -39,6 +42,77 @@ type Config struct {
 	CORSAllowCredentials bool
 
 	AuthCookieSecure bool
+
+	// TrustedProxies lists the CIDRs or addresses of reverse proxies whose
+	// Forwarded and X-Forwarded-* headers are believed. Requests from any
+	// other peer are taken at face value.
+	TrustedProxies []string
+	// IssuerPinPrimaryDomain makes every issuer the org's primary domain,
+	// whichever of its hosts the request came in on.
+	IssuerPinPrimaryDomain bool
+
+	// MFAEncryptionKey encrypts TOTP secrets at rest. MFA enrollment is
+	// rejected while it is empty.
+	MFAEncryptionKey string
+	MFAChallengeTTL  time.Duration
+
+	// SMTP relay used for transactional email. Messages are logged instead of
+	// sent while SMTPHost is empty.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// LinkSigningKey signs links sent by email. Magic-link login is rejected
+	// while it is empty.
+	LinkSigningKey string
+	MagicLinkTTL   time.Duration
+
+	EmailVerificationTTL            time.Duration
+	EmailVerificationResendInterval time.Duration
+
+	// MemberInviteTTL is how long an org invitation link stays valid unless
+	// the inviter picks a shorter expiry.
+	MemberInviteTTL time.Duration
+
+	// Browser sessions end after SessionIdleTimeout without activity or
+	// SessionAbsoluteTimeout after login, whichever comes first.
+	SessionIdleTimeout     time.Duration
+	SessionAbsoluteTimeout time.Duration
+
+	// Resolved org contexts are cached in-process (OrgCacheSize entries for
+	// OrgCacheLocalTTL) and in Redis for OrgCacheTTL. A size of zero disables
+	// caching.
+	OrgCacheSize     int
+	OrgCacheLocalTTL time.Duration
+	OrgCacheTTL      time.Duration
+
+	// CustomDomainTarget is the host custom domains must CNAME to before they
+	// finish provisioning. Verified domains are activated without a DNS check
+	// while it is empty.
+	CustomDomainTarget      string
+	DomainProvisionInterval time.Duration
+
+	// ACMEEnabled starts an HTTPS listener on HTTPSPort that obtains
+	// certificates for verified domains from ACMEDirectoryURL.
+	ACMEEnabled      bool
+	HTTPSPort        string
+	ACMEDirectoryURL string
+	ACMEEmail        string
+	ACMERenewBefore  time.Duration
+	// ACMEEncryptionKey encrypts the ACME account key, certificates and
+	// their private keys in acme_cache. Required when ACMEEnabled is set.
+	ACMEEncryptionKey string
+
+	// OrgDeletionRetention is how long a deleted org can be restored before
+	// OrgPurgeInterval's purger removes it and all of its data.
+	OrgDeletionRetention time.Duration
+	OrgPurgeInterval     time.Duration
+
+	// BackchannelLogoutInterval is how often queued back-channel logout
+	// tokens are delivered or retried.
+	BackchannelLogoutInterval time.Duration
 }
 
 // DSN returns the database connection string.
@@ -69,6 +143,7 @@ func Load() (Config, error) {
 		RefreshTokenBytes:    getInt("REFRESH_TOKEN_BYTES", 32),
 		ServiceName:          getEnv("SERVICE_NAME", "railzway-auth"),
 		RateLimitRPM:         getInt("RATE_LIMIT_RPM", 600),
+		RateLimitPolicies:    getList("RATE_LIMIT_POLICIES", nil),
 		OTLPEndpoint:         os.Getenv("OTLP_ENDPOINT"),
 		OTLPInsecure:         getBool("OTLP_INSECURE", true),
 		CORSAllowedOrigins:   getList("CORS_ALLOWED_ORIGINS", []string{"*"}),
@@ -76,6 +151,37 @@ func Load() (Config, error) {
 		CORSAllowedHeaders:   getList("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type"}),
 		CORSAllowCredentials: getBool("CORS_ALLOW_CREDENTIALS", false),
 		AuthCookieSecure:     getBool("AUTH_COOKIE_SECURE", false),
+		TrustedProxies:       getList("TRUSTED_PROXIES", nil),
+		MFAEncryptionKey:     os.Getenv("MFA_ENCRYPTION_KEY"),
+		MFAChallengeTTL:      getDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		SMTPHost:             os.Getenv("SMTP_HOST"),
+		SMTPPort:             getInt("SMTP_PORT", 587),
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:             getEnv("SMTP_FROM", "no-reply@localhost"),
+		LinkSigningKey:       os.Getenv("LINK_SIGNING_KEY"),
+		MagicLinkTTL:         getDuration("MAGIC_LINK_TTL", 15*time.Minute),
+
+		EmailVerificationTTL:            getDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		EmailVerificationResendInterval: getDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+		MemberInviteTTL:                 getDuration("MEMBER_INVITE_TTL", 7*24*time.Hour),
+		SessionIdleTimeout:              getDuration("SESSION_IDLE_TIMEOUT", 12*time.Hour),
+		SessionAbsoluteTimeout:          getDuration("SESSION_ABSOLUTE_TIMEOUT", 7*24*time.Hour),
+		OrgCacheSize:                    getInt("ORG_CACHE_SIZE", 1000),
+		OrgCacheLocalTTL:                getDuration("ORG_CACHE_LOCAL_TTL", 30*time.Second),
+		OrgCacheTTL:                     getDuration("ORG_CACHE_TTL", 5*time.Minute),
+		CustomDomainTarget:              os.Getenv("CUSTOM_DOMAIN_TARGET"),
+		DomainProvisionInterval:         getDuration("DOMAIN_PROVISION_INTERVAL", time.Minute),
+		ACMEEnabled:                     getBool("ACME_ENABLED", false),
+		HTTPSPort:                       getEnv("HTTPS_PORT", "8443"),
+		ACMEDirectoryURL:                getEnv("ACME_DIRECTORY_URL", "https://acme-v02.api.letsencrypt.org/directory"),
+		ACMEEmail:                       os.Getenv("ACME_EMAIL"),
+		ACMERenewBefore:                 getDuration("ACME_RENEW_BEFORE", 30*24*time.Hour),
+		ACMEEncryptionKey:               os.Getenv("ACME_ENCRYPTION_KEY"),
+		OrgDeletionRetention:            getDuration("ORG_DELETION_RETENTION", 30*24*time.Hour),
+		OrgPurgeInterval:                getDuration("ORG_PURGE_INTERVAL", time.Hour),
+		BackchannelLogoutInterval:       getDuration("BACKCHANNEL_LOGOUT_INTERVAL", 5*time.Second),
+		IssuerPinPrimaryDomain:          getBool("ISSUER_PIN_PRIMARY_DOMAIN", false),
 	}
 
 	// Default AuthCookieSecure to true in production if not explicitly set (handled by getBool default above, but let's enforce safe default logic if needed)
@@ -0,0 +1,36 @@
+// Package configschema holds the JSON Schemas of the org configuration
+// documents accepted by the admin API, so back-office tools can validate and
+// render forms for them. The service layer enforces the same rules.
+package configschema
+
+import (
+	"embed"
+	"sort"
+	"strings"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Get returns the schema called name, e.g. "branding".
+func Get(name string) ([]byte, bool) {
+	if name == "" || strings.ContainsAny(name, "/.") {
+		return nil, false
+	}
+	data, err := files.ReadFile("schemas/" + name + ".json")
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Names lists the available schemas.
+func Names() []string {
+	entries, _ := files.ReadDir("schemas")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// APIResource is a protected API registered with an org. Clients request it
+// by Identifier (an RFC 8707 resource indicator), which becomes the audience
+// of the access tokens issued for it.
+type APIResource struct {
+	ID         int64
+	OrgID      int64
+	Identifier string
+	Name       string
+	// Scopes are the API-specific scopes clients may request for it.
+	Scopes []string
+	// AccessTokenTTL overrides the default access token lifetime when set.
+	AccessTokenTTL   time.Duration
+	SigningAlgorithm string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// AuthProviderMagicLink identifies the email magic-link login method in
+// tenant_auth_providers.provider_type.
+const AuthProviderMagicLink = "magic_link"
+
+// MagicLink is the server-side record of an emailed sign-in link. The link
+// itself only carries a signed reference to this record; NonceHash binds it
+// to the browser that requested it.
+type MagicLink struct {
+	ID             string
+	OrgID          int64
+	UserID         int64
+	ClientID       string
+	Scope          string
+	Issuer         string
+	AuthorizeState string
+	NonceHash      string
+	CreatedAt      time.Time
+}
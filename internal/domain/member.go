@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// Member roles stored in tenant_users.role, from most to least privileged.
+const (
+	MemberRoleOwner  = "OWNER"
+	MemberRoleAdmin  = "ADMIN"
+	MemberRoleStaff  = "STAFF"
+	MemberRoleViewer = "VIEWER"
+)
+
+// Member statuses stored in tenant_users.status.
+const (
+	MemberStatusActive   = "ACTIVE"
+	MemberStatusInvited  = "INVITED"
+	MemberStatusDisabled = "DISABLED"
+)
+
+// Member grants a user a role in an org's administration. Email and Name are
+// read from the user.
+//
+// A pending invitation is a Member with status MemberStatusInvited and no
+// UserID; the user is attached when the invitation is accepted.
+type Member struct {
+	ID              int64
+	OrgID           int64
+	UserID          int64
+	Role            string
+	Status          string
+	IsDefault       bool
+	InvitedEmail    string
+	InvitedBy       int64
+	InviteExpiresAt *time.Time
+	InviteNonce     string
+	JoinedAt        *time.Time
+	Email           string
+	Name            string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
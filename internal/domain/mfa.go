@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// MFA policy values stored in mfa_configs.policy.
+const (
+	MFAPolicyOff      = "off"
+	MFAPolicyOptional = "optional"
+	MFAPolicyRequired = "required"
+)
+
+// MFAFactorTOTP identifies authenticator-app factors.
+const MFAFactorTOTP = "totp"
+
+// MFAConfig holds the multi-factor policy for an org.
+type MFAConfig struct {
+	OrgID     int64
+	Policy    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// MFAFactor is a second factor enrolled by a user. Secret is stored encrypted.
+type MFAFactor struct {
+	ID           int64
+	OrgID        int64
+	UserID       int64
+	Type         string
+	Secret       string
+	Confirmed    bool
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RecoveryCode is a hashed, single-use fallback for a lost second factor.
+type RecoveryCode struct {
+	ID        int64
+	OrgID     int64
+	UserID    int64
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// MFAChallenge tracks a login that passed the first factor and awaits a second.
+type MFAChallenge struct {
+	ChallengeID        string
+	OrgID              int64
+	UserID             int64
+	ClientID           string
+	Scope              string
+	Issuer             string
+	Providers          []string
+	EnrollmentRequired bool
+	CreatedAt          time.Time
+}
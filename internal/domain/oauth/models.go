@@ -51,7 +51,34 @@ type AuthorizeState struct {
 	Nonce               string
 	CodeChallenge       string
 	CodeChallengeMethod string
-	CreatedAt           time.Time
+	// ActiveOrgID is the org the user asked to act in; zero means the
+	// authorizing org.
+	ActiveOrgID  int64
+	ResponseMode string
+	// Prompt holds the space-separated OIDC prompt values.
+	Prompt string
+	// MaxAge is the requested maximum authentication age in seconds; nil
+	// when the client did not send max_age.
+	MaxAge    *int64
+	LoginHint string
+	UILocales string
+	// PushedRequest marks requests that arrived via PAR or a signed request
+	// object; the post-login continuation then goes through a fresh
+	// request_uri instead of plain query parameters.
+	PushedRequest bool
+	// Resource is the API identifier the client asked for; empty when the
+	// token is for the org itself.
+	Resource  string
+	CreatedAt time.Time
+}
+
+// PushedAuthorizationRequest is an authorize request registered at the
+// pushed authorization request endpoint and redeemed once via request_uri.
+type PushedAuthorizationRequest struct {
+	OrgID     int64
+	ClientID  string
+	Params    map[string][]string
+	CreatedAt time.Time
 }
 
 // OAuthTokenResponse models the response from an external IdP token endpoint.
@@ -74,4 +101,6 @@ type OAuthUserInfo struct {
 	OrgID    int64  `json:"org_id,omitempty"`
 	TenantID int64  `json:"tenant_id,omitempty"`
 	Provider string `json:"provider,omitempty"`
+	// DPoPKey is the cnf.jkt of the access token the info was read with.
+	DPoPKey string `json:"-"`
 }
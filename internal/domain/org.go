@@ -20,6 +20,29 @@ type Domain struct {
 	UpdatedAt            time.Time
 }
 
+// Domain verification methods.
+const (
+	DomainVerificationDNS    = "dns"
+	DomainVerificationFile   = "file"
+	DomainVerificationManual = "manual"
+)
+
+// Domain provisioning states. Verified domains move from pending through
+// provisioning to active once their DNS points at this deployment.
+const (
+	DomainProvisioningPending    = "pending"
+	DomainProvisioningInProgress = "provisioning"
+	DomainProvisioningActive     = "active"
+	DomainProvisioningError      = "error"
+)
+
+// Domain certificate states.
+const (
+	DomainCertificatePending = "pending"
+	DomainCertificateActive  = "active"
+	DomainCertificateFailed  = "failed"
+)
+
 // Org represents a logical organization.
 type Org struct {
 	ID          int64
@@ -34,8 +57,25 @@ type Org struct {
 	Status      string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	// DeletedAt is set while a soft-deleted org waits to be purged.
+	DeletedAt *time.Time
 }
 
+// Org types, matching the tenant_type enum.
+const (
+	OrgTypePlatform = "platform"
+	OrgTypePersonal = "personal"
+	OrgTypeCompany  = "company"
+)
+
+// Org lifecycle states. Suspended and deleted orgs are not served.
+const (
+	OrgStatusPending   = "pending"
+	OrgStatusActive    = "active"
+	OrgStatusSuspended = "suspended"
+	OrgStatusDeleted   = "deleted"
+)
+
 // Branding holds white-label information for an org.
 type Branding struct {
 	OrgID            int64
@@ -51,8 +91,11 @@ type Branding struct {
 	CustomJS         *string
 	CustomHTMLHeader *string
 	CustomHTMLFooter *string
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	// CustomJSEnabled is set by platform admins; CustomJS is only served
+	// while it is true.
+	CustomJSEnabled bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 // AuthProvider represents an enabled authentication option for an org.
@@ -66,6 +109,13 @@ type AuthProvider struct {
 	UpdatedAt        time.Time
 }
 
+// Email verification policies stored in password_configs.email_verification.
+const (
+	EmailVerificationOptional = "optional"
+	EmailVerificationLimited  = "limited"
+	EmailVerificationRequired = "required"
+)
+
 // PasswordConfig controls password login policy per org.
 type PasswordConfig struct {
 	OrgID                  int64
@@ -77,6 +127,7 @@ type PasswordConfig struct {
 	AllowPasswordReset     bool
 	LockoutAttempts        int
 	LockoutDurationSeconds int
+	EmailVerification      string
 	CreatedAt              time.Time
 	UpdatedAt              time.Time
 }
@@ -96,6 +147,7 @@ type OTPConfig struct {
 
 // OAuthIDPConfig stores social login configuration.
 type OAuthIDPConfig struct {
+	ID               int64
 	OrgID            int64
 	Provider         string
 	ClientID         string
@@ -137,5 +189,30 @@ type OAuthClient struct {
 	Scopes                   []string
 	TokenEndpointAuthMethods []string
 	RequireConsent           bool
-	CreatedAt                time.Time
+	// JWKS is the client's public JSON Web Key Set for signed request
+	// objects; empty when none is registered.
+	JWKS string
+	// RequirePAR restricts the client to pushed authorization requests.
+	RequirePAR bool
+	// PostLogoutRedirectURIs are the allowed post_logout_redirect_uri values.
+	PostLogoutRedirectURIs []string
+	// BackchannelLogoutURI receives logout tokens; empty disables
+	// back-channel logout for the client.
+	BackchannelLogoutURI string
+	// TokenExchangeAudiences are the audiences the client may request in a
+	// token exchange.
+	TokenExchangeAudiences []string
+	// DPoPBoundAccessTokens makes the token endpoint require a DPoP proof
+	// from the client.
+	DPoPBoundAccessTokens bool
+	// AccessTokenFormat is AccessTokenFormatJWT or AccessTokenFormatOpaque.
+	AccessTokenFormat string
+	CreatedAt         time.Time
 }
+
+// Access token formats of OAuth clients. Opaque tokens carry no claims and
+// are resolved through oauth_tokens.
+const (
+	AccessTokenFormatJWT    = "jwt"
+	AccessTokenFormatOpaque = "opaque"
+)
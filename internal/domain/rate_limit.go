@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// RateLimitOverride replaces the server-wide limit of a named rate limit
+// policy for one org.
+type RateLimitOverride struct {
+	ID            int64
+	OrgID         int64
+	Policy        string
+	Limit         int
+	WindowSeconds int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// RateLimitResult is the outcome of counting one request against a limit.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is how long until the full limit is available again, and
+	// RetryAfter how long until the next request would be allowed (zero when
+	// this one was).
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Session is a browser session established at login. The cookie carries an
+// opaque token; only its hash is stored.
+type Session struct {
+	ID        int64
+	OrgID     int64
+	UserID    int64
+	TokenHash string
+	UserAgent string
+	IPAddress string
+	// AMR lists the authentication methods completed at sign-in.
+	AMR        []string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// BackchannelLogout is a pending logout token delivery to a client's
+// back-channel logout URI. The token is signed when each attempt is made.
+type BackchannelLogout struct {
+	ID            int64
+	OrgID         int64
+	ClientID      string
+	UserID        int64
+	Issuer        string
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
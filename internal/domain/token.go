@@ -11,9 +11,26 @@ type OAuthToken struct {
 	AccessToken  string
 	RefreshToken string
 	Scopes       []string
-	ExpiresAt    time.Time
-	Revoked      bool
-	CreatedAt    time.Time
+	// ActiveOrgID is the org the tokens act in, which is OrgID unless the
+	// user switched to another org they are a member of.
+	ActiveOrgID int64
+	// Resource is the API identifier the access token is audienced to;
+	// empty for org-wide tokens.
+	Resource string
+	// DPoPJKT is the thumbprint of the DPoP key the tokens are bound to;
+	// empty for bearer tokens.
+	DPoPJKT string
+	// ExpiresAt is the refresh token's expiry, or the access token's when
+	// the row has no refresh token.
+	ExpiresAt time.Time
+	// AccessExpiresAt is the access token's expiry; zero for rows written
+	// before it was recorded.
+	AccessExpiresAt time.Time
+	// AMR lists the methods the user authenticated with when the grant was
+	// issued; refreshes carry it over unchanged.
+	AMR       []string
+	Revoked   bool
+	CreatedAt time.Time
 }
 
 // OAuthCode models short-lived authorization codes.
@@ -26,9 +43,16 @@ type OAuthCode struct {
 	RedirectURI         string
 	CodeChallenge       string
 	CodeChallengeMethod string
-	ExpiresAt           time.Time
-	Revoked             bool
-	CreatedAt           time.Time
+	// ActiveOrgID is the org chosen for the tokens the code is exchanged for.
+	ActiveOrgID int64
+	// Resource is the API identifier requested at authorize, if any.
+	Resource string
+	// AuthTime is when the user signed in to the session that approved the
+	// code; zero when unknown.
+	AuthTime  time.Time
+	ExpiresAt time.Time
+	Revoked   bool
+	CreatedAt time.Time
 }
 
 // OAuthKey stores per-org signing keys.
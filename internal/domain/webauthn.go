@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// AuthProviderPasskey identifies the WebAuthn passkey login method in
+// tenant_auth_providers.provider_type.
+const AuthProviderPasskey = "passkey"
+
+// WebAuthn ceremony purposes stored with a pending session.
+const (
+	WebAuthnPurposeRegister = "register"
+	WebAuthnPurposeLogin    = "login"
+	WebAuthnPurposeMFA      = "mfa"
+)
+
+// WebAuthnCredential is a passkey registered by a user for an org RP ID.
+type WebAuthnCredential struct {
+	ID              int64
+	OrgID           int64
+	UserID          int64
+	RPID            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	AAGUID          []byte
+	SignCount       uint32
+	BackupEligible  bool
+	BackupState     bool
+	Name            string
+	LastUsedAt      *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// WebAuthnSession tracks an in-flight registration or assertion ceremony.
+// Data holds the library session payload (challenge, RP ID, allowed
+// credentials) encoded as JSON.
+type WebAuthnSession struct {
+	SessionID string
+	OrgID     int64
+	UserID    int64
+	Purpose   string
+	MFAToken  string
+	Data      []byte
+	CreatedAt time.Time
+}
@@ -0,0 +1,160 @@
+// Package domainverify proves control of a custom domain, either through a
+// DNS TXT record or a file served from the domain itself.
+package domainverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// RecordPrefix is prepended to the host to form the TXT record name.
+	RecordPrefix = "_railzway-verification."
+	// FilePath is where the file method expects the verification token.
+	FilePath = "/.well-known/railzway-verification"
+
+	maxFileSize = 1024
+)
+
+// ErrNotVerified is returned when the token could not be found.
+var ErrNotVerified = errors.New("verification token not found")
+
+// ErrForbiddenAddress is returned when a host resolves to an address the
+// verifier must not connect to, such as loopback or private networks.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// Resolver performs the DNS lookups used for verification and provisioning.
+// *net.Resolver satisfies it.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// Verifier checks verification tokens published for a domain.
+type Verifier struct {
+	resolver Resolver
+	client   *http.Client
+}
+
+// New creates a Verifier. A nil resolver uses net.DefaultResolver and a nil
+// client uses a short-timeout client that does not follow redirects, ignores
+// proxies and only connects to publicly routable addresses.
+func New(resolver Resolver, client *http.Client) *Verifier {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if client == nil {
+		dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refuseInternal}
+		client = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &Verifier{resolver: resolver, client: client}
+}
+
+// refuseInternal is a net.Dialer Control function. It runs after name
+// resolution, so hosts cannot reach internal services through DNS.
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !publicIP(ip) {
+		return fmt.Errorf("dial %s: %w", address, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// publicIP reports whether ip is a globally routable unicast address.
+func publicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// net.IP.IsPrivate does not cover.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// RecordName returns the TXT record name checked for host.
+func RecordName(host string) string {
+	return RecordPrefix + host
+}
+
+// VerifyDNS checks that a TXT record at RecordName(host) equals token.
+func (v *Verifier) VerifyDNS(ctx context.Context, host, token string) error {
+	records, err := v.resolver.LookupTXT(ctx, RecordName(host))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrNotVerified
+		}
+		return fmt.Errorf("lookup txt record: %w", err)
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == token {
+			return nil
+		}
+	}
+	return ErrNotVerified
+}
+
+// VerifyFile checks that FilePath on host serves token over HTTPS. Plain HTTP
+// is never used, so an on-path attacker cannot answer for the host.
+func (v *Verifier) VerifyFile(ctx context.Context, host, token string) error {
+	body, err := v.fetch(ctx, "https://"+host+FilePath)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) != token {
+		return ErrNotVerified
+	}
+	return nil
+}
+
+// PointsTo reports whether host is a CNAME for target.
+func (v *Verifier) PointsTo(ctx context.Context, host, target string) (bool, error) {
+	cname, err := v.resolver.LookupCNAME(ctx, host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("lookup cname: %w", err)
+	}
+	return normalizeName(cname) == normalizeName(target), nil
+}
+
+func (v *Verifier) fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch verification file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", ErrNotVerified
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if err != nil {
+		return "", fmt.Errorf("read verification file: %w", err)
+	}
+	return string(body), nil
+}
+
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
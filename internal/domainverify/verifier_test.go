@@ -0,0 +1,96 @@
+package domainverify_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smallbiznis/railzway-auth/internal/domainverify"
+)
+
+type fakeResolver struct {
+	txt   map[string][]string
+	cname map[string]string
+}
+
+func (f fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	records, ok := f.txt[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func (f fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	cname, ok := f.cname[host]
+	if !ok {
+		return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return cname, nil
+}
+
+func TestVerifyDNS(t *testing.T) {
+	ctx := context.Background()
+	verifier := domainverify.New(fakeResolver{txt: map[string][]string{
+		"_railzway-verification.login.example.com": {"unrelated", "token-123"},
+	}}, nil)
+
+	require.NoError(t, verifier.VerifyDNS(ctx, "login.example.com", "token-123"))
+	require.ErrorIs(t, verifier.VerifyDNS(ctx, "login.example.com", "other"), domainverify.ErrNotVerified)
+	require.ErrorIs(t, verifier.VerifyDNS(ctx, "missing.example.com", "token-123"), domainverify.ErrNotVerified)
+}
+
+func TestVerifyFile(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != domainverify.FilePath {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("token-123\n"))
+	})
+	srv := httptest.NewTLSServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "https://")
+	verifier := domainverify.New(fakeResolver{}, srv.Client())
+
+	require.NoError(t, verifier.VerifyFile(ctx, host, "token-123"))
+	require.ErrorIs(t, verifier.VerifyFile(ctx, host, "other"), domainverify.ErrNotVerified)
+
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	err := verifier.VerifyFile(ctx, strings.TrimPrefix(plain.URL, "http://"), "token-123")
+	require.Error(t, err, "the file is only trusted over HTTPS")
+	require.NotErrorIs(t, err, domainverify.ErrNotVerified)
+}
+
+func TestVerifyFileRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("token-123"))
+	}))
+	defer srv.Close()
+
+	err := domainverify.New(fakeResolver{}, nil).VerifyFile(context.Background(), strings.TrimPrefix(srv.URL, "https://"), "token-123")
+	require.ErrorIs(t, err, domainverify.ErrForbiddenAddress)
+}
+
+func TestPointsTo(t *testing.T) {
+	ctx := context.Background()
+	verifier := domainverify.New(fakeResolver{cname: map[string]string{
+		"login.example.com": "Edge.Railzway.com.",
+	}}, nil)
+
+	ok, err := verifier.PointsTo(ctx, "login.example.com", "edge.railzway.com")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = verifier.PointsTo(ctx, "other.example.com", "edge.railzway.com")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
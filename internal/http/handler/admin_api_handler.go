@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+type upsertAPIResourceRequest struct {
+	Identifier string   `json:"identifier"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	// AccessTokenTTL is in seconds; zero uses the server default.
+	AccessTokenTTL   int64  `json:"access_token_ttl"`
+	SigningAlgorithm string `json:"signing_alg"`
+}
+
+type apiResourceResponse struct {
+	Identifier       string    `json:"identifier"`
+	Name             string    `json:"name"`
+	Scopes           []string  `json:"scopes"`
+	AccessTokenTTL   int64     `json:"access_token_ttl"`
+	SigningAlgorithm string    `json:"signing_alg"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ListAPIResources returns the APIs registered with the org.
+func (h *AdminHandler) ListAPIResources(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	resources, err := h.Auth.ListAPIResources(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	out := make([]apiResourceResponse, 0, len(resources))
+	for _, resource := range resources {
+		out = append(out, newAPIResourceResponse(resource))
+	}
+	c.JSON(http.StatusOK, gin.H{"apis": out})
+}
+
+// UpsertAPIResource registers an API, or updates the one with the same
+// identifier.
+func (h *AdminHandler) UpsertAPIResource(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req upsertAPIResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	resource, err := h.Auth.UpsertAPIResource(c.Request.Context(), orgCtx.Org.ID, service.APIResourceInput{
+		Identifier:       req.Identifier,
+		Name:             req.Name,
+		Scopes:           req.Scopes,
+		AccessTokenTTL:   time.Duration(req.AccessTokenTTL) * time.Second,
+		SigningAlgorithm: req.SigningAlgorithm,
+	})
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAPIResourceResponse(resource))
+}
+
+// DeleteAPIResource removes the API named by the identifier query parameter.
+// Identifiers are URIs, so they are not taken from the path.
+func (h *AdminHandler) DeleteAPIResource(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	identifier := strings.TrimSpace(c.Query("identifier"))
+	if identifier == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "identifier is required."})
+		return
+	}
+	if err := h.Auth.DeleteAPIResource(c.Request.Context(), orgCtx.Org.ID, identifier); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func newAPIResourceResponse(resource domain.APIResource) apiResourceResponse {
+	scopes := resource.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return apiResourceResponse{
+		Identifier:       resource.Identifier,
+		Name:             resource.Name,
+		Scopes:           scopes,
+		AccessTokenTTL:   int64(resource.AccessTokenTTL / time.Second),
+		SigningAlgorithm: resource.SigningAlgorithm,
+		CreatedAt:        resource.CreatedAt,
+		UpdatedAt:        resource.UpdatedAt,
+	}
+}
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/configschema"
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// maxConfigBodyBytes bounds configuration payloads; branding may carry up to
+// four 64 KiB snippets.
+const maxConfigBodyBytes = 512 * 1024
+
+// GetBranding returns the org's branding.
+func (h *AdminHandler) GetBranding(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	branding, err := h.Configs.GetBranding(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, branding)
+}
+
+// SaveBranding replaces the org's branding.
+func (h *AdminHandler) SaveBranding(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.BrandingConfig
+	if !bindConfig(c, &req) {
+		return
+	}
+
+	branding, err := h.Configs.SaveBranding(c.Request.Context(), orgCtx.Org.ID, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, branding)
+}
+
+type setCustomJSRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// SetCustomJS lets platform admins allow or stop an org's custom JS on the
+// login pages.
+func (h *AdminHandler) SetCustomJS(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req setCustomJSRequest
+	if !bindConfig(c, &req) {
+		return
+	}
+	if req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "enabled is required."})
+		return
+	}
+
+	branding, err := h.Configs.SetCustomJSEnabled(c.Request.Context(), orgID, *req.Enabled)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, branding)
+}
+
+// GetPasswordConfig returns the org's password policy.
+func (h *AdminHandler) GetPasswordConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	cfg, err := h.Configs.GetPasswordConfig(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// SavePasswordConfig replaces the org's password policy.
+func (h *AdminHandler) SavePasswordConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.PasswordPolicyConfig
+	if !bindConfig(c, &req) {
+		return
+	}
+
+	cfg, err := h.Configs.SavePasswordConfig(c.Request.Context(), orgCtx.Org.ID, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// GetOTPConfig returns the org's OTP delivery settings.
+func (h *AdminHandler) GetOTPConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	cfg, err := h.Configs.GetOTPConfig(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// SaveOTPConfig replaces the org's OTP delivery settings.
+func (h *AdminHandler) SaveOTPConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.OTPDeliveryConfig
+	if !bindConfig(c, &req) {
+		return
+	}
+
+	cfg, err := h.Configs.SaveOTPConfig(c.Request.Context(), orgCtx.Org.ID, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// ListAuthProviders returns the org's login methods.
+func (h *AdminHandler) ListAuthProviders(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	providers, err := h.Configs.ListAuthProviders(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"auth_providers": providers})
+}
+
+// SaveAuthProvider enables or disables the login method in the path.
+func (h *AdminHandler) SaveAuthProvider(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.AuthProviderConfig
+	if !bindConfig(c, &req) {
+		return
+	}
+
+	provider, err := h.Configs.SaveAuthProvider(c.Request.Context(), orgCtx.Org.ID, c.Param("type"), req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, provider)
+}
+
+// DeleteAuthProvider removes the login method in the path.
+func (h *AdminHandler) DeleteAuthProvider(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	if err := h.Configs.DeleteAuthProvider(c.Request.Context(), orgCtx.Org.ID, c.Param("type")); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListIDPConfigs returns the org's social IdP settings.
+func (h *AdminHandler) ListIDPConfigs(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	configs, err := h.Configs.ListIDPConfigs(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"idp_configs": configs})
+}
+
+// GetIDPConfig returns the settings of the provider in the path.
+func (h *AdminHandler) GetIDPConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	cfg, err := h.Configs.GetIDPConfig(c.Request.Context(), orgCtx.Org.ID, c.Param("provider"))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// SaveIDPConfig creates or replaces the settings of the provider in the path.
+func (h *AdminHandler) SaveIDPConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.IDPConfig
+	if !bindConfig(c, &req) {
+		return
+	}
+
+	cfg, err := h.Configs.SaveIDPConfig(c.Request.Context(), orgCtx.Org.ID, c.Param("provider"), req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// DeleteIDPConfig removes the settings of the provider in the path.
+func (h *AdminHandler) DeleteIDPConfig(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	if err := h.Configs.DeleteIDPConfig(c.Request.Context(), orgCtx.Org.ID, c.Param("provider")); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListRateLimits returns the org's rate limit overrides.
+func (h *AdminHandler) ListRateLimits(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	limits, err := h.Configs.ListRateLimits(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rate_limits": limits})
+}
+
+// SaveRateLimit overrides the rate limit policy in the path.
+func (h *AdminHandler) SaveRateLimit(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.RateLimitConfig
+	if !bindConfig(c, &req) {
+		return
+	}
+
+	limit, err := h.Configs.SaveRateLimit(c.Request.Context(), orgCtx.Org.ID, c.Param("policy"), req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, limit)
+}
+
+// DeleteRateLimit restores the server-wide limit of the policy in the path.
+func (h *AdminHandler) DeleteRateLimit(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	if err := h.Configs.DeleteRateLimit(c.Request.Context(), orgCtx.Org.ID, c.Param("policy")); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListConfigSchemas lists the JSON Schemas of the configuration documents.
+func (h *AdminHandler) ListConfigSchemas(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"schemas": configschema.Names()})
+}
+
+// GetConfigSchema serves one configuration JSON Schema.
+func (h *AdminHandler) GetConfigSchema(c *gin.Context) {
+	schema, ok := configschema.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_request", "error_description": "Schema not found."})
+		return
+	}
+	c.Data(http.StatusOK, "application/schema+json", schema)
+}
+
+// bindConfig decodes a configuration document, rejecting unknown fields so
+// typos are not silently dropped. It writes the error response itself.
+func bindConfig(c *gin.Context, dst any) bool {
+	dec := json.NewDecoder(io.LimitReader(c.Request.Body, maxConfigBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		desc := "Invalid payload."
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			desc = "Invalid value for " + typeErr.Field + "."
+		case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		default:
+			// DisallowUnknownFields reports `json: unknown field "name"`.
+			desc = "Invalid payload: " + err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": desc})
+		return false
+	}
+	return true
+}
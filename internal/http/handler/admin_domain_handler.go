@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+)
+
+type addDomainRequest struct {
+	Host               string `json:"host"`
+	VerificationMethod string `json:"verification_method"`
+}
+
+type domainVerificationTokenRequest struct {
+	VerificationMethod string `json:"verification_method"`
+}
+
+// ListDomains returns the org's domains and their verification state.
+func (h *AdminHandler) ListDomains(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	domains, err := h.Domains.ListDomains(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"domains": domains})
+}
+
+// AddDomain registers a custom domain and returns its verification token.
+func (h *AdminHandler) AddDomain(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req addDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	domain, err := h.Domains.AddDomain(c.Request.Context(), orgCtx.Org.ID, req.Host, req.VerificationMethod)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, domain)
+}
+
+// RegenerateDomainToken issues a new verification token for an unverified
+// domain. The body may switch verification_method.
+func (h *AdminHandler) RegenerateDomainToken(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	domainID, ok := domainIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req domainVerificationTokenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+			return
+		}
+	}
+
+	domain, err := h.Domains.RegenerateVerificationToken(c.Request.Context(), orgCtx.Org.ID, domainID, req.VerificationMethod)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, domain)
+}
+
+// VerifyDomain checks the domain's verification token and starts routing it.
+func (h *AdminHandler) VerifyDomain(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	domainID, ok := domainIDParam(c)
+	if !ok {
+		return
+	}
+
+	domain, err := h.Domains.VerifyDomain(c.Request.Context(), orgCtx.Org.ID, domainID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, domain)
+}
+
+// DeleteDomain removes a custom domain from the org.
+func (h *AdminHandler) DeleteDomain(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	domainID, ok := domainIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Domains.DeleteDomain(c.Request.Context(), orgCtx.Org.ID, domainID); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func domainIDParam(c *gin.Context) (int64, bool) {
+	domainID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || domainID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid domain id."})
+		return 0, false
+	}
+	return domainID, true
+}
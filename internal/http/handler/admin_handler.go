@@ -1,22 +1,36 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/service"
 )
 
 // AdminHandler exposes internal admin endpoints.
 type AdminHandler struct {
-	Auth *service.AuthService
+	Auth    *service.AuthService
+	Domains *service.DomainService
+	Orgs    *service.OrgService
+	Configs *service.OrgConfigService
+	Members *service.MemberService
+	Issuers *issuer.Resolver
 }
 
-func NewAdminHandler(auth *service.AuthService) *AdminHandler {
-	return &AdminHandler{Auth: auth}
+func NewAdminHandler(auth *service.AuthService, domains *service.DomainService, orgs *service.OrgService, configs *service.OrgConfigService, members *service.MemberService, issuers *issuer.Resolver) *AdminHandler {
+	return &AdminHandler{Auth: auth, Domains: domains, Orgs: orgs, Configs: configs, Members: members, Issuers: issuers}
+}
+
+// issuer returns the issuer of tokens minted for the request's org.
+func (h *AdminHandler) issuer(c *gin.Context) string {
+	orgCtx, _ := middleware.GetOrgContext(c)
+	return h.Issuers.Issuer(c.Request, orgCtx)
 }
 
 type upsertOAuthClientRequest struct {
@@ -29,6 +43,18 @@ type upsertOAuthClientRequest struct {
 	Scopes                   []string `json:"scopes"`
 	Grants                   []string `json:"grants"`
 	TokenEndpointAuthMethods []string `json:"token_endpoint_auth_methods"`
+	// JWKS holds the client's public keys for signed request objects.
+	JWKS       json.RawMessage `json:"jwks"`
+	RequirePAR bool            `json:"require_pushed_authorization_requests"`
+	// Logout settings, see /oauth/logout.
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	BackchannelLogoutURI   string   `json:"backchannel_logout_uri"`
+	// TokenExchangeAudiences are the audiences allowed in token exchanges.
+	TokenExchangeAudiences []string `json:"token_exchange_audiences"`
+	// DPoPBoundAccessTokens requires DPoP proofs at the token endpoint.
+	DPoPBoundAccessTokens bool `json:"dpop_bound_access_tokens"`
+	// AccessTokenFormat is "jwt" (the default) or "opaque".
+	AccessTokenFormat string `json:"access_token_format"`
 }
 
 func (h *AdminHandler) UpsertOAuthClient(c *gin.Context) {
@@ -76,6 +102,16 @@ func (h *AdminHandler) UpsertOAuthClient(c *gin.Context) {
 		Grants:                   req.Grants,
 		TokenEndpointAuthMethods: req.TokenEndpointAuthMethods,
 		RotateSecret:             req.RotateSecret,
+		JWKS:                     strings.TrimSpace(string(req.JWKS)),
+		RequirePAR:               req.RequirePAR,
+		PostLogoutRedirectURIs:   req.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:     req.BackchannelLogoutURI,
+		TokenExchangeAudiences:   req.TokenExchangeAudiences,
+		DPoPBoundAccessTokens:    req.DPoPBoundAccessTokens,
+		AccessTokenFormat:        req.AccessTokenFormat,
+	}
+	if input.JWKS == "null" {
+		input.JWKS = ""
 	}
 
 	client, err := h.Auth.UpsertOAuthClient(c.Request.Context(), orgCtx.Org.ID, input)
@@ -85,11 +121,48 @@ func (h *AdminHandler) UpsertOAuthClient(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"client_id":                   client.ClientID,
-		"client_secret":               client.ClientSecret,
-		"redirect_uris":               client.RedirectURIs,
-		"scopes":                      client.Scopes,
-		"grants":                      client.Grants,
-		"token_endpoint_auth_methods": client.TokenEndpointAuthMethods,
+		"client_id":                             client.ClientID,
+		"client_secret":                         client.ClientSecret,
+		"redirect_uris":                         client.RedirectURIs,
+		"scopes":                                client.Scopes,
+		"grants":                                client.Grants,
+		"token_endpoint_auth_methods":           client.TokenEndpointAuthMethods,
+		"require_pushed_authorization_requests": client.RequirePAR,
+		"jwks":                                  clientJWKS(client.JWKS),
+		"post_logout_redirect_uris":             client.PostLogoutRedirectURIs,
+		"backchannel_logout_uri":                client.BackchannelLogoutURI,
+		"token_exchange_audiences":              client.TokenExchangeAudiences,
+		"dpop_bound_access_tokens":              client.DPoPBoundAccessTokens,
+		"access_token_format":                   client.AccessTokenFormat,
 	})
 }
+
+// RevokeUserSessions signs a user out everywhere: all browser sessions end and
+// all refresh tokens are revoked.
+func (h *AdminHandler) RevokeUserSessions(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid user id."})
+		return
+	}
+
+	revoked, err := h.Auth.RevokeAllSessions(c.Request.Context(), orgCtx, userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked_sessions": revoked})
+}
+
+// clientJWKS renders a stored key set as JSON, or null when none is registered.
+func clientJWKS(raw string) json.RawMessage {
+	if raw == "" {
+		return nil
+	}
+	return json.RawMessage(raw)
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// ListMembers returns a page of the org's members. Query parameters: limit,
+// offset.
+func (h *AdminHandler) ListMembers(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid limit."})
+		return
+	}
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid offset."})
+		return
+	}
+
+	page, err := h.Members.ListMembers(c.Request.Context(), orgCtx.Org.ID, limit, offset)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// AddMember gives an existing user of the org a role.
+func (h *AdminHandler) AddMember(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	caller, _ := middleware.GetAdminMember(c)
+
+	var req service.MemberInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	member, err := h.Members.AddMember(c.Request.Context(), caller, orgCtx.Org.ID, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMember changes the role or status of the member in the path.
+func (h *AdminHandler) UpdateMember(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	caller, _ := middleware.GetAdminMember(c)
+	userID, ok := memberIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req service.MemberUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	member, err := h.Members.UpdateMember(c.Request.Context(), caller, orgCtx.Org.ID, userID, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember removes the member in the path.
+func (h *AdminHandler) RemoveMember(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	caller, _ := middleware.GetAdminMember(c)
+	userID, ok := memberIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Members.RemoveMember(c.Request.Context(), caller, orgCtx.Org.ID, userID); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func memberIDParam(c *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid user id."})
+		return 0, false
+	}
+	return userID, true
+}
+
+// ListInvitations returns the org's pending invitations.
+func (h *AdminHandler) ListInvitations(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	invitations, err := h.Members.ListInvitations(c.Request.Context(), orgCtx.Org.ID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// InviteMember emails an invitation to join the org.
+func (h *AdminHandler) InviteMember(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	caller, _ := middleware.GetAdminMember(c)
+
+	var req service.InvitationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	issuer := h.issuer(c)
+	invitation, err := h.Members.InviteMember(c.Request.Context(), caller, orgCtx, req, issuer)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, invitation)
+}
+
+type resendInvitationRequest struct {
+	ExpiresIn int `json:"expires_in"`
+}
+
+// ResendInvitation emails a fresh link for the invitation in the path. The
+// body is optional.
+func (h *AdminHandler) ResendInvitation(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	caller, _ := middleware.GetAdminMember(c)
+	id, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req resendInvitationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+			return
+		}
+	}
+
+	issuer := h.issuer(c)
+	invitation, err := h.Members.ResendInvitation(c.Request.Context(), caller, orgCtx, id, req.ExpiresIn, issuer)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, invitation)
+}
+
+// RevokeInvitation deletes the invitation in the path.
+func (h *AdminHandler) RevokeInvitation(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	caller, _ := middleware.GetAdminMember(c)
+	id, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Members.RevokeInvitation(c.Request.Context(), caller, orgCtx.Org.ID, id); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func invitationIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid invitation id."})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// ListOrgs returns a page of orgs. Query parameters: status, limit, offset.
+func (h *AdminHandler) ListOrgs(c *gin.Context) {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid limit."})
+		return
+	}
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid offset."})
+		return
+	}
+
+	page, err := h.Orgs.ListOrgs(c.Request.Context(), c.Query("status"), limit, offset)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateOrg creates an active org.
+func (h *AdminHandler) CreateOrg(c *gin.Context) {
+	var req service.OrgInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	org, err := h.Orgs.CreateOrg(c.Request.Context(), req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, org)
+}
+
+// GetOrg returns a single org.
+func (h *AdminHandler) GetOrg(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	org, err := h.Orgs.GetOrg(c.Request.Context(), orgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// UpdateOrg changes the fields present in the body.
+func (h *AdminHandler) UpdateOrg(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req service.OrgUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+
+	org, err := h.Orgs.UpdateOrg(c.Request.Context(), orgID, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// SuspendOrg blocks sign-in and token issuance for an org.
+func (h *AdminHandler) SuspendOrg(c *gin.Context) {
+	callerCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	org, err := h.Orgs.SuspendOrg(c.Request.Context(), callerCtx.Org.ID, orgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// ActivateOrg lifts a suspension.
+func (h *AdminHandler) ActivateOrg(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	org, err := h.Orgs.ActivateOrg(c.Request.Context(), orgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// DeleteOrg soft-deletes an org; the response includes purge_after.
+func (h *AdminHandler) DeleteOrg(c *gin.Context) {
+	callerCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	org, err := h.Orgs.DeleteOrg(c.Request.Context(), callerCtx.Org.ID, orgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// RestoreOrg undoes a soft delete before the org is purged.
+func (h *AdminHandler) RestoreOrg(c *gin.Context) {
+	orgID, ok := orgIDParam(c)
+	if !ok {
+		return
+	}
+
+	org, err := h.Orgs.RestoreOrg(c.Request.Context(), orgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+func orgIDParam(c *gin.Context) (int64, bool) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || orgID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid org id."})
+		return 0, false
+	}
+	return orgID, true
+}
+
+func queryInt(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
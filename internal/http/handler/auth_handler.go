@@ -6,9 +6,9 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,8 +17,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
 	domainoauth "github.com/smallbiznis/railzway-auth/internal/domain/oauth"
 	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/org"
 	"github.com/smallbiznis/railzway-auth/internal/repository"
 	"github.com/smallbiznis/railzway-auth/internal/service"
@@ -30,6 +32,8 @@ type AuthHandler struct {
 	Auth                *service.AuthService
 	OAuth               authsvc.OAuthService
 	Discovery           *service.DiscoveryService
+	Issuers             *issuer.Resolver
+	Members             *service.MemberService
 	AuthorizeStateStore repository.AuthorizeStateStore
 	Config              config.Config
 }
@@ -42,25 +46,28 @@ const (
 )
 
 // NewAuthHandler creates the handler set.
-func NewAuthHandler(cfg config.Config, auth *service.AuthService, oauth authsvc.OAuthService, discovery *service.DiscoveryService, authorizeStateStore repository.AuthorizeStateStore) *AuthHandler {
+func NewAuthHandler(cfg config.Config, auth *service.AuthService, oauth authsvc.OAuthService, discovery *service.DiscoveryService, issuers *issuer.Resolver, authorizeStateStore repository.AuthorizeStateStore, members *service.MemberService) *AuthHandler {
 	return &AuthHandler{
 		Config:              cfg,
 		Auth:                auth,
 		OAuth:               oauth,
 		Discovery:           discovery,
+		Issuers:             issuers,
+		Members:             members,
 		AuthorizeStateStore: authorizeStateStore,
 	}
 }
 
-// OrgDiscovery returns metadata.
-// func (h *AuthHandler) OrgDiscovery(c *gin.Context) {
-// 	orgCtx, ok := middleware.GetOrgContext(c)
-// 	if !ok {
-// 		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_organization", "error_description": "Org not resolved."})
-// 		return
-// 	}
-// 	c.JSON(http.StatusOK, h.Discovery.OpenIDConfigurationResponse(orgCtx))
-// }
+// OrgDiscovery returns what the login UI needs to render the org's login
+// page. index.html embeds the same document.
+func (h *AuthHandler) OrgDiscovery(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_organization", "error_description": "Org not resolved."})
+		return
+	}
+	c.JSON(http.StatusOK, h.Discovery.OrgMetadata(orgCtx))
+}
 
 // OpenIDConfig returns OpenID discovery document.
 func (h *AuthHandler) OpenIDConfig(c *gin.Context) {
@@ -69,7 +76,7 @@ func (h *AuthHandler) OpenIDConfig(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_organization", "error_description": "Org not resolved."})
 		return
 	}
-	c.JSON(http.StatusOK, h.Discovery.OpenIDConfigurationResponse(schemeOnly(c.Request), hostOnly(c.Request), orgCtx))
+	c.JSON(http.StatusOK, h.Discovery.OpenIDConfigurationResponse(c.Request, orgCtx))
 }
 
 // JWKS exposes org public keys.
@@ -107,53 +114,102 @@ func (h *AuthHandler) Token(c *gin.Context) {
 		OTP          string `form:"otp"`
 		ClientID     string `form:"client_id"`
 		ClientSecret string `form:"client_secret"`
+		MFAToken     string `form:"mfa_token"`
+		RecoveryCode string `form:"recovery_code"`
+		OrgID        string `form:"org_id"`
+		// Token exchange (RFC 8693) parameters.
+		SubjectToken       string   `form:"subject_token"`
+		SubjectTokenType   string   `form:"subject_token_type"`
+		ActorToken         string   `form:"actor_token"`
+		ActorTokenType     string   `form:"actor_token_type"`
+		RequestedTokenType string   `form:"requested_token_type"`
+		Audience           []string `form:"audience"`
+		// Resource indicators (RFC 8707); audience is accepted as an alias
+		// outside token exchange.
+		Resource []string `form:"resource"`
 	}
 	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid token request."})
 		return
 	}
 
-	clientID := strings.TrimSpace(req.ClientID)
-	clientSecret := strings.TrimSpace(req.ClientSecret)
-	if basicID, basicSecret, ok := c.Request.BasicAuth(); ok {
-		if clientID == "" {
-			clientID = strings.TrimSpace(basicID)
-		}
-		if clientSecret == "" {
-			clientSecret = strings.TrimSpace(basicSecret)
+	clientID, clientSecret := clientCredentials(c.Request, req.ClientID, req.ClientSecret)
+
+	issuer := h.issuer(c)
+	grantType := strings.ToLower(req.GrantType)
+	resource, ok := requestedResource(append(slices.Clone(req.Resource), req.Audience...))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": "Only one resource may be requested."})
+		return
+	}
+	switch grantType {
+	case "authorization_code", "refresh_token", "client_credentials", service.GrantTypeTokenExchange:
+	default:
+		if resource != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": "resource is not supported for this grant type."})
+			return
 		}
 	}
 
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
-	var (
-		resp *service.TokenResponse
-		err  error
-	)
+	// A DPoP proof binds the issued tokens to the client's key.
+	jkt, err := h.Auth.VerifyDPoPProof(c.Request.Context(), orgCtx.Org.ID, service.DPoPProofRequest{
+		Proofs: c.Request.Header.Values("DPoP"),
+		Method: c.Request.Method,
+		URL:    h.Issuers.RequestURL(c.Request),
+	})
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Request = c.Request.WithContext(service.WithDPoPKey(c.Request.Context(), jkt))
 
-	switch strings.ToLower(req.GrantType) {
+	var resp *service.TokenResponse
+	switch grantType {
 	case "password":
 		resp, err = h.Auth.PasswordGrant(c.Request.Context(), orgCtx, req.Username, req.Password, req.Scope, issuer)
 	case "refresh_token":
-		resp, err = h.Auth.RefreshGrant(c.Request.Context(), orgCtx, req.RefreshToken, req.Scope, issuer)
+		if strings.TrimSpace(req.OrgID) == "" {
+			resp, err = h.Auth.RefreshGrant(c.Request.Context(), orgCtx, req.RefreshToken, req.Scope, issuer, resource)
+			break
+		}
+		activeOrgID, parseErr := strconv.ParseInt(strings.TrimSpace(req.OrgID), 10, 64)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "org_id is invalid."})
+			return
+		}
+		resp, err = h.Auth.SwitchOrg(c.Request.Context(), orgCtx, req.RefreshToken, req.Scope, issuer, activeOrgID)
 	case "authorization_code":
-		resp, err = h.Auth.AuthorizationCodeGrant(c.Request.Context(), orgCtx, req.Code, req.RedirectURI, req.Scope, issuer)
+		resp, err = h.Auth.AuthorizationCodeGrant(c.Request.Context(), orgCtx, req.Code, req.RedirectURI, req.Scope, issuer, resource)
 	case "client_credentials":
-		resp, err = h.Auth.ClientCredentialsGrant(c.Request.Context(), orgCtx, clientID, clientSecret, req.Scope, issuer)
+		resp, err = h.Auth.ClientCredentialsGrant(c.Request.Context(), orgCtx, clientID, clientSecret, req.Scope, issuer, resource)
 	case "device_code":
 		resp, err = h.Auth.DeviceCodeGrant(c.Request.Context())
+	case service.GrantTypeTokenExchange:
+		resp, err = h.Auth.TokenExchangeGrant(c.Request.Context(), orgCtx, service.TokenExchangeRequest{
+			ClientID:           clientID,
+			ClientSecret:       clientSecret,
+			SubjectToken:       req.SubjectToken,
+			SubjectTokenType:   req.SubjectTokenType,
+			ActorToken:         req.ActorToken,
+			ActorTokenType:     req.ActorTokenType,
+			RequestedTokenType: req.RequestedTokenType,
+			Audience:           append(req.Audience, req.Resource...),
+			Scope:              req.Scope,
+			Issuer:             issuer,
+		})
 	case "otp", "http://auth0.com/oauth/grant-type/passwordless/otp":
 		resp, err = h.Auth.OTPGrant(c.Request.Context(), orgCtx, req.Username, req.OTP, req.Scope, issuer)
+	case "http://auth0.com/oauth/grant-type/mfa-otp":
+		resp, err = h.Auth.MFAOTPGrant(c.Request.Context(), orgCtx, req.MFAToken, req.OTP)
+	case "http://auth0.com/oauth/grant-type/mfa-recovery-code":
+		resp, err = h.Auth.MFARecoveryCodeGrant(c.Request.Context(), orgCtx, req.MFAToken, req.RecoveryCode)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type", "error_description": "Unsupported grant type."})
 		return
 	}
 
 	if err != nil {
-		if oauthErr, ok := err.(*service.OAuthError); ok {
-			c.JSON(oauthErr.Status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": err.Error()})
+		respondOAuthError(c, err)
 		return
 	}
 
@@ -225,13 +281,29 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "provider, code, and state are required."})
 		return
 	}
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
+	issuer := h.issuer(c)
 	ctx := authsvc.WithIssuer(c.Request.Context(), issuer)
 	session, err := h.OAuth.HandleCallback(ctx, orgCtx.Org.ID, input)
 	if err != nil {
+		// The provider only counts as a first factor: no session or token
+		// cookies until the org's MFA and email verification policies pass.
+		var mfaErr *service.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			c.Redirect(http.StatusFound, mfaChallengeRedirect("", mfaErr))
+			return
+		}
+		var policyErr *service.OAuthError
+		if errors.As(err, &policyErr) {
+			respondOAuthError(c, policyErr)
+			return
+		}
 		h.respondOAuthServiceError(c, err)
 		return
 	}
+	if err := h.startSession(c, orgCtx, session.UserID, session.AMR); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
 
 	h.setCookie(c, CookieNameAccessToken, session.AccessToken, int(session.ExpiresIn))
 	h.setCookie(c, CookieNameRefreshToken, session.RefreshToken, int(session.ExpiresIn))
@@ -245,32 +317,53 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 
 // OAuthIntrospect validates tokens per RFC 7662.
 func (h *AuthHandler) OAuthIntrospect(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
 	var req struct {
-		Token string `form:"token" json:"token" binding:"required"`
+		Token         string `form:"token" json:"token" binding:"required"`
+		TokenTypeHint string `form:"token_type_hint" json:"token_type_hint"`
+		ClientID      string `form:"client_id" json:"client_id"`
+		ClientSecret  string `form:"client_secret" json:"client_secret"`
 	}
 	if err := c.ShouldBind(&req); err != nil || strings.TrimSpace(req.Token) == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "token is required."})
 		return
 	}
-	result, err := h.OAuth.IntrospectToken(c.Request.Context(), req.Token)
+
+	// Introspection reveals token contents, so only registered clients of
+	// the org may call it (RFC 7662 section 2.1).
+	clientID, clientSecret := clientCredentials(c.Request, req.ClientID, req.ClientSecret)
+	client, err := h.Auth.AuthenticateClient(c.Request.Context(), orgCtx.Org.ID, clientID, clientSecret)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		respondOAuthError(c, err)
+		return
+	}
+
+	issuer := h.issuer(c)
+	ctx := authsvc.WithIssuer(c.Request.Context(), issuer)
+	result, err := h.OAuth.IntrospectToken(ctx, orgCtx.Org.ID, req.Token, req.TokenTypeHint)
 	if err != nil {
 		h.respondOAuthServiceError(c, err)
 		return
 	}
-	if result == nil || !result.Active {
-		c.JSON(http.StatusOK, gin.H{"active": false})
+
+	// RFC 9701: clients that ask for it get the response as a signed JWT.
+	if strings.Contains(c.GetHeader("Accept"), introspectionJWTContentType) {
+		signed, err := h.OAuth.SignIntrospection(ctx, orgCtx.Org, client.ClientID, issuer, result)
+		if err != nil {
+			h.respondOAuthServiceError(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, introspectionJWTContentType, []byte(signed))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"active":    true,
-		"sub":       result.Subject,
-		"scope":     result.Scope,
-		"exp":       result.ExpiresAt,
-		"iat":       result.IssuedAt,
-		"client_id": result.ClientID,
-		"org_id":    result.OrgID,
-		"tenant_id": result.OrgID,
-	})
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, result.Response())
 }
 
 // OAuthRevoke processes RFC 7009 token revocation.
@@ -293,19 +386,41 @@ func (h *AuthHandler) OAuthRevoke(c *gin.Context) {
 func (h *AuthHandler) OAuthUserInfo(c *gin.Context) {
 	authz := c.GetHeader("Authorization")
 	parts := strings.SplitN(authz, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+	scheme := parts[0]
+	if len(parts) != 2 || (!strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, service.TokenTypeDPoP)) || strings.TrimSpace(parts[1]) == "" {
 		c.Header("WWW-Authenticate", "Bearer")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "Authorization header missing or invalid."})
 		return
 	}
-	info, err := h.OAuth.UserInfo(c.Request.Context(), strings.TrimSpace(parts[1]))
+	token := strings.TrimSpace(parts[1])
+	info, err := h.OAuth.UserInfo(c.Request.Context(), token)
 	if err != nil {
 		h.respondOAuthServiceError(c, err)
 		return
 	}
+	err = h.Auth.CheckTokenBinding(c.Request.Context(), info.OrgID, scheme, info.DPoPKey, service.DPoPProofRequest{
+		Proofs:      c.Request.Header.Values("DPoP"),
+		Method:      c.Request.Method,
+		URL:         h.Issuers.RequestURL(c.Request),
+		AccessToken: token,
+	})
+	if err != nil {
+		respondTokenBindingError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, info)
 }
 
+// respondTokenBindingError answers a failed DPoP check at a protected
+// resource with a DPoP challenge.
+func respondTokenBindingError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if errors.As(err, &oauthErr) {
+		c.Header("WWW-Authenticate", fmt.Sprintf(`DPoP error=%q, algs=%q`, oauthErr.Code, strings.Join(service.DPoPSigningAlgValues(), " ")))
+	}
+	respondOAuthError(c, err)
+}
+
 type oauthAuthorizeRequest struct {
 	ClientID            string `form:"client_id"`
 	ResponseType        string `form:"response_type"`
@@ -315,6 +430,18 @@ type oauthAuthorizeRequest struct {
 	CodeChallengeMethod string `form:"code_challenge_method"`
 	State               string `form:"state"`
 	Nonce               string `form:"nonce"`
+	OrgID               string `form:"org_id"`
+	ResponseMode        string `form:"response_mode"`
+	Prompt              string `form:"prompt"`
+	MaxAge              string `form:"max_age"`
+	LoginHint           string `form:"login_hint"`
+	UILocales           string `form:"ui_locales"`
+	Request             string `form:"request"`
+	RequestURI          string `form:"request_uri"`
+	// Resource lists RFC 8707 resource indicators; Audience is accepted as
+	// an alias for clients that send audience instead.
+	Resource []string `form:"resource"`
+	Audience string   `form:"audience"`
 }
 
 type oauthAuthorizeParams struct {
@@ -324,6 +451,17 @@ type oauthAuthorizeParams struct {
 	parsedRedirect      *url.URL
 	codeChallenge       string
 	codeChallengeMethod string
+	activeOrgID         int64
+	responseMode        string
+	prompt              []string
+	maxAge              *int64
+	pushedRequest       bool
+	resource            string
+}
+
+// hasPrompt reports whether the request asked for the given prompt value.
+func (p oauthAuthorizeParams) hasPrompt(value string) bool {
+	return slices.Contains(p.prompt, value)
 }
 
 type oauthAuthorizeError struct {
@@ -348,32 +486,69 @@ func (h *AuthHandler) OAuthAuthorize(c *gin.Context) {
 		return
 	}
 
+	req, pushed, oauthErr := h.resolveAuthorizeRequest(c, orgCtx, req)
+	if oauthErr != nil {
+		h.oauthErrorRedirect(c, oauthErr.code, oauthErr.description)
+		return
+	}
+
 	params, oauthErr := h.normalizeAuthorizeRequest(c.Request.Context(), orgCtx.Org.ID, req)
 	if oauthErr != nil {
 		h.oauthErrorRedirect(c, oauthErr.code, oauthErr.description)
 		return
 	}
+	params.pushedRequest = pushed
 
 	// Only session cookie authentication is allowed for /oauth/authorize
-	token, _ := c.Cookie(CookieNameAccessToken)
-	if strings.TrimSpace(token) == "" {
+	var session *domain.Session
+	token, _ := c.Cookie(CookieNameSession)
+	if strings.TrimSpace(token) != "" {
+		session, oauthErr = h.validateAuthorizeSession(c, orgCtx, token)
+		if oauthErr != nil {
+			h.oauthErrorRedirect(c, oauthErr.code, oauthErr.description)
+			return
+		}
+		if session == nil {
+			// Expired or revoked session: drop the stale cookie.
+			h.setCookie(c, CookieNameSession, "", -1)
+		}
+	}
+
+	// A session opened with a first factor only must sign in again once the
+	// org requires MFA.
+	if session == nil || authorizeNeedsLogin(params, session) || !h.Auth.SessionSatisfiesMFA(orgCtx, session) {
+		if params.hasPrompt("none") {
+			h.redirectAuthorizeError(c, params, req.State, "login_required", "The user must sign in.")
+			return
+		}
 		h.redirectAuthorizeLogin(c, orgCtx.Org.ID, req, params)
 		return
 	}
-
-	userID, oauthErr := h.validateAuthorizeSession(c, orgCtx.Org.ID, token)
-	if oauthErr != nil {
-		h.oauthErrorRedirect(c, oauthErr.code, oauthErr.description)
+	if params.hasPrompt("none") && h.Auth.ClientRequiresConsent(c.Request.Context(), orgCtx.Org.ID, params.clientID) {
+		h.redirectAuthorizeError(c, params, req.State, "consent_required", "The client requires the user's consent.")
 		return
 	}
 
-	code, oauthErr := h.createAuthorizationCode(c.Request.Context(), orgCtx, userID, params)
+	code, oauthErr := h.createAuthorizationCode(c.Request.Context(), orgCtx, session, params)
 	if oauthErr != nil {
 		h.oauthErrorRedirect(c, oauthErr.code, oauthErr.description)
 		return
 	}
 
-	h.redirectAuthorizeSuccess(c, params.parsedRedirect, req.State, code)
+	h.redirectAuthorizeSuccess(c, params, req.State, code)
+}
+
+// authorizeNeedsLogin reports whether the request asks for a fresh sign-in
+// despite session: prompt=login or select_account, or a session older than
+// max_age.
+func authorizeNeedsLogin(params oauthAuthorizeParams, session *domain.Session) bool {
+	if params.hasPrompt("login") || params.hasPrompt("select_account") {
+		return true
+	}
+	if params.maxAge != nil {
+		return time.Since(session.CreatedAt) > time.Duration(*params.maxAge)*time.Second
+	}
+	return false
 }
 
 func bindOAuthAuthorizeRequest(c *gin.Context) (oauthAuthorizeRequest, error) {
@@ -427,6 +602,56 @@ func normalizeAuthorizeCodeChallengeMethod(method string) (string, *oauthAuthori
 	return normalized, nil
 }
 
+func normalizeAuthorizeOrgID(orgID string) (int64, *oauthAuthorizeError) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, newOAuthAuthorizeError("invalid_request", "org_id is invalid.")
+	}
+	return id, nil
+}
+
+func normalizeAuthorizeResponseMode(mode string) (string, *oauthAuthorizeError) {
+	mode = strings.TrimSpace(mode)
+	switch mode {
+	case "":
+		return responseModeQuery, nil
+	case responseModeQuery, responseModeFragment, responseModeFormPost:
+		return mode, nil
+	}
+	return "", newOAuthAuthorizeError("invalid_request", "response_mode must be query, fragment or form_post.")
+}
+
+func normalizeAuthorizePrompt(prompt string) ([]string, *oauthAuthorizeError) {
+	values := strings.Fields(prompt)
+	for _, value := range values {
+		switch value {
+		case "none", "login", "consent", "select_account":
+		default:
+			return nil, newOAuthAuthorizeError("invalid_request", "prompt must be none, login, consent or select_account.")
+		}
+	}
+	if slices.Contains(values, "none") && len(values) > 1 {
+		return nil, newOAuthAuthorizeError("invalid_request", "prompt=none cannot be combined with other values.")
+	}
+	return values, nil
+}
+
+func normalizeAuthorizeMaxAge(maxAge string) (*int64, *oauthAuthorizeError) {
+	maxAge = strings.TrimSpace(maxAge)
+	if maxAge == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(maxAge, 10, 64)
+	if err != nil || seconds < 0 {
+		return nil, newOAuthAuthorizeError("invalid_request", "max_age must be a non-negative number of seconds.")
+	}
+	return &seconds, nil
+}
+
 func (h *AuthHandler) normalizeAuthorizeRequest(ctx context.Context, orgID int64, req oauthAuthorizeRequest) (oauthAuthorizeParams, *oauthAuthorizeError) {
 	responseType, err := normalizeAuthorizeResponseType(req.ResponseType)
 	if err != nil {
@@ -448,6 +673,26 @@ func (h *AuthHandler) normalizeAuthorizeRequest(ctx context.Context, orgID int64
 	if err != nil {
 		return oauthAuthorizeParams{}, err
 	}
+	activeOrgID, err := normalizeAuthorizeOrgID(req.OrgID)
+	if err != nil {
+		return oauthAuthorizeParams{}, err
+	}
+	responseMode, err := normalizeAuthorizeResponseMode(req.ResponseMode)
+	if err != nil {
+		return oauthAuthorizeParams{}, err
+	}
+	prompt, err := normalizeAuthorizePrompt(req.Prompt)
+	if err != nil {
+		return oauthAuthorizeParams{}, err
+	}
+	maxAge, err := normalizeAuthorizeMaxAge(req.MaxAge)
+	if err != nil {
+		return oauthAuthorizeParams{}, err
+	}
+	resource, err := h.normalizeAuthorizeResource(ctx, orgID, append(slices.Clone(req.Resource), req.Audience))
+	if err != nil {
+		return oauthAuthorizeParams{}, err
+	}
 	return oauthAuthorizeParams{
 		clientID:            clientID,
 		responseType:        responseType,
@@ -455,6 +700,11 @@ func (h *AuthHandler) normalizeAuthorizeRequest(ctx context.Context, orgID int64
 		parsedRedirect:      parsedRedirect,
 		codeChallenge:       codeChallenge,
 		codeChallengeMethod: codeChallengeMethod,
+		activeOrgID:         activeOrgID,
+		responseMode:        responseMode,
+		prompt:              prompt,
+		maxAge:              maxAge,
+		resource:            resource,
 	}, nil
 }
 
@@ -478,48 +728,48 @@ func (h *AuthHandler) redirectAuthorizeLogin(c *gin.Context, orgID int64, req oa
 	}
 
 	loginURL := &url.URL{
-		Scheme: schemeOnly(c.Request),
-		Host:   hostOnly(c.Request),
+		Scheme: h.Issuers.Scheme(c.Request),
+		Host:   h.Issuers.Host(c.Request),
 		Path:   "/login",
 	}
 
 	q := loginURL.Query()
 	q.Set("state", stateID)
+	if hint := strings.TrimSpace(req.LoginHint); hint != "" {
+		q.Set("login_hint", hint)
+	}
+	if locales := strings.TrimSpace(req.UILocales); locales != "" {
+		q.Set("ui_locales", locales)
+	}
 
 	loginURL.RawQuery = q.Encode()
 	c.Redirect(http.StatusFound, loginURL.String())
 }
 
-func (h *AuthHandler) validateAuthorizeSession(c *gin.Context, orgID int64, token string) (int64, *oauthAuthorizeError) {
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
-	stdClaims, _, err := h.Auth.ValidateToken(
-		c.Request.Context(),
-		orgID,
-		token,
-		issuer,
-	)
+// validateAuthorizeSession resolves the browser session behind the session
+// cookie. It returns nil when the session is no longer active.
+func (h *AuthHandler) validateAuthorizeSession(c *gin.Context, orgCtx *org.Context, token string) (*domain.Session, *oauthAuthorizeError) {
+	session, err := h.Auth.ResolveSession(c.Request.Context(), orgCtx, token)
 	if err != nil {
-		return 0, newOAuthAuthorizeError("invalid_token", "Invalid access token.")
-	}
-	if stdClaims == nil || strings.TrimSpace(stdClaims.Subject) == "" {
-		return 0, newOAuthAuthorizeError("invalid_token", "Missing subject claim.")
+		return nil, newOAuthAuthorizeError("server_error", "Failed to load session.")
 	}
-	userID, err := strconv.ParseInt(stdClaims.Subject, 10, 64)
-	if err != nil || userID <= 0 {
-		return 0, newOAuthAuthorizeError("invalid_token", "Invalid subject claim.")
-	}
-	return userID, nil
+	return session, nil
 }
 
-func (h *AuthHandler) createAuthorizationCode(ctx context.Context, orgCtx *org.Context, userID int64, params oauthAuthorizeParams) (string, *oauthAuthorizeError) {
+// createAuthorizationCode issues a code for the session's user, recording
+// when the session signed in as the ID token's auth_time.
+func (h *AuthHandler) createAuthorizationCode(ctx context.Context, orgCtx *org.Context, session *domain.Session, params oauthAuthorizeParams) (string, *oauthAuthorizeError) {
 	code, err := h.Auth.CreateAuthorizationCode(
 		ctx,
 		orgCtx,
-		userID,
+		session.UserID,
 		params.clientID,
 		params.redirectURI,
 		params.codeChallenge,
 		params.codeChallengeMethod,
+		params.activeOrgID,
+		params.resource,
+		session.CreatedAt,
 	)
 	if err != nil {
 		if oauthErr, ok := err.(*service.OAuthError); ok {
@@ -530,14 +780,42 @@ func (h *AuthHandler) createAuthorizationCode(ctx context.Context, orgCtx *org.C
 	return code, nil
 }
 
-func (h *AuthHandler) redirectAuthorizeSuccess(c *gin.Context, parsedRedirect *url.URL, state, code string) {
-	q := parsedRedirect.Query()
-	q.Set("code", code)
+func (h *AuthHandler) redirectAuthorizeSuccess(c *gin.Context, params oauthAuthorizeParams, state, code string) {
+	values := url.Values{}
+	values.Set("code", code)
+	if state != "" {
+		values.Set("state", state)
+	}
+	h.respondAuthorize(c, params, values)
+}
+
+// redirectAuthorizeError returns an error to the client's redirect_uri, for
+// errors raised once the redirect_uri has been validated.
+func (h *AuthHandler) redirectAuthorizeError(c *gin.Context, params oauthAuthorizeParams, state, code, description string) {
+	values := url.Values{}
+	values.Set("error", code)
+	values.Set("error_description", description)
 	if state != "" {
-		q.Set("state", state)
+		values.Set("state", state)
 	}
-	parsedRedirect.RawQuery = q.Encode()
-	c.Redirect(http.StatusFound, parsedRedirect.String())
+	h.respondAuthorize(c, params, values)
+}
+
+// normalizeAuthorizeResource resolves the requested resource indicators to
+// a registered API identifier, or "" when none was requested.
+func (h *AuthHandler) normalizeAuthorizeResource(ctx context.Context, orgID int64, indicators []string) (string, *oauthAuthorizeError) {
+	resource, err := h.Auth.ResolveResource(ctx, orgID, indicators)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			return "", newOAuthAuthorizeError(oauthErr.Code, oauthErr.Description)
+		}
+		return "", newOAuthAuthorizeError("server_error", "Failed to resolve resource.")
+	}
+	if resource == nil {
+		return "", nil
+	}
+	return resource.Identifier, nil
 }
 
 func (h *AuthHandler) persistAuthorizeState(ctx context.Context, orgID int64, req oauthAuthorizeRequest, params oauthAuthorizeParams) (string, error) {
@@ -559,6 +837,14 @@ func (h *AuthHandler) persistAuthorizeState(ctx context.Context, orgID int64, re
 		Nonce:               strings.TrimSpace(req.Nonce),
 		CodeChallenge:       strings.TrimSpace(params.codeChallenge),
 		CodeChallengeMethod: strings.TrimSpace(params.codeChallengeMethod),
+		ActiveOrgID:         params.activeOrgID,
+		ResponseMode:        params.responseMode,
+		Prompt:              strings.Join(params.prompt, " "),
+		MaxAge:              params.maxAge,
+		LoginHint:           strings.TrimSpace(req.LoginHint),
+		UILocales:           strings.TrimSpace(req.UILocales),
+		PushedRequest:       params.pushedRequest,
+		Resource:            params.resource,
 		CreatedAt:           time.Now().UTC(),
 	}
 	key := buildAuthorizeStateKey(stateID)
@@ -575,8 +861,8 @@ func buildAuthorizeStateKey(stateID string) string {
 // Redirect helper
 func (h *AuthHandler) oauthErrorRedirect(c *gin.Context, code, desc string) {
 	errURL := url.URL{
-		Scheme: schemeOnly(c.Request),
-		Host:   hostOnly(c.Request),
+		Scheme: h.Issuers.Scheme(c.Request),
+		Host:   h.Issuers.Host(c.Request),
 		Path:   "/error/oauth",
 	}
 
@@ -609,26 +895,10 @@ func (h *AuthHandler) respondOAuthServiceError(c *gin.Context, err error) {
 	}
 }
 
-func schemeOnly(r *http.Request) string {
-	scheme := r.Header.Get("X-Forwarded-Proto")
-	if scheme == "" {
-		if r.TLS != nil {
-			scheme = "https"
-		} else {
-			scheme = "http"
-		}
-	}
-	return scheme
-}
-
-func hostOnly(r *http.Request) string {
-	host := r.Host
-	if strings.Contains(host, ":") {
-		if h, _, err := net.SplitHostPort(host); err == nil {
-			return h
-		}
-	}
-	return host
+// issuer returns the issuer of tokens minted for the request's org.
+func (h *AuthHandler) issuer(c *gin.Context) string {
+	orgCtx, _ := middleware.GetOrgContext(c)
+	return h.Issuers.Issuer(c.Request, orgCtx)
 }
 
 func secureRandomString(size int) (string, error) {
@@ -641,3 +911,41 @@ func secureRandomString(size int) (string, error) {
 	}
 	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
+
+// requestedResource returns the resource indicator of a token request, or ""
+// when none was sent. It reports false when more than one distinct resource
+// was requested.
+func requestedResource(values []string) (string, bool) {
+	var resource string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || value == resource {
+			continue
+		}
+		if resource != "" {
+			return "", false
+		}
+		resource = value
+	}
+	return resource, true
+}
+
+// introspectionJWTContentType is the media type of signed introspection
+// responses (RFC 9701).
+const introspectionJWTContentType = "application/token-introspection+jwt"
+
+// clientCredentials returns the client_id and client_secret of a request,
+// falling back to HTTP Basic authentication for whichever the form left out.
+func clientCredentials(r *http.Request, formID, formSecret string) (string, string) {
+	clientID := strings.TrimSpace(formID)
+	clientSecret := strings.TrimSpace(formSecret)
+	if basicID, basicSecret, ok := r.BasicAuth(); ok {
+		if clientID == "" {
+			clientID = strings.TrimSpace(basicID)
+		}
+		if clientSecret == "" {
+			clientSecret = strings.TrimSpace(basicSecret)
+		}
+	}
+	return clientID, clientSecret
+}
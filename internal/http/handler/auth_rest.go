@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -12,6 +13,7 @@ import (
 
 	domainoauth "github.com/smallbiznis/railzway-auth/internal/domain/oauth"
 	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/org"
 	"github.com/smallbiznis/railzway-auth/internal/service"
 )
 
@@ -61,24 +63,29 @@ func (h *AuthHandler) PasswordLogin(c *gin.Context) {
 
 	scope := strings.TrimSpace(req.Scope)
 
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
+	issuer := h.issuer(c)
 	resp, err := h.Auth.LoginWithPassword(c.Request.Context(), orgCtx.Org.ID, req.Email, req.Password, clientID, scope, issuer)
 	if err != nil {
 		respondOAuthError(c, err)
 		return
 	}
 
+	if err := h.startSession(c, orgCtx, resp.User.ID, resp.AMR); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
 	maxAge := 3600
 	h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
 	h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
 
 	authorizeURL := ""
 	if authorizeState != nil {
-		authorizeURL = buildAuthorizeURLFromState(authorizeState)
+		authorizeURL = h.authorizeURLFromState(c, authorizeState)
 		h.deleteAuthorizeState(c, authorizeStateID)
 	}
 
-	c.JSON(http.StatusOK, authResponse{AuthTokensWithUser: resp, AuthorizeURL: authorizeURL})
+	c.JSON(http.StatusOK, authResponse{AuthTokensWithUser: resp, AuthorizeURL: authorizeURL, Orgs: h.authorizeOrgChoices(c, orgCtx, authorizeState, resp.User.ID)})
 }
 
 func (h *AuthHandler) PasswordRegister(c *gin.Context) {
@@ -125,20 +132,25 @@ func (h *AuthHandler) PasswordRegister(c *gin.Context) {
 		return
 	}
 
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
+	issuer := h.issuer(c)
 	resp, err := h.Auth.RegisterWithPassword(c.Request.Context(), orgCtx.Org.ID, req.Email, req.Password, req.Name, clientID, issuer)
 	if err != nil {
 		respondOAuthError(c, err)
 		return
 	}
 
+	if err := h.startSession(c, orgCtx, resp.User.ID, resp.AMR); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
 	maxAge := 3600
 	h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
 	h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
 
 	authorizeURL := ""
 	if authorizeState != nil {
-		authorizeURL = buildAuthorizeURLFromState(authorizeState)
+		authorizeURL = h.authorizeURLFromState(c, authorizeState)
 		h.deleteAuthorizeState(c, authorizeStateID)
 	}
 
@@ -229,7 +241,7 @@ func (h *AuthHandler) OTPVerify(c *gin.Context) {
 	}
 	scope := strings.TrimSpace(req.Scope)
 
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
+	issuer := h.issuer(c)
 	resp, err := h.Auth.VerifyOTP(c.Request.Context(), orgCtx.Org.ID, req.Phone, req.Code, clientID, scope, issuer)
 	if err != nil {
 		respondOAuthError(c, err)
@@ -267,6 +279,17 @@ func (h *AuthHandler) Me(c *gin.Context) {
 }
 
 func respondOAuthError(c *gin.Context, err error) {
+	var mfaErr *service.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":               "mfa_required",
+			"error_description":   "Multifactor authentication required.",
+			"mfa_token":           mfaErr.MFAToken,
+			"enrollment_required": mfaErr.EnrollmentRequired,
+			"factors":             mfaErr.Factors,
+		})
+		return
+	}
 	if oauthErr, ok := err.(*service.OAuthError); ok {
 		c.JSON(oauthErr.Status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
 		return
@@ -290,6 +313,9 @@ func (h *AuthHandler) setCookie(c *gin.Context, name, value string, maxAge int)
 type authResponse struct {
 	service.AuthTokensWithUser
 	AuthorizeURL string `json:"authorize_url,omitempty"`
+	// Orgs is set when the authorize flow should let the user pick the org
+	// to continue in.
+	Orgs []service.UserOrg `json:"orgs,omitempty"`
 }
 
 func (h *AuthHandler) loadAuthorizeState(c *gin.Context, stateID string) (*domainoauth.AuthorizeState, error) {
@@ -325,12 +351,56 @@ func (h *AuthHandler) deleteAuthorizeState(c *gin.Context, stateID string) {
 	}
 }
 
+// authorizeOrgChoices lists the orgs a user may continue an authorize flow
+// in. It returns nil unless the flow left the org open and the user has more
+// than one to choose from.
+func (h *AuthHandler) authorizeOrgChoices(c *gin.Context, orgCtx *org.Context, state *domainoauth.AuthorizeState, userID int64) []service.UserOrg {
+	if state == nil || state.ActiveOrgID != 0 {
+		return nil
+	}
+	orgs, err := h.Auth.ListUserOrgs(c.Request.Context(), orgCtx, userID, 0)
+	if err != nil {
+		zap.L().Warn("failed to list user orgs", zap.Error(err))
+		return nil
+	}
+	if len(orgs) < 2 {
+		return nil
+	}
+	return orgs
+}
+
+// authorizeURLFromState returns the URL that resumes an authorize request
+// after sign-in. Pushed and signed requests resume through a fresh
+// request_uri so their parameters never travel as plain query values.
+func (h *AuthHandler) authorizeURLFromState(c *gin.Context, state *domainoauth.AuthorizeState) string {
+	if state == nil || !state.PushedRequest {
+		return buildAuthorizeURLFromState(state)
+	}
+	requestURI, err := h.savePushedRequest(c.Request.Context(), state.OrgID, state.ClientID, authorizeValuesFromState(state))
+	if err != nil {
+		zap.L().Error("failed to push authorize continuation", zap.Error(err))
+		return ""
+	}
+	authorizeURL := &url.URL{Path: "/oauth/authorize"}
+	q := authorizeURL.Query()
+	q.Set("client_id", state.ClientID)
+	q.Set("request_uri", requestURI)
+	authorizeURL.RawQuery = q.Encode()
+	return authorizeURL.String()
+}
+
 func buildAuthorizeURLFromState(state *domainoauth.AuthorizeState) string {
 	if state == nil {
 		return ""
 	}
 	authorizeURL := &url.URL{Path: "/oauth/authorize"}
-	q := authorizeURL.Query()
+	authorizeURL.RawQuery = authorizeValuesFromState(state).Encode()
+	return authorizeURL.String()
+}
+
+// authorizeValuesFromState rebuilds the authorize parameters held in state.
+func authorizeValuesFromState(state *domainoauth.AuthorizeState) url.Values {
+	q := url.Values{}
 	q.Set("client_id", state.ClientID)
 	responseType := strings.TrimSpace(state.ResponseType)
 	if responseType == "" {
@@ -353,6 +423,31 @@ func buildAuthorizeURLFromState(state *domainoauth.AuthorizeState) string {
 	if strings.TrimSpace(state.CodeChallengeMethod) != "" {
 		q.Set("code_challenge_method", strings.TrimSpace(state.CodeChallengeMethod))
 	}
-	authorizeURL.RawQuery = q.Encode()
-	return authorizeURL.String()
+	if state.ActiveOrgID != 0 {
+		q.Set("org_id", strconv.FormatInt(state.ActiveOrgID, 10))
+	}
+	if resource := strings.TrimSpace(state.Resource); resource != "" {
+		q.Set("resource", resource)
+	}
+	if mode := strings.TrimSpace(state.ResponseMode); mode != "" && mode != responseModeQuery {
+		q.Set("response_mode", mode)
+	}
+	// The sign-in that consumed the state satisfies prompt=login,
+	// select_account and max_age; repeating them would loop back to login.
+	var prompt []string
+	for _, value := range strings.Fields(state.Prompt) {
+		if value != "login" && value != "select_account" {
+			prompt = append(prompt, value)
+		}
+	}
+	if len(prompt) > 0 {
+		q.Set("prompt", strings.Join(prompt, " "))
+	}
+	if strings.TrimSpace(state.LoginHint) != "" {
+		q.Set("login_hint", strings.TrimSpace(state.LoginHint))
+	}
+	if strings.TrimSpace(state.UILocales) != "" {
+		q.Set("ui_locales", strings.TrimSpace(state.UILocales))
+	}
+	return q
 }
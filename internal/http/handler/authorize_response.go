@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/branding"
+)
+
+// Authorization response modes, see OAuth 2.0 Multiple Response Type
+// Encoding Practices and OAuth 2.0 Form Post Response Mode.
+const (
+	responseModeQuery    = "query"
+	responseModeFragment = "fragment"
+	responseModeFormPost = "form_post"
+)
+
+// formPostPage auto-submits the authorization response to the client. The
+// noscript button covers browsers with scripts disabled.
+var formPostPage = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Continue</title></head>
+<body>
+<form method="post" action="{{.Action}}">
+{{range $name, $values := .Values}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<noscript><button type="submit">Continue</button></noscript>
+</form>
+<script nonce="{{.Nonce}}">document.forms[0].submit()</script>
+</body>
+</html>
+`))
+
+// respondAuthorize delivers values to the client's redirect_uri using the
+// request's response mode.
+func (h *AuthHandler) respondAuthorize(c *gin.Context, params oauthAuthorizeParams, values url.Values) {
+	target := *params.parsedRedirect
+	switch params.responseMode {
+	case responseModeFormPost:
+		h.respondAuthorizeFormPost(c, target, values)
+	case responseModeFragment:
+		target.Fragment, target.RawFragment = "", ""
+		c.Redirect(http.StatusFound, target.String()+"#"+values.Encode())
+	default:
+		q := target.Query()
+		for name, vals := range values {
+			q[name] = vals
+		}
+		target.RawQuery = q.Encode()
+		c.Redirect(http.StatusFound, target.String())
+	}
+}
+
+func (h *AuthHandler) respondAuthorizeFormPost(c *gin.Context, target url.URL, values url.Values) {
+	nonce, err := branding.NewNonce()
+	if err != nil {
+		h.oauthErrorRedirect(c, "server_error", "Failed to render response.")
+		return
+	}
+	var page bytes.Buffer
+	err = formPostPage.Execute(&page, struct {
+		Action string
+		Values url.Values
+		Nonce  string
+	}{Action: target.String(), Values: values, Nonce: nonce})
+	if err != nil {
+		zap.L().Error("render form_post response", zap.Error(err))
+		h.oauthErrorRedirect(c, "server_error", "Failed to render response.")
+		return
+	}
+
+	// Framing stays allowed so prompt=none works from a hidden iframe.
+	c.Header("Content-Security-Policy", "default-src 'none'; script-src 'nonce-"+nonce+"'; form-action "+target.Scheme+"://"+target.Host+"; base-uri 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
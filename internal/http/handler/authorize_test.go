@@ -0,0 +1,129 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	domainoauth "github.com/smallbiznis/railzway-auth/internal/domain/oauth"
+	httpHandler "github.com/smallbiznis/railzway-auth/internal/http/handler"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestAuthorizePromptNone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := httpHandler.NewAuthHandler(config.Config{}, newTestAuthService(t), nil, &service.DiscoveryService{}, nil, nil, nil)
+	authorize := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "https://tenant.smallbiznis/oauth/authorize?client_id=client&redirect_uri=https://tenant/callback&state=abc&"+query, nil)
+		c.Set("orgContext", testOrgCtx())
+		handler.OAuthAuthorize(c)
+		return w
+	}
+
+	w := authorize("prompt=none")
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "tenant", location.Host)
+	require.Equal(t, "login_required", location.Query().Get("error"))
+	require.Equal(t, "abc", location.Query().Get("state"))
+
+	w = authorize("prompt=none&response_mode=fragment")
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err = url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Empty(t, location.RawQuery)
+	fragment, err := url.ParseQuery(location.Fragment)
+	require.NoError(t, err)
+	require.Equal(t, "login_required", fragment.Get("error"))
+
+	w = authorize("prompt=none&response_mode=form_post")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `action="https://tenant/callback"`)
+	require.Contains(t, w.Body.String(), `name="error" value="login_required"`)
+	require.Contains(t, w.Header().Get("Content-Security-Policy"), "form-action https://tenant")
+
+	w = authorize("prompt=none%20login")
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err = url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/error/oauth", location.Path, "invalid requests are not sent to the client")
+	require.Equal(t, "invalid_request", location.Query().Get("error"))
+}
+
+func TestAuthorizeLoginKeepsParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAuthorizeStateStore{items: map[string]domainoauth.AuthorizeState{}}
+	handler := httpHandler.NewAuthHandler(config.Config{}, newTestAuthService(t), nil, &service.DiscoveryService{}, nil, store, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "https://tenant.smallbiznis/oauth/authorize?client_id=client&redirect_uri=https://tenant/callback&prompt=login&max_age=60&login_hint=jane@tenant&ui_locales=id%20en&response_mode=form_post", nil)
+	c.Set("orgContext", testOrgCtx())
+	handler.OAuthAuthorize(c)
+
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/login", location.Path)
+	require.Equal(t, "jane@tenant", location.Query().Get("login_hint"))
+	require.Equal(t, "id en", location.Query().Get("ui_locales"))
+
+	state, ok := store.items["oauth:authorize:"+location.Query().Get("state")]
+	require.True(t, ok)
+	require.Equal(t, "form_post", state.ResponseMode)
+	require.Equal(t, "login", state.Prompt)
+	require.NotNil(t, state.MaxAge)
+	require.Equal(t, int64(60), *state.MaxAge)
+	require.Equal(t, "jane@tenant", state.LoginHint)
+	require.Equal(t, "id en", state.UILocales)
+}
+
+type memoryAuthorizeStateStore struct {
+	items  map[string]domainoauth.AuthorizeState
+	pushed map[string]domainoauth.PushedAuthorizationRequest
+}
+
+func (m *memoryAuthorizeStateStore) SaveState(_ context.Context, key string, data domainoauth.AuthorizeState, _ time.Duration) error {
+	m.items[key] = data
+	return nil
+}
+
+func (m *memoryAuthorizeStateStore) GetState(_ context.Context, key string) (*domainoauth.AuthorizeState, error) {
+	state, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (m *memoryAuthorizeStateStore) DeleteState(_ context.Context, key string) error {
+	delete(m.items, key)
+	return nil
+}
+
+func (m *memoryAuthorizeStateStore) SavePushedRequest(_ context.Context, key string, data domainoauth.PushedAuthorizationRequest, _ time.Duration) error {
+	if m.pushed == nil {
+		m.pushed = map[string]domainoauth.PushedAuthorizationRequest{}
+	}
+	m.pushed[key] = data
+	return nil
+}
+
+func (m *memoryAuthorizeStateStore) TakePushedRequest(_ context.Context, key string) (*domainoauth.PushedAuthorizationRequest, error) {
+	req, ok := m.pushed[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(m.pushed, key)
+	return &req, nil
+}
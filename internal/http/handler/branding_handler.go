@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/branding"
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+)
+
+// Branding returns the org's sanitized branding for the login UI. Custom JS
+// is not included; it is only injected into index.html.
+func (h *AuthHandler) Branding(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	c.JSON(http.StatusOK, branding.NewPage(orgCtx.Branding))
+}
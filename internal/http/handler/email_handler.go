@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// EmailVerify confirms the address from the link in a verification email and
+// redirects to the hosted result page.
+func (h *AuthHandler) EmailVerify(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	if err := h.Auth.VerifyEmail(c.Request.Context(), orgCtx.Org.ID, c.Query("token")); err != nil {
+		code, desc := "server_error", "Email address could not be verified."
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			code, desc = oauthErr.Code, oauthErr.Description
+		}
+		c.Redirect(http.StatusFound, "/error?"+url.Values{"error": {code}, "error_description": {desc}}.Encode())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/email-verified")
+}
+
+// EmailVerifyResend sends another verification email. The response is the
+// same whether or not the address belongs to an unverified account.
+func (h *AuthHandler) EmailVerifyResend(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+	if strings.TrimSpace(req.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Email is required."})
+		return
+	}
+
+	issuer := h.issuer(c)
+	if err := h.Auth.ResendEmailVerification(c.Request.Context(), orgCtx.Org.ID, req.Email, issuer); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account needs verification, a new email has been sent."})
+}
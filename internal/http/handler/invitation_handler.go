@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// PreviewInvitation describes the invitation in the token query parameter
+// for the accept page.
+func (h *AuthHandler) PreviewInvitation(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "token is required."})
+		return
+	}
+
+	preview, err := h.Members.PreviewInvitation(c.Request.Context(), orgCtx, token)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, preview)
+}
+
+// AcceptInvitation joins the org, creating the account first when the
+// invitee has none.
+func (h *AuthHandler) AcceptInvitation(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req service.AcceptInvitationInput
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "token is required."})
+		return
+	}
+
+	accepted, err := h.Members.AcceptInvitation(c.Request.Context(), orgCtx, req)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, accepted)
+}
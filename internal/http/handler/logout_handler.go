@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+type endSessionRequest struct {
+	IDTokenHint           string `form:"id_token_hint"`
+	PostLogoutRedirectURI string `form:"post_logout_redirect_uri"`
+	ClientID              string `form:"client_id"`
+	State                 string `form:"state"`
+}
+
+// EndSession implements OIDC RP-Initiated Logout. It signs the browser out
+// and returns it to a registered post_logout_redirect_uri, or to the login
+// page when the client named none.
+func (h *AuthHandler) EndSession(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		h.oauthErrorRedirect(c, "invalid_tenant", "Org could not be resolved.")
+		return
+	}
+
+	var req endSessionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.oauthErrorRedirect(c, "invalid_request", "Invalid logout request.")
+		return
+	}
+
+	sessionToken, _ := c.Cookie(CookieNameSession)
+	refreshToken, _ := c.Cookie(CookieNameRefreshToken)
+	redirectURI, err := h.Auth.Logout(c.Request.Context(), orgCtx, service.LogoutRequest{
+		SessionToken:          sessionToken,
+		RefreshToken:          refreshToken,
+		IDTokenHint:           req.IDTokenHint,
+		ClientID:              req.ClientID,
+		PostLogoutRedirectURI: req.PostLogoutRedirectURI,
+		Issuer:                h.issuer(c),
+	})
+	if err != nil {
+		if oauthErr, ok := err.(*service.OAuthError); ok {
+			h.oauthErrorRedirect(c, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		h.oauthErrorRedirect(c, "server_error", "Failed to sign out.")
+		return
+	}
+	h.clearSessionCookies(c)
+
+	if redirectURI == "" {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		h.oauthErrorRedirect(c, "invalid_request", "post_logout_redirect_uri is invalid.")
+		return
+	}
+	if state := strings.TrimSpace(req.State); state != "" {
+		q := target.Query()
+		q.Set("state", state)
+		target.RawQuery = q.Encode()
+	}
+	c.Redirect(http.StatusFound, target.String())
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+const (
+	// CookieNameMagicLinkNonce binds an emailed sign-in link to the browser
+	// that requested it.
+	CookieNameMagicLinkNonce = "_magic_link_nonce"
+	magicLinkCookiePath      = "/auth/magic-link"
+)
+
+// MagicLinkRequest emails a single-use sign-in link. The response is the same
+// whether or not the address belongs to an account.
+func (h *AuthHandler) MagicLinkRequest(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		Email    string `json:"email"`
+		ClientID string `json:"client_id"`
+		Scope    string `json:"scope"`
+		// Optional: when provided, the link resumes the OAuth authorize flow.
+		State string `json:"state"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+	if strings.TrimSpace(req.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Email is required."})
+		return
+	}
+
+	authorizeStateID := strings.TrimSpace(req.State)
+	authorizeState, err := h.loadAuthorizeState(c, authorizeStateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	clientID := strings.TrimSpace(req.ClientID)
+	if authorizeState != nil {
+		if clientID != "" && clientID != authorizeState.ClientID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "client_id does not match authorize state."})
+			return
+		}
+		clientID = authorizeState.ClientID
+	}
+	if clientID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "Unknown client_id for org."})
+		return
+	}
+
+	issuer := h.issuer(c)
+	nonce, err := h.Auth.RequestMagicLink(c.Request.Context(), orgCtx.Org.ID, req.Email, clientID, strings.TrimSpace(req.Scope), issuer, authorizeStateID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	h.setMagicLinkNonceCookie(c, nonce, int(h.Config.MagicLinkTTL.Seconds()))
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a sign-in link has been sent."})
+}
+
+// MagicLinkVerify redeems the link opened from the email, sets the session
+// cookies and continues the authorize flow that was pending when the link was
+// requested. Errors are shown on the error page since this is a navigation.
+func (h *AuthHandler) MagicLinkVerify(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	nonce, _ := c.Cookie(CookieNameMagicLinkNonce)
+	resp, authorizeStateID, err := h.Auth.VerifyMagicLink(c.Request.Context(), orgCtx.Org.ID, c.Query("token"), nonce)
+	if err != nil {
+		var mfaErr *service.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			h.setMagicLinkNonceCookie(c, "", -1)
+			c.Redirect(http.StatusFound, mfaChallengeRedirect(authorizeStateID, mfaErr))
+			return
+		}
+		code, desc := "server_error", "Sign-in link could not be verified."
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			code, desc = oauthErr.Code, oauthErr.Description
+		}
+		c.Redirect(http.StatusFound, "/error?"+url.Values{"error": {code}, "error_description": {desc}}.Encode())
+		return
+	}
+	if err := h.startSession(c, orgCtx, resp.User.ID, resp.AMR); err != nil {
+		c.Redirect(http.StatusFound, "/error?"+url.Values{"error": {"server_error"}, "error_description": {"Session could not be started."}}.Encode())
+		return
+	}
+
+	maxAge := 3600
+	h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
+	h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
+	h.setMagicLinkNonceCookie(c, "", -1)
+
+	redirect := "/"
+	if authorizeStateID != "" {
+		if authorizeState, err := h.loadAuthorizeState(c, authorizeStateID); err == nil && authorizeState != nil {
+			if authorizeURL := h.authorizeURLFromState(c, authorizeState); authorizeURL != "" {
+				redirect = authorizeURL
+			}
+			h.deleteAuthorizeState(c, authorizeStateID)
+		}
+	}
+	c.Redirect(http.StatusFound, redirect)
+}
+
+func (h *AuthHandler) setMagicLinkNonceCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CookieNameMagicLinkNonce, value, maxAge, magicLinkCookiePath, c.Request.Host, h.Config.AuthCookieSecure, true)
+}
+
+// mfaChallengeRedirect sends the browser to the MFA page. The challenge goes
+// in the fragment so it never reaches server logs; the page moves it into
+// session storage.
+func mfaChallengeRedirect(authorizeStateID string, mfaErr *service.MFARequiredError) string {
+	target := "/mfa"
+	if authorizeStateID != "" {
+		target += "?" + url.Values{"state": {authorizeStateID}}.Encode()
+	}
+	fragment := url.Values{
+		"mfa_token":           {mfaErr.MFAToken},
+		"enrollment_required": {strconv.FormatBool(mfaErr.EnrollmentRequired)},
+		"factors":             {strings.Join(mfaErr.Factors, ",")},
+	}
+	return target + "#" + fragment.Encode()
+}
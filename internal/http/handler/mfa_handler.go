@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+)
+
+// MFAStatus reports the org MFA policy and the caller's enrolled factors.
+func (h *AuthHandler) MFAStatus(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.Auth.MFAStatus(c.Request.Context(), orgCtx, userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// MFAEnrollTOTP starts authenticator enrollment for a signed-in user.
+func (h *AuthHandler) MFAEnrollTOTP(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.Auth.EnrollTOTP(c.Request.Context(), orgCtx, userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// MFAConfirmTOTP activates a pending authenticator and returns recovery codes.
+func (h *AuthHandler) MFAConfirmTOTP(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Code is required."})
+		return
+	}
+
+	codes, err := h.Auth.ConfirmTOTP(c.Request.Context(), orgCtx, userID, req.Code)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// MFADisableTOTP removes the caller's authenticator.
+func (h *AuthHandler) MFADisableTOTP(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Code is required."})
+		return
+	}
+
+	if err := h.Auth.DisableTOTP(c.Request.Context(), orgCtx, userID, req.Code); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MFARegenerateRecoveryCodes replaces the caller's recovery codes.
+func (h *AuthHandler) MFARegenerateRecoveryCodes(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.Auth.RegenerateRecoveryCodes(c.Request.Context(), orgCtx, userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// MFAChallengeEnroll starts authenticator enrollment mid-login when the org
+// requires MFA and the user has no factor yet.
+func (h *AuthHandler) MFAChallengeEnroll(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.MFAToken) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "mfa_token is required."})
+		return
+	}
+
+	userID, err := h.Auth.ChallengeUserID(c.Request.Context(), orgCtx, req.MFAToken)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	enrollment, err := h.Auth.EnrollTOTP(c.Request.Context(), orgCtx, userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// MFAChallengeVerify completes a login that returned mfa_required.
+func (h *AuthHandler) MFAChallengeVerify(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+		// Optional: when provided, continue OAuth authorize flow using stored state.
+		State string `json:"state"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+	if strings.TrimSpace(req.MFAToken) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "mfa_token is required."})
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" && strings.TrimSpace(req.RecoveryCode) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "code or recovery_code is required."})
+		return
+	}
+
+	authorizeStateID := strings.TrimSpace(req.State)
+	authorizeState, err := h.loadAuthorizeState(c, authorizeStateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	resp, err := h.Auth.VerifyMFAChallenge(c.Request.Context(), orgCtx.Org.ID, req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	if err := h.startSession(c, orgCtx, resp.User.ID, resp.AMR); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	maxAge := 3600
+	h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
+	h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
+
+	authorizeURL := ""
+	if authorizeState != nil {
+		authorizeURL = h.authorizeURLFromState(c, authorizeState)
+		h.deleteAuthorizeState(c, authorizeStateID)
+	}
+
+	c.JSON(http.StatusOK, authResponse{AuthTokensWithUser: resp, AuthorizeURL: authorizeURL, Orgs: h.authorizeOrgChoices(c, orgCtx, authorizeState, resp.User.ID)})
+}
+
+// userIDFromClaims reads the numeric subject set by the JWT middleware and
+// writes an error response when it is missing.
+func userIDFromClaims(c *gin.Context) (int64, bool) {
+	std, ok := middleware.GetStdClaims(c)
+	if !ok || std == nil || strings.TrimSpace(std.Subject) == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "Missing subject claim."})
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(std.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "Invalid subject claim."})
+		return 0, false
+	}
+	return userID, true
+}
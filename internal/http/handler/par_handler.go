@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	domainoauth "github.com/smallbiznis/railzway-auth/internal/domain/oauth"
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+const (
+	pushedRequestPrefix = "oauth:par:"
+	pushedRequestTTL    = 90 * time.Second
+	requestURIPrefix    = "urn:ietf:params:oauth:request_uri:"
+)
+
+// PushedAuthorization registers an authorize request for an authenticated
+// client and returns the request_uri to send to /oauth/authorize (RFC 9126).
+func (h *AuthHandler) PushedAuthorization(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	if h.AuthorizeStateStore == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "Authorize state store not configured."})
+		return
+	}
+	if err := c.Request.ParseForm(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid pushed authorization request."})
+		return
+	}
+
+	form := url.Values{}
+	for name, values := range c.Request.PostForm {
+		form[name] = values
+	}
+	clientID, clientSecret := clientCredentials(c.Request, form.Get("client_id"), form.Get("client_secret"))
+	form.Del("client_secret")
+
+	ctx := c.Request.Context()
+	client, err := h.Auth.AuthenticateClient(ctx, orgCtx.Org.ID, clientID, clientSecret)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	if form.Get("request_uri") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "request_uri is not allowed in a pushed authorization request."})
+		return
+	}
+
+	values := form
+	if requestObject := form.Get("request"); requestObject != "" {
+		issuer := h.issuer(c)
+		values, err = h.Auth.VerifyRequestObject(ctx, orgCtx.Org.ID, client.ClientID, requestObject, issuer)
+		if err != nil {
+			respondOAuthError(c, err)
+			return
+		}
+	} else if formClient := strings.TrimSpace(form.Get("client_id")); formClient != "" && formClient != client.ClientID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "client_id does not match the authenticated client."})
+		return
+	}
+	values.Set("client_id", client.ClientID)
+
+	req, err := authorizeRequestFromValues(values)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid pushed authorization request."})
+		return
+	}
+	if _, oauthErr := h.normalizeAuthorizeRequest(ctx, orgCtx.Org.ID, req); oauthErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": oauthErr.code, "error_description": oauthErr.description})
+		return
+	}
+
+	requestURI, err := h.savePushedRequest(ctx, orgCtx.Org.ID, client.ClientID, values)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "Failed to store pushed authorization request."})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, gin.H{
+		"request_uri": requestURI,
+		"expires_in":  int(pushedRequestTTL.Seconds()),
+	})
+}
+
+// resolveAuthorizeRequest expands request_uri and request parameters into
+// the authorize request they carry. pushed reports whether the parameters
+// came from PAR or a signed request object.
+func (h *AuthHandler) resolveAuthorizeRequest(c *gin.Context, orgCtx *org.Context, req oauthAuthorizeRequest) (resolved oauthAuthorizeRequest, pushed bool, oauthErr *oauthAuthorizeError) {
+	ctx := c.Request.Context()
+	clientID := strings.TrimSpace(req.ClientID)
+	requestURI := strings.TrimSpace(req.RequestURI)
+
+	if requestURI != "" {
+		if req.Request != "" {
+			return req, false, newOAuthAuthorizeError("invalid_request", "request and request_uri cannot be used together.")
+		}
+		if clientID == "" {
+			return req, false, newOAuthAuthorizeError("invalid_request", "client_id is required.")
+		}
+		if !strings.HasPrefix(requestURI, requestURIPrefix) {
+			return req, false, newOAuthAuthorizeError("invalid_request_uri", "Only request_uri values from the pushed authorization request endpoint are supported.")
+		}
+		if h.AuthorizeStateStore == nil {
+			return req, false, newOAuthAuthorizeError("server_error", "Authorize state store not configured.")
+		}
+		stored, err := h.AuthorizeStateStore.TakePushedRequest(ctx, pushedRequestPrefix+strings.TrimPrefix(requestURI, requestURIPrefix))
+		if err != nil {
+			return req, false, newOAuthAuthorizeError("server_error", "Failed to load pushed authorization request.")
+		}
+		if stored == nil || stored.OrgID != orgCtx.Org.ID || stored.ClientID != clientID {
+			return req, false, newOAuthAuthorizeError("invalid_request_uri", "request_uri is invalid or expired.")
+		}
+		resolved, err := authorizeRequestFromValues(stored.Params)
+		if err != nil {
+			return req, false, newOAuthAuthorizeError("invalid_request_uri", "request_uri is invalid or expired.")
+		}
+		return resolved, true, nil
+	}
+
+	if h.Auth.ClientRequiresPAR(ctx, orgCtx.Org.ID, clientID) {
+		return req, false, newOAuthAuthorizeError("invalid_request", "This client must use pushed authorization requests.")
+	}
+	if req.Request == "" {
+		return req, false, nil
+	}
+
+	issuer := h.issuer(c)
+	values, err := h.Auth.VerifyRequestObject(ctx, orgCtx.Org.ID, clientID, req.Request, issuer)
+	if err != nil {
+		var svcErr *service.OAuthError
+		if errors.As(err, &svcErr) {
+			return req, false, newOAuthAuthorizeError(svcErr.Code, svcErr.Description)
+		}
+		return req, false, newOAuthAuthorizeError("server_error", "Failed to verify request object.")
+	}
+	resolved, err = authorizeRequestFromValues(values)
+	if err != nil {
+		return req, false, newOAuthAuthorizeError("invalid_request_object", "Request object is invalid.")
+	}
+	return resolved, true, nil
+}
+
+// savePushedRequest stores authorize parameters for one-time use and returns
+// the request_uri that redeems them.
+func (h *AuthHandler) savePushedRequest(ctx context.Context, orgID int64, clientID string, values url.Values) (string, error) {
+	if h.AuthorizeStateStore == nil {
+		return "", fmt.Errorf("authorize state store missing")
+	}
+	id, err := secureRandomString(32)
+	if err != nil {
+		return "", fmt.Errorf("generate request_uri: %w", err)
+	}
+	payload := domainoauth.PushedAuthorizationRequest{
+		OrgID:     orgID,
+		ClientID:  clientID,
+		Params:    values,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.AuthorizeStateStore.SavePushedRequest(ctx, pushedRequestPrefix+id, payload, pushedRequestTTL); err != nil {
+		return "", fmt.Errorf("persist pushed request: %w", err)
+	}
+	return requestURIPrefix + id, nil
+}
+
+// authorizeRequestFromValues binds authorize parameters taken from a pushed
+// request or request object.
+func authorizeRequestFromValues(values map[string][]string) (oauthAuthorizeRequest, error) {
+	var req oauthAuthorizeRequest
+	if err := binding.MapFormWithTag(&req, values, "form"); err != nil {
+		return oauthAuthorizeRequest{}, err
+	}
+	req.Request, req.RequestURI = "", ""
+	return req, nil
+}
@@ -0,0 +1,175 @@
+package handler_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gin-gonic/gin"
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	domainoauth "github.com/smallbiznis/railzway-auth/internal/domain/oauth"
+	httpHandler "github.com/smallbiznis/railzway-auth/internal/http/handler"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestPushedAuthorizationRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAuthorizeStateStore{items: map[string]domainoauth.AuthorizeState{}}
+	handler := httpHandler.NewAuthHandler(config.Config{}, newPARTestAuthService(t, domain.OAuthClient{RequirePAR: true}), nil, &service.DiscoveryService{}, nil, store, nil)
+
+	push := func(secret string) *httptest.ResponseRecorder {
+		form := url.Values{}
+		form.Set("redirect_uri", "https://tenant/callback")
+		form.Set("state", "abc")
+		form.Set("prompt", "none")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "https://tenant.smallbiznis/oauth/par", strings.NewReader(form.Encode()))
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		c.Request.SetBasicAuth("client", secret)
+		c.Set("orgContext", testOrgCtx())
+		handler.PushedAuthorization(c)
+		return w
+	}
+
+	w := push("wrong")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = push("secret")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pushed struct {
+		RequestURI string `json:"request_uri"`
+		ExpiresIn  int    `json:"expires_in"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pushed))
+	require.True(t, strings.HasPrefix(pushed.RequestURI, "urn:ietf:params:oauth:request_uri:"))
+	require.Positive(t, pushed.ExpiresIn)
+
+	redeem := url.Values{"client_id": {"client"}, "request_uri": {pushed.RequestURI}}
+	location := authorizeLocation(t, handler, redeem.Encode())
+	require.Equal(t, "tenant", location.Host)
+	require.Equal(t, "login_required", location.Query().Get("error"))
+	require.Equal(t, "abc", location.Query().Get("state"))
+
+	location = authorizeLocation(t, handler, redeem.Encode())
+	require.Equal(t, "/error/oauth", location.Path, "a request_uri is used once")
+	require.Equal(t, "invalid_request_uri", location.Query().Get("error"))
+
+	location = authorizeLocation(t, handler, "client_id=client&redirect_uri=https://tenant/callback&state=abc")
+	require.Equal(t, "/error/oauth", location.Path, "clients that require PAR cannot send plain requests")
+	require.Equal(t, "invalid_request", location.Query().Get("error"))
+}
+
+func TestAuthorizeRequestObject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(gojose.JSONWebKeySet{Keys: []gojose.JSONWebKey{{Key: &key.PublicKey, KeyID: "k1", Algorithm: string(gojose.ES256), Use: "sig"}}})
+	require.NoError(t, err)
+	store := &memoryAuthorizeStateStore{items: map[string]domainoauth.AuthorizeState{}}
+	handler := httpHandler.NewAuthHandler(config.Config{}, newPARTestAuthService(t, domain.OAuthClient{JWKS: string(jwks)}), nil, &service.DiscoveryService{}, nil, store, nil)
+
+	sign := func(signer *ecdsa.PrivateKey, claims map[string]any) string {
+		s, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.ES256, Key: signer}, (&gojose.SignerOptions{}).WithHeader("kid", "k1"))
+		require.NoError(t, err)
+		token, err := gojwt.Signed(s).Claims(claims).Serialize()
+		require.NoError(t, err)
+		return token
+	}
+	claims := map[string]any{
+		"iss":          "client",
+		"aud":          "https://tenant.smallbiznis",
+		"exp":          time.Now().Add(time.Minute).Unix(),
+		"redirect_uri": "https://tenant/callback",
+		"state":        "signed",
+		"prompt":       "none",
+	}
+
+	query := url.Values{"client_id": {"client"}, "request": {sign(key, claims)}, "state": {"unsigned"}}
+	location := authorizeLocation(t, handler, query.Encode())
+	require.Equal(t, "tenant", location.Host)
+	require.Equal(t, "login_required", location.Query().Get("error"))
+	require.Equal(t, "signed", location.Query().Get("state"), "only request object parameters are used")
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	query.Set("request", sign(other, claims))
+	location = authorizeLocation(t, handler, query.Encode())
+	require.Equal(t, "/error/oauth", location.Path)
+	require.Equal(t, "invalid_request_object", location.Query().Get("error"))
+
+	delete(claims, "prompt")
+	query.Set("request", sign(key, claims))
+	location = authorizeLocation(t, handler, query.Encode())
+	require.Equal(t, "/login", location.Path)
+	state, ok := store.items["oauth:authorize:"+location.Query().Get("state")]
+	require.True(t, ok)
+	require.True(t, state.PushedRequest)
+}
+
+func authorizeLocation(t *testing.T, handler *httpHandler.AuthHandler, query string) *url.URL {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "https://tenant.smallbiznis/oauth/authorize?"+query, nil)
+	c.Set("orgContext", testOrgCtx())
+	handler.OAuthAuthorize(c)
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	return location
+}
+
+// newPARTestAuthService serves a confidential client with secret "secret"
+// and the given request object settings.
+func newPARTestAuthService(t *testing.T, client domain.OAuthClient) *service.AuthService {
+	keyRepo := &inMemoryKeyRepo{}
+	keyManager := jwt.NewKeyManager(keyRepo)
+	generator := jwt.NewGenerator(keyManager, time.Minute)
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	node, _ := snowflake.NewNode(1)
+	clients := &parClientRepo{client: client}
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &noopUserRepo{},
+		Tokens:    &noopTokenRepo{},
+		Codes:     &noopCodeRepo{},
+		Clients:   clients,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	return authService
+}
+
+type parClientRepo struct {
+	noopClientRepo
+	client domain.OAuthClient
+}
+
+func (r *parClientRepo) GetClientByID(ctx context.Context, orgID int64, clientID string) (domain.OAuthClient, error) {
+	client := r.client
+	client.OrgID = orgID
+	client.ClientID = clientID
+	client.ClientSecret = "secret"
+	client.RedirectURIs = []string{"https://tenant/callback"}
+	return client, nil
+}
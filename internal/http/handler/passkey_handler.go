@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+)
+
+// passkeyFinishRequest is the common payload used to complete a WebAuthn
+// ceremony. Credential is the PublicKeyCredential serialized by the browser.
+type passkeyFinishRequest struct {
+	SessionID  string          `json:"session_id"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (r passkeyFinishRequest) valid() bool {
+	return strings.TrimSpace(r.SessionID) != "" && len(r.Credential) > 0
+}
+
+// PasskeyList returns the caller's registered passkeys.
+func (h *AuthHandler) PasskeyList(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	passkeys, err := h.Auth.ListPasskeys(c.Request.Context(), orgCtx, userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"passkeys": passkeys})
+}
+
+// PasskeyDelete removes one of the caller's passkeys.
+func (h *AuthHandler) PasskeyDelete(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+	credentialID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid passkey id."})
+		return
+	}
+
+	if err := h.Auth.DeletePasskey(c.Request.Context(), orgCtx, userID, credentialID); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PasskeyRegisterBegin returns credential creation options for a signed-in user.
+func (h *AuthHandler) PasskeyRegisterBegin(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	options, err := h.Auth.BeginPasskeyRegistration(c.Request.Context(), orgCtx, userID, h.requestOrigin(c))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// PasskeyRegisterFinish verifies the attestation and stores the passkey.
+func (h *AuthHandler) PasskeyRegisterFinish(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		passkeyFinishRequest
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !req.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "session_id and credential are required."})
+		return
+	}
+
+	passkey, err := h.Auth.FinishPasskeyRegistration(c.Request.Context(), orgCtx, userID, req.SessionID, req.Name, h.requestOrigin(c), req.Credential)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, passkey)
+}
+
+// PasskeyLoginBegin returns assertion options for a discoverable passkey login.
+func (h *AuthHandler) PasskeyLoginBegin(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	options, err := h.Auth.BeginPasskeyLogin(c.Request.Context(), orgCtx, h.requestOrigin(c))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// PasskeyLoginFinish verifies a passkey assertion and signs the user in.
+func (h *AuthHandler) PasskeyLoginFinish(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		passkeyFinishRequest
+		ClientID string `json:"client_id"`
+		Scope    string `json:"scope"`
+		// Optional: when provided, continue OAuth authorize flow using stored state.
+		State string `json:"state"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !req.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "session_id and credential are required."})
+		return
+	}
+
+	authorizeStateID := strings.TrimSpace(req.State)
+	authorizeState, err := h.loadAuthorizeState(c, authorizeStateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	clientID := strings.TrimSpace(req.ClientID)
+	if authorizeState != nil {
+		if clientID != "" && clientID != authorizeState.ClientID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "client_id does not match authorize state."})
+			return
+		}
+		clientID = authorizeState.ClientID
+	}
+	if clientID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "Unknown client_id for org."})
+		return
+	}
+
+	issuer := h.issuer(c)
+	resp, err := h.Auth.FinishPasskeyLogin(c.Request.Context(), orgCtx.Org.ID, req.SessionID, clientID, strings.TrimSpace(req.Scope), issuer, h.requestOrigin(c), req.Credential)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	if err := h.startSession(c, orgCtx, resp.User.ID, resp.AMR); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	maxAge := 3600
+	h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
+	h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
+
+	authorizeURL := ""
+	if authorizeState != nil {
+		authorizeURL = h.authorizeURLFromState(c, authorizeState)
+		h.deleteAuthorizeState(c, authorizeStateID)
+	}
+
+	c.JSON(http.StatusOK, authResponse{AuthTokensWithUser: resp, AuthorizeURL: authorizeURL, Orgs: h.authorizeOrgChoices(c, orgCtx, authorizeState, resp.User.ID)})
+}
+
+// MFAChallengePasskeyBegin returns assertion options for answering an MFA
+// challenge with a registered passkey.
+func (h *AuthHandler) MFAChallengePasskeyBegin(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.MFAToken) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "mfa_token is required."})
+		return
+	}
+
+	options, err := h.Auth.BeginPasskeyMFA(c.Request.Context(), orgCtx, req.MFAToken, h.requestOrigin(c))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// MFAChallengePasskeyFinish completes a login that returned mfa_required using a passkey.
+func (h *AuthHandler) MFAChallengePasskeyFinish(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		passkeyFinishRequest
+		MFAToken string `json:"mfa_token"`
+		// Optional: when provided, continue OAuth authorize flow using stored state.
+		State string `json:"state"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !req.valid() || strings.TrimSpace(req.MFAToken) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "mfa_token, session_id and credential are required."})
+		return
+	}
+
+	authorizeStateID := strings.TrimSpace(req.State)
+	authorizeState, err := h.loadAuthorizeState(c, authorizeStateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	resp, err := h.Auth.VerifyMFAPasskey(c.Request.Context(), orgCtx.Org.ID, req.MFAToken, req.SessionID, h.requestOrigin(c), req.Credential)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	if err := h.startSession(c, orgCtx, resp.User.ID, resp.AMR); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	maxAge := 3600
+	h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
+	h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
+
+	authorizeURL := ""
+	if authorizeState != nil {
+		authorizeURL = h.authorizeURLFromState(c, authorizeState)
+		h.deleteAuthorizeState(c, authorizeStateID)
+	}
+
+	c.JSON(http.StatusOK, authResponse{AuthTokensWithUser: resp, AuthorizeURL: authorizeURL, Orgs: h.authorizeOrgChoices(c, orgCtx, authorizeState, resp.User.ID)})
+}
+
+// requestOrigin returns the browser Origin header, falling back to the
+// request scheme and host.
+func (h *AuthHandler) requestOrigin(c *gin.Context) string {
+	if origin := strings.TrimSpace(c.GetHeader("Origin")); origin != "" && origin != "null" {
+		return origin
+	}
+	return h.Issuers.BaseURL(c.Request)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// CookieNameSession holds the opaque browser session token used for SSO at
+// /oauth/authorize.
+const CookieNameSession = "_session"
+
+// SessionList returns the caller's active browser sessions.
+func (h *AuthHandler) SessionList(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	current, _ := c.Cookie(CookieNameSession)
+	sessions, err := h.Auth.ListSessions(c.Request.Context(), orgCtx, userID, current)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// SessionRevoke signs out one of the caller's sessions.
+func (h *AuthHandler) SessionRevoke(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid session id."})
+		return
+	}
+
+	if err := h.Auth.RevokeSession(c.Request.Context(), orgCtx, userID, sessionID); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Logout ends the calling browser's session, revokes its refresh token and
+// clears the auth cookies.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	sessionToken, _ := c.Cookie(CookieNameSession)
+	refreshToken, _ := c.Cookie(CookieNameRefreshToken)
+	_, err := h.Auth.Logout(c.Request.Context(), orgCtx, service.LogoutRequest{
+		SessionToken: sessionToken,
+		RefreshToken: refreshToken,
+		Issuer:       h.issuer(c),
+	})
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	h.clearSessionCookies(c)
+	c.Status(http.StatusNoContent)
+}
+
+// startSession opens a browser session for a user who just signed in and sets
+// the session cookie alongside the token cookies.
+func (h *AuthHandler) startSession(c *gin.Context, orgCtx *org.Context, userID int64, amr []string) error {
+	token, expiresAt, err := h.Auth.CreateSession(c.Request.Context(), orgCtx, userID, amr, c.Request.UserAgent(), h.Issuers.ClientIP(c.Request))
+	if err != nil {
+		return err
+	}
+	h.setCookie(c, CookieNameSession, token, int(time.Until(expiresAt).Seconds()))
+	return nil
+}
+
+func (h *AuthHandler) clearSessionCookies(c *gin.Context) {
+	h.setCookie(c, CookieNameSession, "", -1)
+	h.setCookie(c, CookieNameAccessToken, "", -1)
+	h.setCookie(c, CookieNameRefreshToken, "", -1)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+)
+
+// UserOrgList returns the orgs the caller can act in.
+func (h *AuthHandler) UserOrgList(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	var currentOrgID int64
+	if claims, ok := middleware.GetAccessClaims(c); ok {
+		currentOrgID = claims.OrgID
+	}
+	orgs, err := h.Auth.ListUserOrgs(c.Request.Context(), orgCtx, userID, currentOrgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"orgs": orgs})
+}
+
+// UserOrgSwitch exchanges a refresh token for tokens acting in another org.
+// Browsers may omit refresh_token and rely on the refresh token cookie, which
+// is then replaced along with the access token cookie.
+func (h *AuthHandler) UserOrgSwitch(c *gin.Context) {
+	orgCtx, ok := middleware.GetOrgContext(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Org not resolved."})
+		return
+	}
+
+	var req struct {
+		OrgID        json.Number `json:"org_id"`
+		RefreshToken string      `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "Invalid payload."})
+		return
+	}
+	activeOrgID, err := strconv.ParseInt(strings.TrimSpace(req.OrgID.String()), 10, 64)
+	if err != nil || activeOrgID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "org_id is required."})
+		return
+	}
+
+	refreshToken := strings.TrimSpace(req.RefreshToken)
+	fromCookie := false
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(CookieNameRefreshToken)
+		fromCookie = refreshToken != ""
+	}
+	if refreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "refresh_token is required."})
+		return
+	}
+
+	issuer := h.issuer(c)
+	resp, err := h.Auth.SwitchOrg(c.Request.Context(), orgCtx, refreshToken, "", issuer, activeOrgID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	if fromCookie {
+		maxAge := 3600
+		h.setCookie(c, CookieNameAccessToken, resp.AccessToken, maxAge)
+		h.setCookie(c, CookieNameRefreshToken, resp.RefreshToken, maxAge)
+	}
+	c.JSON(http.StatusOK, resp)
+}
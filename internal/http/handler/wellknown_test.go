@@ -2,6 +2,7 @@ package handler_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,8 +28,8 @@ import (
 func TestJWKSHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	orgCtx := testOrgCtx()
-	authSvc := newTestAuthService()
-	handler := httpHandler.NewAuthHandler(authSvc, nil, &service.DiscoveryService{}, nil)
+	authSvc := newTestAuthService(t)
+	handler := httpHandler.NewAuthHandler(config.Config{}, authSvc, nil, &service.DiscoveryService{}, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "https://tenant.smallbiznis/.well-known/jwks.json", nil)
 	w := httptest.NewRecorder()
@@ -51,7 +52,7 @@ func TestJWKSHandler(t *testing.T) {
 func TestOpenIDConfigurationResponse(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	orgCtx := testOrgCtx()
-	handler := httpHandler.NewAuthHandler(newTestAuthService(), nil, &service.DiscoveryService{}, nil)
+	handler := httpHandler.NewAuthHandler(config.Config{}, newTestAuthService(t), nil, &service.DiscoveryService{}, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "https://tenant.smallbiznis/.well-known/openid-configuration", nil)
 	w := httptest.NewRecorder()
@@ -71,6 +72,39 @@ func TestOpenIDConfigurationResponse(t *testing.T) {
 	require.Contains(t, string(body), "jwks_uri")
 }
 
+func TestOrgDiscovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	orgCtx := testOrgCtx()
+	orgCtx.AuthProviders = append(orgCtx.AuthProviders,
+		domain.AuthProvider{OrgID: 1, ProviderType: "google", IsActive: true},
+		domain.AuthProvider{OrgID: 1, ProviderType: "github", IsActive: true},
+		domain.AuthProvider{OrgID: 1, ProviderType: "otp", IsActive: false},
+	)
+	orgCtx.SocialProviders = []domain.OAuthIDPConfig{{OrgID: 1, Provider: "google", ClientID: "id", ClientSecret: "secret"}}
+	handler := httpHandler.NewAuthHandler(config.Config{}, newTestAuthService(t), nil, &service.DiscoveryService{}, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "https://tenant.smallbiznis/auth/discovery", nil)
+	c.Set("orgContext", orgCtx)
+
+	// PrimaryColor is nil in testOrgCtx.
+	handler.OrgDiscovery(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body service.OrgDiscoveryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, "https://cdn/logo.png", body.Branding.LogoURL)
+	require.Equal(t, []service.DiscoveryProvider{
+		{Type: "password", Name: "Password", Enabled: true},
+		{Type: "google", Name: "Google", Enabled: true},
+	}, body.Providers, "inactive providers and social providers without an IdP config are hidden")
+	require.Equal(t, []service.SocialProviderButton{{Provider: "google", Name: "Google"}}, body.SocialProviders)
+	require.NotNil(t, body.Password)
+	require.Equal(t, 8, body.Password.MinLength)
+	require.NotContains(t, w.Body.String(), "secret")
+}
+
 func testOrgCtx() *org.Context {
 	return &org.Context{
 		Domain: domain.Domain{Host: "tenant.smallbiznis"},
@@ -83,14 +117,26 @@ func testOrgCtx() *org.Context {
 	}
 }
 
-func newTestAuthService() *service.AuthService {
+func newTestAuthService(t *testing.T) *service.AuthService {
 	keyRepo := &inMemoryKeyRepo{}
 	keyManager := jwt.NewKeyManager(keyRepo)
 	generator := jwt.NewGenerator(keyManager, time.Minute)
 	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
 	logger := zap.NewNop()
 	node, _ := snowflake.NewNode(1)
-	return service.NewAuthService(&noopUserRepo{}, &noopTokenRepo{}, &noopCodeRepo{}, &noopClientRepo{}, node, generator, keyManager, cfg, logger)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &noopUserRepo{},
+		Tokens:    &noopTokenRepo{},
+		Codes:     &noopCodeRepo{},
+		Clients:   &noopClientRepo{},
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    logger,
+	})
+	require.NoError(t, err)
+	return authService
 }
 
 type noopUserRepo struct{}
@@ -113,6 +159,10 @@ func (n *noopUserRepo) GetByEmail(ctx context.Context, orgID int64, email string
 	return domain.User{}, fmt.Errorf("not implemented")
 }
 
+func (n *noopUserRepo) GetVerifiedByEmail(ctx context.Context, email string) (domain.User, error) {
+	return domain.User{}, fmt.Errorf("not implemented")
+}
+
 func (n *noopUserRepo) GetByID(ctx context.Context, orgID, userID int64) (domain.User, error) {
 	return domain.User{}, fmt.Errorf("not implemented")
 }
@@ -121,6 +171,10 @@ func (n *noopUserRepo) Create(ctx context.Context, user domain.User) (domain.Use
 	return user, fmt.Errorf("not implemented")
 }
 
+func (n *noopUserRepo) MarkEmailVerified(ctx context.Context, orgID, userID int64) error {
+	return fmt.Errorf("not implemented")
+}
+
 func (n *noopTokenRepo) CreateToken(ctx context.Context, token domain.OAuthToken) (domain.OAuthToken, error) {
 	return token, nil
 }
@@ -137,12 +191,22 @@ func (n *noopTokenRepo) GetByAccessToken(ctx context.Context, token string) (dom
 	return domain.OAuthToken{}, fmt.Errorf("not implemented")
 }
 
-func (n *noopTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64) error {
+func (n *noopTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64, activeOrgID int64, accessToken string, accessExpiresAt int64) error {
 	return nil
 }
 
 func (n *noopTokenRepo) RevokeToken(ctx context.Context, tokenID int64) error { return nil }
 
+func (n *noopTokenRepo) RevokeUserTokens(ctx context.Context, orgID, userID int64) error { return nil }
+
+func (n *noopTokenRepo) RevokeUserClientTokens(ctx context.Context, orgID, userID int64, clientID string) error {
+	return nil
+}
+
+func (n *noopTokenRepo) ListUserClientIDs(ctx context.Context, orgID, userID int64) ([]string, error) {
+	return nil, nil
+}
+
 func (n *noopCodeRepo) CreateCode(ctx context.Context, code domain.OAuthCode) error { return nil }
 
 func (n *noopCodeRepo) GetCode(ctx context.Context, orgID int64, code string) (domain.OAuthCode, error) {
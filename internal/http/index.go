@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smallbiznis/railzway-auth/internal/branding"
+	httpmiddleware "github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+// maxCachedIndexPages bounds the per-org page cache; it is cleared when full.
+const maxCachedIndexPages = 1024
+
+// indexRenderer serves index.html with the org's branding and discovery
+// document injected. Rendering sanitizes custom CSS and HTML, so pages are
+// cached per org until the org's configuration or index.html changes. The
+// cached page holds a placeholder that is replaced by a fresh CSP nonce on
+// every response.
+type indexRenderer struct {
+	path        string
+	discovery   *service.DiscoveryService
+	placeholder []byte
+
+	mu      sync.Mutex
+	modTime time.Time
+	pages   map[int64]cachedIndex
+}
+
+type cachedIndex struct {
+	version string
+	page    []byte
+}
+
+func newIndexRenderer(path string, discovery *service.DiscoveryService) *indexRenderer {
+	return &indexRenderer{
+		path:      path,
+		discovery: discovery,
+		// The placeholder is random so custom content cannot predict it and
+		// have the nonce written into itself.
+		placeholder: []byte(rand.Text()),
+		pages:       map[int64]cachedIndex{},
+	}
+}
+
+func (r *indexRenderer) serve(c *gin.Context) {
+	orgCtx, ok := httpmiddleware.GetOrgContext(c)
+	if !ok {
+		orgCtx = &org.Context{}
+	}
+	page, err := r.page(orgCtx)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	nonce, err := branding.NewNonce()
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Security-Policy", branding.ContentSecurityPolicy(nonce, branding.AssetOrigins(orgCtx.Branding)))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", bytes.ReplaceAll(page, r.placeholder, []byte(nonce)))
+}
+
+// page returns the org's rendered index.html with the nonce placeholder.
+func (r *indexRenderer) page(orgCtx *org.Context) ([]byte, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, err
+	}
+	version := indexVersion(orgCtx)
+
+	r.mu.Lock()
+	if !info.ModTime().Equal(r.modTime) {
+		r.modTime = info.ModTime()
+		r.pages = map[int64]cachedIndex{}
+	}
+	cached, ok := r.pages[orgCtx.Org.ID]
+	r.mu.Unlock()
+	if ok && cached.version == version {
+		return cached.page, nil
+	}
+
+	index, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	var bootstrap []byte
+	if r.discovery != nil && orgCtx.Org.ID != 0 {
+		if bootstrap, err = json.Marshal(r.discovery.OrgMetadata(orgCtx)); err != nil {
+			return nil, err
+		}
+	}
+	page := branding.RenderIndex(index, orgCtx.Branding, string(r.placeholder), bootstrap)
+
+	r.mu.Lock()
+	if len(r.pages) >= maxCachedIndexPages {
+		r.pages = map[int64]cachedIndex{}
+	}
+	r.pages[orgCtx.Org.ID] = cachedIndex{version: version, page: page}
+	r.mu.Unlock()
+	return page, nil
+}
+
+// indexVersion changes whenever the org data rendered into index.html does.
+// Every admin write bumps the row's updated_at, and resolver invalidation
+// makes the new org context visible.
+func indexVersion(orgCtx *org.Context) string {
+	var b strings.Builder
+	stamp := func(t time.Time) {
+		b.WriteString(strconv.FormatInt(t.UnixNano(), 36))
+		b.WriteByte('.')
+	}
+	stamp(orgCtx.Org.UpdatedAt)
+	stamp(orgCtx.Branding.UpdatedAt)
+	stamp(orgCtx.PasswordConfig.UpdatedAt)
+	for _, p := range orgCtx.AuthProviders {
+		b.WriteString(p.ProviderType + strconv.FormatBool(p.IsActive))
+		stamp(p.UpdatedAt)
+	}
+	for _, idp := range orgCtx.SocialProviders {
+		b.WriteString(idp.Provider)
+		stamp(idp.UpdatedAt)
+	}
+	return b.String()
+}
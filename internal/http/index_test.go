@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestIndexRenderer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "index.html")
+	require.NoError(t, os.WriteFile(path, []byte(`<html><head><script src="/app.js"></script></head><body></body></html>`), 0o644))
+	renderer := newIndexRenderer(path, &service.DiscoveryService{})
+
+	orgCtx := &org.Context{
+		Org:           domain.Org{ID: 7, Name: "Acme", Slug: "acme"},
+		AuthProviders: []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+	}
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/login", nil)
+		c.Set("orgContext", orgCtx)
+		renderer.serve(c)
+		return w
+	}
+
+	first, second := serve(), serve()
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, "no-store", first.Header().Get("Cache-Control"))
+	require.Contains(t, first.Body.String(), `<script id="org-bootstrap" type="application/json">{"org":"Acme"`)
+	require.NotEqual(t, first.Header().Get("Content-Security-Policy"), second.Header().Get("Content-Security-Policy"), "every response gets a new nonce")
+	require.NotContains(t, first.Body.String(), string(renderer.placeholder))
+
+	nonce := regexp.MustCompile(`'nonce-([^']+)'`).FindStringSubmatch(first.Header().Get("Content-Security-Policy"))
+	require.Len(t, nonce, 2)
+	require.Contains(t, first.Body.String(), `<script nonce="`+nonce[1]+`" src="/app.js">`)
+
+	// Saving the branding bumps its updated_at, which re-renders the page.
+	css := "body { color: red }"
+	orgCtx.Branding = domain.Branding{CustomCSS: &css, UpdatedAt: time.Now()}
+	require.Contains(t, serve().Body.String(), "body { color: red }")
+}
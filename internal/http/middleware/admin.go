@@ -1,76 +1,135 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
+	"github.com/smallbiznis/railzway-auth/internal/rbac"
 	"github.com/smallbiznis/railzway-auth/internal/service"
 )
 
-// Admin protects internal admin routes with a shared token.
+// adminMemberKey is the gin context key of the caller's membership.
+const adminMemberKey = "adminMember"
+
+// Admin protects internal admin routes. Callers present a user access token
+// and are authorized by their active membership of the org.
 type Admin struct {
-	auth *service.AuthService
+	auth    *service.AuthService
+	members *service.MemberService
+	issuers *issuer.Resolver
 }
 
-func NewAdmin(auth *service.AuthService) *Admin {
-	return &Admin{auth: auth}
+func NewAdmin(auth *service.AuthService, members *service.MemberService, issuers *issuer.Resolver) *Admin {
+	return &Admin{auth: auth, members: members, issuers: issuers}
 }
 
-// Require validates the admin token header.
+// Require validates the access token and loads the caller's membership.
+// DPoP-bound tokens must come with the DPoP scheme and a proof from their
+// key. The membership is read on every request so role changes and removals
+// take effect immediately, regardless of the token's roles claim.
 func (m *Admin) Require(c *gin.Context) {
-	if bearer := readBearerToken(c); bearer != "" {
-		if m.auth == nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
-			return
-		}
-		orgCtx, ok := GetOrgContext(c)
-		if !ok || orgCtx == nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_tenant"})
-			return
-		}
-		issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
-		_, claims, err := m.auth.ValidateToken(c.Request.Context(), orgCtx.Org.ID, bearer, issuer)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+	scheme, token := readAccessToken(c)
+	if token == "" || m.auth == nil || m.members == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+		return
+	}
+	orgCtx, ok := GetOrgContext(c)
+	if !ok || orgCtx == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_tenant"})
+		return
+	}
+	issuer := m.issuers.Issuer(c.Request, orgCtx)
+	std, custom, err := m.auth.ValidateToken(c.Request.Context(), orgCtx.Org.ID, token, issuer)
+	if err != nil || !audienceAllows(std, issuer) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+		return
+	}
+	if !enforceTokenBinding(c, m.auth, m.issuers, orgCtx.Org.ID, scheme, token, custom) {
+		return
+	}
+	if custom.OrgID != 0 && custom.OrgID != orgCtx.Org.ID {
+		// The token acts in another org the user switched to.
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access_denied", "error_description": "The token acts in another org."})
+		return
+	}
+	userID, err := strconv.ParseInt(std.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		// Client credentials tokens have no user and cannot be members.
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access_denied", "error_description": "A user token is required."})
+		return
+	}
+	if custom.Act != nil {
+		// Delegated tokens act for the user on behalf of another party.
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access_denied", "error_description": "Delegated tokens cannot use the admin API."})
+		return
+	}
+
+	member, err := m.members.Authorize(c.Request.Context(), orgCtx.Org.ID, userID)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			c.AbortWithStatusJSON(oauthErr.Status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
 			return
 		}
-		if !hasAdminScope(claims.Scope) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_scope"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.Set(adminMemberKey, member)
+	c.Next()
+}
+
+// RequirePermission restricts a route to members whose role grants
+// permission. It runs after Require.
+func (m *Admin) RequirePermission(permission rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		member, ok := GetAdminMember(c)
+		if !ok || !rbac.Can(member.Role, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "error_description": "Requires " + string(permission) + "."})
 			return
 		}
 		c.Next()
-		return
 	}
+}
 
-	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+// RequirePlatform restricts a route to admins of the platform org, for
+// operations that span orgs. It runs after Require.
+func (m *Admin) RequirePlatform(c *gin.Context) {
+	orgCtx, ok := GetOrgContext(c)
+	if !ok || orgCtx == nil || orgCtx.Org.Type != domain.OrgTypePlatform {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "error_description": "Platform admin required."})
+		return
+	}
+	c.Next()
 }
 
-func readBearerToken(c *gin.Context) string {
+// readAccessToken returns the scheme and token of a Bearer or DPoP
+// Authorization header.
+func readAccessToken(c *gin.Context) (string, string) {
 	if c == nil || c.Request == nil {
-		return ""
+		return "", ""
 	}
 	header := strings.TrimSpace(c.GetHeader("Authorization"))
 	if header == "" {
-		return ""
+		return "", ""
 	}
 	parts := strings.SplitN(header, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return ""
+	if len(parts) != 2 || (!strings.EqualFold(parts[0], "Bearer") && !strings.EqualFold(parts[0], service.TokenTypeDPoP)) {
+		return "", ""
 	}
-	return strings.TrimSpace(parts[1])
+	return parts[0], strings.TrimSpace(parts[1])
 }
 
-func hasAdminScope(scope string) bool {
-	if strings.TrimSpace(scope) == "" {
-		return false
-	}
-	for _, value := range strings.Fields(scope) {
-		switch strings.ToLower(strings.TrimSpace(value)) {
-		case "admin", "admin:*", "admin:oauth_clients":
-			return true
-		}
+// GetAdminMember returns the membership loaded by Admin.Require.
+func GetAdminMember(c *gin.Context) (domain.Member, bool) {
+	value, ok := c.Get(adminMemberKey)
+	if !ok {
+		return domain.Member{}, false
 	}
-	return false
+	member, ok := value.(domain.Member)
+	return member, ok
 }
@@ -1,13 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/jwt"
 	"github.com/smallbiznis/railzway-auth/internal/service"
 )
@@ -20,9 +21,11 @@ const (
 // Auth validates Authorization header and attaches claims.
 type Auth struct {
 	AuthService *service.AuthService
+	Issuers     *issuer.Resolver
 }
 
-// ValidateJWT ensures the request has a valid bearer token.
+// ValidateJWT ensures the request has a valid access token. DPoP-bound
+// tokens must come with the DPoP scheme and a proof from their key.
 func (m *Auth) ValidateJWT(c *gin.Context) {
 	orgCtx, ok := GetOrgContext(c)
 	if !ok {
@@ -35,21 +38,53 @@ func (m *Auth) ValidateJWT(c *gin.Context) {
 		return
 	}
 	parts := strings.SplitN(header, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+	if len(parts) != 2 || (!strings.EqualFold(parts[0], "Bearer") && !strings.EqualFold(parts[0], service.TokenTypeDPoP)) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "Bearer token required."})
 		return
 	}
-	issuer := fmt.Sprintf("%s://%s", schemeOnly(c.Request), hostOnly(c.Request))
+	issuer := m.Issuers.Issuer(c.Request, orgCtx)
 	claims, custom, err := m.AuthService.ValidateToken(c.Request.Context(), orgCtx.Org.ID, parts[1], issuer)
-	if err != nil {
+	if err != nil || !audienceAllows(claims, issuer) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "Invalid access token."})
 		return
 	}
+	if !enforceTokenBinding(c, m.AuthService, m.Issuers, orgCtx.Org.ID, parts[0], parts[1], custom) {
+		return
+	}
 	c.Set(stdClaimsKey, claims)
 	c.Set(accessClaimsKey, custom)
 	c.Next()
 }
 
+// audienceAllows reports whether a token is meant for this server: a token
+// with an aud claim must name the issuer, so tokens aimed at other APIs
+// cannot be replayed here.
+func audienceAllows(claims *gojwt.Claims, issuer string) bool {
+	return len(claims.Audience) == 0 || claims.Audience.Contains(issuer)
+}
+
+// enforceTokenBinding runs CheckTokenBinding for an access token presented
+// under scheme and aborts the request when the binding does not hold.
+func enforceTokenBinding(c *gin.Context, auth *service.AuthService, issuers *issuer.Resolver, orgID int64, scheme, token string, custom *jwt.AccessTokenClaims) bool {
+	err := auth.CheckTokenBinding(c.Request.Context(), orgID, scheme, custom.DPoPKey(), service.DPoPProofRequest{
+		Proofs:      c.Request.Header.Values("DPoP"),
+		Method:      c.Request.Method,
+		URL:         issuers.RequestURL(c.Request),
+		AccessToken: token,
+	})
+	if err == nil {
+		return true
+	}
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "Failed to check token binding."})
+		return false
+	}
+	c.Header("WWW-Authenticate", fmt.Sprintf(`DPoP error=%q, algs=%q`, oauthErr.Code, strings.Join(service.DPoPSigningAlgValues(), " ")))
+	c.AbortWithStatusJSON(oauthErr.Status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+	return false
+}
+
 // GetAccessClaims exposes custom access token claims to handlers.
 func GetAccessClaims(c *gin.Context) (*jwt.AccessTokenClaims, bool) {
 	value, ok := c.Get(accessClaimsKey)
@@ -69,25 +104,3 @@ func GetStdClaims(c *gin.Context) (*gojwt.Claims, bool) {
 	claims, ok := value.(*gojwt.Claims)
 	return claims, ok
 }
-
-func hostOnly(r *http.Request) string {
-	host := r.Host
-	if strings.Contains(host, ":") {
-		if h, _, err := net.SplitHostPort(host); err == nil {
-			return h
-		}
-	}
-	return host
-}
-
-func schemeOnly(r *http.Request) string {
-	scheme := r.Header.Get("X-Forwarded-Proto")
-	if scheme == "" {
-		if r.TLS != nil {
-			scheme = "https"
-		} else {
-			scheme = "http"
-		}
-	}
-	return scheme
-}
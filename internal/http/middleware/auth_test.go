@@ -0,0 +1,212 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gin-gonic/gin"
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+const testIssuer = "http://tenant.test"
+
+func TestMiddlewaresRejectLogoutTokens(t *testing.T) {
+	h := newMiddlewareHarness(t)
+	logout, err := h.generator.GenerateLogoutToken(context.Background(), h.org, "10", "client", testIssuer)
+	require.NoError(t, err)
+	access := h.accessToken(t, jwt.AccessTokenOptions{})
+
+	for _, path := range []string{"/admin/ping", "/auth/me"} {
+		require.Equal(t, http.StatusOK, h.do(path, "Bearer "+access).Code, path)
+		require.Equal(t, http.StatusUnauthorized, h.do(path, "Bearer "+logout).Code, "%s must not accept a logout token", path)
+	}
+}
+
+func TestMiddlewaresRejectForeignAudiences(t *testing.T) {
+	h := newMiddlewareHarness(t)
+	foreign := h.accessToken(t, jwt.AccessTokenOptions{Audience: []string{"https://orders.example"}})
+	orgOnly := h.accessToken(t, jwt.AccessTokenOptions{Audience: []string{h.org.Name}})
+	ours := h.accessToken(t, jwt.AccessTokenOptions{Audience: []string{"https://orders.example", testIssuer}})
+
+	for _, path := range []string{"/admin/ping", "/auth/me"} {
+		require.Equal(t, http.StatusUnauthorized, h.do(path, "Bearer "+foreign).Code, "%s must not accept a token for another API", path)
+		require.Equal(t, http.StatusUnauthorized, h.do(path, "Bearer "+orgOnly).Code, "%s requires the issuer in aud", path)
+		require.Equal(t, http.StatusOK, h.do(path, "Bearer "+ours).Code, path)
+	}
+}
+
+func TestAdminRequireRejectsDelegatedTokens(t *testing.T) {
+	h := newMiddlewareHarness(t)
+	delegated := h.accessToken(t, jwt.AccessTokenOptions{Act: &jwt.ActorClaim{Subject: "client:worker", ClientID: "worker"}})
+
+	require.Equal(t, http.StatusForbidden, h.do("/admin/ping", "Bearer "+delegated).Code)
+	require.Equal(t, http.StatusOK, h.do("/auth/me", "Bearer "+delegated).Code)
+}
+
+func TestAdminRequireEnforcesDPoPBinding(t *testing.T) {
+	h := newMiddlewareHarness(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	thumbprint, err := (&gojose.JSONWebKey{Key: key.Public()}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+	bound := h.accessToken(t, jwt.AccessTokenOptions{DPoPKey: base64.RawURLEncoding.EncodeToString(thumbprint)})
+	url := testIssuer + "/admin/ping"
+
+	rec := h.do("/admin/ping", "Bearer "+bound)
+	require.Equal(t, http.StatusUnauthorized, rec.Code, "a bound token must not be usable as a bearer token")
+	require.Contains(t, rec.Header().Get("WWW-Authenticate"), "DPoP")
+	require.Equal(t, http.StatusUnauthorized, h.do("/admin/ping", "DPoP "+bound).Code, "a proof is required")
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, h.do("/admin/ping", "DPoP "+bound, "DPoP", dpopProof(t, other, http.MethodGet, url, bound)).Code)
+	require.Equal(t, http.StatusOK, h.do("/admin/ping", "DPoP "+bound, "DPoP", dpopProof(t, key, http.MethodGet, url, bound)).Code)
+
+	unbound := h.accessToken(t, jwt.AccessTokenOptions{})
+	require.Equal(t, http.StatusUnauthorized, h.do("/admin/ping", "DPoP "+unbound, "DPoP", dpopProof(t, key, http.MethodGet, url, unbound)).Code)
+}
+
+type middlewareHarness struct {
+	engine    *gin.Engine
+	generator *jwt.Generator
+	org       domain.Org
+}
+
+// newMiddlewareHarness serves /admin/ping behind Admin.Require and /auth/me
+// behind Auth.ValidateJWT for org 1, where user 10 is an active owner.
+func newMiddlewareHarness(t *testing.T) *middlewareHarness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+	auth, err := service.NewAuthService(service.AuthDeps{
+		Cooldowns: &memoryCooldownStore{keys: map[string]bool{}},
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	members := service.NewMemberService(&memoryMemberships{orgID: 1, userID: 10}, nil, nil, node, cfg, zap.NewNop())
+	issuers, err := issuer.NewResolver(nil, false)
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{Org: domain.Org{ID: 1, Name: "Tenant"}}
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("orgContext", orgCtx)
+		c.Next()
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/admin/ping", middleware.NewAdmin(auth, members, issuers).Require, ok)
+	engine.GET("/auth/me", (&middleware.Auth{AuthService: auth, Issuers: issuers}).ValidateJWT, ok)
+	return &middlewareHarness{engine: engine, generator: generator, org: orgCtx.Org}
+}
+
+// accessToken issues a token for user 10 in org 1.
+func (h *middlewareHarness) accessToken(t *testing.T, opts jwt.AccessTokenOptions) string {
+	t.Helper()
+	token, err := h.generator.GenerateAccessTokenWithOptions(context.Background(), h.org, h.org.ID, domain.User{ID: 10, Email: "owner@tenant"}, "openid", testIssuer, []string{"password"}, opts)
+	require.NoError(t, err)
+	return token
+}
+
+func (h *middlewareHarness) do(path, authorization string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, testIssuer+path, nil)
+	req.Header.Set("Authorization", authorization)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+// dpopProof signs a DPoP proof for method and url with key, bound to
+// accessToken.
+func dpopProof(t *testing.T, key *ecdsa.PrivateKey, method, url, accessToken string) string {
+	t.Helper()
+	opts := (&gojose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt")
+	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.ES256, Key: key}, opts)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte(accessToken))
+	proof, err := gojwt.Signed(signer).Claims(map[string]any{
+		"jti": uuid.NewString(),
+		"iat": gojwt.NewNumericDate(time.Now()),
+		"htm": method,
+		"htu": url,
+		"ath": base64.RawURLEncoding.EncodeToString(sum[:]),
+	}).Serialize()
+	require.NoError(t, err)
+	return proof
+}
+
+type memoryKeyRepo struct {
+	key domain.OAuthKey
+}
+
+func (m *memoryKeyRepo) GetActiveKey(ctx context.Context, orgID int64) (domain.OAuthKey, error) {
+	if m.key.KID == "" {
+		return domain.OAuthKey{}, pgx.ErrNoRows
+	}
+	return m.key, nil
+}
+
+func (m *memoryKeyRepo) CreateKey(ctx context.Context, key domain.OAuthKey) (domain.OAuthKey, error) {
+	m.key = key
+	return key, nil
+}
+
+// memoryMemberships knows a single active owner.
+type memoryMemberships struct {
+	repository.MembershipRepository
+	orgID, userID int64
+}
+
+func (m *memoryMemberships) Get(ctx context.Context, orgID, userID int64) (domain.Member, error) {
+	if orgID != m.orgID || userID != m.userID {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	return domain.Member{OrgID: orgID, UserID: userID, Role: "owner", Status: domain.MemberStatusActive}, nil
+}
+
+type memoryCooldownStore struct {
+	keys map[string]bool
+}
+
+func (m *memoryCooldownStore) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
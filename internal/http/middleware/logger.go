@@ -7,10 +7,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 )
 
 // RequestLogger logs incoming HTTP requests with latency, org, and request ID metadata.
-func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
+// Client IPs are resolved through issuers' trusted proxies.
+func RequestLogger(logger *zap.Logger, issuers *issuer.Resolver) gin.HandlerFunc {
 	if logger == nil {
 		logger = zap.L()
 	}
@@ -41,7 +44,7 @@ func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.Duration("latency", latency),
-			zap.String("client_ip", c.ClientIP()),
+			zap.String("client_ip", issuers.ClientIP(c.Request)),
 			zap.String("user_agent", c.Request.UserAgent()),
 		}
 
@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
+)
+
+func TestRequestLoggerResolvesClientIPThroughTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuers, err := issuer.NewResolver([]string{"10.0.0.0/8"}, false)
+	require.NoError(t, err)
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	r := gin.New()
+	r.Use(middleware.RequestLogger(zap.New(core), issuers))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	request := func(remoteAddr string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		return entries[0].ContextMap()["client_ip"].(string)
+	}
+
+	require.Equal(t, "203.0.113.9", request("10.1.2.3:4000"), "a trusted proxy names the client")
+	require.Equal(t, "198.51.100.4", request("198.51.100.4:4000"), "untrusted peers cannot spoof X-Forwarded-For")
+}
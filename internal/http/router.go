@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -9,18 +10,27 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
+	"github.com/smallbiznis/railzway-auth/internal/certs"
 	"github.com/smallbiznis/railzway-auth/internal/config"
 	"github.com/smallbiznis/railzway-auth/internal/http/handler"
 	httpmiddleware "github.com/smallbiznis/railzway-auth/internal/http/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/middleware"
 	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/ratelimit"
+	"github.com/smallbiznis/railzway-auth/internal/rbac"
+	"github.com/smallbiznis/railzway-auth/internal/service"
 )
 
 // NewRouter wires Gin routes and middleware.
-func NewRouter(cfg config.Config, authHandler *handler.AuthHandler, adminHandler *handler.AdminHandler, healthHandler *handler.HealthHandler, authMiddleware *httpmiddleware.Auth, adminMiddleware *httpmiddleware.Admin, resolver *org.Resolver, rateLimiter *middleware.RateLimiter) *gin.Engine {
+func NewRouter(cfg config.Config, authHandler *handler.AuthHandler, adminHandler *handler.AdminHandler, healthHandler *handler.HealthHandler, authMiddleware *httpmiddleware.Auth, adminMiddleware *httpmiddleware.Admin, resolver *org.Resolver, issuers *issuer.Resolver, rateLimiter *middleware.RateLimiter, certManager *certs.Manager) (*gin.Engine, error) {
 	r := gin.New()
+	// Keep c.ClientIP in line with issuers.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
 	r.Use(gin.Recovery())
-	r.Use(httpmiddleware.RequestLogger(nil))
+	r.Use(httpmiddleware.RequestLogger(nil, issuers))
 	if rateLimiter != nil {
 		r.Use(rateLimiter.Handler())
 	}
@@ -29,28 +39,98 @@ func NewRouter(cfg config.Config, authHandler *handler.AuthHandler, adminHandler
 	r.GET("/health", healthHandler.Health)
 	r.GET("/ready", healthHandler.Ready)
 
+	// ACME HTTP-01 challenges must be answered before a domain has a
+	// certificate; the manager checks the host itself, so they skip org
+	// resolution.
+	if certManager != nil {
+		r.GET("/.well-known/acme-challenge/*token", gin.WrapH(certManager.HTTPHandler()))
+	}
+
 	// Apply org middleware for all other routes
-	r.Use(middleware.Org(resolver))
+	r.Use(middleware.Org(resolver, issuers))
 	r.Use(middleware.OrgCORS(cfg))
 	r.Use(otelgin.Middleware(cfg.ServiceName))
 
+	// Credential checks and code delivery get tighter budgets than the
+	// default one, counted by client IP and by the account they name; the
+	// token endpoint is also counted per client_id.
+	authLimit := rateLimiter.ByIP(ratelimit.PolicyAuth)
+	identifierLimit := rateLimiter.ByIdentifier(ratelimit.PolicyAuthIdentifier)
+	tokenLimits := []gin.HandlerFunc{rateLimiter.ByIP(ratelimit.PolicyToken), rateLimiter.ByClientID(ratelimit.PolicyTokenClient), identifierLimit, authHandler.Token}
+
 	authGroup := r.Group("/auth")
 	{
-		password := authGroup.Group("/password")
+		password := authGroup.Group("/password", authLimit, identifierLimit)
 		{
 			password.POST("/login", authHandler.PasswordLogin)
 			password.POST("/register", authHandler.PasswordRegister)
 			password.POST("/forgot", authHandler.PasswordForgot)
 		}
 
-		otp := authGroup.Group("/otp")
+		otp := authGroup.Group("/otp", authLimit, identifierLimit)
 		{
 			otp.POST("/request", authHandler.OTPRequest)
 			otp.POST("/verify", authHandler.OTPVerify)
 		}
 
+		mfa := authGroup.Group("/mfa")
+		{
+			mfa.GET("", authMiddleware.ValidateJWT, authHandler.MFAStatus)
+			mfa.POST("/totp/enroll", authMiddleware.ValidateJWT, authHandler.MFAEnrollTOTP)
+			mfa.POST("/totp/confirm", authMiddleware.ValidateJWT, authHandler.MFAConfirmTOTP)
+			mfa.DELETE("/totp", authMiddleware.ValidateJWT, authHandler.MFADisableTOTP)
+			mfa.POST("/recovery-codes", authMiddleware.ValidateJWT, authHandler.MFARegenerateRecoveryCodes)
+			mfa.POST("/challenge/enroll", authLimit, authHandler.MFAChallengeEnroll)
+			mfa.POST("/challenge/verify", authLimit, authHandler.MFAChallengeVerify)
+			mfa.POST("/challenge/passkey/begin", authLimit, authHandler.MFAChallengePasskeyBegin)
+			mfa.POST("/challenge/passkey/finish", authLimit, authHandler.MFAChallengePasskeyFinish)
+		}
+
+		passkey := authGroup.Group("/passkey")
+		{
+			passkey.GET("", authMiddleware.ValidateJWT, authHandler.PasskeyList)
+			passkey.DELETE("/:id", authMiddleware.ValidateJWT, authHandler.PasskeyDelete)
+			passkey.POST("/register/begin", authMiddleware.ValidateJWT, authHandler.PasskeyRegisterBegin)
+			passkey.POST("/register/finish", authMiddleware.ValidateJWT, authHandler.PasskeyRegisterFinish)
+			passkey.POST("/login/begin", authLimit, authHandler.PasskeyLoginBegin)
+			passkey.POST("/login/finish", authLimit, authHandler.PasskeyLoginFinish)
+		}
+
+		magicLink := authGroup.Group("/magic-link")
+		{
+			magicLink.POST("/request", authLimit, identifierLimit, authHandler.MagicLinkRequest)
+			magicLink.GET("/verify", authLimit, authHandler.MagicLinkVerify)
+		}
+
+		email := authGroup.Group("/email")
+		{
+			email.GET("/verify", authHandler.EmailVerify)
+			email.POST("/verify/resend", authHandler.EmailVerifyResend)
+		}
+
+		invitations := authGroup.Group("/invitations")
+		{
+			invitations.GET("", authHandler.PreviewInvitation)
+			invitations.POST("/accept", authHandler.AcceptInvitation)
+		}
+
+		orgs := authGroup.Group("/orgs")
+		{
+			orgs.GET("", authMiddleware.ValidateJWT, authHandler.UserOrgList)
+			orgs.POST("/switch", authHandler.UserOrgSwitch)
+		}
+
+		sessions := authGroup.Group("/sessions")
+		{
+			sessions.GET("", authMiddleware.ValidateJWT, authHandler.SessionList)
+			sessions.DELETE("/:id", authMiddleware.ValidateJWT, authHandler.SessionRevoke)
+		}
+
+		authGroup.POST("/logout", authHandler.Logout)
 		authGroup.GET("/me", authMiddleware.ValidateJWT, authHandler.Me)
 		authGroup.GET("/oauth/providers", authHandler.OAuthListProviders)
+		authGroup.GET("/branding", authHandler.Branding)
+		authGroup.GET("/discovery", authHandler.OrgDiscovery)
 		authGroup.GET("/oauth/start", authHandler.OAuthStart)
 		authGroup.GET("/oauth/callback", authHandler.OAuthCallback)
 	}
@@ -58,22 +138,88 @@ func NewRouter(cfg config.Config, authHandler *handler.AuthHandler, adminHandler
 	admin := r.Group("/admin")
 	{
 		admin.Use(adminMiddleware.Require)
-		admin.POST("/oauth/clients", adminHandler.UpsertOAuthClient)
+		readConfig := adminMiddleware.RequirePermission(rbac.ConfigRead)
+		manageConfig := adminMiddleware.RequirePermission(rbac.ConfigManage)
+		manageDomains := adminMiddleware.RequirePermission(rbac.DomainsManage)
+
+		admin.POST("/oauth/clients", adminMiddleware.RequirePermission(rbac.ClientsManage), adminHandler.UpsertOAuthClient)
+		admin.GET("/apis", readConfig, adminHandler.ListAPIResources)
+		admin.POST("/apis", adminMiddleware.RequirePermission(rbac.ClientsManage), adminHandler.UpsertAPIResource)
+		admin.DELETE("/apis", adminMiddleware.RequirePermission(rbac.ClientsManage), adminHandler.DeleteAPIResource)
+		admin.DELETE("/users/:id/sessions", adminMiddleware.RequirePermission(rbac.SessionsManage), adminHandler.RevokeUserSessions)
+		admin.GET("/domains", readConfig, adminHandler.ListDomains)
+		admin.POST("/domains", manageDomains, adminHandler.AddDomain)
+		admin.POST("/domains/:id/verification-token", manageDomains, adminHandler.RegenerateDomainToken)
+		admin.POST("/domains/:id/verify", manageDomains, adminHandler.VerifyDomain)
+		admin.DELETE("/domains/:id", manageDomains, adminHandler.DeleteDomain)
+		admin.GET("/branding", readConfig, adminHandler.GetBranding)
+		admin.PUT("/branding", manageConfig, adminHandler.SaveBranding)
+		admin.GET("/password-config", readConfig, adminHandler.GetPasswordConfig)
+		admin.PUT("/password-config", manageConfig, adminHandler.SavePasswordConfig)
+		admin.GET("/otp-config", readConfig, adminHandler.GetOTPConfig)
+		admin.PUT("/otp-config", manageConfig, adminHandler.SaveOTPConfig)
+		admin.GET("/auth-providers", readConfig, adminHandler.ListAuthProviders)
+		admin.PUT("/auth-providers/:type", manageConfig, adminHandler.SaveAuthProvider)
+		admin.DELETE("/auth-providers/:type", manageConfig, adminHandler.DeleteAuthProvider)
+		admin.GET("/idp-configs", readConfig, adminHandler.ListIDPConfigs)
+		admin.GET("/idp-configs/:provider", readConfig, adminHandler.GetIDPConfig)
+		admin.PUT("/idp-configs/:provider", manageConfig, adminHandler.SaveIDPConfig)
+		admin.DELETE("/idp-configs/:provider", manageConfig, adminHandler.DeleteIDPConfig)
+		admin.GET("/rate-limits", readConfig, adminHandler.ListRateLimits)
+		admin.PUT("/rate-limits/:policy", manageConfig, adminHandler.SaveRateLimit)
+		admin.DELETE("/rate-limits/:policy", manageConfig, adminHandler.DeleteRateLimit)
+		admin.GET("/schemas", readConfig, adminHandler.ListConfigSchemas)
+		admin.GET("/schemas/:name", readConfig, adminHandler.GetConfigSchema)
+
+		members := admin.Group("/members")
+		{
+			manageMembers := adminMiddleware.RequirePermission(rbac.MembersManage)
+			members.GET("", adminMiddleware.RequirePermission(rbac.MembersRead), adminHandler.ListMembers)
+			members.POST("", manageMembers, adminHandler.AddMember)
+			members.PATCH("/:user_id", manageMembers, adminHandler.UpdateMember)
+			members.DELETE("/:user_id", manageMembers, adminHandler.RemoveMember)
+		}
+
+		invitations := admin.Group("/invitations")
+		{
+			manageInvitations := adminMiddleware.RequirePermission(rbac.MembersManage)
+			invitations.GET("", adminMiddleware.RequirePermission(rbac.MembersRead), adminHandler.ListInvitations)
+			invitations.POST("", manageInvitations, adminHandler.InviteMember)
+			invitations.POST("/:id/resend", manageInvitations, adminHandler.ResendInvitation)
+			invitations.DELETE("/:id", manageInvitations, adminHandler.RevokeInvitation)
+		}
+
+		orgs := admin.Group("/orgs", adminMiddleware.RequirePlatform, adminMiddleware.RequirePermission(rbac.OrgsManage))
+		{
+			orgs.GET("", adminHandler.ListOrgs)
+			orgs.POST("", adminHandler.CreateOrg)
+			orgs.GET("/:id", adminHandler.GetOrg)
+			orgs.PATCH("/:id", adminHandler.UpdateOrg)
+			orgs.DELETE("/:id", adminHandler.DeleteOrg)
+			orgs.POST("/:id/suspend", adminHandler.SuspendOrg)
+			orgs.POST("/:id/activate", adminHandler.ActivateOrg)
+			orgs.POST("/:id/restore", adminHandler.RestoreOrg)
+			orgs.PUT("/:id/custom-js", adminHandler.SetCustomJS)
+		}
 	}
 
 	r.GET("/.well-known/openid-configuration", authHandler.OpenIDConfig)
 	r.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	r.GET("/authorize", authHandler.OAuthAuthorize)
-	r.POST("/token", authHandler.Token)
+	r.POST("/token", tokenLimits...)
+	r.POST("/par", authHandler.PushedAuthorization)
 	r.POST("/introspect", authHandler.OAuthIntrospect)
 	r.POST("/revoke", authHandler.OAuthRevoke)
 	r.GET("/userinfo", authHandler.OAuthUserInfo)
 
 	oauth := r.Group("/oauth")
 	{
-		oauth.POST("/token", authHandler.Token)
+		oauth.POST("/token", tokenLimits...)
 		oauth.GET("/authorize", authHandler.OAuthAuthorize)
+		oauth.POST("/par", authHandler.PushedAuthorization)
+		oauth.GET("/logout", authHandler.EndSession)
+		oauth.POST("/logout", authHandler.EndSession)
 		oauth.POST("/introspect", authHandler.OAuthIntrospect)
 		oauth.POST("/revoke", authHandler.OAuthRevoke)
 		oauth.GET("/userinfo", authHandler.OAuthUserInfo)
@@ -81,14 +227,16 @@ func NewRouter(cfg config.Config, authHandler *handler.AuthHandler, adminHandler
 
 	// r.GET("/userinfo", authMiddleware.ValidateJWT, authHandler.GetUserInfo)
 
-	// UI is served only as static files; auth/OAuth logic stays on the API routes.
-	attachUIRoutes(r, filepath.Join("ui", "dist"))
+	// UI is served as static files; auth/OAuth logic stays on the API routes.
+	// index.html gets the org's branding and discovery document injected.
+	attachUIRoutes(r, filepath.Join("ui", "dist"), authHandler.Discovery)
 
-	return r
+	return r, nil
 }
 
-func attachUIRoutes(r *gin.Engine, distDir string) {
+func attachUIRoutes(r *gin.Engine, distDir string, discovery *service.DiscoveryService) {
 	indexPath := filepath.Join(distDir, "index.html")
+	index := newIndexRenderer(indexPath, discovery)
 
 	r.NoRoute(func(c *gin.Context) {
 		path := c.Request.URL.Path
@@ -97,14 +245,14 @@ func attachUIRoutes(r *gin.Engine, distDir string) {
 			return
 		}
 
-		if filePath, ok := safeJoin(distDir, path); ok {
+		if filePath, ok := safeJoin(distDir, path); ok && filePath != indexPath {
 			if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
 				c.File(filePath)
 				return
 			}
 		}
 
-		c.File(indexPath)
+		index.serve(c)
 	})
 }
 
@@ -0,0 +1,265 @@
+// Package issuer derives the external scheme, host, client IP and OIDC issuer
+// of requests. Forwarding headers (RFC 7239 Forwarded and the X-Forwarded-*
+// family) are only honoured when they were added by a trusted proxy.
+package issuer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+// Resolver resolves request origins. A nil Resolver trusts no proxy and
+// never pins issuers.
+type Resolver struct {
+	trusted    []netip.Prefix
+	pinPrimary bool
+}
+
+// NewResolver creates a resolver trusting the given proxy CIDRs or
+// addresses. With pinPrimary set, issuers name the org's primary domain
+// whichever host the request came in on.
+func NewResolver(trustedProxies []string, pinPrimary bool) (*Resolver, error) {
+	r := &Resolver{pinPrimary: pinPrimary}
+	for _, value := range trustedProxies {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(value)
+			if addrErr != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", value, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+// Origin is what a request looked like to the client, before any proxy.
+type Origin struct {
+	Scheme string
+	// Host may carry a port.
+	Host     string
+	ClientIP string
+}
+
+// Origin resolves the external origin of r.
+func (r *Resolver) Origin(req *http.Request) Origin {
+	out := Origin{Scheme: "http", Host: req.Host, ClientIP: remoteIP(req)}
+	if req.TLS != nil {
+		out.Scheme = "https"
+	}
+	if !r.isTrusted(out.ClientIP) {
+		return out
+	}
+
+	hops := forwardedHops(req.Header)
+	if len(hops) == 0 {
+		return out
+	}
+	// Walk from the nearest proxy outwards. Each hop was written by a proxy
+	// we trust for as long as every address after it is trusted; the first
+	// untrusted address is the client.
+	hop := hops[0]
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop = hops[i]
+		if hop.forIP == "" || !r.isTrusted(hop.forIP) {
+			break
+		}
+	}
+	if hop.forIP != "" {
+		out.ClientIP = hop.forIP
+	}
+	if hop.proto == "http" || hop.proto == "https" {
+		out.Scheme = hop.proto
+	}
+	if validHost(hop.host) {
+		out.Host = hop.host
+	}
+	return out
+}
+
+// Scheme returns the external scheme of r.
+func (r *Resolver) Scheme(req *http.Request) string {
+	return r.Origin(req).Scheme
+}
+
+// Host returns the external host of r without its port.
+func (r *Resolver) Host(req *http.Request) string {
+	return stripPort(r.Origin(req).Host)
+}
+
+// ClientIP returns the address of the client behind any trusted proxies.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	return r.Origin(req).ClientIP
+}
+
+// BaseURL returns the external scheme and host of r, without a port.
+func (r *Resolver) BaseURL(req *http.Request) string {
+	origin := r.Origin(req)
+	return fmt.Sprintf("%s://%s", origin.Scheme, stripPort(origin.Host))
+}
+
+// RequestURL returns the URL the client requested, without the query.
+// DPoP proofs name it in htu.
+func (r *Resolver) RequestURL(req *http.Request) string {
+	origin := r.Origin(req)
+	return fmt.Sprintf("%s://%s%s", origin.Scheme, origin.Host, req.URL.Path)
+}
+
+// Issuer returns the issuer of tokens minted for orgCtx on r. It is the
+// request's base URL unless issuers are pinned to the org's primary domain.
+func (r *Resolver) Issuer(req *http.Request, orgCtx *org.Context) string {
+	if r != nil && r.pinPrimary && orgCtx != nil && orgCtx.PrimaryHost != "" {
+		return "https://" + orgCtx.PrimaryHost
+	}
+	return r.BaseURL(req)
+}
+
+func (r *Resolver) isTrusted(ip string) bool {
+	if r == nil || len(r.trusted) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hop is one proxy's record of the request it received.
+type hop struct {
+	forIP string
+	proto string
+	host  string
+}
+
+// forwardedHops reads the Forwarded header, falling back to
+// X-Forwarded-For, -Proto and -Host. Hops are ordered client first.
+func forwardedHops(header http.Header) []hop {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		return parseForwarded(values)
+	}
+
+	var hops []hop
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, part := range strings.Split(value, ",") {
+			hops = append(hops, hop{forIP: parseNode(part)})
+		}
+	}
+	proto := strings.ToLower(firstListValue(header.Get("X-Forwarded-Proto")))
+	host := firstListValue(header.Get("X-Forwarded-Host"))
+	if proto == "" && host == "" {
+		return hops
+	}
+	// X-Forwarded-Proto and -Host are set by the proxy facing the client,
+	// so they describe the outermost hop.
+	if len(hops) == 0 {
+		hops = []hop{{}}
+	}
+	for i := range hops {
+		hops[i].proto, hops[i].host = proto, host
+	}
+	return hops
+}
+
+// parseForwarded parses RFC 7239 forwarded-elements.
+func parseForwarded(values []string) []hop {
+	var hops []hop
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			var h hop
+			for _, pair := range splitQuoted(element, ';') {
+				name, val, ok := strings.Cut(pair, "=")
+				if !ok {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "for":
+					h.forIP = parseNode(val)
+				case "proto":
+					h.proto = strings.ToLower(val)
+				case "host":
+					h.host = val
+				}
+			}
+			hops = append(hops, h)
+		}
+	}
+	return hops
+}
+
+// splitQuoted splits s on sep outside double quotes.
+func splitQuoted(s string, sep rune) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseNode returns the IP of a node such as 192.0.2.1, 192.0.2.1:80 or
+// [2001:db8::1]:80, or "" for obfuscated and unknown nodes.
+func parseNode(node string) string {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if addrPort, err := netip.ParseAddrPort(node); err == nil {
+		return addrPort.Addr().Unmap().String()
+	}
+	if addr, err := netip.ParseAddr(strings.Trim(node, "[]")); err == nil {
+		return addr.Unmap().String()
+	}
+	return ""
+}
+
+func firstListValue(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	return strings.TrimSpace(first)
+}
+
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(req.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(req.RemoteAddr)
+	}
+	return host
+}
+
+// validHost rejects forwarded hosts that could smuggle a path or userinfo
+// into URLs built from them.
+func validHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, "/\\@?# \t")
+}
+
+func stripPort(host string) string {
+	if strings.Contains(host, ":") {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			return h
+		}
+	}
+	return host
+}
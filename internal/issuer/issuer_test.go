@@ -0,0 +1,82 @@
+package issuer_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+func TestResolverIgnoresUntrustedPeers(t *testing.T) {
+	resolver, err := issuer.NewResolver([]string{"10.0.0.0/8"}, false)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "http://auth.example/oauth/token", nil)
+	req.RemoteAddr = "203.0.113.9:4000"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	req.Header.Set("Forwarded", "for=198.51.100.1;proto=https;host=evil.example")
+
+	require.Equal(t, "http://auth.example", resolver.Issuer(req, nil))
+	require.Equal(t, "203.0.113.9", resolver.ClientIP(req))
+
+	var none *issuer.Resolver
+	require.Equal(t, "http://auth.example", none.Issuer(req, nil))
+	require.Equal(t, "203.0.113.9", none.ClientIP(req))
+}
+
+func TestResolverTrustedXForwarded(t *testing.T) {
+	resolver, err := issuer.NewResolver([]string{"10.0.0.0/8", "192.0.2.7"}, false)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "http://internal:8080/oauth/token?x=1", nil)
+	req.RemoteAddr = "10.1.2.3:4000"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "auth.example:8443")
+	// The client forged the first entry; 192.0.2.7 is a trusted hop.
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 198.51.100.1, 192.0.2.7")
+
+	require.Equal(t, "https://auth.example", resolver.Issuer(req, nil))
+	require.Equal(t, "https://auth.example:8443/oauth/token", resolver.RequestURL(req))
+	require.Equal(t, "auth.example", resolver.Host(req))
+	require.Equal(t, "198.51.100.1", resolver.ClientIP(req))
+}
+
+func TestResolverTrustedForwarded(t *testing.T) {
+	resolver, err := issuer.NewResolver([]string{"10.0.0.0/8"}, false)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "http://internal/userinfo", nil)
+	req.RemoteAddr = "10.0.0.2:4000"
+	req.Header.Set("X-Forwarded-Proto", "http")
+	req.Header.Add("Forwarded", `for="[2001:db8::1]:4711";proto=https;host=auth.example`)
+	req.Header.Add("Forwarded", "for=10.0.0.1;proto=http;host=internal")
+
+	require.Equal(t, "https://auth.example", resolver.Issuer(req, nil))
+	require.Equal(t, "2001:db8::1", resolver.ClientIP(req))
+
+	req.Header.Set("Forwarded", "for=198.51.100.1;proto=https;host=\"evil.example/path\"")
+	require.Equal(t, "https://internal", resolver.Issuer(req, nil), "hosts with a path are ignored")
+}
+
+func TestResolverPinsPrimaryDomain(t *testing.T) {
+	orgCtx := &org.Context{PrimaryHost: "login.example"}
+	req := httptest.NewRequest("GET", "http://alias.example/.well-known/openid-configuration", nil)
+
+	resolver, err := issuer.NewResolver(nil, true)
+	require.NoError(t, err)
+	require.Equal(t, "https://login.example", resolver.Issuer(req, orgCtx))
+	require.Equal(t, "http://alias.example", resolver.Issuer(req, &org.Context{}))
+
+	resolver, err = issuer.NewResolver(nil, false)
+	require.NoError(t, err)
+	require.Equal(t, "http://alias.example", resolver.Issuer(req, orgCtx))
+}
+
+func TestNewResolverRejectsInvalidProxies(t *testing.T) {
+	_, err := issuer.NewResolver([]string{"not-a-cidr"}, false)
+	require.Error(t, err)
+}
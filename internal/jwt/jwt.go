@@ -3,18 +3,40 @@ package jwt
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	gojose "github.com/go-jose/go-jose/v4"
 	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/google/uuid"
 
 	"github.com/smallbiznis/railzway-auth/internal/domain"
 )
 
+// JWT typ header values. Every token type is signed with the org key, so the
+// header is what stops one kind from being accepted as another.
+const (
+	// AccessTokenType marks access tokens (RFC 9068).
+	AccessTokenType = "at+jwt"
+	// IDTokenType marks OIDC ID tokens.
+	IDTokenType = "JWT"
+	// LogoutTokenType marks OIDC back-channel logout tokens.
+	LogoutTokenType = "logout+jwt"
+	// IntrospectionResponseType marks signed introspection responses
+	// (RFC 9701).
+	IntrospectionResponseType = "token-introspection+jwt"
+)
+
 // Generator is responsible for signing and validating JWTs.
 type Generator struct {
 	keys      *KeyManager
 	accessTTL time.Duration
+	roles     RoleLookup
+}
+
+// RoleLookup returns a user's roles in an org for the roles claim.
+type RoleLookup interface {
+	Roles(ctx context.Context, orgID, userID int64) ([]string, error)
 }
 
 // NewGenerator constructs a JWT generator.
@@ -22,47 +44,154 @@ func NewGenerator(manager *KeyManager, accessTTL time.Duration) *Generator {
 	return &Generator{keys: manager, accessTTL: accessTTL}
 }
 
+// WithRoles makes access tokens issued to users carry their org roles.
+func (g *Generator) WithRoles(lookup RoleLookup) *Generator {
+	g.roles = lookup
+	return g
+}
+
 // AccessTokenClaims represent the JWT payload for access tokens.
 type AccessTokenClaims struct {
-	OrgID     int64    `json:"org_id"`
-	TenantID  int64    `json:"tenant_id,omitempty"`
-	Scope     string   `json:"scope"`
-	Email     string   `json:"email"`
-	Name      string   `json:"name"`
-	Picture   string   `json:"picture"`
-	Providers []string `json:"providers"`
+	OrgID         int64    `json:"org_id"`
+	TenantID      int64    `json:"tenant_id,omitempty"`
+	Scope         string   `json:"scope"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+	Providers     []string `json:"providers"`
+	// Roles are the user's org member roles when the token was issued. They
+	// are informational; the admin API checks the current membership.
+	Roles []string `json:"roles,omitempty"`
+	// ClientID is the client the token was issued to, when known.
+	ClientID string `json:"client_id,omitempty"`
+	// SubjectClientID names the client a token is about when its subject
+	// is a client acting for itself, as with client credentials, rather
+	// than a user.
+	SubjectClientID string `json:"sub_client_id,omitempty"`
+	// Act names the party acting for the subject in an exchanged token.
+	Act *ActorClaim `json:"act,omitempty"`
+	// Cnf binds the token to a DPoP key (RFC 9449).
+	Cnf *Confirmation `json:"cnf,omitempty"`
+}
+
+// Confirmation is the cnf claim of a sender-constrained token. JKT is the
+// RFC 7638 SHA-256 thumbprint of the holder's DPoP public key.
+type Confirmation struct {
+	JKT string `json:"jkt"`
+}
+
+// DPoPKey returns the thumbprint the token is bound to, or "" for bearer
+// tokens.
+func (c *AccessTokenClaims) DPoPKey() string {
+	if c == nil || c.Cnf == nil {
+		return ""
+	}
+	return c.Cnf.JKT
+}
+
+// ActorClaim is the RFC 8693 act claim. Act holds the prior actor when
+// tokens are exchanged more than once.
+type ActorClaim struct {
+	Subject  string      `json:"sub"`
+	ClientID string      `json:"client_id,omitempty"`
+	Act      *ActorClaim `json:"act,omitempty"`
+}
+
+// SigningAlgorithms lists the algorithms org signing keys use. API resources
+// may only ask for one of these.
+var SigningAlgorithms = []string{string(gojose.HS256)}
+
+// AccessTokenOptions override the org defaults of an access token.
+type AccessTokenOptions struct {
+	// ClientID is the client the token was issued to.
+	ClientID string
+	// SubjectClientID marks the subject as that client rather than a user.
+	SubjectClientID string
+	// Audience defaults to the issuer, which this server's own APIs require,
+	// and the org name.
+	Audience []string
+	// TTL defaults to the generator's access token lifetime.
+	TTL time.Duration
+	// Algorithm, when set, must be the algorithm of the org signing key.
+	Algorithm string
+	// Act records the actor chain of an exchanged token.
+	Act *ActorClaim
+	// DPoPKey, when set, binds the token to that DPoP key thumbprint.
+	DPoPKey string
 }
 
 // GenerateAccessToken produces a signed JWT.
 func (g *Generator) GenerateAccessToken(ctx context.Context, org domain.Org, user domain.User, scope, issuer string, providers []string) (string, error) {
+	return g.GenerateOrgAccessToken(ctx, org, org.ID, user, scope, issuer, providers)
+}
+
+// GenerateOrgAccessToken produces a JWT signed by org for a user acting in
+// activeOrgID, which the caller has checked the user may act in. org_id and
+// roles describe the active org.
+func (g *Generator) GenerateOrgAccessToken(ctx context.Context, org domain.Org, activeOrgID int64, user domain.User, scope, issuer string, providers []string) (string, error) {
+	return g.GenerateAccessTokenWithOptions(ctx, org, activeOrgID, user, scope, issuer, providers, AccessTokenOptions{})
+}
+
+// GenerateAccessTokenWithOptions is GenerateOrgAccessToken with a client,
+// audience, lifetime, actor or DPoP binding other than the defaults.
+func (g *Generator) GenerateAccessTokenWithOptions(ctx context.Context, org domain.Org, activeOrgID int64, user domain.User, scope, issuer string, providers []string, opts AccessTokenOptions) (string, error) {
+	audience := opts.Audience
+	if len(audience) == 0 {
+		audience = []string{issuer, org.Name}
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = g.accessTTL
+	}
+
 	key, err := g.keys.EnsureSigningKey(ctx, org.ID)
 	if err != nil {
 		return "", fmt.Errorf("ensure signing key: %w", err)
 	}
+	if opts.Algorithm != "" && opts.Algorithm != key.Algorithm {
+		return "", fmt.Errorf("signing key algorithm %s does not match %s", key.Algorithm, opts.Algorithm)
+	}
 
-	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.SignatureAlgorithm(key.Algorithm), Key: key.Secret}, (&gojose.SignerOptions{}).WithType("JWT").WithHeader("kid", key.KID))
+	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.SignatureAlgorithm(key.Algorithm), Key: key.Secret}, (&gojose.SignerOptions{}).WithType(AccessTokenType).WithHeader("kid", key.KID))
 	if err != nil {
 		return "", fmt.Errorf("new signer: %w", err)
 	}
 
+	var roles []string
+	if g.roles != nil && user.ID != 0 {
+		if roles, err = g.roles.Roles(ctx, activeOrgID, user.ID); err != nil {
+			return "", fmt.Errorf("load roles: %w", err)
+		}
+	}
+
 	now := time.Now().UTC()
 	stdClaims := gojwt.Claims{
+		ID:        uuid.NewString(),
 		Subject:   fmt.Sprintf("%d", user.ID),
-		Audience:  gojwt.Audience{org.Name},
+		Audience:  gojwt.Audience(audience),
 		Issuer:    issuer,
 		IssuedAt:  gojwt.NewNumericDate(now),
-		Expiry:    gojwt.NewNumericDate(now.Add(g.accessTTL)),
+		Expiry:    gojwt.NewNumericDate(now.Add(ttl)),
 		NotBefore: gojwt.NewNumericDate(now),
 	}
 
 	custom := AccessTokenClaims{
-		OrgID:     org.ID,
-		TenantID:  org.ID,
-		Scope:     scope,
-		Email:     user.Email,
-		Name:      user.Name,
-		Picture:   user.AvatarURL,
-		Providers: providers,
+		OrgID:           activeOrgID,
+		TenantID:        activeOrgID,
+		Scope:           scope,
+		Email:           user.Email,
+		EmailVerified:   user.EmailVerified,
+		Name:            user.Name,
+		Picture:         user.AvatarURL,
+		Providers:       providers,
+		Roles:           roles,
+		ClientID:        opts.ClientID,
+		SubjectClientID: opts.SubjectClientID,
+		Act:             opts.Act,
+	}
+	if opts.DPoPKey != "" {
+		custom.Cnf = &Confirmation{JKT: opts.DPoPKey}
 	}
 
 	token, err := gojwt.Signed(signer).Claims(stdClaims).Claims(custom).Serialize()
@@ -73,7 +202,10 @@ func (g *Generator) GenerateAccessToken(ctx context.Context, org domain.Org, use
 	return token, nil
 }
 
-// ValidateAccessToken ensures the token is valid and returns its claims.
+// ValidateAccessToken ensures the token is a valid access token of orgID and
+// returns its claims. Other tokens signed with the org key, such as logout
+// tokens and introspection responses, are rejected by their typ header and
+// their missing org_id.
 func (g *Generator) ValidateAccessToken(ctx context.Context, orgID int64, token, issuer string) (*gojwt.Claims, *AccessTokenClaims, error) {
 	key, err := g.keys.ActiveKey(ctx, orgID)
 	if err != nil {
@@ -86,6 +218,9 @@ func (g *Generator) ValidateAccessToken(ctx context.Context, orgID int64, token,
 	if err != nil {
 		return nil, nil, fmt.Errorf("parse token: %w", err)
 	}
+	if len(parsed.Headers) != 1 || parsed.Headers[0].ExtraHeaders[gojose.HeaderType] != AccessTokenType {
+		return nil, nil, fmt.Errorf("validate token: typ is not %s", AccessTokenType)
+	}
 
 	var std gojwt.Claims
 	var custom AccessTokenClaims
@@ -100,6 +235,166 @@ func (g *Generator) ValidateAccessToken(ctx context.Context, orgID int64, token,
 	if custom.OrgID == 0 && custom.TenantID != 0 {
 		custom.OrgID = custom.TenantID
 	}
+	if custom.OrgID == 0 {
+		return nil, nil, fmt.Errorf("validate claims: missing org_id")
+	}
 
 	return &std, &custom, nil
 }
+
+// introspectionResponseTTL bounds how long a signed introspection response
+// may be relied on.
+const introspectionResponseTTL = time.Minute
+
+// GenerateIntrospectionResponse signs an RFC 9701 token introspection
+// response for audience, the client that asked.
+func (g *Generator) GenerateIntrospectionResponse(ctx context.Context, org domain.Org, issuer, audience string, introspection map[string]any) (string, error) {
+	key, err := g.keys.EnsureSigningKey(ctx, org.ID)
+	if err != nil {
+		return "", fmt.Errorf("ensure signing key: %w", err)
+	}
+
+	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.SignatureAlgorithm(key.Algorithm), Key: key.Secret}, (&gojose.SignerOptions{}).WithType(IntrospectionResponseType).WithHeader("kid", key.KID))
+	if err != nil {
+		return "", fmt.Errorf("new signer: %w", err)
+	}
+
+	now := time.Now().UTC()
+	stdClaims := gojwt.Claims{
+		Audience: gojwt.Audience{audience},
+		Issuer:   issuer,
+		IssuedAt: gojwt.NewNumericDate(now),
+		Expiry:   gojwt.NewNumericDate(now.Add(introspectionResponseTTL)),
+	}
+	custom := map[string]any{"token_introspection": introspection}
+
+	token, err := gojwt.Signed(signer).Claims(stdClaims).Claims(custom).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("serialize introspection response: %w", err)
+	}
+	return token, nil
+}
+
+// IDTokenClaims are the OIDC claims of an ID token besides the registered
+// ones. Profile and email claims follow the granted scope.
+type IDTokenClaims struct {
+	Email         string   `json:"email,omitempty"`
+	EmailVerified *bool    `json:"email_verified,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Picture       string   `json:"picture,omitempty"`
+	AMR           []string `json:"amr,omitempty"`
+	// AuthTime is when the user signed in, so clients can enforce max_age
+	// and prompt=login.
+	AuthTime *gojwt.NumericDate `json:"auth_time,omitempty"`
+}
+
+// GenerateIDToken produces an OIDC ID token telling clientID that user
+// signed in with amr at authTime. A zero authTime omits auth_time.
+func (g *Generator) GenerateIDToken(ctx context.Context, org domain.Org, user domain.User, clientID, scope, issuer string, amr []string, authTime time.Time) (string, error) {
+	key, err := g.keys.EnsureSigningKey(ctx, org.ID)
+	if err != nil {
+		return "", fmt.Errorf("ensure signing key: %w", err)
+	}
+
+	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.SignatureAlgorithm(key.Algorithm), Key: key.Secret}, (&gojose.SignerOptions{}).WithType(IDTokenType).WithHeader("kid", key.KID))
+	if err != nil {
+		return "", fmt.Errorf("new signer: %w", err)
+	}
+
+	now := time.Now().UTC()
+	stdClaims := gojwt.Claims{
+		Subject:  fmt.Sprintf("%d", user.ID),
+		Audience: gojwt.Audience{clientID},
+		Issuer:   issuer,
+		IssuedAt: gojwt.NewNumericDate(now),
+		Expiry:   gojwt.NewNumericDate(now.Add(g.accessTTL)),
+	}
+	custom := IDTokenClaims{AMR: amr}
+	if !authTime.IsZero() {
+		custom.AuthTime = gojwt.NewNumericDate(authTime)
+	}
+	for _, value := range strings.Fields(scope) {
+		switch value {
+		case "email":
+			verified := user.EmailVerified
+			custom.Email, custom.EmailVerified = user.Email, &verified
+		case "profile":
+			custom.Name, custom.Picture = user.Name, user.AvatarURL
+		}
+	}
+
+	token, err := gojwt.Signed(signer).Claims(stdClaims).Claims(custom).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("serialize id token: %w", err)
+	}
+	return token, nil
+}
+
+// backchannelLogoutEvent is the events member identifying logout tokens.
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// logoutTokenTTL bounds how long a client may accept a logout token.
+const logoutTokenTTL = 2 * time.Minute
+
+// GenerateLogoutToken produces an OIDC back-channel logout token telling
+// clientID that subject signed out.
+func (g *Generator) GenerateLogoutToken(ctx context.Context, org domain.Org, subject, clientID, issuer string) (string, error) {
+	key, err := g.keys.EnsureSigningKey(ctx, org.ID)
+	if err != nil {
+		return "", fmt.Errorf("ensure signing key: %w", err)
+	}
+
+	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.SignatureAlgorithm(key.Algorithm), Key: key.Secret}, (&gojose.SignerOptions{}).WithType(LogoutTokenType).WithHeader("kid", key.KID))
+	if err != nil {
+		return "", fmt.Errorf("new signer: %w", err)
+	}
+
+	now := time.Now().UTC()
+	stdClaims := gojwt.Claims{
+		Subject:  subject,
+		Audience: gojwt.Audience{clientID},
+		Issuer:   issuer,
+		IssuedAt: gojwt.NewNumericDate(now),
+		Expiry:   gojwt.NewNumericDate(now.Add(logoutTokenTTL)),
+		ID:       uuid.NewString(),
+	}
+	events := map[string]any{
+		"events": map[string]any{backchannelLogoutEvent: map[string]any{}},
+	}
+
+	token, err := gojwt.Signed(signer).Claims(stdClaims).Claims(events).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("serialize logout token: %w", err)
+	}
+	return token, nil
+}
+
+// VerifyTokenHint checks that token is an ID token the org issued for
+// issuer to a single client, clientID when it is set, and returns its
+// claims. Expired tokens are accepted, as id_token_hint allows.
+func (g *Generator) VerifyTokenHint(ctx context.Context, orgID int64, token, issuer, clientID string) (*gojwt.Claims, error) {
+	key, err := g.keys.ActiveKey(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("load key: %w", err)
+	}
+
+	parsed, err := gojwt.ParseSigned(token, []gojose.SignatureAlgorithm{gojose.SignatureAlgorithm(key.Algorithm)})
+	if err != nil {
+		return nil, fmt.Errorf("parse token: %w", err)
+	}
+	if len(parsed.Headers) != 1 || parsed.Headers[0].ExtraHeaders[gojose.HeaderType] != IDTokenType {
+		return nil, fmt.Errorf("validate token: typ is not %s", IDTokenType)
+	}
+
+	var std gojwt.Claims
+	if err := parsed.Claims(key.Secret, &std); err != nil {
+		return nil, fmt.Errorf("verify token: %w", err)
+	}
+	if std.Issuer != issuer {
+		return nil, fmt.Errorf("validate claims: %w", gojwt.ErrInvalidIssuer)
+	}
+	if len(std.Audience) != 1 || (clientID != "" && std.Audience[0] != clientID) {
+		return nil, fmt.Errorf("validate claims: %w", gojwt.ErrInvalidAudience)
+	}
+	return &std, nil
+}
@@ -29,6 +29,68 @@ func TestGeneratorRoundTrip(t *testing.T) {
 	require.Equal(t, "99", claims.Subject)
 	require.Equal(t, int64(1), custom.OrgID)
 	require.Equal(t, "user@tenant", custom.Email)
+	require.Empty(t, custom.Roles)
+}
+
+func TestGeneratorRoles(t *testing.T) {
+	manager := customjwt.NewKeyManager(&fakeKeyRepo{})
+	generator := customjwt.NewGenerator(manager, time.Hour).WithRoles(fakeRoles{99: {"admin"}})
+	org := domain.Org{ID: 1, Name: "Tenant"}
+
+	token, err := generator.GenerateAccessToken(context.Background(), org, domain.User{ID: 99}, "openid", "https://tenant", nil)
+	require.NoError(t, err)
+	_, custom, err := generator.ValidateAccessToken(context.Background(), org.ID, token, "https://tenant")
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin"}, custom.Roles)
+
+	token, err = generator.GenerateAccessToken(context.Background(), org, domain.User{ID: 7}, "openid", "https://tenant", nil)
+	require.NoError(t, err)
+	_, custom, err = generator.ValidateAccessToken(context.Background(), org.ID, token, "https://tenant")
+	require.NoError(t, err)
+	require.Empty(t, custom.Roles)
+}
+
+func TestGeneratorActiveOrg(t *testing.T) {
+	manager := customjwt.NewKeyManager(&fakeKeyRepo{})
+	generator := customjwt.NewGenerator(manager, time.Hour).WithRoles(orgRoles{2: {"staff"}})
+	org := domain.Org{ID: 1, Name: "Tenant"}
+
+	token, err := generator.GenerateOrgAccessToken(context.Background(), org, 2, domain.User{ID: 99}, "openid", "https://tenant", nil)
+	require.NoError(t, err)
+	_, custom, err := generator.ValidateAccessToken(context.Background(), org.ID, token, "https://tenant")
+	require.NoError(t, err, "the issuing org signs the token")
+	require.Equal(t, int64(2), custom.OrgID)
+	require.Equal(t, int64(2), custom.TenantID)
+	require.Equal(t, []string{"staff"}, custom.Roles)
+}
+
+func TestValidateAccessTokenRejectsOtherTokenTypes(t *testing.T) {
+	ctx := context.Background()
+	generator := customjwt.NewGenerator(customjwt.NewKeyManager(&fakeKeyRepo{}), time.Hour)
+	org := domain.Org{ID: 1, Name: "Tenant"}
+
+	logout, err := generator.GenerateLogoutToken(ctx, org, "99", "client", "https://tenant")
+	require.NoError(t, err)
+	_, _, err = generator.ValidateAccessToken(ctx, org.ID, logout, "https://tenant")
+	require.Error(t, err, "logout tokens are signed with the same key but are not access tokens")
+
+	introspection, err := generator.GenerateIntrospectionResponse(ctx, org, "https://tenant", "client", map[string]any{"active": true, "org_id": 1})
+	require.NoError(t, err)
+	_, _, err = generator.ValidateAccessToken(ctx, org.ID, introspection, "https://tenant")
+	require.Error(t, err)
+}
+
+type fakeRoles map[int64][]string
+
+func (f fakeRoles) Roles(ctx context.Context, orgID, userID int64) ([]string, error) {
+	return f[userID], nil
+}
+
+// orgRoles returns the same roles for every user of an org.
+type orgRoles map[int64][]string
+
+func (f orgRoles) Roles(ctx context.Context, orgID, userID int64) ([]string, error) {
+	return f[orgID], nil
 }
 
 type fakeKeyRepo struct {
@@ -0,0 +1,35 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// opaqueTokenBytes is the entropy of opaque access tokens.
+const opaqueTokenBytes = 32
+
+// NewOpaqueToken returns a random opaque access token and the hash it is
+// stored under. The token itself is never persisted.
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("read random: %w", err)
+	}
+	token = hex.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the value an opaque access token is looked up by.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsOpaqueToken reports whether token is an opaque access token rather than
+// a compact JWS.
+func IsOpaqueToken(token string) bool {
+	return !strings.Contains(token, ".")
+}
@@ -2,12 +2,13 @@ package middleware
 
 import (
 	"context"
-	"net"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/org"
 )
 
@@ -18,8 +19,9 @@ const (
 
 type orgContextKey struct{}
 
-// Org resolves the org from the Host header and stores it in Gin and request contexts.
-func Org(resolver *org.Resolver) gin.HandlerFunc {
+// Org resolves the org from the external host of the request, as reported by
+// trusted proxies, and stores it in Gin and request contexts.
+func Org(resolver *org.Resolver, issuers *issuer.Resolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orgSlug := strings.TrimSpace(c.Request.Header.Get("Host"))
 		if orgSlug == "" {
@@ -34,15 +36,25 @@ func Org(resolver *org.Resolver) gin.HandlerFunc {
 		if orgSlug != "" {
 			orgCtx, err = resolver.ResolveBySlug(c.Request.Context(), orgSlug)
 		} else {
-			host := stripPort(c.Request.Host)
-			orgCtx, err = resolver.Resolve(c.Request.Context(), host)
+			orgCtx, err = resolver.Resolve(c.Request.Context(), issuers.Host(c.Request))
 		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Unknown org."})
 			return
 		}
 
-		ctx := context.WithValue(c.Request.Context(), orgContextKey{}, orgCtx)
+		// Suspended orgs cannot sign users in or obtain tokens; deleted orgs
+		// are treated as gone while they wait to be purged.
+		switch strings.ToLower(orgCtx.Org.Status) {
+		case domain.OrgStatusSuspended:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access_denied", "error_description": "Org is suspended."})
+			return
+		case domain.OrgStatusDeleted:
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "invalid_tenant", "error_description": "Unknown org."})
+			return
+		}
+
+		ctx := WithOrgContext(c.Request.Context(), orgCtx)
 		// Legacy context keys for compatibility across handlers.
 		ctx = context.WithValue(ctx, "org_id", orgCtx.Org.ID)
 		ctx = context.WithValue(ctx, "tenant_id", orgCtx.Org.ID)
@@ -57,6 +69,11 @@ func Org(resolver *org.Resolver) gin.HandlerFunc {
 	}
 }
 
+// WithOrgContext returns a copy of ctx carrying orgCtx for the service layer.
+func WithOrgContext(ctx context.Context, orgCtx *org.Context) context.Context {
+	return context.WithValue(ctx, orgContextKey{}, orgCtx)
+}
+
 // OrgContextFromContext extracts the org context from a standard context.
 func OrgContextFromContext(ctx context.Context) (*org.Context, bool) {
 	value := ctx.Value(orgContextKey{})
@@ -66,12 +83,3 @@ func OrgContextFromContext(ctx context.Context) (*org.Context, bool) {
 	orgCtx, ok := value.(*org.Context)
 	return orgCtx, ok
 }
-
-func stripPort(host string) string {
-	if strings.Contains(host, ":") {
-		if h, _, err := net.SplitHostPort(host); err == nil {
-			return h
-		}
-	}
-	return host
-}
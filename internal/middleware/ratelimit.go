@@ -1,48 +1,129 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
 	"net/http"
-	"sync"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/ratelimit"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+const (
+	rateLimitKeyPrefix = "ratelimit:"
+	// ginRateLimitKey holds the result that set the RateLimit-* headers, so
+	// the most restrictive of several policies on a route is reported.
+	ginRateLimitKey = "rateLimit"
+	// maxPeekBody bounds how much of a request body is read to find the
+	// identifier or client_id it carries.
+	maxPeekBody = 64 * 1024
 )
 
-// RateLimiter enforces per-client throttling.
+// RateLimiter enforces named rate limit policies. Counts live in the shared
+// store so limits hold across replicas; while the store fails, requests are
+// counted in process instead.
 type RateLimiter struct {
-	limit   rate.Limit
-	burst   int
-	window  time.Duration
-	mu      sync.Mutex
-	clients map[string]*clientLimiter
-}
+	store    repository.RateLimitStore
+	fallback repository.RateLimitStore
+	policies map[string]ratelimit.Policy
+	issuers  *issuer.Resolver
+	logger   *zap.Logger
 
-type clientLimiter struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+	// degraded is set while the store fails, so the switch to and from the
+	// fallback is logged once rather than on every request.
+	degraded      atomic.Bool
+	degradedGauge metric.Int64UpDownCounter
 }
 
-// NewRateLimiter creates a limiter for the provided requests-per-minute budget.
-func NewRateLimiter(requestsPerMinute int) *RateLimiter {
-	if requestsPerMinute <= 0 {
-		return nil
+// NewRateLimiter creates a limiter enforcing policies in store. Clients are
+// keyed by their address behind trusted proxies. A nil store counts in
+// process only.
+func NewRateLimiter(store repository.RateLimitStore, policies map[string]ratelimit.Policy, issuers *issuer.Resolver, logger *zap.Logger) *RateLimiter {
+	if logger == nil {
+		logger = zap.L()
 	}
-	limit := rate.Limit(float64(requestsPerMinute) / 60.0)
-	burst := requestsPerMinute / 10
-	if burst < 1 {
-		burst = 1
+	fallback := ratelimit.NewMemoryStore()
+	if store == nil {
+		store = fallback
 	}
+	// Instrument creation only fails for invalid names; the noop fallback
+	// keeps limiting working either way.
+	degradedGauge, _ := otel.Meter("github.com/smallbiznis/railzway-auth/internal/middleware").Int64UpDownCounter(
+		"ratelimit.store.degraded",
+		metric.WithDescription("1 while the rate limit store is unavailable and requests are counted in process."),
+	)
 	return &RateLimiter{
-		limit:   limit,
-		burst:   burst,
-		window:  5 * time.Minute,
-		clients: make(map[string]*clientLimiter),
+		store:         store,
+		fallback:      fallback,
+		policies:      policies,
+		issuers:       issuers,
+		logger:        logger,
+		degradedGauge: degradedGauge,
 	}
 }
 
-// Handler returns the gin middleware enforcing throttling behaviour.
+// Handler applies the default policy to every request by client IP. It runs
+// before the org is resolved, so orgs cannot override it.
 func (r *RateLimiter) Handler() gin.HandlerFunc {
+	return r.ByIP(ratelimit.PolicyDefault)
+}
+
+// ByIP applies the named policy by client IP.
+func (r *RateLimiter) ByIP(policy string) gin.HandlerFunc {
+	return r.limit(policy, func(c *gin.Context) string {
+		return "ip:" + r.issuers.ClientIP(c.Request)
+	})
+}
+
+// ByIdentifier applies the named policy by the email, phone number or
+// username in the request body. Requests without one are not counted.
+func (r *RateLimiter) ByIdentifier(policy string) gin.HandlerFunc {
+	return r.limit(policy, func(c *gin.Context) string {
+		identifier := strings.ToLower(strings.TrimSpace(requestIdentifier(c.Request)))
+		if identifier == "" {
+			return ""
+		}
+		// Keys are hashed to keep addresses out of the store.
+		sum := sha256.Sum256([]byte(identifier))
+		return "id:" + hex.EncodeToString(sum[:])
+	})
+}
+
+// ByClientID applies the named policy by the client_id named in the request,
+// from HTTP Basic credentials or the form body, and the client IP. The
+// client_id is counted before the client authenticates, so keying it by
+// address too keeps anyone else from spending a client's budget.
+func (r *RateLimiter) ByClientID(policy string) gin.HandlerFunc {
+	return r.limit(policy, func(c *gin.Context) string {
+		clientID := requestClientID(c.Request)
+		if clientID == "" {
+			return ""
+		}
+		return "client:" + url.QueryEscape(clientID) + ":ip:" + r.issuers.ClientIP(c.Request)
+	})
+}
+
+// limit builds a middleware counting requests under the key returned by
+// keyOf; an empty key lets the request through uncounted.
+func (r *RateLimiter) limit(name string, keyOf func(*gin.Context) string) gin.HandlerFunc {
 	if r == nil {
 		return func(c *gin.Context) {
 			c.Next()
@@ -50,9 +131,26 @@ func (r *RateLimiter) Handler() gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
-		key := c.ClientIP()
-		limiter := r.getLimiter(key)
-		if !limiter.Allow() {
+		orgCtx, _ := OrgContextFromContext(c.Request.Context())
+		policy, ok := r.policy(name, orgCtx)
+		if !ok {
+			c.Next()
+			return
+		}
+		key := keyOf(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		scope := "global"
+		if orgCtx != nil && name != ratelimit.PolicyDefault {
+			scope = strconv.FormatInt(orgCtx.Org.ID, 10)
+		}
+		result := r.allow(c, rateLimitKeyPrefix+policy.Name+":"+scope+":"+key, policy)
+		setRateLimitHeaders(c, policy, result)
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"error":             "rate_limited",
 				"error_description": "Too many requests. Please slow down.",
@@ -64,26 +162,130 @@ func (r *RateLimiter) Handler() gin.HandlerFunc {
 	}
 }
 
-func (r *RateLimiter) getLimiter(key string) *rate.Limiter {
-	now := time.Now()
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// policy returns the named policy with the org's override applied, and
+// false when it is not configured or disabled.
+func (r *RateLimiter) policy(name string, orgCtx *org.Context) (ratelimit.Policy, bool) {
+	policy, ok := r.policies[name]
+	if !ok {
+		return ratelimit.Policy{}, false
+	}
+	if orgCtx != nil && ratelimit.Overridable(name) {
+		for _, override := range orgCtx.RateLimits {
+			if override.Policy == name {
+				policy.Limit = override.Limit
+				policy.Window = time.Duration(override.WindowSeconds) * time.Second
+				break
+			}
+		}
+	}
+	return policy, policy.Limit > 0 && policy.Window > 0
+}
+
+func (r *RateLimiter) allow(c *gin.Context, key string, policy ratelimit.Policy) domain.RateLimitResult {
+	ctx := c.Request.Context()
+	result, err := r.store.Allow(ctx, key, policy.Limit, policy.Window)
+	if err == nil {
+		if r.degraded.CompareAndSwap(true, false) {
+			r.degradedGauge.Add(ctx, -1)
+			r.logger.Info("rate limit store recovered, counting in the store again")
+		}
+		return result
+	}
+	if r.degraded.CompareAndSwap(false, true) {
+		r.degradedGauge.Add(ctx, 1)
+		r.logger.Warn("rate limit store unavailable, counting in process", zap.String("policy", policy.Name), zap.Error(err))
+	}
+	result, err = r.fallback.Allow(ctx, key, policy.Limit, policy.Window)
+	if err != nil {
+		// The in-process store does not fail; never lock clients out if it
+		// somehow does.
+		return domain.RateLimitResult{Allowed: true, Limit: policy.Limit, Remaining: policy.Limit}
+	}
+	return result
+}
 
-	if entry, ok := r.clients[key]; ok {
-		entry.lastSeen = now
-		return entry.limiter
+// setRateLimitHeaders reports result unless an earlier policy on the route
+// left fewer requests.
+func setRateLimitHeaders(c *gin.Context, policy ratelimit.Policy, result domain.RateLimitResult) {
+	if previous, ok := c.Get(ginRateLimitKey); ok {
+		if prev, ok := previous.(domain.RateLimitResult); ok && result.Allowed && prev.Remaining <= result.Remaining {
+			return
+		}
 	}
+	c.Set(ginRateLimitKey, result)
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	c.Header("RateLimit-Policy", policy.String())
+}
 
-	limiter := rate.NewLimiter(r.limit, r.burst)
-	r.clients[key] = &clientLimiter{limiter: limiter, lastSeen: now}
-	r.cleanupLocked(now)
-	return limiter
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
 
-func (r *RateLimiter) cleanupLocked(now time.Time) {
-	for key, entry := range r.clients {
-		if now.Sub(entry.lastSeen) > r.window {
-			delete(r.clients, key)
+// requestIdentifier returns the account identifier named by a JSON login
+// request or an OAuth password grant.
+func requestIdentifier(req *http.Request) string {
+	body := peekBody(req)
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return ""
 		}
+		return form.Get("username")
+	}
+	var fields struct {
+		Email string `json:"email"`
+		Phone string `json:"phone"`
 	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	if fields.Email != "" {
+		return fields.Email
+	}
+	return fields.Phone
+}
+
+func requestClientID(req *http.Request) string {
+	if value := req.Header.Get("Authorization"); len(value) > 6 && strings.EqualFold(value[:6], "basic ") {
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[6:])); err == nil {
+			if id, _, ok := strings.Cut(string(decoded), ":"); ok {
+				if unescaped, err := url.QueryUnescape(id); err == nil && unescaped != "" {
+					return unescaped
+				}
+			}
+		}
+	}
+	body := peekBody(req)
+	if len(body) == 0 {
+		return ""
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+	return form.Get("client_id")
+}
+
+// peekBody returns up to maxPeekBody bytes of the request body and puts
+// them back for the handler.
+func peekBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxPeekBody))
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
 }
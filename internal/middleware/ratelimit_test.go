@@ -0,0 +1,214 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/ratelimit"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+func TestRateLimiterPolicies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := ratelimit.DefaultPolicies(0)
+	policies[ratelimit.PolicyAuth] = ratelimit.Policy{Name: ratelimit.PolicyAuth, Limit: 3, Window: time.Minute}
+	policies[ratelimit.PolicyAuthIdentifier] = ratelimit.Policy{Name: ratelimit.PolicyAuthIdentifier, Limit: 1, Window: time.Minute}
+	limiter := middleware.NewRateLimiter(nil, policies, nil, zap.NewNop())
+
+	r := gin.New()
+	r.Use(middleware.Org(org.NewResolver(&rateLimitOrgRepo{}), nil))
+	r.POST("/login", limiter.ByIP(ratelimit.PolicyAuth), limiter.ByIdentifier(ratelimit.PolicyAuthIdentifier), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	login := func(host, email string) *httptest.ResponseRecorder {
+		body := `{"email":"` + email + `","password":"secret"}`
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set("X-Tenant-ID", host)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := login("acme", "a@example.com")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "a@example.com", "the handler still reads the body")
+	require.Equal(t, "1", rec.Header().Get("RateLimit-Limit"), "the most restrictive policy is reported")
+	require.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	rec = login("acme", "A@example.com ")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, "identifiers are normalised")
+	require.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusOK, login("acme", "b@example.com").Code)
+	rec = login("acme", "c@example.com")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, "the IP budget is spent")
+	require.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+
+	// globex raises its auth limit to 10, and counts apart from acme.
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, login("globex", "user"+strconv.Itoa(i)+"@example.com").Code)
+	}
+}
+
+func TestRateLimiterClientIDAndFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := map[string]ratelimit.Policy{
+		ratelimit.PolicyTokenClient: {Name: ratelimit.PolicyTokenClient, Limit: 1, Window: time.Minute},
+	}
+	limiter := middleware.NewRateLimiter(failingRateLimitStore{}, policies, nil, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/oauth/token", limiter.ByClientID(ratelimit.PolicyTokenClient), func(c *gin.Context) {
+		c.String(http.StatusOK, c.PostForm("grant_type"))
+	})
+	remoteAddr := "192.0.2.1:1234"
+	token := func(client string, basic bool) *httptest.ResponseRecorder {
+		body := "grant_type=client_credentials"
+		if !basic {
+			body += "&client_id=" + client
+		}
+		req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basic {
+			req.SetBasicAuth(client, "secret")
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := token("web", true)
+	require.Equal(t, http.StatusOK, rec.Code, "an unavailable store falls back to counting in process")
+	require.Equal(t, "client_credentials", rec.Body.String())
+	require.Equal(t, http.StatusTooManyRequests, token("web", false).Code)
+	require.Equal(t, http.StatusOK, token("cli", false).Code)
+	remoteAddr = "198.51.100.7:4321"
+	require.Equal(t, http.StatusOK, token("web", false).Code, "requests naming a client_id from another address count apart")
+
+	var none *middleware.RateLimiter
+	r = gin.New()
+	r.GET("/", none.Handler(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestRateLimiterLogsFallbackTransitionsOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.InfoLevel)
+	store := &flakyRateLimitStore{}
+	store.down.Store(true)
+	policies := map[string]ratelimit.Policy{
+		ratelimit.PolicyDefault: {Name: ratelimit.PolicyDefault, Limit: 100, Window: time.Minute},
+	}
+	limiter := middleware.NewRateLimiter(store, policies, nil, zap.New(core))
+
+	r := gin.New()
+	r.GET("/", limiter.Handler(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	get := func() {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusNoContent, rec.Code)
+	}
+
+	for i := 0; i < 5; i++ {
+		get()
+	}
+	require.Equal(t, 1, logs.FilterMessageSnippet("unavailable").Len(), "an outage is logged once")
+
+	store.down.Store(false)
+	get()
+	get()
+	require.Equal(t, 1, logs.FilterMessageSnippet("recovered").Len())
+
+	store.down.Store(true)
+	get()
+	require.Equal(t, 2, logs.FilterMessageSnippet("unavailable").Len(), "a new outage is logged again")
+}
+
+// flakyRateLimitStore fails while down and allows every request otherwise.
+type flakyRateLimitStore struct {
+	down atomic.Bool
+}
+
+func (s *flakyRateLimitStore) Allow(_ context.Context, _ string, limit int, _ time.Duration) (domain.RateLimitResult, error) {
+	if s.down.Load() {
+		return domain.RateLimitResult{}, errors.New("redis: connection refused")
+	}
+	return domain.RateLimitResult{Allowed: true, Limit: limit, Remaining: limit}, nil
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Allow(context.Context, string, int, time.Duration) (domain.RateLimitResult, error) {
+	return domain.RateLimitResult{}, errors.New("redis: connection refused")
+}
+
+// rateLimitOrgRepo resolves orgs by slug; globex overrides the auth policy.
+type rateLimitOrgRepo struct {
+	repository.OrgRepository
+}
+
+func (rateLimitOrgRepo) GetOrgBySlug(_ context.Context, slug string) (domain.Org, error) {
+	id := int64(1)
+	if slug == "globex" {
+		id = 2
+	}
+	return domain.Org{ID: id, Slug: slug, Status: domain.OrgStatusActive}, nil
+}
+
+func (rateLimitOrgRepo) GetPrimaryDomain(_ context.Context, orgID int64) (domain.Domain, error) {
+	return domain.Domain{OrgID: orgID, Host: "auth.example"}, nil
+}
+
+func (rateLimitOrgRepo) GetBranding(_ context.Context, orgID int64) (domain.Branding, error) {
+	return domain.Branding{OrgID: orgID}, nil
+}
+
+func (rateLimitOrgRepo) ListAuthProviders(context.Context, int64) ([]domain.AuthProvider, error) {
+	return nil, nil
+}
+
+func (rateLimitOrgRepo) GetPasswordConfig(_ context.Context, orgID int64) (domain.PasswordConfig, error) {
+	return domain.PasswordConfig{OrgID: orgID}, nil
+}
+
+func (rateLimitOrgRepo) GetOTPConfig(_ context.Context, orgID int64) (domain.OTPConfig, error) {
+	return domain.OTPConfig{OrgID: orgID}, nil
+}
+
+func (rateLimitOrgRepo) GetMFAConfig(_ context.Context, orgID int64) (domain.MFAConfig, error) {
+	return domain.MFAConfig{OrgID: orgID}, nil
+}
+
+func (rateLimitOrgRepo) ListOAuthIDPConfigs(context.Context, int64) ([]domain.OAuthIDPConfig, error) {
+	return nil, nil
+}
+
+func (rateLimitOrgRepo) ListRateLimitOverrides(_ context.Context, orgID int64) ([]domain.RateLimitOverride, error) {
+	if orgID != 2 {
+		return nil, nil
+	}
+	return []domain.RateLimitOverride{{OrgID: orgID, Policy: ratelimit.PolicyAuth, Limit: 10, WindowSeconds: 60}}, nil
+}
@@ -0,0 +1,135 @@
+package org
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache stores resolved org contexts keyed by lookup (host or slug).
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) (*Context, bool)
+	// Version returns the cache generation, which every Invalidate advances.
+	// Take it before loading a context from the database.
+	Version(ctx context.Context) uint64
+	// Set stores value unless the cache was invalidated since version, so a
+	// load that raced an update cannot put stale data back.
+	Set(ctx context.Context, key string, value *Context, version uint64)
+	// Invalidate drops every entry that belongs to orgID.
+	Invalidate(ctx context.Context, orgID int64) error
+}
+
+// LocalCache is an in-process LRU cache with a fixed entry lifetime.
+type LocalCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List
+	version  uint64
+	now      func() time.Time
+}
+
+type localEntry struct {
+	key       string
+	value     *Context
+	expiresAt time.Time
+}
+
+var _ Cache = (*LocalCache)(nil)
+
+// NewLocalCache creates an LRU cache holding up to capacity contexts for ttl.
+func NewLocalCache(capacity int, ttl time.Duration) *LocalCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LocalCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns a live entry and marks it as recently used.
+func (c *LocalCache) Get(_ context.Context, key string) (*Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*localEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Version returns the number of invalidations so far.
+func (c *LocalCache) Version(_ context.Context) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// Set stores value, evicting the least recently used entry when full. It
+// does nothing if Invalidate ran since version was taken.
+func (c *LocalCache) Set(_ context.Context, key string, value *Context, version uint64) {
+	if value == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.version {
+		return
+	}
+
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*localEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&localEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate drops all entries resolved for orgID.
+func (c *LocalCache) Invalidate(_ context.Context, orgID int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*localEntry).value.Org.ID == orgID {
+			c.remove(elem)
+		}
+		elem = next
+	}
+	return nil
+}
+
+// Len reports the number of cached entries, including expired ones not yet
+// evicted.
+func (c *LocalCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LocalCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*localEntry).key)
+}
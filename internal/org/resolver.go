@@ -2,34 +2,85 @@ package org
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 
 	"github.com/smallbiznis/railzway-auth/internal/domain"
 	"github.com/smallbiznis/railzway-auth/internal/repository"
 	"go.uber.org/zap"
 )
 
+// ErrDomainNotVerified is returned when a host is registered to an org but its
+// ownership has not been verified yet. Such hosts must not be routed.
+var ErrDomainNotVerified = errors.New("domain not verified")
+
 // Context stores resolved org metadata used throughout the request lifecycle.
 type Context struct {
-	Domain          domain.Domain
+	Domain domain.Domain
+	// PrimaryHost is the org's primary domain, which issuers are pinned to
+	// when configured.
+	PrimaryHost     string
 	Org             domain.Org
 	ClientID        string
 	Branding        domain.Branding
 	AuthProviders   []domain.AuthProvider
 	PasswordConfig  domain.PasswordConfig
 	OTPConfig       domain.OTPConfig
+	MFAConfig       domain.MFAConfig
 	SocialProviders []domain.OAuthIDPConfig
+	// RateLimits override the server-wide limits of named rate limit
+	// policies for this org.
+	RateLimits []domain.RateLimitOverride
 }
 
 // Resolver loads org metadata from repositories.
 type Resolver struct {
-	repo repository.OrgRepository
+	repo  repository.OrgRepository
+	cache Cache
+
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	lookups metric.Int64Counter
 }
 
 // NewResolver creates an org resolver.
 func NewResolver(repo repository.OrgRepository) *Resolver {
-	return &Resolver{repo: repo}
+	// Instrument creation only fails for invalid names; the noop fallback
+	// keeps resolution working either way.
+	lookups, _ := otel.Meter("github.com/smallbiznis/railzway-auth/internal/org").Int64Counter(
+		"org_context.cache.lookups",
+		metric.WithDescription("Org context cache lookups by result (hit or miss)."),
+	)
+	return &Resolver{repo: repo, lookups: lookups}
+}
+
+// WithCache enables caching of resolved contexts.
+func (r *Resolver) WithCache(cache Cache) *Resolver {
+	r.cache = cache
+	return r
+}
+
+// Invalidate drops cached contexts for orgID. Call it after changing any
+// configuration that is part of Context.
+func (r *Resolver) Invalidate(ctx context.Context, orgID int64) error {
+	if r.cache == nil {
+		return nil
+	}
+	if err := r.cache.Invalidate(ctx, orgID); err != nil {
+		return fmt.Errorf("invalidate org context: %w", err)
+	}
+	return nil
+}
+
+// CacheStats reports cache hits and misses since the resolver was created.
+func (r *Resolver) CacheStats() (hits, misses uint64) {
+	return r.hits.Load(), r.misses.Load()
 }
 
 // Resolve loads org information from host header.
@@ -40,11 +91,21 @@ func (r *Resolver) Resolve(ctx context.Context, host string) (*Context, error) {
 		return nil, fmt.Errorf("resolve org: empty host")
 	}
 
+	key := "host:" + cleaned
+	if cached, ok := r.cached(ctx, key); ok {
+		return cached, nil
+	}
+	version := r.cacheVersion(ctx)
+
 	domainRow, err := r.repo.GetDomainByHost(ctx, cleaned)
 	if err != nil {
 		zap.L().Error("failed to resolve domain", zap.String("host", cleaned), zap.Error(err))
 		return nil, fmt.Errorf("resolve domain: %w", err)
 	}
+	if !domainRow.Verified {
+		zap.L().Warn("refusing unverified domain", zap.String("host", cleaned), zap.Int64("org_id", domainRow.OrgID))
+		return nil, fmt.Errorf("resolve domain %q: %w", cleaned, ErrDomainNotVerified)
+	}
 
 	orgRow, err := r.repo.GetOrg(ctx, domainRow.OrgID)
 	if err != nil {
@@ -52,7 +113,13 @@ func (r *Resolver) Resolve(ctx context.Context, host string) (*Context, error) {
 		return nil, fmt.Errorf("resolve org: %w", err)
 	}
 
-	return r.buildContext(ctx, domainRow, orgRow)
+	primary, err := r.repo.GetPrimaryDomain(ctx, orgRow.ID)
+	if err != nil {
+		zap.L().Error("failed to resolve primary domain", zap.Int64("org_id", orgRow.ID), zap.String("host", cleaned), zap.Error(err))
+		return nil, fmt.Errorf("resolve primary domain: %w", err)
+	}
+
+	return r.buildAndStore(ctx, key, version, domainRow, primary.Host, orgRow)
 }
 
 // ResolveBySlug loads org information using org slug header.
@@ -63,6 +130,12 @@ func (r *Resolver) ResolveBySlug(ctx context.Context, slug string) (*Context, er
 		return nil, fmt.Errorf("resolve org: empty slug")
 	}
 
+	key := "slug:" + cleaned
+	if cached, ok := r.cached(ctx, key); ok {
+		return cached, nil
+	}
+	version := r.cacheVersion(ctx)
+
 	orgRow, err := r.repo.GetOrgBySlug(ctx, cleaned)
 	if err != nil {
 		zap.L().Error("failed to resolve org by slug", zap.String("slug", cleaned), zap.Error(err))
@@ -75,10 +148,46 @@ func (r *Resolver) ResolveBySlug(ctx context.Context, slug string) (*Context, er
 		return nil, fmt.Errorf("resolve primary domain: %w", err)
 	}
 
-	return r.buildContext(ctx, domainRow, orgRow)
+	return r.buildAndStore(ctx, key, version, domainRow, domainRow.Host, orgRow)
 }
 
-func (r *Resolver) buildContext(ctx context.Context, domainRow domain.Domain, orgRow domain.Org) (*Context, error) {
+func (r *Resolver) cached(ctx context.Context, key string) (*Context, bool) {
+	if r.cache == nil {
+		return nil, false
+	}
+	value, ok := r.cache.Get(ctx, key)
+	if !ok || value == nil {
+		r.misses.Add(1)
+		r.lookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "miss")))
+		return nil, false
+	}
+	r.hits.Add(1)
+	r.lookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "hit")))
+	return value.clone(), true
+}
+
+func (r *Resolver) cacheVersion(ctx context.Context) uint64 {
+	if r.cache == nil {
+		return 0
+	}
+	return r.cache.Version(ctx)
+}
+
+// buildAndStore loads the rest of the context and caches it, unless the
+// cache was invalidated after version was taken.
+func (r *Resolver) buildAndStore(ctx context.Context, key string, version uint64, domainRow domain.Domain, primaryHost string, orgRow domain.Org) (*Context, error) {
+	built, err := r.buildContext(ctx, domainRow, primaryHost, orgRow)
+	if err != nil {
+		return nil, err
+	}
+	if r.cache == nil {
+		return built, nil
+	}
+	r.cache.Set(ctx, key, built, version)
+	return built.clone(), nil
+}
+
+func (r *Resolver) buildContext(ctx context.Context, domainRow domain.Domain, primaryHost string, orgRow domain.Org) (*Context, error) {
 	branding, err := r.repo.GetBranding(ctx, orgRow.ID)
 	if err != nil {
 		zap.L().Error("failed to resolve branding", zap.Int64("org_id", orgRow.ID), zap.Error(err))
@@ -102,23 +211,56 @@ func (r *Resolver) buildContext(ctx context.Context, domainRow domain.Domain, or
 		zap.L().Error("failed to load otp config", zap.Int64("org_id", orgRow.ID), zap.Error(err))
 		return nil, fmt.Errorf("resolve otp config: %w", err)
 	}
+	// Like IdP secrets below, the delivery API key stays out of the cache;
+	// RequestOTP loads it when sending.
+	otpConfig.APIKey = ""
+
+	mfaConfig, err := r.repo.GetMFAConfig(ctx, orgRow.ID)
+	if err != nil {
+		zap.L().Error("failed to load mfa config", zap.Int64("org_id", orgRow.ID), zap.Error(err))
+		return nil, fmt.Errorf("resolve mfa config: %w", err)
+	}
 
 	socialProviders, err := r.repo.ListOAuthIDPConfigs(ctx, orgRow.ID)
 	if err != nil {
 		zap.L().Error("failed to load social providers", zap.Int64("org_id", orgRow.ID), zap.Error(err))
 		return nil, fmt.Errorf("resolve social providers: %w", err)
 	}
+	// Contexts are cached in Redis; the OAuth service loads IdP credentials
+	// itself, so client secrets are never part of one.
+	for i := range socialProviders {
+		socialProviders[i].ClientSecret = ""
+	}
+
+	rateLimits, err := r.repo.ListRateLimitOverrides(ctx, orgRow.ID)
+	if err != nil {
+		zap.L().Error("failed to load rate limit overrides", zap.Int64("org_id", orgRow.ID), zap.Error(err))
+		return nil, fmt.Errorf("resolve rate limits: %w", err)
+	}
 
 	zap.L().Debug("org context resolved", zap.String("host", domainRow.Host), zap.Int64("org_id", orgRow.ID))
 
 	return &Context{
-		Domain: domainRow,
-		Org:    orgRow,
+		Domain:      domainRow,
+		PrimaryHost: primaryHost,
+		Org:         orgRow,
 		// ClientID:        orgRow.Code,
 		Branding:        branding,
 		AuthProviders:   authProviders,
 		PasswordConfig:  passwordConfig,
 		OTPConfig:       otpConfig,
+		MFAConfig:       mfaConfig,
 		SocialProviders: socialProviders,
+		RateLimits:      rateLimits,
 	}, nil
 }
+
+// clone returns a copy the caller may mutate (the service layer sets ClientID
+// per request) without touching the cached value.
+func (c *Context) clone() *Context {
+	out := *c
+	out.AuthProviders = append([]domain.AuthProvider(nil), c.AuthProviders...)
+	out.SocialProviders = append([]domain.OAuthIDPConfig(nil), c.SocialProviders...)
+	out.RateLimits = append([]domain.RateLimitOverride(nil), c.RateLimits...)
+	return &out
+}
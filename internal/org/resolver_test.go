@@ -2,7 +2,9 @@ package org_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -21,6 +23,9 @@ func TestResolverResolve(t *testing.T) {
 	// require.Equal(t, "client", ctx.ClientID)
 	require.Len(t, ctx.AuthProviders, 1)
 	require.Equal(t, 8, ctx.PasswordConfig.MinLength)
+	require.Equal(t, "tenant.smallbiznis.test", ctx.Domain.Host)
+	require.Equal(t, "primary.smallbiznis.test", ctx.PrimaryHost)
+	require.Len(t, ctx.RateLimits, 1)
 }
 
 func TestResolverResolveBySlug(t *testing.T) {
@@ -31,35 +36,148 @@ func TestResolverResolveBySlug(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(1), ctx.Org.ID)
 	require.Equal(t, "primary.smallbiznis.test", ctx.Domain.Host)
+	require.Equal(t, "primary.smallbiznis.test", ctx.PrimaryHost)
 }
 
-type mockOrgRepo struct{}
+func TestResolverRefusesUnverifiedDomain(t *testing.T) {
+	resolver := org.NewResolver(&mockOrgRepo{unverified: true})
+
+	_, err := resolver.Resolve(context.Background(), "pending.example.com")
+	require.ErrorIs(t, err, org.ErrDomainNotVerified)
+}
+
+func TestResolverCachesContexts(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockOrgRepo{}
+	resolver := org.NewResolver(repo).WithCache(org.NewLocalCache(10, time.Minute))
+
+	first, err := resolver.Resolve(ctx, "tenant.smallbiznis.test")
+	require.NoError(t, err)
+	calls := repo.calls
+	require.Positive(t, calls)
+	first.ClientID = "mutated"
+
+	second, err := resolver.Resolve(ctx, "tenant.smallbiznis.test")
+	require.NoError(t, err)
+	require.Equal(t, calls, repo.calls, "cache hit must not touch the repository")
+	require.Empty(t, second.ClientID, "callers get their own copy")
+	hits, misses := resolver.CacheStats()
+	require.Equal(t, uint64(1), hits)
+	require.Equal(t, uint64(1), misses)
+
+	require.NoError(t, resolver.Invalidate(ctx, first.Org.ID))
+	_, err = resolver.Resolve(ctx, "tenant.smallbiznis.test")
+	require.NoError(t, err)
+	require.Equal(t, 2*calls, repo.calls, "invalidation forces a reload")
+}
+
+func TestResolverDropsLoadsThatRaceInvalidation(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockOrgRepo{}
+	cache := org.NewLocalCache(10, time.Minute)
+	resolver := org.NewResolver(repo).WithCache(cache)
+	repo.onBranding = func() { require.NoError(t, resolver.Invalidate(ctx, 1)) }
+
+	resolved, err := resolver.Resolve(ctx, "tenant.smallbiznis.test")
+	require.NoError(t, err)
+	require.Equal(t, "id", resolved.SocialProviders[0].ClientID)
+	require.Empty(t, resolved.SocialProviders[0].ClientSecret, "IdP secrets are not part of cached contexts")
+	require.Equal(t, "twilio", resolved.OTPConfig.Provider)
+	require.Empty(t, resolved.OTPConfig.APIKey, "nor is the OTP delivery key")
+	require.Zero(t, cache.Len(), "a load that raced an invalidation is not cached")
+
+	repo.onBranding = nil
+	_, err = resolver.Resolve(ctx, "tenant.smallbiznis.test")
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.Len())
+}
+
+func TestLocalCacheEvictsLeastRecentlyUsedAndExpired(t *testing.T) {
+	ctx := context.Background()
+	cache := org.NewLocalCache(2, time.Minute)
+	cache.Set(ctx, "a", &org.Context{Org: domain.Org{ID: 1}}, 0)
+	cache.Set(ctx, "b", &org.Context{Org: domain.Org{ID: 2}}, 0)
+	_, ok := cache.Get(ctx, "a")
+	require.True(t, ok)
+	cache.Set(ctx, "c", &org.Context{Org: domain.Org{ID: 3}}, 0)
+
+	_, ok = cache.Get(ctx, "b")
+	require.False(t, ok, "least recently used entry is evicted")
+	_, ok = cache.Get(ctx, "a")
+	require.True(t, ok)
+
+	short := org.NewLocalCache(2, 10*time.Millisecond)
+	short.Set(ctx, "a", &org.Context{Org: domain.Org{ID: 1}}, 0)
+	time.Sleep(20 * time.Millisecond)
+	_, ok = short.Get(ctx, "a")
+	require.False(t, ok, "expired entries are not served")
+}
+
+// BenchmarkResolverResolve compares repository round trips per request with
+// and without the org context cache.
+func BenchmarkResolverResolve(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cached=%t", cached), func(b *testing.B) {
+			ctx := context.Background()
+			repo := &mockOrgRepo{}
+			resolver := org.NewResolver(repo)
+			if cached {
+				resolver.WithCache(org.NewLocalCache(10, time.Minute))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := resolver.Resolve(ctx, "tenant.smallbiznis.test"); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(repo.calls)/float64(b.N), "repo_calls/op")
+		})
+	}
+}
+
+// mockOrgRepo counts calls made while resolving a context.
+type mockOrgRepo struct {
+	calls      int
+	unverified bool
+	// onBranding runs while a context is being built.
+	onBranding func()
+}
 
 func (m *mockOrgRepo) GetDomainByHost(ctx context.Context, host string) (domain.Domain, error) {
-	return domain.Domain{ID: 1, Host: host, OrgID: 1}, nil
+	m.calls++
+	return domain.Domain{ID: 1, Host: host, OrgID: 1, Verified: !m.unverified}, nil
 }
 
 func (m *mockOrgRepo) GetOrg(ctx context.Context, orgID int64) (domain.Org, error) {
+	m.calls++
 	return domain.Org{ID: orgID, Name: "SmallBiznis", Code: "client", Slug: "smallbiznis"}, nil
 }
 
 func (m *mockOrgRepo) GetOrgBySlug(ctx context.Context, slug string) (domain.Org, error) {
+	m.calls++
 	return domain.Org{ID: 1, Name: "SmallBiznis", Code: "client", Slug: slug}, nil
 }
 
 func (m *mockOrgRepo) GetPrimaryDomain(ctx context.Context, orgID int64) (domain.Domain, error) {
+	m.calls++
 	return domain.Domain{ID: orgID, Host: "primary.smallbiznis.test", OrgID: orgID}, nil
 }
 
 func (m *mockOrgRepo) GetBranding(ctx context.Context, orgID int64) (domain.Branding, error) {
+	m.calls++
+	if m.onBranding != nil {
+		m.onBranding()
+	}
 	return domain.Branding{OrgID: orgID, LogoURL: strPtr("https://cdn/logo.png")}, nil
 }
 
 func (m *mockOrgRepo) ListAuthProviders(ctx context.Context, orgID int64) ([]domain.AuthProvider, error) {
+	m.calls++
 	return []domain.AuthProvider{{OrgID: orgID, ProviderType: "password", IsActive: true}}, nil
 }
 
 func (m *mockOrgRepo) GetPasswordConfig(ctx context.Context, orgID int64) (domain.PasswordConfig, error) {
+	m.calls++
 	return domain.PasswordConfig{
 		OrgID:                  orgID,
 		MinLength:              8,
@@ -74,13 +192,61 @@ func (m *mockOrgRepo) GetPasswordConfig(ctx context.Context, orgID int64) (domai
 }
 
 func (m *mockOrgRepo) GetOTPConfig(ctx context.Context, orgID int64) (domain.OTPConfig, error) {
-	return domain.OTPConfig{OrgID: orgID, Channel: "sms", ExpirySeconds: 300}, nil
+	m.calls++
+	return domain.OTPConfig{OrgID: orgID, Channel: "sms", Provider: "twilio", APIKey: "key", ExpirySeconds: 300}, nil
 }
 
 func (m *mockOrgRepo) ListOAuthIDPConfigs(ctx context.Context, orgID int64) ([]domain.OAuthIDPConfig, error) {
+	m.calls++
 	return []domain.OAuthIDPConfig{{OrgID: orgID, Provider: "google", ClientID: "id", ClientSecret: "secret", AuthorizationURL: "https://auth", TokenURL: "https://token", UserinfoURL: "https://userinfo", JWKSURL: "https://jwks"}}, nil
 }
 
+func (m *mockOrgRepo) GetMFAConfig(ctx context.Context, orgID int64) (domain.MFAConfig, error) {
+	m.calls++
+	return domain.MFAConfig{OrgID: orgID, Policy: domain.MFAPolicyOff}, nil
+}
+
+func (m *mockOrgRepo) ListRateLimitOverrides(ctx context.Context, orgID int64) ([]domain.RateLimitOverride, error) {
+	m.calls++
+	return []domain.RateLimitOverride{{OrgID: orgID, Policy: "auth", Limit: 5, WindowSeconds: 60}}, nil
+}
+
+func (m *mockOrgRepo) Create(ctx context.Context, org domain.Org) (domain.Org, error) {
+	return org, nil
+}
+
+func (m *mockOrgRepo) GetByExternalID(ctx context.Context, externalID string) (domain.Org, error) {
+	return domain.Org{ID: 1, Name: "SmallBiznis", Code: "client", ExternalID: externalID}, nil
+}
+
+func (m *mockOrgRepo) Count(ctx context.Context) (int64, error) {
+	return 1, nil
+}
+
+func (m *mockOrgRepo) List(ctx context.Context, status string, limit, offset int) ([]domain.Org, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockOrgRepo) Update(ctx context.Context, org domain.Org) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) SetStatus(ctx context.Context, orgID int64, status string) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) SoftDelete(ctx context.Context, orgID int64) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) Restore(ctx context.Context, orgID int64, status string) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int64, error) {
+	return nil, nil
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+// MemoryStore is a RateLimitStore local to the process. Limits are not
+// shared between replicas, so it only stands in while Redis is unavailable.
+type MemoryStore struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+var _ repository.RateLimitStore = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-process store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time), now: time.Now}
+}
+
+// Allow implements GCRA: each key stores the theoretical arrival time of
+// its next request, which advances by window/limit per allowed request and
+// may run at most one window ahead of now.
+func (s *MemoryStore) Allow(_ context.Context, key string, limit int, window time.Duration) (domain.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.pruneLocked(now)
+
+	tat, ok := s.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+	result, next := gcra(now, tat, limit, window)
+	if result.Allowed {
+		s.tats[key] = next
+	}
+	return result, nil
+}
+
+// pruneLocked drops keys whose limit has fully recovered, at most once a
+// minute.
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+}
+
+// gcra decides a request arriving at now against a key whose theoretical
+// arrival time is tat (never before now), and returns the tat to store if
+// the request is allowed.
+func gcra(now, tat time.Time, limit int, window time.Duration) (domain.RateLimitResult, time.Time) {
+	interval := window / time.Duration(limit)
+	next := tat.Add(interval)
+	allowAt := next.Add(-window)
+	if now.Before(allowAt) {
+		return domain.RateLimitResult{
+			Limit:      limit,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}, tat
+	}
+	return domain.RateLimitResult{
+		Allowed:    true,
+		Limit:      limit,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: next.Sub(now),
+	}, next
+}
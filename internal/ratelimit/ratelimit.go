@@ -0,0 +1,94 @@
+// Package ratelimit defines the named rate limit policies applied to route
+// groups and an in-process GCRA store used when Redis is unavailable.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy names. Default applies to every request by client IP; the others
+// are attached to route groups by the router.
+const (
+	PolicyDefault = "default"
+	// PolicyAuth throttles login, registration and code delivery by client
+	// IP, and PolicyAuthIdentifier the same routes by the email or phone
+	// number they name.
+	PolicyAuth           = "auth"
+	PolicyAuthIdentifier = "auth_identifier"
+	// PolicyToken throttles the token endpoint by client IP, and
+	// PolicyTokenClient by the authenticating client_id.
+	PolicyToken       = "token"
+	PolicyTokenClient = "token_client"
+)
+
+// Policy allows Limit requests per Window for each key it counts.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// String formats the policy for the RateLimit-Policy header, e.g. "30;w=60".
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Window/time.Second))
+}
+
+// Orgs may override every policy but the default one, which is enforced
+// before the org is resolved.
+var overridable = map[string]bool{
+	PolicyAuth:           true,
+	PolicyAuthIdentifier: true,
+	PolicyToken:          true,
+	PolicyTokenClient:    true,
+}
+
+// Overridable reports whether orgs may override the named policy.
+func Overridable(name string) bool {
+	return overridable[name]
+}
+
+// DefaultPolicies returns the built-in policies. defaultRPM is the
+// per-minute budget of the default policy; zero or less disables it.
+func DefaultPolicies(defaultRPM int) map[string]Policy {
+	return map[string]Policy{
+		PolicyDefault:        {Name: PolicyDefault, Limit: defaultRPM, Window: time.Minute},
+		PolicyAuth:           {Name: PolicyAuth, Limit: 30, Window: time.Minute},
+		PolicyAuthIdentifier: {Name: PolicyAuthIdentifier, Limit: 10, Window: 5 * time.Minute},
+		PolicyToken:          {Name: PolicyToken, Limit: 120, Window: time.Minute},
+		PolicyTokenClient:    {Name: PolicyTokenClient, Limit: 600, Window: time.Minute},
+	}
+}
+
+// ParsePolicies applies overrides written as "name=limit/window", e.g.
+// "auth=20/1m", on top of the built-in policies. A limit of zero disables
+// the policy.
+func ParsePolicies(defaultRPM int, specs []string) (map[string]Policy, error) {
+	policies := DefaultPolicies(defaultRPM)
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(strings.TrimSpace(spec), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("rate limit policy %q: want name=limit/window", spec)
+		}
+		if _, known := policies[name]; !known {
+			return nil, fmt.Errorf("rate limit policy %q: unknown policy %q", spec, name)
+		}
+		limitValue, windowValue, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit policy %q: want name=limit/window", spec)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitValue))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("rate limit policy %q: invalid limit", spec)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowValue))
+		if err != nil || window < time.Second {
+			return nil, fmt.Errorf("rate limit policy %q: window must be a duration of at least 1s", spec)
+		}
+		policies[name] = Policy{Name: name, Limit: limit, Window: window}
+	}
+	return policies, nil
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreAllowsLimitPerWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		result, err := store.Allow(ctx, "key", 3, time.Minute)
+		require.NoError(t, err)
+		require.True(t, result.Allowed, "request %d", i)
+		require.Equal(t, 2-i, result.Remaining)
+	}
+	result, err := store.Allow(ctx, "key", 3, time.Minute)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 20*time.Second, result.RetryAfter)
+	require.Equal(t, time.Minute, result.ResetAfter)
+
+	other, err := store.Allow(ctx, "other", 3, time.Minute)
+	require.NoError(t, err)
+	require.True(t, other.Allowed, "keys are counted separately")
+
+	now = now.Add(20 * time.Second)
+	result, err = store.Allow(ctx, "key", 3, time.Minute)
+	require.NoError(t, err)
+	require.True(t, result.Allowed, "one request is recovered per window/limit")
+	require.Equal(t, 0, result.Remaining)
+
+	now = now.Add(time.Minute)
+	result, err = store.Allow(ctx, "key", 3, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Remaining, "the full limit is back after a window")
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies(600, []string{"auth=20/1m", " token_client = 100/30s"})
+	require.NoError(t, err)
+	require.Equal(t, Policy{Name: PolicyAuth, Limit: 20, Window: time.Minute}, policies[PolicyAuth])
+	require.Equal(t, Policy{Name: PolicyTokenClient, Limit: 100, Window: 30 * time.Second}, policies[PolicyTokenClient])
+	require.Equal(t, 600, policies[PolicyDefault].Limit)
+	require.Equal(t, "20;w=60", policies[PolicyAuth].String())
+
+	for _, spec := range []string{"auth", "unknown=1/1m", "auth=x/1m", "auth=10", "auth=10/1ms"} {
+		_, err := ParsePolicies(600, []string{spec})
+		require.Error(t, err, spec)
+	}
+}
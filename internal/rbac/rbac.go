@@ -0,0 +1,71 @@
+// Package rbac maps org member roles to the permissions checked by the admin
+// API.
+package rbac
+
+import "github.com/smallbiznis/railzway-auth/internal/domain"
+
+// Permission names an admin capability.
+type Permission string
+
+const (
+	// ConfigRead allows reading org configuration: branding, login methods,
+	// IdP settings and domains.
+	ConfigRead Permission = "config:read"
+	// ConfigManage allows changing org configuration.
+	ConfigManage Permission = "config:manage"
+	// DomainsManage allows adding, verifying and removing custom domains.
+	DomainsManage Permission = "domains:manage"
+	// ClientsManage allows creating and updating OAuth clients.
+	ClientsManage Permission = "clients:manage"
+	// SessionsManage allows revoking users' sessions.
+	SessionsManage Permission = "sessions:manage"
+	// MembersRead allows listing members.
+	MembersRead Permission = "members:read"
+	// MembersManage allows adding, changing and removing members.
+	MembersManage Permission = "members:manage"
+	// OrgsManage allows managing other orgs. It only has effect in the
+	// platform org.
+	OrgsManage Permission = "orgs:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	domain.MemberRoleOwner:  {ConfigRead, ConfigManage, DomainsManage, ClientsManage, SessionsManage, MembersRead, MembersManage, OrgsManage},
+	domain.MemberRoleAdmin:  {ConfigRead, ConfigManage, DomainsManage, ClientsManage, SessionsManage, MembersRead, MembersManage, OrgsManage},
+	domain.MemberRoleStaff:  {ConfigRead, SessionsManage, MembersRead},
+	domain.MemberRoleViewer: {ConfigRead, MembersRead},
+}
+
+// roleRanks orders roles so a member can only grant roles up to their own.
+var roleRanks = map[string]int{
+	domain.MemberRoleOwner:  4,
+	domain.MemberRoleAdmin:  3,
+	domain.MemberRoleStaff:  2,
+	domain.MemberRoleViewer: 1,
+}
+
+// ValidRole reports whether role is a known member role.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether role grants permission.
+func Can(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists the permissions role grants.
+func Permissions(role string) []Permission {
+	return append([]Permission(nil), rolePermissions[role]...)
+}
+
+// AtLeast reports whether role is a known role at least as privileged as
+// other.
+func AtLeast(role, other string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[other]
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// PostgresCertCache implements autocert.Cache so replicas share ACME
+// accounts, certificates and challenge tokens.
+type PostgresCertCache struct {
+	db *pgxpool.Pool
+}
+
+var _ autocert.Cache = (*PostgresCertCache)(nil)
+
+func NewPostgresCertCache(pool *pgxpool.Pool) *PostgresCertCache {
+	return &PostgresCertCache{db: pool}
+}
+
+func (r *PostgresCertCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := r.db.QueryRow(ctx, `SELECT data FROM acme_cache WHERE key = $1`, key).Scan(&data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, autocert.ErrCacheMiss
+		}
+		return nil, fmt.Errorf("get acme cache entry: %w", err)
+	}
+	return data, nil
+}
+
+func (r *PostgresCertCache) Put(ctx context.Context, key string, data []byte) error {
+	const query = `
+INSERT INTO acme_cache (key, data, updated_at) VALUES ($1, $2, NOW())
+ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = NOW()`
+	if _, err := r.db.Exec(ctx, query, key, data); err != nil {
+		return fmt.Errorf("put acme cache entry: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresCertCache) Delete(ctx context.Context, key string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM acme_cache WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("delete acme cache entry: %w", err)
+	}
+	return nil
+}
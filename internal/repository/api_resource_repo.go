@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresAPIResourceRepo implements APIResourceRepository.
+type PostgresAPIResourceRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresAPIResourceRepo(pool *pgxpool.Pool) *PostgresAPIResourceRepo {
+	return &PostgresAPIResourceRepo{db: pool}
+}
+
+const apiResourceColumns = `id, tenant_id, identifier, name, scopes, COALESCE(access_token_ttl_seconds, 0), signing_alg, created_at, updated_at`
+
+func (r *PostgresAPIResourceRepo) Get(ctx context.Context, orgID int64, identifier string) (domain.APIResource, error) {
+	query := `SELECT ` + apiResourceColumns + ` FROM api_resources WHERE tenant_id = $1 AND identifier = $2`
+	resource, err := scanAPIResource(r.db.QueryRow(ctx, query, orgID, identifier))
+	if err != nil {
+		return domain.APIResource{}, fmt.Errorf("get api resource: %w", err)
+	}
+	return resource, nil
+}
+
+func (r *PostgresAPIResourceRepo) List(ctx context.Context, orgID int64) ([]domain.APIResource, error) {
+	query := `SELECT ` + apiResourceColumns + ` FROM api_resources WHERE tenant_id = $1 ORDER BY identifier ASC`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list api resources: %w", err)
+	}
+	defer rows.Close()
+
+	var resources []domain.APIResource
+	for rows.Next() {
+		resource, err := scanAPIResource(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api resource: %w", err)
+		}
+		resources = append(resources, resource)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api resources: %w", err)
+	}
+	return resources, nil
+}
+
+func (r *PostgresAPIResourceRepo) Upsert(ctx context.Context, resource domain.APIResource) (domain.APIResource, error) {
+	query := `
+INSERT INTO api_resources (id, tenant_id, identifier, name, scopes, access_token_ttl_seconds, signing_alg)
+VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7)
+ON CONFLICT (tenant_id, identifier) DO UPDATE SET
+	name = EXCLUDED.name,
+	scopes = EXCLUDED.scopes,
+	access_token_ttl_seconds = EXCLUDED.access_token_ttl_seconds,
+	signing_alg = EXCLUDED.signing_alg,
+	updated_at = NOW()
+RETURNING ` + apiResourceColumns
+	stored, err := scanAPIResource(r.db.QueryRow(ctx, query,
+		resource.ID,
+		resource.OrgID,
+		resource.Identifier,
+		resource.Name,
+		nonNilStrings(resource.Scopes),
+		int64(resource.AccessTokenTTL/time.Second),
+		resource.SigningAlgorithm,
+	))
+	if err != nil {
+		return domain.APIResource{}, fmt.Errorf("upsert api resource: %w", err)
+	}
+	return stored, nil
+}
+
+func (r *PostgresAPIResourceRepo) Delete(ctx context.Context, orgID int64, identifier string) error {
+	const query = `DELETE FROM api_resources WHERE tenant_id = $1 AND identifier = $2`
+	tag, err := r.db.Exec(ctx, query, orgID, identifier)
+	if err != nil {
+		return fmt.Errorf("delete api resource: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete api resource: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func scanAPIResource(row pgx.Row) (domain.APIResource, error) {
+	var (
+		resource   domain.APIResource
+		ttlSeconds int64
+		createdAt  sql.NullTime
+		updatedAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&resource.ID,
+		&resource.OrgID,
+		&resource.Identifier,
+		&resource.Name,
+		&resource.Scopes,
+		&ttlSeconds,
+		&resource.SigningAlgorithm,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.APIResource{}, err
+	}
+	resource.AccessTokenTTL = time.Duration(ttlSeconds) * time.Second
+	resource.CreatedAt = createdAt.Time
+	resource.UpdatedAt = updatedAt.Time
+	return resource, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresBackchannelLogoutRepo implements BackchannelLogoutRepository.
+type PostgresBackchannelLogoutRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresBackchannelLogoutRepo(pool *pgxpool.Pool) *PostgresBackchannelLogoutRepo {
+	return &PostgresBackchannelLogoutRepo{db: pool}
+}
+
+const backchannelLogoutColumns = `id, tenant_id, client_id, user_id, issuer, attempts, next_attempt_at, created_at`
+
+func (r *PostgresBackchannelLogoutRepo) Enqueue(ctx context.Context, d domain.BackchannelLogout) error {
+	const query = `
+INSERT INTO backchannel_logout_deliveries (id, tenant_id, client_id, user_id, issuer, attempts, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := r.db.Exec(ctx, query, d.ID, d.OrgID, d.ClientID, d.UserID, d.Issuer, d.Attempts, d.NextAttemptAt); err != nil {
+		return fmt.Errorf("enqueue backchannel logout: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresBackchannelLogoutRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.BackchannelLogout, error) {
+	query := `
+UPDATE backchannel_logout_deliveries SET next_attempt_at = $2
+WHERE id IN (
+	SELECT id FROM backchannel_logout_deliveries
+	WHERE next_attempt_at <= $1
+	ORDER BY next_attempt_at ASC
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + backchannelLogoutColumns
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim backchannel logouts: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.BackchannelLogout
+	for rows.Next() {
+		d, err := scanBackchannelLogout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan backchannel logout: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim backchannel logouts: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *PostgresBackchannelLogoutRepo) Reschedule(ctx context.Context, id int64, attempts int, next time.Time) error {
+	const query = `UPDATE backchannel_logout_deliveries SET attempts = $2, next_attempt_at = $3 WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, id, attempts, next); err != nil {
+		return fmt.Errorf("reschedule backchannel logout: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresBackchannelLogoutRepo) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM backchannel_logout_deliveries WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete backchannel logout: %w", err)
+	}
+	return nil
+}
+
+func scanBackchannelLogout(row pgx.Row) (domain.BackchannelLogout, error) {
+	var (
+		d         domain.BackchannelLogout
+		createdAt sql.NullTime
+	)
+	if err := row.Scan(&d.ID, &d.OrgID, &d.ClientID, &d.UserID, &d.Issuer, &d.Attempts, &d.NextAttemptAt, &createdAt); err != nil {
+		return domain.BackchannelLogout{}, err
+	}
+	d.CreatedAt = createdAt.Time
+	return d, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresDomainRepo implements DomainRepository.
+type PostgresDomainRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresDomainRepo(pool *pgxpool.Pool) *PostgresDomainRepo {
+	return &PostgresDomainRepo{db: pool}
+}
+
+const domainColumns = `id, tenant_id, host, COALESCE(is_primary, FALSE),
+COALESCE(verification_method::text, 'dns'), COALESCE(verification_code, ''), COALESCE(verified, FALSE), verified_at,
+COALESCE(certificate_status::text, 'pending'), certificate_updated_at,
+COALESCE(provisioning_status::text, 'pending'), provisioned_at,
+created_at, updated_at`
+
+func (r *PostgresDomainRepo) Create(ctx context.Context, d domain.Domain) (domain.Domain, error) {
+	query := `
+INSERT INTO domains (id, tenant_id, host, is_primary, verification_method, verification_code, verified, certificate_status, provisioning_status)
+SELECT $1, $2, $3, FALSE, $4::domain_verification_method, $5, FALSE, 'pending', 'pending'
+WHERE NOT EXISTS (SELECT 1 FROM domains WHERE host = $3 AND verified = TRUE)
+RETURNING ` + domainColumns
+	stored, err := scanDomain(r.db.QueryRow(ctx, query, d.ID, d.OrgID, d.Host, d.VerificationMethod, d.VerificationCode))
+	if err != nil {
+		return domain.Domain{}, fmt.Errorf("create domain: %w", err)
+	}
+	return stored, nil
+}
+
+func (r *PostgresDomainRepo) Get(ctx context.Context, orgID, id int64) (domain.Domain, error) {
+	query := `SELECT ` + domainColumns + ` FROM domains WHERE tenant_id = $1 AND id = $2`
+	d, err := scanDomain(r.db.QueryRow(ctx, query, orgID, id))
+	if err != nil {
+		return domain.Domain{}, fmt.Errorf("get domain: %w", err)
+	}
+	return d, nil
+}
+
+func (r *PostgresDomainRepo) ListByOrg(ctx context.Context, orgID int64) ([]domain.Domain, error) {
+	query := `SELECT ` + domainColumns + ` FROM domains WHERE tenant_id = $1 ORDER BY is_primary DESC, id ASC`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list domains: %w", err)
+	}
+	defer rows.Close()
+	return collectDomains(rows, "list domains")
+}
+
+func (r *PostgresDomainRepo) Delete(ctx context.Context, orgID, id int64) error {
+	const query = `DELETE FROM domains WHERE tenant_id = $1 AND id = $2 AND COALESCE(is_primary, FALSE) = FALSE`
+	tag, err := r.db.Exec(ctx, query, orgID, id)
+	if err != nil {
+		return fmt.Errorf("delete domain: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete domain: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *PostgresDomainRepo) SetVerificationToken(ctx context.Context, orgID, id int64, method, code string) (domain.Domain, error) {
+	query := `
+UPDATE domains SET verification_method = $3::domain_verification_method, verification_code = $4, updated_at = NOW()
+WHERE tenant_id = $1 AND id = $2 AND COALESCE(verified, FALSE) = FALSE
+RETURNING ` + domainColumns
+	d, err := scanDomain(r.db.QueryRow(ctx, query, orgID, id, method, code))
+	if err != nil {
+		return domain.Domain{}, fmt.Errorf("set domain verification token: %w", err)
+	}
+	return d, nil
+}
+
+func (r *PostgresDomainRepo) MarkVerified(ctx context.Context, orgID, id int64, method string, verifiedAt time.Time) (domain.Domain, error) {
+	query := `
+UPDATE domains SET verified = TRUE, verified_at = $4, verification_method = $3::domain_verification_method, updated_at = NOW()
+WHERE tenant_id = $1 AND id = $2
+RETURNING ` + domainColumns
+	d, err := scanDomain(r.db.QueryRow(ctx, query, orgID, id, method, verifiedAt))
+	if err != nil {
+		return domain.Domain{}, fmt.Errorf("mark domain verified: %w", err)
+	}
+	return d, nil
+}
+
+func (r *PostgresDomainRepo) ListProvisioning(ctx context.Context, limit int) ([]domain.Domain, error) {
+	query := `SELECT ` + domainColumns + ` FROM domains
+WHERE verified = TRUE AND provisioning_status IN ('pending', 'provisioning')
+ORDER BY verified_at ASC
+LIMIT $1`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list provisioning domains: %w", err)
+	}
+	defer rows.Close()
+	return collectDomains(rows, "list provisioning domains")
+}
+
+func (r *PostgresDomainRepo) UpdateProvisioningStatus(ctx context.Context, id int64, from, to string, at time.Time) (bool, error) {
+	const query = `
+UPDATE domains SET provisioning_status = $3::domain_provisioning_status,
+	provisioned_at = CASE WHEN $3::domain_provisioning_status = 'active' THEN $4 ELSE provisioned_at END,
+	updated_at = NOW()
+WHERE id = $1 AND provisioning_status = $2::domain_provisioning_status`
+	tag, err := r.db.Exec(ctx, query, id, from, to, at)
+	if err != nil {
+		return false, fmt.Errorf("update domain provisioning status: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresDomainRepo) UpdateCertificateStatus(ctx context.Context, host, status string, at time.Time) error {
+	const query = `
+UPDATE domains SET certificate_status = $2::domain_certificate_status, certificate_updated_at = $3, updated_at = NOW()
+WHERE host = $1 AND verified = TRUE`
+	if _, err := r.db.Exec(ctx, query, host, status, at); err != nil {
+		return fmt.Errorf("update domain certificate status: %w", err)
+	}
+	return nil
+}
+
+func collectDomains(rows pgx.Rows, op string) ([]domain.Domain, error) {
+	var domains []domain.Domain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return domains, nil
+}
+
+func scanDomain(row pgx.Row) (domain.Domain, error) {
+	var (
+		d                    domain.Domain
+		verifiedAt           sql.NullTime
+		certificateUpdatedAt sql.NullTime
+		provisionedAt        sql.NullTime
+		createdAt            sql.NullTime
+		updatedAt            sql.NullTime
+	)
+	if err := row.Scan(
+		&d.ID,
+		&d.OrgID,
+		&d.Host,
+		&d.IsPrimary,
+		&d.VerificationMethod,
+		&d.VerificationCode,
+		&d.Verified,
+		&verifiedAt,
+		&d.CertificateStatus,
+		&certificateUpdatedAt,
+		&d.ProvisioningStatus,
+		&provisionedAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.Domain{}, err
+	}
+	d.VerifiedAt = nullableTime(verifiedAt)
+	d.CertificateUpdatedAt = nullableTime(certificateUpdatedAt)
+	d.ProvisionedAt = nullableTime(provisionedAt)
+	d.CreatedAt = createdAt.Time
+	d.UpdatedAt = updatedAt.Time
+	return d, nil
+}
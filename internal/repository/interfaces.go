@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/smallbiznis/railzway-auth/internal/domain"
 )
@@ -19,14 +20,30 @@ type OrgRepository interface {
 	GetPasswordConfig(ctx context.Context, orgID int64) (domain.PasswordConfig, error)
 	GetOTPConfig(ctx context.Context, orgID int64) (domain.OTPConfig, error)
 	ListOAuthIDPConfigs(ctx context.Context, orgID int64) ([]domain.OAuthIDPConfig, error)
+	GetMFAConfig(ctx context.Context, orgID int64) (domain.MFAConfig, error)
+	ListRateLimitOverrides(ctx context.Context, orgID int64) ([]domain.RateLimitOverride, error)
 	Count(ctx context.Context) (int64, error)
+	// List returns orgs ordered by ID, optionally filtered by status, and the
+	// total number of matching orgs.
+	List(ctx context.Context, status string, limit, offset int) ([]domain.Org, int64, error)
+	Update(ctx context.Context, org domain.Org) (domain.Org, error)
+	SetStatus(ctx context.Context, orgID int64, status string) (domain.Org, error)
+	SoftDelete(ctx context.Context, orgID int64) (domain.Org, error)
+	Restore(ctx context.Context, orgID int64, status string) (domain.Org, error)
+	// PurgeDeleted permanently deletes orgs soft-deleted before the cutoff and
+	// returns their IDs.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int64, error)
 }
 
 // UserRepository exposes persistence for platform users.
 type UserRepository interface {
 	GetByEmail(ctx context.Context, orgID int64, email string) (domain.User, error)
 	GetByID(ctx context.Context, orgID, userID int64) (domain.User, error)
+	// GetVerifiedByEmail returns the oldest account in any org whose email
+	// is verified and matches.
+	GetVerifiedByEmail(ctx context.Context, email string) (domain.User, error)
 	Create(ctx context.Context, user domain.User) (domain.User, error)
+	MarkEmailVerified(ctx context.Context, orgID, userID int64) error
 }
 
 // TokenRepository handles refresh token persistence.
@@ -35,8 +52,14 @@ type TokenRepository interface {
 	GetByRefreshToken(ctx context.Context, orgID int64, token string) (domain.OAuthToken, error)
 	GetByRefreshTokenValue(ctx context.Context, token string) (domain.OAuthToken, error)
 	GetByAccessToken(ctx context.Context, token string) (domain.OAuthToken, error)
-	RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64) error
+	// RotateRefreshToken replaces the refresh token and the access token
+	// issued with it, and sets the org the tokens act in.
+	RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64, activeOrgID int64, accessToken string, accessExpiresAt int64) error
 	RevokeToken(ctx context.Context, tokenID int64) error
+	RevokeUserTokens(ctx context.Context, orgID, userID int64) error
+	RevokeUserClientTokens(ctx context.Context, orgID, userID int64, clientID string) error
+	// ListUserClientIDs returns the clients holding unrevoked tokens for the user.
+	ListUserClientIDs(ctx context.Context, orgID, userID int64) ([]string, error)
 }
 
 // OAuthClientRepository exposes client metadata.
@@ -63,3 +86,136 @@ type KeyRepository interface {
 	GetActiveKey(ctx context.Context, orgID int64) (domain.OAuthKey, error)
 	CreateKey(ctx context.Context, key domain.OAuthKey) (domain.OAuthKey, error)
 }
+
+// MFARepository persists second factors and recovery codes.
+type MFARepository interface {
+	GetFactor(ctx context.Context, orgID, userID int64, factorType string) (domain.MFAFactor, error)
+	UpsertFactor(ctx context.Context, factor domain.MFAFactor) (domain.MFAFactor, error)
+	ConfirmFactor(ctx context.Context, factorID, step int64) error
+	// AdvanceFactorStep records the last accepted TOTP step; it returns false when
+	// step is not newer than the stored one so replayed codes can be rejected.
+	AdvanceFactorStep(ctx context.Context, factorID, step int64) (bool, error)
+	DeleteFactor(ctx context.Context, orgID, userID int64, factorType string) error
+	ReplaceRecoveryCodes(ctx context.Context, orgID, userID int64, codes []domain.RecoveryCode) error
+	ConsumeRecoveryCode(ctx context.Context, orgID, userID int64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, orgID, userID int64) (int, error)
+}
+
+// WebAuthnCredentialRepository persists passkeys registered by users.
+type WebAuthnCredentialRepository interface {
+	ListByUser(ctx context.Context, orgID, userID int64) ([]domain.WebAuthnCredential, error)
+	GetByCredentialID(ctx context.Context, orgID int64, credentialID []byte) (domain.WebAuthnCredential, error)
+	Create(ctx context.Context, credential domain.WebAuthnCredential) (domain.WebAuthnCredential, error)
+	// UpdateUsage stores the authenticator counter and backup state after a
+	// successful assertion.
+	UpdateUsage(ctx context.Context, id int64, signCount uint32, backupState bool) error
+	Delete(ctx context.Context, orgID, userID, id int64) error
+}
+
+// SessionRepository persists browser sessions for listing and revocation.
+type SessionRepository interface {
+	Create(ctx context.Context, session domain.Session) (domain.Session, error)
+	// ListActiveByUser returns unrevoked, unexpired sessions seen after idleSince.
+	ListActiveByUser(ctx context.Context, orgID, userID int64, idleSince time.Time) ([]domain.Session, error)
+	Touch(ctx context.Context, id int64, seenAt time.Time) error
+	// Revoke marks a session revoked and returns it so the caller can drop the
+	// live copy.
+	Revoke(ctx context.Context, orgID, userID, id int64) (domain.Session, error)
+	RevokeAllByUser(ctx context.Context, orgID, userID int64) ([]domain.Session, error)
+}
+
+// BackchannelLogoutRepository queues back-channel logout deliveries so that
+// retries survive restarts.
+type BackchannelLogoutRepository interface {
+	Enqueue(ctx context.Context, delivery domain.BackchannelLogout) error
+	// ClaimDue returns up to limit deliveries due at now and hides them from
+	// other workers for lease, after which an unfinished one is due again.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.BackchannelLogout, error)
+	Reschedule(ctx context.Context, id int64, attempts int, next time.Time) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// OrgConfigRepository reads and writes per-org login configuration for the
+// admin API. The singleton getters return defaults with a zero UpdatedAt for
+// orgs without a row. Save methods take the updated_at the caller last read,
+// or nil to create the row; they return pgx.ErrNoRows when the row already
+// exists or has changed since.
+type OrgConfigRepository interface {
+	GetBranding(ctx context.Context, orgID int64) (domain.Branding, error)
+	SaveBranding(ctx context.Context, b domain.Branding, expectedUpdatedAt *time.Time) (domain.Branding, error)
+	SetCustomJSEnabled(ctx context.Context, orgID int64, enabled bool) (domain.Branding, error)
+	GetPasswordConfig(ctx context.Context, orgID int64) (domain.PasswordConfig, error)
+	SavePasswordConfig(ctx context.Context, cfg domain.PasswordConfig, expectedUpdatedAt *time.Time) (domain.PasswordConfig, error)
+	GetOTPConfig(ctx context.Context, orgID int64) (domain.OTPConfig, error)
+	SaveOTPConfig(ctx context.Context, cfg domain.OTPConfig, expectedUpdatedAt *time.Time) (domain.OTPConfig, error)
+	ListAuthProviders(ctx context.Context, orgID int64) ([]domain.AuthProvider, error)
+	SaveAuthProvider(ctx context.Context, p domain.AuthProvider, expectedUpdatedAt *time.Time) (domain.AuthProvider, error)
+	DeleteAuthProvider(ctx context.Context, orgID int64, providerType string) error
+	ListIDPConfigs(ctx context.Context, orgID int64) ([]domain.OAuthIDPConfig, error)
+	GetIDPConfig(ctx context.Context, orgID int64, provider string) (domain.OAuthIDPConfig, error)
+	SaveIDPConfig(ctx context.Context, cfg domain.OAuthIDPConfig, expectedUpdatedAt *time.Time) (domain.OAuthIDPConfig, error)
+	DeleteIDPConfig(ctx context.Context, orgID int64, provider string) error
+	ListRateLimitOverrides(ctx context.Context, orgID int64) ([]domain.RateLimitOverride, error)
+	SaveRateLimitOverride(ctx context.Context, o domain.RateLimitOverride, expectedUpdatedAt *time.Time) (domain.RateLimitOverride, error)
+	DeleteRateLimitOverride(ctx context.Context, orgID int64, policy string) error
+}
+
+// MembershipRepository manages tenant_users, the members of an org's
+// administration and their pending invitations. Missing members are
+// reported as pgx.ErrNoRows.
+type MembershipRepository interface {
+	Get(ctx context.Context, orgID, userID int64) (domain.Member, error)
+	// List returns a page of members ordered by ID, and the total count.
+	// Pending invitations are not included.
+	List(ctx context.Context, orgID int64, limit, offset int) ([]domain.Member, int64, error)
+	// Create returns pgx.ErrNoRows when the user is already a member.
+	Create(ctx context.Context, m domain.Member) (domain.Member, error)
+	Update(ctx context.Context, orgID, userID int64, role, status string) (domain.Member, error)
+	Delete(ctx context.Context, orgID, userID int64) error
+	CountActiveOwners(ctx context.Context, orgID int64) (int, error)
+	// ListByUser returns the user's active memberships in every org, the
+	// default membership first.
+	ListByUser(ctx context.Context, userID int64) ([]domain.Member, error)
+
+	// CreateInvitation returns pgx.ErrNoRows when the email already has a
+	// pending invitation.
+	CreateInvitation(ctx context.Context, m domain.Member) (domain.Member, error)
+	GetInvitation(ctx context.Context, orgID, id int64) (domain.Member, error)
+	ListInvitations(ctx context.Context, orgID int64) ([]domain.Member, error)
+	RenewInvitation(ctx context.Context, orgID, id int64, nonce string, expiresAt time.Time) (domain.Member, error)
+	DeleteInvitation(ctx context.Context, orgID, id int64) error
+	// AcceptInvitation attaches userID if the invitation is pending,
+	// unexpired and nonce is current; otherwise it returns pgx.ErrNoRows.
+	AcceptInvitation(ctx context.Context, orgID, id int64, nonce string, userID int64) (domain.Member, error)
+}
+
+// DomainRepository manages the hosts an org is served on.
+type DomainRepository interface {
+	// Create claims a host for an org. It fails with pgx.ErrNoRows when the
+	// host is verified by another org.
+	Create(ctx context.Context, d domain.Domain) (domain.Domain, error)
+	Get(ctx context.Context, orgID, id int64) (domain.Domain, error)
+	ListByOrg(ctx context.Context, orgID int64) ([]domain.Domain, error)
+	Delete(ctx context.Context, orgID, id int64) error
+	// SetVerificationToken replaces the token and method of an unverified domain.
+	SetVerificationToken(ctx context.Context, orgID, id int64, method, code string) (domain.Domain, error)
+	// MarkVerified fails with a unique violation when another org has
+	// verified the same host.
+	MarkVerified(ctx context.Context, orgID, id int64, method string, verifiedAt time.Time) (domain.Domain, error)
+	// ListProvisioning returns verified domains whose provisioning has not
+	// finished, oldest first.
+	ListProvisioning(ctx context.Context, limit int) ([]domain.Domain, error)
+	// UpdateProvisioningStatus moves a domain from one provisioning state to
+	// another. It reports false when the domain was no longer in state from.
+	UpdateProvisioningStatus(ctx context.Context, id int64, from, to string, at time.Time) (bool, error)
+	UpdateCertificateStatus(ctx context.Context, host, status string, at time.Time) error
+}
+
+// APIResourceRepository stores the protected APIs registered with an org.
+type APIResourceRepository interface {
+	Get(ctx context.Context, orgID int64, identifier string) (domain.APIResource, error)
+	List(ctx context.Context, orgID int64) ([]domain.APIResource, error)
+	// Upsert creates the resource or replaces the one with the same identifier.
+	Upsert(ctx context.Context, resource domain.APIResource) (domain.APIResource, error)
+	Delete(ctx context.Context, orgID int64, identifier string) error
+}
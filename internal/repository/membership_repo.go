@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// memberColumns reads a tenant_users row aliased tu joined with its user u.
+const memberColumns = `tu.tenant_user_id, tu.tenant_id, COALESCE(tu.user_id, 0), tu.role, tu.status, COALESCE(tu.is_default, FALSE), COALESCE(tu.invited_email, ''), COALESCE(tu.invited_by, 0), tu.invite_expires_at, COALESCE(tu.invite_nonce, ''), tu.joined_at, COALESCE(u.email, ''), COALESCE(u.name, ''), tu.created_at, tu.updated_at`
+
+// PostgresMembershipRepo implements MembershipRepository.
+type PostgresMembershipRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresMembershipRepo(pool *pgxpool.Pool) *PostgresMembershipRepo {
+	return &PostgresMembershipRepo{db: pool}
+}
+
+func (r *PostgresMembershipRepo) Get(ctx context.Context, orgID, userID int64) (domain.Member, error) {
+	m, err := scanMember(r.db.QueryRow(ctx, `
+SELECT `+memberColumns+`
+FROM tenant_users tu LEFT JOIN users u ON u.id = tu.user_id
+WHERE tu.tenant_id = $1 AND tu.user_id = $2`, orgID, userID))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("get member: %w", err)
+	}
+	return m, nil
+}
+
+func (r *PostgresMembershipRepo) List(ctx context.Context, orgID int64, limit, offset int) ([]domain.Member, int64, error) {
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tenant_users WHERE tenant_id = $1 AND status <> 'INVITED'`, orgID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count members: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+SELECT `+memberColumns+`
+FROM tenant_users tu LEFT JOIN users u ON u.id = tu.user_id
+WHERE tu.tenant_id = $1 AND tu.status <> 'INVITED'
+ORDER BY tu.tenant_user_id ASC
+LIMIT $2 OFFSET $3`, orgID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []domain.Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("list members: %w", err)
+	}
+	return members, total, nil
+}
+
+func (r *PostgresMembershipRepo) ListByUser(ctx context.Context, userID int64) ([]domain.Member, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT `+memberColumns+`
+FROM tenant_users tu JOIN users u ON u.id = tu.user_id
+WHERE tu.user_id = $1 AND tu.status = 'ACTIVE'
+ORDER BY tu.is_default DESC NULLS LAST, tu.tenant_id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user memberships: %w", err)
+	}
+	defer rows.Close()
+
+	var members []domain.Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list user memberships: %w", err)
+	}
+	return members, nil
+}
+
+func (r *PostgresMembershipRepo) Create(ctx context.Context, m domain.Member) (domain.Member, error) {
+	const query = `
+WITH tu AS (
+	INSERT INTO tenant_users (tenant_user_id, tenant_id, user_id, role, status, is_default, invited_email, joined_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), CASE WHEN $5 = 'ACTIVE' THEN NOW() END, NOW(), NOW())
+	ON CONFLICT (tenant_id, user_id) DO NOTHING
+	RETURNING *
+)
+SELECT ` + memberColumns + ` FROM tu LEFT JOIN users u ON u.id = tu.user_id`
+	created, err := scanMember(r.db.QueryRow(ctx, query, m.ID, m.OrgID, m.UserID, m.Role, m.Status, m.IsDefault, m.InvitedEmail))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("create member: %w", err)
+	}
+	return created, nil
+}
+
+func (r *PostgresMembershipRepo) Update(ctx context.Context, orgID, userID int64, role, status string) (domain.Member, error) {
+	const query = `
+WITH tu AS (
+	UPDATE tenant_users
+	SET role = $3, status = $4, joined_at = COALESCE(joined_at, CASE WHEN $4 = 'ACTIVE' THEN NOW() END), updated_at = NOW()
+	WHERE tenant_id = $1 AND user_id = $2
+	RETURNING *
+)
+SELECT ` + memberColumns + ` FROM tu LEFT JOIN users u ON u.id = tu.user_id`
+	updated, err := scanMember(r.db.QueryRow(ctx, query, orgID, userID, role, status))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("update member: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *PostgresMembershipRepo) Delete(ctx context.Context, orgID, userID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tenant_users WHERE tenant_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("delete member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete member: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *PostgresMembershipRepo) CountActiveOwners(ctx context.Context, orgID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tenant_users WHERE tenant_id = $1 AND role = 'OWNER' AND status = 'ACTIVE'`, orgID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count owners: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresMembershipRepo) CreateInvitation(ctx context.Context, m domain.Member) (domain.Member, error) {
+	const query = `
+WITH tu AS (
+	INSERT INTO tenant_users (tenant_user_id, tenant_id, role, status, invited_email, invited_by, invite_expires_at, invite_nonce, joined_at, created_at, updated_at)
+	VALUES ($1, $2, $3, 'INVITED', $4, NULLIF($5, 0), $6, $7, NULL, NOW(), NOW())
+	ON CONFLICT DO NOTHING
+	RETURNING *
+)
+SELECT ` + memberColumns + ` FROM tu LEFT JOIN users u ON u.id = tu.user_id`
+	created, err := scanMember(r.db.QueryRow(ctx, query, m.ID, m.OrgID, m.Role, m.InvitedEmail, m.InvitedBy, m.InviteExpiresAt, m.InviteNonce))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("create invitation: %w", err)
+	}
+	return created, nil
+}
+
+func (r *PostgresMembershipRepo) GetInvitation(ctx context.Context, orgID, id int64) (domain.Member, error) {
+	m, err := scanMember(r.db.QueryRow(ctx, `
+SELECT `+memberColumns+`
+FROM tenant_users tu LEFT JOIN users u ON u.id = tu.user_id
+WHERE tu.tenant_id = $1 AND tu.tenant_user_id = $2 AND tu.status = 'INVITED'`, orgID, id))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("get invitation: %w", err)
+	}
+	return m, nil
+}
+
+func (r *PostgresMembershipRepo) ListInvitations(ctx context.Context, orgID int64) ([]domain.Member, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT `+memberColumns+`
+FROM tenant_users tu LEFT JOIN users u ON u.id = tu.user_id
+WHERE tu.tenant_id = $1 AND tu.status = 'INVITED'
+ORDER BY tu.tenant_user_id ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []domain.Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan invitation: %w", err)
+		}
+		invitations = append(invitations, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+func (r *PostgresMembershipRepo) RenewInvitation(ctx context.Context, orgID, id int64, nonce string, expiresAt time.Time) (domain.Member, error) {
+	const query = `
+WITH tu AS (
+	UPDATE tenant_users
+	SET invite_nonce = $3, invite_expires_at = $4, updated_at = NOW()
+	WHERE tenant_id = $1 AND tenant_user_id = $2 AND status = 'INVITED'
+	RETURNING *
+)
+SELECT ` + memberColumns + ` FROM tu LEFT JOIN users u ON u.id = tu.user_id`
+	renewed, err := scanMember(r.db.QueryRow(ctx, query, orgID, id, nonce, expiresAt))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("renew invitation: %w", err)
+	}
+	return renewed, nil
+}
+
+func (r *PostgresMembershipRepo) DeleteInvitation(ctx context.Context, orgID, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tenant_users WHERE tenant_id = $1 AND tenant_user_id = $2 AND status = 'INVITED'`, orgID, id)
+	if err != nil {
+		return fmt.Errorf("delete invitation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete invitation: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *PostgresMembershipRepo) AcceptInvitation(ctx context.Context, orgID, id int64, nonce string, userID int64) (domain.Member, error) {
+	const query = `
+WITH tu AS (
+	UPDATE tenant_users
+	SET user_id = $4, status = 'ACTIVE', joined_at = NOW(), invite_nonce = NULL, invite_expires_at = NULL, updated_at = NOW()
+	WHERE tenant_id = $1 AND tenant_user_id = $2 AND status = 'INVITED'
+	  AND invite_nonce = $3 AND invite_expires_at > NOW()
+	RETURNING *
+)
+SELECT ` + memberColumns + ` FROM tu LEFT JOIN users u ON u.id = tu.user_id`
+	accepted, err := scanMember(r.db.QueryRow(ctx, query, orgID, id, nonce, userID))
+	if err != nil {
+		return domain.Member{}, fmt.Errorf("accept invitation: %w", err)
+	}
+	return accepted, nil
+}
+
+func scanMember(row pgx.Row) (domain.Member, error) {
+	var (
+		m                    domain.Member
+		expiresAt, joinedAt  sql.NullTime
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(&m.ID, &m.OrgID, &m.UserID, &m.Role, &m.Status, &m.IsDefault, &m.InvitedEmail, &m.InvitedBy, &expiresAt, &m.InviteNonce, &joinedAt, &m.Email, &m.Name, &createdAt, &updatedAt); err != nil {
+		return domain.Member{}, err
+	}
+	m.InviteExpiresAt = nullableTime(expiresAt)
+	m.JoinedAt = nullableTime(joinedAt)
+	m.CreatedAt = createdAt.Time
+	m.UpdatedAt = updatedAt.Time
+	return m, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresMFARepo implements MFARepository.
+type PostgresMFARepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresMFARepo(pool *pgxpool.Pool) *PostgresMFARepo {
+	return &PostgresMFARepo{db: pool}
+}
+
+const mfaFactorColumns = `id, tenant_id, user_id, factor_type, secret, confirmed, last_used_step, confirmed_at, created_at, updated_at`
+
+func (r *PostgresMFARepo) GetFactor(ctx context.Context, orgID, userID int64, factorType string) (domain.MFAFactor, error) {
+	query := `SELECT ` + mfaFactorColumns + ` FROM user_mfa_factors WHERE tenant_id = $1 AND user_id = $2 AND factor_type = $3`
+	factor, err := scanMFAFactor(r.db.QueryRow(ctx, query, orgID, userID, factorType))
+	if err != nil {
+		return domain.MFAFactor{}, fmt.Errorf("get mfa factor: %w", err)
+	}
+	return factor, nil
+}
+
+func (r *PostgresMFARepo) UpsertFactor(ctx context.Context, factor domain.MFAFactor) (domain.MFAFactor, error) {
+	query := `
+INSERT INTO user_mfa_factors (id, tenant_id, user_id, factor_type, secret, confirmed, last_used_step)
+VALUES ($1, $2, $3, $4, $5, FALSE, 0)
+ON CONFLICT (tenant_id, user_id, factor_type) DO UPDATE SET
+	secret = EXCLUDED.secret,
+	confirmed = FALSE,
+	last_used_step = 0,
+	confirmed_at = NULL,
+	updated_at = NOW()
+RETURNING ` + mfaFactorColumns
+
+	stored, err := scanMFAFactor(r.db.QueryRow(ctx, query, factor.ID, factor.OrgID, factor.UserID, factor.Type, factor.Secret))
+	if err != nil {
+		return domain.MFAFactor{}, fmt.Errorf("upsert mfa factor: %w", err)
+	}
+	return stored, nil
+}
+
+func (r *PostgresMFARepo) ConfirmFactor(ctx context.Context, factorID, step int64) error {
+	const query = `
+UPDATE user_mfa_factors
+SET confirmed = TRUE, confirmed_at = NOW(), last_used_step = $2, updated_at = NOW()
+WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, factorID, step); err != nil {
+		return fmt.Errorf("confirm mfa factor: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresMFARepo) AdvanceFactorStep(ctx context.Context, factorID, step int64) (bool, error) {
+	const query = `
+UPDATE user_mfa_factors
+SET last_used_step = $2, updated_at = NOW()
+WHERE id = $1 AND last_used_step < $2`
+	tag, err := r.db.Exec(ctx, query, factorID, step)
+	if err != nil {
+		return false, fmt.Errorf("advance mfa factor step: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresMFARepo) DeleteFactor(ctx context.Context, orgID, userID int64, factorType string) error {
+	const query = `DELETE FROM user_mfa_factors WHERE tenant_id = $1 AND user_id = $2 AND factor_type = $3`
+	if _, err := r.db.Exec(ctx, query, orgID, userID, factorType); err != nil {
+		return fmt.Errorf("delete mfa factor: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresMFARepo) ReplaceRecoveryCodes(ctx context.Context, orgID, userID int64, codes []domain.RecoveryCode) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin recovery codes tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE tenant_id = $1 AND user_id = $2`, orgID, userID); err != nil {
+		return fmt.Errorf("clear recovery codes: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO user_recovery_codes (id, tenant_id, user_id, code_hash) VALUES ($1, $2, $3, $4)`,
+			code.ID, orgID, userID, code.CodeHash,
+		); err != nil {
+			return fmt.Errorf("insert recovery code: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit recovery codes: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresMFARepo) ConsumeRecoveryCode(ctx context.Context, orgID, userID int64, codeHash string) (bool, error) {
+	const query = `
+UPDATE user_recovery_codes
+SET used_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, orgID, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("consume recovery code: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresMFARepo) CountRecoveryCodes(ctx context.Context, orgID, userID int64) (int, error) {
+	const query = `SELECT COUNT(*) FROM user_recovery_codes WHERE tenant_id = $1 AND user_id = $2 AND used_at IS NULL`
+	var count int
+	if err := r.db.QueryRow(ctx, query, orgID, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func scanMFAFactor(row pgx.Row) (domain.MFAFactor, error) {
+	var (
+		factor      domain.MFAFactor
+		confirmedAt sql.NullTime
+		createdAt   sql.NullTime
+		updatedAt   sql.NullTime
+	)
+	if err := row.Scan(
+		&factor.ID,
+		&factor.OrgID,
+		&factor.UserID,
+		&factor.Type,
+		&factor.Secret,
+		&factor.Confirmed,
+		&factor.LastUsedStep,
+		&confirmedAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.MFAFactor{}, err
+	}
+	factor.ConfirmedAt = nullableTime(confirmedAt)
+	factor.CreatedAt = createdAt.Time
+	factor.UpdatedAt = updatedAt.Time
+	return factor, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/smallbiznis/railzway-auth/internal/domain"
 	"github.com/smallbiznis/railzway-auth/internal/domain/oauth"
 	"github.com/smallbiznis/railzway-auth/sqlc"
 )
@@ -30,6 +31,60 @@ type AuthorizeStateStore interface {
 	SaveState(ctx context.Context, key string, data oauth.AuthorizeState, ttl time.Duration) error
 	GetState(ctx context.Context, key string) (*oauth.AuthorizeState, error)
 	DeleteState(ctx context.Context, key string) error
+	SavePushedRequest(ctx context.Context, key string, data oauth.PushedAuthorizationRequest, ttl time.Duration) error
+	// TakePushedRequest loads and removes a pushed request so a request_uri
+	// can be used once. It returns nil when the key is missing.
+	TakePushedRequest(ctx context.Context, key string) (*oauth.PushedAuthorizationRequest, error)
+}
+
+// MFAChallengeStore persists pending multi-factor login challenges.
+type MFAChallengeStore interface {
+	SaveChallenge(ctx context.Context, key string, data domain.MFAChallenge, ttl time.Duration) error
+	GetChallenge(ctx context.Context, key string) (*domain.MFAChallenge, error)
+	// IncrementAttempts atomically counts a failed attempt against the
+	// challenge and returns the new total. The counter expires after ttl.
+	IncrementAttempts(ctx context.Context, key string, ttl time.Duration) (int, error)
+	DeleteChallenge(ctx context.Context, key string) error
+}
+
+// WebAuthnSessionStore persists in-flight WebAuthn ceremonies.
+type WebAuthnSessionStore interface {
+	SaveSession(ctx context.Context, key string, data domain.WebAuthnSession, ttl time.Duration) error
+	GetSession(ctx context.Context, key string) (*domain.WebAuthnSession, error)
+	DeleteSession(ctx context.Context, key string) error
+}
+
+// MagicLinkStore persists pending email sign-in links. ConsumeLink reports
+// whether this call removed the record, so each link is redeemed once.
+type MagicLinkStore interface {
+	SaveLink(ctx context.Context, key string, data domain.MagicLink, ttl time.Duration) error
+	GetLink(ctx context.Context, key string) (*domain.MagicLink, error)
+	ConsumeLink(ctx context.Context, key string) (bool, error)
+}
+
+// SessionStore holds live browser sessions keyed by token hash. Entries expire
+// after the idle timeout unless saved again.
+type SessionStore interface {
+	SaveSession(ctx context.Context, key string, data domain.Session, ttl time.Duration) error
+	// RefreshSession replaces the payload and TTL of a session that is
+	// still stored. It reports false, writing nothing, when the key is gone,
+	// so a session revoked mid-request is not brought back.
+	RefreshSession(ctx context.Context, key string, data domain.Session, ttl time.Duration) (bool, error)
+	GetSession(ctx context.Context, key string) (*domain.Session, error)
+	DeleteSession(ctx context.Context, key string) error
+}
+
+// CooldownStore enforces a minimum interval between repeated actions such as
+// resending emails. Acquire reports false while the key is still cooling down.
+type CooldownStore interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// RateLimitStore counts requests against a limit of requests per window,
+// shared by every replica using the same store. Allow records the request
+// only when it is allowed.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (domain.RateLimitResult, error)
 }
 
 // PostgresOAuthProviderConfigRepo implements OAuthProviderConfigRepo.
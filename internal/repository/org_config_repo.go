@@ -0,0 +1,560 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresOrgConfigRepo implements OrgConfigRepository.
+type PostgresOrgConfigRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresOrgConfigRepo(pool *pgxpool.Pool) *PostgresOrgConfigRepo {
+	return &PostgresOrgConfigRepo{db: pool}
+}
+
+const (
+	brandingColumns       = `tenant_id, COALESCE(logo_url, ''), COALESCE(favicon_url, ''), COALESCE(primary_color, ''), COALESCE(secondary_color, ''), COALESCE(accent_color, ''), COALESCE(background_color, ''), COALESCE(text_color, ''), COALESCE(dark_mode, TRUE), COALESCE(custom_css, ''), COALESCE(custom_js, ''), COALESCE(custom_html_header, ''), COALESCE(custom_html_footer, ''), custom_js_enabled, created_at, updated_at`
+	passwordConfigColumns = `tenant_id, COALESCE(min_length, 8), COALESCE(require_uppercase, FALSE), COALESCE(require_number, FALSE), COALESCE(require_symbol, FALSE), COALESCE(allow_signup, TRUE), COALESCE(allow_password_reset, TRUE), COALESCE(lockout_attempts, 5), COALESCE(lockout_duration_seconds, 300), email_verification, created_at, updated_at`
+	otpConfigColumns      = `tenant_id, channel, COALESCE(provider, ''), COALESCE(api_key, ''), COALESCE(sender, ''), COALESCE(template, ''), COALESCE(expiry_seconds, 300), created_at, updated_at`
+	authProviderColumns   = `id, tenant_id, provider_type, provider_config_id, is_active, created_at, updated_at`
+	rateLimitColumns      = `id, tenant_id, policy, max_requests, window_seconds, created_at, updated_at`
+	idpConfigColumns      = `id, tenant_id, provider, client_id, COALESCE(client_secret, ''), COALESCE(issuer_url, ''), COALESCE(authorization_url, ''), COALESCE(token_url, ''), COALESCE(userinfo_url, ''), COALESCE(jwks_url, ''), COALESCE(scopes, ARRAY[]::TEXT[]), COALESCE(extra, '{}'::jsonb), created_at, updated_at`
+)
+
+// GetBranding returns the org's branding, or the defaults with a zero
+// UpdatedAt when none has been saved. The other singleton getters behave the
+// same way.
+func (r *PostgresOrgConfigRepo) GetBranding(ctx context.Context, orgID int64) (domain.Branding, error) {
+	b, err := scanBranding(r.db.QueryRow(ctx, `SELECT `+brandingColumns+` FROM brandings WHERE tenant_id = $1`, orgID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultBranding(orgID), nil
+	}
+	if err != nil {
+		return domain.Branding{}, fmt.Errorf("get branding: %w", err)
+	}
+	return b, nil
+}
+
+func (r *PostgresOrgConfigRepo) SaveBranding(ctx context.Context, b domain.Branding, expectedUpdatedAt *time.Time) (domain.Branding, error) {
+	args := []any{
+		b.OrgID,
+		valueOrEmpty(b.LogoURL),
+		valueOrEmpty(b.FaviconURL),
+		valueOrEmpty(b.PrimaryColor),
+		valueOrEmpty(b.SecondaryColor),
+		valueOrEmpty(b.AccentColor),
+		valueOrEmpty(b.BackgroundColor),
+		valueOrEmpty(b.TextColor),
+		b.DarkMode,
+		valueOrEmpty(b.CustomCSS),
+		valueOrEmpty(b.CustomJS),
+		valueOrEmpty(b.CustomHTMLHeader),
+		valueOrEmpty(b.CustomHTMLFooter),
+	}
+	var query string
+	if expectedUpdatedAt == nil {
+		query = `
+INSERT INTO brandings (tenant_id, logo_url, favicon_url, primary_color, secondary_color, accent_color, background_color, text_color, dark_mode, custom_css, custom_js, custom_html_header, custom_html_footer, created_at, updated_at)
+VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NOW(), NOW())
+ON CONFLICT (tenant_id) DO NOTHING
+RETURNING ` + brandingColumns
+	} else {
+		query = `
+UPDATE brandings
+SET logo_url = NULLIF($2, ''), favicon_url = NULLIF($3, ''), primary_color = NULLIF($4, ''), secondary_color = NULLIF($5, ''),
+    accent_color = NULLIF($6, ''), background_color = NULLIF($7, ''), text_color = NULLIF($8, ''), dark_mode = $9,
+    custom_css = NULLIF($10, ''), custom_js = NULLIF($11, ''), custom_html_header = NULLIF($12, ''), custom_html_footer = NULLIF($13, ''),
+    updated_at = NOW()
+WHERE tenant_id = $1 AND updated_at = $14
+RETURNING ` + brandingColumns
+		args = append(args, *expectedUpdatedAt)
+	}
+	saved, err := scanBranding(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		return domain.Branding{}, fmt.Errorf("save branding: %w", err)
+	}
+	return saved, nil
+}
+
+// SetCustomJSEnabled changes the flag gating custom JS, creating the branding
+// row if needed. It bumps updated_at, so branding edits in flight conflict.
+func (r *PostgresOrgConfigRepo) SetCustomJSEnabled(ctx context.Context, orgID int64, enabled bool) (domain.Branding, error) {
+	const query = `
+INSERT INTO brandings (tenant_id, custom_js_enabled, created_at, updated_at)
+VALUES ($1, $2, NOW(), NOW())
+ON CONFLICT (tenant_id) DO UPDATE SET custom_js_enabled = EXCLUDED.custom_js_enabled, updated_at = NOW()
+RETURNING ` + brandingColumns
+	b, err := scanBranding(r.db.QueryRow(ctx, query, orgID, enabled))
+	if err != nil {
+		return domain.Branding{}, fmt.Errorf("set custom js enabled: %w", err)
+	}
+	return b, nil
+}
+
+func (r *PostgresOrgConfigRepo) GetPasswordConfig(ctx context.Context, orgID int64) (domain.PasswordConfig, error) {
+	cfg, err := scanPasswordConfig(r.db.QueryRow(ctx, `SELECT `+passwordConfigColumns+` FROM password_configs WHERE tenant_id = $1`, orgID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultPasswordConfig(orgID), nil
+	}
+	if err != nil {
+		return domain.PasswordConfig{}, fmt.Errorf("get password config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (r *PostgresOrgConfigRepo) SavePasswordConfig(ctx context.Context, cfg domain.PasswordConfig, expectedUpdatedAt *time.Time) (domain.PasswordConfig, error) {
+	args := []any{
+		cfg.OrgID,
+		cfg.MinLength,
+		cfg.RequireUppercase,
+		cfg.RequireNumber,
+		cfg.RequireSymbol,
+		cfg.AllowSignup,
+		cfg.AllowPasswordReset,
+		cfg.LockoutAttempts,
+		cfg.LockoutDurationSeconds,
+		cfg.EmailVerification,
+	}
+	var query string
+	if expectedUpdatedAt == nil {
+		query = `
+INSERT INTO password_configs (tenant_id, min_length, require_uppercase, require_number, require_symbol, allow_signup, allow_password_reset, lockout_attempts, lockout_duration_seconds, email_verification, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+ON CONFLICT (tenant_id) DO NOTHING
+RETURNING ` + passwordConfigColumns
+	} else {
+		query = `
+UPDATE password_configs
+SET min_length = $2, require_uppercase = $3, require_number = $4, require_symbol = $5, allow_signup = $6,
+    allow_password_reset = $7, lockout_attempts = $8, lockout_duration_seconds = $9, email_verification = $10,
+    updated_at = NOW()
+WHERE tenant_id = $1 AND updated_at = $11
+RETURNING ` + passwordConfigColumns
+		args = append(args, *expectedUpdatedAt)
+	}
+	saved, err := scanPasswordConfig(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		return domain.PasswordConfig{}, fmt.Errorf("save password config: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresOrgConfigRepo) GetOTPConfig(ctx context.Context, orgID int64) (domain.OTPConfig, error) {
+	cfg, err := scanOTPConfig(r.db.QueryRow(ctx, `SELECT `+otpConfigColumns+` FROM otp_configs WHERE tenant_id = $1`, orgID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultOTPConfig(orgID), nil
+	}
+	if err != nil {
+		return domain.OTPConfig{}, fmt.Errorf("get otp config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (r *PostgresOrgConfigRepo) SaveOTPConfig(ctx context.Context, cfg domain.OTPConfig, expectedUpdatedAt *time.Time) (domain.OTPConfig, error) {
+	args := []any{
+		cfg.OrgID,
+		cfg.Channel,
+		cfg.Provider,
+		cfg.APIKey,
+		cfg.Sender,
+		cfg.Template,
+		cfg.ExpirySeconds,
+	}
+	var query string
+	if expectedUpdatedAt == nil {
+		query = `
+INSERT INTO otp_configs (tenant_id, channel, provider, api_key, sender, template, expiry_seconds, created_at, updated_at)
+VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, NOW(), NOW())
+ON CONFLICT (tenant_id) DO NOTHING
+RETURNING ` + otpConfigColumns
+	} else {
+		query = `
+UPDATE otp_configs
+SET channel = $2, provider = $3, api_key = NULLIF($4, ''), sender = NULLIF($5, ''), template = NULLIF($6, ''),
+    expiry_seconds = $7, updated_at = NOW()
+WHERE tenant_id = $1 AND updated_at = $8
+RETURNING ` + otpConfigColumns
+		args = append(args, *expectedUpdatedAt)
+	}
+	saved, err := scanOTPConfig(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		return domain.OTPConfig{}, fmt.Errorf("save otp config: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresOrgConfigRepo) ListAuthProviders(ctx context.Context, orgID int64) ([]domain.AuthProvider, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+authProviderColumns+` FROM tenant_auth_providers WHERE tenant_id = $1 ORDER BY provider_type`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list auth providers: %w", err)
+	}
+	defer rows.Close()
+	var providers []domain.AuthProvider
+	for rows.Next() {
+		p, err := scanAuthProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan auth provider: %w", err)
+		}
+		providers = append(providers, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list auth providers: %w", err)
+	}
+	return providers, nil
+}
+
+func (r *PostgresOrgConfigRepo) SaveAuthProvider(ctx context.Context, p domain.AuthProvider, expectedUpdatedAt *time.Time) (domain.AuthProvider, error) {
+	var (
+		query string
+		args  []any
+	)
+	if expectedUpdatedAt == nil {
+		query = `
+INSERT INTO tenant_auth_providers (id, tenant_id, provider_type, provider_config_id, is_active, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+ON CONFLICT (tenant_id, provider_type) DO NOTHING
+RETURNING ` + authProviderColumns
+		args = []any{p.ID, p.OrgID, p.ProviderType, p.ProviderConfigID, p.IsActive}
+	} else {
+		query = `
+UPDATE tenant_auth_providers
+SET provider_config_id = $3, is_active = $4, updated_at = NOW()
+WHERE tenant_id = $1 AND provider_type = $2 AND updated_at = $5
+RETURNING ` + authProviderColumns
+		args = []any{p.OrgID, p.ProviderType, p.ProviderConfigID, p.IsActive, *expectedUpdatedAt}
+	}
+	saved, err := scanAuthProvider(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		return domain.AuthProvider{}, fmt.Errorf("save auth provider: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresOrgConfigRepo) DeleteAuthProvider(ctx context.Context, orgID int64, providerType string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tenant_auth_providers WHERE tenant_id = $1 AND provider_type = $2`, orgID, providerType)
+	if err != nil {
+		return fmt.Errorf("delete auth provider: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete auth provider: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *PostgresOrgConfigRepo) ListIDPConfigs(ctx context.Context, orgID int64) ([]domain.OAuthIDPConfig, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+idpConfigColumns+` FROM oauth_idp_configs WHERE tenant_id = $1 ORDER BY provider`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list idp configs: %w", err)
+	}
+	defer rows.Close()
+	var configs []domain.OAuthIDPConfig
+	for rows.Next() {
+		cfg, err := scanIDPConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan idp config: %w", err)
+		}
+		configs = append(configs, cfg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list idp configs: %w", err)
+	}
+	return configs, nil
+}
+
+func (r *PostgresOrgConfigRepo) GetIDPConfig(ctx context.Context, orgID int64, provider string) (domain.OAuthIDPConfig, error) {
+	cfg, err := scanIDPConfig(r.db.QueryRow(ctx, `SELECT `+idpConfigColumns+` FROM oauth_idp_configs WHERE tenant_id = $1 AND provider = $2`, orgID, provider))
+	if err != nil {
+		return domain.OAuthIDPConfig{}, fmt.Errorf("get idp config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (r *PostgresOrgConfigRepo) SaveIDPConfig(ctx context.Context, cfg domain.OAuthIDPConfig, expectedUpdatedAt *time.Time) (domain.OAuthIDPConfig, error) {
+	extra, err := json.Marshal(cfg.Extra)
+	if err != nil {
+		return domain.OAuthIDPConfig{}, fmt.Errorf("encode idp extra: %w", err)
+	}
+	scopes := cfg.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	// Optional columns are stored as '' rather than NULL because the social
+	// login reader scans them into plain strings.
+	var (
+		query string
+		args  []any
+	)
+	if expectedUpdatedAt == nil {
+		query = `
+INSERT INTO oauth_idp_configs (id, tenant_id, provider, client_id, client_secret, issuer_url, authorization_url, token_url, userinfo_url, jwks_url, scopes, extra, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
+ON CONFLICT (tenant_id, provider) DO NOTHING
+RETURNING ` + idpConfigColumns
+		args = []any{cfg.ID, cfg.OrgID, cfg.Provider, cfg.ClientID, cfg.ClientSecret, cfg.IssuerURL, cfg.AuthorizationURL, cfg.TokenURL, cfg.UserinfoURL, cfg.JWKSURL, scopes, extra}
+	} else {
+		query = `
+UPDATE oauth_idp_configs
+SET client_id = $3, client_secret = $4, issuer_url = $5, authorization_url = $6, token_url = $7,
+    userinfo_url = $8, jwks_url = $9, scopes = $10, extra = $11, updated_at = NOW()
+WHERE tenant_id = $1 AND provider = $2 AND updated_at = $12
+RETURNING ` + idpConfigColumns
+		args = []any{cfg.OrgID, cfg.Provider, cfg.ClientID, cfg.ClientSecret, cfg.IssuerURL, cfg.AuthorizationURL, cfg.TokenURL, cfg.UserinfoURL, cfg.JWKSURL, scopes, extra, *expectedUpdatedAt}
+	}
+	saved, err := scanIDPConfig(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		return domain.OAuthIDPConfig{}, fmt.Errorf("save idp config: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresOrgConfigRepo) DeleteIDPConfig(ctx context.Context, orgID int64, provider string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM oauth_idp_configs WHERE tenant_id = $1 AND provider = $2`, orgID, provider)
+	if err != nil {
+		return fmt.Errorf("delete idp config: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete idp config: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *PostgresOrgConfigRepo) ListRateLimitOverrides(ctx context.Context, orgID int64) ([]domain.RateLimitOverride, error) {
+	return listRateLimitOverrides(ctx, r.db, orgID)
+}
+
+func (r *PostgresOrgConfigRepo) SaveRateLimitOverride(ctx context.Context, o domain.RateLimitOverride, expectedUpdatedAt *time.Time) (domain.RateLimitOverride, error) {
+	var (
+		query string
+		args  []any
+	)
+	if expectedUpdatedAt == nil {
+		query = `
+INSERT INTO rate_limit_overrides (id, tenant_id, policy, max_requests, window_seconds, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+ON CONFLICT (tenant_id, policy) DO NOTHING
+RETURNING ` + rateLimitColumns
+		args = []any{o.ID, o.OrgID, o.Policy, o.Limit, o.WindowSeconds}
+	} else {
+		query = `
+UPDATE rate_limit_overrides
+SET max_requests = $3, window_seconds = $4, updated_at = NOW()
+WHERE tenant_id = $1 AND policy = $2 AND updated_at = $5
+RETURNING ` + rateLimitColumns
+		args = []any{o.OrgID, o.Policy, o.Limit, o.WindowSeconds, *expectedUpdatedAt}
+	}
+	saved, err := scanRateLimitOverride(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		return domain.RateLimitOverride{}, fmt.Errorf("save rate limit override: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresOrgConfigRepo) DeleteRateLimitOverride(ctx context.Context, orgID int64, policy string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM rate_limit_overrides WHERE tenant_id = $1 AND policy = $2`, orgID, policy)
+	if err != nil {
+		return fmt.Errorf("delete rate limit override: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete rate limit override: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+// listRateLimitOverrides is shared with PostgresOrgRepo, which loads the
+// overrides into org contexts.
+func listRateLimitOverrides(ctx context.Context, db *pgxpool.Pool, orgID int64) ([]domain.RateLimitOverride, error) {
+	rows, err := db.Query(ctx, `SELECT `+rateLimitColumns+` FROM rate_limit_overrides WHERE tenant_id = $1 ORDER BY policy`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list rate limit overrides: %w", err)
+	}
+	defer rows.Close()
+	var overrides []domain.RateLimitOverride
+	for rows.Next() {
+		o, err := scanRateLimitOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rate limit override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list rate limit overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+func scanBranding(row pgx.Row) (domain.Branding, error) {
+	var (
+		b                                               domain.Branding
+		logo, favicon, primary, secondary, accent       string
+		background, text, css, js, htmlHeader, htmlFoot string
+		createdAt, updatedAt                            sql.NullTime
+	)
+	if err := row.Scan(&b.OrgID, &logo, &favicon, &primary, &secondary, &accent, &background, &text, &b.DarkMode, &css, &js, &htmlHeader, &htmlFoot, &b.CustomJSEnabled, &createdAt, &updatedAt); err != nil {
+		return domain.Branding{}, err
+	}
+	b.LogoURL = optionalString(logo)
+	b.FaviconURL = optionalString(favicon)
+	b.PrimaryColor = optionalString(primary)
+	b.SecondaryColor = optionalString(secondary)
+	b.AccentColor = optionalString(accent)
+	b.BackgroundColor = optionalString(background)
+	b.TextColor = optionalString(text)
+	b.CustomCSS = optionalString(css)
+	b.CustomJS = optionalString(js)
+	b.CustomHTMLHeader = optionalString(htmlHeader)
+	b.CustomHTMLFooter = optionalString(htmlFoot)
+	b.CreatedAt = createdAt.Time
+	b.UpdatedAt = updatedAt.Time
+	return b, nil
+}
+
+func scanPasswordConfig(row pgx.Row) (domain.PasswordConfig, error) {
+	var (
+		cfg                  domain.PasswordConfig
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&cfg.OrgID,
+		&cfg.MinLength,
+		&cfg.RequireUppercase,
+		&cfg.RequireNumber,
+		&cfg.RequireSymbol,
+		&cfg.AllowSignup,
+		&cfg.AllowPasswordReset,
+		&cfg.LockoutAttempts,
+		&cfg.LockoutDurationSeconds,
+		&cfg.EmailVerification,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.PasswordConfig{}, err
+	}
+	cfg.CreatedAt = createdAt.Time
+	cfg.UpdatedAt = updatedAt.Time
+	return cfg, nil
+}
+
+func scanOTPConfig(row pgx.Row) (domain.OTPConfig, error) {
+	var (
+		cfg                  domain.OTPConfig
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(&cfg.OrgID, &cfg.Channel, &cfg.Provider, &cfg.APIKey, &cfg.Sender, &cfg.Template, &cfg.ExpirySeconds, &createdAt, &updatedAt); err != nil {
+		return domain.OTPConfig{}, err
+	}
+	cfg.CreatedAt = createdAt.Time
+	cfg.UpdatedAt = updatedAt.Time
+	return cfg, nil
+}
+
+func scanAuthProvider(row pgx.Row) (domain.AuthProvider, error) {
+	var (
+		p                    domain.AuthProvider
+		configID             sql.NullInt64
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(&p.ID, &p.OrgID, &p.ProviderType, &configID, &p.IsActive, &createdAt, &updatedAt); err != nil {
+		return domain.AuthProvider{}, err
+	}
+	if configID.Valid {
+		p.ProviderConfigID = &configID.Int64
+	}
+	p.CreatedAt = createdAt.Time
+	p.UpdatedAt = updatedAt.Time
+	return p, nil
+}
+
+func scanRateLimitOverride(row pgx.Row) (domain.RateLimitOverride, error) {
+	var (
+		o                    domain.RateLimitOverride
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(&o.ID, &o.OrgID, &o.Policy, &o.Limit, &o.WindowSeconds, &createdAt, &updatedAt); err != nil {
+		return domain.RateLimitOverride{}, err
+	}
+	o.CreatedAt = createdAt.Time
+	o.UpdatedAt = updatedAt.Time
+	return o, nil
+}
+
+func scanIDPConfig(row pgx.Row) (domain.OAuthIDPConfig, error) {
+	var (
+		cfg                  domain.OAuthIDPConfig
+		extra                []byte
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&cfg.ID,
+		&cfg.OrgID,
+		&cfg.Provider,
+		&cfg.ClientID,
+		&cfg.ClientSecret,
+		&cfg.IssuerURL,
+		&cfg.AuthorizationURL,
+		&cfg.TokenURL,
+		&cfg.UserinfoURL,
+		&cfg.JWKSURL,
+		&cfg.Scopes,
+		&extra,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.OAuthIDPConfig{}, err
+	}
+	cfg.Extra = map[string]any{}
+	if len(extra) > 0 {
+		if err := json.Unmarshal(extra, &cfg.Extra); err != nil {
+			return domain.OAuthIDPConfig{}, fmt.Errorf("decode idp extra: %w", err)
+		}
+	}
+	cfg.CreatedAt = createdAt.Time
+	cfg.UpdatedAt = updatedAt.Time
+	return cfg, nil
+}
+
+// Defaults for orgs without a config row, matching the column defaults.
+
+func defaultBranding(orgID int64) domain.Branding {
+	return domain.Branding{OrgID: orgID, DarkMode: true}
+}
+
+func defaultPasswordConfig(orgID int64) domain.PasswordConfig {
+	return domain.PasswordConfig{
+		OrgID:                  orgID,
+		MinLength:              8,
+		AllowSignup:            true,
+		AllowPasswordReset:     true,
+		LockoutAttempts:        5,
+		LockoutDurationSeconds: 300,
+		EmailVerification:      domain.EmailVerificationOptional,
+	}
+}
+
+func defaultOTPConfig(orgID int64) domain.OTPConfig {
+	return domain.OTPConfig{OrgID: orgID, Channel: "sms", ExpirySeconds: 300}
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
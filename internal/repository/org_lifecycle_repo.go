@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+const orgColumns = `id, type::text, name, code, slug, COALESCE(external_id, ''), country_code, timezone, is_default, status, created_at, updated_at, deleted_at`
+
+// withOrgDefaults fills the columns the tenants table requires but callers may
+// leave empty. The country and timezone match the seeded platform org.
+func withOrgDefaults(org domain.Org) domain.Org {
+	switch org.Type {
+	case domain.OrgTypePlatform, domain.OrgTypePersonal, domain.OrgTypeCompany:
+	default:
+		org.Type = domain.OrgTypeCompany
+	}
+	if org.Code == "" {
+		org.Code = strings.ToUpper(org.Slug)
+	}
+	if org.CountryCode == "" {
+		org.CountryCode = "SG"
+	}
+	if org.Timezone == "" {
+		org.Timezone = "Asia/Singapore"
+	}
+	org.Status = strings.ToLower(strings.TrimSpace(org.Status))
+	if org.Status == "" {
+		org.Status = domain.OrgStatusActive
+	}
+	return org
+}
+
+func (r *PostgresOrgRepo) List(ctx context.Context, status string, limit, offset int) ([]domain.Org, int64, error) {
+	const filter = ` FROM tenants WHERE ($1 = '' OR status = $1)`
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*)`+filter, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count orgs: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT `+orgColumns+filter+` ORDER BY id ASC LIMIT $2 OFFSET $3`, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list orgs: %w", err)
+	}
+	defer rows.Close()
+	var orgs []domain.Org
+	for rows.Next() {
+		org, err := scanOrg(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan org: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("list orgs: %w", err)
+	}
+	return orgs, total, nil
+}
+
+func (r *PostgresOrgRepo) Update(ctx context.Context, org domain.Org) (domain.Org, error) {
+	query := `
+UPDATE tenants
+SET name = $2, code = $3, slug = $4, external_id = NULLIF($5, ''), country_code = $6, timezone = $7, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + orgColumns
+	updated, err := scanOrg(r.db.QueryRow(ctx, query,
+		org.ID,
+		org.Name,
+		org.Code,
+		org.Slug,
+		org.ExternalID,
+		org.CountryCode,
+		org.Timezone,
+	))
+	if err != nil {
+		return domain.Org{}, fmt.Errorf("update org: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *PostgresOrgRepo) SetStatus(ctx context.Context, orgID int64, status string) (domain.Org, error) {
+	query := `
+UPDATE tenants SET status = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + orgColumns
+	org, err := scanOrg(r.db.QueryRow(ctx, query, orgID, status))
+	if err != nil {
+		return domain.Org{}, fmt.Errorf("set org status: %w", err)
+	}
+	return org, nil
+}
+
+func (r *PostgresOrgRepo) SoftDelete(ctx context.Context, orgID int64) (domain.Org, error) {
+	query := `
+UPDATE tenants SET status = 'deleted', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + orgColumns
+	org, err := scanOrg(r.db.QueryRow(ctx, query, orgID))
+	if err != nil {
+		return domain.Org{}, fmt.Errorf("delete org: %w", err)
+	}
+	return org, nil
+}
+
+func (r *PostgresOrgRepo) Restore(ctx context.Context, orgID int64, status string) (domain.Org, error) {
+	query := `
+UPDATE tenants SET status = $2, deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING ` + orgColumns
+	org, err := scanOrg(r.db.QueryRow(ctx, query, orgID, status))
+	if err != nil {
+		return domain.Org{}, fmt.Errorf("restore org: %w", err)
+	}
+	return org, nil
+}
+
+func (r *PostgresOrgRepo) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `DELETE FROM tenants WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id`, deletedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("purge orgs: %w", err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan purged org: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("purge orgs: %w", err)
+	}
+	return ids, nil
+}
+
+func scanOrg(row pgx.Row) (domain.Org, error) {
+	var (
+		org       domain.Org
+		createdAt sql.NullTime
+		updatedAt sql.NullTime
+		deletedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&org.ID,
+		&org.Type,
+		&org.Name,
+		&org.Code,
+		&org.Slug,
+		&org.ExternalID,
+		&org.CountryCode,
+		&org.Timezone,
+		&org.IsDefault,
+		&org.Status,
+		&createdAt,
+		&updatedAt,
+		&deletedAt,
+	); err != nil {
+		return domain.Org{}, err
+	}
+	org.CreatedAt = createdAt.Time
+	org.UpdatedAt = updatedAt.Time
+	org.DeletedAt = nullableTime(deletedAt)
+	return org, nil
+}
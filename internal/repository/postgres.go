@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/smallbiznis/railzway-auth/internal/domain"
@@ -15,13 +17,15 @@ import (
 
 // Compile-time interface assertions.
 var (
-	_ OrgRepository         = (*PostgresOrgRepo)(nil)
-	_ UserRepository        = (*PostgresUserRepo)(nil)
-	_ TokenRepository       = (*PostgresTokenRepo)(nil)
-	_ CodeRepository        = (*PostgresCodeRepo)(nil)
-	_ KeyRepository         = (*PostgresKeyRepo)(nil)
-	_ OAuthClientRepository = (*PostgresOAuthClientRepo)(nil)
-	_ OAuthAppRepository    = (*PostgresOAuthAppRepo)(nil)
+	_ OrgRepository                = (*PostgresOrgRepo)(nil)
+	_ UserRepository               = (*PostgresUserRepo)(nil)
+	_ TokenRepository              = (*PostgresTokenRepo)(nil)
+	_ CodeRepository               = (*PostgresCodeRepo)(nil)
+	_ KeyRepository                = (*PostgresKeyRepo)(nil)
+	_ OAuthClientRepository        = (*PostgresOAuthClientRepo)(nil)
+	_ OAuthAppRepository           = (*PostgresOAuthAppRepo)(nil)
+	_ MFARepository                = (*PostgresMFARepo)(nil)
+	_ WebAuthnCredentialRepository = (*PostgresWebAuthnRepo)(nil)
 )
 
 // PostgresOrgRepo implements OrgRepository using sqlc.
@@ -40,7 +44,7 @@ func (r *PostgresOrgRepo) GetDomainByHost(ctx context.Context, host string) (dom
 		return domain.Domain{}, fmt.Errorf("get domain: %w", err)
 	}
 	// TODO(v1): rename tenant_id columns to org_id in DB schema.
-	return domain.Domain{ID: row.ID, Host: row.Host, OrgID: row.TenantID}, nil
+	return domain.Domain{ID: row.ID, Host: row.Host, OrgID: row.TenantID, Verified: row.Verified}, nil
 }
 
 func (r *PostgresOrgRepo) GetOrg(ctx context.Context, orgID int64) (domain.Org, error) {
@@ -61,42 +65,28 @@ func (r *PostgresOrgRepo) Count(ctx context.Context) (int64, error) {
 }
 
 func (r *PostgresOrgRepo) Create(ctx context.Context, org domain.Org) (domain.Org, error) {
-	const query = `
-INSERT INTO tenants (id, type, name, slug, external_id, status, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
-RETURNING id, type, name, code, slug, external_id, country_code, timezone, is_default, status, created_at, updated_at`
+	org = withOrgDefaults(org)
+	query := `
+INSERT INTO tenants (id, type, name, code, slug, external_id, country_code, timezone, is_default, status, created_at, updated_at)
+VALUES ($1, $2::tenant_type, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, NOW(), NOW())
+RETURNING ` + orgColumns
 
-	var row sqlc.GetTenantRow
-	var extID sql.NullString
-	err := r.db.QueryRow(ctx, query,
+	created, err := scanOrg(r.db.QueryRow(ctx, query,
 		org.ID,
-		"organization", // Default type if not in domain.Org, assume structure
-		org.Name,
-		org.Slug,
+		org.Type,
 		org.Name,
+		org.Code,
 		org.Slug,
-		sql.NullString{String: org.ExternalID, Valid: org.ExternalID != ""},
-		org.Status, // Assuming Active
-	).Scan(
-		&row.ID,
-		&row.Type,
-		&row.Name,
-		&row.Code,
-		&row.Slug,
-		&extID,
-		&row.CountryCode,
-		&row.Timezone,
-		&row.IsDefault,
-		&row.Status,
-		&row.CreatedAt,
-		&row.UpdatedAt,
-	)
+		org.ExternalID,
+		org.CountryCode,
+		org.Timezone,
+		org.IsDefault,
+		org.Status,
+	))
 	if err != nil {
 		return domain.Org{}, fmt.Errorf("create org: %w", err)
 	}
-	res := mapOrgRow(row)
-	res.ExternalID = extID.String
-	return res, nil
+	return created, nil
 }
 
 func (r *PostgresOrgRepo) GetByExternalID(ctx context.Context, externalID string) (domain.Org, error) {
@@ -144,15 +134,18 @@ func (r *PostgresOrgRepo) GetPrimaryDomain(ctx context.Context, orgID int64) (do
 	if err != nil {
 		return domain.Domain{}, fmt.Errorf("get primary domain: %w", err)
 	}
-	return domain.Domain{ID: row.ID, Host: row.Host, OrgID: row.TenantID}, nil
+	return domain.Domain{ID: row.ID, Host: row.Host, OrgID: row.TenantID, Verified: row.Verified}, nil
 }
 
 func (r *PostgresOrgRepo) GetBranding(ctx context.Context, orgID int64) (domain.Branding, error) {
-	row, err := r.q.GetBranding(ctx, orgID)
+	b, err := scanBranding(r.db.QueryRow(ctx, `SELECT `+brandingColumns+` FROM brandings WHERE tenant_id = $1`, orgID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultBranding(orgID), nil
+	}
 	if err != nil {
 		return domain.Branding{}, fmt.Errorf("get branding: %w", err)
 	}
-	return domain.Branding{OrgID: row.TenantID, LogoURL: &row.LogoURL, PrimaryColor: &row.PrimaryColor}, nil
+	return b, nil
 }
 
 func (r *PostgresOrgRepo) ListAuthProviders(ctx context.Context, orgID int64) ([]domain.AuthProvider, error) {
@@ -182,6 +175,9 @@ func (r *PostgresOrgRepo) ListAuthProviders(ctx context.Context, orgID int64) ([
 
 func (r *PostgresOrgRepo) GetPasswordConfig(ctx context.Context, orgID int64) (domain.PasswordConfig, error) {
 	row, err := r.q.GetPasswordConfig(ctx, orgID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultPasswordConfig(orgID), nil
+	}
 	if err != nil {
 		return domain.PasswordConfig{}, fmt.Errorf("get password config: %w", err)
 	}
@@ -195,6 +191,7 @@ func (r *PostgresOrgRepo) GetPasswordConfig(ctx context.Context, orgID int64) (d
 		AllowPasswordReset:     row.AllowPasswordReset,
 		LockoutAttempts:        int(row.LockoutAttempts),
 		LockoutDurationSeconds: int(row.LockoutDurationSeconds),
+		EmailVerification:      row.EmailVerification,
 		CreatedAt:              row.CreatedAt,
 		UpdatedAt:              row.UpdatedAt,
 	}, nil
@@ -202,6 +199,9 @@ func (r *PostgresOrgRepo) GetPasswordConfig(ctx context.Context, orgID int64) (d
 
 func (r *PostgresOrgRepo) GetOTPConfig(ctx context.Context, orgID int64) (domain.OTPConfig, error) {
 	row, err := r.q.GetOTPConfig(ctx, orgID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultOTPConfig(orgID), nil
+	}
 	if err != nil {
 		return domain.OTPConfig{}, fmt.Errorf("get otp config: %w", err)
 	}
@@ -252,6 +252,27 @@ func (r *PostgresOrgRepo) ListOAuthIDPConfigs(ctx context.Context, orgID int64)
 	return res, nil
 }
 
+func (r *PostgresOrgRepo) GetMFAConfig(ctx context.Context, orgID int64) (domain.MFAConfig, error) {
+	const query = `SELECT tenant_id, policy, created_at, updated_at FROM mfa_configs WHERE tenant_id = $1`
+
+	cfg := domain.MFAConfig{OrgID: orgID, Policy: domain.MFAPolicyOff}
+	var createdAt, updatedAt sql.NullTime
+	err := r.db.QueryRow(ctx, query, orgID).Scan(&cfg.OrgID, &cfg.Policy, &createdAt, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return cfg, nil
+	}
+	if err != nil {
+		return domain.MFAConfig{}, fmt.Errorf("get mfa config: %w", err)
+	}
+	cfg.CreatedAt = createdAt.Time
+	cfg.UpdatedAt = updatedAt.Time
+	return cfg, nil
+}
+
+func (r *PostgresOrgRepo) ListRateLimitOverrides(ctx context.Context, orgID int64) ([]domain.RateLimitOverride, error) {
+	return listRateLimitOverrides(ctx, r.db, orgID)
+}
+
 // PostgresUserRepo implements UserRepository.
 type PostgresUserRepo struct {
 	q  *sqlc.Queries
@@ -278,6 +299,14 @@ func (r *PostgresUserRepo) GetByID(ctx context.Context, orgID, userID int64) (do
 	return mapUserRow(row), nil
 }
 
+func (r *PostgresUserRepo) GetVerifiedByEmail(ctx context.Context, email string) (domain.User, error) {
+	row, err := r.q.GetVerifiedUserByEmail(ctx, email)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("get verified user: %w", err)
+	}
+	return mapUserRow(row), nil
+}
+
 const insertUserSQL = `INSERT INTO users (id, tenant_id, email, email_verified, password_hash, name, phone, phone_verified, avatar_url, status)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING id, tenant_id, email, email_verified, password_hash, name, phone, phone_verified, avatar_url, status, created_at, updated_at`
@@ -317,6 +346,19 @@ func (r *PostgresUserRepo) Create(ctx context.Context, user domain.User) (domain
 	return mapUserRow(inserted), nil
 }
 
+const markEmailVerifiedSQL = `UPDATE users SET email_verified = TRUE, updated_at = NOW() WHERE tenant_id = $1 AND id = $2`
+
+func (r *PostgresUserRepo) MarkEmailVerified(ctx context.Context, orgID, userID int64) error {
+	tag, err := r.db.Exec(ctx, markEmailVerifiedSQL, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("mark email verified: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("mark email verified: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
 // PostgresTokenRepo implements TokenRepository.
 type PostgresTokenRepo struct {
 	q *sqlc.Queries
@@ -335,7 +377,15 @@ func (r *PostgresTokenRepo) CreateToken(ctx context.Context, token domain.OAuthT
 	if token.UserID != 0 {
 		userID = sql.NullInt64{Int64: token.UserID, Valid: true}
 	}
-	row, err := r.q.InsertOAuthToken(ctx, token.ID, token.OrgID, token.ClientID, userID, token.AccessToken, refresh, token.Scopes, token.ExpiresAt)
+	activeOrgID := token.ActiveOrgID
+	if activeOrgID == 0 {
+		activeOrgID = token.OrgID
+	}
+	accessExpiresAt := sql.NullTime{}
+	if !token.AccessExpiresAt.IsZero() {
+		accessExpiresAt = sql.NullTime{Time: token.AccessExpiresAt, Valid: true}
+	}
+	row, err := r.q.InsertOAuthToken(ctx, token.ID, token.OrgID, token.ClientID, userID, token.AccessToken, refresh, token.Scopes, token.ExpiresAt, activeOrgID, token.Resource, token.DPoPJKT, accessExpiresAt, token.AMR)
 	if err != nil {
 		return domain.OAuthToken{}, fmt.Errorf("insert token: %w", err)
 	}
@@ -366,8 +416,8 @@ func (r *PostgresTokenRepo) GetByAccessToken(ctx context.Context, token string)
 	return mapTokenRow(row), nil
 }
 
-func (r *PostgresTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64) error {
-	if err := r.q.RotateRefreshToken(ctx, tokenID, refreshToken, time.Unix(expiresAt, 0)); err != nil {
+func (r *PostgresTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64, activeOrgID int64, accessToken string, accessExpiresAt int64) error {
+	if err := r.q.RotateRefreshToken(ctx, tokenID, refreshToken, time.Unix(expiresAt, 0), activeOrgID, accessToken, time.Unix(accessExpiresAt, 0)); err != nil {
 		return fmt.Errorf("rotate refresh token: %w", err)
 	}
 	return nil
@@ -380,6 +430,28 @@ func (r *PostgresTokenRepo) RevokeToken(ctx context.Context, tokenID int64) erro
 	return nil
 }
 
+func (r *PostgresTokenRepo) RevokeUserTokens(ctx context.Context, orgID, userID int64) error {
+	if err := r.q.RevokeOAuthTokensByUser(ctx, orgID, userID); err != nil {
+		return fmt.Errorf("revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTokenRepo) RevokeUserClientTokens(ctx context.Context, orgID, userID int64, clientID string) error {
+	if err := r.q.RevokeOAuthTokensByUserClient(ctx, orgID, userID, clientID); err != nil {
+		return fmt.Errorf("revoke user client tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTokenRepo) ListUserClientIDs(ctx context.Context, orgID, userID int64) ([]string, error) {
+	clientIDs, err := r.q.ListOAuthTokenClientsByUser(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user token clients: %w", err)
+	}
+	return clientIDs, nil
+}
+
 // PostgresCodeRepo implements CodeRepository.
 type PostgresCodeRepo struct {
 	q *sqlc.Queries
@@ -398,7 +470,15 @@ func (r *PostgresCodeRepo) CreateCode(ctx context.Context, code domain.OAuthCode
 	if code.CodeChallengeMethod != "" {
 		challengeMethod = sql.NullString{String: code.CodeChallengeMethod, Valid: true}
 	}
-	if err := r.q.InsertOAuthCode(ctx, code.ID, code.OrgID, code.ClientID, code.UserID, code.Code, code.RedirectURI, challenge, challengeMethod, code.ExpiresAt); err != nil {
+	activeOrgID := code.ActiveOrgID
+	if activeOrgID == 0 {
+		activeOrgID = code.OrgID
+	}
+	var authTime sql.NullTime
+	if !code.AuthTime.IsZero() {
+		authTime = sql.NullTime{Time: code.AuthTime, Valid: true}
+	}
+	if err := r.q.InsertOAuthCode(ctx, code.ID, code.OrgID, code.ClientID, code.UserID, code.Code, code.RedirectURI, challenge, challengeMethod, code.ExpiresAt, activeOrgID, code.Resource, authTime); err != nil {
 		return fmt.Errorf("insert code: %w", err)
 	}
 	return nil
@@ -418,6 +498,9 @@ func (r *PostgresCodeRepo) GetCode(ctx context.Context, orgID int64, code string
 		RedirectURI:         row.RedirectURI,
 		CodeChallenge:       row.CodeChallenge.String,
 		CodeChallengeMethod: row.CodeChallengeMethod.String,
+		ActiveOrgID:         row.ActiveTenantID,
+		Resource:            row.Resource,
+		AuthTime:            row.AuthTime.Time,
 		ExpiresAt:           row.ExpiresAt,
 		Revoked:             row.Revoked,
 		CreatedAt:           row.CreatedAt,
@@ -469,7 +552,7 @@ func NewPostgresOAuthClientRepo(pool *pgxpool.Pool) *PostgresOAuthClientRepo {
 
 func (r *PostgresOAuthClientRepo) GetClientByID(ctx context.Context, orgID int64, clientID string) (domain.OAuthClient, error) {
 	const query = `
-SELECT id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants, scopes, token_endpoint_auth_methods, require_consent, created_at
+SELECT id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants, scopes, token_endpoint_auth_methods, require_consent, COALESCE(jwks::text, ''), require_pushed_authorization_requests, post_logout_redirect_uris, COALESCE(backchannel_logout_uri, ''), token_exchange_audiences, dpop_bound_access_tokens, access_token_format, created_at
 FROM oauth_clients
 WHERE tenant_id = $1 AND client_id = $2
 LIMIT 1`
@@ -485,6 +568,13 @@ LIMIT 1`
 		scopes       []string
 		authMethods  []string
 		requireCons  bool
+		jwks         string
+		requirePAR   bool
+		logoutURIs   []string
+		backchannel  string
+		exchangeAuds []string
+		dpopBound    bool
+		tokenFormat  string
 		createdAt    time.Time
 	)
 
@@ -499,6 +589,13 @@ LIMIT 1`
 		&scopes,
 		&authMethods,
 		&requireCons,
+		&jwks,
+		&requirePAR,
+		&logoutURIs,
+		&backchannel,
+		&exchangeAuds,
+		&dpopBound,
+		&tokenFormat,
 		&createdAt,
 	); err != nil {
 		return domain.OAuthClient{}, fmt.Errorf("get oauth client: %w", err)
@@ -521,21 +618,35 @@ LIMIT 1`
 		Scopes:                   append([]string{}, scopes...),
 		TokenEndpointAuthMethods: append([]string{}, authMethods...),
 		RequireConsent:           requireCons,
+		JWKS:                     jwks,
+		RequirePAR:               requirePAR,
+		PostLogoutRedirectURIs:   append([]string{}, logoutURIs...),
+		BackchannelLogoutURI:     backchannel,
+		TokenExchangeAudiences:   append([]string{}, exchangeAuds...),
+		DPoPBoundAccessTokens:    dpopBound,
+		AccessTokenFormat:        tokenFormat,
 		CreatedAt:                createdAt,
 	}, nil
 }
 
 func (r *PostgresOAuthClientRepo) UpsertClient(ctx context.Context, client domain.OAuthClient) (domain.OAuthClient, error) {
 	const query = `
-INSERT INTO oauth_clients (id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants, scopes, token_endpoint_auth_methods, require_consent)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+INSERT INTO oauth_clients (id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants, scopes, token_endpoint_auth_methods, require_consent, jwks, require_pushed_authorization_requests, post_logout_redirect_uris, backchannel_logout_uri, token_exchange_audiences, dpop_bound_access_tokens, access_token_format)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, '')::jsonb, $12, $13, NULLIF($14, ''), $15, $16, COALESCE(NULLIF($17, ''), 'jwt'))
 ON CONFLICT (client_id) DO UPDATE SET
 	redirect_uris = EXCLUDED.redirect_uris,
 	grants = EXCLUDED.grants,
 	scopes = EXCLUDED.scopes,
 	token_endpoint_auth_methods = EXCLUDED.token_endpoint_auth_methods,
-	require_consent = EXCLUDED.require_consent
-RETURNING id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants, scopes, token_endpoint_auth_methods, require_consent, created_at`
+	require_consent = EXCLUDED.require_consent,
+	jwks = EXCLUDED.jwks,
+	require_pushed_authorization_requests = EXCLUDED.require_pushed_authorization_requests,
+	post_logout_redirect_uris = EXCLUDED.post_logout_redirect_uris,
+	backchannel_logout_uri = EXCLUDED.backchannel_logout_uri,
+	token_exchange_audiences = EXCLUDED.token_exchange_audiences,
+	dpop_bound_access_tokens = EXCLUDED.dpop_bound_access_tokens,
+	access_token_format = EXCLUDED.access_token_format
+RETURNING id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants, scopes, token_endpoint_auth_methods, require_consent, COALESCE(jwks::text, ''), require_pushed_authorization_requests, post_logout_redirect_uris, COALESCE(backchannel_logout_uri, ''), token_exchange_audiences, dpop_bound_access_tokens, access_token_format, created_at`
 
 	var (
 		rowID        int64
@@ -548,6 +659,13 @@ RETURNING id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants
 		scopes       []string
 		authMethods  []string
 		requireCons  bool
+		jwks         string
+		requirePAR   bool
+		logoutURIs   []string
+		backchannel  string
+		exchangeAuds []string
+		dpopBound    bool
+		tokenFormat  string
 		createdAt    time.Time
 	)
 
@@ -569,6 +687,13 @@ RETURNING id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants
 		client.Scopes,
 		client.TokenEndpointAuthMethods,
 		client.RequireConsent,
+		client.JWKS,
+		client.RequirePAR,
+		nonNilStrings(client.PostLogoutRedirectURIs),
+		client.BackchannelLogoutURI,
+		nonNilStrings(client.TokenExchangeAudiences),
+		client.DPoPBoundAccessTokens,
+		client.AccessTokenFormat,
 	).Scan(
 		&rowID,
 		&rowTenantID,
@@ -580,6 +705,13 @@ RETURNING id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants
 		&scopes,
 		&authMethods,
 		&requireCons,
+		&jwks,
+		&requirePAR,
+		&logoutURIs,
+		&backchannel,
+		&exchangeAuds,
+		&dpopBound,
+		&tokenFormat,
 		&createdAt,
 	); err != nil {
 		return domain.OAuthClient{}, fmt.Errorf("upsert oauth client: %w", err)
@@ -602,6 +734,13 @@ RETURNING id, tenant_id, app_id, client_id, client_secret, redirect_uris, grants
 		Scopes:                   append([]string{}, scopes...),
 		TokenEndpointAuthMethods: append([]string{}, authMethods...),
 		RequireConsent:           requireCons,
+		JWKS:                     jwks,
+		RequirePAR:               requirePAR,
+		PostLogoutRedirectURIs:   append([]string{}, logoutURIs...),
+		BackchannelLogoutURI:     backchannel,
+		TokenExchangeAudiences:   append([]string{}, exchangeAuds...),
+		DPoPBoundAccessTokens:    dpopBound,
+		AccessTokenFormat:        tokenFormat,
 		CreatedAt:                createdAt,
 	}, nil
 }
@@ -765,16 +904,21 @@ func mapTokenRow(row sqlc.InsertOAuthTokenRow) domain.OAuthToken {
 		userID = row.UserID.Int64
 	}
 	return domain.OAuthToken{
-		ID:           row.ID,
-		OrgID:        row.TenantID,
-		ClientID:     row.ClientID,
-		UserID:       userID,
-		AccessToken:  row.AccessToken,
-		RefreshToken: row.RefreshToken.String,
-		Scopes:       scopes,
-		ExpiresAt:    row.ExpiresAt,
-		Revoked:      row.Revoked,
-		CreatedAt:    row.CreatedAt,
+		ID:              row.ID,
+		OrgID:           row.TenantID,
+		ClientID:        row.ClientID,
+		UserID:          userID,
+		AccessToken:     row.AccessToken,
+		RefreshToken:    row.RefreshToken.String,
+		Scopes:          scopes,
+		ActiveOrgID:     row.ActiveTenantID,
+		Resource:        row.Resource,
+		DPoPJKT:         row.DPoPJKT,
+		ExpiresAt:       row.ExpiresAt,
+		AccessExpiresAt: row.AccessExpiresAt.Time,
+		AMR:             row.AMR,
+		Revoked:         row.Revoked,
+		CreatedAt:       row.CreatedAt,
 	}
 }
 
@@ -805,3 +949,11 @@ func mapUserRow(row sqlc.GetUserByEmailRow) domain.User {
 		UpdatedAt:     row.UpdatedAt,
 	}
 }
+
+// nonNilStrings keeps NOT NULL array columns from receiving NULL.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
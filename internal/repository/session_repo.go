@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresSessionRepo implements SessionRepository.
+type PostgresSessionRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresSessionRepo(pool *pgxpool.Pool) *PostgresSessionRepo {
+	return &PostgresSessionRepo{db: pool}
+}
+
+const sessionColumns = `id, tenant_id, user_id, token_hash, user_agent, ip_address, amr, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *PostgresSessionRepo) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	query := `
+INSERT INTO user_sessions (id, tenant_id, user_id, token_hash, user_agent, ip_address, amr, created_at, last_seen_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9)
+RETURNING ` + sessionColumns
+
+	stored, err := scanSession(r.db.QueryRow(ctx, query,
+		session.ID,
+		session.OrgID,
+		session.UserID,
+		session.TokenHash,
+		session.UserAgent,
+		session.IPAddress,
+		session.AMR,
+		session.CreatedAt,
+		session.ExpiresAt,
+	))
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("create session: %w", err)
+	}
+	return stored, nil
+}
+
+func (r *PostgresSessionRepo) ListActiveByUser(ctx context.Context, orgID, userID int64, idleSince time.Time) ([]domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions
+WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW() AND last_seen_at > $3
+ORDER BY last_seen_at DESC`
+	rows, err := r.db.Query(ctx, query, orgID, userID, idleSince)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+	return collectSessions(rows, "list sessions")
+}
+
+func (r *PostgresSessionRepo) Touch(ctx context.Context, id int64, seenAt time.Time) error {
+	const query = `UPDATE user_sessions SET last_seen_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+	if _, err := r.db.Exec(ctx, query, id, seenAt); err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresSessionRepo) Revoke(ctx context.Context, orgID, userID, id int64) (domain.Session, error) {
+	query := `
+UPDATE user_sessions SET revoked_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2 AND id = $3 AND revoked_at IS NULL
+RETURNING ` + sessionColumns
+	session, err := scanSession(r.db.QueryRow(ctx, query, orgID, userID, id))
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("revoke session: %w", err)
+	}
+	return session, nil
+}
+
+func (r *PostgresSessionRepo) RevokeAllByUser(ctx context.Context, orgID, userID int64) ([]domain.Session, error) {
+	query := `
+UPDATE user_sessions SET revoked_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING ` + sessionColumns
+	rows, err := r.db.Query(ctx, query, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("revoke user sessions: %w", err)
+	}
+	defer rows.Close()
+	return collectSessions(rows, "revoke user sessions")
+}
+
+func collectSessions(rows pgx.Rows, op string) ([]domain.Session, error) {
+	var sessions []domain.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+func scanSession(row pgx.Row) (domain.Session, error) {
+	var (
+		session   domain.Session
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&session.ID,
+		&session.OrgID,
+		&session.UserID,
+		&session.TokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.AMR,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	); err != nil {
+		return domain.Session{}, err
+	}
+	session.RevokedAt = nullableTime(revokedAt)
+	return session, nil
+}
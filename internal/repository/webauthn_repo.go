@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// PostgresWebAuthnRepo implements WebAuthnCredentialRepository.
+type PostgresWebAuthnRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresWebAuthnRepo(pool *pgxpool.Pool) *PostgresWebAuthnRepo {
+	return &PostgresWebAuthnRepo{db: pool}
+}
+
+const webAuthnCredentialColumns = `id, tenant_id, user_id, rp_id, credential_id, public_key, attestation_type, transports, aaguid, sign_count, backup_eligible, backup_state, name, last_used_at, created_at, updated_at`
+
+func (r *PostgresWebAuthnRepo) ListByUser(ctx context.Context, orgID, userID int64) ([]domain.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + ` FROM user_webauthn_credentials WHERE tenant_id = $1 AND user_id = $2 ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []domain.WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webauthn credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webauthn credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+func (r *PostgresWebAuthnRepo) GetByCredentialID(ctx context.Context, orgID int64, credentialID []byte) (domain.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + ` FROM user_webauthn_credentials WHERE tenant_id = $1 AND credential_id = $2`
+	credential, err := scanWebAuthnCredential(r.db.QueryRow(ctx, query, orgID, credentialID))
+	if err != nil {
+		return domain.WebAuthnCredential{}, fmt.Errorf("get webauthn credential: %w", err)
+	}
+	return credential, nil
+}
+
+func (r *PostgresWebAuthnRepo) Create(ctx context.Context, credential domain.WebAuthnCredential) (domain.WebAuthnCredential, error) {
+	query := `
+INSERT INTO user_webauthn_credentials (
+	id, tenant_id, user_id, rp_id, credential_id, public_key, attestation_type,
+	transports, aaguid, sign_count, backup_eligible, backup_state, name
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING ` + webAuthnCredentialColumns
+
+	transports := credential.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	stored, err := scanWebAuthnCredential(r.db.QueryRow(ctx, query,
+		credential.ID,
+		credential.OrgID,
+		credential.UserID,
+		credential.RPID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		transports,
+		credential.AAGUID,
+		int64(credential.SignCount),
+		credential.BackupEligible,
+		credential.BackupState,
+		credential.Name,
+	))
+	if err != nil {
+		return domain.WebAuthnCredential{}, fmt.Errorf("create webauthn credential: %w", err)
+	}
+	return stored, nil
+}
+
+func (r *PostgresWebAuthnRepo) UpdateUsage(ctx context.Context, id int64, signCount uint32, backupState bool) error {
+	const query = `
+UPDATE user_webauthn_credentials
+SET sign_count = $2, backup_state = $3, last_used_at = NOW(), updated_at = NOW()
+WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, id, int64(signCount), backupState); err != nil {
+		return fmt.Errorf("update webauthn credential usage: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresWebAuthnRepo) Delete(ctx context.Context, orgID, userID, id int64) error {
+	const query = `DELETE FROM user_webauthn_credentials WHERE tenant_id = $1 AND user_id = $2 AND id = $3`
+	tag, err := r.db.Exec(ctx, query, orgID, userID, id)
+	if err != nil {
+		return fmt.Errorf("delete webauthn credential: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete webauthn credential: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func scanWebAuthnCredential(row pgx.Row) (domain.WebAuthnCredential, error) {
+	var (
+		credential domain.WebAuthnCredential
+		signCount  int64
+		lastUsedAt sql.NullTime
+		createdAt  sql.NullTime
+		updatedAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&credential.ID,
+		&credential.OrgID,
+		&credential.UserID,
+		&credential.RPID,
+		&credential.CredentialID,
+		&credential.PublicKey,
+		&credential.AttestationType,
+		&credential.Transports,
+		&credential.AAGUID,
+		&signCount,
+		&credential.BackupEligible,
+		&credential.BackupState,
+		&credential.Name,
+		&lastUsedAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.WebAuthnCredential{}, err
+	}
+	credential.SignCount = uint32(signCount)
+	credential.LastUsedAt = nullableTime(lastUsedAt)
+	credential.CreatedAt = createdAt.Time
+	credential.UpdatedAt = updatedAt.Time
+	return credential, nil
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,13 +15,15 @@ import (
 // HTTPServer wraps a gin.Engine with graceful shutdown helpers.
 type HTTPServer struct {
 	Engine *gin.Engine
+	// TLSConfig enables RunTLS. It is nil when TLS is terminated elsewhere.
+	TLSConfig *tls.Config
 }
 
 // NewHTTPServer creates a server with sane defaults such as recovery middleware.
-func NewHTTPServer(router *gin.Engine) *HTTPServer {
+func NewHTTPServer(router *gin.Engine, tlsConfig *tls.Config) *HTTPServer {
 	router.HandleMethodNotAllowed = true
 	router.ForwardedByClientIP = true
-	return &HTTPServer{Engine: router}
+	return &HTTPServer{Engine: router, TLSConfig: tlsConfig}
 }
 
 // Run starts the HTTP server on the provided addr and shuts it down when ctx is done.
@@ -28,11 +32,31 @@ func (s *HTTPServer) Run(ctx context.Context, addr string) error {
 		Addr:    addr,
 		Handler: s.Engine,
 	}
+	return serve(ctx, srv, srv.ListenAndServe)
+}
+
+// RunTLS starts the HTTPS listener on addr using TLSConfig and shuts it down
+// when ctx is done.
+func (s *HTTPServer) RunTLS(ctx context.Context, addr string) error {
+	if s.TLSConfig == nil {
+		return errors.New("tls is not configured")
+	}
+	srv := &http.Server{
+		Addr:      addr,
+		Handler:   s.Engine,
+		TLSConfig: s.TLSConfig,
+	}
+	return serve(ctx, srv, func() error {
+		// Certificates come from TLSConfig.GetCertificate.
+		return srv.ListenAndServeTLS("", "")
+	})
+}
 
+func serve(ctx context.Context, srv *http.Server, listen func() error) error {
 	g, ctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := listen(); err != nil && err != http.ErrServerClosed {
 			return fmt.Errorf("listen: %w", err)
 		}
 		return nil
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+)
+
+// maxAPIResourceTokenTTL caps the access token lifetime an API may ask for.
+const maxAPIResourceTokenTTL = 24 * time.Hour
+
+// identityScopes are OIDC scopes about the user rather than an API, so they
+// may be requested alongside any resource.
+var identityScopes = []string{"openid", "profile", "email", "phone", "address", "offline_access"}
+
+// APIResourceInput describes an API registration.
+type APIResourceInput struct {
+	Identifier string
+	Name       string
+	Scopes     []string
+	// AccessTokenTTL of zero uses the server default.
+	AccessTokenTTL time.Duration
+	// SigningAlgorithm defaults to the first of jwt.SigningAlgorithms.
+	SigningAlgorithm string
+}
+
+// ListAPIResources returns the APIs registered with the org.
+func (s *AuthService) ListAPIResources(ctx context.Context, orgID int64) ([]domain.APIResource, error) {
+	if s == nil || s.resources == nil {
+		return nil, newOAuthError("server_error", "API resource repository unavailable.", http.StatusInternalServerError)
+	}
+	resources, err := s.resources.List(ctx, orgID)
+	if err != nil {
+		return nil, newOAuthError("server_error", "Failed to list API resources.", http.StatusInternalServerError)
+	}
+	return resources, nil
+}
+
+// UpsertAPIResource registers an API or updates the one with the same
+// identifier.
+func (s *AuthService) UpsertAPIResource(ctx context.Context, orgID int64, input APIResourceInput) (domain.APIResource, error) {
+	if s == nil || s.resources == nil {
+		return domain.APIResource{}, newOAuthError("server_error", "API resource repository unavailable.", http.StatusInternalServerError)
+	}
+
+	identifier := strings.TrimSpace(input.Identifier)
+	if !isResourceIndicator(identifier) {
+		return domain.APIResource{}, newOAuthError("invalid_request", "identifier must be an absolute URI without a fragment.", http.StatusBadRequest)
+	}
+	scopes := normalizeList(input.Scopes)
+	for _, scope := range scopes {
+		if strings.ContainsAny(scope, " \t\"\\") {
+			return domain.APIResource{}, newOAuthError("invalid_request", "scopes must not contain spaces, quotes or backslashes.", http.StatusBadRequest)
+		}
+	}
+	if input.AccessTokenTTL < 0 || input.AccessTokenTTL > maxAPIResourceTokenTTL {
+		return domain.APIResource{}, newOAuthError("invalid_request", "access_token_ttl must be between 0 and 24 hours.", http.StatusBadRequest)
+	}
+	alg := strings.TrimSpace(input.SigningAlgorithm)
+	if alg == "" {
+		alg = jwt.SigningAlgorithms[0]
+	}
+	if !slices.Contains(jwt.SigningAlgorithms, alg) {
+		return domain.APIResource{}, newOAuthError("invalid_request", "signing_alg must be one of "+strings.Join(jwt.SigningAlgorithms, ", ")+".", http.StatusBadRequest)
+	}
+
+	stored, err := s.resources.Upsert(ctx, domain.APIResource{
+		ID:               s.snowflake.Generate().Int64(),
+		OrgID:            orgID,
+		Identifier:       identifier,
+		Name:             strings.TrimSpace(input.Name),
+		Scopes:           scopes,
+		AccessTokenTTL:   input.AccessTokenTTL.Truncate(time.Second),
+		SigningAlgorithm: alg,
+	})
+	if err != nil {
+		return domain.APIResource{}, newOAuthError("server_error", "Failed to save API resource.", http.StatusInternalServerError)
+	}
+	s.audit("api_resource.saved", "org_id", orgID, "identifier", identifier)
+	return stored, nil
+}
+
+// DeleteAPIResource removes an API. Tokens already issued for it stay valid
+// until they expire, but cannot be refreshed.
+func (s *AuthService) DeleteAPIResource(ctx context.Context, orgID int64, identifier string) error {
+	if s == nil || s.resources == nil {
+		return newOAuthError("server_error", "API resource repository unavailable.", http.StatusInternalServerError)
+	}
+	if err := s.resources.Delete(ctx, orgID, strings.TrimSpace(identifier)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("not_found", "API resource not found.", http.StatusNotFound)
+		}
+		return newOAuthError("server_error", "Failed to delete API resource.", http.StatusInternalServerError)
+	}
+	s.audit("api_resource.deleted", "org_id", orgID, "identifier", identifier)
+	return nil
+}
+
+// ResolveResource looks up the API named by the resource indicators of a
+// request. It returns nil when none was requested. Tokens are audienced to a
+// single API, so asking for more than one fails with invalid_target.
+func (s *AuthService) ResolveResource(ctx context.Context, orgID int64, indicators []string) (*domain.APIResource, error) {
+	var identifier string
+	for _, value := range normalizeList(indicators) {
+		if identifier != "" && value != identifier {
+			return nil, newOAuthError("invalid_target", "Only one resource may be requested.", http.StatusBadRequest)
+		}
+		identifier = value
+	}
+	if identifier == "" {
+		return nil, nil
+	}
+	if !isResourceIndicator(identifier) {
+		return nil, newOAuthError("invalid_target", "resource must be an absolute URI without a fragment.", http.StatusBadRequest)
+	}
+	if s == nil || s.resources == nil {
+		return nil, newOAuthError("invalid_target", "Unknown resource.", http.StatusBadRequest)
+	}
+	resource, err := s.resources.Get(ctx, orgID, identifier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newOAuthError("invalid_target", "Unknown resource.", http.StatusBadRequest)
+		}
+		return nil, newOAuthError("server_error", "Failed to load resource.", http.StatusInternalServerError)
+	}
+	return &resource, nil
+}
+
+// checkResourceScope rejects scopes that are neither identity scopes nor
+// defined by resource.
+func checkResourceScope(resource *domain.APIResource, scope string) error {
+	if resource == nil {
+		return nil
+	}
+	for _, value := range strings.Fields(scope) {
+		if !slices.Contains(identityScopes, value) && !slices.Contains(resource.Scopes, value) {
+			return newOAuthError("invalid_scope", "Scope "+value+" is not defined for the requested resource.", http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// accessTokenOptions audiences a token for clientID to resource, or to the
+// org when resource is nil.
+func accessTokenOptions(clientID string, resource *domain.APIResource) jwt.AccessTokenOptions {
+	opts := jwt.AccessTokenOptions{ClientID: clientID}
+	if resource != nil {
+		opts.Audience = []string{resource.Identifier}
+		opts.Algorithm = resource.SigningAlgorithm
+		opts.TTL = resource.AccessTokenTTL
+	}
+	return opts
+}
+
+// accessTokenTTL is the lifetime of access tokens issued for resource.
+func (s *AuthService) accessTokenTTL(resource *domain.APIResource) time.Duration {
+	if resource != nil && resource.AccessTokenTTL > 0 {
+		return resource.AccessTokenTTL
+	}
+	return s.cfg.AccessTokenTTL
+}
+
+// resourceIdentifier returns the identifier of resource, or "" for nil.
+func resourceIdentifier(resource *domain.APIResource) string {
+	if resource == nil {
+		return ""
+	}
+	return resource.Identifier
+}
+
+// isResourceIndicator reports whether value is an absolute URI without a
+// fragment, as RFC 8707 requires.
+func isResourceIndicator(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Scheme != "" && parsed.Fragment == "" && !strings.Contains(value, "#")
+}
@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestAPIResourceTokens(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := password.Hash("password")
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", PasswordHash: hash}
+	tokenRepo := &memoryTokenRepo{}
+	clients := &logoutClientRepo{client: domain.OAuthClient{ClientID: "worker", ClientSecret: "secret"}}
+	resources := &memoryAPIResourceRepo{}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &memoryUserRepo{user: user},
+		Tokens:    tokenRepo,
+		Codes:     &memoryCodeRepo{},
+		Clients:   clients,
+		Resources: resources,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{
+		Org:            domain.Org{ID: 1, Name: "Tenant A"},
+		PasswordConfig: domain.PasswordConfig{OrgID: 1, MinLength: 8, LockoutAttempts: 5, LockoutDurationSeconds: 300},
+		AuthProviders:  []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+	}
+	issuer := "https://tenant.example"
+	requireCode := func(err error, code string) {
+		t.Helper()
+		var oauthErr *service.OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		require.Equal(t, code, oauthErr.Code)
+	}
+
+	_, err = authService.UpsertAPIResource(ctx, 1, service.APIResourceInput{Identifier: "orders"})
+	requireCode(err, "invalid_request")
+	_, err = authService.UpsertAPIResource(ctx, 1, service.APIResourceInput{Identifier: "https://orders.example", SigningAlgorithm: "none"})
+	requireCode(err, "invalid_request")
+	api, err := authService.UpsertAPIResource(ctx, 1, service.APIResourceInput{
+		Identifier:     "https://orders.example",
+		Name:           "Orders",
+		Scopes:         []string{"orders:read", "orders:write"},
+		AccessTokenTTL: 5 * time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "HS256", api.SigningAlgorithm)
+
+	_, err = authService.ClientCredentialsGrant(ctx, orgCtx, "worker", "secret", "orders:read", issuer, "https://billing.example")
+	requireCode(err, "invalid_target")
+	_, err = authService.ClientCredentialsGrant(ctx, orgCtx, "worker", "secret", "billing:read", issuer, api.Identifier)
+	requireCode(err, "invalid_scope")
+	_, err = authService.ResolveResource(ctx, 1, []string{api.Identifier, "https://billing.example"})
+	requireCode(err, "invalid_target")
+
+	resp, err := authService.ClientCredentialsGrant(ctx, orgCtx, "worker", "secret", "orders:read", issuer, api.Identifier)
+	require.NoError(t, err)
+	require.Equal(t, 300, resp.ExpiresIn)
+	std, claims, err := generator.ValidateAccessToken(ctx, 1, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, []string{api.Identifier}, []string(std.Audience))
+	require.Equal(t, "worker", claims.ClientID)
+	require.Equal(t, api.Identifier, tokenRepo.lastToken.Resource)
+
+	_, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", issuer)
+	require.NoError(t, err)
+	tokenRepo.lastToken.Resource = api.Identifier
+	_, err = authService.RefreshGrant(ctx, orgCtx, tokenRepo.lastToken.RefreshToken, "", issuer, "https://billing.example")
+	requireCode(err, "invalid_target")
+	resp, err = authService.RefreshGrant(ctx, orgCtx, tokenRepo.lastToken.RefreshToken, "", issuer, "")
+	require.NoError(t, err)
+	std, _, err = generator.ValidateAccessToken(ctx, 1, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, []string{api.Identifier}, []string(std.Audience), "refreshed tokens keep their resource")
+
+	require.NoError(t, authService.DeleteAPIResource(ctx, 1, api.Identifier))
+	_, err = authService.RefreshGrant(ctx, orgCtx, tokenRepo.lastToken.RefreshToken, "", issuer, "")
+	requireCode(err, "invalid_grant")
+}
+
+type memoryAPIResourceRepo struct {
+	items map[string]domain.APIResource
+}
+
+func (m *memoryAPIResourceRepo) Get(ctx context.Context, orgID int64, identifier string) (domain.APIResource, error) {
+	resource, ok := m.items[identifier]
+	if !ok || resource.OrgID != orgID {
+		return domain.APIResource{}, pgx.ErrNoRows
+	}
+	return resource, nil
+}
+
+func (m *memoryAPIResourceRepo) List(ctx context.Context, orgID int64) ([]domain.APIResource, error) {
+	var out []domain.APIResource
+	for _, resource := range m.items {
+		if resource.OrgID == orgID {
+			out = append(out, resource)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryAPIResourceRepo) Upsert(ctx context.Context, resource domain.APIResource) (domain.APIResource, error) {
+	if m.items == nil {
+		m.items = make(map[string]domain.APIResource)
+	}
+	m.items[resource.Identifier] = resource
+	return resource, nil
+}
+
+func (m *memoryAPIResourceRepo) Delete(ctx context.Context, orgID int64, identifier string) error {
+	if _, err := m.Get(ctx, orgID, identifier); err != nil {
+		return err
+	}
+	delete(m.items, identifier)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,7 +29,8 @@ type OAuthService interface {
 	ListProviders(ctx context.Context, orgID int64) ([]domainoauth.OAuthProvider, error)
 	StartAuthorization(ctx context.Context, orgID int64, in StartAuthorizationInput) (*StartAuthorizationOutput, error)
 	HandleCallback(ctx context.Context, orgID int64, in OAuthCallbackInput) (*OAuthSession, error)
-	IntrospectToken(ctx context.Context, token string) (*TokenIntrospection, error)
+	IntrospectToken(ctx context.Context, orgID int64, token, tokenTypeHint string) (*TokenIntrospection, error)
+	SignIntrospection(ctx context.Context, org domain.Org, clientID, issuer string, result *TokenIntrospection) (string, error)
 	RevokeToken(ctx context.Context, token string) error
 	UserInfo(ctx context.Context, token string) (*domainoauth.OAuthUserInfo, error)
 }
@@ -65,17 +67,76 @@ type OAuthSession struct {
 	RefreshToken string
 	ExpiresIn    int64
 	TokenType    string
+	// AMR lists the authentication methods completed, for the browser
+	// session opened alongside the tokens.
+	AMR []string
+}
+
+// SignInPolicy applies an org's email verification and MFA policies to a
+// user returning from an external identity provider. It returns the scope
+// the user may be granted, or an error such as an MFA challenge when the
+// provider alone is not enough to issue tokens.
+type SignInPolicy interface {
+	AdmitFederatedSignIn(ctx context.Context, orgID int64, user domain.User, scope, issuer string, providers []string) (string, error)
 }
 
 // TokenIntrospection expresses RFC 7662 compliant response.
 type TokenIntrospection struct {
 	Active    bool
 	Subject   string
+	Username  string
 	Scope     string
 	ExpiresAt int64
 	IssuedAt  int64
+	NotBefore int64
 	ClientID  string
+	Audience  []string
 	OrgID     int64
+	// TokenType is Bearer or DPoP for access tokens and refresh_token for
+	// refresh tokens.
+	TokenType string
+	Issuer    string
+	JTI       string
+	// DPoPKey is the thumbprint the token is bound to, reported as cnf.jkt.
+	DPoPKey string
+}
+
+// Response renders the RFC 7662 response members. Inactive tokens report
+// nothing but active=false.
+func (t *TokenIntrospection) Response() map[string]any {
+	if t == nil || !t.Active {
+		return map[string]any{"active": false}
+	}
+	out := map[string]any{
+		"active":     true,
+		"sub":        t.Subject,
+		"scope":      t.Scope,
+		"exp":        t.ExpiresAt,
+		"client_id":  t.ClientID,
+		"token_type": t.TokenType,
+		"org_id":     t.OrgID,
+		"tenant_id":  t.OrgID,
+	}
+	optional := map[string]string{"username": t.Username, "iss": t.Issuer, "jti": t.JTI}
+	for key, value := range optional {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	if len(t.Audience) > 0 {
+		out["aud"] = t.Audience
+	}
+	// Opaque access tokens do not record when they were issued.
+	if t.IssuedAt != 0 {
+		out["iat"] = t.IssuedAt
+	}
+	if t.NotBefore != 0 {
+		out["nbf"] = t.NotBefore
+	}
+	if t.DPoPKey != "" {
+		out["cnf"] = map[string]string{"jkt": t.DPoPKey}
+	}
+	return out
 }
 
 type oauthService struct {
@@ -86,6 +147,7 @@ type oauthService struct {
 	userRepo       repository.UserRepository
 	tokenRepo      repository.TokenRepository
 	jwt            *jwt.Generator
+	policy         SignInPolicy
 	cfg            config.Config
 	logger         *zap.Logger
 }
@@ -99,6 +161,7 @@ func NewOAuthService(
 	userRepo repository.UserRepository,
 	tokenRepo repository.TokenRepository,
 	jwtGenerator *jwt.Generator,
+	policy SignInPolicy,
 	cfg config.Config,
 	logger *zap.Logger,
 ) OAuthService {
@@ -110,6 +173,7 @@ func NewOAuthService(
 		userRepo:       userRepo,
 		tokenRepo:      tokenRepo,
 		jwt:            jwtGenerator,
+		policy:         policy,
 		cfg:            cfg,
 		logger:         logger,
 	}
@@ -380,7 +444,16 @@ func (s *oauthService) buildOAuthSession(ctx context.Context, orgID int64, cfg *
 	}
 	scopeString := strings.Join(scope, " ")
 
-	accessToken, err := s.jwt.GenerateAccessToken(ctx, orgRow, user, scopeString, issuer, []string{cfg.ProviderName})
+	providers := []string{cfg.ProviderName}
+	if s.policy != nil {
+		scopeString, err = s.policy.AdmitFederatedSignIn(ctx, orgID, user, scopeString, issuer, providers)
+		if err != nil {
+			return nil, err
+		}
+		scope = strings.Fields(scopeString)
+	}
+
+	accessToken, err := s.jwt.GenerateAccessToken(ctx, orgRow, user, scopeString, issuer, providers)
 	if err != nil {
 		return nil, fmt.Errorf("generate access token: %w", err)
 	}
@@ -398,6 +471,7 @@ func (s *oauthService) buildOAuthSession(ctx context.Context, orgID int64, cfg *
 		RefreshToken: refreshToken,
 		Scopes:       scope,
 		ExpiresAt:    time.Now().Add(s.cfg.RefreshTokenTTL),
+		AMR:          providers,
 		CreatedAt:    time.Now(),
 	}); err != nil {
 		return nil, fmt.Errorf("persist refresh token: %w", err)
@@ -412,39 +486,71 @@ func (s *oauthService) buildOAuthSession(ctx context.Context, orgID int64, cfg *
 		RefreshToken: refreshToken,
 		ExpiresIn:    int64(s.cfg.AccessTokenTTL.Seconds()),
 		TokenType:    "Bearer",
+		AMR:          providers,
 	}, nil
 }
 
-func (s *oauthService) IntrospectToken(ctx context.Context, token string) (*TokenIntrospection, error) {
-	if strings.TrimSpace(token) == "" {
+// IntrospectToken reports the state of an access or refresh token issued by
+// orgID (RFC 7662). tokenTypeHint, when "refresh_token", makes refresh
+// tokens be looked up first. Unknown, expired and revoked tokens, and tokens
+// of other orgs, are inactive.
+func (s *oauthService) IntrospectToken(ctx context.Context, orgID int64, token, tokenTypeHint string) (*TokenIntrospection, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
 		return nil, domainoauth.ErrInvalidRequest
 	}
-	claimsJSON, err := decodeJWTSection(token, 1)
-	if err != nil {
-		return &TokenIntrospection{Active: false}, nil
-	}
-	var payload struct {
-		Issuer   string `json:"iss"`
-		OrgID    int64  `json:"org_id"`
-		TenantID int64  `json:"tenant_id"`
+	lookups := []func(context.Context, int64, string) (*TokenIntrospection, error){s.introspectAccessToken, s.introspectRefreshToken}
+	if strings.TrimSpace(tokenTypeHint) == "refresh_token" {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
 	}
-	if err := json.Unmarshal(claimsJSON, &payload); err != nil {
-		return &TokenIntrospection{Active: false}, nil
+	for _, lookup := range lookups {
+		result, err := lookup(ctx, orgID, token)
+		if err != nil {
+			return nil, err
+		}
+		if result.Active {
+			return result, nil
+		}
 	}
-	orgID := payload.OrgID
-	if orgID == 0 {
-		orgID = payload.TenantID
+	return &TokenIntrospection{Active: false}, nil
+}
+
+func (s *oauthService) introspectAccessToken(ctx context.Context, orgID int64, token string) (*TokenIntrospection, error) {
+	if jwt.IsOpaqueToken(token) {
+		return s.introspectOpaqueToken(ctx, orgID, token)
 	}
-	std, custom, err := s.jwt.ValidateAccessToken(ctx, orgID, token, payload.Issuer)
+	std, custom, err := s.jwt.ValidateAccessToken(ctx, orgID, token, "")
 	if err != nil {
 		return &TokenIntrospection{Active: false}, nil
 	}
+	stored, err := s.tokenRepo.GetByAccessToken(ctx, token)
+	switch {
+	case err == nil:
+		if stored.Revoked || stored.OrgID != orgID {
+			return &TokenIntrospection{Active: false}, nil
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("lookup access token: %w", err)
+	}
+
 	ti := &TokenIntrospection{
-		Active:   true,
-		Subject:  std.Subject,
-		Scope:    custom.Scope,
-		OrgID:    custom.OrgID,
-		ClientID: firstAudience(std.Audience),
+		Active:    true,
+		Subject:   std.Subject,
+		Scope:     custom.Scope,
+		OrgID:     custom.OrgID,
+		ClientID:  custom.ClientID,
+		Audience:  []string(std.Audience),
+		TokenType: "Bearer",
+		Issuer:    std.Issuer,
+		JTI:       std.ID,
+		DPoPKey:   custom.DPoPKey(),
+	}
+	if ti.DPoPKey != "" {
+		ti.TokenType = "DPoP"
+	}
+	// Client credentials tokens have no user.
+	if std.Subject != "0" {
+		ti.Username = custom.Email
 	}
 	if std.Expiry != nil {
 		ti.ExpiresAt = std.Expiry.Time().Unix()
@@ -452,9 +558,117 @@ func (s *oauthService) IntrospectToken(ctx context.Context, token string) (*Toke
 	if std.IssuedAt != nil {
 		ti.IssuedAt = std.IssuedAt.Time().Unix()
 	}
+	if std.NotBefore != nil {
+		ti.NotBefore = std.NotBefore.Time().Unix()
+	}
 	return ti, nil
 }
 
+// introspectOpaqueToken reports an opaque access token from its
+// oauth_tokens row. Refresh tokens are opaque too; they are not found here.
+func (s *oauthService) introspectOpaqueToken(ctx context.Context, orgID int64, token string) (*TokenIntrospection, error) {
+	stored, ok, err := s.lookupOpaqueToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || stored.OrgID != orgID {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	ti := &TokenIntrospection{
+		Active:    true,
+		Subject:   strconv.FormatInt(stored.UserID, 10),
+		Scope:     strings.Join(stored.Scopes, " "),
+		ExpiresAt: stored.AccessExpiresAt.Unix(),
+		ClientID:  stored.ClientID,
+		OrgID:     stored.ActiveOrgID,
+		TokenType: "Bearer",
+		JTI:       strconv.FormatInt(stored.ID, 10),
+		DPoPKey:   stored.DPoPJKT,
+	}
+	if ti.OrgID == 0 {
+		ti.OrgID = stored.OrgID
+	}
+	if ti.DPoPKey != "" {
+		ti.TokenType = "DPoP"
+	}
+	if stored.Resource != "" {
+		ti.Audience = []string{stored.Resource}
+	}
+	if issuer, ok := issuerFromContext(ctx); ok {
+		ti.Issuer = issuer
+	}
+	if stored.UserID != 0 {
+		if user, err := s.userRepo.GetByID(ctx, stored.OrgID, stored.UserID); err == nil {
+			ti.Username = user.Email
+		}
+	}
+	return ti, nil
+}
+
+// lookupOpaqueToken returns the oauth_tokens row of an opaque access token,
+// and whether the token is still active.
+func (s *oauthService) lookupOpaqueToken(ctx context.Context, token string) (domain.OAuthToken, bool, error) {
+	stored, err := s.tokenRepo.GetByAccessToken(ctx, jwt.HashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.OAuthToken{}, false, nil
+		}
+		return domain.OAuthToken{}, false, fmt.Errorf("lookup opaque token: %w", err)
+	}
+	active := !stored.Revoked && !stored.AccessExpiresAt.IsZero() && time.Now().Before(stored.AccessExpiresAt)
+	return stored, active, nil
+}
+
+func (s *oauthService) introspectRefreshToken(ctx context.Context, orgID int64, token string) (*TokenIntrospection, error) {
+	stored, err := s.tokenRepo.GetByRefreshToken(ctx, orgID, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &TokenIntrospection{Active: false}, nil
+		}
+		return nil, fmt.Errorf("lookup refresh token: %w", err)
+	}
+	if stored.Revoked || stored.RefreshToken == "" || time.Now().After(stored.ExpiresAt) {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	ti := &TokenIntrospection{
+		Active:    true,
+		Subject:   strconv.FormatInt(stored.UserID, 10),
+		Scope:     strings.Join(stored.Scopes, " "),
+		ExpiresAt: stored.ExpiresAt.Unix(),
+		IssuedAt:  stored.CreatedAt.Unix(),
+		ClientID:  stored.ClientID,
+		OrgID:     stored.ActiveOrgID,
+		TokenType: "refresh_token",
+		JTI:       strconv.FormatInt(stored.ID, 10),
+		DPoPKey:   stored.DPoPJKT,
+	}
+	if ti.OrgID == 0 {
+		ti.OrgID = stored.OrgID
+	}
+	if stored.Resource != "" {
+		ti.Audience = []string{stored.Resource}
+	}
+	if issuer, ok := issuerFromContext(ctx); ok {
+		ti.Issuer = issuer
+	}
+	if user, err := s.userRepo.GetByID(ctx, stored.OrgID, stored.UserID); err == nil {
+		ti.Username = user.Email
+	}
+	return ti, nil
+}
+
+// SignIntrospection wraps an introspection result in a JWT signed by org
+// for clientID (RFC 9701).
+func (s *oauthService) SignIntrospection(ctx context.Context, org domain.Org, clientID, issuer string, result *TokenIntrospection) (string, error) {
+	signed, err := s.jwt.GenerateIntrospectionResponse(ctx, org, issuer, clientID, result.Response())
+	if err != nil {
+		return "", fmt.Errorf("sign introspection: %w", err)
+	}
+	return signed, nil
+}
+
 func (s *oauthService) RevokeToken(ctx context.Context, token string) error {
 	if strings.TrimSpace(token) == "" {
 		return domainoauth.ErrInvalidRequest
@@ -466,7 +680,12 @@ func (s *oauthService) RevokeToken(ctx context.Context, token string) error {
 		return fmt.Errorf("lookup refresh token: %w", err)
 	}
 
-	if stored, err := s.tokenRepo.GetByAccessToken(ctx, token); err == nil {
+	// Opaque access tokens are stored hashed.
+	accessToken := token
+	if jwt.IsOpaqueToken(token) {
+		accessToken = jwt.HashOpaqueToken(token)
+	}
+	if stored, err := s.tokenRepo.GetByAccessToken(ctx, accessToken); err == nil {
 		return s.tokenRepo.RevokeToken(ctx, stored.ID)
 	} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return fmt.Errorf("lookup access token: %w", err)
@@ -479,6 +698,9 @@ func (s *oauthService) UserInfo(ctx context.Context, token string) (*domainoauth
 	if strings.TrimSpace(token) == "" {
 		return nil, domainoauth.ErrInvalidRequest
 	}
+	if jwt.IsOpaqueToken(token) {
+		return s.opaqueUserInfo(ctx, token)
+	}
 	claimsJSON, err := decodeJWTSection(token, 1)
 	if err != nil {
 		return nil, domainoauth.ErrTokenInvalid
@@ -511,6 +733,36 @@ func (s *oauthService) UserInfo(ctx context.Context, token string) (*domainoauth
 		OrgID:    custom.OrgID,
 		TenantID: custom.OrgID,
 		Provider: provider,
+		DPoPKey:  custom.DPoPKey(),
+	}, nil
+}
+
+// opaqueUserInfo answers userinfo for an opaque access token. Client
+// credentials tokens have no user to describe.
+func (s *oauthService) opaqueUserInfo(ctx context.Context, token string) (*domainoauth.OAuthUserInfo, error) {
+	stored, ok, err := s.lookupOpaqueToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || stored.UserID == 0 {
+		return nil, domainoauth.ErrTokenInvalid
+	}
+	user, err := s.userRepo.GetByID(ctx, stored.OrgID, stored.UserID)
+	if err != nil {
+		return nil, domainoauth.ErrTokenInvalid
+	}
+	orgID := stored.ActiveOrgID
+	if orgID == 0 {
+		orgID = stored.OrgID
+	}
+	return &domainoauth.OAuthUserInfo{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Email:    user.Email,
+		Name:     user.Name,
+		Picture:  user.AvatarURL,
+		OrgID:    orgID,
+		TenantID: orgID,
+		DPoPKey:  stored.DPoPJKT,
 	}, nil
 }
 
@@ -577,10 +829,3 @@ func decodeJWTSection(token string, index int) ([]byte, error) {
 	}
 	return base64.RawURLEncoding.DecodeString(parts[index])
 }
-
-func firstAudience(aud []string) string {
-	if len(aud) > 0 {
-		return aud[0]
-	}
-	return ""
-}
@@ -74,6 +74,45 @@ func TestOAuthService_HandleCallback(t *testing.T) {
 	require.NotEmpty(t, session.RefreshToken)
 }
 
+func TestOAuthService_HandleCallbackAppliesSignInPolicy(t *testing.T) {
+	h := newOAuthTestHarness()
+	policy := &stubSignInPolicy{err: errSecondFactor}
+	h.service.(*oauthService).policy = policy
+	ctx := WithIssuer(context.Background(), "https://tenant.smallbiznis.dev")
+	h.providerClient.token = &domainoauth.OAuthTokenResponse{AccessToken: "external-access", TokenType: "Bearer"}
+	h.providerClient.userinfo = &domainoauth.OAuthUserInfo{Subject: "sub-123", Email: "oauth@example.com", Name: "OAuth User"}
+	callback := func(state string) (*OAuthSession, error) {
+		require.NoError(t, h.stateStore.SaveState(ctx, buildStateKey(state), domainoauth.OAuthState{State: state, Provider: "google", OrgID: 1}, time.Minute))
+		return h.service.HandleCallback(ctx, 1, OAuthCallbackInput{Provider: "google", Code: "auth-code", State: state})
+	}
+
+	_, err := callback("state-mfa")
+	require.ErrorIs(t, err, errSecondFactor)
+	require.Empty(t, h.tokenRepo.tokens, "no tokens before the policy admits the user")
+	require.Equal(t, []string{"google"}, policy.providers)
+
+	policy.err = nil
+	policy.scope = "openid"
+	session, err := callback("state-ok")
+	require.NoError(t, err)
+	require.Equal(t, []string{"google"}, session.AMR)
+	require.Len(t, h.tokenRepo.tokens, 1)
+	require.Equal(t, []string{"openid"}, h.tokenRepo.tokens[0].Scopes, "the admitted scope is the one granted")
+}
+
+var errSecondFactor = fmt.Errorf("second factor required")
+
+type stubSignInPolicy struct {
+	scope     string
+	err       error
+	providers []string
+}
+
+func (p *stubSignInPolicy) AdmitFederatedSignIn(_ context.Context, _ int64, _ domain.User, _, _ string, providers []string) (string, error) {
+	p.providers = providers
+	return p.scope, p.err
+}
+
 func TestOAuthService_IntrospectToken(t *testing.T) {
 	h := newOAuthTestHarness()
 	ctx := context.Background()
@@ -100,11 +139,39 @@ func TestOAuthService_IntrospectToken(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	introspect, err := h.service.IntrospectToken(ctx, session.AccessToken)
+	introspect, err := h.service.IntrospectToken(ctx, 1, session.AccessToken, "")
 	require.NoError(t, err)
 	require.True(t, introspect.Active)
 	require.Equal(t, fmt.Sprintf("%d", session.UserID), introspect.Subject)
 	require.Equal(t, int64(1), introspect.OrgID)
+	require.Equal(t, "introspect@example.com", introspect.Username)
+	require.Equal(t, "Bearer", introspect.TokenType)
+	require.Equal(t, "https://tenant.smallbiznis.dev", introspect.Issuer)
+	require.NotEmpty(t, introspect.JTI)
+
+	introspect, err = h.service.IntrospectToken(ctx, 2, session.AccessToken, "")
+	require.NoError(t, err)
+	require.False(t, introspect.Active, "tokens of other orgs are inactive")
+
+	refresh, err := h.service.IntrospectToken(WithIssuer(ctx, "https://tenant.smallbiznis.dev"), 1, session.RefreshToken, "refresh_token")
+	require.NoError(t, err)
+	require.True(t, refresh.Active)
+	require.Equal(t, "refresh_token", refresh.TokenType)
+	require.Equal(t, "introspect@example.com", refresh.Username)
+	require.Equal(t, "https://tenant.smallbiznis.dev", refresh.Issuer)
+	require.Equal(t, map[string]any{"active": false}, (&TokenIntrospection{}).Response())
+
+	require.NoError(t, h.service.RevokeToken(ctx, session.RefreshToken))
+	introspect, err = h.service.IntrospectToken(ctx, 1, session.AccessToken, "access_token")
+	require.NoError(t, err)
+	require.False(t, introspect.Active, "revoked tokens are inactive")
+	refresh, err = h.service.IntrospectToken(ctx, 1, session.RefreshToken, "refresh_token")
+	require.NoError(t, err)
+	require.False(t, refresh.Active)
+
+	signed, err := h.service.SignIntrospection(ctx, domain.Org{ID: 1}, "client", "https://tenant.smallbiznis.dev", introspect)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(signed, "."), 3)
 }
 
 // ---- Test harness and fakes ----
@@ -114,6 +181,7 @@ type oauthTestHarness struct {
 	stateStore     *memoryStateStore
 	providerClient *fakeProviderClient
 	userRepo       *fakeUserRepo
+	tokenRepo      *fakeTokenRepo
 }
 
 func newOAuthTestHarness() *oauthTestHarness {
@@ -142,12 +210,13 @@ func newOAuthTestHarness() *oauthTestHarness {
 	keyManager := jwt.NewKeyManager(keyRepo)
 	generator := jwt.NewGenerator(keyManager, time.Minute)
 	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
-	svc := NewOAuthService(providerRepo, stateStore, providerClient, orgRepo, userRepo, tokenRepo, generator, cfg, zap.NewNop())
+	svc := NewOAuthService(providerRepo, stateStore, providerClient, orgRepo, userRepo, tokenRepo, generator, nil, cfg, zap.NewNop())
 	return &oauthTestHarness{
 		service:        svc,
 		stateStore:     stateStore,
 		providerClient: providerClient,
 		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
 	}
 }
 
@@ -257,6 +326,45 @@ func (f *fakeOrgRepo) GetOTPConfig(context.Context, int64) (domain.OTPConfig, er
 func (f *fakeOrgRepo) ListOAuthIDPConfigs(context.Context, int64) ([]domain.OAuthIDPConfig, error) {
 	return nil, nil
 }
+func (f *fakeOrgRepo) GetMFAConfig(context.Context, int64) (domain.MFAConfig, error) {
+	return domain.MFAConfig{Policy: domain.MFAPolicyOff}, nil
+}
+func (f *fakeOrgRepo) ListRateLimitOverrides(context.Context, int64) ([]domain.RateLimitOverride, error) {
+	return nil, nil
+}
+func (f *fakeOrgRepo) Create(ctx context.Context, org domain.Org) (domain.Org, error) {
+	return org, nil
+}
+func (f *fakeOrgRepo) GetByExternalID(context.Context, string) (domain.Org, error) {
+	return f.org, nil
+}
+func (f *fakeOrgRepo) Count(context.Context) (int64, error) {
+	return 1, nil
+}
+
+func (f *fakeOrgRepo) List(context.Context, string, int, int) ([]domain.Org, int64, error) {
+	return nil, 0, nil
+}
+
+func (f *fakeOrgRepo) Update(context.Context, domain.Org) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (f *fakeOrgRepo) SetStatus(context.Context, int64, string) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (f *fakeOrgRepo) SoftDelete(context.Context, int64) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (f *fakeOrgRepo) Restore(context.Context, int64, string) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (f *fakeOrgRepo) PurgeDeleted(context.Context, time.Time) ([]int64, error) {
+	return nil, nil
+}
 
 type fakeUserRepo struct {
 	mu    sync.Mutex
@@ -277,6 +385,15 @@ func (f *fakeUserRepo) GetByEmail(ctx context.Context, orgID int64, email string
 	return domain.User{}, fmt.Errorf("get user: %w", pgx.ErrNoRows)
 }
 
+func (f *fakeUserRepo) GetVerifiedByEmail(ctx context.Context, email string) (domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, ok := f.users[email]; ok && user.EmailVerified {
+		return user, nil
+	}
+	return domain.User{}, fmt.Errorf("get verified user: %w", pgx.ErrNoRows)
+}
+
 func (f *fakeUserRepo) GetByID(ctx context.Context, orgID, userID int64) (domain.User, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -297,6 +414,19 @@ func (f *fakeUserRepo) Create(ctx context.Context, user domain.User) (domain.Use
 	return user, nil
 }
 
+func (f *fakeUserRepo) MarkEmailVerified(ctx context.Context, orgID, userID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for email, u := range f.users {
+		if u.ID == userID {
+			u.EmailVerified = true
+			f.users[email] = u
+			return nil
+		}
+	}
+	return fmt.Errorf("get user: %w", pgx.ErrNoRows)
+}
+
 type fakeTokenRepo struct {
 	mu     sync.Mutex
 	nextID int64
@@ -349,12 +479,14 @@ func (f *fakeTokenRepo) GetByAccessToken(ctx context.Context, token string) (dom
 	return domain.OAuthToken{}, fmt.Errorf("get access token: %w", pgx.ErrNoRows)
 }
 
-func (f *fakeTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64) error {
+func (f *fakeTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64, activeOrgID int64, accessToken string, accessExpiresAt int64) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	for idx, t := range f.tokens {
 		if t.ID == tokenID {
 			f.tokens[idx].RefreshToken = refreshToken
+			f.tokens[idx].ActiveOrgID = activeOrgID
+			f.tokens[idx].AccessToken = accessToken
 			return nil
 		}
 	}
@@ -362,9 +494,28 @@ func (f *fakeTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, r
 }
 
 func (f *fakeTokenRepo) RevokeToken(ctx context.Context, tokenID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for idx, t := range f.tokens {
+		if t.ID == tokenID {
+			f.tokens[idx].Revoked = true
+		}
+	}
 	return nil
 }
 
+func (f *fakeTokenRepo) RevokeUserTokens(ctx context.Context, orgID, userID int64) error {
+	return nil
+}
+
+func (f *fakeTokenRepo) RevokeUserClientTokens(ctx context.Context, orgID, userID int64, clientID string) error {
+	return nil
+}
+
+func (f *fakeTokenRepo) ListUserClientIDs(ctx context.Context, orgID, userID int64) ([]string, error) {
+	return nil, nil
+}
+
 type memoryKeyRepo struct {
 	mu  sync.Mutex
 	key domain.OAuthKey
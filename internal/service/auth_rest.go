@@ -60,6 +60,10 @@ func (s *AuthService) RegisterWithPassword(ctx context.Context, orgID int64, ema
 		span.RecordError(err)
 		return AuthTokensWithUser{}, err
 	}
+	// Invited members can still create an account through AcceptInvitation.
+	if !orgCtx.PasswordConfig.AllowSignup {
+		return AuthTokensWithUser{}, newOAuthError("access_denied", "Sign-up is disabled for this org.", http.StatusForbidden)
+	}
 
 	normalized := normalizeIdentifier(email)
 	if normalized == "" {
@@ -113,6 +117,15 @@ func (s *AuthService) RegisterWithPassword(ctx context.Context, orgID int64, ema
 		effectiveIssuer = orgIssuer(orgCtx)
 	}
 
+	s.sendRegistrationVerification(ctx, orgCtx, created, effectiveIssuer)
+
+	// New accounts have no second factor, so a "required" policy sends them
+	// to enrolment instead of handing out tokens.
+	if err := s.beginMFA(ctx, orgCtx, created, defaultRESTScope, effectiveIssuer, providers); err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+
 	tokenResp, err := s.issueTokens(ctx, orgCtx, created, defaultRESTScope, effectiveIssuer, providers)
 	if err != nil {
 		span.RecordError(err)
@@ -173,8 +186,15 @@ func (s *AuthService) RequestOTP(ctx context.Context, orgID int64, phone, channe
 		return newOAuthError("invalid_request", "Account not eligible for OTP login.", http.StatusBadRequest)
 	}
 
-	_ = generateOTP(user.PasswordHash, otpCodeLength(orgCtx.OTPConfig), otpTTL(orgCtx.OTPConfig))
-	s.audit("rest.otp_request.accepted", "org_id", orgID, "user_id", user.ID, "channel", channel)
+	// The cached org context omits the delivery API key, so the sender's
+	// settings come straight from the repository.
+	delivery, err := s.orgs.GetOTPConfig(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("load otp delivery config: %w", err)
+	}
+	_ = generateOTP(user.PasswordHash, otpCodeLength(delivery), otpTTL(delivery))
+	s.audit("rest.otp_request.accepted", "org_id", orgID, "user_id", user.ID, "channel", channel, "provider", delivery.Provider)
 
 	return nil
 }
@@ -223,12 +243,13 @@ func (s *AuthService) GetUserInfo(ctx context.Context, orgID int64, userID int64
 	}
 
 	return UserViewModel{
-		ID:        user.ID,
-		OrgID:     user.OrgID,
-		TenantID:  user.OrgID,
-		Email:     user.Email,
-		Name:      user.Name,
-		AvatarURL: user.AvatarURL,
+		ID:            user.ID,
+		OrgID:         user.OrgID,
+		TenantID:      user.OrgID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		AvatarURL:     user.AvatarURL,
 	}, nil
 }
 
@@ -255,16 +276,18 @@ func newAuthTokensWithUser(user domain.User, tokenResp *TokenResponse) AuthToken
 	return AuthTokensWithUser{
 		AccessToken:  tokenResp.AccessToken,
 		RefreshToken: tokenResp.RefreshToken,
-		IDToken:      "",
+		IDToken:      tokenResp.IDToken,
 		TokenType:    tokenResp.TokenType,
 		ExpiresIn:    int64(tokenResp.ExpiresIn),
+		AMR:          tokenResp.AMR,
 		User: UserViewModel{
-			ID:        user.ID,
-			OrgID:     user.OrgID,
-			TenantID:  user.OrgID,
-			Email:     user.Email,
-			Name:      user.Name,
-			AvatarURL: user.AvatarURL,
+			ID:            user.ID,
+			OrgID:         user.OrgID,
+			TenantID:      user.OrgID,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			Name:          user.Name,
+			AvatarURL:     user.AvatarURL,
 		},
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -26,17 +27,30 @@ import (
 	"github.com/smallbiznis/railzway-auth/internal/config"
 	"github.com/smallbiznis/railzway-auth/internal/domain"
 	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
 	"github.com/smallbiznis/railzway-auth/internal/org"
 	pw "github.com/smallbiznis/railzway-auth/internal/password"
 	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/secretbox"
+	"github.com/smallbiznis/railzway-auth/internal/signedtoken"
 )
 
 // TokenResponse matches Auth0 OAuth token responses.
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token,omitempty"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    int    `json:"expires_in"`
+	// IDToken is set when an openid scope is granted to a known client.
+	IDToken   string `json:"id_token,omitempty"`
+	TokenType string `json:"token_type"`
+	ExpiresIn int    `json:"expires_in"`
+	// IssuedTokenType and Scope are set for token exchange responses.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+	Scope           string `json:"scope,omitempty"`
+	// RecoveryCodes is only set when MFA enrollment completes during login.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// AMR lists the authentication methods behind the tokens, for the
+	// browser session opened alongside them.
+	AMR []string `json:"-"`
 }
 
 // OAuthError standardizes OAuth compliant errors.
@@ -64,30 +78,98 @@ type AuthService struct {
 	clients   repository.OAuthClientRepository
 	apps      repository.OAuthAppRepository
 	orgs      repository.OrgRepository
+	mfa       repository.MFARepository
 	snowflake *snowflake.Node
 	jwt       *jwt.Generator
 	keys      *jwt.KeyManager
 	cfg       config.Config
 	logger    *zap.Logger
 	tracer    trace.Tracer
-}
 
-// NewAuthService wires dependencies.
-func NewAuthService(users repository.UserRepository, tokens repository.TokenRepository, codes repository.CodeRepository, clients repository.OAuthClientRepository, apps repository.OAuthAppRepository, orgs repository.OrgRepository, snowflake *snowflake.Node, generator *jwt.Generator, keys *jwt.KeyManager, cfg config.Config, logger *zap.Logger) *AuthService {
+	mfaChallenges    repository.MFAChallengeStore
+	sealer           *secretbox.Sealer
+	passkeys         repository.WebAuthnCredentialRepository
+	webauthnSessions repository.WebAuthnSessionStore
+	links            repository.MagicLinkStore
+	mailer           mailer.Mailer
+	linkSigner       *signedtoken.Signer
+	cooldowns        repository.CooldownStore
+	sessions         repository.SessionRepository
+	sessionStore     repository.SessionStore
+	memberships      repository.MembershipRepository
+	resources        repository.APIResourceRepository
+	// backchannelLogouts queues logout tokens for clients' back-channel
+	// logout URIs.
+	backchannelLogouts repository.BackchannelLogoutRepository
+}
+
+// AuthDeps lists AuthService's dependencies. Optional stores may be left
+// nil; the flows that need them then report that they are not configured.
+type AuthDeps struct {
+	Users            repository.UserRepository
+	Tokens           repository.TokenRepository
+	Codes            repository.CodeRepository
+	Clients          repository.OAuthClientRepository
+	Apps             repository.OAuthAppRepository
+	Orgs             repository.OrgRepository
+	MFA              repository.MFARepository
+	MFAChallenges    repository.MFAChallengeStore
+	Passkeys         repository.WebAuthnCredentialRepository
+	WebAuthnSessions repository.WebAuthnSessionStore
+	Links            repository.MagicLinkStore
+	Mailer           mailer.Mailer
+	Cooldowns        repository.CooldownStore
+	Sessions         repository.SessionRepository
+	SessionStore     repository.SessionStore
+	Memberships      repository.MembershipRepository
+	Resources        repository.APIResourceRepository
+	// BackchannelLogouts may be nil, in which case clients get no
+	// back-channel logout tokens.
+	BackchannelLogouts repository.BackchannelLogoutRepository
+	Snowflake          *snowflake.Node
+	Generator          *jwt.Generator
+	Keys               *jwt.KeyManager
+	Config             config.Config
+	Logger             *zap.Logger
+}
+
+// NewAuthService wires dependencies. It fails when MFA_ENCRYPTION_KEY
+// cannot be turned into a sealer.
+func NewAuthService(deps AuthDeps) (*AuthService, error) {
+	sealer, err := secretbox.New(deps.Config.MFAEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("MFA_ENCRYPTION_KEY: %w", err)
+	}
 	return &AuthService{
-		users:     users,
-		tokens:    tokens,
-		codes:     codes,
-		clients:   clients,
-		apps:      apps,
-		orgs:      orgs,
-		snowflake: snowflake,
-		jwt:       generator,
-		keys:      keys,
-		cfg:       cfg,
-		logger:    logger,
+		users:     deps.Users,
+		tokens:    deps.Tokens,
+		codes:     deps.Codes,
+		clients:   deps.Clients,
+		apps:      deps.Apps,
+		orgs:      deps.Orgs,
+		mfa:       deps.MFA,
+		snowflake: deps.Snowflake,
+		jwt:       deps.Generator,
+		keys:      deps.Keys,
+		cfg:       deps.Config,
+		logger:    deps.Logger,
 		tracer:    otel.Tracer("github.com/smallbiznis/railzway-auth/internal/service"),
-	}
+
+		mfaChallenges:    deps.MFAChallenges,
+		sealer:           sealer,
+		passkeys:         deps.Passkeys,
+		webauthnSessions: deps.WebAuthnSessions,
+		links:            deps.Links,
+		mailer:           deps.Mailer,
+		linkSigner:       signedtoken.New(deps.Config.LinkSigningKey),
+		cooldowns:        deps.Cooldowns,
+		sessions:         deps.Sessions,
+		sessionStore:     deps.SessionStore,
+		memberships:      deps.Memberships,
+		resources:        deps.Resources,
+
+		backchannelLogouts: deps.BackchannelLogouts,
+	}, nil
 }
 
 // EnsureOrg gets or creates an organization by external ID.
@@ -122,13 +204,13 @@ func (s *AuthService) EnsureOrg(ctx context.Context, externalID, name, slug stri
 
 	// 3. Create
 	newOrg := domain.Org{
-		ID:          s.snowflake.Generate().Int64(),
-		Name:        name,
-		Slug:        slug,
-		ExternalID:  externalID,
-		Status:      "active",
-		CountryCode: "US", // Default
-		Timezone:    "UTC",
+		ID:         s.snowflake.Generate().Int64(),
+		Name:       name,
+		Slug:       slug,
+		ExternalID: externalID,
+		Type:       domain.OrgTypeCompany,
+		Status:     domain.OrgStatusActive,
+		// Country and timezone fall back to the repository defaults.
 	}
 	created, err := s.orgs.Create(ctx, newOrg)
 	if err != nil {
@@ -189,6 +271,15 @@ func (s *AuthService) PasswordGrant(ctx context.Context, orgCtx *org.Context, em
 		}
 	}
 
+	// Reject unverified users before prompting for a second factor.
+	if _, err := applyEmailVerificationPolicy(orgCtx, user, scope); err != nil {
+		return nil, err
+	}
+	if err := s.beginMFA(ctx, orgCtx, user, scope, issuer, providers); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
 	resp, err := s.issueTokens(ctx, orgCtx, user, scope, issuer, providers)
 	if err == nil {
 		s.audit("password.login.success", "org_id", orgCtx.Org.ID, "user_id", user.ID)
@@ -224,6 +315,15 @@ func (s *AuthService) OTPGrant(ctx context.Context, orgCtx *org.Context, email,
 	}
 
 	providers := []string{"otp"}
+	// OTP is a first factor like a password, so the same policies apply.
+	if _, err := applyEmailVerificationPolicy(orgCtx, user, scope); err != nil {
+		return nil, err
+	}
+	if err := s.beginMFA(ctx, orgCtx, user, scope, issuer, providers); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
 	resp, err := s.issueTokens(ctx, orgCtx, user, scope, issuer, providers)
 	if err == nil {
 		s.audit("otp.login.success", "org_id", orgCtx.Org.ID, "user_id", user.ID)
@@ -233,11 +333,21 @@ func (s *AuthService) OTPGrant(ctx context.Context, orgCtx *org.Context, email,
 	return resp, err
 }
 
-// RefreshGrant rotates the refresh token and issues a new access token.
-func (s *AuthService) RefreshGrant(ctx context.Context, orgCtx *org.Context, refreshToken, scope, issuer string) (*TokenResponse, error) {
+// RefreshGrant rotates the refresh token and issues a new access token for
+// the same org and resource. A non-empty resource must be the one the
+// refresh token was granted for.
+func (s *AuthService) RefreshGrant(ctx context.Context, orgCtx *org.Context, refreshToken, scope, issuer, resource string) (*TokenResponse, error) {
 	ctx, span := s.startSpan(ctx, "AuthService.RefreshGrant")
 	defer span.End()
 
+	return s.refreshGrant(ctx, orgCtx, refreshToken, scope, issuer, resource, 0)
+}
+
+// refreshGrant rotates the refresh token. A non-zero activeOrgID moves the
+// tokens to that org; otherwise they stay in the org they act in.
+func (s *AuthService) refreshGrant(ctx context.Context, orgCtx *org.Context, refreshToken, scope, issuer, resource string, activeOrgID int64) (*TokenResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
 	if refreshToken == "" {
 		return nil, newOAuthError("invalid_grant", "Refresh token missing.", 400)
 	}
@@ -249,6 +359,25 @@ func (s *AuthService) RefreshGrant(ctx context.Context, orgCtx *org.Context, ref
 		}
 		return nil, newOAuthError("invalid_grant", "Invalid refresh token.", 400)
 	}
+	if resource = strings.TrimSpace(resource); resource != "" && resource != token.Resource {
+		return nil, newOAuthError("invalid_target", "resource was not granted to this refresh token.", http.StatusBadRequest)
+	}
+	jkt, err := s.dpopBinding(ctx, orgCtx.Org.ID, token.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if token.DPoPJKT != "" && jkt != token.DPoPJKT {
+		return nil, newOAuthError("invalid_dpop_proof", "Refresh token is bound to a different DPoP key.", http.StatusBadRequest)
+	}
+	api, err := s.ResolveResource(ctx, orgCtx.Org.ID, []string{token.Resource})
+	if err != nil {
+		span.RecordError(err)
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_target" {
+			return nil, newOAuthError("invalid_grant", "The refresh token's resource is no longer registered.", http.StatusBadRequest)
+		}
+		return nil, err
+	}
 
 	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, token.UserID)
 	if err != nil {
@@ -256,37 +385,76 @@ func (s *AuthService) RefreshGrant(ctx context.Context, orgCtx *org.Context, ref
 		return nil, fmt.Errorf("refresh load user: %w", err)
 	}
 
-	refresh, err := s.rotateRefreshToken(ctx, token)
+	storedScope := strings.Join(token.Scopes, " ")
+	effectiveScope, err := applyEmailVerificationPolicy(orgCtx, user, normalizeScope(coalesce(scope, storedScope)))
 	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := checkResourceScope(api, effectiveScope); err != nil {
 		return nil, err
 	}
 
-	providers := make([]string, 0, len(orgCtx.AuthProviders))
-	for _, provider := range orgCtx.AuthProviders {
-		if provider.IsActive {
-			providers = append(providers, provider.ProviderType)
+	previousOrgID := coalesceOrgID(token.ActiveOrgID, orgCtx.Org.ID)
+	switching := activeOrgID != 0
+	if !switching {
+		activeOrgID = previousOrgID
+	}
+	if err := s.checkActiveOrg(ctx, orgCtx, user, activeOrgID); err != nil {
+		span.RecordError(err)
+		var oauthErr *OAuthError
+		if !switching && errors.As(err, &oauthErr) {
+			return nil, newOAuthError("invalid_grant", "The user is no longer a member of the token's org.", http.StatusBadRequest)
+		}
+		return nil, err
+	}
+
+	// Refreshed tokens report how the user signed in for the original grant,
+	// so a completed MFA challenge is not lost on refresh.
+	providers := token.AMR
+	if len(providers) == 0 {
+		// Rows written before amr was recorded.
+		providers = make([]string, 0, len(orgCtx.AuthProviders))
+		for _, provider := range orgCtx.AuthProviders {
+			if provider.IsActive {
+				providers = append(providers, provider.ProviderType)
+			}
 		}
 	}
-	storedScope := strings.Join(token.Scopes, " ")
-	effectiveScope := normalizeScope(coalesce(scope, storedScope))
-	access, err := s.jwt.GenerateAccessToken(ctx, orgCtx.Org, user, effectiveScope, issuer, providers)
+	format, err := s.clientTokenFormat(ctx, orgCtx.Org.ID, token.ClientID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	opts := accessTokenOptions(token.ClientID, api)
+	opts.DPoPKey = jkt
+	access, err := s.newAccessToken(ctx, format, orgCtx.Org, activeOrgID, user, effectiveScope, issuer, providers, opts)
 	if err != nil {
 		span.RecordError(err)
 		return nil, fmt.Errorf("refresh token generate: %w", err)
 	}
 
+	refresh, err := s.rotateRefreshToken(ctx, token, activeOrgID, access)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := &TokenResponse{
-		AccessToken:  access,
+		AccessToken:  access.value,
 		RefreshToken: refresh,
-		TokenType:    "Bearer",
-		ExpiresIn:    int(s.cfg.AccessTokenTTL.Seconds()),
+		TokenType:    tokenType(jkt),
+		ExpiresIn:    int(s.accessTokenTTL(api).Seconds()),
+	}
+	if activeOrgID != previousOrgID {
+		s.audit("org.switched", "org_id", orgCtx.Org.ID, "user_id", user.ID, "from_org_id", previousOrgID, "to_org_id", activeOrgID)
 	}
-	s.audit("refresh_token.success", "org_id", orgCtx.Org.ID, "user_id", user.ID)
+	s.audit("refresh_token.success", "org_id", orgCtx.Org.ID, "user_id", user.ID, "active_org_id", activeOrgID)
 	return resp, nil
 }
 
-// AuthorizationCodeGrant redeems an authorization code.
-func (s *AuthService) AuthorizationCodeGrant(ctx context.Context, orgCtx *org.Context, code, redirectURI, scope, issuer string) (*TokenResponse, error) {
+// AuthorizationCodeGrant redeems an authorization code. resource may name
+// the API when the authorize request did not; otherwise it must match.
+func (s *AuthService) AuthorizationCodeGrant(ctx context.Context, orgCtx *org.Context, code, redirectURI, scope, issuer, resource string) (*TokenResponse, error) {
 	if code == "" {
 		return nil, newOAuthError("invalid_grant", "Authorization code missing.", 400)
 	}
@@ -300,6 +468,14 @@ func (s *AuthService) AuthorizationCodeGrant(ctx context.Context, orgCtx *org.Co
 	if redirectURI != "" && stored.RedirectURI != redirectURI {
 		return nil, newOAuthError("invalid_grant", "Mismatched redirect_uri.", 400)
 	}
+	resource = strings.TrimSpace(resource)
+	if stored.Resource != "" && resource != "" && resource != stored.Resource {
+		return nil, newOAuthError("invalid_target", "resource does not match the authorization request.", http.StatusBadRequest)
+	}
+	api, err := s.ResolveResource(ctx, orgCtx.Org.ID, []string{coalesce(stored.Resource, resource)})
+	if err != nil {
+		return nil, err
+	}
 	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, stored.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("authorization code load user: %w", err)
@@ -307,6 +483,11 @@ func (s *AuthService) AuthorizationCodeGrant(ctx context.Context, orgCtx *org.Co
 	if err := s.codes.MarkCodeUsed(ctx, stored.Code); err != nil {
 		return nil, fmt.Errorf("authorization code mark used: %w", err)
 	}
+	// Membership may have ended since the code was issued.
+	activeOrgID := coalesceOrgID(stored.ActiveOrgID, orgCtx.Org.ID)
+	if err := s.checkActiveOrg(ctx, orgCtx, user, activeOrgID); err != nil {
+		return nil, newOAuthError("invalid_grant", "Invalid authorization code.", 400)
+	}
 
 	providers := make([]string, 0, len(orgCtx.AuthProviders))
 	for _, provider := range orgCtx.AuthProviders {
@@ -314,11 +495,15 @@ func (s *AuthService) AuthorizationCodeGrant(ctx context.Context, orgCtx *org.Co
 			providers = append(providers, provider.ProviderType)
 		}
 	}
-	return s.issueTokens(ctx, orgCtx, user, coalesce(scope, defaultRESTScope), issuer, providers)
+	scoped := *orgCtx
+	scoped.ClientID = stored.ClientID
+	return s.issueOrgTokens(ctx, &scoped, activeOrgID, user, coalesce(scope, defaultRESTScope), issuer, providers, api, stored.AuthTime)
 }
 
-// CreateAuthorizationCode persists an authorization code for later redemption.
-func (s *AuthService) CreateAuthorizationCode(ctx context.Context, orgCtx *org.Context, userID int64, clientID, redirectURI, codeChallenge, codeChallengeMethod string) (string, error) {
+// CreateAuthorizationCode persists an authorization code for later
+// redemption. A non-zero activeOrgID picks the org the tokens act in, and a
+// non-empty resource the API they are for.
+func (s *AuthService) CreateAuthorizationCode(ctx context.Context, orgCtx *org.Context, userID int64, clientID, redirectURI, codeChallenge, codeChallengeMethod string, activeOrgID int64, resource string, authTime time.Time) (string, error) {
 	ctx, span := s.startSpan(ctx, "AuthService.CreateAuthorizationCode")
 	defer span.End()
 
@@ -344,6 +529,11 @@ func (s *AuthService) CreateAuthorizationCode(ctx context.Context, orgCtx *org.C
 		span.RecordError(err)
 		return "", fmt.Errorf("authorize load user: %w", err)
 	}
+	activeOrgID = coalesceOrgID(activeOrgID, orgCtx.Org.ID)
+	if err := s.checkActiveOrg(ctx, orgCtx, user, activeOrgID); err != nil {
+		span.RecordError(err)
+		return "", err
+	}
 
 	codeValue := randomString(32)
 	record := domain.OAuthCode{
@@ -355,6 +545,9 @@ func (s *AuthService) CreateAuthorizationCode(ctx context.Context, orgCtx *org.C
 		RedirectURI:         redirect,
 		CodeChallenge:       strings.TrimSpace(codeChallenge),
 		CodeChallengeMethod: strings.TrimSpace(codeChallengeMethod),
+		ActiveOrgID:         activeOrgID,
+		Resource:            strings.TrimSpace(resource),
+		AuthTime:            authTime,
 		ExpiresAt:           time.Now().Add(authorizationCodeTTL),
 		CreatedAt:           time.Now(),
 	}
@@ -364,7 +557,7 @@ func (s *AuthService) CreateAuthorizationCode(ctx context.Context, orgCtx *org.C
 		return "", fmt.Errorf("persist authorization code: %w", err)
 	}
 
-	s.audit("authorization_code.issued", "org_id", orgCtx.Org.ID, "user_id", user.ID, "client_id", client)
+	s.audit("authorization_code.issued", "org_id", orgCtx.Org.ID, "user_id", user.ID, "client_id", client, "active_org_id", activeOrgID)
 	return codeValue, nil
 }
 
@@ -374,6 +567,7 @@ func (s *AuthService) DeviceCodeGrant(ctx context.Context) (*TokenResponse, erro
 }
 
 // ClientCredentialsGrant issues an access token for a client (no user context).
+// A non-empty resource audiences the token to that registered API.
 func (s *AuthService) ClientCredentialsGrant(
 	ctx context.Context,
 	orgCtx *org.Context,
@@ -381,6 +575,7 @@ func (s *AuthService) ClientCredentialsGrant(
 	clientSecret string,
 	scope string,
 	issuer string,
+	resource string,
 ) (*TokenResponse, error) {
 	ctx, span := s.startSpan(ctx, "AuthService.ClientCredentialsGrant")
 	defer span.End()
@@ -408,23 +603,40 @@ func (s *AuthService) ClientCredentialsGrant(
 		return nil, newOAuthError("invalid_client", "Invalid client_secret.", http.StatusUnauthorized)
 	}
 
+	api, err := s.ResolveResource(ctx, orgCtx.Org.ID, []string{resource})
+	if err != nil {
+		return nil, err
+	}
 	effectiveScope := normalizeScope(scope)
-	access, err := s.jwt.GenerateAccessToken(ctx, orgCtx.Org, serviceUser(orgCtx.Org.ID, cleanClient), effectiveScope, issuer, []string{"client_credentials"})
+	if err := checkResourceScope(api, effectiveScope); err != nil {
+		return nil, err
+	}
+	jkt, err := dpopBindingFor(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	opts := accessTokenOptions(cleanClient, api)
+	opts.SubjectClientID = cleanClient
+	opts.DPoPKey = jkt
+	access, err := s.newAccessToken(ctx, tokenFormatFor(client), orgCtx.Org, orgCtx.Org.ID, serviceUser(orgCtx.Org.ID, cleanClient), effectiveScope, issuer, []string{"client_credentials"}, opts)
 	if err != nil {
 		span.RecordError(err)
 		return nil, fmt.Errorf("generate access token: %w", err)
 	}
 
 	oauthToken := domain.OAuthToken{
-		ID:           s.snowflake.Generate().Int64(),
-		OrgID:        orgCtx.Org.ID,
-		ClientID:     cleanClient,
-		UserID:       0,
-		AccessToken:  access,
-		RefreshToken: "",
-		Scopes:       strings.Fields(effectiveScope),
-		ExpiresAt:    time.Now().Add(s.cfg.AccessTokenTTL),
-		CreatedAt:    time.Now(),
+		ID:              s.snowflake.Generate().Int64(),
+		OrgID:           orgCtx.Org.ID,
+		ClientID:        cleanClient,
+		UserID:          0,
+		AccessToken:     access.stored,
+		RefreshToken:    "",
+		Scopes:          strings.Fields(effectiveScope),
+		Resource:        resourceIdentifier(api),
+		DPoPJKT:         jkt,
+		ExpiresAt:       access.expiresAt,
+		AccessExpiresAt: access.expiresAt,
+		CreatedAt:       time.Now(),
 	}
 
 	if _, err := s.tokens.CreateToken(ctx, oauthToken); err != nil {
@@ -432,12 +644,12 @@ func (s *AuthService) ClientCredentialsGrant(
 		return nil, fmt.Errorf("persist access token: %w", err)
 	}
 
-	s.audit("client_credentials.issued", "org_id", orgCtx.Org.ID, "client_id", cleanClient)
+	s.audit("client_credentials.issued", "org_id", orgCtx.Org.ID, "client_id", cleanClient, "resource", resourceIdentifier(api))
 	return &TokenResponse{
-		AccessToken:  access,
+		AccessToken:  access.value,
 		RefreshToken: "",
-		TokenType:    "Bearer",
-		ExpiresIn:    int(s.cfg.AccessTokenTTL.Seconds()),
+		TokenType:    tokenType(jkt),
+		ExpiresIn:    int(s.accessTokenTTL(api).Seconds()),
 	}, nil
 }
 
@@ -474,21 +686,47 @@ func normalizeScope(scope string) string {
 	return trimmed
 }
 
-func (s *AuthService) rotateRefreshToken(ctx context.Context, token domain.OAuthToken) (string, error) {
+func (s *AuthService) rotateRefreshToken(ctx context.Context, token domain.OAuthToken, activeOrgID int64, access accessToken) (string, error) {
 	next := randomString(s.cfg.RefreshTokenBytes)
 	expires := time.Now().Add(s.cfg.RefreshTokenTTL)
-	if err := s.tokens.RotateRefreshToken(ctx, token.ID, next, expires.Unix()); err != nil {
+	if err := s.tokens.RotateRefreshToken(ctx, token.ID, next, expires.Unix(), activeOrgID, access.stored, access.expiresAt.Unix()); err != nil {
 		return "", fmt.Errorf("rotate refresh token: %w", err)
 	}
 	return next, nil
 }
 
+// issueTokens issues tokens for a user who has just signed in.
 func (s *AuthService) issueTokens(ctx context.Context, orgCtx *org.Context, user domain.User, scope, issuer string, providers []string) (*TokenResponse, error) {
+	return s.issueOrgTokens(ctx, orgCtx, orgCtx.Org.ID, user, scope, issuer, providers, nil, time.Now())
+}
+
+// issueOrgTokens issues tokens signed by orgCtx for a user acting in
+// activeOrgID, which the caller has checked with checkActiveOrg. A non-nil
+// resource audiences the access token to that API. A non-zero authTime is
+// reported as the ID token's auth_time.
+func (s *AuthService) issueOrgTokens(ctx context.Context, orgCtx *org.Context, activeOrgID int64, user domain.User, scope, issuer string, providers []string, resource *domain.APIResource, authTime time.Time) (*TokenResponse, error) {
 	ctx, span := s.startSpan(ctx, "AuthService.issueTokens")
 	defer span.End()
 
-	effectiveScope := normalizeScope(scope)
-	access, err := s.jwt.GenerateAccessToken(ctx, orgCtx.Org, user, effectiveScope, issuer, providers)
+	effectiveScope, err := applyEmailVerificationPolicy(orgCtx, user, normalizeScope(scope))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResourceScope(resource, effectiveScope); err != nil {
+		return nil, err
+	}
+	jkt, err := s.dpopBinding(ctx, orgCtx.Org.ID, orgCtx.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	format, err := s.clientTokenFormat(ctx, orgCtx.Org.ID, orgCtx.ClientID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	opts := accessTokenOptions(orgCtx.ClientID, resource)
+	opts.DPoPKey = jkt
+	access, err := s.newAccessToken(ctx, format, orgCtx.Org, activeOrgID, user, effectiveScope, issuer, providers, opts)
 	if err != nil {
 		span.RecordError(err)
 		return nil, fmt.Errorf("generate access token: %w", err)
@@ -496,15 +734,20 @@ func (s *AuthService) issueTokens(ctx context.Context, orgCtx *org.Context, user
 
 	refreshToken := randomString(s.cfg.RefreshTokenBytes)
 	oauthToken := domain.OAuthToken{
-		ID:           s.snowflake.Generate().Int64(),
-		OrgID:        orgCtx.Org.ID,
-		ClientID:     orgCtx.ClientID,
-		UserID:       user.ID,
-		AccessToken:  access,
-		RefreshToken: refreshToken,
-		Scopes:       strings.Fields(effectiveScope),
-		ExpiresAt:    time.Now().Add(s.cfg.RefreshTokenTTL),
-		CreatedAt:    time.Now(),
+		ID:              s.snowflake.Generate().Int64(),
+		OrgID:           orgCtx.Org.ID,
+		ClientID:        orgCtx.ClientID,
+		UserID:          user.ID,
+		AccessToken:     access.stored,
+		RefreshToken:    refreshToken,
+		Scopes:          strings.Fields(effectiveScope),
+		ActiveOrgID:     activeOrgID,
+		Resource:        resourceIdentifier(resource),
+		DPoPJKT:         jkt,
+		ExpiresAt:       time.Now().Add(s.cfg.RefreshTokenTTL),
+		AccessExpiresAt: access.expiresAt,
+		AMR:             providers,
+		CreatedAt:       time.Now(),
 	}
 
 	if _, err := s.tokens.CreateToken(ctx, oauthToken); err != nil {
@@ -512,11 +755,22 @@ func (s *AuthService) issueTokens(ctx context.Context, orgCtx *org.Context, user
 		return nil, fmt.Errorf("persist refresh token: %w", err)
 	}
 
+	var idToken string
+	if orgCtx.ClientID != "" && slices.Contains(strings.Fields(effectiveScope), "openid") {
+		idToken, err = s.jwt.GenerateIDToken(ctx, orgCtx.Org, user, orgCtx.ClientID, effectiveScope, issuer, providers, authTime)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("generate id token: %w", err)
+		}
+	}
+
 	return &TokenResponse{
-		AccessToken:  access,
+		AccessToken:  access.value,
 		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int(s.cfg.AccessTokenTTL.Seconds()),
+		IDToken:      idToken,
+		TokenType:    tokenType(jkt),
+		ExpiresIn:    int(s.accessTokenTTL(resource).Seconds()),
+		AMR:          providers,
 	}, nil
 }
 
@@ -528,7 +782,11 @@ func (s *AuthService) startSpan(ctx context.Context, name string) (context.Conte
 }
 
 func (s *AuthService) audit(event string, attrs ...any) {
-	logger := s.log()
+	auditLog(s.log(), event, attrs...)
+}
+
+// auditLog writes a structured audit event; attrs are key/value pairs.
+func auditLog(logger *zap.Logger, event string, attrs ...any) {
 	if logger == nil {
 		return
 	}
@@ -571,8 +829,23 @@ func randomID() int64 {
 	return int64(binary.BigEndian.Uint64(b[:]))
 }
 
-// ValidateToken proxies to JWT generator to validate tokens.
+// authProviderEnabled reports whether the org has an active
+// tenant_auth_providers row of the given type.
+func authProviderEnabled(orgCtx *org.Context, providerType string) bool {
+	for _, provider := range orgCtx.AuthProviders {
+		if provider.IsActive && provider.ProviderType == providerType {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateToken validates an access token of either format and returns its
+// claims. Opaque tokens are looked up in oauth_tokens.
 func (s *AuthService) ValidateToken(ctx context.Context, orgID int64, token, issuer string) (*gojwt.Claims, *jwt.AccessTokenClaims, error) {
+	if jwt.IsOpaqueToken(token) {
+		return s.validateOpaqueToken(ctx, orgID, token, issuer)
+	}
 	return s.jwt.ValidateAccessToken(ctx, orgID, token, issuer)
 }
 
@@ -605,6 +878,20 @@ func (s *AuthService) IsValidRedirectURI(ctx context.Context, orgID int64, clien
 	return false
 }
 
+// ClientRequiresConsent reports whether the client is configured to require
+// the user's consent. Unknown clients are treated as requiring it.
+func (s *AuthService) ClientRequiresConsent(ctx context.Context, orgID int64, clientID string) bool {
+	if s == nil || s.clients == nil {
+		return true
+	}
+	client, err := s.clients.GetClientByID(ctx, orgID, strings.TrimSpace(clientID))
+	if err != nil {
+		s.log().Warn("lookup oauth client failed", zap.Int64("org_id", orgID), zap.String("client_id", clientID), zap.Error(err))
+		return true
+	}
+	return client.RequireConsent
+}
+
 func otpEnabled(cfg domain.OTPConfig) bool {
 	return strings.TrimSpace(cfg.Channel) != ""
 }
@@ -118,22 +118,32 @@ func newRealAuthService(t *testing.T, db *pgxpool.Pool, q *sqlc.Queries) *servic
 	codeRepo := repository.NewPostgresCodeRepo(q)
 	keyRepo := repository.NewPostgresKeyRepo(q)
 	clientRepo := repository.NewPostgresOAuthClientRepo(db)
+	appRepo := repository.NewPostgresOAuthAppRepo(db)
+	orgRepo := repository.NewPostgresOrgRepo(db, q)
+	mfaRepo := repository.NewPostgresMFARepo(db)
+	passkeyRepo := repository.NewPostgresWebAuthnRepo(db)
 	node, _ := snowflake.NewNode(1)
 
 	keyManager := jwt.NewKeyManager(keyRepo)
 	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
 
-	return service.NewAuthService(
-		userRepo,
-		tokenRepo,
-		codeRepo,
-		clientRepo,
-		node,
-		generator,
-		keyManager,
-		cfg,
-		logger,
-	)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     userRepo,
+		Tokens:    tokenRepo,
+		Codes:     codeRepo,
+		Clients:   clientRepo,
+		Apps:      appRepo,
+		Orgs:      orgRepo,
+		MFA:       mfaRepo,
+		Passkeys:  passkeyRepo,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    logger,
+	})
+	assert.NoError(t, err)
+	return authService
 }
 
 func TestAuthService_LoginWithPassword_Integration(t *testing.T) {
@@ -2,10 +2,16 @@ package service_test
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -13,9 +19,11 @@ import (
 	"github.com/smallbiznis/railzway-auth/internal/config"
 	"github.com/smallbiznis/railzway-auth/internal/domain"
 	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/middleware"
 	"github.com/smallbiznis/railzway-auth/internal/org"
 	"github.com/smallbiznis/railzway-auth/internal/password"
 	"github.com/smallbiznis/railzway-auth/internal/service"
+	"github.com/smallbiznis/railzway-auth/internal/totp"
 )
 
 func TestPasswordGrantAndRefreshFlow(t *testing.T) {
@@ -35,7 +43,18 @@ func TestPasswordGrantAndRefreshFlow(t *testing.T) {
 	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
 	logger := zap.NewNop()
 	node, _ := snowflake.NewNode(1)
-	authService := service.NewAuthService(userRepo, tokenRepo, codeRepo, clientRepo, node, generator, keyManager, cfg, logger)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     userRepo,
+		Tokens:    tokenRepo,
+		Codes:     codeRepo,
+		Clients:   clientRepo,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    logger,
+	})
+	require.NoError(t, err)
 
 	orgCtx := &org.Context{
 		Org: domain.Org{ID: 1, Name: "Tenant A", Code: "client"},
@@ -49,8 +68,29 @@ func TestPasswordGrantAndRefreshFlow(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, tokenResp.AccessToken)
 	require.NotEmpty(t, tokenResp.RefreshToken)
+	require.Empty(t, tokenResp.IDToken, "ID tokens need a client to be audienced to")
 
-	refreshResp, err := authService.RefreshGrant(ctx, orgCtx, tokenRepo.lastToken.RefreshToken, "", "https://tenant")
+	withClient := *orgCtx
+	withClient.ClientID = "client"
+	oidcResp, err := authService.PasswordGrant(ctx, &withClient, user.Email, "password", "openid email", "https://tenant")
+	require.NoError(t, err)
+	hint, err := generator.VerifyTokenHint(ctx, orgCtx.Org.ID, oidcResp.IDToken, "https://tenant", "client")
+	require.NoError(t, err)
+	require.Equal(t, "10", hint.Subject)
+	require.WithinDuration(t, time.Now(), idTokenClaims(t, oidcResp.IDToken).AuthTime.Time(), 5*time.Second,
+		"a grant that signs the user in reports now as auth_time")
+	_, _, err = authService.ValidateToken(ctx, orgCtx.Org.ID, oidcResp.IDToken, "https://tenant")
+	require.Error(t, err, "ID tokens are not access tokens")
+
+	signedIn := time.Now().Add(-time.Hour).Truncate(time.Second)
+	code, err := authService.CreateAuthorizationCode(ctx, orgCtx, user.ID, "client", "https://app/callback", "", "", 0, "", signedIn)
+	require.NoError(t, err)
+	codeResp, err := authService.AuthorizationCodeGrant(ctx, orgCtx, code, "https://app/callback", "openid", "https://tenant", "")
+	require.NoError(t, err)
+	require.Equal(t, signedIn.Unix(), idTokenClaims(t, codeResp.IDToken).AuthTime.Time().Unix(),
+		"codes report when the authorizing session signed in")
+
+	refreshResp, err := authService.RefreshGrant(ctx, orgCtx, tokenRepo.lastToken.RefreshToken, "", "https://tenant", "")
 	require.NoError(t, err)
 	require.NotEmpty(t, refreshResp.AccessToken)
 	require.NotEqual(t, tokenResp.RefreshToken, refreshResp.RefreshToken)
@@ -61,6 +101,218 @@ func TestPasswordGrantAndRefreshFlow(t *testing.T) {
 	require.Equal(t, user.Email, custom.Email)
 }
 
+func TestPasswordGrantRequiresMFAWhenEnrolled(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User"}
+	hash, _ := password.Hash("password")
+	user.PasswordHash = hash
+
+	tokenRepo := &memoryTokenRepo{}
+	keyRepo := &memoryKeyRepo{}
+	mfaRepo := &memoryMFARepo{}
+	challenges := &memoryChallengeStore{items: map[string]domain.MFAChallenge{}}
+
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, MFAEncryptionKey: "test-key"}
+	keyManager := jwt.NewKeyManager(keyRepo)
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:         &memoryUserRepo{user: user},
+		Tokens:        tokenRepo,
+		Codes:         &memoryCodeRepo{},
+		Clients:       &memoryClientRepo{},
+		MFA:           mfaRepo,
+		MFAChallenges: challenges,
+		Snowflake:     node,
+		Generator:     generator,
+		Keys:          keyManager,
+		Config:        cfg,
+		Logger:        zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Org:           domain.Org{ID: 1, Name: "Tenant A", Code: "client"},
+		AuthProviders: []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+		MFAConfig:     domain.MFAConfig{OrgID: 1, Policy: domain.MFAPolicyOptional},
+	}
+
+	enrollment, err := authService.EnrollTOTP(ctx, orgCtx, user.ID)
+	require.NoError(t, err)
+	require.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/")
+	require.NotEqual(t, enrollment.Secret, mfaRepo.factor.Secret, "secret must be encrypted at rest")
+
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := authService.ConfirmTOTP(ctx, orgCtx, user.ID, code)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+
+	_, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", "https://tenant")
+	var mfaErr *service.MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	require.False(t, mfaErr.EnrollmentRequired)
+
+	_, err = authService.MFAOTPGrant(ctx, orgCtx, mfaErr.MFAToken, code)
+	require.Error(t, err, "replayed code must be rejected")
+
+	resp, err := authService.MFARecoveryCodeGrant(ctx, orgCtx, mfaErr.MFAToken, recoveryCodes[0])
+	require.NoError(t, err)
+	_, custom, err := authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.Contains(t, custom.Providers, "mfa")
+
+	refreshed, err := authService.RefreshGrant(ctx, orgCtx, resp.RefreshToken, "", "https://tenant", "")
+	require.NoError(t, err)
+	_, custom, err = authService.ValidateToken(ctx, orgCtx.Org.ID, refreshed.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.Contains(t, custom.Providers, "mfa", "refreshes keep the grant's methods")
+
+	_, err = authService.MFARecoveryCodeGrant(ctx, orgCtx, mfaErr.MFAToken, recoveryCodes[1])
+	require.Error(t, err, "challenge is single use")
+
+	_, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", "https://tenant")
+	require.ErrorAs(t, err, &mfaErr)
+	for i := 0; i < 5; i++ {
+		_, err = authService.MFAOTPGrant(ctx, orgCtx, mfaErr.MFAToken, "000000")
+		require.Error(t, err)
+	}
+	_, err = authService.MFARecoveryCodeGrant(ctx, orgCtx, mfaErr.MFAToken, recoveryCodes[1])
+	require.Error(t, err, "the challenge is burned after too many failures")
+}
+
+func TestOTPGrantAndRegistrationRequireMFA(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User", PasswordHash: "hash"}
+	userRepo := &memoryUserRepo{user: user}
+	challenges := &memoryChallengeStore{items: map[string]domain.MFAChallenge{}}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, MFAEncryptionKey: "test-key"}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:         &registeringUserRepo{userRepo},
+		Tokens:        &memoryTokenRepo{},
+		Codes:         &memoryCodeRepo{},
+		Clients:       &memoryClientRepo{},
+		MFA:           &memoryMFARepo{},
+		MFAChallenges: challenges,
+		Snowflake:     node,
+		Generator:     generator,
+		Keys:          keyManager,
+		Config:        cfg,
+		Logger:        zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Org:            domain.Org{ID: 1, Name: "Tenant A", Code: "client"},
+		AuthProviders:  []domain.AuthProvider{{ProviderType: "password", IsActive: true}, {ProviderType: "otp", IsActive: true}},
+		PasswordConfig: domain.PasswordConfig{OrgID: 1, MinLength: 8, AllowSignup: true},
+		OTPConfig:      domain.OTPConfig{OrgID: 1, Channel: "email", ExpirySeconds: 300},
+		MFAConfig:      domain.MFAConfig{OrgID: 1, Policy: domain.MFAPolicyRequired},
+	}
+
+	_, err = authService.OTPGrant(ctx, orgCtx, user.Email, otpCode(user.PasswordHash, 300), "openid", "https://tenant")
+	var mfaErr *service.MFARequiredError
+	require.ErrorAs(t, err, &mfaErr, "OTP login must not bypass a required second factor")
+	require.True(t, mfaErr.EnrollmentRequired)
+
+	orgCtx.MFAConfig.Policy = domain.MFAPolicyOff
+	resp, err := authService.OTPGrant(ctx, orgCtx, user.Email, otpCode(user.PasswordHash, 300), "openid", "https://tenant")
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.AccessToken)
+
+	orgCtx.MFAConfig.Policy = domain.MFAPolicyRequired
+	_, err = authService.RegisterWithPassword(middleware.WithOrgContext(ctx, orgCtx), 1, "new@tenant", "password123", "New", "client", "https://tenant")
+	require.ErrorAs(t, err, &mfaErr, "new accounts enrol a second factor before getting tokens")
+	require.True(t, mfaErr.EnrollmentRequired)
+	require.Equal(t, "new@tenant", userRepo.user.Email, "the account is still created")
+}
+
+func TestFederatedSignInFollowsMFAPolicy(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", EmailVerified: true}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, MFAEncryptionKey: "test-key"}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	node, _ := snowflake.NewNode(1)
+	challenges := &memoryChallengeStore{items: map[string]domain.MFAChallenge{}}
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:         &memoryUserRepo{user: user},
+		Tokens:        &memoryTokenRepo{},
+		Codes:         &memoryCodeRepo{},
+		Clients:       &memoryClientRepo{},
+		MFA:           &memoryMFARepo{},
+		MFAChallenges: challenges,
+		Snowflake:     node,
+		Generator:     jwt.NewGenerator(keyManager, cfg.AccessTokenTTL),
+		Keys:          keyManager,
+		Config:        cfg,
+		Logger:        zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{Org: domain.Org{ID: 1}, MFAConfig: domain.MFAConfig{OrgID: 1, Policy: domain.MFAPolicyRequired}}
+	ctx = middleware.WithOrgContext(ctx, orgCtx)
+
+	_, err = authService.AdmitFederatedSignIn(ctx, 1, user, "openid email", "https://tenant", []string{"google"})
+	var mfaErr *service.MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	require.Len(t, challenges.items, 1)
+
+	firstFactor := &domain.Session{AMR: []string{"google"}}
+	require.False(t, authService.SessionSatisfiesMFA(orgCtx, firstFactor), "a provider-only session cannot authorize under a required policy")
+	require.True(t, authService.SessionSatisfiesMFA(orgCtx, &domain.Session{AMR: []string{"google", "mfa"}}))
+
+	orgCtx.MFAConfig.Policy = domain.MFAPolicyOff
+	require.True(t, authService.SessionSatisfiesMFA(orgCtx, firstFactor))
+	scope, err := authService.AdmitFederatedSignIn(ctx, 1, user, "openid email", "https://tenant", []string{"google"})
+	require.NoError(t, err)
+	require.Equal(t, "openid email", scope)
+
+	orgCtx.PasswordConfig.EmailVerification = domain.EmailVerificationRequired
+	user.EmailVerified = false
+	_, err = authService.AdmitFederatedSignIn(ctx, 1, user, "openid email", "https://tenant", []string{"google"})
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "email_not_verified", oauthErr.Code)
+}
+
+// registeringUserRepo only finds the stored user by its own email, so new
+// addresses can register.
+type registeringUserRepo struct {
+	*memoryUserRepo
+}
+
+func (r *registeringUserRepo) GetByEmail(ctx context.Context, orgID int64, email string) (domain.User, error) {
+	if email != r.user.Email {
+		return domain.User{}, pgx.ErrNoRows
+	}
+	return r.memoryUserRepo.GetByEmail(ctx, orgID, email)
+}
+
+// otpCode mirrors the server's email OTP derivation.
+func otpCode(secret string, periodSeconds int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(time.Now().Unix()/periodSeconds))
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := int(sum[offset]&0x7f)<<24 | int(sum[offset+1])<<16 | int(sum[offset+2])<<8 | int(sum[offset+3])
+	return fmt.Sprintf("%06d", code%1000000)
+}
+
+type memoryMFARepo struct {
+	factor    domain.MFAFactor
+	hasFactor bool
+	codes     []domain.RecoveryCode
+}
+
+type memoryChallengeStore struct {
+	items    map[string]domain.MFAChallenge
+	attempts map[string]int
+}
+
 type memoryUserRepo struct {
 	user domain.User
 }
@@ -69,7 +321,9 @@ type memoryTokenRepo struct {
 	lastToken domain.OAuthToken
 }
 
-type memoryCodeRepo struct{}
+type memoryCodeRepo struct {
+	items map[string]domain.OAuthCode
+}
 
 type memoryKeyRepo struct {
 	key domain.OAuthKey
@@ -81,6 +335,10 @@ func (m *memoryUserRepo) GetByEmail(ctx context.Context, orgID int64, email stri
 	return m.user, nil
 }
 
+func (m *memoryUserRepo) GetVerifiedByEmail(ctx context.Context, email string) (domain.User, error) {
+	return m.user, nil
+}
+
 func (m *memoryUserRepo) GetByID(ctx context.Context, orgID, userID int64) (domain.User, error) {
 	return m.user, nil
 }
@@ -96,6 +354,11 @@ func (m *memoryUserRepo) Create(ctx context.Context, user domain.User) (domain.U
 	return m.user, nil
 }
 
+func (m *memoryUserRepo) MarkEmailVerified(ctx context.Context, orgID, userID int64) error {
+	m.user.EmailVerified = true
+	return nil
+}
+
 func (m *memoryTokenRepo) CreateToken(ctx context.Context, token domain.OAuthToken) (domain.OAuthToken, error) {
 	token.ID = 1
 	m.lastToken = token
@@ -111,24 +374,78 @@ func (m *memoryTokenRepo) GetByRefreshTokenValue(ctx context.Context, token stri
 }
 
 func (m *memoryTokenRepo) GetByAccessToken(ctx context.Context, token string) (domain.OAuthToken, error) {
+	if m.lastToken.AccessToken != token {
+		return domain.OAuthToken{}, pgx.ErrNoRows
+	}
 	return m.lastToken, nil
 }
 
-func (m *memoryTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64) error {
+func (m *memoryTokenRepo) RotateRefreshToken(ctx context.Context, tokenID int64, refreshToken string, expiresAt int64, activeOrgID int64, accessToken string, accessExpiresAt int64) error {
 	m.lastToken.RefreshToken = refreshToken
 	m.lastToken.ExpiresAt = time.Unix(expiresAt, 0)
+	m.lastToken.ActiveOrgID = activeOrgID
+	m.lastToken.AccessToken = accessToken
+	m.lastToken.AccessExpiresAt = time.Unix(accessExpiresAt, 0)
 	return nil
 }
 
 func (m *memoryTokenRepo) RevokeToken(ctx context.Context, tokenID int64) error { return nil }
 
-func (m *memoryCodeRepo) CreateCode(ctx context.Context, code domain.OAuthCode) error { return nil }
+func (m *memoryTokenRepo) RevokeUserTokens(ctx context.Context, orgID, userID int64) error {
+	if m.lastToken.OrgID == orgID && m.lastToken.UserID == userID {
+		m.lastToken.Revoked = true
+	}
+	return nil
+}
+
+func (m *memoryTokenRepo) RevokeUserClientTokens(ctx context.Context, orgID, userID int64, clientID string) error {
+	if m.lastToken.OrgID == orgID && m.lastToken.UserID == userID && m.lastToken.ClientID == clientID {
+		m.lastToken.Revoked = true
+	}
+	return nil
+}
+
+func (m *memoryTokenRepo) ListUserClientIDs(ctx context.Context, orgID, userID int64) ([]string, error) {
+	if m.lastToken.OrgID == orgID && m.lastToken.UserID == userID && !m.lastToken.Revoked {
+		return []string{m.lastToken.ClientID}, nil
+	}
+	return nil, nil
+}
+
+// idTokenClaims decodes the OIDC claims of an ID token issued in a test.
+func idTokenClaims(t *testing.T, token string) jwt.IDTokenClaims {
+	t.Helper()
+	parsed, err := gojwt.ParseSigned(token, []gojose.SignatureAlgorithm{gojose.HS256})
+	require.NoError(t, err)
+	var claims jwt.IDTokenClaims
+	require.NoError(t, parsed.UnsafeClaimsWithoutVerification(&claims))
+	require.NotNil(t, claims.AuthTime)
+	return claims
+}
+
+func (m *memoryCodeRepo) CreateCode(ctx context.Context, code domain.OAuthCode) error {
+	if m.items == nil {
+		m.items = map[string]domain.OAuthCode{}
+	}
+	m.items[code.Code] = code
+	return nil
+}
 
 func (m *memoryCodeRepo) GetCode(ctx context.Context, orgID int64, code string) (domain.OAuthCode, error) {
-	return domain.OAuthCode{}, pgx.ErrNoRows
+	stored, ok := m.items[code]
+	if !ok || stored.OrgID != orgID {
+		return domain.OAuthCode{}, pgx.ErrNoRows
+	}
+	return stored, nil
 }
 
-func (m *memoryCodeRepo) MarkCodeUsed(ctx context.Context, code string) error { return nil }
+func (m *memoryCodeRepo) MarkCodeUsed(ctx context.Context, code string) error {
+	if stored, ok := m.items[code]; ok {
+		stored.Revoked = true
+		m.items[code] = stored
+	}
+	return nil
+}
 
 func (m *memoryKeyRepo) GetActiveKey(ctx context.Context, orgID int64) (domain.OAuthKey, error) {
 	if m.key.ID == 0 {
@@ -154,3 +471,88 @@ func (m *memoryClientRepo) GetClientByID(ctx context.Context, orgID int64, clien
 func (m *memoryClientRepo) UpsertClient(ctx context.Context, client domain.OAuthClient) (domain.OAuthClient, error) {
 	return client, nil
 }
+
+func (m *memoryMFARepo) GetFactor(ctx context.Context, orgID, userID int64, factorType string) (domain.MFAFactor, error) {
+	if !m.hasFactor {
+		return domain.MFAFactor{}, pgx.ErrNoRows
+	}
+	return m.factor, nil
+}
+
+func (m *memoryMFARepo) UpsertFactor(ctx context.Context, factor domain.MFAFactor) (domain.MFAFactor, error) {
+	m.factor = factor
+	m.hasFactor = true
+	return factor, nil
+}
+
+func (m *memoryMFARepo) ConfirmFactor(ctx context.Context, factorID, step int64) error {
+	m.factor.Confirmed = true
+	m.factor.LastUsedStep = step
+	return nil
+}
+
+func (m *memoryMFARepo) AdvanceFactorStep(ctx context.Context, factorID, step int64) (bool, error) {
+	if step <= m.factor.LastUsedStep {
+		return false, nil
+	}
+	m.factor.LastUsedStep = step
+	return true, nil
+}
+
+func (m *memoryMFARepo) DeleteFactor(ctx context.Context, orgID, userID int64, factorType string) error {
+	m.hasFactor = false
+	return nil
+}
+
+func (m *memoryMFARepo) ReplaceRecoveryCodes(ctx context.Context, orgID, userID int64, codes []domain.RecoveryCode) error {
+	m.codes = codes
+	return nil
+}
+
+func (m *memoryMFARepo) ConsumeRecoveryCode(ctx context.Context, orgID, userID int64, codeHash string) (bool, error) {
+	for i := range m.codes {
+		if m.codes[i].CodeHash == codeHash && m.codes[i].UsedAt == nil {
+			now := time.Now()
+			m.codes[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryMFARepo) CountRecoveryCodes(ctx context.Context, orgID, userID int64) (int, error) {
+	count := 0
+	for _, code := range m.codes {
+		if code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memoryChallengeStore) SaveChallenge(ctx context.Context, key string, data domain.MFAChallenge, ttl time.Duration) error {
+	m.items[key] = data
+	return nil
+}
+
+func (m *memoryChallengeStore) GetChallenge(ctx context.Context, key string) (*domain.MFAChallenge, error) {
+	item, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+func (m *memoryChallengeStore) IncrementAttempts(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	if m.attempts == nil {
+		m.attempts = map[string]int{}
+	}
+	m.attempts[key]++
+	return m.attempts[key], nil
+}
+
+func (m *memoryChallengeStore) DeleteChallenge(ctx context.Context, key string) error {
+	delete(m.items, key)
+	delete(m.attempts, key)
+	return nil
+}
@@ -2,19 +2,58 @@ package service
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
 
+	"github.com/smallbiznis/railzway-auth/internal/branding"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/issuer"
 	"github.com/smallbiznis/railzway-auth/internal/org"
 )
 
 // DiscoveryService builds responses for discovery endpoints.
-type DiscoveryService struct{}
+type DiscoveryService struct {
+	// Issuers resolves the issuer the documents advertise. A nil resolver
+	// trusts no proxy headers.
+	Issuers *issuer.Resolver
+}
 
-// OrgDiscoveryResponse matches Auth0 discovery output.
+// OrgDiscoveryResponse is what the login UI needs to render an org's login
+// page. It only carries public data: sanitized branding, enabled login
+// methods, password hints and social login buttons.
 type OrgDiscoveryResponse struct {
-	Org       string            `json:"org"`
-	Tenant    string            `json:"tenant"`
-	Branding  map[string]string `json:"branding"`
-	Providers []map[string]any  `json:"providers"`
+	Org             string                 `json:"org"`
+	Tenant          string                 `json:"tenant"`
+	Slug            string                 `json:"slug"`
+	Branding        branding.Page          `json:"branding"`
+	Providers       []DiscoveryProvider    `json:"providers"`
+	Password        *PasswordPolicyHints   `json:"password,omitempty"`
+	SocialProviders []SocialProviderButton `json:"social_providers"`
+}
+
+// DiscoveryProvider is an enabled login method.
+type DiscoveryProvider struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// PasswordPolicyHints lets the UI check passwords and hide sign-up or reset
+// links before calling the API.
+type PasswordPolicyHints struct {
+	MinLength          int  `json:"min_length"`
+	RequireUppercase   bool `json:"require_uppercase"`
+	RequireNumber      bool `json:"require_number"`
+	RequireSymbol      bool `json:"require_symbol"`
+	AllowSignup        bool `json:"allow_signup"`
+	AllowPasswordReset bool `json:"allow_password_reset"`
+}
+
+// SocialProviderButton is a social login button. Start the flow with
+// /auth/oauth/start?provider=<provider>.
+type SocialProviderButton struct {
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
 }
 
 // OpenIDConfiguration matches OIDC discovery document.
@@ -25,54 +64,123 @@ type OpenIDConfiguration struct {
 	UserinfoEndpoint                 string   `json:"userinfo_endpoint"`
 	JWKSURI                          string   `json:"jwks_uri"`
 	ResponseTypesSupported           []string `json:"response_types_supported"`
+	ResponseModesSupported           []string `json:"response_modes_supported"`
+	PromptValuesSupported            []string `json:"prompt_values_supported"`
 	SubjectTypesSupported            []string `json:"subject_types_supported"`
 	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
 	ScopesSupported                  []string `json:"scopes_supported"`
 	TokenEndpointAuthMethods         []string `json:"token_endpoint_auth_methods_supported"`
 	ClaimsSupported                  []string `json:"claims_supported"`
+	// Pushed authorization requests (RFC 9126) and request objects (RFC 9101).
+	// Only request_uri values issued by the PAR endpoint are accepted, so
+	// request_uri_parameter_supported stays false.
+	PushedAuthorizationRequestEndpoint     string   `json:"pushed_authorization_request_endpoint"`
+	RequirePushedAuthorizationRequests     bool     `json:"require_pushed_authorization_requests"`
+	RequestParameterSupported              bool     `json:"request_parameter_supported"`
+	RequestURIParameterSupported           bool     `json:"request_uri_parameter_supported"`
+	RequestObjectSigningAlgValuesSupported []string `json:"request_object_signing_alg_values_supported"`
+	// DPoP proofs (RFC 9449) accepted at the token endpoint.
+	DPoPSigningAlgValuesSupported []string `json:"dpop_signing_alg_values_supported"`
+	// RP-initiated and back-channel logout. Logout tokens carry no sid.
+	EndSessionEndpoint                string `json:"end_session_endpoint"`
+	BackchannelLogoutSupported        bool   `json:"backchannel_logout_supported"`
+	BackchannelLogoutSessionSupported bool   `json:"backchannel_logout_session_supported"`
 }
 
-// OrgMetadata builds org discovery payload.
+// OrgMetadata builds org discovery payload. Inactive providers, and social
+// providers without an IdP config, are left out.
 func (s *DiscoveryService) OrgMetadata(ctx *org.Context) OrgDiscoveryResponse {
-	branding := map[string]string{
-		"logo_url":      *ctx.Branding.LogoURL,
-		"primary_color": *ctx.Branding.PrimaryColor,
+	resp := OrgDiscoveryResponse{
+		Org:             ctx.Org.Name,
+		Tenant:          ctx.Org.Name,
+		Slug:            ctx.Org.Slug,
+		Branding:        branding.NewPage(ctx.Branding),
+		Providers:       []DiscoveryProvider{},
+		SocialProviders: []SocialProviderButton{},
 	}
-	providers := make([]map[string]any, 0, len(ctx.AuthProviders))
-	for _, provider := range ctx.AuthProviders {
-		providers = append(providers, map[string]any{
-			"type":    provider.ProviderType,
-			"name":    provider.ProviderType,
-			"enabled": provider.IsActive,
-		})
+
+	idps := make(map[string]domain.OAuthIDPConfig, len(ctx.SocialProviders))
+	for _, idp := range ctx.SocialProviders {
+		idps[idp.Provider] = idp
 	}
-	return OrgDiscoveryResponse{
-		Org:       ctx.Org.Name,
-		Tenant:    ctx.Org.Name,
-		Branding:  branding,
-		Providers: providers,
+	for _, provider := range ctx.AuthProviders {
+		if !provider.IsActive {
+			continue
+		}
+		name := providerDisplayNames[provider.ProviderType]
+		if socialProviders[provider.ProviderType] {
+			idp, ok := idps[provider.ProviderType]
+			if !ok {
+				continue
+			}
+			if custom, ok := idp.Extra["display_name"].(string); ok && strings.TrimSpace(custom) != "" {
+				name = strings.TrimSpace(custom)
+			}
+			resp.SocialProviders = append(resp.SocialProviders, SocialProviderButton{Provider: provider.ProviderType, Name: name})
+		}
+		if name == "" {
+			name = provider.ProviderType
+		}
+		resp.Providers = append(resp.Providers, DiscoveryProvider{Type: provider.ProviderType, Name: name, Enabled: true})
+
+		if provider.ProviderType == "password" {
+			policy := ctx.PasswordConfig
+			resp.Password = &PasswordPolicyHints{
+				MinLength:          policy.MinLength,
+				RequireUppercase:   policy.RequireUppercase,
+				RequireNumber:      policy.RequireNumber,
+				RequireSymbol:      policy.RequireSymbol,
+				AllowSignup:        policy.AllowSignup,
+				AllowPasswordReset: policy.AllowPasswordReset,
+			}
+		}
 	}
+	return resp
+}
+
+// providerDisplayNames are the default labels of login methods. Social
+// providers can override theirs with the IdP config's extra.display_name.
+var providerDisplayNames = map[string]string{
+	"password":   "Password",
+	"otp":        "One-time code",
+	"passkey":    "Passkey",
+	"magic_link": "Email link",
+	"google":     "Google",
+	"apple":      "Apple",
+	"github":     "GitHub",
+	"microsoft":  "Microsoft",
+	"oidc":       "Single sign-on",
 }
 
-// OpenIDConfigurationResponse builds the OIDC document using request host.
-func (s *DiscoveryService) OpenIDConfigurationResponse(schema, host string, ctx *org.Context) OpenIDConfiguration {
-	issuer := fmt.Sprintf("%s://%s", schema, host)
+// OpenIDConfigurationResponse builds the OIDC document for the issuer of r.
+func (s *DiscoveryService) OpenIDConfigurationResponse(r *http.Request, ctx *org.Context) OpenIDConfiguration {
+	issuer := s.Issuers.Issuer(r, ctx)
 	base := issuer
 	authorize := fmt.Sprintf("%s/oauth/authorize", base)
 	token := fmt.Sprintf("%s/oauth/token", base)
 	userinfo := fmt.Sprintf("%s/userinfo", base)
 	jwks := fmt.Sprintf("%s/.well-known/jwks.json", base)
+	par := fmt.Sprintf("%s/oauth/par", base)
+	endSession := fmt.Sprintf("%s/oauth/logout", base)
 	return OpenIDConfiguration{
-		Issuer:                           issuer,
-		AuthorizationEndpoint:            authorize,
-		TokenEndpoint:                    token,
-		UserinfoEndpoint:                 userinfo,
-		JWKSURI:                          jwks,
-		ResponseTypesSupported:           []string{"code", "token"},
-		SubjectTypesSupported:            []string{"public"},
-		IDTokenSigningAlgValuesSupported: []string{"HS256"},
-		ScopesSupported:                  []string{"openid", "profile", "email", "offline_access"},
-		TokenEndpointAuthMethods:         []string{"client_secret_post"},
-		ClaimsSupported:                  []string{"sub", "email", "name", "picture", "org_id", "tenant_id"},
+		Issuer:                                 issuer,
+		AuthorizationEndpoint:                  authorize,
+		TokenEndpoint:                          token,
+		UserinfoEndpoint:                       userinfo,
+		JWKSURI:                                jwks,
+		ResponseTypesSupported:                 []string{"code", "token"},
+		ResponseModesSupported:                 []string{"query", "fragment", "form_post"},
+		PromptValuesSupported:                  []string{"none", "login", "consent", "select_account"},
+		SubjectTypesSupported:                  []string{"public"},
+		IDTokenSigningAlgValuesSupported:       []string{"HS256"},
+		ScopesSupported:                        []string{"openid", "profile", "email", "offline_access"},
+		TokenEndpointAuthMethods:               []string{"client_secret_post"},
+		ClaimsSupported:                        []string{"sub", "auth_time", "email", "email_verified", "name", "picture", "org_id", "tenant_id"},
+		PushedAuthorizationRequestEndpoint:     par,
+		RequestParameterSupported:              true,
+		RequestObjectSigningAlgValuesSupported: signingAlgNames(RequestObjectSigningAlgs),
+		DPoPSigningAlgValuesSupported:          DPoPSigningAlgValues(),
+		EndSessionEndpoint:                     endSession,
+		BackchannelLogoutSupported:             true,
 	}
 }
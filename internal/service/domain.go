@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/domainverify"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+const (
+	domainTokenPrefix = "railzway-verification-"
+	// domainProvisioningTimeout is how long a verified domain may take to point
+	// at CustomDomainTarget before provisioning is marked as failed.
+	domainProvisioningTimeout = 72 * time.Hour
+	domainProvisioningBatch   = 100
+)
+
+// DomainService manages the custom domains an org is served on: adding them,
+// proving ownership and advancing their provisioning.
+type DomainService struct {
+	domains   repository.DomainRepository
+	resolver  *org.Resolver
+	verifier  *domainverify.Verifier
+	snowflake *snowflake.Node
+	cfg       config.Config
+	logger    *zap.Logger
+	tracer    trace.Tracer
+}
+
+// NewDomainService wires dependencies.
+func NewDomainService(domains repository.DomainRepository, resolver *org.Resolver, verifier *domainverify.Verifier, snowflake *snowflake.Node, cfg config.Config, logger *zap.Logger) *DomainService {
+	if logger == nil {
+		logger = zap.L()
+	}
+	return &DomainService{
+		domains:   domains,
+		resolver:  resolver,
+		verifier:  verifier,
+		snowflake: snowflake,
+		cfg:       cfg,
+		logger:    logger,
+		tracer:    otel.Tracer("github.com/smallbiznis/railzway-auth/internal/service"),
+	}
+}
+
+// DomainInfo is the admin view of a domain. Verification is only set while
+// the domain is unverified.
+type DomainInfo struct {
+	ID                 int64                   `json:"id,string"`
+	Host               string                  `json:"host"`
+	IsPrimary          bool                    `json:"is_primary"`
+	VerificationMethod string                  `json:"verification_method"`
+	Verified           bool                    `json:"verified"`
+	VerifiedAt         *time.Time              `json:"verified_at,omitempty"`
+	CertificateStatus  string                  `json:"certificate_status"`
+	ProvisioningStatus string                  `json:"provisioning_status"`
+	ProvisionedAt      *time.Time              `json:"provisioned_at,omitempty"`
+	CNAMETarget        string                  `json:"cname_target,omitempty"`
+	CreatedAt          time.Time               `json:"created_at"`
+	Verification       *DomainVerificationInfo `json:"verification,omitempty"`
+}
+
+// DomainVerificationInfo tells the org how to publish its verification token.
+type DomainVerificationInfo struct {
+	Token       string `json:"token"`
+	DNSRecord   string `json:"dns_record"`
+	DNSType     string `json:"dns_type"`
+	FileURL     string `json:"file_url"`
+	FileContent string `json:"file_content"`
+}
+
+// ListDomains returns every domain of the org, primary first.
+func (s *DomainService) ListDomains(ctx context.Context, orgID int64) ([]DomainInfo, error) {
+	ctx, span := s.startSpan(ctx, "DomainService.ListDomains")
+	defer span.End()
+
+	domains, err := s.domains.ListByOrg(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	views := make([]DomainInfo, 0, len(domains))
+	for _, d := range domains {
+		views = append(views, s.domainInfo(d))
+	}
+	return views, nil
+}
+
+// AddDomain registers a custom domain for the org and issues its verification
+// token. The domain is not routed until it has been verified.
+func (s *DomainService) AddDomain(ctx context.Context, orgID int64, host, method string) (DomainInfo, error) {
+	ctx, span := s.startSpan(ctx, "DomainService.AddDomain")
+	defer span.End()
+
+	host, ok := normalizeDomainHost(host)
+	if !ok {
+		return DomainInfo{}, newOAuthError("invalid_request", "host must be a fully qualified domain name.", http.StatusBadRequest)
+	}
+	method, err := parseVerificationMethod(method)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+
+	d, err := s.domains.Create(ctx, domain.Domain{
+		ID:                 s.snowflake.Generate().Int64(),
+		OrgID:              orgID,
+		Host:               host,
+		VerificationMethod: method,
+		VerificationCode:   domainTokenPrefix + randomString(16),
+	})
+	if err != nil {
+		// Create refuses hosts another org has verified; unverified claims
+		// by other orgs do not block anyone.
+		if errors.Is(err, pgx.ErrNoRows) || isUniqueViolation(err) {
+			return DomainInfo{}, newOAuthError("invalid_request", "Domain is already registered.", http.StatusConflict)
+		}
+		span.RecordError(err)
+		return DomainInfo{}, err
+	}
+
+	auditLog(s.logger, "domain.added", "org_id", orgID, "domain_id", d.ID, "host", d.Host)
+	return s.domainInfo(d), nil
+}
+
+// RegenerateVerificationToken issues a new token for an unverified domain,
+// optionally switching the verification method.
+func (s *DomainService) RegenerateVerificationToken(ctx context.Context, orgID, domainID int64, method string) (DomainInfo, error) {
+	ctx, span := s.startSpan(ctx, "DomainService.RegenerateVerificationToken")
+	defer span.End()
+
+	d, err := s.getDomain(ctx, orgID, domainID)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	if d.Verified {
+		return DomainInfo{}, newOAuthError("invalid_request", "Domain is already verified.", http.StatusConflict)
+	}
+	if strings.TrimSpace(method) == "" {
+		method = d.VerificationMethod
+	}
+	method, err = parseVerificationMethod(method)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+
+	d, err = s.domains.SetVerificationToken(ctx, orgID, domainID, method, domainTokenPrefix+randomString(16))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return DomainInfo{}, newOAuthError("invalid_request", "Domain is already verified.", http.StatusConflict)
+		}
+		span.RecordError(err)
+		return DomainInfo{}, err
+	}
+
+	auditLog(s.logger, "domain.verification_token_issued", "org_id", orgID, "domain_id", d.ID, "method", method)
+	return s.domainInfo(d), nil
+}
+
+// VerifyDomain checks that the org has published the domain's token using its
+// verification method and, if so, starts routing the domain.
+func (s *DomainService) VerifyDomain(ctx context.Context, orgID, domainID int64) (DomainInfo, error) {
+	ctx, span := s.startSpan(ctx, "DomainService.VerifyDomain")
+	defer span.End()
+
+	d, err := s.getDomain(ctx, orgID, domainID)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	if d.Verified {
+		return s.domainInfo(d), nil
+	}
+
+	switch d.VerificationMethod {
+	case domain.DomainVerificationDNS:
+		err = s.verifier.VerifyDNS(ctx, d.Host, d.VerificationCode)
+	case domain.DomainVerificationFile:
+		err = s.verifier.VerifyFile(ctx, d.Host, d.VerificationCode)
+	default:
+		err = domainverify.ErrNotVerified
+	}
+	if err != nil {
+		auditLog(s.logger, "domain.verification_failed", "org_id", orgID, "domain_id", d.ID, "method", d.VerificationMethod, "error", err.Error())
+		if errors.Is(err, domainverify.ErrNotVerified) {
+			return DomainInfo{}, newOAuthError("invalid_request", "Verification token not found for "+d.Host+".", http.StatusBadRequest)
+		}
+		if errors.Is(err, domainverify.ErrForbiddenAddress) {
+			return DomainInfo{}, newOAuthError("invalid_request", d.Host+" does not resolve to a public address.", http.StatusBadRequest)
+		}
+		span.RecordError(err)
+		return DomainInfo{}, newOAuthError("temporarily_unavailable", "Domain verification could not be completed; try again later.", http.StatusServiceUnavailable)
+	}
+
+	return s.markVerified(ctx, d, d.VerificationMethod, "domain.verified")
+}
+
+// AttestDomain marks a domain as verified without checking a token. It is
+// for operators who established ownership out of band and is not reachable
+// through the admin API.
+func (s *DomainService) AttestDomain(ctx context.Context, orgID, domainID int64) (DomainInfo, error) {
+	ctx, span := s.startSpan(ctx, "DomainService.AttestDomain")
+	defer span.End()
+
+	d, err := s.getDomain(ctx, orgID, domainID)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	if d.Verified {
+		return s.domainInfo(d), nil
+	}
+	return s.markVerified(ctx, d, domain.DomainVerificationManual, "domain.attested")
+}
+
+// markVerified starts routing d. Only one org can hold a verified host, so a
+// host verified by another org in the meantime is a conflict.
+func (s *DomainService) markVerified(ctx context.Context, d domain.Domain, method, event string) (DomainInfo, error) {
+	verified, err := s.domains.MarkVerified(ctx, d.OrgID, d.ID, method, time.Now().UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return DomainInfo{}, newOAuthError("invalid_request", "Domain is already verified by another org.", http.StatusConflict)
+		}
+		trace.SpanFromContext(ctx).RecordError(err)
+		return DomainInfo{}, err
+	}
+	s.invalidate(ctx, d.OrgID)
+
+	auditLog(s.logger, event, "org_id", d.OrgID, "domain_id", d.ID, "host", d.Host, "method", method)
+	return s.domainInfo(verified), nil
+}
+
+// DeleteDomain stops serving the org on a custom domain. The primary domain
+// cannot be removed.
+func (s *DomainService) DeleteDomain(ctx context.Context, orgID, domainID int64) error {
+	ctx, span := s.startSpan(ctx, "DomainService.DeleteDomain")
+	defer span.End()
+
+	d, err := s.getDomain(ctx, orgID, domainID)
+	if err != nil {
+		return err
+	}
+	if d.IsPrimary {
+		return newOAuthError("invalid_request", "The primary domain cannot be removed.", http.StatusBadRequest)
+	}
+	if err := s.domains.Delete(ctx, orgID, domainID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Domain not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "domain.deleted", "org_id", orgID, "domain_id", d.ID, "host", d.Host)
+	return nil
+}
+
+// AdvanceProvisioning moves verified domains through provisioning: pending
+// domains start provisioning, and provisioning domains become active once
+// they are a CNAME for CustomDomainTarget (immediately when no target is
+// configured), or fail after domainProvisioningTimeout.
+func (s *DomainService) AdvanceProvisioning(ctx context.Context) error {
+	ctx, span := s.startSpan(ctx, "DomainService.AdvanceProvisioning")
+	defer span.End()
+
+	domains, err := s.domains.ListProvisioning(ctx, domainProvisioningBatch)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	now := time.Now().UTC()
+	for _, d := range domains {
+		status := d.ProvisioningStatus
+		if status == domain.DomainProvisioningPending {
+			if !s.transition(ctx, d, status, domain.DomainProvisioningInProgress, now) {
+				continue
+			}
+			status = domain.DomainProvisioningInProgress
+		}
+
+		next, err := s.provisioningOutcome(ctx, d, now)
+		if err != nil {
+			s.logger.Warn("check domain provisioning", zap.String("host", d.Host), zap.Error(err))
+			continue
+		}
+		if next != "" {
+			s.transition(ctx, d, status, next, now)
+		}
+	}
+	return nil
+}
+
+// RunProvisioner calls AdvanceProvisioning every interval until ctx is done.
+func (s *DomainService) RunProvisioner(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.AdvanceProvisioning(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("advance domain provisioning", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// provisioningOutcome returns the state a provisioning domain should move to,
+// or "" while it should keep waiting.
+func (s *DomainService) provisioningOutcome(ctx context.Context, d domain.Domain, now time.Time) (string, error) {
+	target := strings.TrimSpace(s.cfg.CustomDomainTarget)
+	if target == "" {
+		return domain.DomainProvisioningActive, nil
+	}
+	ok, err := s.verifier.PointsTo(ctx, d.Host, target)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return domain.DomainProvisioningActive, nil
+	}
+	if d.VerifiedAt != nil && now.Sub(*d.VerifiedAt) > domainProvisioningTimeout {
+		return domain.DomainProvisioningError, nil
+	}
+	return "", nil
+}
+
+func (s *DomainService) transition(ctx context.Context, d domain.Domain, from, to string, now time.Time) bool {
+	moved, err := s.domains.UpdateProvisioningStatus(ctx, d.ID, from, to, now)
+	if err != nil {
+		s.logger.Warn("update domain provisioning status", zap.String("host", d.Host), zap.Error(err))
+		return false
+	}
+	if !moved {
+		// Another instance got there first.
+		return false
+	}
+	s.invalidate(ctx, d.OrgID)
+	auditLog(s.logger, "domain.provisioning_"+to, "org_id", d.OrgID, "domain_id", d.ID, "host", d.Host)
+	return true
+}
+
+func (s *DomainService) getDomain(ctx context.Context, orgID, domainID int64) (domain.Domain, error) {
+	d, err := s.domains.Get(ctx, orgID, domainID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Domain{}, newOAuthError("invalid_request", "Domain not found.", http.StatusNotFound)
+		}
+		return domain.Domain{}, err
+	}
+	return d, nil
+}
+
+// invalidate drops cached org contexts after a domain change. Failures only
+// delay the change until the cache entries expire, so they are logged.
+func (s *DomainService) invalidate(ctx context.Context, orgID int64) {
+	if s.resolver == nil {
+		return
+	}
+	if err := s.resolver.Invalidate(ctx, orgID); err != nil {
+		s.logger.Warn("invalidate org context", zap.Int64("org_id", orgID), zap.Error(err))
+	}
+}
+
+func (s *DomainService) domainInfo(d domain.Domain) DomainInfo {
+	info := DomainInfo{
+		ID:                 d.ID,
+		Host:               d.Host,
+		IsPrimary:          d.IsPrimary,
+		VerificationMethod: d.VerificationMethod,
+		Verified:           d.Verified,
+		VerifiedAt:         d.VerifiedAt,
+		CertificateStatus:  d.CertificateStatus,
+		ProvisioningStatus: d.ProvisioningStatus,
+		ProvisionedAt:      d.ProvisionedAt,
+		CNAMETarget:        strings.TrimSpace(s.cfg.CustomDomainTarget),
+		CreatedAt:          d.CreatedAt,
+	}
+	if !d.Verified && d.VerificationCode != "" {
+		info.Verification = &DomainVerificationInfo{
+			Token:       d.VerificationCode,
+			DNSRecord:   domainverify.RecordName(d.Host),
+			DNSType:     "TXT",
+			FileURL:     "https://" + d.Host + domainverify.FilePath,
+			FileContent: d.VerificationCode,
+		}
+	}
+	return info
+}
+
+func (s *DomainService) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if s == nil || s.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return s.tracer.Start(ctx, name)
+}
+
+// parseVerificationMethod accepts the methods an org can complete itself.
+// Manual verification is only available to operators via AttestDomain.
+func parseVerificationMethod(method string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(method)) {
+	case "", domain.DomainVerificationDNS:
+		return domain.DomainVerificationDNS, nil
+	case domain.DomainVerificationFile:
+		return domain.DomainVerificationFile, nil
+	default:
+		return "", newOAuthError("invalid_request", "verification_method must be dns or file.", http.StatusBadRequest)
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// normalizeDomainHost lowercases host and checks that it is a fully qualified
+// DNS name without port, scheme or trailing dot.
+func normalizeDomainHost(host string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" || len(host) > 253 || net.ParseIP(host) != nil {
+		return "", false
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return "", false
+			}
+		}
+	}
+	return host, true
+}
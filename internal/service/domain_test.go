@@ -0,0 +1,243 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/domainverify"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestCustomDomainVerificationAndProvisioning(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryDomainRepo{items: map[int64]domain.Domain{
+		1: {ID: 1, OrgID: 1, Host: "accounts.railzway.com", IsPrimary: true, Verified: true, ProvisioningStatus: domain.DomainProvisioningActive},
+	}}
+	dns := &fakeDNS{txt: map[string][]string{}, cname: map[string]string{}}
+	node, _ := snowflake.NewNode(1)
+	cfg := config.Config{CustomDomainTarget: "edge.railzway.com"}
+	domains := service.NewDomainService(repo, nil, domainverify.New(dns, nil), node, cfg, zap.NewNop())
+
+	_, err := domains.AddDomain(ctx, 1, "https://login.example.com", "")
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr, "hosts must be bare domain names")
+
+	added, err := domains.AddDomain(ctx, 1, "Login.Example.com.", "dns")
+	require.NoError(t, err)
+	require.Equal(t, "login.example.com", added.Host)
+	require.False(t, added.Verified)
+	require.NotNil(t, added.Verification)
+	require.Equal(t, "_railzway-verification.login.example.com", added.Verification.DNSRecord)
+
+	_, err = domains.VerifyDomain(ctx, 1, added.ID)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 400, oauthErr.Status)
+
+	_, err = domains.VerifyDomain(ctx, 2, added.ID)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 404, oauthErr.Status, "domains are scoped to their org")
+
+	dns.txt[added.Verification.DNSRecord] = []string{added.Verification.Token}
+	verified, err := domains.VerifyDomain(ctx, 1, added.ID)
+	require.NoError(t, err)
+	require.True(t, verified.Verified)
+	require.Nil(t, verified.Verification, "the token is not exposed once verified")
+
+	_, err = domains.RegenerateVerificationToken(ctx, 1, added.ID, "")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status)
+
+	require.NoError(t, domains.AdvanceProvisioning(ctx))
+	require.Equal(t, domain.DomainProvisioningInProgress, repo.items[added.ID].ProvisioningStatus, "waits for the CNAME")
+
+	dns.cname["login.example.com"] = "edge.railzway.com."
+	require.NoError(t, domains.AdvanceProvisioning(ctx))
+	require.Equal(t, domain.DomainProvisioningActive, repo.items[added.ID].ProvisioningStatus)
+	require.NotNil(t, repo.items[added.ID].ProvisionedAt)
+
+	err = domains.DeleteDomain(ctx, 1, 1)
+	require.ErrorAs(t, err, &oauthErr, "the primary domain stays")
+	require.NoError(t, domains.DeleteDomain(ctx, 1, added.ID))
+	listed, err := domains.ListDomains(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+}
+
+func TestCustomDomainClaimsAndManualVerification(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryDomainRepo{items: map[int64]domain.Domain{}}
+	dns := &fakeDNS{txt: map[string][]string{}, cname: map[string]string{}}
+	node, _ := snowflake.NewNode(1)
+	domains := service.NewDomainService(repo, nil, domainverify.New(dns, nil), node, config.Config{}, zap.NewNop())
+	var oauthErr *service.OAuthError
+
+	_, err := domains.AddDomain(ctx, 1, "login.example.com", domain.DomainVerificationManual)
+	require.ErrorAs(t, err, &oauthErr, "org admins cannot skip the ownership check")
+	require.Equal(t, 400, oauthErr.Status)
+
+	squatter, err := domains.AddDomain(ctx, 2, "login.example.com", "")
+	require.NoError(t, err)
+	owner, err := domains.AddDomain(ctx, 1, "login.example.com", "")
+	require.NoError(t, err, "an unverified claim does not reserve the host")
+	_, err = domains.RegenerateVerificationToken(ctx, 1, owner.ID, domain.DomainVerificationManual)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 400, oauthErr.Status)
+
+	dns.txt[owner.Verification.DNSRecord] = []string{owner.Verification.Token, squatter.Verification.Token}
+	_, err = domains.VerifyDomain(ctx, 1, owner.ID)
+	require.NoError(t, err)
+	_, err = domains.VerifyDomain(ctx, 2, squatter.ID)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "only one org can hold a verified host")
+	_, err = domains.AddDomain(ctx, 3, "login.example.com", "")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status)
+
+	internal, err := domains.AddDomain(ctx, 3, "sso.example.org", "")
+	require.NoError(t, err)
+	attested, err := domains.AttestDomain(ctx, 3, internal.ID)
+	require.NoError(t, err, "operators attest ownership out of band")
+	require.True(t, attested.Verified)
+	require.Equal(t, domain.DomainVerificationManual, attested.VerificationMethod)
+}
+
+type fakeDNS struct {
+	txt   map[string][]string
+	cname map[string]string
+}
+
+func (f *fakeDNS) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if records, ok := f.txt[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (f *fakeDNS) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := f.cname[host]; ok {
+		return cname, nil
+	}
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+type memoryDomainRepo struct {
+	items map[int64]domain.Domain
+}
+
+func (m *memoryDomainRepo) Create(ctx context.Context, d domain.Domain) (domain.Domain, error) {
+	for _, existing := range m.items {
+		if existing.Host != d.Host {
+			continue
+		}
+		if existing.OrgID == d.OrgID {
+			return domain.Domain{}, &pgconn.PgError{Code: "23505"}
+		}
+		if existing.Verified {
+			return domain.Domain{}, pgx.ErrNoRows
+		}
+	}
+	d.CertificateStatus = domain.DomainCertificatePending
+	d.ProvisioningStatus = domain.DomainProvisioningPending
+	d.CreatedAt = time.Now()
+	m.items[d.ID] = d
+	return d, nil
+}
+
+func (m *memoryDomainRepo) Get(ctx context.Context, orgID, id int64) (domain.Domain, error) {
+	d, ok := m.items[id]
+	if !ok || d.OrgID != orgID {
+		return domain.Domain{}, pgx.ErrNoRows
+	}
+	return d, nil
+}
+
+func (m *memoryDomainRepo) ListByOrg(ctx context.Context, orgID int64) ([]domain.Domain, error) {
+	var domains []domain.Domain
+	for _, d := range m.items {
+		if d.OrgID == orgID {
+			domains = append(domains, d)
+		}
+	}
+	return domains, nil
+}
+
+func (m *memoryDomainRepo) Delete(ctx context.Context, orgID, id int64) error {
+	d, ok := m.items[id]
+	if !ok || d.OrgID != orgID || d.IsPrimary {
+		return pgx.ErrNoRows
+	}
+	delete(m.items, id)
+	return nil
+}
+
+func (m *memoryDomainRepo) SetVerificationToken(ctx context.Context, orgID, id int64, method, code string) (domain.Domain, error) {
+	d, ok := m.items[id]
+	if !ok || d.OrgID != orgID || d.Verified {
+		return domain.Domain{}, pgx.ErrNoRows
+	}
+	d.VerificationMethod = method
+	d.VerificationCode = code
+	m.items[id] = d
+	return d, nil
+}
+
+func (m *memoryDomainRepo) MarkVerified(ctx context.Context, orgID, id int64, method string, verifiedAt time.Time) (domain.Domain, error) {
+	d, ok := m.items[id]
+	if !ok || d.OrgID != orgID {
+		return domain.Domain{}, pgx.ErrNoRows
+	}
+	for _, existing := range m.items {
+		if existing.ID != id && existing.Host == d.Host && existing.Verified {
+			return domain.Domain{}, &pgconn.PgError{Code: "23505"}
+		}
+	}
+	d.Verified = true
+	d.VerifiedAt = &verifiedAt
+	d.VerificationMethod = method
+	m.items[id] = d
+	return d, nil
+}
+
+func (m *memoryDomainRepo) ListProvisioning(ctx context.Context, limit int) ([]domain.Domain, error) {
+	var domains []domain.Domain
+	for _, d := range m.items {
+		if d.Verified && (d.ProvisioningStatus == domain.DomainProvisioningPending || d.ProvisioningStatus == domain.DomainProvisioningInProgress) {
+			domains = append(domains, d)
+		}
+	}
+	return domains, nil
+}
+
+func (m *memoryDomainRepo) UpdateProvisioningStatus(ctx context.Context, id int64, from, to string, at time.Time) (bool, error) {
+	d, ok := m.items[id]
+	if !ok || d.ProvisioningStatus != from {
+		return false, nil
+	}
+	d.ProvisioningStatus = to
+	if to == domain.DomainProvisioningActive {
+		d.ProvisionedAt = &at
+	}
+	m.items[id] = d
+	return true, nil
+}
+
+func (m *memoryDomainRepo) UpdateCertificateStatus(ctx context.Context, host, status string, at time.Time) error {
+	for id, d := range m.items {
+		if d.Host == host {
+			d.CertificateStatus = status
+			d.CertificateUpdatedAt = &at
+			m.items[id] = d
+		}
+	}
+	return nil
+}
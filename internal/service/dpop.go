@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// TokenTypeDPoP is the token_type of DPoP-bound access tokens and the
+// authorization scheme they are presented with.
+const TokenTypeDPoP = "DPoP"
+
+// DPoPSigningAlgs lists the algorithms accepted for DPoP proofs (RFC 9449).
+// Proofs are signed with the client's own key, so only asymmetric
+// algorithms make sense.
+var DPoPSigningAlgs = []gojose.SignatureAlgorithm{
+	gojose.ES256, gojose.ES384, gojose.ES512,
+	gojose.RS256, gojose.RS384, gojose.RS512,
+	gojose.PS256, gojose.PS384, gojose.PS512,
+	gojose.EdDSA,
+}
+
+// dpopProofWindow is how far a proof's iat may be from now. Proof jti values
+// are remembered for twice as long, which covers the whole window.
+const dpopProofWindow = time.Minute
+
+// DPoPProofRequest is the HTTP request a DPoP proof arrived with.
+type DPoPProofRequest struct {
+	// Proofs are the values of the DPoP header.
+	Proofs []string
+	Method string
+	URL    string
+	// AccessToken is the token presented with the proof at a protected
+	// resource; the proof's ath claim must be its hash.
+	AccessToken string
+}
+
+// dpopProofClaims are the DPoP-specific claims of a proof.
+type dpopProofClaims struct {
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	ATH string `json:"ath,omitempty"`
+}
+
+type dpopKeyContextKey struct{}
+
+// WithDPoPKey records the thumbprint of the DPoP key a token request proved
+// possession of. Tokens issued under ctx are bound to it.
+func WithDPoPKey(ctx context.Context, jkt string) context.Context {
+	if jkt == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, dpopKeyContextKey{}, jkt)
+}
+
+func dpopKeyFromContext(ctx context.Context) string {
+	jkt, _ := ctx.Value(dpopKeyContextKey{}).(string)
+	return jkt
+}
+
+// DPoPSigningAlgValues returns DPoPSigningAlgs as strings, for discovery and
+// WWW-Authenticate challenges.
+func DPoPSigningAlgValues() []string {
+	return signingAlgNames(DPoPSigningAlgs)
+}
+
+// VerifyDPoPProof checks the DPoP proof of a request and returns the
+// thumbprint of its key, or "" when the request carries no proof. A proof is
+// accepted only once.
+func (s *AuthService) VerifyDPoPProof(ctx context.Context, orgID int64, req DPoPProofRequest) (string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.VerifyDPoPProof")
+	defer span.End()
+
+	invalid := func(desc string) error {
+		return newOAuthError("invalid_dpop_proof", desc, http.StatusBadRequest)
+	}
+	switch len(req.Proofs) {
+	case 0:
+		return "", nil
+	case 1:
+	default:
+		return "", invalid("Only one DPoP proof may be sent.")
+	}
+
+	parsed, err := gojwt.ParseSigned(strings.TrimSpace(req.Proofs[0]), DPoPSigningAlgs)
+	if err != nil {
+		return "", invalid("DPoP proof must be a JWT signed with an asymmetric key.")
+	}
+	header := parsed.Headers[0]
+	if typ, _ := header.ExtraHeaders[gojose.HeaderType].(string); typ != "dpop+jwt" {
+		return "", invalid("DPoP proof typ must be dpop+jwt.")
+	}
+	jwk := header.JSONWebKey
+	if jwk == nil || !jwk.Valid() || !jwk.IsPublic() {
+		return "", invalid("DPoP proof must carry a public jwk.")
+	}
+
+	var std gojwt.Claims
+	var claims dpopProofClaims
+	if err := parsed.Claims(jwk.Key, &std, &claims); err != nil {
+		return "", invalid("DPoP proof signature is invalid.")
+	}
+	if std.ID == "" || std.IssuedAt == nil {
+		return "", invalid("DPoP proof must carry jti and iat.")
+	}
+	now := time.Now()
+	if issued := std.IssuedAt.Time(); issued.Before(now.Add(-dpopProofWindow)) || issued.After(now.Add(dpopProofWindow)) {
+		return "", invalid("DPoP proof is expired or not yet valid.")
+	}
+	if !strings.EqualFold(claims.HTM, req.Method) {
+		return "", invalid("DPoP proof htm does not match the request method.")
+	}
+	if htu := normalizeHTU(claims.HTU); htu == "" || htu != normalizeHTU(req.URL) {
+		return "", invalid("DPoP proof htu does not match the request URL.")
+	}
+	if req.AccessToken != "" {
+		sum := sha256.Sum256([]byte(req.AccessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			return "", invalid("DPoP proof ath does not match the access token.")
+		}
+	}
+
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", invalid("DPoP proof jwk is invalid.")
+	}
+	jkt := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	if s.cooldowns == nil {
+		return "", newOAuthError("server_error", "DPoP replay store unavailable.", http.StatusInternalServerError)
+	}
+	fresh, err := s.cooldowns.Acquire(ctx, fmt.Sprintf("dpop:jti:%d:%s:%s", orgID, jkt, std.ID), 2*dpopProofWindow)
+	if err != nil {
+		span.RecordError(err)
+		return "", newOAuthError("server_error", "Failed to check DPoP proof.", http.StatusInternalServerError)
+	}
+	if !fresh {
+		return "", invalid("DPoP proof has already been used.")
+	}
+	return jkt, nil
+}
+
+// CheckTokenBinding enforces the DPoP binding of an access token presented
+// to a protected resource under scheme. boundKey is the token's cnf.jkt.
+// Bound tokens need the DPoP scheme and a proof from their key; bearer
+// tokens must not be presented as DPoP tokens.
+func (s *AuthService) CheckTokenBinding(ctx context.Context, orgID int64, scheme, boundKey string, req DPoPProofRequest) error {
+	isDPoP := strings.EqualFold(scheme, TokenTypeDPoP)
+	if boundKey == "" {
+		if isDPoP {
+			return newOAuthError("invalid_token", "Access token is not DPoP-bound.", http.StatusUnauthorized)
+		}
+		return nil
+	}
+	if !isDPoP {
+		return newOAuthError("invalid_token", "DPoP-bound access tokens must use the DPoP scheme.", http.StatusUnauthorized)
+	}
+	if len(req.Proofs) == 0 {
+		return newOAuthError("invalid_dpop_proof", "DPoP proof required.", http.StatusUnauthorized)
+	}
+	jkt, err := s.VerifyDPoPProof(ctx, orgID, req)
+	if err != nil {
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_dpop_proof" {
+			return newOAuthError(oauthErr.Code, oauthErr.Description, http.StatusUnauthorized)
+		}
+		return err
+	}
+	if jkt != boundKey {
+		return newOAuthError("invalid_dpop_proof", "DPoP proof key does not match the access token.", http.StatusUnauthorized)
+	}
+	return nil
+}
+
+// dpopBinding returns the DPoP key a token request proved possession of. It
+// fails when clientID must use DPoP and the request carried no proof.
+func (s *AuthService) dpopBinding(ctx context.Context, orgID int64, clientID string) (string, error) {
+	if jkt := dpopKeyFromContext(ctx); jkt != "" || clientID == "" || s.clients == nil {
+		return jkt, nil
+	}
+	client, err := s.clients.GetClientByID(ctx, orgID, clientID)
+	if err != nil {
+		// Unknown clients are rejected by the grant itself.
+		return "", nil
+	}
+	return dpopBindingFor(ctx, client)
+}
+
+// dpopBindingFor is dpopBinding for a client that is already loaded.
+func dpopBindingFor(ctx context.Context, client domain.OAuthClient) (string, error) {
+	jkt := dpopKeyFromContext(ctx)
+	if jkt == "" && client.DPoPBoundAccessTokens {
+		return "", newOAuthError("invalid_dpop_proof", "DPoP proof required for this client.", http.StatusBadRequest)
+	}
+	return jkt, nil
+}
+
+// tokenType is the token_type of access tokens bound to jkt.
+func tokenType(jkt string) string {
+	if jkt != "" {
+		return TokenTypeDPoP
+	}
+	return "Bearer"
+}
+
+// normalizeHTU reduces a URL to the scheme, host and path that a proof's htu
+// is compared on, dropping default ports, query and fragment.
+func normalizeHTU(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && !(scheme == "https" && port == "443") && !(scheme == "http" && port == "80") {
+		host = net.JoinHostPort(host, port)
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path
+}
+
+func signingAlgNames(algs []gojose.SignatureAlgorithm) []string {
+	out := make([]string, 0, len(algs))
+	for _, alg := range algs {
+		out = append(out, string(alg))
+	}
+	return out
+}
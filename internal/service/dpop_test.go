@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+const dpopTokenURL = "https://tenant.example/oauth/token"
+
+func TestDPoPBoundTokens(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := password.Hash("password")
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", PasswordHash: hash}
+	tokenRepo := &memoryTokenRepo{}
+	clients := &logoutClientRepo{client: domain.OAuthClient{ClientID: "mobile", ClientSecret: "secret"}}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &memoryUserRepo{user: user},
+		Tokens:    tokenRepo,
+		Codes:     &memoryCodeRepo{},
+		Clients:   clients,
+		Cooldowns: &memoryCooldownStore{keys: map[string]bool{}},
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{
+		Org:            domain.Org{ID: 1, Name: "Tenant A"},
+		ClientID:       "mobile",
+		PasswordConfig: domain.PasswordConfig{OrgID: 1, MinLength: 8, LockoutAttempts: 5, LockoutDurationSeconds: 300},
+		AuthProviders:  []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+	}
+	issuer := "https://tenant.example"
+	requireCode := func(err error, code string) {
+		t.Helper()
+		var oauthErr *service.OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		require.Equal(t, code, oauthErr.Code)
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	verify := func(proof, method, url string) (string, error) {
+		return authService.VerifyDPoPProof(ctx, 1, service.DPoPProofRequest{Proofs: []string{proof}, Method: method, URL: url})
+	}
+
+	jkt, err := authService.VerifyDPoPProof(ctx, 1, service.DPoPProofRequest{Method: "POST", URL: dpopTokenURL})
+	require.NoError(t, err)
+	require.Empty(t, jkt, "requests without a proof stay bearer")
+	_, err = verify(dpopProof(t, key, "GET", dpopTokenURL, ""), "POST", dpopTokenURL)
+	requireCode(err, "invalid_dpop_proof")
+	_, err = verify(dpopProof(t, key, "POST", "https://other.example/oauth/token", ""), "POST", dpopTokenURL)
+	requireCode(err, "invalid_dpop_proof")
+
+	proof := dpopProof(t, key, "POST", "https://tenant.example:443/oauth/token?x=1", "")
+	jkt, err = verify(proof, "POST", dpopTokenURL)
+	require.NoError(t, err)
+	require.NotEmpty(t, jkt)
+	_, err = verify(proof, "POST", dpopTokenURL)
+	requireCode(err, "invalid_dpop_proof")
+
+	resp, err := authService.PasswordGrant(service.WithDPoPKey(ctx, jkt), orgCtx, user.Email, "password", "openid", issuer)
+	require.NoError(t, err)
+	require.Equal(t, "DPoP", resp.TokenType)
+	_, claims, err := generator.ValidateAccessToken(ctx, 1, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, jkt, claims.DPoPKey())
+	require.Equal(t, jkt, tokenRepo.lastToken.DPoPJKT)
+
+	resourceURL := "https://tenant.example/auth/me"
+	present := func(scheme string, proofs ...string) error {
+		return authService.CheckTokenBinding(ctx, 1, scheme, claims.DPoPKey(), service.DPoPProofRequest{
+			Proofs: proofs, Method: "GET", URL: resourceURL, AccessToken: resp.AccessToken,
+		})
+	}
+	requireCode(present("Bearer"), "invalid_token")
+	requireCode(present("DPoP"), "invalid_dpop_proof")
+	requireCode(present("DPoP", dpopProof(t, key, "GET", resourceURL, "")), "invalid_dpop_proof")
+	requireCode(present("DPoP", dpopProof(t, otherKey, "GET", resourceURL, resp.AccessToken)), "invalid_dpop_proof")
+	require.NoError(t, present("DPoP", dpopProof(t, key, "GET", resourceURL, resp.AccessToken)))
+	requireCode(authService.CheckTokenBinding(ctx, 1, "DPoP", "", service.DPoPProofRequest{}), "invalid_token")
+
+	_, err = authService.RefreshGrant(ctx, orgCtx, tokenRepo.lastToken.RefreshToken, "", issuer, "")
+	requireCode(err, "invalid_dpop_proof")
+	otherJKT, err := verify(dpopProof(t, otherKey, "POST", dpopTokenURL, ""), "POST", dpopTokenURL)
+	require.NoError(t, err)
+	_, err = authService.RefreshGrant(service.WithDPoPKey(ctx, otherJKT), orgCtx, tokenRepo.lastToken.RefreshToken, "", issuer, "")
+	requireCode(err, "invalid_dpop_proof")
+	resp, err = authService.RefreshGrant(service.WithDPoPKey(ctx, jkt), orgCtx, tokenRepo.lastToken.RefreshToken, "", issuer, "")
+	require.NoError(t, err)
+	require.Equal(t, "DPoP", resp.TokenType)
+
+	clients.client.DPoPBoundAccessTokens = true
+	_, err = authService.ClientCredentialsGrant(ctx, orgCtx, "mobile", "secret", "openid", issuer, "")
+	requireCode(err, "invalid_dpop_proof")
+	resp, err = authService.ClientCredentialsGrant(service.WithDPoPKey(ctx, jkt), orgCtx, "mobile", "secret", "openid", issuer, "")
+	require.NoError(t, err)
+	require.Equal(t, "DPoP", resp.TokenType)
+}
+
+// dpopProof signs a DPoP proof for method and url with key. A non-empty
+// accessToken adds its ath hash.
+func dpopProof(t *testing.T, key *ecdsa.PrivateKey, method, url, accessToken string) string {
+	t.Helper()
+	opts := (&gojose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt")
+	signer, err := gojose.NewSigner(gojose.SigningKey{Algorithm: gojose.ES256, Key: key}, opts)
+	require.NoError(t, err)
+	claims := map[string]any{
+		"jti": uuid.NewString(),
+		"iat": gojwt.NewNumericDate(time.Now()),
+		"htm": method,
+		"htu": url,
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	proof, err := gojwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+	return proof
+}
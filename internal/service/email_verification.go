@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/signedtoken"
+)
+
+const (
+	emailVerificationPurpose      = "email_verification"
+	emailVerificationResendPrefix = "email_verification:resend:"
+	// EmailVerificationPath is the endpoint embedded in verification emails.
+	EmailVerificationPath = "/auth/email/verify"
+)
+
+// limitedScopes are the only scopes granted to unverified users when the org
+// email verification policy is "limited".
+var limitedScopes = map[string]bool{"openid": true, "profile": true, "email": true}
+
+// VerifyEmail is the REST counterpart of ConfirmEmail.
+func (s *AuthService) VerifyEmail(ctx context.Context, orgID int64, token string) error {
+	ctx, span := s.startSpan(ctx, "AuthService.VerifyEmail")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, "")
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.ConfirmEmail(ctx, orgCtx, token); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// ConfirmEmail marks the user's address as verified. Tokens are bound to the
+// address they were sent to, so a link stops working if the email changes.
+func (s *AuthService) ConfirmEmail(ctx context.Context, orgCtx *org.Context, token string) error {
+	ctx, span := s.startSpan(ctx, "AuthService.ConfirmEmail")
+	defer span.End()
+
+	if !s.linkSigner.Enabled() {
+		return newOAuthError("server_error", "Email verification is not configured.", http.StatusInternalServerError)
+	}
+	claims, err := s.linkSigner.Verify(token, emailVerificationPurpose, time.Now())
+	if err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return newOAuthError("invalid_grant", "Verification link expired.", http.StatusBadRequest)
+		}
+		return newOAuthError("invalid_grant", "Verification link is invalid.", http.StatusBadRequest)
+	}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || claims.OrgID != orgCtx.Org.ID {
+		return newOAuthError("invalid_grant", "Verification link is invalid.", http.StatusBadRequest)
+	}
+
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, userID)
+	if err != nil || claims.ID != emailFingerprint(user.Email) {
+		if err != nil {
+			span.RecordError(err)
+		}
+		return newOAuthError("invalid_grant", "Verification link is invalid.", http.StatusBadRequest)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	if err := s.users.MarkEmailVerified(ctx, orgCtx.Org.ID, user.ID); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	s.audit("email_verification.confirmed", "org_id", orgCtx.Org.ID, "user_id", user.ID)
+	return nil
+}
+
+// ResendEmailVerification is the REST counterpart of SendEmailVerification.
+func (s *AuthService) ResendEmailVerification(ctx context.Context, orgID int64, email, issuer string) error {
+	ctx, span := s.startSpan(ctx, "AuthService.ResendEmailVerification")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, "")
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	effectiveIssuer := strings.TrimSpace(issuer)
+	if effectiveIssuer == "" {
+		effectiveIssuer = orgIssuer(orgCtx)
+	}
+	if err := s.SendEmailVerification(ctx, orgCtx, email, effectiveIssuer); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// SendEmailVerification emails a fresh verification link. Requests for the
+// same address are throttled, and unknown or already verified addresses are
+// accepted silently so the response does not reveal which accounts exist.
+func (s *AuthService) SendEmailVerification(ctx context.Context, orgCtx *org.Context, email, issuer string) error {
+	ctx, span := s.startSpan(ctx, "AuthService.SendEmailVerification")
+	defer span.End()
+
+	if err := s.requireEmailVerificationConfigured(); err != nil {
+		return err
+	}
+	normalized := normalizeIdentifier(email)
+	if normalized == "" {
+		return newOAuthError("invalid_request", "Email is required.", http.StatusBadRequest)
+	}
+
+	if s.cooldowns != nil {
+		key := fmt.Sprintf("%s%d:%s", emailVerificationResendPrefix, orgCtx.Org.ID, emailFingerprint(normalized))
+		allowed, err := s.cooldowns.Acquire(ctx, key, s.emailVerificationResendInterval())
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if !allowed {
+			return newOAuthError("rate_limited", "A verification email was sent recently. Please wait before requesting another.", http.StatusTooManyRequests)
+		}
+	}
+
+	user, err := s.users.GetByEmail(ctx, orgCtx.Org.ID, normalized)
+	if err != nil || user.EmailVerified {
+		if err != nil {
+			span.RecordError(err)
+		}
+		s.audit("email_verification.resend.skipped", "org_id", orgCtx.Org.ID, "email", normalized)
+		return nil
+	}
+	if err := s.sendVerificationEmail(ctx, orgCtx, user, issuer); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+func (s *AuthService) sendVerificationEmail(ctx context.Context, orgCtx *org.Context, user domain.User, issuer string) error {
+	if strings.TrimSpace(issuer) == "" {
+		return newOAuthError("invalid_request", "Issuer is required.", http.StatusBadRequest)
+	}
+	ttl := s.emailVerificationTTL()
+	token, err := s.linkSigner.Sign(signedtoken.Claims{
+		Purpose:   emailVerificationPurpose,
+		OrgID:     orgCtx.Org.ID,
+		Subject:   strconv.FormatInt(user.ID, 10),
+		ID:        emailFingerprint(user.Email),
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("sign verification link: %w", err)
+	}
+
+	target := strings.TrimRight(issuer, "/") + EmailVerificationPath + "?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Verify your email for %s", coalesce(orgCtx.Org.Name, "your account")),
+		Text: fmt.Sprintf("Confirm your email address by opening the link below. It expires in %d hours.\n\n%s\n\n"+
+			"If you did not create an account you can ignore this email.\n", int(ttl.Hours()), target),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send verification email: %w", err)
+	}
+
+	s.audit("email_verification.sent", "org_id", orgCtx.Org.ID, "user_id", user.ID)
+	return nil
+}
+
+// sendRegistrationVerification is best effort: a delivery failure must not
+// undo the registration, and the user can ask for another email.
+func (s *AuthService) sendRegistrationVerification(ctx context.Context, orgCtx *org.Context, user domain.User, issuer string) {
+	if s.requireEmailVerificationConfigured() != nil {
+		return
+	}
+	if err := s.sendVerificationEmail(ctx, orgCtx, user, issuer); err != nil {
+		s.log().Warn("failed to send verification email",
+			zap.Int64("org_id", orgCtx.Org.ID),
+			zap.Int64("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// applyEmailVerificationPolicy returns the scope an unverified user may be
+// granted, or an error when the org requires a verified address.
+func applyEmailVerificationPolicy(orgCtx *org.Context, user domain.User, scope string) (string, error) {
+	if user.EmailVerified {
+		return scope, nil
+	}
+	switch emailVerificationPolicy(orgCtx) {
+	case domain.EmailVerificationRequired:
+		return "", newOAuthError("email_not_verified", "Verify your email address to continue. Check your inbox for a verification link.", http.StatusForbidden)
+	case domain.EmailVerificationLimited:
+		var kept []string
+		for _, item := range strings.Fields(scope) {
+			if limitedScopes[item] {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) == 0 {
+			return "openid", nil
+		}
+		return strings.Join(kept, " "), nil
+	default:
+		return scope, nil
+	}
+}
+
+func emailVerificationPolicy(orgCtx *org.Context) string {
+	switch strings.ToLower(strings.TrimSpace(orgCtx.PasswordConfig.EmailVerification)) {
+	case domain.EmailVerificationLimited:
+		return domain.EmailVerificationLimited
+	case domain.EmailVerificationRequired:
+		return domain.EmailVerificationRequired
+	default:
+		return domain.EmailVerificationOptional
+	}
+}
+
+func (s *AuthService) requireEmailVerificationConfigured() error {
+	if s.mailer == nil || !s.linkSigner.Enabled() {
+		return newOAuthError("server_error", "Email verification is not configured.", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (s *AuthService) emailVerificationTTL() time.Duration {
+	if s.cfg.EmailVerificationTTL > 0 {
+		return s.cfg.EmailVerificationTTL
+	}
+	return 24 * time.Hour
+}
+
+func (s *AuthService) emailVerificationResendInterval() time.Duration {
+	if s.cfg.EmailVerificationResendInterval > 0 {
+		return s.cfg.EmailVerificationResendInterval
+	}
+	return time.Minute
+}
+
+// emailFingerprint binds verification tokens to an address without putting
+// the address itself in the link.
+func emailFingerprint(email string) string {
+	sum := sha256.Sum256([]byte(normalizeIdentifier(email)))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestEmailVerificationPolicy(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User"}
+	hash, _ := password.Hash("password")
+	user.PasswordHash = hash
+	users := &memoryUserRepo{user: user}
+	outbox := &memoryMailer{}
+
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, LinkSigningKey: "test-key"}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     users,
+		Tokens:    &memoryTokenRepo{},
+		Codes:     &memoryCodeRepo{},
+		Clients:   &memoryClientRepo{},
+		Mailer:    outbox,
+		Cooldowns: &memoryCooldownStore{keys: map[string]bool{}},
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Org:            domain.Org{ID: 1, Name: "Tenant A"},
+		AuthProviders:  []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+		PasswordConfig: domain.PasswordConfig{OrgID: 1, EmailVerification: domain.EmailVerificationLimited},
+	}
+
+	resp, err := authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid email orders:write", "https://tenant")
+	require.NoError(t, err)
+	_, custom, err := authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.Equal(t, "openid email", custom.Scope, "unverified users only get identity scopes")
+	require.False(t, custom.EmailVerified)
+
+	orgCtx.PasswordConfig.EmailVerification = domain.EmailVerificationRequired
+	_, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", "https://tenant")
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "email_not_verified", oauthErr.Code)
+
+	require.NoError(t, authService.SendEmailVerification(ctx, orgCtx, user.Email, "https://tenant"))
+	require.Len(t, outbox.sent, 1)
+	err = authService.SendEmailVerification(ctx, orgCtx, user.Email, "https://tenant")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "rate_limited", oauthErr.Code)
+
+	target, err := url.Parse(magicLinkPattern.FindString(outbox.sent[0].Text))
+	require.NoError(t, err)
+	require.Equal(t, service.EmailVerificationPath, target.Path)
+	token := target.Query().Get("token")
+
+	require.NoError(t, authService.ConfirmEmail(ctx, orgCtx, token))
+	require.True(t, users.user.EmailVerified)
+
+	resp, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid email orders:write", "https://tenant")
+	require.NoError(t, err)
+	_, custom, err = authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.True(t, custom.EmailVerified)
+	require.Equal(t, "openid email orders:write", custom.Scope)
+}
+
+type memoryCooldownStore struct {
+	keys map[string]bool
+}
+
+func (m *memoryCooldownStore) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
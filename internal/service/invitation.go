@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	pw "github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/signedtoken"
+)
+
+const (
+	memberInvitePurpose = "member_invite"
+	// MemberInvitePath is the login UI page embedded in invitation emails.
+	MemberInvitePath   = "/invite"
+	maxMemberInviteTTL = 30 * 24 * time.Hour
+)
+
+// InvitationInput invites an email address to join the org with a role.
+type InvitationInput struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+	// ExpiresIn is the link lifetime in seconds; zero uses MEMBER_INVITE_TTL.
+	ExpiresIn int `json:"expires_in"`
+}
+
+// InvitationInfo is the admin view of a pending invitation.
+type InvitationInfo struct {
+	ID        int64      `json:"id,string"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	InvitedBy int64      `json:"invited_by,string,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Expired   bool       `json:"expired"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// InvitationPreview is what the invitee sees before accepting.
+type InvitationPreview struct {
+	Org       string    `json:"org"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// AccountExists tells the UI whether accepting needs a password to
+	// create the account.
+	AccountExists bool `json:"account_exists"`
+}
+
+// AcceptInvitationInput accepts an invitation. Name and Password are only
+// used when the invitee has no account yet.
+type AcceptInvitationInput struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// InvitationAcceptance reports the new membership. New accounts sign in
+// through the regular login flow afterwards.
+type InvitationAcceptance struct {
+	Member      MemberInfo `json:"member"`
+	UserCreated bool       `json:"user_created"`
+}
+
+// ListInvitations returns the org's pending invitations, including expired
+// ones that can still be resent.
+func (s *MemberService) ListInvitations(ctx context.Context, orgID int64) ([]InvitationInfo, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.ListInvitations")
+	defer span.End()
+
+	invitations, err := s.members.ListInvitations(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	now := time.Now()
+	views := make([]InvitationInfo, 0, len(invitations))
+	for _, inv := range invitations {
+		views = append(views, invitationInfo(inv, now))
+	}
+	return views, nil
+}
+
+// InviteMember emails a signed link inviting email to join the org with
+// role. Callers can invite with at most their own role.
+func (s *MemberService) InviteMember(ctx context.Context, caller domain.Member, orgCtx *org.Context, input InvitationInput, issuer string) (InvitationInfo, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.InviteMember")
+	defer span.End()
+
+	if err := s.requireInvitesConfigured(); err != nil {
+		return InvitationInfo{}, err
+	}
+	role, err := parseMemberRole(input.Role)
+	if err != nil {
+		return InvitationInfo{}, err
+	}
+	if err := checkGrant(caller, role); err != nil {
+		return InvitationInfo{}, err
+	}
+	email := normalizeIdentifier(input.Email)
+	if email == "" || !strings.Contains(email, "@") {
+		return InvitationInfo{}, newOAuthError("invalid_request", "email must be an email address.", http.StatusBadRequest)
+	}
+	ttl, err := s.inviteTTL(input.ExpiresIn)
+	if err != nil {
+		return InvitationInfo{}, err
+	}
+
+	if user, err := s.findInvitee(ctx, orgCtx.Org.ID, email); err == nil {
+		if _, err := s.members.Get(ctx, orgCtx.Org.ID, user.ID); err == nil {
+			return InvitationInfo{}, newOAuthError("invalid_request", "The user is already a member.", http.StatusConflict)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			span.RecordError(err)
+			return InvitationInfo{}, err
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		return InvitationInfo{}, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	inv, err := s.members.CreateInvitation(ctx, domain.Member{
+		ID:              s.snowflake.Generate().Int64(),
+		OrgID:           orgCtx.Org.ID,
+		Role:            role,
+		InvitedEmail:    email,
+		InvitedBy:       caller.UserID,
+		InviteExpiresAt: &expiresAt,
+		InviteNonce:     rand.Text(),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return InvitationInfo{}, newOAuthError("invalid_request", "This email already has a pending invitation; resend it instead.", http.StatusConflict)
+		}
+		span.RecordError(err)
+		return InvitationInfo{}, err
+	}
+
+	if err := s.sendInvitation(ctx, orgCtx, inv, issuer); err != nil {
+		span.RecordError(err)
+		// Without the email the invitation is unusable; drop it so the
+		// admin can simply try again.
+		if delErr := s.members.DeleteInvitation(ctx, orgCtx.Org.ID, inv.ID); delErr != nil {
+			s.logger.Warn("failed to drop unsent invitation", zap.Int64("org_id", orgCtx.Org.ID), zap.Int64("invitation_id", inv.ID), zap.Error(delErr))
+		}
+		return InvitationInfo{}, err
+	}
+
+	auditLog(s.logger, "member.invitation.created", "org_id", orgCtx.Org.ID, "invitation_id", inv.ID, "email", email, "role", role, "expires_at", expiresAt, "by", caller.UserID)
+	return invitationInfo(inv, time.Now()), nil
+}
+
+// ResendInvitation emails a new link with a fresh expiry. Links sent
+// earlier stop working.
+func (s *MemberService) ResendInvitation(ctx context.Context, caller domain.Member, orgCtx *org.Context, id int64, expiresIn int, issuer string) (InvitationInfo, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.ResendInvitation")
+	defer span.End()
+
+	if err := s.requireInvitesConfigured(); err != nil {
+		return InvitationInfo{}, err
+	}
+	inv, err := s.getInvitation(ctx, orgCtx.Org.ID, id)
+	if err != nil {
+		return InvitationInfo{}, err
+	}
+	if err := checkGrant(caller, inv.Role); err != nil {
+		return InvitationInfo{}, err
+	}
+	ttl, err := s.inviteTTL(expiresIn)
+	if err != nil {
+		return InvitationInfo{}, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	renewed, err := s.members.RenewInvitation(ctx, orgCtx.Org.ID, id, rand.Text(), expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return InvitationInfo{}, newOAuthError("invalid_request", "Invitation not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return InvitationInfo{}, err
+	}
+	if err := s.sendInvitation(ctx, orgCtx, renewed, issuer); err != nil {
+		span.RecordError(err)
+		return InvitationInfo{}, err
+	}
+
+	auditLog(s.logger, "member.invitation.resent", "org_id", orgCtx.Org.ID, "invitation_id", id, "email", renewed.InvitedEmail, "expires_at", expiresAt, "by", caller.UserID)
+	return invitationInfo(renewed, time.Now()), nil
+}
+
+// RevokeInvitation deletes a pending invitation so its link stops working.
+func (s *MemberService) RevokeInvitation(ctx context.Context, caller domain.Member, orgID, id int64) error {
+	ctx, span := s.startSpan(ctx, "MemberService.RevokeInvitation")
+	defer span.End()
+
+	inv, err := s.getInvitation(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	if err := checkGrant(caller, inv.Role); err != nil {
+		return err
+	}
+	if err := s.members.DeleteInvitation(ctx, orgID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Invitation not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+
+	auditLog(s.logger, "member.invitation.revoked", "org_id", orgID, "invitation_id", id, "email", inv.InvitedEmail, "by", caller.UserID)
+	return nil
+}
+
+// PreviewInvitation checks an invitation link and describes it, so the UI
+// can ask for a password when the invitee has no account yet.
+func (s *MemberService) PreviewInvitation(ctx context.Context, orgCtx *org.Context, token string) (InvitationPreview, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.PreviewInvitation")
+	defer span.End()
+
+	inv, err := s.verifyInvitation(ctx, orgCtx, token)
+	if err != nil {
+		return InvitationPreview{}, err
+	}
+	exists := true
+	if _, err := s.findInvitee(ctx, orgCtx.Org.ID, inv.InvitedEmail); errors.Is(err, pgx.ErrNoRows) {
+		exists = false
+	} else if err != nil {
+		span.RecordError(err)
+		return InvitationPreview{}, err
+	}
+	return InvitationPreview{
+		Org:           orgCtx.Org.Name,
+		Email:         inv.InvitedEmail,
+		Role:          strings.ToLower(inv.Role),
+		ExpiresAt:     *inv.InviteExpiresAt,
+		AccountExists: exists,
+	}, nil
+}
+
+// AcceptInvitation makes the invitee a member. An existing account with the
+// invited email is attached as is. Otherwise an account is created with the
+// given password, even when the org does not allow sign-up, and its email
+// counts as verified because the link was delivered to it.
+func (s *MemberService) AcceptInvitation(ctx context.Context, orgCtx *org.Context, input AcceptInvitationInput) (InvitationAcceptance, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.AcceptInvitation")
+	defer span.End()
+
+	inv, err := s.verifyInvitation(ctx, orgCtx, input.Token)
+	if err != nil {
+		return InvitationAcceptance{}, err
+	}
+
+	created := false
+	user, err := s.findInvitee(ctx, orgCtx.Org.ID, inv.InvitedEmail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if strings.TrimSpace(input.Password) == "" {
+			return InvitationAcceptance{}, newOAuthError("invalid_request", "Password is required to create your account.", http.StatusBadRequest)
+		}
+		hashed, err := pw.Hash(input.Password)
+		if err != nil {
+			span.RecordError(err)
+			return InvitationAcceptance{}, fmt.Errorf("hash password: %w", err)
+		}
+		user, err = s.users.Create(ctx, domain.User{
+			ID:            s.snowflake.Generate().Int64(),
+			OrgID:         orgCtx.Org.ID,
+			Email:         inv.InvitedEmail,
+			EmailVerified: true,
+			PasswordHash:  hashed,
+			Name:          strings.TrimSpace(input.Name),
+			Status:        "ACTIVE",
+		})
+		if err != nil {
+			span.RecordError(err)
+			return InvitationAcceptance{}, fmt.Errorf("create user: %w", err)
+		}
+		created = true
+		auditLog(s.logger, "member.invitation.user_created", "org_id", orgCtx.Org.ID, "invitation_id", inv.ID, "user_id", user.ID)
+	} else if err != nil {
+		span.RecordError(err)
+		return InvitationAcceptance{}, err
+	}
+
+	member, err := s.members.AcceptInvitation(ctx, orgCtx.Org.ID, inv.ID, inv.InviteNonce, user.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return InvitationAcceptance{}, newOAuthError("invalid_grant", "Invitation already used or expired.", http.StatusBadRequest)
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return InvitationAcceptance{}, newOAuthError("invalid_request", "You are already a member of this org.", http.StatusConflict)
+		}
+		span.RecordError(err)
+		return InvitationAcceptance{}, err
+	}
+
+	auditLog(s.logger, "member.invitation.accepted", "org_id", orgCtx.Org.ID, "invitation_id", inv.ID, "user_id", user.ID, "role", member.Role, "user_created", created)
+	return InvitationAcceptance{Member: memberInfo(member), UserCreated: created}, nil
+}
+
+// verifyInvitation returns the pending invitation a link points at, as long
+// as the link is the latest one sent and has not expired.
+func (s *MemberService) verifyInvitation(ctx context.Context, orgCtx *org.Context, token string) (domain.Member, error) {
+	if !s.linkSigner.Enabled() {
+		return domain.Member{}, newOAuthError("server_error", "Invitations are not configured.", http.StatusInternalServerError)
+	}
+	claims, err := s.linkSigner.Verify(token, memberInvitePurpose, time.Now())
+	if err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return domain.Member{}, newOAuthError("invalid_grant", "Invitation expired. Ask for a new one.", http.StatusBadRequest)
+		}
+		return domain.Member{}, newOAuthError("invalid_grant", "Invitation is invalid.", http.StatusBadRequest)
+	}
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || claims.OrgID != orgCtx.Org.ID {
+		return domain.Member{}, newOAuthError("invalid_grant", "Invitation is invalid.", http.StatusBadRequest)
+	}
+
+	inv, err := s.members.GetInvitation(ctx, orgCtx.Org.ID, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Member{}, newOAuthError("invalid_grant", "Invitation already used or revoked.", http.StatusBadRequest)
+		}
+		return domain.Member{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.ID), []byte(inv.InviteNonce)) != 1 {
+		return domain.Member{}, newOAuthError("invalid_grant", "This invitation link was replaced by a newer one.", http.StatusBadRequest)
+	}
+	if inv.InviteExpiresAt == nil || !time.Now().Before(*inv.InviteExpiresAt) {
+		return domain.Member{}, newOAuthError("invalid_grant", "Invitation expired. Ask for a new one.", http.StatusBadRequest)
+	}
+	return inv, nil
+}
+
+func (s *MemberService) sendInvitation(ctx context.Context, orgCtx *org.Context, inv domain.Member, issuer string) error {
+	if strings.TrimSpace(issuer) == "" {
+		return newOAuthError("invalid_request", "Issuer is required.", http.StatusBadRequest)
+	}
+	token, err := s.linkSigner.Sign(signedtoken.Claims{
+		Purpose:   memberInvitePurpose,
+		OrgID:     orgCtx.Org.ID,
+		Subject:   strconv.FormatInt(inv.ID, 10),
+		ID:        inv.InviteNonce,
+		ExpiresAt: inv.InviteExpiresAt.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("sign invitation: %w", err)
+	}
+
+	orgName := coalesce(orgCtx.Org.Name, "an organization")
+	target := strings.TrimRight(issuer, "/") + MemberInvitePath + "?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      inv.InvitedEmail,
+		Subject: fmt.Sprintf("You're invited to join %s", orgName),
+		Text: fmt.Sprintf("You have been invited to join %s as %s. Open the link below to accept. It expires on %s.\n\n%s\n\n"+
+			"If you were not expecting this invitation you can ignore this email.\n",
+			orgName, strings.ToLower(inv.Role), inv.InviteExpiresAt.UTC().Format(time.RFC1123), target),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send invitation: %w", err)
+	}
+	return nil
+}
+
+// findInvitee returns the account an invitation to email is for: the org's
+// own user, or else a verified account signed up at another org, which then
+// joins this org as a member while still signing in at its own org.
+func (s *MemberService) findInvitee(ctx context.Context, orgID int64, email string) (domain.User, error) {
+	user, err := s.users.GetByEmail(ctx, orgID, email)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return user, err
+	}
+	return s.users.GetVerifiedByEmail(ctx, email)
+}
+
+func (s *MemberService) getInvitation(ctx context.Context, orgID, id int64) (domain.Member, error) {
+	inv, err := s.members.GetInvitation(ctx, orgID, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Member{}, newOAuthError("invalid_request", "Invitation not found.", http.StatusNotFound)
+		}
+		return domain.Member{}, err
+	}
+	return inv, nil
+}
+
+// inviteTTL turns a requested lifetime in seconds into the link lifetime.
+func (s *MemberService) inviteTTL(expiresIn int) (time.Duration, error) {
+	if expiresIn == 0 {
+		if s.cfg.MemberInviteTTL > 0 {
+			return s.cfg.MemberInviteTTL, nil
+		}
+		return 7 * 24 * time.Hour, nil
+	}
+	ttl := time.Duration(expiresIn) * time.Second
+	if ttl < time.Hour || ttl > maxMemberInviteTTL {
+		return 0, newOAuthError("invalid_request", "expires_in must be between 1 hour and 30 days.", http.StatusBadRequest)
+	}
+	return ttl, nil
+}
+
+func (s *MemberService) requireInvitesConfigured() error {
+	if s.mailer == nil || !s.linkSigner.Enabled() {
+		return newOAuthError("server_error", "Invitations are not configured.", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func invitationInfo(inv domain.Member, now time.Time) InvitationInfo {
+	return InvitationInfo{
+		ID:        inv.ID,
+		Email:     inv.InvitedEmail,
+		Role:      strings.ToLower(inv.Role),
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: inv.InviteExpiresAt,
+		Expired:   inv.InviteExpiresAt == nil || !now.Before(*inv.InviteExpiresAt),
+		CreatedAt: inv.CreatedAt,
+		UpdatedAt: inv.UpdatedAt,
+	}
+}
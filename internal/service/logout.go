@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+// backchannelLogoutTimeout bounds a single logout token delivery.
+const backchannelLogoutTimeout = 5 * time.Second
+
+// backchannelRetryDelays are the waits before each retry of a failed
+// back-channel logout delivery.
+var backchannelRetryDelays = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+const (
+	// backchannelLogoutBatch bounds the deliveries one worker pass claims.
+	backchannelLogoutBatch = 50
+	// backchannelLogoutLease is how long a claimed delivery stays hidden
+	// from other workers; one interrupted by a restart is retried after it.
+	backchannelLogoutLease = time.Minute
+)
+
+var backchannelHTTPClient = &http.Client{
+	Timeout: backchannelLogoutTimeout,
+	// Logout tokens go to the registered URI only.
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// LogoutRequest describes a sign-out from the browser, optionally started by
+// a client (OIDC RP-Initiated Logout).
+type LogoutRequest struct {
+	SessionToken          string
+	RefreshToken          string
+	IDTokenHint           string
+	ClientID              string
+	PostLogoutRedirectURI string
+	Issuer                string
+}
+
+// Logout ends the browser session and revokes the refresh token the browser
+// holds. The signed-out user's tokens for the requesting client and for
+// every client with a back-channel logout URI are revoked, and those clients
+// receive a logout token. It returns the validated post_logout_redirect_uri,
+// or "" when none was requested.
+func (s *AuthService) Logout(ctx context.Context, orgCtx *org.Context, req LogoutRequest) (string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.Logout")
+	defer span.End()
+
+	if orgCtx == nil {
+		return "", newOAuthError("invalid_request", "Org context missing.", http.StatusBadRequest)
+	}
+	orgID := orgCtx.Org.ID
+
+	clientID := strings.TrimSpace(req.ClientID)
+	var hintUserID int64
+	var hintAudience []string
+	if hint := strings.TrimSpace(req.IDTokenHint); hint != "" {
+		claims, err := s.jwt.VerifyTokenHint(ctx, orgID, hint, req.Issuer, clientID)
+		if err != nil {
+			return "", newOAuthError("invalid_request", "id_token_hint is invalid.", http.StatusBadRequest)
+		}
+		hintUserID, _ = strconv.ParseInt(claims.Subject, 10, 64)
+		hintAudience = claims.Audience
+	}
+
+	client, err := s.logoutClient(ctx, orgID, clientID, hintAudience)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	redirectURI := strings.TrimSpace(req.PostLogoutRedirectURI)
+	if redirectURI != "" {
+		if client == nil {
+			return "", newOAuthError("invalid_request", "post_logout_redirect_uri requires client_id or id_token_hint.", http.StatusBadRequest)
+		}
+		if !slices.Contains(client.PostLogoutRedirectURIs, redirectURI) {
+			return "", newOAuthError("invalid_request", "post_logout_redirect_uri is not registered for this client.", http.StatusBadRequest)
+		}
+	}
+
+	// A hint for another user leaves this browser's session alone.
+	session, err := s.endSession(ctx, orgCtx, req.SessionToken, hintUserID)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	if refresh := strings.TrimSpace(req.RefreshToken); refresh != "" {
+		token, err := s.tokens.GetByRefreshToken(ctx, orgID, refresh)
+		if err == nil && !token.Revoked && (session == nil || token.UserID == session.UserID) {
+			if err := s.tokens.RevokeToken(ctx, token.ID); err != nil {
+				span.RecordError(err)
+				return "", err
+			}
+		}
+	}
+	if session != nil {
+		if err := s.logoutClients(ctx, orgCtx, session.UserID, client, req.Issuer); err != nil {
+			span.RecordError(err)
+			return "", err
+		}
+		s.audit("session.logout", "org_id", orgID, "user_id", session.UserID, "client_id", clientID)
+	}
+	return redirectURI, nil
+}
+
+// logoutClient resolves the client asking for logout from client_id, or from
+// the id_token_hint audience when it names a registered client.
+func (s *AuthService) logoutClient(ctx context.Context, orgID int64, clientID string, hintAudience []string) (*domain.OAuthClient, error) {
+	explicit := clientID != ""
+	if !explicit {
+		if len(hintAudience) != 1 {
+			return nil, nil
+		}
+		clientID = hintAudience[0]
+	}
+	client, err := s.clients.GetClientByID(ctx, orgID, clientID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("logout load client: %w", err)
+		}
+		if explicit {
+			return nil, newOAuthError("invalid_request", "Unknown client_id for org.", http.StatusBadRequest)
+		}
+		return nil, newOAuthError("invalid_request", "id_token_hint was issued to an unknown client.", http.StatusBadRequest)
+	}
+	return &client, nil
+}
+
+// logoutClients revokes userID's tokens for the requesting client and for
+// clients registered for back-channel logout, then queues a logout token for
+// each of the latter.
+func (s *AuthService) logoutClients(ctx context.Context, orgCtx *org.Context, userID int64, requester *domain.OAuthClient, issuer string) error {
+	orgID := orgCtx.Org.ID
+	clientIDs, err := s.tokens.ListUserClientIDs(ctx, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("logout list clients: %w", err)
+	}
+	if requester != nil && !slices.Contains(clientIDs, requester.ClientID) {
+		clientIDs = append(clientIDs, requester.ClientID)
+	}
+
+	for _, clientID := range clientIDs {
+		client, err := s.clients.GetClientByID(ctx, orgID, clientID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("logout load client: %w", err)
+		}
+		isRequester := requester != nil && requester.ClientID == clientID
+		if client.BackchannelLogoutURI == "" && !isRequester {
+			continue
+		}
+		if err := s.tokens.RevokeUserClientTokens(ctx, orgID, userID, clientID); err != nil {
+			return fmt.Errorf("logout revoke client tokens: %w", err)
+		}
+		if client.BackchannelLogoutURI == "" {
+			continue
+		}
+		if s.backchannelLogouts == nil {
+			s.log().Warn("back-channel logout queue not configured", zap.Int64("org_id", orgID), zap.String("client_id", clientID))
+			continue
+		}
+		now := time.Now().UTC()
+		if err := s.backchannelLogouts.Enqueue(ctx, domain.BackchannelLogout{
+			ID:            s.snowflake.Generate().Int64(),
+			OrgID:         orgID,
+			ClientID:      clientID,
+			UserID:        userID,
+			Issuer:        issuer,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}); err != nil {
+			return fmt.Errorf("logout queue delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeliverBackchannelLogouts makes one attempt at each due back-channel
+// logout delivery.
+func (s *AuthService) DeliverBackchannelLogouts(ctx context.Context) error {
+	ctx, span := s.startSpan(ctx, "AuthService.DeliverBackchannelLogouts")
+	defer span.End()
+
+	if s.backchannelLogouts == nil {
+		return nil
+	}
+	due, err := s.backchannelLogouts.ClaimDue(ctx, time.Now().UTC(), backchannelLogoutLease, backchannelLogoutBatch)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	for _, delivery := range due {
+		if err := s.deliverBackchannelLogout(ctx, delivery); err != nil {
+			// The lease runs out and the delivery is claimed again.
+			s.log().Warn("back-channel logout attempt", zap.Int64("org_id", delivery.OrgID), zap.String("client_id", delivery.ClientID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// RunBackchannelLogoutWorker calls DeliverBackchannelLogouts every interval
+// until ctx is done.
+func (s *AuthService) RunBackchannelLogoutWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.DeliverBackchannelLogouts(ctx); err != nil && ctx.Err() == nil {
+			s.log().Error("deliver back-channel logouts", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverBackchannelLogout posts a freshly signed logout token. Network
+// failures and server errors are rescheduled with backchannelRetryDelays;
+// anything else ends the delivery.
+func (s *AuthService) deliverBackchannelLogout(ctx context.Context, delivery domain.BackchannelLogout) error {
+	client, err := s.clients.GetClientByID(ctx, delivery.OrgID, delivery.ClientID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && client.BackchannelLogoutURI == "") {
+		return s.backchannelLogouts.Delete(ctx, delivery.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("load client: %w", err)
+	}
+	logoutToken, err := s.jwt.GenerateLogoutToken(ctx, domain.Org{ID: delivery.OrgID}, strconv.FormatInt(delivery.UserID, 10), delivery.ClientID, delivery.Issuer)
+	if err != nil {
+		return fmt.Errorf("logout token: %w", err)
+	}
+
+	attempts := delivery.Attempts + 1
+	retry, err := postLogoutToken(ctx, client.BackchannelLogoutURI, logoutToken)
+	if err == nil {
+		s.audit("logout.backchannel_delivered", "org_id", delivery.OrgID, "client_id", delivery.ClientID, "attempts", attempts)
+		return s.backchannelLogouts.Delete(ctx, delivery.ID)
+	}
+	if !retry || delivery.Attempts >= len(backchannelRetryDelays) {
+		s.log().Warn("back-channel logout failed", zap.Int64("org_id", delivery.OrgID), zap.String("client_id", delivery.ClientID), zap.Int("attempts", attempts), zap.Error(err))
+		s.audit("logout.backchannel_failed", "org_id", delivery.OrgID, "client_id", delivery.ClientID, "attempts", attempts)
+		return s.backchannelLogouts.Delete(ctx, delivery.ID)
+	}
+	return s.backchannelLogouts.Reschedule(ctx, delivery.ID, attempts, time.Now().UTC().Add(backchannelRetryDelays[delivery.Attempts]))
+}
+
+// postLogoutToken sends one logout token. retry reports whether a failure
+// may succeed later: network errors and 5xx responses are retried, while
+// other statuses mean the client rejected the token.
+func postLogoutToken(ctx context.Context, uri, logoutToken string) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, backchannelLogoutTimeout)
+	defer cancel()
+
+	body := url.Values{"logout_token": {logoutToken}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("build logout request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := backchannelHTTPClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("post logout token: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode < 500:
+		return false, fmt.Errorf("logout token rejected with status %d", resp.StatusCode)
+	default:
+		return true, fmt.Errorf("logout endpoint returned status %d", resp.StatusCode)
+	}
+}
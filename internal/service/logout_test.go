@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestLogoutNotifiesBackchannelClients(t *testing.T) {
+	ctx := context.Background()
+	logoutTokens := make(chan string, 2)
+	rpDown := true
+	rp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rpDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		logoutTokens <- r.PostFormValue("logout_token")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer rp.Close()
+
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant"}
+	tokenRepo := &memoryTokenRepo{lastToken: domain.OAuthToken{ID: 1, OrgID: 1, UserID: user.ID, ClientID: "app", RefreshToken: "refresh"}}
+	clients := &logoutClientRepo{client: domain.OAuthClient{
+		ClientID:               "app",
+		PostLogoutRedirectURIs: []string{"https://app/signed-out"},
+		BackchannelLogoutURI:   rp.URL,
+	}}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyRepo := &memoryKeyRepo{}
+	keyManager := jwt.NewKeyManager(keyRepo)
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	queue := &memoryBackchannelLogouts{items: map[int64]domain.BackchannelLogout{}}
+	deps := service.AuthDeps{
+		Users:              &memoryUserRepo{user: user},
+		Tokens:             tokenRepo,
+		Codes:              &memoryCodeRepo{},
+		Clients:            clients,
+		Sessions:           &memorySessionRepo{items: map[int64]domain.Session{}},
+		SessionStore:       &memorySessionStore{items: map[string]domain.Session{}},
+		BackchannelLogouts: queue,
+		Snowflake:          node,
+		Generator:          generator,
+		Keys:               keyManager,
+		Config:             cfg,
+		Logger:             zap.NewNop(),
+	}
+	authService, err := service.NewAuthService(deps)
+	require.NoError(t, err)
+	orgCtx := &org.Context{Org: domain.Org{ID: 1, Name: "Tenant A"}}
+	issuer := "https://tenant.example"
+
+	sessionToken, _, err := authService.CreateSession(ctx, orgCtx, user.ID, []string{"password"}, "Laptop", "203.0.113.1")
+	require.NoError(t, err)
+
+	_, err = authService.Logout(ctx, orgCtx, service.LogoutRequest{
+		SessionToken:          sessionToken,
+		ClientID:              "app",
+		PostLogoutRedirectURI: "https://evil/signed-out",
+		Issuer:                issuer,
+	})
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "invalid_request", oauthErr.Code)
+	session, err := authService.ResolveSession(ctx, orgCtx, sessionToken)
+	require.NoError(t, err)
+	require.NotNil(t, session, "a rejected logout keeps the session")
+
+	redirect, err := authService.Logout(ctx, orgCtx, service.LogoutRequest{
+		SessionToken:          sessionToken,
+		RefreshToken:          "refresh",
+		ClientID:              "app",
+		PostLogoutRedirectURI: "https://app/signed-out",
+		Issuer:                issuer,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://app/signed-out", redirect)
+	session, err = authService.ResolveSession(ctx, orgCtx, sessionToken)
+	require.NoError(t, err)
+	require.Nil(t, session)
+	require.True(t, tokenRepo.lastToken.Revoked, "the client's refresh tokens are revoked")
+
+	require.NoError(t, authService.DeliverBackchannelLogouts(ctx))
+	require.Len(t, queue.items, 1, "a failed delivery stays queued")
+	for id, delivery := range queue.items {
+		require.Equal(t, 1, delivery.Attempts)
+		delivery.NextAttemptAt = time.Now().Add(-time.Second)
+		queue.items[id] = delivery
+	}
+	rpDown = false
+	restarted, err := service.NewAuthService(deps)
+	require.NoError(t, err)
+	require.NoError(t, restarted.DeliverBackchannelLogouts(ctx), "retries survive a restart")
+	require.Empty(t, queue.items)
+
+	var logoutToken string
+	select {
+	case logoutToken = <-logoutTokens:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logout token was not delivered")
+	}
+	key, err := keyManager.ActiveKey(ctx, orgCtx.Org.ID)
+	require.NoError(t, err)
+	parsed, err := gojwt.ParseSigned(logoutToken, []gojose.SignatureAlgorithm{gojose.SignatureAlgorithm(key.Algorithm)})
+	require.NoError(t, err)
+	var std gojwt.Claims
+	var custom struct {
+		Events map[string]any `json:"events"`
+	}
+	require.NoError(t, parsed.Claims(key.Secret, &std, &custom))
+	require.NoError(t, std.Validate(gojwt.Expected{Issuer: issuer, AnyAudience: gojwt.Audience{"app"}, Subject: "10"}))
+	require.Contains(t, custom.Events, "http://schemas.openid.net/event/backchannel-logout")
+	require.NotEmpty(t, std.ID)
+}
+
+func TestLogoutRequiresIDTokenHints(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant"}
+	clients := &logoutClientRepo{client: domain.OAuthClient{ClientID: "app", PostLogoutRedirectURIs: []string{"https://app/signed-out"}}}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &memoryUserRepo{user: user},
+		Tokens:    &memoryTokenRepo{},
+		Clients:   clients,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	org1 := domain.Org{ID: 1, Name: "Tenant A"}
+	orgCtx := &org.Context{Org: org1}
+	issuer := "https://tenant.example"
+
+	accessToken, err := generator.GenerateAccessToken(ctx, org1, user, "openid", issuer, nil)
+	require.NoError(t, err)
+	idToken, err := generator.GenerateIDToken(ctx, org1, user, "app", "openid", issuer, []string{"password"}, time.Now())
+	require.NoError(t, err)
+
+	var oauthErr *service.OAuthError
+	_, err = authService.Logout(ctx, orgCtx, service.LogoutRequest{IDTokenHint: accessToken, ClientID: "app", Issuer: issuer})
+	require.ErrorAs(t, err, &oauthErr, "access tokens are not ID tokens")
+	_, err = authService.Logout(ctx, orgCtx, service.LogoutRequest{IDTokenHint: idToken, ClientID: "other", Issuer: issuer})
+	require.ErrorAs(t, err, &oauthErr, "the hint must be audienced to client_id")
+
+	redirect, err := authService.Logout(ctx, orgCtx, service.LogoutRequest{IDTokenHint: idToken, PostLogoutRedirectURI: "https://app/signed-out", Issuer: issuer})
+	require.NoError(t, err)
+	require.Equal(t, "https://app/signed-out", redirect, "the hint's audience names the client")
+}
+
+type memoryBackchannelLogouts struct {
+	items map[int64]domain.BackchannelLogout
+}
+
+func (m *memoryBackchannelLogouts) Enqueue(ctx context.Context, delivery domain.BackchannelLogout) error {
+	m.items[delivery.ID] = delivery
+	return nil
+}
+
+func (m *memoryBackchannelLogouts) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.BackchannelLogout, error) {
+	var due []domain.BackchannelLogout
+	for id, delivery := range m.items {
+		if len(due) < limit && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = now.Add(lease)
+			m.items[id] = delivery
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryBackchannelLogouts) Reschedule(ctx context.Context, id int64, attempts int, next time.Time) error {
+	delivery := m.items[id]
+	delivery.Attempts = attempts
+	delivery.NextAttemptAt = next
+	m.items[id] = delivery
+	return nil
+}
+
+func (m *memoryBackchannelLogouts) Delete(ctx context.Context, id int64) error {
+	delete(m.items, id)
+	return nil
+}
+
+type logoutClientRepo struct {
+	memoryClientRepo
+	client domain.OAuthClient
+}
+
+func (r *logoutClientRepo) GetClientByID(ctx context.Context, orgID int64, clientID string) (domain.OAuthClient, error) {
+	client := r.client
+	client.OrgID = orgID
+	return client, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/signedtoken"
+)
+
+const (
+	magicLinkPrefix  = "magiclink:"
+	magicLinkPurpose = "magic_link"
+	// MagicLinkVerifyPath is the endpoint embedded in emailed sign-in links.
+	MagicLinkVerifyPath = "/auth/magic-link/verify"
+)
+
+// RequestMagicLink is the REST counterpart of SendMagicLink.
+func (s *AuthService) RequestMagicLink(ctx context.Context, orgID int64, email, clientID, scope, issuer, authorizeState string) (string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.RequestMagicLink")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, clientID)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	effectiveIssuer := strings.TrimSpace(issuer)
+	if effectiveIssuer == "" {
+		effectiveIssuer = orgIssuer(orgCtx)
+	}
+	nonce, err := s.SendMagicLink(ctx, orgCtx, email, coalesce(scope, defaultRESTScope), effectiveIssuer, authorizeState)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return nonce, err
+}
+
+// SendMagicLink emails a single-use sign-in link pointing at issuer and
+// returns the device nonce the caller must hand to the requesting browser.
+// Unknown addresses receive no email but still yield a nonce, so the response
+// does not reveal which accounts exist.
+func (s *AuthService) SendMagicLink(ctx context.Context, orgCtx *org.Context, email, scope, issuer, authorizeState string) (string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.SendMagicLink")
+	defer span.End()
+
+	if err := s.requireMagicLinkConfigured(); err != nil {
+		return "", err
+	}
+	if !authProviderEnabled(orgCtx, domain.AuthProviderMagicLink) {
+		return "", newOAuthError("unsupported_grant_type", "Magic-link login disabled for org.", http.StatusBadRequest)
+	}
+	normalized := normalizeIdentifier(email)
+	if normalized == "" {
+		return "", newOAuthError("invalid_request", "Email is required.", http.StatusBadRequest)
+	}
+	if strings.TrimSpace(issuer) == "" {
+		return "", newOAuthError("invalid_request", "Issuer is required.", http.StatusBadRequest)
+	}
+
+	nonce := randomString(32)
+	user, err := s.users.GetByEmail(ctx, orgCtx.Org.ID, normalized)
+	if err != nil {
+		span.RecordError(err)
+		s.audit("magic_link.request.unknown_user", "org_id", orgCtx.Org.ID, "email", normalized)
+		return nonce, nil
+	}
+
+	now := time.Now()
+	link := domain.MagicLink{
+		ID:             randomString(16),
+		OrgID:          orgCtx.Org.ID,
+		UserID:         user.ID,
+		ClientID:       orgCtx.ClientID,
+		Scope:          scope,
+		Issuer:         issuer,
+		AuthorizeState: strings.TrimSpace(authorizeState),
+		NonceHash:      hashMagicLinkNonce(nonce),
+		CreatedAt:      now,
+	}
+	token, err := s.linkSigner.Sign(signedtoken.Claims{
+		Purpose:   magicLinkPurpose,
+		OrgID:     link.OrgID,
+		Subject:   strconv.FormatInt(user.ID, 10),
+		ID:        link.ID,
+		ExpiresAt: now.Add(s.magicLinkTTL()).Unix(),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("sign magic link: %w", err)
+	}
+	if err := s.links.SaveLink(ctx, magicLinkPrefix+link.ID, link, s.magicLinkTTL()); err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	target := strings.TrimRight(issuer, "/") + MagicLinkVerifyPath + "?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Sign in to %s", coalesce(orgCtx.Org.Name, "your account")),
+		Text: fmt.Sprintf("Use the link below to sign in. It expires in %d minutes and can be used once, "+
+			"from the browser where you requested it.\n\n%s\n\nIf you did not request this email you can ignore it.\n",
+			int(s.magicLinkTTL().Minutes()), target),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("send magic link: %w", err)
+	}
+
+	s.audit("magic_link.request.sent", "org_id", orgCtx.Org.ID, "user_id", user.ID)
+	return nonce, nil
+}
+
+// VerifyMagicLink is the REST counterpart of MagicLinkGrant. It also returns
+// the authorize state recorded with the link so the caller can resume the
+// OAuth flow, including when an MFA challenge is still pending.
+func (s *AuthService) VerifyMagicLink(ctx context.Context, orgID int64, token, nonce string) (AuthTokensWithUser, string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.VerifyMagicLink")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, "")
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, "", err
+	}
+
+	tokenResp, user, link, err := s.magicLinkLogin(ctx, orgCtx, token, nonce)
+	authorizeState := ""
+	if link != nil {
+		authorizeState = link.AuthorizeState
+	}
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, authorizeState, err
+	}
+
+	s.audit("rest.magic_link_login.success", "org_id", orgID, "user_id", user.ID)
+	return newAuthTokensWithUser(user, tokenResp), authorizeState, nil
+}
+
+// MagicLinkGrant redeems a signed sign-in link. The nonce must match the one
+// issued to the requesting browser, and the link is consumed only once that
+// check passes so mail scanners following the URL cannot burn it.
+func (s *AuthService) MagicLinkGrant(ctx context.Context, orgCtx *org.Context, token, nonce string) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.MagicLinkGrant")
+	defer span.End()
+
+	resp, _, _, err := s.magicLinkLogin(ctx, orgCtx, token, nonce)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
+}
+
+func (s *AuthService) magicLinkLogin(ctx context.Context, orgCtx *org.Context, token, nonce string) (*TokenResponse, domain.User, *domain.MagicLink, error) {
+	if err := s.requireMagicLinkConfigured(); err != nil {
+		return nil, domain.User{}, nil, err
+	}
+	if !authProviderEnabled(orgCtx, domain.AuthProviderMagicLink) {
+		return nil, domain.User{}, nil, newOAuthError("unsupported_grant_type", "Magic-link login disabled for org.", http.StatusBadRequest)
+	}
+
+	claims, err := s.linkSigner.Verify(token, magicLinkPurpose, time.Now())
+	if err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return nil, domain.User{}, nil, newOAuthError("invalid_grant", "Sign-in link expired.", http.StatusBadRequest)
+		}
+		return nil, domain.User{}, nil, newOAuthError("invalid_grant", "Sign-in link is invalid.", http.StatusBadRequest)
+	}
+	if claims.OrgID != orgCtx.Org.ID {
+		return nil, domain.User{}, nil, newOAuthError("invalid_grant", "Sign-in link is invalid.", http.StatusBadRequest)
+	}
+
+	key := magicLinkPrefix + claims.ID
+	link, err := s.links.GetLink(ctx, key)
+	if err != nil {
+		return nil, domain.User{}, nil, err
+	}
+	if link == nil || link.OrgID != orgCtx.Org.ID || strconv.FormatInt(link.UserID, 10) != claims.Subject {
+		return nil, domain.User{}, nil, newOAuthError("invalid_grant", "Sign-in link already used or expired.", http.StatusBadRequest)
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(hashMagicLinkNonce(nonce)), []byte(link.NonceHash)) != 1 {
+		s.audit("magic_link.verify.device_mismatch", "org_id", orgCtx.Org.ID, "user_id", link.UserID)
+		return nil, domain.User{}, nil, newOAuthError("invalid_grant", "Open the sign-in link in the browser where you requested it.", http.StatusBadRequest)
+	}
+	consumed, err := s.links.ConsumeLink(ctx, key)
+	if err != nil {
+		return nil, domain.User{}, nil, err
+	}
+	if !consumed {
+		return nil, domain.User{}, nil, newOAuthError("invalid_grant", "Sign-in link already used or expired.", http.StatusBadRequest)
+	}
+
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, link.UserID)
+	if err != nil {
+		return nil, domain.User{}, link, fmt.Errorf("load user: %w", err)
+	}
+
+	// Opening the emailed link proves control of the address.
+	if !user.EmailVerified {
+		if err := s.users.MarkEmailVerified(ctx, orgCtx.Org.ID, user.ID); err != nil {
+			return nil, user, link, err
+		}
+		user.EmailVerified = true
+	}
+
+	orgCtx.ClientID = link.ClientID
+	providers := []string{domain.AuthProviderMagicLink}
+	if err := s.beginMFA(ctx, orgCtx, user, link.Scope, link.Issuer, providers); err != nil {
+		return nil, user, link, err
+	}
+	resp, err := s.issueTokens(ctx, orgCtx, user, link.Scope, link.Issuer, providers)
+	if err != nil {
+		return nil, user, link, err
+	}
+	s.audit("magic_link.login.success", "org_id", orgCtx.Org.ID, "user_id", user.ID)
+	return resp, user, link, nil
+}
+
+func (s *AuthService) requireMagicLinkConfigured() error {
+	if s.links == nil || s.mailer == nil || !s.linkSigner.Enabled() {
+		return newOAuthError("server_error", "Magic links are not configured.", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (s *AuthService) magicLinkTTL() time.Duration {
+	if s.cfg.MagicLinkTTL > 0 {
+		return s.cfg.MagicLinkTTL
+	}
+	return 15 * time.Minute
+}
+
+func hashMagicLinkNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+var magicLinkPattern = regexp.MustCompile(`https://\S+`)
+
+func TestMagicLinkIsDeviceBoundAndSingleUse(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User"}
+	links := &memoryMagicLinkStore{items: map[string]domain.MagicLink{}}
+	outbox := &memoryMailer{}
+
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, LinkSigningKey: "test-key", MagicLinkTTL: time.Minute}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &memoryUserRepo{user: user},
+		Tokens:    &memoryTokenRepo{},
+		Codes:     &memoryCodeRepo{},
+		Clients:   &memoryClientRepo{},
+		Links:     links,
+		Mailer:    outbox,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Org:           domain.Org{ID: 1, Name: "Tenant A"},
+		AuthProviders: []domain.AuthProvider{{ProviderType: domain.AuthProviderMagicLink, IsActive: true}},
+	}
+
+	nonce, err := authService.SendMagicLink(ctx, orgCtx, "User@Tenant", "openid", "https://tenant", "")
+	require.NoError(t, err)
+	require.NotEmpty(t, nonce)
+	require.Len(t, outbox.sent, 1)
+	require.Equal(t, user.Email, outbox.sent[0].To)
+
+	target, err := url.Parse(magicLinkPattern.FindString(outbox.sent[0].Text))
+	require.NoError(t, err)
+	require.Equal(t, service.MagicLinkVerifyPath, target.Path)
+	token := target.Query().Get("token")
+
+	_, err = authService.MagicLinkGrant(ctx, orgCtx, token, "other-browser")
+	require.Error(t, err, "link opened on another device must be rejected")
+
+	resp, err := authService.MagicLinkGrant(ctx, orgCtx, token, nonce)
+	require.NoError(t, err, "a rejected attempt must not consume the link")
+	_, custom, err := authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.Contains(t, custom.Providers, domain.AuthProviderMagicLink)
+
+	_, err = authService.MagicLinkGrant(ctx, orgCtx, token, nonce)
+	require.Error(t, err, "link is single use")
+
+	orgCtx.AuthProviders = []domain.AuthProvider{{ProviderType: "password", IsActive: true}}
+	_, err = authService.SendMagicLink(ctx, orgCtx, user.Email, "openid", "https://tenant", "")
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "unsupported_grant_type", oauthErr.Code)
+}
+
+type memoryMagicLinkStore struct {
+	items map[string]domain.MagicLink
+}
+
+func (m *memoryMagicLinkStore) SaveLink(ctx context.Context, key string, data domain.MagicLink, ttl time.Duration) error {
+	m.items[key] = data
+	return nil
+}
+
+func (m *memoryMagicLinkStore) GetLink(ctx context.Context, key string) (*domain.MagicLink, error) {
+	link, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	return &link, nil
+}
+
+func (m *memoryMagicLinkStore) ConsumeLink(ctx context.Context, key string) (bool, error) {
+	_, ok := m.items[key]
+	delete(m.items, key)
+	return ok, nil
+}
+
+type memoryMailer struct {
+	sent []mailer.Message
+}
+
+func (m *memoryMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/mailer"
+	"github.com/smallbiznis/railzway-auth/internal/rbac"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/signedtoken"
+)
+
+const (
+	defaultMemberPageSize = 50
+	maxMemberPageSize     = 200
+)
+
+// OperatorMember is the caller used for changes made from the CLI by whoever
+// runs the server. It may do anything an owner may.
+var OperatorMember = domain.Member{Role: domain.MemberRoleOwner, Status: domain.MemberStatusActive}
+
+// MemberService manages who administers an org and with which role, and the
+// invitations that bring new members in.
+type MemberService struct {
+	members    repository.MembershipRepository
+	users      repository.UserRepository
+	mailer     mailer.Mailer
+	linkSigner *signedtoken.Signer
+	snowflake  *snowflake.Node
+	cfg        config.Config
+	logger     *zap.Logger
+	tracer     trace.Tracer
+}
+
+// NewMemberService wires dependencies.
+func NewMemberService(members repository.MembershipRepository, users repository.UserRepository, mail mailer.Mailer, snowflake *snowflake.Node, cfg config.Config, logger *zap.Logger) *MemberService {
+	if logger == nil {
+		logger = zap.L()
+	}
+	return &MemberService{
+		members:    members,
+		users:      users,
+		mailer:     mail,
+		linkSigner: signedtoken.New(cfg.LinkSigningKey),
+		snowflake:  snowflake,
+		cfg:        cfg,
+		logger:     logger,
+		tracer:     otel.Tracer("github.com/smallbiznis/railzway-auth/internal/service"),
+	}
+}
+
+// MemberInfo is the admin view of a member.
+type MemberInfo struct {
+	UserID      int64      `json:"user_id,string"`
+	Email       string     `json:"email"`
+	Name        string     `json:"name,omitempty"`
+	Role        string     `json:"role"`
+	Status      string     `json:"status"`
+	Permissions []string   `json:"permissions"`
+	JoinedAt    *time.Time `json:"joined_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MemberPage is one page of ListMembers results.
+type MemberPage struct {
+	Members []MemberInfo `json:"members"`
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// MemberInput adds an existing user of the org as a member.
+type MemberInput struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// MemberUpdate changes the fields that are set.
+type MemberUpdate struct {
+	Role   *string `json:"role"`
+	Status *string `json:"status"`
+}
+
+// ListMembers returns the org's members ordered by when they were added.
+func (s *MemberService) ListMembers(ctx context.Context, orgID int64, limit, offset int) (MemberPage, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.ListMembers")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultMemberPageSize
+	}
+	if limit > maxMemberPageSize {
+		limit = maxMemberPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	members, total, err := s.members.List(ctx, orgID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return MemberPage{}, err
+	}
+	page := MemberPage{Members: make([]MemberInfo, 0, len(members)), Total: total, Limit: limit, Offset: offset}
+	for _, m := range members {
+		page.Members = append(page.Members, memberInfo(m))
+	}
+	return page, nil
+}
+
+// AddMember gives an existing user of the org a role. Callers can grant at
+// most their own role.
+func (s *MemberService) AddMember(ctx context.Context, caller domain.Member, orgID int64, input MemberInput) (MemberInfo, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.AddMember")
+	defer span.End()
+
+	role, err := parseMemberRole(input.Role)
+	if err != nil {
+		return MemberInfo{}, err
+	}
+	if err := checkGrant(caller, role); err != nil {
+		return MemberInfo{}, err
+	}
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == "" {
+		return MemberInfo{}, newOAuthError("invalid_request", "email is required.", http.StatusBadRequest)
+	}
+	user, err := s.users.GetByEmail(ctx, orgID, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return MemberInfo{}, newOAuthError("invalid_request", "No user with this email exists in the org.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return MemberInfo{}, err
+	}
+
+	created, err := s.members.Create(ctx, domain.Member{
+		ID:     s.snowflake.Generate().Int64(),
+		OrgID:  orgID,
+		UserID: user.ID,
+		Role:   role,
+		Status: domain.MemberStatusActive,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return MemberInfo{}, newOAuthError("invalid_request", "The user is already a member.", http.StatusConflict)
+		}
+		span.RecordError(err)
+		return MemberInfo{}, err
+	}
+
+	auditLog(s.logger, "member.added", "org_id", orgID, "user_id", user.ID, "role", role, "by", caller.UserID)
+	return memberInfo(created), nil
+}
+
+// UpdateMember changes a member's role or status. Only owners may change
+// owners, and the org always keeps one active owner.
+func (s *MemberService) UpdateMember(ctx context.Context, caller domain.Member, orgID, userID int64, update MemberUpdate) (MemberInfo, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.UpdateMember")
+	defer span.End()
+
+	target, err := s.getMember(ctx, orgID, userID)
+	if err != nil {
+		return MemberInfo{}, err
+	}
+	if err := checkManage(caller, target); err != nil {
+		return MemberInfo{}, err
+	}
+
+	role, status := target.Role, target.Status
+	if update.Role != nil {
+		if role, err = parseMemberRole(*update.Role); err != nil {
+			return MemberInfo{}, err
+		}
+		if err := checkGrant(caller, role); err != nil {
+			return MemberInfo{}, err
+		}
+	}
+	if update.Status != nil {
+		if status, err = parseMemberStatus(*update.Status); err != nil {
+			return MemberInfo{}, err
+		}
+	}
+	if role != domain.MemberRoleOwner || status != domain.MemberStatusActive {
+		if err := s.keepOwner(ctx, target); err != nil {
+			return MemberInfo{}, err
+		}
+	}
+
+	updated, err := s.members.Update(ctx, orgID, userID, role, status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return MemberInfo{}, newOAuthError("invalid_request", "Member not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return MemberInfo{}, err
+	}
+
+	auditLog(s.logger, "member.updated", "org_id", orgID, "user_id", userID, "role", role, "status", status, "by", caller.UserID)
+	return memberInfo(updated), nil
+}
+
+// RemoveMember takes away a member's access to the admin API. The user
+// account itself is kept.
+func (s *MemberService) RemoveMember(ctx context.Context, caller domain.Member, orgID, userID int64) error {
+	ctx, span := s.startSpan(ctx, "MemberService.RemoveMember")
+	defer span.End()
+
+	target, err := s.getMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkManage(caller, target); err != nil {
+		return err
+	}
+	if err := s.keepOwner(ctx, target); err != nil {
+		return err
+	}
+
+	if err := s.members.Delete(ctx, orgID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Member not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+
+	auditLog(s.logger, "member.removed", "org_id", orgID, "user_id", userID, "role", target.Role, "by", caller.UserID)
+	return nil
+}
+
+// Authorize returns the user's membership of the org, or access_denied
+// when the user is not an active member.
+func (s *MemberService) Authorize(ctx context.Context, orgID, userID int64) (domain.Member, error) {
+	ctx, span := s.startSpan(ctx, "MemberService.Authorize")
+	defer span.End()
+
+	m, err := s.members.Get(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Member{}, newOAuthError("access_denied", "Not a member of this org.", http.StatusForbidden)
+		}
+		span.RecordError(err)
+		return domain.Member{}, err
+	}
+	if m.Status != domain.MemberStatusActive {
+		return domain.Member{}, newOAuthError("access_denied", "Membership is not active.", http.StatusForbidden)
+	}
+	return m, nil
+}
+
+// Roles returns the user's roles in the org for the access token roles
+// claim. Users without an active membership have none.
+func (s *MemberService) Roles(ctx context.Context, orgID, userID int64) ([]string, error) {
+	m, err := s.members.Get(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if m.Status != domain.MemberStatusActive {
+		return nil, nil
+	}
+	return []string{strings.ToLower(m.Role)}, nil
+}
+
+func (s *MemberService) getMember(ctx context.Context, orgID, userID int64) (domain.Member, error) {
+	m, err := s.members.Get(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Member{}, newOAuthError("invalid_request", "Member not found.", http.StatusNotFound)
+		}
+		return domain.Member{}, err
+	}
+	return m, nil
+}
+
+// keepOwner refuses to take away the last active owner, which would leave
+// the org without anyone able to manage it.
+func (s *MemberService) keepOwner(ctx context.Context, target domain.Member) error {
+	if target.Role != domain.MemberRoleOwner || target.Status != domain.MemberStatusActive {
+		return nil
+	}
+	owners, err := s.members.CountActiveOwners(ctx, target.OrgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return newOAuthError("invalid_request", "The org must keep at least one active owner.", http.StatusConflict)
+	}
+	return nil
+}
+
+func (s *MemberService) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if s == nil || s.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return s.tracer.Start(ctx, name)
+}
+
+// checkGrant refuses roles above the caller's own.
+func checkGrant(caller domain.Member, role string) error {
+	if !rbac.AtLeast(caller.Role, role) {
+		return newOAuthError("access_denied", "You cannot grant a role above your own.", http.StatusForbidden)
+	}
+	return nil
+}
+
+// checkManage refuses changes to members ranked above the caller, so only
+// owners can change owners.
+func checkManage(caller domain.Member, target domain.Member) error {
+	if !rbac.AtLeast(caller.Role, target.Role) {
+		return newOAuthError("access_denied", "You cannot change a member ranked above you.", http.StatusForbidden)
+	}
+	return nil
+}
+
+func parseMemberRole(role string) (string, error) {
+	role = strings.ToUpper(strings.TrimSpace(role))
+	if !rbac.ValidRole(role) {
+		return "", newOAuthError("invalid_request", "role must be owner, admin, staff or viewer.", http.StatusBadRequest)
+	}
+	return role, nil
+}
+
+// parseMemberStatus accepts the statuses an admin may set; invited is only
+// set by invitations.
+func parseMemberStatus(status string) (string, error) {
+	switch status = strings.ToUpper(strings.TrimSpace(status)); status {
+	case domain.MemberStatusActive, domain.MemberStatusDisabled:
+		return status, nil
+	}
+	return "", newOAuthError("invalid_request", "status must be active or disabled.", http.StatusBadRequest)
+}
+
+func memberInfo(m domain.Member) MemberInfo {
+	email := m.Email
+	if email == "" {
+		email = m.InvitedEmail
+	}
+	info := MemberInfo{
+		UserID:      m.UserID,
+		Email:       email,
+		Name:        m.Name,
+		Role:        strings.ToLower(m.Role),
+		Status:      strings.ToLower(m.Status),
+		Permissions: []string{},
+		JoinedAt:    m.JoinedAt,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	for _, p := range rbac.Permissions(m.Role) {
+		info.Permissions = append(info.Permissions, string(p))
+	}
+	return info
+}
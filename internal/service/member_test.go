@@ -0,0 +1,356 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/rbac"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestMemberRoles(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryMembershipRepo{}
+	members := newTestMemberService(t, repo, domain.User{ID: 10, Email: "new@tenant"})
+	var oauthErr *service.OAuthError
+
+	owner := repo.add(1, domain.MemberRoleOwner)
+	admin := repo.add(2, domain.MemberRoleAdmin)
+
+	_, err := members.AddMember(ctx, admin, 1, service.MemberInput{Email: "new@tenant", Role: "owner"})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 403, oauthErr.Status, "admins cannot grant owner")
+
+	added, err := members.AddMember(ctx, admin, 1, service.MemberInput{Email: "new@tenant", Role: "staff"})
+	require.NoError(t, err)
+	require.Equal(t, "staff", added.Role)
+	require.Equal(t, []string{"config:read", "sessions:manage", "members:read"}, added.Permissions)
+
+	_, err = members.AddMember(ctx, admin, 1, service.MemberInput{Email: "new@tenant", Role: "viewer"})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status)
+
+	err = members.RemoveMember(ctx, admin, 1, owner.UserID)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 403, oauthErr.Status, "only owners can change owners")
+
+	demote := "admin"
+	_, err = members.UpdateMember(ctx, owner, 1, owner.UserID, service.MemberUpdate{Role: &demote})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "the last owner stays")
+
+	promote := "owner"
+	_, err = members.UpdateMember(ctx, owner, 1, admin.UserID, service.MemberUpdate{Role: &promote})
+	require.NoError(t, err)
+	updated, err := members.UpdateMember(ctx, owner, 1, owner.UserID, service.MemberUpdate{Role: &demote})
+	require.NoError(t, err)
+	require.Equal(t, "admin", updated.Role)
+
+	disabled := "disabled"
+	_, err = members.UpdateMember(ctx, service.OperatorMember, 1, added.UserID, service.MemberUpdate{Status: &disabled})
+	require.NoError(t, err)
+	_, err = members.Authorize(ctx, 1, added.UserID)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 403, oauthErr.Status)
+	roles, err := members.Roles(ctx, 1, added.UserID)
+	require.NoError(t, err)
+	require.Empty(t, roles, "disabled members have no roles")
+
+	roles, err = members.Roles(ctx, 1, admin.UserID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"owner"}, roles)
+}
+
+func TestRolePermissions(t *testing.T) {
+	require.True(t, rbac.Can(domain.MemberRoleAdmin, rbac.ConfigManage))
+	require.False(t, rbac.Can(domain.MemberRoleStaff, rbac.ConfigManage))
+	require.True(t, rbac.Can(domain.MemberRoleStaff, rbac.SessionsManage))
+	require.False(t, rbac.Can(domain.MemberRoleViewer, rbac.MembersManage))
+	require.False(t, rbac.Can("ROOT", rbac.ConfigRead))
+	require.True(t, rbac.AtLeast(domain.MemberRoleOwner, domain.MemberRoleAdmin))
+	require.False(t, rbac.AtLeast(domain.MemberRoleStaff, domain.MemberRoleAdmin))
+	require.False(t, rbac.AtLeast("", ""))
+}
+
+func TestMemberInvitations(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryMembershipRepo{}
+	outbox := &memoryMailer{}
+	members := newInvitingMemberService(t, repo, &memoryUserRepo{user: domain.User{ID: 10, Email: "new@tenant"}}, outbox)
+	orgCtx := &org.Context{Org: domain.Org{ID: 1, Name: "Tenant A"}}
+	var oauthErr *service.OAuthError
+
+	owner := repo.add(1, domain.MemberRoleOwner)
+	admin := repo.add(2, domain.MemberRoleAdmin)
+
+	_, err := members.InviteMember(ctx, admin, orgCtx, service.InvitationInput{Email: "new@tenant", Role: "owner"}, "https://tenant-a.example")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 403, oauthErr.Status, "admins cannot invite owners")
+
+	inv, err := members.InviteMember(ctx, owner, orgCtx, service.InvitationInput{Email: "New@Tenant", Role: "staff"}, "https://tenant-a.example")
+	require.NoError(t, err)
+	require.Equal(t, "new@tenant", inv.Email)
+	require.Len(t, outbox.sent, 1)
+	require.Contains(t, outbox.sent[0].Text, "https://tenant-a.example/invite?token=")
+	first := inviteToken(t, outbox.sent[0].Text)
+
+	_, err = members.InviteMember(ctx, owner, orgCtx, service.InvitationInput{Email: "new@tenant", Role: "viewer"}, "https://tenant-a.example")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "one pending invitation per email")
+
+	preview, err := members.PreviewInvitation(ctx, orgCtx, first)
+	require.NoError(t, err)
+	require.Equal(t, "staff", preview.Role)
+	require.True(t, preview.AccountExists)
+
+	_, err = members.ResendInvitation(ctx, owner, orgCtx, inv.ID, 0, "https://tenant-a.example")
+	require.NoError(t, err)
+	require.Len(t, outbox.sent, 2)
+	_, err = members.AcceptInvitation(ctx, orgCtx, service.AcceptInvitationInput{Token: first})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "invalid_grant", oauthErr.Code, "resending replaces the old link")
+
+	_, err = members.AcceptInvitation(ctx, &org.Context{Org: domain.Org{ID: 2}}, service.AcceptInvitationInput{Token: inviteToken(t, outbox.sent[1].Text)})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "invalid_grant", oauthErr.Code, "links are bound to the org")
+
+	accepted, err := members.AcceptInvitation(ctx, orgCtx, service.AcceptInvitationInput{Token: inviteToken(t, outbox.sent[1].Text)})
+	require.NoError(t, err)
+	require.False(t, accepted.UserCreated)
+	require.Equal(t, int64(10), accepted.Member.UserID)
+	require.Equal(t, "staff", accepted.Member.Role)
+	_, err = members.Authorize(ctx, 1, 10)
+	require.NoError(t, err)
+
+	_, err = members.AcceptInvitation(ctx, orgCtx, service.AcceptInvitationInput{Token: inviteToken(t, outbox.sent[1].Text)})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "invalid_grant", oauthErr.Code)
+}
+
+func TestMemberInvitationCreatesAccount(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryMembershipRepo{}
+	outbox := &memoryMailer{}
+	users := &signupUserRepo{}
+	members := newInvitingMemberService(t, repo, users, outbox)
+	// Invitations work even when the org does not allow sign-up.
+	orgCtx := &org.Context{Org: domain.Org{ID: 1, Name: "Tenant A"}, PasswordConfig: domain.PasswordConfig{AllowSignup: false}}
+	var oauthErr *service.OAuthError
+
+	owner := repo.add(1, domain.MemberRoleOwner)
+
+	inv, err := members.InviteMember(ctx, owner, orgCtx, service.InvitationInput{Email: "invitee@tenant", Role: "viewer"}, "https://tenant-a.example")
+	require.NoError(t, err)
+	require.NoError(t, members.RevokeInvitation(ctx, owner, 1, inv.ID))
+	_, err = members.PreviewInvitation(ctx, orgCtx, inviteToken(t, outbox.sent[0].Text))
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "invalid_grant", oauthErr.Code, "revoked links stop working")
+
+	_, err = members.InviteMember(ctx, owner, orgCtx, service.InvitationInput{Email: "invitee@tenant", Role: "viewer"}, "https://tenant-a.example")
+	require.NoError(t, err)
+	token := inviteToken(t, outbox.sent[1].Text)
+
+	preview, err := members.PreviewInvitation(ctx, orgCtx, token)
+	require.NoError(t, err)
+	require.False(t, preview.AccountExists)
+
+	_, err = members.AcceptInvitation(ctx, orgCtx, service.AcceptInvitationInput{Token: token})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 400, oauthErr.Status, "new accounts need a password")
+
+	accepted, err := members.AcceptInvitation(ctx, orgCtx, service.AcceptInvitationInput{Token: token, Name: "Invitee", Password: "Sup3r-secret!"})
+	require.NoError(t, err)
+	require.True(t, accepted.UserCreated)
+	require.Equal(t, "viewer", accepted.Member.Role)
+	require.True(t, users.user.EmailVerified)
+	require.Equal(t, "invitee@tenant", users.user.Email)
+}
+
+func newTestMemberService(t *testing.T, repo repository.MembershipRepository, user domain.User) *service.MemberService {
+	t.Helper()
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+	return service.NewMemberService(repo, &memoryUserRepo{user: user}, nil, node, config.Config{}, zap.NewNop())
+}
+
+func newInvitingMemberService(t *testing.T, repo repository.MembershipRepository, users repository.UserRepository, outbox *memoryMailer) *service.MemberService {
+	t.Helper()
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+	cfg := config.Config{LinkSigningKey: "test-key", MemberInviteTTL: time.Hour}
+	return service.NewMemberService(repo, users, outbox, node, cfg, zap.NewNop())
+}
+
+var inviteTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+func inviteToken(t *testing.T, text string) string {
+	t.Helper()
+	match := inviteTokenPattern.FindStringSubmatch(text)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+// signupUserRepo has no users until one is created.
+type signupUserRepo struct {
+	memoryUserRepo
+	created bool
+}
+
+func (m *signupUserRepo) GetByEmail(ctx context.Context, orgID int64, email string) (domain.User, error) {
+	if !m.created {
+		return domain.User{}, pgx.ErrNoRows
+	}
+	return m.user, nil
+}
+
+func (m *signupUserRepo) GetVerifiedByEmail(ctx context.Context, email string) (domain.User, error) {
+	return m.GetByEmail(ctx, 0, email)
+}
+
+func (m *signupUserRepo) Create(ctx context.Context, user domain.User) (domain.User, error) {
+	m.created = true
+	return m.memoryUserRepo.Create(ctx, user)
+}
+
+type memoryMembershipRepo struct {
+	repository.MembershipRepository
+	members []domain.Member
+}
+
+func (m *memoryMembershipRepo) add(userID int64, role string) domain.Member {
+	member := domain.Member{ID: userID, OrgID: 1, UserID: userID, Role: role, Status: domain.MemberStatusActive}
+	m.members = append(m.members, member)
+	return member
+}
+
+func (m *memoryMembershipRepo) find(orgID, userID int64) int {
+	for i, member := range m.members {
+		if member.OrgID == orgID && member.UserID == userID && member.Status != domain.MemberStatusInvited {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *memoryMembershipRepo) Get(ctx context.Context, orgID, userID int64) (domain.Member, error) {
+	i := m.find(orgID, userID)
+	if i < 0 {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	return m.members[i], nil
+}
+
+func (m *memoryMembershipRepo) ListByUser(ctx context.Context, userID int64) ([]domain.Member, error) {
+	var members []domain.Member
+	for _, member := range m.members {
+		if member.UserID == userID && member.Status == domain.MemberStatusActive {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (m *memoryMembershipRepo) Create(ctx context.Context, member domain.Member) (domain.Member, error) {
+	if m.find(member.OrgID, member.UserID) >= 0 {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	m.members = append(m.members, member)
+	return member, nil
+}
+
+func (m *memoryMembershipRepo) Update(ctx context.Context, orgID, userID int64, role, status string) (domain.Member, error) {
+	i := m.find(orgID, userID)
+	if i < 0 {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	m.members[i].Role, m.members[i].Status = role, status
+	return m.members[i], nil
+}
+
+func (m *memoryMembershipRepo) Delete(ctx context.Context, orgID, userID int64) error {
+	i := m.find(orgID, userID)
+	if i < 0 {
+		return pgx.ErrNoRows
+	}
+	m.members = append(m.members[:i], m.members[i+1:]...)
+	return nil
+}
+
+func (m *memoryMembershipRepo) CountActiveOwners(ctx context.Context, orgID int64) (int, error) {
+	count := 0
+	for _, member := range m.members {
+		if member.OrgID == orgID && member.Role == domain.MemberRoleOwner && member.Status == domain.MemberStatusActive {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memoryMembershipRepo) findInvitation(orgID, id int64) int {
+	for i, member := range m.members {
+		if member.OrgID == orgID && member.ID == id && member.Status == domain.MemberStatusInvited {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *memoryMembershipRepo) CreateInvitation(ctx context.Context, member domain.Member) (domain.Member, error) {
+	for _, existing := range m.members {
+		if existing.OrgID == member.OrgID && existing.Status == domain.MemberStatusInvited && existing.InvitedEmail == member.InvitedEmail {
+			return domain.Member{}, pgx.ErrNoRows
+		}
+	}
+	member.Status = domain.MemberStatusInvited
+	m.members = append(m.members, member)
+	return member, nil
+}
+
+func (m *memoryMembershipRepo) GetInvitation(ctx context.Context, orgID, id int64) (domain.Member, error) {
+	i := m.findInvitation(orgID, id)
+	if i < 0 {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	return m.members[i], nil
+}
+
+func (m *memoryMembershipRepo) RenewInvitation(ctx context.Context, orgID, id int64, nonce string, expiresAt time.Time) (domain.Member, error) {
+	i := m.findInvitation(orgID, id)
+	if i < 0 {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	m.members[i].InviteNonce, m.members[i].InviteExpiresAt = nonce, &expiresAt
+	return m.members[i], nil
+}
+
+func (m *memoryMembershipRepo) DeleteInvitation(ctx context.Context, orgID, id int64) error {
+	i := m.findInvitation(orgID, id)
+	if i < 0 {
+		return pgx.ErrNoRows
+	}
+	m.members = append(m.members[:i], m.members[i+1:]...)
+	return nil
+}
+
+func (m *memoryMembershipRepo) AcceptInvitation(ctx context.Context, orgID, id int64, nonce string, userID int64) (domain.Member, error) {
+	i := m.findInvitation(orgID, id)
+	if i < 0 || m.members[i].InviteNonce != nonce || !time.Now().Before(*m.members[i].InviteExpiresAt) {
+		return domain.Member{}, pgx.ErrNoRows
+	}
+	m.members[i].UserID, m.members[i].Status = userID, domain.MemberStatusActive
+	return m.members[i], nil
+}
@@ -0,0 +1,600 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	basemiddleware "github.com/smallbiznis/railzway-auth/internal/middleware"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/totp"
+)
+
+const (
+	mfaChallengePrefix     = "mfa:challenge:"
+	defaultMFAChallengeTTL = 5 * time.Minute
+	mfaMaxAttempts         = 5
+	mfaProvider            = "mfa"
+	totpSkew               = 1
+	recoveryCodeCount      = 10
+)
+
+// MFARequiredError is returned instead of tokens when the first factor
+// succeeded but the org policy demands a second one.
+type MFARequiredError struct {
+	MFAToken           string
+	EnrollmentRequired bool
+	// Factors lists the second factors the user can answer the challenge with.
+	Factors []string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "mfa_required: Multifactor authentication required."
+}
+
+// TOTPEnrollment carries the shared secret shown to the user during enrollment.
+type TOTPEnrollment struct {
+	Secret      string `json:"secret"`
+	OTPAuthURI  string `json:"otpauth_uri"`
+	Issuer      string `json:"issuer"`
+	AccountName string `json:"account_name"`
+}
+
+// MFAStatus summarizes a user's enrolled factors.
+type MFAStatus struct {
+	Policy                 string `json:"policy"`
+	TOTPEnrolled           bool   `json:"totp_enrolled"`
+	PasskeyEnrolled        bool   `json:"passkey_enrolled"`
+	RecoveryCodesRemaining int    `json:"recovery_codes_remaining"`
+}
+
+// MFAStatus reports the org policy and the user's enrollment state.
+func (s *AuthService) MFAStatus(ctx context.Context, orgCtx *org.Context, userID int64) (MFAStatus, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.MFAStatus")
+	defer span.End()
+
+	if err := s.requireMFAConfigured(); err != nil {
+		return MFAStatus{}, err
+	}
+
+	status := MFAStatus{Policy: mfaPolicy(orgCtx)}
+	factor, err := s.mfa.GetFactor(ctx, orgCtx.Org.ID, userID, domain.MFAFactorTOTP)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		return MFAStatus{}, fmt.Errorf("load mfa factor: %w", err)
+	}
+	status.TOTPEnrolled = err == nil && factor.Confirmed
+
+	if status.PasskeyEnrolled, err = s.hasPasskeys(ctx, orgCtx.Org.ID, userID); err != nil {
+		span.RecordError(err)
+		return MFAStatus{}, err
+	}
+
+	remaining, err := s.mfa.CountRecoveryCodes(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return MFAStatus{}, err
+	}
+	status.RecoveryCodesRemaining = remaining
+	return status, nil
+}
+
+// EnrollTOTP creates (or replaces an unconfirmed) authenticator secret for the user.
+func (s *AuthService) EnrollTOTP(ctx context.Context, orgCtx *org.Context, userID int64) (TOTPEnrollment, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.EnrollTOTP")
+	defer span.End()
+
+	if err := s.requireMFAConfigured(); err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if mfaPolicy(orgCtx) == domain.MFAPolicyOff {
+		return TOTPEnrollment{}, newOAuthError("invalid_request", "MFA is disabled for org.", http.StatusBadRequest)
+	}
+
+	existing, err := s.mfa.GetFactor(ctx, orgCtx.Org.ID, userID, domain.MFAFactorTOTP)
+	if err == nil && existing.Confirmed {
+		return TOTPEnrollment{}, newOAuthError("invalid_request", "Authenticator already enrolled.", http.StatusConflict)
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		return TOTPEnrollment{}, fmt.Errorf("load mfa factor: %w", err)
+	}
+
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return TOTPEnrollment{}, fmt.Errorf("enroll load user: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		span.RecordError(err)
+		return TOTPEnrollment{}, err
+	}
+	sealed, err := s.sealer.Seal([]byte(secret))
+	if err != nil {
+		span.RecordError(err)
+		return TOTPEnrollment{}, fmt.Errorf("seal totp secret: %w", err)
+	}
+
+	if _, err := s.mfa.UpsertFactor(ctx, domain.MFAFactor{
+		ID:     s.snowflake.Generate().Int64(),
+		OrgID:  orgCtx.Org.ID,
+		UserID: userID,
+		Type:   domain.MFAFactorTOTP,
+		Secret: sealed,
+	}); err != nil {
+		span.RecordError(err)
+		return TOTPEnrollment{}, err
+	}
+
+	issuer := orgCtx.Org.Name
+	account := coalesce(user.Email, user.Phone)
+	s.audit("mfa.totp.enroll.started", "org_id", orgCtx.Org.ID, "user_id", userID)
+	return TOTPEnrollment{
+		Secret:      secret,
+		OTPAuthURI:  totp.ProvisioningURI(secret, issuer, account),
+		Issuer:      issuer,
+		AccountName: account,
+	}, nil
+}
+
+// ConfirmTOTP activates a pending authenticator and issues fresh recovery codes.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, orgCtx *org.Context, userID int64, code string) ([]string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.ConfirmTOTP")
+	defer span.End()
+
+	if err := s.requireMFAConfigured(); err != nil {
+		return nil, err
+	}
+	factor, err := s.verifyTOTP(ctx, orgCtx.Org.ID, userID, code, true)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if factor.Confirmed {
+		return nil, newOAuthError("invalid_request", "Authenticator already enrolled.", http.StatusConflict)
+	}
+
+	codes, err := s.issueRecoveryCodes(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	s.audit("mfa.totp.enroll.confirmed", "org_id", orgCtx.Org.ID, "user_id", userID)
+	return codes, nil
+}
+
+// DisableTOTP removes the user's authenticator after verifying a current code.
+func (s *AuthService) DisableTOTP(ctx context.Context, orgCtx *org.Context, userID int64, code string) error {
+	ctx, span := s.startSpan(ctx, "AuthService.DisableTOTP")
+	defer span.End()
+
+	if err := s.requireMFAConfigured(); err != nil {
+		return err
+	}
+	if _, err := s.verifyTOTP(ctx, orgCtx.Org.ID, userID, code, false); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.mfa.DeleteFactor(ctx, orgCtx.Org.ID, userID, domain.MFAFactorTOTP); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.mfa.ReplaceRecoveryCodes(ctx, orgCtx.Org.ID, userID, nil); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	s.audit("mfa.totp.disabled", "org_id", orgCtx.Org.ID, "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes for an enrolled user.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, orgCtx *org.Context, userID int64) ([]string, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.RegenerateRecoveryCodes")
+	defer span.End()
+
+	if err := s.requireMFAConfigured(); err != nil {
+		return nil, err
+	}
+	factor, err := s.mfa.GetFactor(ctx, orgCtx.Org.ID, userID, domain.MFAFactorTOTP)
+	if err != nil || !factor.Confirmed {
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			span.RecordError(err)
+			return nil, fmt.Errorf("load mfa factor: %w", err)
+		}
+		return nil, newOAuthError("invalid_request", "No authenticator enrolled.", http.StatusBadRequest)
+	}
+
+	codes, err := s.issueRecoveryCodes(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	s.audit("mfa.recovery_codes.regenerated", "org_id", orgCtx.Org.ID, "user_id", userID)
+	return codes, nil
+}
+
+// ChallengeUserID resolves the user behind a pending MFA challenge so that
+// enrollment can happen mid-login when the org requires MFA.
+func (s *AuthService) ChallengeUserID(ctx context.Context, orgCtx *org.Context, mfaToken string) (int64, error) {
+	challenge, err := s.loadMFAChallenge(ctx, orgCtx, mfaToken)
+	if err != nil {
+		return 0, err
+	}
+	if !challenge.EnrollmentRequired {
+		return 0, newOAuthError("invalid_request", "Enrollment not permitted for this challenge.", http.StatusForbidden)
+	}
+	return challenge.UserID, nil
+}
+
+// MFAOTPGrant completes a pending challenge using an authenticator code.
+func (s *AuthService) MFAOTPGrant(ctx context.Context, orgCtx *org.Context, mfaToken, code string) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.MFAOTPGrant")
+	defer span.End()
+
+	resp, _, err := s.completeMFA(ctx, orgCtx, mfaToken, s.totpVerifier(code))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
+}
+
+// MFARecoveryCodeGrant completes a pending challenge using a one-time recovery code.
+func (s *AuthService) MFARecoveryCodeGrant(ctx context.Context, orgCtx *org.Context, mfaToken, recoveryCode string) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.MFARecoveryCodeGrant")
+	defer span.End()
+
+	resp, _, err := s.completeMFA(ctx, orgCtx, mfaToken, s.recoveryCodeVerifier(recoveryCode))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
+}
+
+// VerifyMFAChallenge is the REST counterpart of the MFA grants.
+func (s *AuthService) VerifyMFAChallenge(ctx context.Context, orgID int64, mfaToken, code, recoveryCode string) (AuthTokensWithUser, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.VerifyMFAChallenge")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, "")
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+
+	verify := s.totpVerifier(code)
+	if strings.TrimSpace(recoveryCode) != "" {
+		verify = s.recoveryCodeVerifier(recoveryCode)
+	}
+	tokenResp, user, err := s.completeMFA(ctx, orgCtx, mfaToken, verify)
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+
+	result := newAuthTokensWithUser(user, tokenResp)
+	result.RecoveryCodes = tokenResp.RecoveryCodes
+	s.audit("rest.mfa_verify.success", "org_id", orgID, "user_id", user.ID)
+	return result, nil
+}
+
+// mfaVerifier checks the second factor for a challenge and may return freshly
+// issued recovery codes (when enrollment completes during login).
+type mfaVerifier func(context.Context, *domain.MFAChallenge) ([]string, error)
+
+func (s *AuthService) totpVerifier(code string) mfaVerifier {
+	return func(ctx context.Context, challenge *domain.MFAChallenge) ([]string, error) {
+		factor, err := s.verifyTOTP(ctx, challenge.OrgID, challenge.UserID, code, challenge.EnrollmentRequired)
+		if err != nil {
+			return nil, err
+		}
+		if factor.Confirmed {
+			return nil, nil
+		}
+		return s.issueRecoveryCodes(ctx, challenge.OrgID, challenge.UserID)
+	}
+}
+
+func (s *AuthService) recoveryCodeVerifier(recoveryCode string) mfaVerifier {
+	return func(ctx context.Context, challenge *domain.MFAChallenge) ([]string, error) {
+		cleaned := normalizeRecoveryCode(recoveryCode)
+		if cleaned == "" {
+			return nil, newOAuthError("invalid_grant", "Recovery code required.", http.StatusBadRequest)
+		}
+		ok, err := s.mfa.ConsumeRecoveryCode(ctx, challenge.OrgID, challenge.UserID, hashRecoveryCode(cleaned))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, newOAuthError("invalid_grant", "Invalid recovery code.", http.StatusForbidden)
+		}
+		s.audit("mfa.recovery_code.used", "org_id", challenge.OrgID, "user_id", challenge.UserID)
+		return nil, nil
+	}
+}
+
+// beginMFA decides whether a successful first factor must be followed by a
+// second one. It returns nil when tokens may be issued directly.
+func (s *AuthService) beginMFA(ctx context.Context, orgCtx *org.Context, user domain.User, scope, issuer string, providers []string) error {
+	if s.mfa == nil {
+		return nil
+	}
+	policy := mfaPolicy(orgCtx)
+	if policy == domain.MFAPolicyOff {
+		return nil
+	}
+
+	factor, err := s.mfa.GetFactor(ctx, orgCtx.Org.ID, user.ID, domain.MFAFactorTOTP)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("load mfa factor: %w", err)
+	}
+	var factors []string
+	if err == nil && factor.Confirmed {
+		factors = append(factors, domain.MFAFactorTOTP)
+	}
+	hasPasskeys, err := s.hasPasskeys(ctx, orgCtx.Org.ID, user.ID)
+	if err != nil {
+		return fmt.Errorf("load passkeys: %w", err)
+	}
+	if hasPasskeys {
+		factors = append(factors, domain.AuthProviderPasskey)
+	}
+	enrolled := len(factors) > 0
+	if !enrolled && policy != domain.MFAPolicyRequired {
+		return nil
+	}
+	if s.mfaChallenges == nil {
+		return newOAuthError("server_error", "MFA challenge store unavailable.", http.StatusInternalServerError)
+	}
+
+	challenge := domain.MFAChallenge{
+		ChallengeID:        randomString(32),
+		OrgID:              orgCtx.Org.ID,
+		UserID:             user.ID,
+		ClientID:           orgCtx.ClientID,
+		Scope:              scope,
+		Issuer:             issuer,
+		Providers:          providers,
+		EnrollmentRequired: !enrolled,
+		CreatedAt:          time.Now().UTC(),
+	}
+	if err := s.mfaChallenges.SaveChallenge(ctx, mfaChallengePrefix+challenge.ChallengeID, challenge, s.mfaChallengeTTL()); err != nil {
+		return fmt.Errorf("persist mfa challenge: %w", err)
+	}
+	s.audit("mfa.challenge.issued", "org_id", orgCtx.Org.ID, "user_id", user.ID, "enrollment_required", !enrolled)
+	return &MFARequiredError{MFAToken: challenge.ChallengeID, EnrollmentRequired: !enrolled, Factors: factors}
+}
+
+// AdmitFederatedSignIn applies the org's email verification and MFA policies
+// to a user returning from an external identity provider, before any token
+// or session is issued. It returns the scope the user may be granted, or an
+// *MFARequiredError when a second factor must follow.
+func (s *AuthService) AdmitFederatedSignIn(ctx context.Context, orgID int64, user domain.User, scope, issuer string, providers []string) (string, error) {
+	orgCtx, ok := basemiddleware.OrgContextFromContext(ctx)
+	if !ok || orgCtx == nil || orgCtx.Org.ID != orgID {
+		return "", newOAuthError("invalid_request", "Org context missing.", http.StatusBadRequest)
+	}
+	effectiveScope, err := applyEmailVerificationPolicy(orgCtx, user, scope)
+	if err != nil {
+		return "", err
+	}
+	if err := s.beginMFA(ctx, orgCtx, user, effectiveScope, issuer, providers); err != nil {
+		return "", err
+	}
+	return effectiveScope, nil
+}
+
+// SessionSatisfiesMFA reports whether a browser session may authorize
+// clients under the org's current MFA policy. A session opened with a first
+// factor only stops satisfying it once the org requires MFA.
+func (s *AuthService) SessionSatisfiesMFA(orgCtx *org.Context, session *domain.Session) bool {
+	if mfaPolicy(orgCtx) != domain.MFAPolicyRequired {
+		return true
+	}
+	for _, method := range session.AMR {
+		if method == mfaProvider || method == domain.AuthProviderPasskey {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AuthService) completeMFA(ctx context.Context, orgCtx *org.Context, mfaToken string, verify mfaVerifier) (*TokenResponse, domain.User, error) {
+	if err := s.requireMFAConfigured(); err != nil {
+		return nil, domain.User{}, err
+	}
+	challenge, err := s.loadMFAChallenge(ctx, orgCtx, mfaToken)
+	if err != nil {
+		return nil, domain.User{}, err
+	}
+
+	recoveryCodes, err := verify(ctx, challenge)
+	if err != nil {
+		s.recordMFAFailure(ctx, challenge)
+		return nil, domain.User{}, err
+	}
+	if err := s.mfaChallenges.DeleteChallenge(ctx, mfaChallengePrefix+challenge.ChallengeID); err != nil {
+		return nil, domain.User{}, err
+	}
+
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, challenge.UserID)
+	if err != nil {
+		return nil, domain.User{}, fmt.Errorf("mfa load user: %w", err)
+	}
+
+	scoped := *orgCtx
+	scoped.ClientID = challenge.ClientID
+	providers := append(append([]string{}, challenge.Providers...), mfaProvider)
+	resp, err := s.issueTokens(ctx, &scoped, user, challenge.Scope, challenge.Issuer, providers)
+	if err != nil {
+		return nil, domain.User{}, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	s.audit("mfa.challenge.success", "org_id", orgCtx.Org.ID, "user_id", user.ID)
+	return resp, user, nil
+}
+
+func (s *AuthService) loadMFAChallenge(ctx context.Context, orgCtx *org.Context, mfaToken string) (*domain.MFAChallenge, error) {
+	if err := s.requireMFAConfigured(); err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(mfaToken)
+	if token == "" {
+		return nil, newOAuthError("invalid_request", "mfa_token is required.", http.StatusBadRequest)
+	}
+	challenge, err := s.mfaChallenges.GetChallenge(ctx, mfaChallengePrefix+token)
+	if err != nil {
+		return nil, fmt.Errorf("load mfa challenge: %w", err)
+	}
+	if challenge == nil || challenge.OrgID != orgCtx.Org.ID {
+		return nil, newOAuthError("invalid_grant", "Invalid or expired mfa_token.", http.StatusForbidden)
+	}
+	return challenge, nil
+}
+
+// recordMFAFailure counts a failed attempt and burns the challenge once the
+// limit is reached so codes cannot be brute forced within the TTL.
+func (s *AuthService) recordMFAFailure(ctx context.Context, challenge *domain.MFAChallenge) {
+	key := mfaChallengePrefix + challenge.ChallengeID
+	remaining := time.Until(challenge.CreatedAt.Add(s.mfaChallengeTTL()))
+	attempts := mfaMaxAttempts
+	if remaining > 0 {
+		counted, err := s.mfaChallenges.IncrementAttempts(ctx, key, remaining)
+		if err != nil {
+			// Without a count the limit cannot be enforced; burn the
+			// challenge rather than allow unlimited guesses.
+			s.log().Warn("failed to count mfa attempt", zap.Error(err))
+		} else {
+			attempts = counted
+		}
+	}
+	if attempts >= mfaMaxAttempts {
+		if err := s.mfaChallenges.DeleteChallenge(ctx, key); err != nil {
+			s.log().Warn("failed to delete mfa challenge", zap.Error(err))
+		}
+		s.audit("mfa.challenge.locked", "org_id", challenge.OrgID, "user_id", challenge.UserID)
+	}
+}
+
+func (s *AuthService) verifyTOTP(ctx context.Context, orgID, userID int64, code string, allowPending bool) (domain.MFAFactor, error) {
+	factor, err := s.mfa.GetFactor(ctx, orgID, userID, domain.MFAFactorTOTP)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.MFAFactor{}, newOAuthError("invalid_request", "No authenticator enrolled.", http.StatusBadRequest)
+		}
+		return domain.MFAFactor{}, fmt.Errorf("load mfa factor: %w", err)
+	}
+	if !factor.Confirmed && !allowPending {
+		return domain.MFAFactor{}, newOAuthError("invalid_request", "No authenticator enrolled.", http.StatusBadRequest)
+	}
+
+	secret, err := s.sealer.Open(factor.Secret)
+	if err != nil {
+		return domain.MFAFactor{}, fmt.Errorf("open totp secret: %w", err)
+	}
+	step, ok := totp.Validate(string(secret), code, time.Now(), totpSkew)
+	if !ok {
+		return domain.MFAFactor{}, newOAuthError("invalid_grant", "Invalid authenticator code.", http.StatusForbidden)
+	}
+
+	if !factor.Confirmed {
+		if err := s.mfa.ConfirmFactor(ctx, factor.ID, step); err != nil {
+			return domain.MFAFactor{}, err
+		}
+		return factor, nil
+	}
+	advanced, err := s.mfa.AdvanceFactorStep(ctx, factor.ID, step)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	if !advanced {
+		return domain.MFAFactor{}, newOAuthError("invalid_grant", "Authenticator code already used.", http.StatusForbidden)
+	}
+	return factor, nil
+}
+
+func (s *AuthService) issueRecoveryCodes(ctx context.Context, orgID, userID int64) ([]string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	records := make([]domain.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		plain = append(plain, code)
+		records = append(records, domain.RecoveryCode{
+			ID:       s.snowflake.Generate().Int64(),
+			OrgID:    orgID,
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(normalizeRecoveryCode(code)),
+		})
+	}
+	if err := s.mfa.ReplaceRecoveryCodes(ctx, orgID, userID, records); err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
+
+func (s *AuthService) requireMFAConfigured() error {
+	if s.mfa == nil || s.mfaChallenges == nil {
+		return newOAuthError("server_error", "MFA is not configured.", http.StatusInternalServerError)
+	}
+	if !s.sealer.Enabled() {
+		return newOAuthError("server_error", "MFA encryption key not configured.", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (s *AuthService) mfaChallengeTTL() time.Duration {
+	if s.cfg.MFAChallengeTTL > 0 {
+		return s.cfg.MFAChallengeTTL
+	}
+	return defaultMFAChallengeTTL
+}
+
+func mfaPolicy(orgCtx *org.Context) string {
+	if orgCtx == nil {
+		return domain.MFAPolicyOff
+	}
+	switch strings.ToLower(strings.TrimSpace(orgCtx.MFAConfig.Policy)) {
+	case domain.MFAPolicyOptional:
+		return domain.MFAPolicyOptional
+	case domain.MFAPolicyRequired:
+		return domain.MFAPolicyRequired
+	default:
+		return domain.MFAPolicyOff
+	}
+}
+
+// generateRecoveryCode returns a 10 character base32 code formatted as xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate recovery code: %w", err)
+	}
+	raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	cleaned := strings.ToLower(strings.TrimSpace(code))
+	cleaned = strings.ReplaceAll(cleaned, "-", "")
+	return strings.ReplaceAll(cleaned, " ", "")
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -8,15 +8,20 @@ type AuthTokensWithUser struct {
 	TokenType    string        `json:"token_type"`
 	ExpiresIn    int64         `json:"expires_in"`
 	User         UserViewModel `json:"user"`
+	// RecoveryCodes is only set when MFA enrollment completes during login.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// AMR lists the authentication methods completed at sign-in.
+	AMR []string `json:"-"`
 }
 
 // UserViewModel represents lightweight user profile data returned to clients.
 type UserViewModel struct {
-	ID        int64  `json:"id"`
-	OrgID     int64  `json:"org_id,omitempty"`
-	TenantID  int64  `json:"tenant_id,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Phone     string `json:"phone,omitempty"`
-	Name      string `json:"name,omitempty"`
-	AvatarURL string `json:"avatar_url,omitempty"`
+	ID            int64  `json:"id"`
+	OrgID         int64  `json:"org_id,omitempty"`
+	TenantID      int64  `json:"tenant_id,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified"`
+	Phone         string `json:"phone,omitempty"`
+	Name          string `json:"name,omitempty"`
+	AvatarURL     string `json:"avatar_url,omitempty"`
 }
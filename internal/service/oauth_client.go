@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -22,6 +23,19 @@ type OAuthClientInput struct {
 	TokenEndpointAuthMethods []string
 	RequireConsent           bool
 	RotateSecret             bool
+	// JWKS is the client's public JSON Web Key Set for signed request
+	// objects. Empty keeps the registered set; a set without keys clears it.
+	JWKS       string
+	RequirePAR bool
+	// PostLogoutRedirectURIs and BackchannelLogoutURI configure logout.
+	PostLogoutRedirectURIs []string
+	BackchannelLogoutURI   string
+	// TokenExchangeAudiences are the audiences allowed in token exchanges.
+	TokenExchangeAudiences []string
+	// DPoPBoundAccessTokens requires DPoP proofs at the token endpoint.
+	DPoPBoundAccessTokens bool
+	// AccessTokenFormat is "jwt" (the default) or "opaque".
+	AccessTokenFormat string
 }
 
 // UpsertOAuthClient creates or updates an OAuth client for the given org.
@@ -55,10 +69,46 @@ func (s *AuthService) UpsertOAuthClient(ctx context.Context, orgID int64, input
 		authMethods = []string{"client_secret_post"}
 	}
 
+	postLogoutURIs := normalizeList(input.PostLogoutRedirectURIs)
+	for _, uri := range postLogoutURIs {
+		if !isAbsoluteHTTPURL(uri) {
+			return domain.OAuthClient{}, newOAuthError("invalid_request", "post_logout_redirect_uris must be absolute URLs.", http.StatusBadRequest)
+		}
+	}
+	backchannelURI := strings.TrimSpace(input.BackchannelLogoutURI)
+	if backchannelURI != "" && !isAbsoluteHTTPURL(backchannelURI) {
+		return domain.OAuthClient{}, newOAuthError("invalid_request", "backchannel_logout_uri must be an absolute URL without a fragment.", http.StatusBadRequest)
+	}
+
+	tokenFormat := strings.ToLower(strings.TrimSpace(input.AccessTokenFormat))
+	switch tokenFormat {
+	case "":
+		tokenFormat = domain.AccessTokenFormatJWT
+	case domain.AccessTokenFormatJWT, domain.AccessTokenFormatOpaque:
+	default:
+		return domain.OAuthClient{}, newOAuthError("invalid_request", "access_token_format must be jwt or opaque.", http.StatusBadRequest)
+	}
+
+	jwks := strings.TrimSpace(input.JWKS)
+	if jwks != "" {
+		keys, err := ParseClientJWKS(jwks)
+		if err != nil {
+			return domain.OAuthClient{}, newOAuthError("invalid_request", "jwks must be a JSON Web Key Set of public keys.", http.StatusBadRequest)
+		}
+		if len(keys.Keys) == 0 {
+			jwks = ""
+		}
+	}
+
 	secret := strings.TrimSpace(input.ClientSecret)
-	if secret == "" && !input.RotateSecret {
+	if (secret == "" && !input.RotateSecret) || strings.TrimSpace(input.JWKS) == "" {
 		if existing, err := s.clients.GetClientByID(ctx, orgID, clientID); err == nil {
-			secret = strings.TrimSpace(existing.ClientSecret)
+			if secret == "" && !input.RotateSecret {
+				secret = strings.TrimSpace(existing.ClientSecret)
+			}
+			if strings.TrimSpace(input.JWKS) == "" {
+				jwks = existing.JWKS
+			}
 		} else if !errors.Is(err, pgx.ErrNoRows) {
 			return domain.OAuthClient{}, newOAuthError("server_error", "Failed to load OAuth client.", http.StatusInternalServerError)
 		}
@@ -78,6 +128,13 @@ func (s *AuthService) UpsertOAuthClient(ctx context.Context, orgID int64, input
 		Scopes:                   scopes,
 		TokenEndpointAuthMethods: authMethods,
 		RequireConsent:           input.RequireConsent,
+		JWKS:                     jwks,
+		RequirePAR:               input.RequirePAR,
+		PostLogoutRedirectURIs:   postLogoutURIs,
+		BackchannelLogoutURI:     backchannelURI,
+		TokenExchangeAudiences:   normalizeList(input.TokenExchangeAudiences),
+		DPoPBoundAccessTokens:    input.DPoPBoundAccessTokens,
+		AccessTokenFormat:        tokenFormat,
 	}
 
 	created, err := s.clients.UpsertClient(ctx, client)
@@ -100,3 +157,13 @@ func normalizeList(values []string) []string {
 	}
 	return out
 }
+
+// isAbsoluteHTTPURL reports whether raw is an http(s) URL with a host and no
+// fragment.
+func isAbsoluteHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || parsed.Fragment != "" {
+		return false
+	}
+	return parsed.Scheme == "https" || parsed.Scheme == "http"
+}
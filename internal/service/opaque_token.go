@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+)
+
+var errOpaqueTokenInactive = errors.New("opaque access token is revoked, expired or issued by another org")
+
+// accessToken is an issued access token and what oauth_tokens stores for it.
+type accessToken struct {
+	// value is returned to the client.
+	value string
+	// stored goes in oauth_tokens.access_token: the JWT itself, or the hash
+	// of an opaque token.
+	stored    string
+	expiresAt time.Time
+}
+
+// newAccessToken issues an access token in format. Opaque tokens carry no
+// claims; ValidateToken rebuilds them from the oauth_tokens row.
+func (s *AuthService) newAccessToken(ctx context.Context, format string, org domain.Org, activeOrgID int64, user domain.User, scope, issuer string, providers []string, opts jwt.AccessTokenOptions) (accessToken, error) {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = s.cfg.AccessTokenTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	if format == domain.AccessTokenFormatOpaque {
+		token, hash, err := jwt.NewOpaqueToken()
+		if err != nil {
+			return accessToken{}, fmt.Errorf("generate opaque token: %w", err)
+		}
+		return accessToken{value: token, stored: hash, expiresAt: expiresAt}, nil
+	}
+	token, err := s.jwt.GenerateAccessTokenWithOptions(ctx, org, activeOrgID, user, scope, issuer, providers, opts)
+	if err != nil {
+		return accessToken{}, err
+	}
+	return accessToken{value: token, stored: token, expiresAt: expiresAt}, nil
+}
+
+// clientTokenFormat returns the access token format of clientID. Tokens
+// not issued to a registered client are JWTs.
+func (s *AuthService) clientTokenFormat(ctx context.Context, orgID int64, clientID string) (string, error) {
+	if clientID == "" || s.clients == nil {
+		return domain.AccessTokenFormatJWT, nil
+	}
+	client, err := s.clients.GetClientByID(ctx, orgID, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.AccessTokenFormatJWT, nil
+		}
+		return "", fmt.Errorf("load client token format: %w", err)
+	}
+	return tokenFormatFor(client), nil
+}
+
+// tokenFormatFor is clientTokenFormat for a client that is already loaded.
+func tokenFormatFor(client domain.OAuthClient) string {
+	if client.AccessTokenFormat == domain.AccessTokenFormatOpaque {
+		return domain.AccessTokenFormatOpaque
+	}
+	return domain.AccessTokenFormatJWT
+}
+
+// validateOpaqueToken resolves an opaque access token issued by orgID into
+// the claims a JWT would have carried. Providers and roles are not
+// recorded, so they are left empty.
+func (s *AuthService) validateOpaqueToken(ctx context.Context, orgID int64, token, issuer string) (*gojwt.Claims, *jwt.AccessTokenClaims, error) {
+	stored, err := s.tokens.GetByAccessToken(ctx, jwt.HashOpaqueToken(token))
+	if err != nil {
+		return nil, nil, fmt.Errorf("lookup opaque token: %w", err)
+	}
+	if stored.OrgID != orgID || stored.Revoked || stored.AccessExpiresAt.IsZero() || time.Now().After(stored.AccessExpiresAt) {
+		return nil, nil, errOpaqueTokenInactive
+	}
+
+	user := serviceUser(stored.OrgID, stored.ClientID)
+	if stored.UserID != 0 {
+		if user, err = s.users.GetByID(ctx, stored.OrgID, stored.UserID); err != nil {
+			return nil, nil, fmt.Errorf("load opaque token user: %w", err)
+		}
+	}
+
+	activeOrgID := coalesceOrgID(stored.ActiveOrgID, stored.OrgID)
+	std := &gojwt.Claims{
+		ID:      strconv.FormatInt(stored.ID, 10),
+		Subject: strconv.FormatInt(user.ID, 10),
+		Issuer:  issuer,
+		Expiry:  gojwt.NewNumericDate(stored.AccessExpiresAt),
+	}
+	if stored.Resource != "" {
+		std.Audience = gojwt.Audience{stored.Resource}
+	}
+	custom := &jwt.AccessTokenClaims{
+		OrgID:         activeOrgID,
+		TenantID:      activeOrgID,
+		Scope:         strings.Join(stored.Scopes, " "),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		Picture:       user.AvatarURL,
+		ClientID:      stored.ClientID,
+	}
+	if stored.UserID == 0 {
+		custom.SubjectClientID = stored.ClientID
+	}
+	if stored.DPoPJKT != "" {
+		custom.Cnf = &jwt.Confirmation{JKT: stored.DPoPJKT}
+	}
+	return std, custom, nil
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestOpaqueAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := password.Hash("password")
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "User", PasswordHash: hash}
+	tokenRepo := &memoryTokenRepo{}
+	clients := &logoutClientRepo{client: domain.OAuthClient{ClientID: "legacy", AccessTokenFormat: domain.AccessTokenFormatOpaque}}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &memoryUserRepo{user: user},
+		Tokens:    tokenRepo,
+		Codes:     &memoryCodeRepo{},
+		Clients:   clients,
+		Cooldowns: &memoryCooldownStore{keys: map[string]bool{}},
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{
+		Org:            domain.Org{ID: 1, Name: "Tenant A"},
+		ClientID:       "legacy",
+		PasswordConfig: domain.PasswordConfig{OrgID: 1, MinLength: 8, LockoutAttempts: 5, LockoutDurationSeconds: 300},
+		AuthProviders:  []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+	}
+	issuer := "https://tenant.example"
+
+	resp, err := authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid profile", issuer)
+	require.NoError(t, err)
+	require.False(t, strings.Contains(resp.AccessToken, "."), "opaque tokens are not JWTs")
+	require.NotEqual(t, resp.AccessToken, tokenRepo.lastToken.AccessToken, "only the hash is stored")
+	require.Equal(t, jwt.HashOpaqueToken(resp.AccessToken), tokenRepo.lastToken.AccessToken)
+
+	std, custom, err := authService.ValidateToken(ctx, 1, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, "10", std.Subject)
+	require.Equal(t, issuer, std.Issuer)
+	require.Equal(t, int64(1), custom.OrgID)
+	require.Equal(t, "openid profile", custom.Scope)
+	require.Equal(t, user.Email, custom.Email)
+	require.Equal(t, "legacy", custom.ClientID)
+
+	_, _, err = authService.ValidateToken(ctx, 2, resp.AccessToken, issuer)
+	require.Error(t, err, "tokens of another org are rejected")
+	_, _, err = authService.ValidateToken(ctx, 1, "unknown", issuer)
+	require.Error(t, err)
+
+	refreshed, err := authService.RefreshGrant(ctx, orgCtx, resp.RefreshToken, "", issuer, "")
+	require.NoError(t, err)
+	require.False(t, strings.Contains(refreshed.AccessToken, "."))
+	_, _, err = authService.ValidateToken(ctx, 1, resp.AccessToken, issuer)
+	require.Error(t, err, "refresh replaces the previous access token")
+	_, _, err = authService.ValidateToken(ctx, 1, refreshed.AccessToken, issuer)
+	require.NoError(t, err)
+
+	tokenRepo.lastToken.AccessExpiresAt = time.Now().Add(-time.Second)
+	_, _, err = authService.ValidateToken(ctx, 1, refreshed.AccessToken, issuer)
+	require.Error(t, err, "expired opaque tokens are rejected")
+
+	clients.client.AccessTokenFormat = domain.AccessTokenFormatJWT
+	resp, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", issuer)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(resp.AccessToken, "."))
+	_, custom, err = authService.ValidateToken(ctx, 1, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, custom.Email)
+}
@@ -0,0 +1,480 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+const (
+	defaultOrgPageSize = 50
+	maxOrgPageSize     = 200
+)
+
+// OrgService manages the lifecycle of orgs for platform operators: creating,
+// updating, suspending and (soft) deleting them.
+type OrgService struct {
+	orgs      repository.OrgRepository
+	resolver  *org.Resolver
+	snowflake *snowflake.Node
+	cfg       config.Config
+	logger    *zap.Logger
+	tracer    trace.Tracer
+}
+
+// NewOrgService wires dependencies.
+func NewOrgService(orgs repository.OrgRepository, resolver *org.Resolver, snowflake *snowflake.Node, cfg config.Config, logger *zap.Logger) *OrgService {
+	if logger == nil {
+		logger = zap.L()
+	}
+	return &OrgService{
+		orgs:      orgs,
+		resolver:  resolver,
+		snowflake: snowflake,
+		cfg:       cfg,
+		logger:    logger,
+		tracer:    otel.Tracer("github.com/smallbiznis/railzway-auth/internal/service"),
+	}
+}
+
+// OrgInfo is the admin view of an org. PurgeAfter is set for deleted orgs and
+// is when their data is permanently removed.
+type OrgInfo struct {
+	ID          int64      `json:"id,string"`
+	Type        string     `json:"type"`
+	Name        string     `json:"name"`
+	Code        string     `json:"code"`
+	Slug        string     `json:"slug"`
+	ExternalID  string     `json:"external_id,omitempty"`
+	CountryCode string     `json:"country_code"`
+	Timezone    string     `json:"timezone"`
+	IsDefault   bool       `json:"is_default"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	PurgeAfter  *time.Time `json:"purge_after,omitempty"`
+}
+
+// OrgPage is one page of ListOrgs results.
+type OrgPage struct {
+	Orgs   []OrgInfo `json:"orgs"`
+	Total  int64     `json:"total"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+}
+
+// OrgInput describes a new org.
+type OrgInput struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Code        string `json:"code"`
+	Slug        string `json:"slug"`
+	ExternalID  string `json:"external_id"`
+	CountryCode string `json:"country_code"`
+	Timezone    string `json:"timezone"`
+}
+
+// OrgUpdate changes the fields that are set.
+type OrgUpdate struct {
+	Name        *string `json:"name"`
+	Code        *string `json:"code"`
+	Slug        *string `json:"slug"`
+	ExternalID  *string `json:"external_id"`
+	CountryCode *string `json:"country_code"`
+	Timezone    *string `json:"timezone"`
+}
+
+// ListOrgs returns orgs ordered by ID, optionally filtered by status.
+func (s *OrgService) ListOrgs(ctx context.Context, status string, limit, offset int) (OrgPage, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.ListOrgs")
+	defer span.End()
+
+	status = strings.ToLower(strings.TrimSpace(status))
+	switch status {
+	case "", domain.OrgStatusPending, domain.OrgStatusActive, domain.OrgStatusSuspended, domain.OrgStatusDeleted:
+	default:
+		return OrgPage{}, newOAuthError("invalid_request", "status must be pending, active, suspended or deleted.", http.StatusBadRequest)
+	}
+	if limit <= 0 {
+		limit = defaultOrgPageSize
+	}
+	if limit > maxOrgPageSize {
+		limit = maxOrgPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	orgs, total, err := s.orgs.List(ctx, status, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return OrgPage{}, err
+	}
+	page := OrgPage{Orgs: make([]OrgInfo, 0, len(orgs)), Total: total, Limit: limit, Offset: offset}
+	for _, o := range orgs {
+		page.Orgs = append(page.Orgs, s.orgInfo(o))
+	}
+	return page, nil
+}
+
+// GetOrg returns a single org, including deleted ones.
+func (s *OrgService) GetOrg(ctx context.Context, orgID int64) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.GetOrg")
+	defer span.End()
+
+	o, err := s.getOrg(ctx, orgID)
+	if err != nil {
+		return OrgInfo{}, err
+	}
+	return s.orgInfo(o), nil
+}
+
+// CreateOrg creates an active org.
+func (s *OrgService) CreateOrg(ctx context.Context, input OrgInput) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.CreateOrg")
+	defer span.End()
+
+	o := domain.Org{
+		ID:          s.snowflake.Generate().Int64(),
+		Type:        strings.ToLower(strings.TrimSpace(input.Type)),
+		Name:        strings.TrimSpace(input.Name),
+		Code:        strings.TrimSpace(input.Code),
+		Slug:        strings.ToLower(strings.TrimSpace(input.Slug)),
+		ExternalID:  strings.TrimSpace(input.ExternalID),
+		CountryCode: strings.ToUpper(strings.TrimSpace(input.CountryCode)),
+		Timezone:    strings.TrimSpace(input.Timezone),
+		Status:      domain.OrgStatusActive,
+	}
+	switch o.Type {
+	case "":
+		o.Type = domain.OrgTypeCompany
+	case domain.OrgTypePersonal, domain.OrgTypeCompany:
+	default:
+		return OrgInfo{}, newOAuthError("invalid_request", "type must be personal or company.", http.StatusBadRequest)
+	}
+	if err := validateOrg(o); err != nil {
+		return OrgInfo{}, err
+	}
+
+	created, err := s.orgs.Create(ctx, o)
+	if err != nil {
+		if oauthErr := orgWriteError(err); oauthErr != nil {
+			return OrgInfo{}, oauthErr
+		}
+		span.RecordError(err)
+		return OrgInfo{}, err
+	}
+
+	auditLog(s.logger, "org.created", "org_id", created.ID, "slug", created.Slug, "type", created.Type)
+	return s.orgInfo(created), nil
+}
+
+// UpdateOrg changes an org's details. Deleted orgs must be restored first.
+func (s *OrgService) UpdateOrg(ctx context.Context, orgID int64, update OrgUpdate) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.UpdateOrg")
+	defer span.End()
+
+	o, err := s.getOrg(ctx, orgID)
+	if err != nil {
+		return OrgInfo{}, err
+	}
+	if o.DeletedAt != nil {
+		return OrgInfo{}, newOAuthError("invalid_request", "Org is deleted; restore it first.", http.StatusConflict)
+	}
+	if update.Name != nil {
+		o.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Code != nil {
+		o.Code = strings.TrimSpace(*update.Code)
+	}
+	if update.Slug != nil {
+		o.Slug = strings.ToLower(strings.TrimSpace(*update.Slug))
+	}
+	if update.ExternalID != nil {
+		o.ExternalID = strings.TrimSpace(*update.ExternalID)
+	}
+	if update.CountryCode != nil {
+		o.CountryCode = strings.ToUpper(strings.TrimSpace(*update.CountryCode))
+	}
+	if update.Timezone != nil {
+		o.Timezone = strings.TrimSpace(*update.Timezone)
+	}
+	if err := validateOrg(o); err != nil {
+		return OrgInfo{}, err
+	}
+	for _, field := range []*string{update.Code, update.CountryCode, update.Timezone} {
+		if field != nil && strings.TrimSpace(*field) == "" {
+			return OrgInfo{}, newOAuthError("invalid_request", "code, country_code and timezone cannot be empty.", http.StatusBadRequest)
+		}
+	}
+
+	updated, err := s.orgs.Update(ctx, o)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OrgInfo{}, newOAuthError("invalid_request", "Org not found.", http.StatusNotFound)
+		}
+		if oauthErr := orgWriteError(err); oauthErr != nil {
+			return OrgInfo{}, oauthErr
+		}
+		span.RecordError(err)
+		return OrgInfo{}, err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "org.updated", "org_id", orgID, "slug", updated.Slug)
+	return s.orgInfo(updated), nil
+}
+
+// SuspendOrg stops an org's users from signing in and its clients from
+// obtaining tokens. callerOrgID is the org of the admin making the request,
+// which cannot suspend itself; it is 0 for operator tooling.
+func (s *OrgService) SuspendOrg(ctx context.Context, callerOrgID, orgID int64) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.SuspendOrg")
+	defer span.End()
+
+	if _, err := s.guardedOrg(ctx, callerOrgID, orgID, "suspended"); err != nil {
+		return OrgInfo{}, err
+	}
+	return s.setStatus(ctx, span, orgID, domain.OrgStatusSuspended, "org.suspended")
+}
+
+// ActivateOrg lifts a suspension or activates a pending org.
+func (s *OrgService) ActivateOrg(ctx context.Context, orgID int64) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.ActivateOrg")
+	defer span.End()
+
+	o, err := s.getOrg(ctx, orgID)
+	if err != nil {
+		return OrgInfo{}, err
+	}
+	if o.DeletedAt != nil {
+		return OrgInfo{}, newOAuthError("invalid_request", "Org is deleted; restore it instead.", http.StatusConflict)
+	}
+	return s.setStatus(ctx, span, orgID, domain.OrgStatusActive, "org.activated")
+}
+
+// DeleteOrg soft-deletes an org. It stops being served immediately and its
+// data is purged once OrgDeletionRetention has passed, unless it is restored
+// first. The same rules as SuspendOrg apply to callerOrgID.
+func (s *OrgService) DeleteOrg(ctx context.Context, callerOrgID, orgID int64) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.DeleteOrg")
+	defer span.End()
+
+	o, err := s.guardedOrg(ctx, callerOrgID, orgID, "deleted")
+	if err != nil {
+		return OrgInfo{}, err
+	}
+	if o.DeletedAt != nil {
+		return s.orgInfo(o), nil
+	}
+
+	deleted, err := s.orgs.SoftDelete(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OrgInfo{}, newOAuthError("invalid_request", "Org not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return OrgInfo{}, err
+	}
+	s.invalidate(ctx, orgID)
+
+	info := s.orgInfo(deleted)
+	auditLog(s.logger, "org.deleted", "org_id", orgID, "slug", deleted.Slug, "purge_after", info.PurgeAfter)
+	return info, nil
+}
+
+// RestoreOrg brings back a deleted org that has not been purged yet. It comes
+// back suspended so an operator can review it before activating it.
+func (s *OrgService) RestoreOrg(ctx context.Context, orgID int64) (OrgInfo, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.RestoreOrg")
+	defer span.End()
+
+	restored, err := s.orgs.Restore(ctx, orgID, domain.OrgStatusSuspended)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OrgInfo{}, newOAuthError("invalid_request", "Org not found or not deleted.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return OrgInfo{}, err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "org.restored", "org_id", orgID, "slug", restored.Slug)
+	return s.orgInfo(restored), nil
+}
+
+// PurgeDeletedOrgs permanently removes orgs deleted more than
+// OrgDeletionRetention ago, together with all of their data.
+func (s *OrgService) PurgeDeletedOrgs(ctx context.Context) (int, error) {
+	ctx, span := s.startSpan(ctx, "OrgService.PurgeDeletedOrgs")
+	defer span.End()
+
+	ids, err := s.orgs.PurgeDeleted(ctx, time.Now().UTC().Add(-s.cfg.OrgDeletionRetention))
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	for _, id := range ids {
+		s.invalidate(ctx, id)
+		auditLog(s.logger, "org.purged", "org_id", id)
+	}
+	return len(ids), nil
+}
+
+// RunPurger calls PurgeDeletedOrgs every interval until ctx is done.
+func (s *OrgService) RunPurger(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.PurgeDeletedOrgs(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("purge deleted orgs", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *OrgService) setStatus(ctx context.Context, span trace.Span, orgID int64, status, event string) (OrgInfo, error) {
+	o, err := s.orgs.SetStatus(ctx, orgID, status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OrgInfo{}, newOAuthError("invalid_request", "Org not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return OrgInfo{}, err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, event, "org_id", orgID, "slug", o.Slug)
+	return s.orgInfo(o), nil
+}
+
+// guardedOrg loads an org that is about to be taken out of service. The
+// platform org and the caller's own org are refused so operators cannot lock
+// themselves out.
+func (s *OrgService) guardedOrg(ctx context.Context, callerOrgID, orgID int64, action string) (domain.Org, error) {
+	o, err := s.getOrg(ctx, orgID)
+	if err != nil {
+		return domain.Org{}, err
+	}
+	if o.Type == domain.OrgTypePlatform || orgID == callerOrgID {
+		return domain.Org{}, newOAuthError("invalid_request", "This org cannot be "+action+".", http.StatusBadRequest)
+	}
+	return o, nil
+}
+
+func (s *OrgService) getOrg(ctx context.Context, orgID int64) (domain.Org, error) {
+	o, err := s.orgs.GetOrg(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Org{}, newOAuthError("invalid_request", "Org not found.", http.StatusNotFound)
+		}
+		return domain.Org{}, err
+	}
+	return o, nil
+}
+
+// invalidate drops cached org contexts so status changes take effect on the
+// next request. Failures only delay the change until the cache entries
+// expire, so they are logged.
+func (s *OrgService) invalidate(ctx context.Context, orgID int64) {
+	if s.resolver == nil {
+		return
+	}
+	if err := s.resolver.Invalidate(ctx, orgID); err != nil {
+		s.logger.Warn("invalidate org context", zap.Int64("org_id", orgID), zap.Error(err))
+	}
+}
+
+func (s *OrgService) orgInfo(o domain.Org) OrgInfo {
+	info := OrgInfo{
+		ID:          o.ID,
+		Type:        o.Type,
+		Name:        o.Name,
+		Code:        o.Code,
+		Slug:        o.Slug,
+		ExternalID:  o.ExternalID,
+		CountryCode: o.CountryCode,
+		Timezone:    o.Timezone,
+		IsDefault:   o.IsDefault,
+		Status:      strings.ToLower(o.Status),
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+		DeletedAt:   o.DeletedAt,
+	}
+	if o.DeletedAt != nil {
+		purgeAfter := o.DeletedAt.Add(s.cfg.OrgDeletionRetention)
+		info.PurgeAfter = &purgeAfter
+	}
+	return info
+}
+
+func (s *OrgService) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if s == nil || s.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return s.tracer.Start(ctx, name)
+}
+
+func validateOrg(o domain.Org) error {
+	if o.Name == "" {
+		return newOAuthError("invalid_request", "name is required.", http.StatusBadRequest)
+	}
+	if !validOrgSlug(o.Slug) {
+		return newOAuthError("invalid_request", "slug must be 1-63 lowercase letters, digits or hyphens.", http.StatusBadRequest)
+	}
+	if _, err := time.LoadLocation(o.Timezone); err != nil {
+		return newOAuthError("invalid_request", "timezone must be an IANA time zone.", http.StatusBadRequest)
+	}
+	return nil
+}
+
+// validOrgSlug reports whether slug can be used as a DNS label, since slugs
+// double as subdomains and X-Tenant-ID values.
+func validOrgSlug(slug string) bool {
+	if slug == "" || len(slug) > 63 || slug[0] == '-' || slug[len(slug)-1] == '-' {
+		return false
+	}
+	for _, r := range slug {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// orgWriteError maps constraint violations on tenants to client errors.
+func orgWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch pgErr.Code {
+	case "23505":
+		return newOAuthError("invalid_request", "An org with this slug or external_id already exists.", http.StatusConflict)
+	case "23503":
+		return newOAuthError("invalid_request", "country_code is not supported.", http.StatusBadRequest)
+	}
+	return nil
+}
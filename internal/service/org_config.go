@@ -0,0 +1,808 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/ratelimit"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+)
+
+const (
+	maxBrandingURLLength  = 2048
+	maxBrandingCodeLength = 64 * 1024
+
+	maxRateLimit              = 1000000
+	maxRateLimitWindowSeconds = 86400
+)
+
+var (
+	brandingColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	scopeTokenPattern    = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+	// socialProviders are the auth provider types backed by an IdP config.
+	socialProviders = map[string]bool{"google": true, "apple": true, "github": true, "microsoft": true, "oidc": true}
+	// authProviderTypes are the login methods an admin can enable.
+	authProviderTypes = map[string]bool{"password": true, "otp": true, "passkey": true, "magic_link": true, "google": true, "apple": true, "github": true, "microsoft": true, "oidc": true}
+)
+
+// OrgConfigService lets org admins manage branding, password and OTP policy,
+// enabled login methods, social IdP settings and rate limits. Writes use the updated_at of
+// the version the caller read for optimistic concurrency.
+type OrgConfigService struct {
+	configs   repository.OrgConfigRepository
+	resolver  *org.Resolver
+	snowflake *snowflake.Node
+	logger    *zap.Logger
+	tracer    trace.Tracer
+}
+
+// NewOrgConfigService wires dependencies.
+func NewOrgConfigService(configs repository.OrgConfigRepository, resolver *org.Resolver, snowflake *snowflake.Node, logger *zap.Logger) *OrgConfigService {
+	if logger == nil {
+		logger = zap.L()
+	}
+	return &OrgConfigService{
+		configs:   configs,
+		resolver:  resolver,
+		snowflake: snowflake,
+		logger:    logger,
+		tracer:    otel.Tracer("github.com/smallbiznis/railzway-auth/internal/service"),
+	}
+}
+
+// BrandingConfig is the branding document. UpdatedAt is nil until it has been
+// saved, and must be sent back unchanged when saving.
+type BrandingConfig struct {
+	LogoURL          string `json:"logo_url"`
+	FaviconURL       string `json:"favicon_url"`
+	PrimaryColor     string `json:"primary_color"`
+	SecondaryColor   string `json:"secondary_color"`
+	AccentColor      string `json:"accent_color"`
+	BackgroundColor  string `json:"background_color"`
+	TextColor        string `json:"text_color"`
+	DarkMode         *bool  `json:"dark_mode"`
+	CustomCSS        string `json:"custom_css"`
+	CustomJS         string `json:"custom_js"`
+	CustomHTMLHeader string `json:"custom_html_header"`
+	CustomHTMLFooter string `json:"custom_html_footer"`
+	// CustomJSEnabled is read-only here; platform admins change it with
+	// SetCustomJSEnabled.
+	CustomJSEnabled bool       `json:"custom_js_enabled"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+// PasswordPolicyConfig is the password policy document. Omitted optional
+// fields take their defaults.
+type PasswordPolicyConfig struct {
+	MinLength              int        `json:"min_length"`
+	RequireUppercase       bool       `json:"require_uppercase"`
+	RequireNumber          bool       `json:"require_number"`
+	RequireSymbol          bool       `json:"require_symbol"`
+	AllowSignup            *bool      `json:"allow_signup"`
+	AllowPasswordReset     *bool      `json:"allow_password_reset"`
+	LockoutAttempts        *int       `json:"lockout_attempts"`
+	LockoutDurationSeconds *int       `json:"lockout_duration_seconds"`
+	EmailVerification      string     `json:"email_verification"`
+	UpdatedAt              *time.Time `json:"updated_at"`
+}
+
+// OTPDeliveryConfig is the OTP document. APIKey is write-only: it is never
+// returned, APIKeySet reports whether one is stored, and omitting it on save
+// keeps the stored key.
+type OTPDeliveryConfig struct {
+	Channel       string     `json:"channel"`
+	Provider      string     `json:"provider"`
+	APIKey        *string    `json:"api_key,omitempty"`
+	APIKeySet     bool       `json:"api_key_set"`
+	Sender        string     `json:"sender"`
+	Template      string     `json:"template"`
+	ExpirySeconds *int       `json:"expiry_seconds"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+// AuthProviderConfig enables or disables one login method.
+type AuthProviderConfig struct {
+	Type      string     `json:"type"`
+	IsActive  bool       `json:"is_active"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// IDPConfig holds a social provider's client settings. ClientSecret is
+// write-only in the same way as OTPDeliveryConfig.APIKey.
+type IDPConfig struct {
+	Provider         string         `json:"provider"`
+	ClientID         string         `json:"client_id"`
+	ClientSecret     *string        `json:"client_secret,omitempty"`
+	ClientSecretSet  bool           `json:"client_secret_set"`
+	IssuerURL        string         `json:"issuer_url"`
+	AuthorizationURL string         `json:"authorization_url"`
+	TokenURL         string         `json:"token_url"`
+	UserinfoURL      string         `json:"userinfo_url"`
+	JWKSURL          string         `json:"jwks_url"`
+	Scopes           []string       `json:"scopes"`
+	Extra            map[string]any `json:"extra"`
+	UpdatedAt        *time.Time     `json:"updated_at"`
+}
+
+// RateLimitConfig overrides a rate limit policy for the org: at most Limit
+// requests per WindowSeconds for each key the policy counts.
+type RateLimitConfig struct {
+	Policy        string     `json:"policy"`
+	Limit         int        `json:"limit"`
+	WindowSeconds int        `json:"window_seconds"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+// GetBranding returns the org's branding.
+func (s *OrgConfigService) GetBranding(ctx context.Context, orgID int64) (BrandingConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.GetBranding")
+	defer span.End()
+
+	b, err := s.configs.GetBranding(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return BrandingConfig{}, err
+	}
+	return brandingConfig(b), nil
+}
+
+// SaveBranding replaces the org's branding.
+func (s *OrgConfigService) SaveBranding(ctx context.Context, orgID int64, cfg BrandingConfig) (BrandingConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SaveBranding")
+	defer span.End()
+
+	for field, value := range map[string]string{"logo_url": cfg.LogoURL, "favicon_url": cfg.FaviconURL} {
+		if err := validateConfigURL(field, value); err != nil {
+			return BrandingConfig{}, err
+		}
+	}
+	colors := map[string]string{
+		"primary_color":    cfg.PrimaryColor,
+		"secondary_color":  cfg.SecondaryColor,
+		"accent_color":     cfg.AccentColor,
+		"background_color": cfg.BackgroundColor,
+		"text_color":       cfg.TextColor,
+	}
+	for field, value := range colors {
+		if value != "" && !brandingColorPattern.MatchString(value) {
+			return BrandingConfig{}, newOAuthError("invalid_request", field+" must be a hex color such as #1a2b3c.", http.StatusBadRequest)
+		}
+	}
+	code := map[string]string{
+		"custom_css":         cfg.CustomCSS,
+		"custom_js":          cfg.CustomJS,
+		"custom_html_header": cfg.CustomHTMLHeader,
+		"custom_html_footer": cfg.CustomHTMLFooter,
+	}
+	for field, value := range code {
+		if len(value) > maxBrandingCodeLength {
+			return BrandingConfig{}, newOAuthError("invalid_request", field+" must be at most 64 KiB.", http.StatusBadRequest)
+		}
+	}
+
+	darkMode := true
+	if cfg.DarkMode != nil {
+		darkMode = *cfg.DarkMode
+	}
+	saved, err := s.configs.SaveBranding(ctx, domain.Branding{
+		OrgID:            orgID,
+		LogoURL:          &cfg.LogoURL,
+		FaviconURL:       &cfg.FaviconURL,
+		PrimaryColor:     &cfg.PrimaryColor,
+		SecondaryColor:   &cfg.SecondaryColor,
+		AccentColor:      &cfg.AccentColor,
+		BackgroundColor:  &cfg.BackgroundColor,
+		TextColor:        &cfg.TextColor,
+		DarkMode:         darkMode,
+		CustomCSS:        &cfg.CustomCSS,
+		CustomJS:         &cfg.CustomJS,
+		CustomHTMLHeader: &cfg.CustomHTMLHeader,
+		CustomHTMLFooter: &cfg.CustomHTMLFooter,
+	}, cfg.UpdatedAt)
+	if err := s.saved(ctx, span, err, orgID, "org_config.branding_updated"); err != nil {
+		return BrandingConfig{}, err
+	}
+	return brandingConfig(saved), nil
+}
+
+// SetCustomJSEnabled allows or stops the org's custom JS on the login pages.
+// Only platform admins may call it: custom JS runs next to the credential
+// form, so enabling it is a trust decision about the org.
+func (s *OrgConfigService) SetCustomJSEnabled(ctx context.Context, orgID int64, enabled bool) (BrandingConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SetCustomJSEnabled")
+	defer span.End()
+
+	b, err := s.configs.SetCustomJSEnabled(ctx, orgID, enabled)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return BrandingConfig{}, newOAuthError("invalid_request", "Org not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return BrandingConfig{}, err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "org_config.custom_js_updated", "org_id", orgID, "enabled", enabled)
+	return brandingConfig(b), nil
+}
+
+// GetPasswordConfig returns the org's password policy.
+func (s *OrgConfigService) GetPasswordConfig(ctx context.Context, orgID int64) (PasswordPolicyConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.GetPasswordConfig")
+	defer span.End()
+
+	cfg, err := s.configs.GetPasswordConfig(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return PasswordPolicyConfig{}, err
+	}
+	return passwordPolicyConfig(cfg), nil
+}
+
+// SavePasswordConfig replaces the org's password policy.
+func (s *OrgConfigService) SavePasswordConfig(ctx context.Context, orgID int64, cfg PasswordPolicyConfig) (PasswordPolicyConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SavePasswordConfig")
+	defer span.End()
+
+	policy := domain.PasswordConfig{
+		OrgID:                  orgID,
+		MinLength:              cfg.MinLength,
+		RequireUppercase:       cfg.RequireUppercase,
+		RequireNumber:          cfg.RequireNumber,
+		RequireSymbol:          cfg.RequireSymbol,
+		AllowSignup:            boolOr(cfg.AllowSignup, true),
+		AllowPasswordReset:     boolOr(cfg.AllowPasswordReset, true),
+		LockoutAttempts:        intOr(cfg.LockoutAttempts, 5),
+		LockoutDurationSeconds: intOr(cfg.LockoutDurationSeconds, 300),
+		EmailVerification:      strings.ToLower(strings.TrimSpace(cfg.EmailVerification)),
+	}
+	if policy.MinLength < 8 || policy.MinLength > 128 {
+		return PasswordPolicyConfig{}, newOAuthError("invalid_request", "min_length must be between 8 and 128.", http.StatusBadRequest)
+	}
+	if policy.LockoutAttempts < 0 || policy.LockoutAttempts > 100 {
+		return PasswordPolicyConfig{}, newOAuthError("invalid_request", "lockout_attempts must be between 0 and 100.", http.StatusBadRequest)
+	}
+	if policy.LockoutDurationSeconds < 0 || policy.LockoutDurationSeconds > 86400 {
+		return PasswordPolicyConfig{}, newOAuthError("invalid_request", "lockout_duration_seconds must be between 0 and 86400.", http.StatusBadRequest)
+	}
+	switch policy.EmailVerification {
+	case domain.EmailVerificationOptional, domain.EmailVerificationLimited, domain.EmailVerificationRequired:
+	default:
+		return PasswordPolicyConfig{}, newOAuthError("invalid_request", "email_verification must be optional, limited or required.", http.StatusBadRequest)
+	}
+
+	saved, err := s.configs.SavePasswordConfig(ctx, policy, cfg.UpdatedAt)
+	if err := s.saved(ctx, span, err, orgID, "org_config.password_updated"); err != nil {
+		return PasswordPolicyConfig{}, err
+	}
+	return passwordPolicyConfig(saved), nil
+}
+
+// GetOTPConfig returns the org's OTP delivery settings without the API key.
+func (s *OrgConfigService) GetOTPConfig(ctx context.Context, orgID int64) (OTPDeliveryConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.GetOTPConfig")
+	defer span.End()
+
+	cfg, err := s.configs.GetOTPConfig(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return OTPDeliveryConfig{}, err
+	}
+	return otpDeliveryConfig(cfg), nil
+}
+
+// SaveOTPConfig replaces the org's OTP delivery settings.
+func (s *OrgConfigService) SaveOTPConfig(ctx context.Context, orgID int64, cfg OTPDeliveryConfig) (OTPDeliveryConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SaveOTPConfig")
+	defer span.End()
+
+	otp := domain.OTPConfig{
+		OrgID:         orgID,
+		Channel:       strings.ToLower(strings.TrimSpace(cfg.Channel)),
+		Provider:      strings.TrimSpace(cfg.Provider),
+		Sender:        strings.TrimSpace(cfg.Sender),
+		Template:      cfg.Template,
+		ExpirySeconds: intOr(cfg.ExpirySeconds, 300),
+	}
+	switch otp.Channel {
+	case "sms", "whatsapp", "email":
+	default:
+		return OTPDeliveryConfig{}, newOAuthError("invalid_request", "channel must be sms, whatsapp or email.", http.StatusBadRequest)
+	}
+	if otp.Provider == "" || len(otp.Provider) > 50 {
+		return OTPDeliveryConfig{}, newOAuthError("invalid_request", "provider is required and must be at most 50 characters.", http.StatusBadRequest)
+	}
+	if len(otp.Sender) > 255 || len(otp.Template) > 4096 {
+		return OTPDeliveryConfig{}, newOAuthError("invalid_request", "sender or template is too long.", http.StatusBadRequest)
+	}
+	if otp.ExpirySeconds < 60 || otp.ExpirySeconds > 3600 {
+		return OTPDeliveryConfig{}, newOAuthError("invalid_request", "expiry_seconds must be between 60 and 3600.", http.StatusBadRequest)
+	}
+	if cfg.APIKey != nil {
+		if len(*cfg.APIKey) > 4096 {
+			return OTPDeliveryConfig{}, newOAuthError("invalid_request", "api_key is too long.", http.StatusBadRequest)
+		}
+		otp.APIKey = *cfg.APIKey
+	} else if cfg.UpdatedAt != nil {
+		current, err := s.configs.GetOTPConfig(ctx, orgID)
+		if err != nil {
+			span.RecordError(err)
+			return OTPDeliveryConfig{}, err
+		}
+		otp.APIKey = current.APIKey
+	}
+
+	saved, err := s.configs.SaveOTPConfig(ctx, otp, cfg.UpdatedAt)
+	if err := s.saved(ctx, span, err, orgID, "org_config.otp_updated"); err != nil {
+		return OTPDeliveryConfig{}, err
+	}
+	return otpDeliveryConfig(saved), nil
+}
+
+// ListAuthProviders returns the org's login methods.
+func (s *OrgConfigService) ListAuthProviders(ctx context.Context, orgID int64) ([]AuthProviderConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.ListAuthProviders")
+	defer span.End()
+
+	providers, err := s.configs.ListAuthProviders(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	views := make([]AuthProviderConfig, 0, len(providers))
+	for _, p := range providers {
+		views = append(views, authProviderConfig(p))
+	}
+	return views, nil
+}
+
+// SaveAuthProvider enables or disables a login method. Social providers are
+// linked to the org's IdP config for that provider, which must exist.
+func (s *OrgConfigService) SaveAuthProvider(ctx context.Context, orgID int64, providerType string, cfg AuthProviderConfig) (AuthProviderConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SaveAuthProvider")
+	defer span.End()
+
+	providerType = strings.ToLower(strings.TrimSpace(providerType))
+	if !authProviderTypes[providerType] {
+		return AuthProviderConfig{}, newOAuthError("invalid_request", "Unsupported auth provider type.", http.StatusBadRequest)
+	}
+	provider := domain.AuthProvider{
+		ID:           s.snowflake.Generate().Int64(),
+		OrgID:        orgID,
+		ProviderType: providerType,
+		IsActive:     cfg.IsActive,
+	}
+	if socialProviders[providerType] {
+		idp, err := s.configs.GetIDPConfig(ctx, orgID, providerType)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return AuthProviderConfig{}, newOAuthError("invalid_request", "Configure the "+providerType+" IdP before enabling it.", http.StatusBadRequest)
+			}
+			span.RecordError(err)
+			return AuthProviderConfig{}, err
+		}
+		provider.ProviderConfigID = &idp.ID
+	}
+
+	saved, err := s.configs.SaveAuthProvider(ctx, provider, cfg.UpdatedAt)
+	if err := s.saved(ctx, span, err, orgID, "org_config.auth_provider_updated", "provider_type", providerType, "is_active", cfg.IsActive); err != nil {
+		return AuthProviderConfig{}, err
+	}
+	return authProviderConfig(saved), nil
+}
+
+// DeleteAuthProvider removes a login method.
+func (s *OrgConfigService) DeleteAuthProvider(ctx context.Context, orgID int64, providerType string) error {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.DeleteAuthProvider")
+	defer span.End()
+
+	providerType = strings.ToLower(strings.TrimSpace(providerType))
+	if err := s.configs.DeleteAuthProvider(ctx, orgID, providerType); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Auth provider not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "org_config.auth_provider_deleted", "org_id", orgID, "provider_type", providerType)
+	return nil
+}
+
+// ListIDPConfigs returns the org's social IdP settings without secrets.
+func (s *OrgConfigService) ListIDPConfigs(ctx context.Context, orgID int64) ([]IDPConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.ListIDPConfigs")
+	defer span.End()
+
+	configs, err := s.configs.ListIDPConfigs(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	views := make([]IDPConfig, 0, len(configs))
+	for _, cfg := range configs {
+		views = append(views, idpConfig(cfg))
+	}
+	return views, nil
+}
+
+// GetIDPConfig returns one provider's settings without the client secret.
+func (s *OrgConfigService) GetIDPConfig(ctx context.Context, orgID int64, provider string) (IDPConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.GetIDPConfig")
+	defer span.End()
+
+	cfg, err := s.getIDPConfig(ctx, orgID, provider)
+	if err != nil {
+		return IDPConfig{}, err
+	}
+	return idpConfig(cfg), nil
+}
+
+// SaveIDPConfig creates or replaces a provider's settings.
+func (s *OrgConfigService) SaveIDPConfig(ctx context.Context, orgID int64, provider string, cfg IDPConfig) (IDPConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SaveIDPConfig")
+	defer span.End()
+
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if !socialProviders[provider] {
+		return IDPConfig{}, newOAuthError("invalid_request", "provider must be google, apple, github, microsoft or oidc.", http.StatusBadRequest)
+	}
+	idp := domain.OAuthIDPConfig{
+		ID:               s.snowflake.Generate().Int64(),
+		OrgID:            orgID,
+		Provider:         provider,
+		ClientID:         strings.TrimSpace(cfg.ClientID),
+		IssuerURL:        strings.TrimSpace(cfg.IssuerURL),
+		AuthorizationURL: strings.TrimSpace(cfg.AuthorizationURL),
+		TokenURL:         strings.TrimSpace(cfg.TokenURL),
+		UserinfoURL:      strings.TrimSpace(cfg.UserinfoURL),
+		JWKSURL:          strings.TrimSpace(cfg.JWKSURL),
+		Scopes:           cfg.Scopes,
+		Extra:            cfg.Extra,
+	}
+	if err := validateIDPConfig(idp); err != nil {
+		return IDPConfig{}, err
+	}
+	if idp.Extra == nil {
+		idp.Extra = map[string]any{}
+	}
+	if cfg.ClientSecret != nil {
+		if len(*cfg.ClientSecret) > 4096 {
+			return IDPConfig{}, newOAuthError("invalid_request", "client_secret is too long.", http.StatusBadRequest)
+		}
+		idp.ClientSecret = *cfg.ClientSecret
+	} else if cfg.UpdatedAt != nil {
+		current, err := s.getIDPConfig(ctx, orgID, provider)
+		if err != nil {
+			return IDPConfig{}, err
+		}
+		idp.ClientSecret = current.ClientSecret
+	}
+
+	saved, err := s.configs.SaveIDPConfig(ctx, idp, cfg.UpdatedAt)
+	if err := s.saved(ctx, span, err, orgID, "org_config.idp_updated", "provider", provider); err != nil {
+		return IDPConfig{}, err
+	}
+	return idpConfig(saved), nil
+}
+
+// DeleteIDPConfig removes a provider's settings. The provider must not be
+// enabled as a login method.
+func (s *OrgConfigService) DeleteIDPConfig(ctx context.Context, orgID int64, provider string) error {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.DeleteIDPConfig")
+	defer span.End()
+
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	providers, err := s.configs.ListAuthProviders(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	for _, p := range providers {
+		if p.ProviderType == provider && p.IsActive {
+			return newOAuthError("invalid_request", "Disable the "+provider+" auth provider first.", http.StatusConflict)
+		}
+	}
+
+	if err := s.configs.DeleteIDPConfig(ctx, orgID, provider); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "IdP config not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "org_config.idp_deleted", "org_id", orgID, "provider", provider)
+	return nil
+}
+
+// ListRateLimits returns the org's rate limit overrides.
+func (s *OrgConfigService) ListRateLimits(ctx context.Context, orgID int64) ([]RateLimitConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.ListRateLimits")
+	defer span.End()
+
+	overrides, err := s.configs.ListRateLimitOverrides(ctx, orgID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	views := make([]RateLimitConfig, 0, len(overrides))
+	for _, o := range overrides {
+		views = append(views, rateLimitConfig(o))
+	}
+	return views, nil
+}
+
+// SaveRateLimit overrides the server-wide limit of a rate limit policy. The
+// default policy is enforced before the org is known and cannot be
+// overridden.
+func (s *OrgConfigService) SaveRateLimit(ctx context.Context, orgID int64, policy string, cfg RateLimitConfig) (RateLimitConfig, error) {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.SaveRateLimit")
+	defer span.End()
+
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if !ratelimit.Overridable(policy) {
+		return RateLimitConfig{}, newOAuthError("invalid_request", "Unsupported rate limit policy.", http.StatusBadRequest)
+	}
+	if cfg.Limit < 1 || cfg.Limit > maxRateLimit {
+		return RateLimitConfig{}, newOAuthError("invalid_request", "limit must be between 1 and 1000000.", http.StatusBadRequest)
+	}
+	if cfg.WindowSeconds < 1 || cfg.WindowSeconds > maxRateLimitWindowSeconds {
+		return RateLimitConfig{}, newOAuthError("invalid_request", "window_seconds must be between 1 and 86400.", http.StatusBadRequest)
+	}
+
+	saved, err := s.configs.SaveRateLimitOverride(ctx, domain.RateLimitOverride{
+		ID:            s.snowflake.Generate().Int64(),
+		OrgID:         orgID,
+		Policy:        policy,
+		Limit:         cfg.Limit,
+		WindowSeconds: cfg.WindowSeconds,
+	}, cfg.UpdatedAt)
+	if err := s.saved(ctx, span, err, orgID, "org_config.rate_limit_updated", "policy", policy, "limit", cfg.Limit, "window_seconds", cfg.WindowSeconds); err != nil {
+		return RateLimitConfig{}, err
+	}
+	return rateLimitConfig(saved), nil
+}
+
+// DeleteRateLimit restores the server-wide limit of a rate limit policy.
+func (s *OrgConfigService) DeleteRateLimit(ctx context.Context, orgID int64, policy string) error {
+	ctx, span := s.startSpan(ctx, "OrgConfigService.DeleteRateLimit")
+	defer span.End()
+
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if err := s.configs.DeleteRateLimitOverride(ctx, orgID, policy); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Rate limit override not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+	s.invalidate(ctx, orgID)
+
+	auditLog(s.logger, "org_config.rate_limit_deleted", "org_id", orgID, "policy", policy)
+	return nil
+}
+
+// saved finishes a write: stale versions become 409, and successful writes
+// invalidate the org context and are audited.
+func (s *OrgConfigService) saved(ctx context.Context, span trace.Span, err error, orgID int64, event string, kv ...any) error {
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "The configuration was changed since it was read; reload it and retry with the new updated_at.", http.StatusConflict)
+		}
+		span.RecordError(err)
+		return err
+	}
+	s.invalidate(ctx, orgID)
+	auditLog(s.logger, event, append([]any{"org_id", orgID}, kv...)...)
+	return nil
+}
+
+func (s *OrgConfigService) getIDPConfig(ctx context.Context, orgID int64, provider string) (domain.OAuthIDPConfig, error) {
+	cfg, err := s.configs.GetIDPConfig(ctx, orgID, strings.ToLower(strings.TrimSpace(provider)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.OAuthIDPConfig{}, newOAuthError("invalid_request", "IdP config not found.", http.StatusNotFound)
+		}
+		return domain.OAuthIDPConfig{}, err
+	}
+	return cfg, nil
+}
+
+// invalidate drops cached org contexts so configuration changes apply to the
+// next request. Failures only delay the change until the cache entries
+// expire, so they are logged.
+func (s *OrgConfigService) invalidate(ctx context.Context, orgID int64) {
+	if s.resolver == nil {
+		return
+	}
+	if err := s.resolver.Invalidate(ctx, orgID); err != nil {
+		s.logger.Warn("invalidate org context", zap.Int64("org_id", orgID), zap.Error(err))
+	}
+}
+
+func (s *OrgConfigService) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if s == nil || s.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return s.tracer.Start(ctx, name)
+}
+
+func validateIDPConfig(cfg domain.OAuthIDPConfig) error {
+	if cfg.ClientID == "" || len(cfg.ClientID) > 1024 {
+		return newOAuthError("invalid_request", "client_id is required and must be at most 1024 characters.", http.StatusBadRequest)
+	}
+	urls := map[string]string{
+		"issuer_url":        cfg.IssuerURL,
+		"authorization_url": cfg.AuthorizationURL,
+		"token_url":         cfg.TokenURL,
+		"userinfo_url":      cfg.UserinfoURL,
+		"jwks_url":          cfg.JWKSURL,
+	}
+	for field, value := range urls {
+		if err := validateConfigURL(field, value); err != nil {
+			return err
+		}
+	}
+	if cfg.Provider == "oidc" && cfg.IssuerURL == "" && (cfg.AuthorizationURL == "" || cfg.TokenURL == "") {
+		return newOAuthError("invalid_request", "oidc needs issuer_url, or authorization_url and token_url.", http.StatusBadRequest)
+	}
+	if len(cfg.Scopes) > 50 {
+		return newOAuthError("invalid_request", "scopes may list at most 50 values.", http.StatusBadRequest)
+	}
+	seen := make(map[string]bool, len(cfg.Scopes))
+	for _, scope := range cfg.Scopes {
+		if !scopeTokenPattern.MatchString(scope) || seen[scope] {
+			return newOAuthError("invalid_request", "scopes must be unique scope tokens.", http.StatusBadRequest)
+		}
+		seen[scope] = true
+	}
+	return nil
+}
+
+// validateConfigURL accepts an empty value or an absolute https URL.
+func validateConfigURL(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" || len(value) > maxBrandingURLLength {
+		return newOAuthError("invalid_request", field+" must be an absolute https URL.", http.StatusBadRequest)
+	}
+	return nil
+}
+
+func brandingConfig(b domain.Branding) BrandingConfig {
+	darkMode := b.DarkMode
+	return BrandingConfig{
+		LogoURL:          derefString(b.LogoURL),
+		FaviconURL:       derefString(b.FaviconURL),
+		PrimaryColor:     derefString(b.PrimaryColor),
+		SecondaryColor:   derefString(b.SecondaryColor),
+		AccentColor:      derefString(b.AccentColor),
+		BackgroundColor:  derefString(b.BackgroundColor),
+		TextColor:        derefString(b.TextColor),
+		DarkMode:         &darkMode,
+		CustomCSS:        derefString(b.CustomCSS),
+		CustomJS:         derefString(b.CustomJS),
+		CustomHTMLHeader: derefString(b.CustomHTMLHeader),
+		CustomHTMLFooter: derefString(b.CustomHTMLFooter),
+		CustomJSEnabled:  b.CustomJSEnabled,
+		UpdatedAt:        configVersion(b.UpdatedAt),
+	}
+}
+
+func passwordPolicyConfig(cfg domain.PasswordConfig) PasswordPolicyConfig {
+	return PasswordPolicyConfig{
+		MinLength:              cfg.MinLength,
+		RequireUppercase:       cfg.RequireUppercase,
+		RequireNumber:          cfg.RequireNumber,
+		RequireSymbol:          cfg.RequireSymbol,
+		AllowSignup:            &cfg.AllowSignup,
+		AllowPasswordReset:     &cfg.AllowPasswordReset,
+		LockoutAttempts:        &cfg.LockoutAttempts,
+		LockoutDurationSeconds: &cfg.LockoutDurationSeconds,
+		EmailVerification:      cfg.EmailVerification,
+		UpdatedAt:              configVersion(cfg.UpdatedAt),
+	}
+}
+
+func otpDeliveryConfig(cfg domain.OTPConfig) OTPDeliveryConfig {
+	return OTPDeliveryConfig{
+		Channel:       cfg.Channel,
+		Provider:      cfg.Provider,
+		APIKeySet:     cfg.APIKey != "",
+		Sender:        cfg.Sender,
+		Template:      cfg.Template,
+		ExpirySeconds: &cfg.ExpirySeconds,
+		UpdatedAt:     configVersion(cfg.UpdatedAt),
+	}
+}
+
+func authProviderConfig(p domain.AuthProvider) AuthProviderConfig {
+	return AuthProviderConfig{
+		Type:      p.ProviderType,
+		IsActive:  p.IsActive,
+		UpdatedAt: configVersion(p.UpdatedAt),
+	}
+}
+
+func idpConfig(cfg domain.OAuthIDPConfig) IDPConfig {
+	scopes := cfg.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	extra := cfg.Extra
+	if extra == nil {
+		extra = map[string]any{}
+	}
+	return IDPConfig{
+		Provider:         cfg.Provider,
+		ClientID:         cfg.ClientID,
+		ClientSecretSet:  cfg.ClientSecret != "",
+		IssuerURL:        cfg.IssuerURL,
+		AuthorizationURL: cfg.AuthorizationURL,
+		TokenURL:         cfg.TokenURL,
+		UserinfoURL:      cfg.UserinfoURL,
+		JWKSURL:          cfg.JWKSURL,
+		Scopes:           scopes,
+		Extra:            extra,
+		UpdatedAt:        configVersion(cfg.UpdatedAt),
+	}
+}
+
+func rateLimitConfig(o domain.RateLimitOverride) RateLimitConfig {
+	return RateLimitConfig{
+		Policy:        o.Policy,
+		Limit:         o.Limit,
+		WindowSeconds: o.WindowSeconds,
+		UpdatedAt:     configVersion(o.UpdatedAt),
+	}
+}
+
+// configVersion is nil for documents that have not been saved yet.
+func configVersion(updatedAt time.Time) *time.Time {
+	if updatedAt.IsZero() {
+		return nil
+	}
+	return &updatedAt
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func boolOr(value *bool, fallback bool) bool {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func intOr(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
@@ -0,0 +1,314 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/configschema"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestOrgConfigConcurrency(t *testing.T) {
+	ctx := context.Background()
+	configs := newTestOrgConfigService(&memoryOrgConfigRepo{})
+	var oauthErr *service.OAuthError
+
+	otp, err := configs.GetOTPConfig(ctx, 1)
+	require.NoError(t, err)
+	require.Nil(t, otp.UpdatedAt, "unsaved documents have no version")
+
+	key := "secret-key"
+	saved, err := configs.SaveOTPConfig(ctx, 1, service.OTPDeliveryConfig{Channel: "sms", Provider: "twilio", APIKey: &key})
+	require.NoError(t, err)
+	require.True(t, saved.APIKeySet)
+	require.NotNil(t, saved.UpdatedAt)
+
+	_, err = configs.SaveOTPConfig(ctx, 1, service.OTPDeliveryConfig{Channel: "sms", Provider: "twilio"})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "creating over an existing document conflicts")
+
+	saved.Provider = "vonage"
+	updated, err := configs.SaveOTPConfig(ctx, 1, saved)
+	require.NoError(t, err)
+	require.Equal(t, "vonage", updated.Provider)
+	require.True(t, updated.APIKeySet, "omitted api_key keeps the stored key")
+
+	_, err = configs.SaveOTPConfig(ctx, 1, saved)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "saving a stale version conflicts")
+
+	saved.Channel = "pigeon"
+	saved.UpdatedAt = updated.UpdatedAt
+	_, err = configs.SaveOTPConfig(ctx, 1, saved)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 400, oauthErr.Status)
+}
+
+func TestOrgConfigProviders(t *testing.T) {
+	ctx := context.Background()
+	configs := newTestOrgConfigService(&memoryOrgConfigRepo{})
+	var oauthErr *service.OAuthError
+
+	_, err := configs.SaveAuthProvider(ctx, 1, "google", service.AuthProviderConfig{IsActive: true})
+	require.ErrorAs(t, err, &oauthErr, "social providers need an IdP config")
+	_, err = configs.SaveAuthProvider(ctx, 1, "saml", service.AuthProviderConfig{IsActive: true})
+	require.ErrorAs(t, err, &oauthErr)
+
+	_, err = configs.SaveIDPConfig(ctx, 1, "oidc", service.IDPConfig{ClientID: "client"})
+	require.ErrorAs(t, err, &oauthErr, "oidc needs endpoints")
+	_, err = configs.SaveIDPConfig(ctx, 1, "google", service.IDPConfig{ClientID: "client", TokenURL: "http://example.com/token"})
+	require.ErrorAs(t, err, &oauthErr, "endpoints must use https")
+
+	secret := "shh"
+	idp, err := configs.SaveIDPConfig(ctx, 1, "Google", service.IDPConfig{ClientID: "client", ClientSecret: &secret, Scopes: []string{"openid", "email"}})
+	require.NoError(t, err)
+	require.Equal(t, "google", idp.Provider)
+	require.True(t, idp.ClientSecretSet)
+	require.Nil(t, idp.ClientSecret, "secrets are never returned")
+
+	provider, err := configs.SaveAuthProvider(ctx, 1, "google", service.AuthProviderConfig{IsActive: true})
+	require.NoError(t, err)
+	require.True(t, provider.IsActive)
+
+	err = configs.DeleteIDPConfig(ctx, 1, "google")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "an enabled provider keeps its IdP config")
+
+	require.NoError(t, configs.DeleteAuthProvider(ctx, 1, "google"))
+	require.NoError(t, configs.DeleteIDPConfig(ctx, 1, "google"))
+	_, err = configs.GetIDPConfig(ctx, 1, "google")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 404, oauthErr.Status)
+}
+
+func TestOrgConfigRateLimits(t *testing.T) {
+	ctx := context.Background()
+	configs := newTestOrgConfigService(&memoryOrgConfigRepo{})
+	var oauthErr *service.OAuthError
+
+	_, err := configs.SaveRateLimit(ctx, 1, "default", service.RateLimitConfig{Limit: 10, WindowSeconds: 60})
+	require.ErrorAs(t, err, &oauthErr, "the default policy applies before the org is known")
+	_, err = configs.SaveRateLimit(ctx, 1, "auth", service.RateLimitConfig{Limit: 0, WindowSeconds: 60})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 400, oauthErr.Status)
+
+	saved, err := configs.SaveRateLimit(ctx, 1, "Auth", service.RateLimitConfig{Limit: 5, WindowSeconds: 60})
+	require.NoError(t, err)
+	require.Equal(t, "auth", saved.Policy)
+	require.NotNil(t, saved.UpdatedAt)
+
+	_, err = configs.SaveRateLimit(ctx, 1, "auth", service.RateLimitConfig{Limit: 6, WindowSeconds: 60})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "updates must name the version they replace")
+	updated, err := configs.SaveRateLimit(ctx, 1, "auth", service.RateLimitConfig{Limit: 6, WindowSeconds: 60, UpdatedAt: saved.UpdatedAt})
+	require.NoError(t, err)
+	require.Equal(t, 6, updated.Limit)
+
+	limits, err := configs.ListRateLimits(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, limits, 1)
+
+	require.NoError(t, configs.DeleteRateLimit(ctx, 1, "auth"))
+	err = configs.DeleteRateLimit(ctx, 1, "auth")
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 404, oauthErr.Status)
+}
+
+// TestConfigSchemasMatchInputs keeps the published schemas and the request
+// structs from drifting apart.
+func TestConfigSchemasMatchInputs(t *testing.T) {
+	inputs := map[string]any{
+		"branding":        service.BrandingConfig{},
+		"password-config": service.PasswordPolicyConfig{},
+		"otp-config":      service.OTPDeliveryConfig{},
+		"auth-provider":   service.AuthProviderConfig{},
+		"idp-config":      service.IDPConfig{},
+		"rate-limit":      service.RateLimitConfig{},
+	}
+	require.Len(t, configschema.Names(), len(inputs))
+
+	for name, input := range inputs {
+		raw, ok := configschema.Get(name)
+		require.True(t, ok, name)
+		var schema struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &schema), name)
+
+		fields := map[string]bool{}
+		typ := reflect.TypeOf(input)
+		for i := 0; i < typ.NumField(); i++ {
+			tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields[tag] = true
+		}
+		for property := range schema.Properties {
+			require.True(t, fields[property], "%s: schema property %q has no input field", name, property)
+		}
+	}
+
+	_, ok := configschema.Get("../schema")
+	require.False(t, ok)
+}
+
+func newTestOrgConfigService(repo *memoryOrgConfigRepo) *service.OrgConfigService {
+	node, _ := snowflake.NewNode(1)
+	return service.NewOrgConfigService(repo, nil, node, zap.NewNop())
+}
+
+// memoryOrgConfigRepo stores a single org's OTP config, auth providers, IdP
+// configs and rate limit overrides with the repository's versioning rules.
+type memoryOrgConfigRepo struct {
+	repository.OrgConfigRepository
+	otp       *domain.OTPConfig
+	providers []domain.AuthProvider
+	idps      []domain.OAuthIDPConfig
+	limits    []domain.RateLimitOverride
+}
+
+func (m *memoryOrgConfigRepo) GetOTPConfig(_ context.Context, orgID int64) (domain.OTPConfig, error) {
+	if m.otp == nil {
+		return domain.OTPConfig{OrgID: orgID, Channel: "sms", ExpirySeconds: 300}, nil
+	}
+	return *m.otp, nil
+}
+
+func (m *memoryOrgConfigRepo) SaveOTPConfig(_ context.Context, cfg domain.OTPConfig, expected *time.Time) (domain.OTPConfig, error) {
+	if !versionMatches(m.otp != nil, func() time.Time { return m.otp.UpdatedAt }, expected) {
+		return domain.OTPConfig{}, pgx.ErrNoRows
+	}
+	cfg.UpdatedAt = nextVersion()
+	m.otp = &cfg
+	return cfg, nil
+}
+
+func (m *memoryOrgConfigRepo) ListAuthProviders(context.Context, int64) ([]domain.AuthProvider, error) {
+	return m.providers, nil
+}
+
+func (m *memoryOrgConfigRepo) SaveAuthProvider(_ context.Context, p domain.AuthProvider, expected *time.Time) (domain.AuthProvider, error) {
+	for i, existing := range m.providers {
+		if existing.ProviderType == p.ProviderType {
+			if expected == nil || !existing.UpdatedAt.Equal(*expected) {
+				return domain.AuthProvider{}, pgx.ErrNoRows
+			}
+			p.UpdatedAt = nextVersion()
+			m.providers[i] = p
+			return p, nil
+		}
+	}
+	if expected != nil {
+		return domain.AuthProvider{}, pgx.ErrNoRows
+	}
+	p.UpdatedAt = nextVersion()
+	m.providers = append(m.providers, p)
+	return p, nil
+}
+
+func (m *memoryOrgConfigRepo) DeleteAuthProvider(_ context.Context, _ int64, providerType string) error {
+	for i, existing := range m.providers {
+		if existing.ProviderType == providerType {
+			m.providers = append(m.providers[:i], m.providers[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *memoryOrgConfigRepo) GetIDPConfig(_ context.Context, _ int64, provider string) (domain.OAuthIDPConfig, error) {
+	for _, existing := range m.idps {
+		if existing.Provider == provider {
+			return existing, nil
+		}
+	}
+	return domain.OAuthIDPConfig{}, pgx.ErrNoRows
+}
+
+func (m *memoryOrgConfigRepo) SaveIDPConfig(_ context.Context, cfg domain.OAuthIDPConfig, expected *time.Time) (domain.OAuthIDPConfig, error) {
+	for i, existing := range m.idps {
+		if existing.Provider == cfg.Provider {
+			if expected == nil || !existing.UpdatedAt.Equal(*expected) {
+				return domain.OAuthIDPConfig{}, pgx.ErrNoRows
+			}
+			cfg.ID = existing.ID
+			cfg.UpdatedAt = nextVersion()
+			m.idps[i] = cfg
+			return cfg, nil
+		}
+	}
+	if expected != nil {
+		return domain.OAuthIDPConfig{}, pgx.ErrNoRows
+	}
+	cfg.UpdatedAt = nextVersion()
+	m.idps = append(m.idps, cfg)
+	return cfg, nil
+}
+
+func (m *memoryOrgConfigRepo) DeleteIDPConfig(_ context.Context, _ int64, provider string) error {
+	for i, existing := range m.idps {
+		if existing.Provider == provider {
+			m.idps = append(m.idps[:i], m.idps[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *memoryOrgConfigRepo) ListRateLimitOverrides(context.Context, int64) ([]domain.RateLimitOverride, error) {
+	return m.limits, nil
+}
+
+func (m *memoryOrgConfigRepo) SaveRateLimitOverride(_ context.Context, o domain.RateLimitOverride, expected *time.Time) (domain.RateLimitOverride, error) {
+	for i, existing := range m.limits {
+		if existing.Policy == o.Policy {
+			if expected == nil || !existing.UpdatedAt.Equal(*expected) {
+				return domain.RateLimitOverride{}, pgx.ErrNoRows
+			}
+			o.UpdatedAt = nextVersion()
+			m.limits[i] = o
+			return o, nil
+		}
+	}
+	if expected != nil {
+		return domain.RateLimitOverride{}, pgx.ErrNoRows
+	}
+	o.UpdatedAt = nextVersion()
+	m.limits = append(m.limits, o)
+	return o, nil
+}
+
+func (m *memoryOrgConfigRepo) DeleteRateLimitOverride(_ context.Context, _ int64, policy string) error {
+	for i, existing := range m.limits {
+		if existing.Policy == policy {
+			m.limits = append(m.limits[:i], m.limits[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+// versionMatches applies the repository rule: a nil version creates, any
+// other version must equal the stored one.
+func versionMatches(exists bool, current func() time.Time, expected *time.Time) bool {
+	if expected == nil {
+		return !exists
+	}
+	return exists && current().Equal(*expected)
+}
+
+var configVersionClock = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func nextVersion() time.Time {
+	configVersionClock = configVersionClock.Add(time.Second)
+	return configVersionClock
+}
@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestOrgLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryOrgRepo{items: map[int64]domain.Org{
+		1: {ID: 1, Type: domain.OrgTypePlatform, Name: "Railzway", Slug: "railzway", Status: domain.OrgStatusActive},
+	}}
+	node, _ := snowflake.NewNode(1)
+	retention := 30 * 24 * time.Hour
+	orgs := service.NewOrgService(repo, nil, node, config.Config{OrgDeletionRetention: retention}, zap.NewNop())
+
+	var oauthErr *service.OAuthError
+	_, err := orgs.CreateOrg(ctx, service.OrgInput{Name: "Acme", Slug: "Acme Inc"})
+	require.ErrorAs(t, err, &oauthErr, "slugs must be DNS labels")
+	_, err = orgs.CreateOrg(ctx, service.OrgInput{Name: "Acme", Slug: "acme", Type: domain.OrgTypePlatform})
+	require.ErrorAs(t, err, &oauthErr, "there is only one platform org")
+
+	acme, err := orgs.CreateOrg(ctx, service.OrgInput{Name: "Acme", Slug: "Acme", CountryCode: "sg"})
+	require.NoError(t, err)
+	require.Equal(t, "acme", acme.Slug)
+	require.Equal(t, domain.OrgTypeCompany, acme.Type)
+	require.Equal(t, domain.OrgStatusActive, acme.Status)
+
+	_, err = orgs.CreateOrg(ctx, service.OrgInput{Name: "Acme 2", Slug: "acme"})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status)
+
+	name := "Acme Corp"
+	updated, err := orgs.UpdateOrg(ctx, acme.ID, service.OrgUpdate{Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, "Acme Corp", updated.Name)
+	require.Equal(t, "acme", updated.Slug, "unset fields are kept")
+
+	_, err = orgs.SuspendOrg(ctx, 1, 1)
+	require.ErrorAs(t, err, &oauthErr, "the platform org cannot be suspended")
+	_, err = orgs.SuspendOrg(ctx, acme.ID, acme.ID)
+	require.ErrorAs(t, err, &oauthErr, "admins cannot suspend their own org")
+
+	suspended, err := orgs.SuspendOrg(ctx, 1, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.OrgStatusSuspended, suspended.Status)
+	activated, err := orgs.ActivateOrg(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.OrgStatusActive, activated.Status)
+
+	deleted, err := orgs.DeleteOrg(ctx, 1, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.OrgStatusDeleted, deleted.Status)
+	require.NotNil(t, deleted.PurgeAfter)
+	require.Equal(t, deleted.DeletedAt.Add(retention), *deleted.PurgeAfter)
+
+	_, err = orgs.UpdateOrg(ctx, acme.ID, service.OrgUpdate{Name: &name})
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 409, oauthErr.Status, "deleted orgs are read-only")
+
+	page, err := orgs.ListOrgs(ctx, "deleted", 0, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, page.Total)
+	require.Equal(t, 50, page.Limit)
+
+	restored, err := orgs.RestoreOrg(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.OrgStatusSuspended, restored.Status, "restored orgs are reviewed before activation")
+	require.Nil(t, restored.DeletedAt)
+
+	_, err = orgs.DeleteOrg(ctx, 1, acme.ID)
+	require.NoError(t, err)
+	purged, err := orgs.PurgeDeletedOrgs(ctx)
+	require.NoError(t, err)
+	require.Zero(t, purged, "orgs are kept for the retention period")
+
+	past := time.Now().Add(-retention - time.Hour)
+	o := repo.items[acme.ID]
+	o.DeletedAt = &past
+	repo.items[acme.ID] = o
+	purged, err = orgs.PurgeDeletedOrgs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	_, err = orgs.GetOrg(ctx, acme.ID)
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 404, oauthErr.Status)
+}
+
+type memoryOrgRepo struct {
+	repository.OrgRepository
+	items map[int64]domain.Org
+}
+
+func (m *memoryOrgRepo) GetOrg(_ context.Context, orgID int64) (domain.Org, error) {
+	o, ok := m.items[orgID]
+	if !ok {
+		return domain.Org{}, pgx.ErrNoRows
+	}
+	return o, nil
+}
+
+func (m *memoryOrgRepo) Create(_ context.Context, o domain.Org) (domain.Org, error) {
+	for _, existing := range m.items {
+		if existing.Slug == o.Slug {
+			return domain.Org{}, &pgconn.PgError{Code: "23505"}
+		}
+	}
+	o.CreatedAt = time.Now()
+	m.items[o.ID] = o
+	return o, nil
+}
+
+func (m *memoryOrgRepo) List(_ context.Context, status string, limit, offset int) ([]domain.Org, int64, error) {
+	var orgs []domain.Org
+	for _, o := range m.items {
+		if status == "" || o.Status == status {
+			orgs = append(orgs, o)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].ID < orgs[j].ID })
+	total := int64(len(orgs))
+	if offset > len(orgs) {
+		offset = len(orgs)
+	}
+	orgs = orgs[offset:]
+	if len(orgs) > limit {
+		orgs = orgs[:limit]
+	}
+	return orgs, total, nil
+}
+
+func (m *memoryOrgRepo) Update(_ context.Context, o domain.Org) (domain.Org, error) {
+	existing, ok := m.items[o.ID]
+	if !ok || existing.DeletedAt != nil {
+		return domain.Org{}, pgx.ErrNoRows
+	}
+	m.items[o.ID] = o
+	return o, nil
+}
+
+func (m *memoryOrgRepo) SetStatus(_ context.Context, orgID int64, status string) (domain.Org, error) {
+	o, ok := m.items[orgID]
+	if !ok || o.DeletedAt != nil {
+		return domain.Org{}, pgx.ErrNoRows
+	}
+	o.Status = status
+	m.items[orgID] = o
+	return o, nil
+}
+
+func (m *memoryOrgRepo) SoftDelete(_ context.Context, orgID int64) (domain.Org, error) {
+	o, ok := m.items[orgID]
+	if !ok || o.DeletedAt != nil {
+		return domain.Org{}, pgx.ErrNoRows
+	}
+	now := time.Now()
+	o.Status = domain.OrgStatusDeleted
+	o.DeletedAt = &now
+	m.items[orgID] = o
+	return o, nil
+}
+
+func (m *memoryOrgRepo) Restore(_ context.Context, orgID int64, status string) (domain.Org, error) {
+	o, ok := m.items[orgID]
+	if !ok || o.DeletedAt == nil {
+		return domain.Org{}, pgx.ErrNoRows
+	}
+	o.Status = status
+	o.DeletedAt = nil
+	m.items[orgID] = o
+	return o, nil
+}
+
+func (m *memoryOrgRepo) PurgeDeleted(_ context.Context, deletedBefore time.Time) ([]int64, error) {
+	var ids []int64
+	for id, o := range m.items {
+		if o.DeletedAt != nil && o.DeletedAt.Before(deletedBefore) {
+			delete(m.items, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gojose "github.com/go-jose/go-jose/v4"
+	gojwt "github.com/go-jose/go-jose/v4/jwt"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+)
+
+// RequestObjectSigningAlgs lists the algorithms accepted for signed request
+// objects. Unsigned ("none") request objects are never accepted.
+var RequestObjectSigningAlgs = []gojose.SignatureAlgorithm{
+	gojose.RS256, gojose.RS384, gojose.RS512,
+	gojose.PS256, gojose.PS384, gojose.PS512,
+	gojose.ES256, gojose.ES384, gojose.ES512,
+	gojose.EdDSA,
+}
+
+// requestObjectLeeway tolerates clock skew between the client and us.
+const requestObjectLeeway = time.Minute
+
+// requestObjectRegisteredClaims are JWT claims that describe the request
+// object itself rather than authorization request parameters.
+var requestObjectRegisteredClaims = map[string]bool{
+	"iss": true, "aud": true, "exp": true, "iat": true, "nbf": true, "jti": true, "sub": true,
+}
+
+// AuthenticateClient verifies a confidential client's credentials for org.
+func (s *AuthService) AuthenticateClient(ctx context.Context, orgID int64, clientID, clientSecret string) (domain.OAuthClient, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.AuthenticateClient")
+	defer span.End()
+
+	cleanClient := strings.TrimSpace(clientID)
+	if cleanClient == "" {
+		return domain.OAuthClient{}, newOAuthError("invalid_client", "client_id is required.", http.StatusUnauthorized)
+	}
+	cleanSecret := strings.TrimSpace(clientSecret)
+	if cleanSecret == "" {
+		return domain.OAuthClient{}, newOAuthError("invalid_client", "client_secret is required.", http.StatusUnauthorized)
+	}
+
+	client, err := s.clients.GetClientByID(ctx, orgID, cleanClient)
+	if err != nil {
+		span.RecordError(err)
+		return domain.OAuthClient{}, newOAuthError("invalid_client", "Client authentication failed.", http.StatusUnauthorized)
+	}
+	if client.ClientSecret == "" || !secureCompare(client.ClientSecret, cleanSecret) {
+		return domain.OAuthClient{}, newOAuthError("invalid_client", "Client authentication failed.", http.StatusUnauthorized)
+	}
+	return client, nil
+}
+
+// ClientRequiresPAR reports whether clientID must send authorize requests
+// through the pushed authorization request endpoint.
+func (s *AuthService) ClientRequiresPAR(ctx context.Context, orgID int64, clientID string) bool {
+	if s == nil || s.clients == nil {
+		return false
+	}
+	client, err := s.clients.GetClientByID(ctx, orgID, strings.TrimSpace(clientID))
+	if err != nil {
+		// Unknown clients are rejected later by redirect_uri validation.
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.log().Warn("lookup oauth client failed", zap.Int64("org_id", orgID), zap.String("client_id", clientID), zap.Error(err))
+		}
+		return false
+	}
+	return client.RequirePAR
+}
+
+// VerifyRequestObject verifies a signed authorization request object (RFC
+// 9101) against the client's registered keys and returns its parameters.
+func (s *AuthService) VerifyRequestObject(ctx context.Context, orgID int64, clientID, requestObject, issuer string) (url.Values, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.VerifyRequestObject")
+	defer span.End()
+
+	invalid := func(desc string) error {
+		return newOAuthError("invalid_request_object", desc, http.StatusBadRequest)
+	}
+
+	cleanClient := strings.TrimSpace(clientID)
+	if cleanClient == "" {
+		return nil, newOAuthError("invalid_request", "client_id is required.", http.StatusBadRequest)
+	}
+	client, err := s.clients.GetClientByID(ctx, orgID, cleanClient)
+	if err != nil {
+		span.RecordError(err)
+		return nil, newOAuthError("unauthorized_client", "Unknown client_id for org.", http.StatusBadRequest)
+	}
+	keys, err := ParseClientJWKS(client.JWKS)
+	if err != nil || keys == nil || len(keys.Keys) == 0 {
+		return nil, invalid("Client has no registered keys for request objects.")
+	}
+
+	parsed, err := gojwt.ParseSigned(strings.TrimSpace(requestObject), RequestObjectSigningAlgs)
+	if err != nil {
+		return nil, invalid("Request object must be a signed JWT.")
+	}
+	var key any
+	kid := parsed.Headers[0].KeyID
+	switch {
+	case kid != "":
+		matches := keys.Key(kid)
+		if len(matches) == 0 {
+			return nil, invalid("Request object key is not registered.")
+		}
+		key = matches[0].Key
+	case len(keys.Keys) == 1:
+		key = keys.Keys[0].Key
+	default:
+		return nil, invalid("Request object must name its key with kid.")
+	}
+
+	var std gojwt.Claims
+	claims := map[string]any{}
+	if err := parsed.Claims(key, &std, &claims); err != nil {
+		return nil, invalid("Request object signature is invalid.")
+	}
+	if std.Expiry == nil {
+		return nil, invalid("Request object must carry exp.")
+	}
+	err = std.ValidateWithLeeway(gojwt.Expected{
+		Issuer:      client.ClientID,
+		AnyAudience: gojwt.Audience{issuer},
+		Time:        time.Now().UTC(),
+	}, requestObjectLeeway)
+	if err != nil {
+		return nil, invalid("Request object claims are invalid.")
+	}
+
+	values := url.Values{}
+	for name, raw := range claims {
+		if requestObjectRegisteredClaims[name] {
+			continue
+		}
+		if name == "request" || name == "request_uri" {
+			return nil, invalid("Request objects cannot be nested.")
+		}
+		value, err := requestObjectValue(raw)
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("Request object claim %s is invalid.", name))
+		}
+		values.Set(name, value)
+	}
+	if claimed := values.Get("client_id"); claimed != "" && claimed != client.ClientID {
+		return nil, invalid("Request object client_id does not match.")
+	}
+	values.Set("client_id", client.ClientID)
+	return values, nil
+}
+
+// ParseClientJWKS decodes a client's registered JSON Web Key Set. It returns
+// nil for an empty set and rejects private or unusable keys.
+func ParseClientJWKS(raw string) (*gojose.JSONWebKeySet, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var set gojose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(raw), &set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+	for _, key := range set.Keys {
+		if !key.Valid() {
+			return nil, errors.New("jwks contains an invalid key")
+		}
+		if !key.IsPublic() {
+			return nil, errors.New("jwks must only contain public keys")
+		}
+	}
+	return &set, nil
+}
+
+// requestObjectValue converts a request object claim to its authorize
+// parameter form. Objects such as the OIDC claims parameter stay JSON.
+func requestObjectValue(raw any) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case map[string]any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	default:
+		return "", fmt.Errorf("unsupported claim type %T", raw)
+	}
+}
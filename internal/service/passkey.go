@@ -0,0 +1,680 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+const (
+	webauthnSessionPrefix = "webauthn:session:"
+	passkeyCeremonyTTL    = 5 * time.Minute
+	passkeyNameMaxLength  = 100
+)
+
+// PasskeyOptions carries the options passed to navigator.credentials.create()
+// or navigator.credentials.get(), together with the server-side session handle.
+type PasskeyOptions struct {
+	SessionID string `json:"session_id"`
+	Options   any    `json:"options"`
+}
+
+// PasskeyCredential is the public view of a registered passkey.
+type PasskeyCredential struct {
+	ID             int64      `json:"id,string"`
+	Name           string     `json:"name"`
+	RPID           string     `json:"rp_id"`
+	Transports     []string   `json:"transports"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackupState    bool       `json:"backup_state"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// BeginPasskeyRegistration starts a registration ceremony for a signed-in user.
+func (s *AuthService) BeginPasskeyRegistration(ctx context.Context, orgCtx *org.Context, userID int64, origin string) (PasskeyOptions, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.BeginPasskeyRegistration")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return PasskeyOptions{}, err
+	}
+	rp, err := s.relyingParty(ctx, orgCtx, origin)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, fmt.Errorf("passkey load user: %w", err)
+	}
+	owner, err := s.webauthnUser(ctx, user, "")
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+
+	creation, session, err := rp.BeginRegistration(owner,
+		webauthn.WithExclusions(webauthn.Credentials(owner.credentials).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, fmt.Errorf("begin passkey registration: %w", err)
+	}
+
+	sessionID, err := s.saveWebAuthnSession(ctx, orgCtx.Org.ID, user.ID, domain.WebAuthnPurposeRegister, "", session)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	return PasskeyOptions{SessionID: sessionID, Options: creation}, nil
+}
+
+// FinishPasskeyRegistration verifies the attestation response and stores the new credential.
+func (s *AuthService) FinishPasskeyRegistration(ctx context.Context, orgCtx *org.Context, userID int64, sessionID, name, origin string, response []byte) (PasskeyCredential, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.FinishPasskeyRegistration")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return PasskeyCredential{}, err
+	}
+	session, data, err := s.consumeWebAuthnSession(ctx, orgCtx, sessionID, domain.WebAuthnPurposeRegister)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, err
+	}
+	if session.UserID != userID {
+		return PasskeyCredential{}, newOAuthError("invalid_request", "Passkey session does not belong to user.", http.StatusForbidden)
+	}
+
+	rp, err := s.relyingParty(ctx, orgCtx, origin)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, err
+	}
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, fmt.Errorf("passkey load user: %w", err)
+	}
+	owner, err := s.webauthnUser(ctx, user, "")
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, newOAuthError("invalid_request", "Malformed passkey response.", http.StatusBadRequest)
+	}
+	credential, err := rp.CreateCredential(owner, *data, parsed)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, newOAuthError("invalid_grant", "Passkey registration could not be verified.", http.StatusBadRequest)
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+	stored, err := s.passkeys.Create(ctx, domain.WebAuthnCredential{
+		ID:              s.snowflake.Generate().Int64(),
+		OrgID:           orgCtx.Org.ID,
+		UserID:          userID,
+		RPID:            rp.Config.RPID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: coalesce(credential.AttestationType, "none"),
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            passkeyName(name),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyCredential{}, err
+	}
+
+	s.audit("passkey.registered", "org_id", orgCtx.Org.ID, "user_id", userID, "credential_id", stored.ID)
+	return newPasskeyCredential(stored), nil
+}
+
+// ListPasskeys returns the passkeys registered by the user.
+func (s *AuthService) ListPasskeys(ctx context.Context, orgCtx *org.Context, userID int64) ([]PasskeyCredential, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.ListPasskeys")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return nil, err
+	}
+	credentials, err := s.passkeys.ListByUser(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	views := make([]PasskeyCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		views = append(views, newPasskeyCredential(credential))
+	}
+	return views, nil
+}
+
+// DeletePasskey removes one of the user's passkeys.
+func (s *AuthService) DeletePasskey(ctx context.Context, orgCtx *org.Context, userID, credentialID int64) error {
+	ctx, span := s.startSpan(ctx, "AuthService.DeletePasskey")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return err
+	}
+	if err := s.passkeys.Delete(ctx, orgCtx.Org.ID, userID, credentialID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Passkey not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+	s.audit("passkey.deleted", "org_id", orgCtx.Org.ID, "user_id", userID, "credential_id", credentialID)
+	return nil
+}
+
+// BeginPasskeyLogin starts a discoverable assertion ceremony used as a first factor.
+func (s *AuthService) BeginPasskeyLogin(ctx context.Context, orgCtx *org.Context, origin string) (PasskeyOptions, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.BeginPasskeyLogin")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return PasskeyOptions{}, err
+	}
+	if !authProviderEnabled(orgCtx, domain.AuthProviderPasskey) {
+		return PasskeyOptions{}, newOAuthError("unsupported_grant_type", "Passkey login disabled for org.", http.StatusBadRequest)
+	}
+	rp, err := s.relyingParty(ctx, orgCtx, origin)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+
+	assertion, session, err := rp.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, fmt.Errorf("begin passkey login: %w", err)
+	}
+	sessionID, err := s.saveWebAuthnSession(ctx, orgCtx.Org.ID, 0, domain.WebAuthnPurposeLogin, "", session)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	return PasskeyOptions{SessionID: sessionID, Options: assertion}, nil
+}
+
+// FinishPasskeyLogin is the REST counterpart of PasskeyGrant.
+func (s *AuthService) FinishPasskeyLogin(ctx context.Context, orgID int64, sessionID, clientID, scope, issuer, origin string, response []byte) (AuthTokensWithUser, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.FinishPasskeyLogin")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, clientID)
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+
+	effectiveIssuer := strings.TrimSpace(issuer)
+	if effectiveIssuer == "" {
+		effectiveIssuer = orgIssuer(orgCtx)
+	}
+	tokenResp, user, err := s.passkeyLogin(ctx, orgCtx, sessionID, coalesce(scope, defaultRESTScope), effectiveIssuer, origin, response)
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+
+	s.audit("rest.passkey_login.success", "org_id", orgID, "user_id", user.ID)
+	return newAuthTokensWithUser(user, tokenResp), nil
+}
+
+// PasskeyGrant verifies a discoverable assertion and issues tokens. A
+// user-verified passkey already combines possession and inherence, so the
+// org MFA policy is considered satisfied.
+func (s *AuthService) PasskeyGrant(ctx context.Context, orgCtx *org.Context, sessionID, scope, issuer, origin string, response []byte) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.PasskeyGrant")
+	defer span.End()
+
+	resp, _, err := s.passkeyLogin(ctx, orgCtx, sessionID, scope, issuer, origin, response)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
+}
+
+func (s *AuthService) passkeyLogin(ctx context.Context, orgCtx *org.Context, sessionID, scope, issuer, origin string, response []byte) (*TokenResponse, domain.User, error) {
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return nil, domain.User{}, err
+	}
+	if !authProviderEnabled(orgCtx, domain.AuthProviderPasskey) {
+		return nil, domain.User{}, newOAuthError("unsupported_grant_type", "Passkey login disabled for org.", http.StatusBadRequest)
+	}
+	_, data, err := s.consumeWebAuthnSession(ctx, orgCtx, sessionID, domain.WebAuthnPurposeLogin)
+	if err != nil {
+		return nil, domain.User{}, err
+	}
+	rp, err := s.relyingParty(ctx, orgCtx, origin)
+	if err != nil {
+		return nil, domain.User{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, domain.User{}, newOAuthError("invalid_request", "Malformed passkey response.", http.StatusBadRequest)
+	}
+
+	var owner *webauthnUser
+	lookup := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := decodeUserHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := s.passkeys.GetByCredentialID(ctx, orgCtx.Org.ID, rawID)
+		if err != nil {
+			return nil, err
+		}
+		if stored.UserID != userID {
+			return nil, fmt.Errorf("credential does not belong to user handle")
+		}
+		user, err := s.users.GetByID(ctx, orgCtx.Org.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		owner, err = s.webauthnUser(ctx, user, rp.Config.RPID)
+		return owner, err
+	}
+	if _, _, err := rp.ValidatePasskeyLogin(lookup, *data, parsed); err != nil || owner == nil {
+		s.audit("passkey.login.failed", "org_id", orgCtx.Org.ID)
+		return nil, domain.User{}, newOAuthError("invalid_grant", "Passkey could not be verified.", http.StatusForbidden)
+	}
+	if err := s.recordPasskeyUse(ctx, owner, parsed); err != nil {
+		return nil, domain.User{}, err
+	}
+
+	resp, err := s.issueTokens(ctx, orgCtx, owner.user, scope, issuer, []string{domain.AuthProviderPasskey})
+	if err != nil {
+		return nil, domain.User{}, err
+	}
+	s.audit("passkey.login.success", "org_id", orgCtx.Org.ID, "user_id", owner.user.ID)
+	return resp, owner.user, nil
+}
+
+// BeginPasskeyMFA starts an assertion ceremony that completes a pending MFA
+// challenge with one of the user's passkeys.
+func (s *AuthService) BeginPasskeyMFA(ctx context.Context, orgCtx *org.Context, mfaToken, origin string) (PasskeyOptions, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.BeginPasskeyMFA")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return PasskeyOptions{}, err
+	}
+	challenge, err := s.loadMFAChallenge(ctx, orgCtx, mfaToken)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	rp, err := s.relyingParty(ctx, orgCtx, origin)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	user, err := s.users.GetByID(ctx, orgCtx.Org.ID, challenge.UserID)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, fmt.Errorf("passkey load user: %w", err)
+	}
+	owner, err := s.webauthnUser(ctx, user, rp.Config.RPID)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	if len(owner.credentials) == 0 {
+		return PasskeyOptions{}, newOAuthError("invalid_request", "No passkey registered.", http.StatusBadRequest)
+	}
+
+	assertion, session, err := rp.BeginLogin(owner, webauthn.WithUserVerification(protocol.VerificationPreferred))
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, fmt.Errorf("begin passkey mfa: %w", err)
+	}
+	sessionID, err := s.saveWebAuthnSession(ctx, orgCtx.Org.ID, user.ID, domain.WebAuthnPurposeMFA, challenge.ChallengeID, session)
+	if err != nil {
+		span.RecordError(err)
+		return PasskeyOptions{}, err
+	}
+	return PasskeyOptions{SessionID: sessionID, Options: assertion}, nil
+}
+
+// MFAPasskeyGrant completes a pending challenge using a passkey assertion.
+func (s *AuthService) MFAPasskeyGrant(ctx context.Context, orgCtx *org.Context, mfaToken, sessionID, origin string, response []byte) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.MFAPasskeyGrant")
+	defer span.End()
+
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return nil, err
+	}
+	resp, _, err := s.completeMFA(ctx, orgCtx, mfaToken, s.passkeyVerifier(orgCtx, sessionID, origin, response))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
+}
+
+// VerifyMFAPasskey is the REST counterpart of MFAPasskeyGrant.
+func (s *AuthService) VerifyMFAPasskey(ctx context.Context, orgID int64, mfaToken, sessionID, origin string, response []byte) (AuthTokensWithUser, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.VerifyMFAPasskey")
+	defer span.End()
+
+	orgCtx, err := s.orgContextFromContext(ctx, orgID, "")
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+	if err := s.requirePasskeysConfigured(); err != nil {
+		return AuthTokensWithUser{}, err
+	}
+
+	tokenResp, user, err := s.completeMFA(ctx, orgCtx, mfaToken, s.passkeyVerifier(orgCtx, sessionID, origin, response))
+	if err != nil {
+		span.RecordError(err)
+		return AuthTokensWithUser{}, err
+	}
+	s.audit("rest.mfa_verify.success", "org_id", orgID, "user_id", user.ID, "factor", domain.AuthProviderPasskey)
+	return newAuthTokensWithUser(user, tokenResp), nil
+}
+
+func (s *AuthService) passkeyVerifier(orgCtx *org.Context, sessionID, origin string, response []byte) mfaVerifier {
+	return func(ctx context.Context, challenge *domain.MFAChallenge) ([]string, error) {
+		session, data, err := s.consumeWebAuthnSession(ctx, orgCtx, sessionID, domain.WebAuthnPurposeMFA)
+		if err != nil {
+			return nil, err
+		}
+		if session.MFAToken != challenge.ChallengeID || session.UserID != challenge.UserID {
+			return nil, newOAuthError("invalid_grant", "Passkey session does not match mfa_token.", http.StatusForbidden)
+		}
+		rp, err := s.relyingParty(ctx, orgCtx, origin)
+		if err != nil {
+			return nil, err
+		}
+		user, err := s.users.GetByID(ctx, challenge.OrgID, challenge.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("passkey load user: %w", err)
+		}
+		owner, err := s.webauthnUser(ctx, user, rp.Config.RPID)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+		if err != nil {
+			return nil, newOAuthError("invalid_request", "Malformed passkey response.", http.StatusBadRequest)
+		}
+		if _, err := rp.ValidateLogin(owner, *data, parsed); err != nil {
+			return nil, newOAuthError("invalid_grant", "Passkey could not be verified.", http.StatusForbidden)
+		}
+		return nil, s.recordPasskeyUse(ctx, owner, parsed)
+	}
+}
+
+// webauthnUser adapts a domain user and its stored passkeys to webauthn.User.
+type webauthnUser struct {
+	user        domain.User
+	stored      []domain.WebAuthnCredential
+	credentials []webauthn.Credential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return encodeUserHandle(u.user.ID)
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return coalesce(u.user.Email, u.user.Phone, fmt.Sprintf("%d", u.user.ID))
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	return coalesce(u.user.Name, u.WebAuthnName())
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// webauthnUser loads the user's passkeys. When rpID is set only credentials
+// bound to that RP ID are returned.
+func (s *AuthService) webauthnUser(ctx context.Context, user domain.User, rpID string) (*webauthnUser, error) {
+	stored, err := s.passkeys.ListByUser(ctx, user.OrgID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	owner := &webauthnUser{user: user}
+	for _, credential := range stored {
+		if rpID != "" && credential.RPID != rpID {
+			continue
+		}
+		owner.stored = append(owner.stored, credential)
+		owner.credentials = append(owner.credentials, toWebAuthnCredential(credential))
+	}
+	return owner, nil
+}
+
+// recordPasskeyUse persists the new signature counter. A counter that did not
+// advance indicates a cloned authenticator and the assertion is rejected.
+func (s *AuthService) recordPasskeyUse(ctx context.Context, owner *webauthnUser, parsed *protocol.ParsedCredentialAssertionData) error {
+	for _, stored := range owner.stored {
+		if string(stored.CredentialID) != string(parsed.RawID) {
+			continue
+		}
+		counter := parsed.Response.AuthenticatorData.Counter
+		if (counter != 0 || stored.SignCount != 0) && counter <= stored.SignCount {
+			s.audit("passkey.clone_warning", "org_id", stored.OrgID, "user_id", stored.UserID, "credential_id", stored.ID)
+			return newOAuthError("invalid_grant", "Passkey could not be verified.", http.StatusForbidden)
+		}
+		return s.passkeys.UpdateUsage(ctx, stored.ID, counter, parsed.Response.AuthenticatorData.Flags.HasBackupState())
+	}
+	return newOAuthError("invalid_grant", "Passkey could not be verified.", http.StatusForbidden)
+}
+
+// relyingParty builds the WebAuthn configuration for an org. The RP ID is the
+// org's primary domain host; the request origin is accepted in addition to
+// https://<host> only when it is https, the RP ID or a subdomain of it, and a
+// verified domain of the same org.
+func (s *AuthService) relyingParty(ctx context.Context, orgCtx *org.Context, origin string) (*webauthn.WebAuthn, error) {
+	host := orgCtx.Domain.Host
+	if s.orgs != nil {
+		if primary, err := s.orgs.GetPrimaryDomain(ctx, orgCtx.Org.ID); err == nil && primary.Host != "" {
+			host = primary.Host
+		}
+	}
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return nil, newOAuthError("invalid_request", "Org has no primary domain.", http.StatusBadRequest)
+	}
+	rpID := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		rpID = h
+	}
+
+	origins := []string{"https://" + host}
+	if parsed, err := url.Parse(strings.TrimSpace(origin)); err == nil && parsed.Scheme == "https" {
+		hostname := strings.ToLower(parsed.Hostname())
+		if (hostname == rpID || strings.HasSuffix(hostname, "."+rpID)) && s.isVerifiedOrgHost(ctx, orgCtx, hostname) {
+			origins = append(origins, "https://"+strings.ToLower(parsed.Host))
+		}
+	}
+
+	rp, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: coalesce(orgCtx.Org.Name, rpID),
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("configure webauthn: %w", err)
+	}
+	return rp, nil
+}
+
+// isVerifiedOrgHost reports whether host is a verified domain of the org.
+func (s *AuthService) isVerifiedOrgHost(ctx context.Context, orgCtx *org.Context, host string) bool {
+	if strings.EqualFold(orgCtx.Domain.Host, host) && orgCtx.Domain.Verified && orgCtx.Domain.OrgID == orgCtx.Org.ID {
+		return true
+	}
+	if s.orgs == nil {
+		return false
+	}
+	d, err := s.orgs.GetDomainByHost(ctx, host)
+	return err == nil && d.Verified && d.OrgID == orgCtx.Org.ID
+}
+
+func (s *AuthService) saveWebAuthnSession(ctx context.Context, orgID, userID int64, purpose, mfaToken string, data *webauthn.SessionData) (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("encode webauthn session: %w", err)
+	}
+	session := domain.WebAuthnSession{
+		SessionID: randomString(32),
+		OrgID:     orgID,
+		UserID:    userID,
+		Purpose:   purpose,
+		MFAToken:  mfaToken,
+		Data:      encoded,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.webauthnSessions.SaveSession(ctx, webauthnSessionPrefix+session.SessionID, session, passkeyCeremonyTTL); err != nil {
+		return "", fmt.Errorf("persist webauthn session: %w", err)
+	}
+	return session.SessionID, nil
+}
+
+// consumeWebAuthnSession loads and deletes a ceremony so each challenge can
+// only be answered once.
+func (s *AuthService) consumeWebAuthnSession(ctx context.Context, orgCtx *org.Context, sessionID, purpose string) (*domain.WebAuthnSession, *webauthn.SessionData, error) {
+	id := strings.TrimSpace(sessionID)
+	if id == "" {
+		return nil, nil, newOAuthError("invalid_request", "session_id is required.", http.StatusBadRequest)
+	}
+	key := webauthnSessionPrefix + id
+	session, err := s.webauthnSessions.GetSession(ctx, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load webauthn session: %w", err)
+	}
+	if session == nil || session.OrgID != orgCtx.Org.ID || session.Purpose != purpose {
+		return nil, nil, newOAuthError("invalid_grant", "Invalid or expired passkey session.", http.StatusForbidden)
+	}
+	if err := s.webauthnSessions.DeleteSession(ctx, key); err != nil {
+		return nil, nil, err
+	}
+	var data webauthn.SessionData
+	if err := json.Unmarshal(session.Data, &data); err != nil {
+		return nil, nil, fmt.Errorf("decode webauthn session: %w", err)
+	}
+	return session, &data, nil
+}
+
+func (s *AuthService) hasPasskeys(ctx context.Context, orgID, userID int64) (bool, error) {
+	if s.passkeys == nil {
+		return false, nil
+	}
+	credentials, err := s.passkeys.ListByUser(ctx, orgID, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(credentials) > 0, nil
+}
+
+func (s *AuthService) requirePasskeysConfigured() error {
+	if s.passkeys == nil || s.webauthnSessions == nil {
+		return newOAuthError("server_error", "Passkeys are not configured.", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func passkeyName(name string) string {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return "Passkey"
+	}
+	if len(trimmed) > passkeyNameMaxLength {
+		trimmed = trimmed[:passkeyNameMaxLength]
+	}
+	return trimmed
+}
+
+func toWebAuthnCredential(credential domain.WebAuthnCredential) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+	for _, transport := range credential.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+	return webauthn.Credential{
+		ID:              credential.CredentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    true,
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: credential.SignCount,
+		},
+	}
+}
+
+func newPasskeyCredential(credential domain.WebAuthnCredential) PasskeyCredential {
+	transports := credential.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	return PasskeyCredential{
+		ID:             credential.ID,
+		Name:           credential.Name,
+		RPID:           credential.RPID,
+		Transports:     transports,
+		BackupEligible: credential.BackupEligible,
+		BackupState:    credential.BackupState,
+		LastUsedAt:     credential.LastUsedAt,
+		CreatedAt:      credential.CreatedAt,
+	}
+}
+
+// encodeUserHandle maps a user ID to the opaque WebAuthn user handle.
+func encodeUserHandle(userID int64) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
+
+func decodeUserHandle(handle []byte) (int64, error) {
+	if len(handle) != 8 {
+		return 0, fmt.Errorf("invalid user handle")
+	}
+	return int64(binary.BigEndian.Uint64(handle)), nil
+}
@@ -0,0 +1,445 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/repository"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+const passkeyTestOrigin = "https://auth.tenant.test"
+
+func TestPasskeyRegistrationLoginAndMFA(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User"}
+	hash, _ := password.Hash("password")
+	user.PasswordHash = hash
+
+	passkeys := &memoryPasskeyRepo{}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, MFAEncryptionKey: "test-key"}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:            &memoryUserRepo{user: user},
+		Tokens:           &memoryTokenRepo{},
+		Codes:            &memoryCodeRepo{},
+		Clients:          &memoryClientRepo{},
+		MFA:              &memoryMFARepo{},
+		MFAChallenges:    &memoryChallengeStore{items: map[string]domain.MFAChallenge{}},
+		Passkeys:         passkeys,
+		WebAuthnSessions: &memoryWebAuthnSessionStore{items: map[string]domain.WebAuthnSession{}},
+		Snowflake:        node,
+		Generator:        generator,
+		Keys:             keyManager,
+		Config:           cfg,
+		Logger:           zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Domain: domain.Domain{Host: "auth.tenant.test", OrgID: 1, IsPrimary: true},
+		Org:    domain.Org{ID: 1, Name: "Tenant A", Code: "client"},
+		AuthProviders: []domain.AuthProvider{
+			{ProviderType: "password", IsActive: true},
+			{ProviderType: domain.AuthProviderPasskey, IsActive: true},
+		},
+		MFAConfig: domain.MFAConfig{OrgID: 1, Policy: domain.MFAPolicyOptional},
+	}
+	authenticator := newSoftwareAuthenticator(t, "auth.tenant.test")
+
+	// Registration ceremony.
+	options, err := authService.BeginPasskeyRegistration(ctx, orgCtx, user.ID, passkeyTestOrigin)
+	require.NoError(t, err)
+	creation, ok := options.Options.(*protocol.CredentialCreation)
+	require.True(t, ok)
+	require.Equal(t, "auth.tenant.test", creation.Response.RelyingParty.ID)
+
+	registered, err := authService.FinishPasskeyRegistration(ctx, orgCtx, user.ID, options.SessionID, "Laptop", passkeyTestOrigin,
+		authenticator.create(t, creation.Response.Challenge, passkeyTestOrigin))
+	require.NoError(t, err)
+	require.Equal(t, "Laptop", registered.Name)
+	require.Equal(t, "auth.tenant.test", registered.RPID)
+	require.Len(t, passkeys.items, 1)
+
+	// Passkey as a first factor bypasses the MFA challenge.
+	options, err = authService.BeginPasskeyLogin(ctx, orgCtx, passkeyTestOrigin)
+	require.NoError(t, err)
+	assertion := options.Options.(*protocol.CredentialAssertion)
+	response := authenticator.get(t, assertion.Response.Challenge, passkeyTestOrigin, user.ID)
+	resp, err := authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", passkeyTestOrigin, response)
+	require.NoError(t, err)
+	_, custom, err := authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.Equal(t, []string{domain.AuthProviderPasskey}, custom.Providers)
+
+	_, err = authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", passkeyTestOrigin, response)
+	require.Error(t, err, "ceremony is single use")
+
+	// Password login now requires a second factor, answered with the passkey.
+	_, err = authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", "https://tenant")
+	var mfaErr *service.MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	require.Equal(t, []string{domain.AuthProviderPasskey}, mfaErr.Factors)
+
+	options, err = authService.BeginPasskeyMFA(ctx, orgCtx, mfaErr.MFAToken, passkeyTestOrigin)
+	require.NoError(t, err)
+	assertion = options.Options.(*protocol.CredentialAssertion)
+	require.Len(t, assertion.Response.AllowedCredentials, 1)
+	resp, err = authService.MFAPasskeyGrant(ctx, orgCtx, mfaErr.MFAToken, options.SessionID, passkeyTestOrigin,
+		authenticator.get(t, assertion.Response.Challenge, passkeyTestOrigin, user.ID))
+	require.NoError(t, err)
+	_, custom, err = authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, "https://tenant")
+	require.NoError(t, err)
+	require.Contains(t, custom.Providers, "mfa")
+}
+
+func TestPasskeyLoginRejectsForeignOriginAndClonedAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant"}
+	passkeys := &memoryPasskeyRepo{}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:            &memoryUserRepo{user: user},
+		Tokens:           &memoryTokenRepo{},
+		Codes:            &memoryCodeRepo{},
+		Clients:          &memoryClientRepo{},
+		Passkeys:         passkeys,
+		WebAuthnSessions: &memoryWebAuthnSessionStore{items: map[string]domain.WebAuthnSession{}},
+		Snowflake:        node,
+		Generator:        generator,
+		Keys:             keyManager,
+		Config:           cfg,
+		Logger:           zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{
+		Domain:        domain.Domain{Host: "auth.tenant.test", OrgID: 1, IsPrimary: true},
+		Org:           domain.Org{ID: 1, Name: "Tenant A"},
+		AuthProviders: []domain.AuthProvider{{ProviderType: domain.AuthProviderPasskey, IsActive: true}},
+	}
+	authenticator := newSoftwareAuthenticator(t, "auth.tenant.test")
+
+	options, err := authService.BeginPasskeyRegistration(ctx, orgCtx, user.ID, passkeyTestOrigin)
+	require.NoError(t, err)
+	creation := options.Options.(*protocol.CredentialCreation)
+	_, err = authService.FinishPasskeyRegistration(ctx, orgCtx, user.ID, options.SessionID, "", passkeyTestOrigin,
+		authenticator.create(t, creation.Response.Challenge, passkeyTestOrigin))
+	require.NoError(t, err)
+
+	options, err = authService.BeginPasskeyLogin(ctx, orgCtx, passkeyTestOrigin)
+	require.NoError(t, err)
+	assertion := options.Options.(*protocol.CredentialAssertion)
+	_, err = authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", "https://evil.test",
+		authenticator.get(t, assertion.Response.Challenge, "https://evil.test", user.ID))
+	require.Error(t, err, "origin outside the RP ID must be rejected")
+
+	options, err = authService.BeginPasskeyLogin(ctx, orgCtx, passkeyTestOrigin)
+	require.NoError(t, err)
+	assertion = options.Options.(*protocol.CredentialAssertion)
+	authenticator.counter = 0
+	_, err = authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", passkeyTestOrigin,
+		authenticator.get(t, assertion.Response.Challenge, passkeyTestOrigin, user.ID))
+	require.NoError(t, err)
+
+	options, err = authService.BeginPasskeyLogin(ctx, orgCtx, passkeyTestOrigin)
+	require.NoError(t, err)
+	assertion = options.Options.(*protocol.CredentialAssertion)
+	authenticator.counter = 0
+	_, err = authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", passkeyTestOrigin,
+		authenticator.get(t, assertion.Response.Challenge, passkeyTestOrigin, user.ID))
+	require.Error(t, err, "a signature counter that does not advance indicates a cloned authenticator")
+}
+
+func TestPasskeyOriginsLimitedToVerifiedHTTPSDomains(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant"}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:   &memoryUserRepo{user: user},
+		Tokens:  &memoryTokenRepo{},
+		Codes:   &memoryCodeRepo{},
+		Clients: &memoryClientRepo{},
+		Orgs: &passkeyOrgRepo{domains: map[string]domain.Domain{
+			"auth.tenant.test":       {Host: "auth.tenant.test", OrgID: 1, IsPrimary: true, Verified: true},
+			"login.auth.tenant.test": {Host: "login.auth.tenant.test", OrgID: 1, Verified: true},
+			"beta.auth.tenant.test":  {Host: "beta.auth.tenant.test", OrgID: 1},
+			"other.auth.tenant.test": {Host: "other.auth.tenant.test", OrgID: 2, Verified: true},
+		}},
+		Passkeys:         &memoryPasskeyRepo{},
+		WebAuthnSessions: &memoryWebAuthnSessionStore{items: map[string]domain.WebAuthnSession{}},
+		Snowflake:        node,
+		Generator:        generator,
+		Keys:             keyManager,
+		Config:           cfg,
+		Logger:           zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{
+		Domain:        domain.Domain{Host: "auth.tenant.test", OrgID: 1, IsPrimary: true, Verified: true},
+		Org:           domain.Org{ID: 1, Name: "Tenant A"},
+		AuthProviders: []domain.AuthProvider{{ProviderType: domain.AuthProviderPasskey, IsActive: true}},
+	}
+	authenticator := newSoftwareAuthenticator(t, "auth.tenant.test")
+
+	options, err := authService.BeginPasskeyRegistration(ctx, orgCtx, user.ID, passkeyTestOrigin)
+	require.NoError(t, err)
+	creation := options.Options.(*protocol.CredentialCreation)
+	_, err = authService.FinishPasskeyRegistration(ctx, orgCtx, user.ID, options.SessionID, "", passkeyTestOrigin,
+		authenticator.create(t, creation.Response.Challenge, passkeyTestOrigin))
+	require.NoError(t, err)
+
+	for _, origin := range []string{
+		"http://auth.tenant.test",
+		"https://beta.auth.tenant.test",
+		"https://other.auth.tenant.test",
+		"https://unknown.auth.tenant.test",
+	} {
+		options, err = authService.BeginPasskeyLogin(ctx, orgCtx, origin)
+		require.NoError(t, err)
+		assertion := options.Options.(*protocol.CredentialAssertion)
+		_, err = authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", origin,
+			authenticator.get(t, assertion.Response.Challenge, origin, user.ID))
+		require.Error(t, err, "origin %s must be rejected", origin)
+	}
+
+	origin := "https://login.auth.tenant.test"
+	options, err = authService.BeginPasskeyLogin(ctx, orgCtx, origin)
+	require.NoError(t, err)
+	assertion := options.Options.(*protocol.CredentialAssertion)
+	_, err = authService.PasskeyGrant(ctx, orgCtx, options.SessionID, "openid", "https://tenant", origin,
+		authenticator.get(t, assertion.Response.Challenge, origin, user.ID))
+	require.NoError(t, err, "a verified subdomain of the org is a valid origin")
+}
+
+// softwareAuthenticator emulates a platform authenticator producing "none"
+// attestations and ES256 assertions.
+type softwareAuthenticator struct {
+	rpID         string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	counter      uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T, rpID string) *softwareAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	return &softwareAuthenticator{rpID: rpID, key: key, credentialID: credentialID}
+}
+
+func (a *softwareAuthenticator) create(t *testing.T, challenge []byte, origin string) []byte {
+	t.Helper()
+	coseKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	var authData bytes.Buffer
+	authData.Write(a.authenticatorData(0x01 | 0x04 | 0x40))
+	authData.Write(make([]byte, 16)) // AAGUID
+	_ = binary.Write(&authData, binary.BigEndian, uint16(len(a.credentialID)))
+	authData.Write(a.credentialID)
+	authData.Write(coseKey)
+
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData.Bytes(),
+	})
+	require.NoError(t, err)
+
+	return a.credential(t, map[string]any{
+		"clientDataJSON":    a.clientData(t, "webauthn.create", challenge, origin),
+		"attestationObject": b64(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+func (a *softwareAuthenticator) get(t *testing.T, challenge []byte, origin string, userID int64) []byte {
+	t.Helper()
+	a.counter++
+	authData := a.authenticatorData(0x01 | 0x04)
+	clientData := a.clientData(t, "webauthn.get", challenge, origin)
+	rawClientData, err := base64.RawURLEncoding.DecodeString(clientData)
+	require.NoError(t, err)
+
+	clientHash := sha256.Sum256(rawClientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	userHandle := make([]byte, 8)
+	binary.BigEndian.PutUint64(userHandle, uint64(userID))
+	return a.credential(t, map[string]any{
+		"clientDataJSON":    clientData,
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(userHandle),
+	})
+}
+
+func (a *softwareAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, a.counter)
+}
+
+func (a *softwareAuthenticator) clientData(t *testing.T, ceremony string, challenge []byte, origin string) string {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   b64(challenge),
+		"origin":      origin,
+		"crossOrigin": false,
+	})
+	require.NoError(t, err)
+	return b64(payload)
+}
+
+func (a *softwareAuthenticator) credential(t *testing.T, response map[string]any) []byte {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(t, err)
+	return payload
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type memoryPasskeyRepo struct {
+	items []domain.WebAuthnCredential
+}
+
+type memoryWebAuthnSessionStore struct {
+	items map[string]domain.WebAuthnSession
+}
+
+func (m *memoryPasskeyRepo) ListByUser(ctx context.Context, orgID, userID int64) ([]domain.WebAuthnCredential, error) {
+	var out []domain.WebAuthnCredential
+	for _, item := range m.items {
+		if item.OrgID == orgID && item.UserID == userID {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryPasskeyRepo) GetByCredentialID(ctx context.Context, orgID int64, credentialID []byte) (domain.WebAuthnCredential, error) {
+	for _, item := range m.items {
+		if item.OrgID == orgID && bytes.Equal(item.CredentialID, credentialID) {
+			return item, nil
+		}
+	}
+	return domain.WebAuthnCredential{}, pgx.ErrNoRows
+}
+
+func (m *memoryPasskeyRepo) Create(ctx context.Context, credential domain.WebAuthnCredential) (domain.WebAuthnCredential, error) {
+	credential.CreatedAt = time.Now()
+	m.items = append(m.items, credential)
+	return credential, nil
+}
+
+func (m *memoryPasskeyRepo) UpdateUsage(ctx context.Context, id int64, signCount uint32, backupState bool) error {
+	for i := range m.items {
+		if m.items[i].ID == id {
+			now := time.Now()
+			m.items[i].SignCount = signCount
+			m.items[i].BackupState = backupState
+			m.items[i].LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memoryPasskeyRepo) Delete(ctx context.Context, orgID, userID, id int64) error {
+	for i := range m.items {
+		if m.items[i].ID == id && m.items[i].UserID == userID {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *memoryWebAuthnSessionStore) SaveSession(ctx context.Context, key string, data domain.WebAuthnSession, ttl time.Duration) error {
+	m.items[key] = data
+	return nil
+}
+
+func (m *memoryWebAuthnSessionStore) GetSession(ctx context.Context, key string) (*domain.WebAuthnSession, error) {
+	item, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+func (m *memoryWebAuthnSessionStore) DeleteSession(ctx context.Context, key string) error {
+	delete(m.items, key)
+	return nil
+}
+
+type passkeyOrgRepo struct {
+	repository.OrgRepository
+	domains map[string]domain.Domain
+}
+
+func (m *passkeyOrgRepo) GetDomainByHost(ctx context.Context, host string) (domain.Domain, error) {
+	d, ok := m.domains[host]
+	if !ok {
+		return domain.Domain{}, pgx.ErrNoRows
+	}
+	return d, nil
+}
+
+func (m *passkeyOrgRepo) GetPrimaryDomain(ctx context.Context, orgID int64) (domain.Domain, error) {
+	for _, d := range m.domains {
+		if d.OrgID == orgID && d.IsPrimary {
+			return d, nil
+		}
+	}
+	return domain.Domain{}, pgx.ErrNoRows
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+const (
+	sessionPrefix = "session:"
+	// sessionTouchInterval limits how often activity is written to Postgres;
+	// the Redis TTL is still extended on every request.
+	sessionTouchInterval = time.Minute
+	sessionUserAgentMax  = 512
+)
+
+// SessionInfo is the public view of a browser session.
+type SessionInfo struct {
+	ID         int64     `json:"id,string"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// CreateSession starts a browser session for a user who just signed in with
+// the methods in amr and returns the opaque token to store in the session
+// cookie.
+func (s *AuthService) CreateSession(ctx context.Context, orgCtx *org.Context, userID int64, amr []string, userAgent, ipAddress string) (string, time.Time, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.CreateSession")
+	defer span.End()
+
+	if err := s.requireSessionsConfigured(); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := randomString(32)
+	now := time.Now().UTC()
+	if len(userAgent) > sessionUserAgentMax {
+		userAgent = userAgent[:sessionUserAgentMax]
+	}
+	session, err := s.sessions.Create(ctx, domain.Session{
+		ID:        s.snowflake.Generate().Int64(),
+		OrgID:     orgCtx.Org.ID,
+		UserID:    userID,
+		TokenHash: hashSessionToken(token),
+		UserAgent: strings.TrimSpace(userAgent),
+		IPAddress: strings.TrimSpace(ipAddress),
+		AMR:       append([]string{}, amr...),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.sessionAbsoluteTimeout()),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return "", time.Time{}, err
+	}
+	if err := s.sessionStore.SaveSession(ctx, sessionPrefix+session.TokenHash, session, s.sessionTTL(session, now)); err != nil {
+		span.RecordError(err)
+		return "", time.Time{}, err
+	}
+
+	s.audit("session.created", "org_id", orgCtx.Org.ID, "user_id", userID, "session_id", session.ID)
+	return token, session.ExpiresAt, nil
+}
+
+// ResolveSession returns the live session for a cookie token and extends its
+// idle timeout. It returns nil when the session is unknown, revoked, idle for
+// too long, past its absolute lifetime, or belongs to another org.
+func (s *AuthService) ResolveSession(ctx context.Context, orgCtx *org.Context, token string) (*domain.Session, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.ResolveSession")
+	defer span.End()
+
+	token = strings.TrimSpace(token)
+	if token == "" || s.requireSessionsConfigured() != nil {
+		return nil, nil
+	}
+	key := sessionPrefix + hashSessionToken(token)
+	session, err := s.sessionStore.GetSession(ctx, key)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if session == nil || session.OrgID != orgCtx.Org.ID {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	if !now.Before(session.ExpiresAt) {
+		_ = s.sessionStore.DeleteSession(ctx, key)
+		return nil, nil
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessions.Touch(ctx, session.ID, now); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		session.LastSeenAt = now
+	}
+	// Revocation deletes the key, so only a session that is still stored
+	// gets its idle timeout extended.
+	live, err := s.sessionStore.RefreshSession(ctx, key, *session, s.sessionTTL(*session, now))
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !live {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// EndSession signs the browser holding token out. Unknown tokens are ignored.
+func (s *AuthService) EndSession(ctx context.Context, orgCtx *org.Context, token string) error {
+	ctx, span := s.startSpan(ctx, "AuthService.EndSession")
+	defer span.End()
+
+	if _, err := s.endSession(ctx, orgCtx, token, 0); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// endSession revokes the live session behind token and returns it. It
+// returns nil when token names no session of orgCtx, or when userID is set
+// and the session belongs to someone else.
+func (s *AuthService) endSession(ctx context.Context, orgCtx *org.Context, token string, userID int64) (*domain.Session, error) {
+	token = strings.TrimSpace(token)
+	if token == "" || s.requireSessionsConfigured() != nil {
+		return nil, nil
+	}
+	key := sessionPrefix + hashSessionToken(token)
+	session, err := s.sessionStore.GetSession(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.OrgID != orgCtx.Org.ID || (userID != 0 && session.UserID != userID) {
+		return nil, nil
+	}
+	if _, err := s.sessions.Revoke(ctx, session.OrgID, session.UserID, session.ID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err := s.sessionStore.DeleteSession(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.audit("session.ended", "org_id", session.OrgID, "user_id", session.UserID, "session_id", session.ID)
+	return session, nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+// currentToken marks the session of the calling browser, if any.
+func (s *AuthService) ListSessions(ctx context.Context, orgCtx *org.Context, userID int64, currentToken string) ([]SessionInfo, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.ListSessions")
+	defer span.End()
+
+	if err := s.requireSessionsConfigured(); err != nil {
+		return nil, err
+	}
+	idleSince := time.Now().UTC().Add(-s.sessionIdleTimeout())
+	sessions, err := s.sessions.ListActiveByUser(ctx, orgCtx.Org.ID, userID, idleSince)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	currentHash := ""
+	if strings.TrimSpace(currentToken) != "" {
+		currentHash = hashSessionToken(strings.TrimSpace(currentToken))
+	}
+	views := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, SessionInfo{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			Current:    currentHash != "" && session.TokenHash == currentHash,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+	return views, nil
+}
+
+// RevokeSession signs one of the user's sessions out.
+func (s *AuthService) RevokeSession(ctx context.Context, orgCtx *org.Context, userID, sessionID int64) error {
+	ctx, span := s.startSpan(ctx, "AuthService.RevokeSession")
+	defer span.End()
+
+	if err := s.requireSessionsConfigured(); err != nil {
+		return err
+	}
+	session, err := s.sessions.Revoke(ctx, orgCtx.Org.ID, userID, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newOAuthError("invalid_request", "Session not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return err
+	}
+	if err := s.sessionStore.DeleteSession(ctx, sessionPrefix+session.TokenHash); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	s.audit("session.revoked", "org_id", orgCtx.Org.ID, "user_id", userID, "session_id", sessionID)
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere: every browser session is
+// ended and every refresh token revoked, so no client can mint new access
+// tokens. Access tokens already issued stay valid until they expire.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, orgCtx *org.Context, userID int64) (int, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.RevokeAllSessions")
+	defer span.End()
+
+	if err := s.requireSessionsConfigured(); err != nil {
+		return 0, err
+	}
+	if _, err := s.users.GetByID(ctx, orgCtx.Org.ID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, newOAuthError("invalid_request", "User not found.", http.StatusNotFound)
+		}
+		span.RecordError(err)
+		return 0, err
+	}
+
+	sessions, err := s.sessions.RevokeAllByUser(ctx, orgCtx.Org.ID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	for _, session := range sessions {
+		if err := s.sessionStore.DeleteSession(ctx, sessionPrefix+session.TokenHash); err != nil {
+			span.RecordError(err)
+			return 0, err
+		}
+	}
+	if err := s.tokens.RevokeUserTokens(ctx, orgCtx.Org.ID, userID); err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	s.audit("session.revoked_all", "org_id", orgCtx.Org.ID, "user_id", userID, "count", len(sessions))
+	return len(sessions), nil
+}
+
+// sessionTTL is the Redis lifetime of a live session: the idle timeout, capped
+// by the time left before the absolute timeout.
+func (s *AuthService) sessionTTL(session domain.Session, now time.Time) time.Duration {
+	ttl := s.sessionIdleTimeout()
+	if remaining := session.ExpiresAt.Sub(now); remaining < ttl {
+		ttl = remaining
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+func (s *AuthService) requireSessionsConfigured() error {
+	if s.sessions == nil || s.sessionStore == nil {
+		return newOAuthError("server_error", "Sessions are not configured.", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (s *AuthService) sessionIdleTimeout() time.Duration {
+	if s.cfg.SessionIdleTimeout > 0 {
+		return s.cfg.SessionIdleTimeout
+	}
+	return 12 * time.Hour
+}
+
+func (s *AuthService) sessionAbsoluteTimeout() time.Duration {
+	if s.cfg.SessionAbsoluteTimeout > 0 {
+		return s.cfg.SessionAbsoluteTimeout
+	}
+	return 7 * 24 * time.Hour
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
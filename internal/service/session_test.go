@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestSessionsCanBeListedAndRevoked(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User"}
+	hash, _ := password.Hash("password")
+	user.PasswordHash = hash
+	sessions := &memorySessionRepo{items: map[int64]domain.Session{}}
+	store := &memorySessionStore{items: map[string]domain.Session{}}
+	tokenRepo := &memoryTokenRepo{}
+
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32, SessionIdleTimeout: time.Hour, SessionAbsoluteTimeout: 24 * time.Hour}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:        &memoryUserRepo{user: user},
+		Tokens:       tokenRepo,
+		Codes:        &memoryCodeRepo{},
+		Clients:      &memoryClientRepo{},
+		Sessions:     sessions,
+		SessionStore: store,
+		Snowflake:    node,
+		Generator:    generator,
+		Keys:         keyManager,
+		Config:       cfg,
+		Logger:       zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Org:           domain.Org{ID: 1, Name: "Tenant A"},
+		AuthProviders: []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+	}
+
+	laptop, expiresAt, err := authService.CreateSession(ctx, orgCtx, user.ID, []string{"password"}, "Laptop", "203.0.113.1")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(24*time.Hour), expiresAt, time.Minute)
+	phone, _, err := authService.CreateSession(ctx, orgCtx, user.ID, []string{"password"}, "Phone", "203.0.113.2")
+	require.NoError(t, err)
+
+	session, err := authService.ResolveSession(ctx, orgCtx, laptop)
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	require.Equal(t, user.ID, session.UserID)
+
+	other := &org.Context{Org: domain.Org{ID: 2}}
+	session, err = authService.ResolveSession(ctx, other, laptop)
+	require.NoError(t, err)
+	require.Nil(t, session, "sessions are scoped to the org that issued them")
+
+	listed, err := authService.ListSessions(ctx, orgCtx, user.ID, laptop)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	var laptopID, phoneID int64
+	for _, item := range listed {
+		require.Equal(t, item.UserAgent == "Laptop", item.Current)
+		if item.UserAgent == "Phone" {
+			phoneID = item.ID
+		} else {
+			laptopID = item.ID
+		}
+	}
+
+	require.NoError(t, authService.RevokeSession(ctx, orgCtx, user.ID, phoneID))
+	session, err = authService.ResolveSession(ctx, orgCtx, phone)
+	require.NoError(t, err)
+	require.Nil(t, session, "revoked session must not authenticate")
+
+	store.onGet = func(string) {
+		store.onGet = nil
+		require.NoError(t, authService.RevokeSession(ctx, orgCtx, user.ID, laptopID))
+	}
+	session, err = authService.ResolveSession(ctx, orgCtx, laptop)
+	require.NoError(t, err)
+	require.Nil(t, session, "a session revoked mid-request stays revoked")
+	require.Empty(t, store.items, "sliding the expiry must not recreate it")
+	laptop, _, err = authService.CreateSession(ctx, orgCtx, user.ID, []string{"password"}, "Laptop", "203.0.113.1")
+	require.NoError(t, err)
+
+	err = authService.RevokeSession(ctx, orgCtx, user.ID, phoneID)
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, 404, oauthErr.Status)
+
+	resp, err := authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", "https://tenant")
+	require.NoError(t, err)
+
+	revoked, err := authService.RevokeAllSessions(ctx, orgCtx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, revoked)
+	session, err = authService.ResolveSession(ctx, orgCtx, laptop)
+	require.NoError(t, err)
+	require.Nil(t, session)
+	_, err = authService.RefreshGrant(ctx, orgCtx, resp.RefreshToken, "", "https://tenant", "")
+	require.Error(t, err, "force logout revokes refresh tokens")
+}
+
+type memorySessionRepo struct {
+	items map[int64]domain.Session
+}
+
+func (m *memorySessionRepo) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	session.LastSeenAt = session.CreatedAt
+	m.items[session.ID] = session
+	return session, nil
+}
+
+func (m *memorySessionRepo) ListActiveByUser(ctx context.Context, orgID, userID int64, idleSince time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+	for _, session := range m.items {
+		if session.OrgID == orgID && session.UserID == userID && session.RevokedAt == nil && session.LastSeenAt.After(idleSince) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *memorySessionRepo) Touch(ctx context.Context, id int64, seenAt time.Time) error {
+	session := m.items[id]
+	session.LastSeenAt = seenAt
+	m.items[id] = session
+	return nil
+}
+
+func (m *memorySessionRepo) Revoke(ctx context.Context, orgID, userID, id int64) (domain.Session, error) {
+	session, ok := m.items[id]
+	if !ok || session.OrgID != orgID || session.UserID != userID || session.RevokedAt != nil {
+		return domain.Session{}, pgx.ErrNoRows
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	m.items[id] = session
+	return session, nil
+}
+
+func (m *memorySessionRepo) RevokeAllByUser(ctx context.Context, orgID, userID int64) ([]domain.Session, error) {
+	var revoked []domain.Session
+	for id, session := range m.items {
+		if session.OrgID == orgID && session.UserID == userID && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			m.items[id] = session
+			revoked = append(revoked, session)
+		}
+	}
+	return revoked, nil
+}
+
+type memorySessionStore struct {
+	items map[string]domain.Session
+	onGet func(key string)
+}
+
+func (m *memorySessionStore) SaveSession(ctx context.Context, key string, data domain.Session, ttl time.Duration) error {
+	m.items[key] = data
+	return nil
+}
+
+func (m *memorySessionStore) RefreshSession(ctx context.Context, key string, data domain.Session, ttl time.Duration) (bool, error) {
+	if _, ok := m.items[key]; !ok {
+		return false, nil
+	}
+	m.items[key] = data
+	return true, nil
+}
+
+func (m *memorySessionStore) GetSession(ctx context.Context, key string) (*domain.Session, error) {
+	session, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	if m.onGet != nil {
+		m.onGet(key)
+	}
+	return &session, nil
+}
+
+func (m *memorySessionStore) DeleteSession(ctx context.Context, key string) error {
+	delete(m.items, key)
+	return nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+// Token exchange identifiers from RFC 8693.
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TokenExchangeRequest carries the RFC 8693 token request parameters.
+type TokenExchangeRequest struct {
+	ClientID           string
+	ClientSecret       string
+	SubjectToken       string
+	SubjectTokenType   string
+	ActorToken         string
+	ActorTokenType     string
+	RequestedTokenType string
+	Audience           []string
+	Scope              string
+	Issuer             string
+}
+
+// exchangedToken is a validated subject or actor token.
+type exchangedToken struct {
+	subject  string
+	userID   int64
+	clientID string
+	claims   *jwt.AccessTokenClaims
+}
+
+// TokenExchangeGrant exchanges an access token issued by this org for a new
+// one with the requested audience and a narrower or equal scope. With an
+// actor token the result records the actor in its act claim (delegation);
+// without one the client impersonates the subject, which is only allowed
+// towards audiences other than this server.
+func (s *AuthService) TokenExchangeGrant(ctx context.Context, orgCtx *org.Context, req TokenExchangeRequest) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.TokenExchangeGrant")
+	defer span.End()
+
+	if orgCtx == nil {
+		return nil, newOAuthError("invalid_request", "Org context missing.", http.StatusBadRequest)
+	}
+	client, err := s.AuthenticateClient(ctx, orgCtx.Org.ID, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(client.Grants, GrantTypeTokenExchange) {
+		return nil, newOAuthError("unauthorized_client", "Client is not allowed to exchange tokens.", http.StatusBadRequest)
+	}
+	if requested := strings.TrimSpace(req.RequestedTokenType); requested != "" && requested != TokenTypeAccessToken && requested != TokenTypeJWT {
+		return nil, newOAuthError("invalid_request", "requested_token_type is not supported.", http.StatusBadRequest)
+	}
+
+	subject, err := s.exchangeInputToken(ctx, orgCtx, req.SubjectToken, req.SubjectTokenType, req.Issuer, "subject_token")
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	act := subject.claims.Act
+	if strings.TrimSpace(req.ActorToken) != "" {
+		actor, err := s.exchangeInputToken(ctx, orgCtx, req.ActorToken, req.ActorTokenType, req.Issuer, "actor_token")
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		act = &jwt.ActorClaim{Subject: actor.subject, ClientID: actor.clientID, Act: subject.claims.Act}
+	} else if strings.TrimSpace(req.ActorTokenType) != "" {
+		return nil, newOAuthError("invalid_request", "actor_token_type requires actor_token.", http.StatusBadRequest)
+	}
+
+	audience := normalizeList(req.Audience)
+	for _, aud := range audience {
+		if !slices.Contains(client.TokenExchangeAudiences, aud) {
+			return nil, newOAuthError("invalid_target", fmt.Sprintf("Audience %s is not allowed for this client.", aud), http.StatusBadRequest)
+		}
+	}
+
+	allowed := make([]string, 0)
+	for _, scope := range strings.Fields(subject.claims.Scope) {
+		if slices.Contains(client.Scopes, scope) {
+			allowed = append(allowed, scope)
+		}
+	}
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = allowed
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return nil, newOAuthError("invalid_scope", fmt.Sprintf("Scope %s exceeds the subject token or client policy.", scope), http.StatusBadRequest)
+		}
+	}
+	effectiveScope := strings.Join(scopes, " ")
+	if act == nil && (len(audience) == 0 || slices.Contains(audience, req.Issuer)) {
+		// Without an actor the result is indistinguishable from the
+		// subject's own token, so impersonation may only target other APIs.
+		return nil, newOAuthError("invalid_target", "Exchanges without an actor_token must name an audience other than this server.", http.StatusBadRequest)
+	}
+
+	user := serviceUser(orgCtx.Org.ID, subject.clientID)
+	activeOrgID := coalesceOrgID(subject.claims.OrgID, orgCtx.Org.ID)
+	if subject.userID != 0 {
+		if user, err = s.users.GetByID(ctx, orgCtx.Org.ID, subject.userID); err != nil {
+			span.RecordError(err)
+			return nil, newOAuthError("invalid_grant", "subject_token user no longer exists.", http.StatusBadRequest)
+		}
+		if err := s.checkActiveOrg(ctx, orgCtx, user, activeOrgID); err != nil {
+			span.RecordError(err)
+			return nil, newOAuthError("invalid_grant", "The subject is no longer a member of the token's org.", http.StatusBadRequest)
+		}
+	}
+
+	jkt, err := dpopBindingFor(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	access, err := s.jwt.GenerateAccessTokenWithOptions(ctx, orgCtx.Org, activeOrgID, user, effectiveScope, req.Issuer, subject.claims.Providers, jwt.AccessTokenOptions{
+		ClientID:        client.ClientID,
+		SubjectClientID: subject.clientID,
+		Audience:        audience,
+		Act:             act,
+		DPoPKey:         jkt,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("generate exchanged token: %w", err)
+	}
+	// Exchanged tokens are JWTs whatever the client's access token format:
+	// their act claim is the only record of the delegation.
+	expiresAt := time.Now().Add(s.cfg.AccessTokenTTL)
+	oauthToken := domain.OAuthToken{
+		ID:              s.snowflake.Generate().Int64(),
+		OrgID:           orgCtx.Org.ID,
+		ClientID:        client.ClientID,
+		UserID:          subject.userID,
+		AccessToken:     access,
+		Scopes:          scopes,
+		ActiveOrgID:     activeOrgID,
+		DPoPJKT:         jkt,
+		ExpiresAt:       expiresAt,
+		AccessExpiresAt: expiresAt,
+		AMR:             subject.claims.Providers,
+		CreatedAt:       time.Now(),
+	}
+	if _, err := s.tokens.CreateToken(ctx, oauthToken); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("persist exchanged token: %w", err)
+	}
+
+	s.audit("token.exchanged",
+		"org_id", orgCtx.Org.ID,
+		"client_id", client.ClientID,
+		"subject", subject.subject,
+		"audience", strings.Join(audience, " "),
+		"scope", effectiveScope,
+		"actor_chain", strings.Join(actorChain(act), " "),
+	)
+	return &TokenResponse{
+		AccessToken:     access,
+		IssuedTokenType: TokenTypeAccessToken,
+		TokenType:       tokenType(jkt),
+		ExpiresIn:       int(s.cfg.AccessTokenTTL.Seconds()),
+		Scope:           effectiveScope,
+	}, nil
+}
+
+// exchangeInputToken validates a subject or actor token issued by this org
+// and refuses tokens that were revoked.
+func (s *AuthService) exchangeInputToken(ctx context.Context, orgCtx *org.Context, token, tokenType, issuer, param string) (exchangedToken, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return exchangedToken{}, newOAuthError("invalid_request", param+" is required.", http.StatusBadRequest)
+	}
+	switch strings.TrimSpace(tokenType) {
+	case TokenTypeAccessToken, TokenTypeJWT:
+	case "":
+		return exchangedToken{}, newOAuthError("invalid_request", param+"_type is required.", http.StatusBadRequest)
+	default:
+		return exchangedToken{}, newOAuthError("invalid_request", param+"_type is not supported.", http.StatusBadRequest)
+	}
+
+	invalid := newOAuthError("invalid_grant", param+" is invalid.", http.StatusBadRequest)
+	std, claims, err := s.ValidateToken(ctx, orgCtx.Org.ID, token, issuer)
+	if err != nil {
+		return exchangedToken{}, invalid
+	}
+	// Only access tokens carry org_id; this keeps logout tokens out.
+	if claims.OrgID == 0 {
+		return exchangedToken{}, invalid
+	}
+	if stored, err := s.tokens.GetByAccessToken(ctx, token); err == nil && (stored.Revoked || stored.OrgID != orgCtx.Org.ID) {
+		return exchangedToken{}, invalid
+	}
+
+	out := exchangedToken{subject: std.Subject, claims: claims}
+	if claims.SubjectClientID != "" {
+		// Client credentials tokens name the client rather than a user.
+		if std.Subject != "0" {
+			return exchangedToken{}, invalid
+		}
+		out.subject = "client:" + claims.SubjectClientID
+		out.clientID = claims.SubjectClientID
+		return out, nil
+	}
+	out.userID, err = strconv.ParseInt(std.Subject, 10, 64)
+	if err != nil || out.userID <= 0 {
+		return exchangedToken{}, invalid
+	}
+	return out, nil
+}
+
+// actorChain lists actor subjects from the current actor to the first.
+func actorChain(act *jwt.ActorClaim) []string {
+	var chain []string
+	for ; act != nil; act = act.Act {
+		chain = append(chain, act.Subject)
+	}
+	return chain
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestTokenExchangeGrant(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant"}
+	clients := &logoutClientRepo{client: domain.OAuthClient{
+		ClientID:               "gateway",
+		ClientSecret:           "secret",
+		Grants:                 []string{service.GrantTypeTokenExchange},
+		Scopes:                 []string{"openid", "profile"},
+		TokenExchangeAudiences: []string{"orders-api"},
+	}}
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:     &memoryUserRepo{user: user},
+		Tokens:    &memoryTokenRepo{},
+		Codes:     &memoryCodeRepo{},
+		Clients:   clients,
+		Snowflake: node,
+		Generator: generator,
+		Keys:      keyManager,
+		Config:    cfg,
+		Logger:    zap.NewNop(),
+	})
+	require.NoError(t, err)
+	orgCtx := &org.Context{Org: domain.Org{ID: 1, Name: "Tenant A"}}
+	issuer := "https://tenant.example"
+
+	subjectToken, err := generator.GenerateAccessToken(ctx, orgCtx.Org, user, "openid profile email", issuer, nil)
+	require.NoError(t, err)
+	actor, err := authService.ClientCredentialsGrant(ctx, orgCtx, "worker", "secret", "openid", issuer, "")
+	require.NoError(t, err)
+
+	exchange := func(req service.TokenExchangeRequest) (*service.TokenResponse, error) {
+		req.ClientID, req.ClientSecret, req.Issuer = "gateway", "secret", issuer
+		if req.SubjectToken == "" {
+			req.SubjectToken = subjectToken
+		}
+		req.SubjectTokenType = service.TokenTypeAccessToken
+		return authService.TokenExchangeGrant(ctx, orgCtx, req)
+	}
+	requireCode := func(err error, code string) {
+		t.Helper()
+		var oauthErr *service.OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		require.Equal(t, code, oauthErr.Code)
+	}
+
+	_, err = exchange(service.TokenExchangeRequest{Audience: []string{"billing-api"}})
+	requireCode(err, "invalid_target")
+	_, err = exchange(service.TokenExchangeRequest{Scope: "openid email"})
+	requireCode(err, "invalid_scope")
+	_, err = exchange(service.TokenExchangeRequest{SubjectToken: "not-a-jwt"})
+	requireCode(err, "invalid_grant")
+	_, err = exchange(service.TokenExchangeRequest{})
+	requireCode(err, "invalid_target")
+	clients.client.TokenExchangeAudiences = []string{"orders-api", issuer}
+	_, err = exchange(service.TokenExchangeRequest{Audience: []string{issuer}})
+	requireCode(err, "invalid_target")
+	clients.client.TokenExchangeAudiences = []string{"orders-api"}
+
+	resp, err := exchange(service.TokenExchangeRequest{
+		Audience:       []string{"orders-api"},
+		ActorToken:     actor.AccessToken,
+		ActorTokenType: service.TokenTypeAccessToken,
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.TokenTypeAccessToken, resp.IssuedTokenType)
+	require.Equal(t, "openid profile", resp.Scope, "scope defaults to what both the subject and client allow")
+	require.Empty(t, resp.RefreshToken)
+
+	std, claims, err := generator.ValidateAccessToken(ctx, orgCtx.Org.ID, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, "10", std.Subject)
+	require.Equal(t, []string{"orders-api"}, []string(std.Audience))
+	require.NotNil(t, claims.Act)
+	require.Equal(t, "client:worker", claims.Act.Subject)
+	require.Equal(t, "worker", claims.Act.ClientID)
+
+	// Only the explicit claim marks a client subject; a token for a user
+	// with ID 0 and a client-like email does not.
+	lookalike, err := generator.GenerateAccessToken(ctx, orgCtx.Org, domain.User{OrgID: 1, Email: "client:worker"}, "openid", issuer, nil)
+	require.NoError(t, err)
+	_, err = exchange(service.TokenExchangeRequest{
+		Audience:       []string{"orders-api"},
+		ActorToken:     lookalike,
+		ActorTokenType: service.TokenTypeAccessToken,
+	})
+	requireCode(err, "invalid_grant")
+
+	second, err := authService.ClientCredentialsGrant(ctx, orgCtx, "reporter", "secret", "openid", issuer, "")
+	require.NoError(t, err)
+	resp, err = exchange(service.TokenExchangeRequest{
+		SubjectToken:   resp.AccessToken,
+		ActorToken:     second.AccessToken,
+		ActorTokenType: service.TokenTypeJWT,
+		Scope:          "openid",
+	})
+	require.NoError(t, err)
+	_, claims, err = generator.ValidateAccessToken(ctx, orgCtx.Org.ID, resp.AccessToken, issuer)
+	require.NoError(t, err)
+	require.Equal(t, "client:reporter", claims.Act.Subject)
+	require.NotNil(t, claims.Act.Act, "the prior actor is kept in the chain")
+	require.Equal(t, "client:worker", claims.Act.Act.Subject)
+
+	clients.client.Grants = []string{"client_credentials"}
+	_, err = exchange(service.TokenExchangeRequest{})
+	requireCode(err, "unauthorized_client")
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+)
+
+// UserOrg is an org the signed-in user can act in.
+type UserOrg struct {
+	ID      int64  `json:"id,string"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	Role    string `json:"role,omitempty"`
+	Current bool   `json:"current"`
+}
+
+// ListUserOrgs returns the orgs a user can act in: the org they sign in at,
+// followed by the orgs where they hold an active membership.
+func (s *AuthService) ListUserOrgs(ctx context.Context, orgCtx *org.Context, userID, currentOrgID int64) ([]UserOrg, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.ListUserOrgs")
+	defer span.End()
+
+	currentOrgID = coalesceOrgID(currentOrgID, orgCtx.Org.ID)
+	orgs := []UserOrg{{
+		ID:      orgCtx.Org.ID,
+		Name:    orgCtx.Org.Name,
+		Slug:    orgCtx.Org.Slug,
+		Current: currentOrgID == orgCtx.Org.ID,
+	}}
+	if s.memberships == nil {
+		return orgs, nil
+	}
+
+	members, err := s.memberships.ListByUser(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("list user orgs: %w", err)
+	}
+	for _, member := range members {
+		if member.OrgID == orgCtx.Org.ID {
+			orgs[0].Role = member.Role
+			continue
+		}
+		memberOrg, err := s.orgs.GetOrg(ctx, member.OrgID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			span.RecordError(err)
+			return nil, fmt.Errorf("list user orgs load org: %w", err)
+		}
+		if !orgServed(memberOrg) {
+			continue
+		}
+		orgs = append(orgs, UserOrg{
+			ID:      memberOrg.ID,
+			Name:    memberOrg.Name,
+			Slug:    memberOrg.Slug,
+			Role:    member.Role,
+			Current: currentOrgID == memberOrg.ID,
+		})
+	}
+	return orgs, nil
+}
+
+// SwitchOrg exchanges a refresh token for tokens acting in another org the
+// user belongs to. The new tokens are still issued by orgCtx.
+func (s *AuthService) SwitchOrg(ctx context.Context, orgCtx *org.Context, refreshToken, scope, issuer string, activeOrgID int64) (*TokenResponse, error) {
+	ctx, span := s.startSpan(ctx, "AuthService.SwitchOrg")
+	defer span.End()
+
+	if activeOrgID <= 0 {
+		return nil, newOAuthError("invalid_request", "org_id is required.", http.StatusBadRequest)
+	}
+	return s.refreshGrant(ctx, orgCtx, refreshToken, scope, issuer, "", activeOrgID)
+}
+
+// checkActiveOrg reports whether user may act in activeOrgID. The org that
+// issues the token is always allowed; any other org needs an active
+// membership.
+func (s *AuthService) checkActiveOrg(ctx context.Context, orgCtx *org.Context, user domain.User, activeOrgID int64) error {
+	if activeOrgID == orgCtx.Org.ID {
+		return nil
+	}
+	denied := newOAuthError("access_denied", "You are not a member of that org.", http.StatusForbidden)
+	if s.memberships == nil || activeOrgID <= 0 {
+		return denied
+	}
+
+	member, err := s.memberships.Get(ctx, activeOrgID, user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return denied
+		}
+		return fmt.Errorf("check active org membership: %w", err)
+	}
+	if member.Status != domain.MemberStatusActive {
+		return denied
+	}
+
+	activeOrg, err := s.orgs.GetOrg(ctx, activeOrgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return denied
+		}
+		return fmt.Errorf("check active org: %w", err)
+	}
+	if !orgServed(activeOrg) {
+		return denied
+	}
+	return nil
+}
+
+// orgServed reports whether tokens may act in o. Orgs created before
+// lifecycle states carry no status.
+func orgServed(o domain.Org) bool {
+	return o.Status == "" || o.Status == domain.OrgStatusActive
+}
+
+// coalesceOrgID returns id, or fallback when id is unset.
+func coalesceOrgID(id, fallback int64) int64 {
+	if id != 0 {
+		return id
+	}
+	return fallback
+}
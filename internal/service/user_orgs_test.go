@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/smallbiznis/railzway-auth/internal/config"
+	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/jwt"
+	"github.com/smallbiznis/railzway-auth/internal/org"
+	"github.com/smallbiznis/railzway-auth/internal/password"
+	"github.com/smallbiznis/railzway-auth/internal/service"
+)
+
+func TestUserSwitchesBetweenOrgs(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: 10, OrgID: 1, Email: "user@tenant", Name: "Test User", EmailVerified: true}
+	hash, _ := password.Hash("password")
+	user.PasswordHash = hash
+	orgs := &memoryOrgRepo{items: map[int64]domain.Org{
+		1: {ID: 1, Name: "Tenant A", Slug: "tenant-a", Status: domain.OrgStatusActive},
+		2: {ID: 2, Name: "Tenant B", Slug: "tenant-b", Status: domain.OrgStatusActive},
+		3: {ID: 3, Name: "Tenant C", Slug: "tenant-c", Status: domain.OrgStatusActive},
+		4: {ID: 4, Name: "Tenant D", Slug: "tenant-d", Status: domain.OrgStatusSuspended},
+	}}
+	members := &memoryMembershipRepo{members: []domain.Member{
+		{OrgID: 2, UserID: user.ID, Role: domain.MemberRoleAdmin, Status: domain.MemberStatusActive},
+		{OrgID: 3, UserID: user.ID, Role: domain.MemberRoleViewer, Status: domain.MemberStatusDisabled},
+		{OrgID: 4, UserID: user.ID, Role: domain.MemberRoleOwner, Status: domain.MemberStatusActive},
+	}}
+	tokenRepo := &memoryTokenRepo{}
+
+	cfg := config.Config{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, RefreshTokenBytes: 32}
+	keyManager := jwt.NewKeyManager(&memoryKeyRepo{})
+	generator := jwt.NewGenerator(keyManager, cfg.AccessTokenTTL)
+	node, _ := snowflake.NewNode(1)
+	authService, err := service.NewAuthService(service.AuthDeps{
+		Users:       &memoryUserRepo{user: user},
+		Tokens:      tokenRepo,
+		Codes:       &memoryCodeRepo{},
+		Clients:     &memoryClientRepo{},
+		Orgs:        orgs,
+		Memberships: members,
+		Snowflake:   node,
+		Generator:   generator,
+		Keys:        keyManager,
+		Config:      cfg,
+		Logger:      zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	orgCtx := &org.Context{
+		Org:           orgs.items[1],
+		AuthProviders: []domain.AuthProvider{{ProviderType: "password", IsActive: true}},
+	}
+	const issuer = "https://tenant"
+	activeOrg := func(resp *service.TokenResponse) int64 {
+		_, claims, err := authService.ValidateToken(ctx, orgCtx.Org.ID, resp.AccessToken, issuer)
+		require.NoError(t, err)
+		return claims.OrgID
+	}
+
+	resp, err := authService.PasswordGrant(ctx, orgCtx, user.Email, "password", "openid", issuer)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), activeOrg(resp))
+
+	listed, err := authService.ListUserOrgs(ctx, orgCtx, user.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, listed, 2, "disabled memberships and suspended orgs are not listed")
+	require.Equal(t, service.UserOrg{ID: 1, Name: "Tenant A", Slug: "tenant-a", Current: true}, listed[0])
+	require.Equal(t, service.UserOrg{ID: 2, Name: "Tenant B", Slug: "tenant-b", Role: domain.MemberRoleAdmin}, listed[1])
+
+	resp, err = authService.SwitchOrg(ctx, orgCtx, resp.RefreshToken, "", issuer, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), activeOrg(resp))
+
+	resp, err = authService.RefreshGrant(ctx, orgCtx, resp.RefreshToken, "", issuer, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), activeOrg(resp), "refresh keeps the active org")
+
+	for _, orgID := range []int64{3, 4, 99} {
+		_, err = authService.SwitchOrg(ctx, orgCtx, resp.RefreshToken, "", issuer, orgID)
+		var oauthErr *service.OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		require.Equal(t, "access_denied", oauthErr.Code)
+		require.Equal(t, 403, oauthErr.Status)
+	}
+
+	require.NoError(t, members.Delete(ctx, 2, user.ID))
+	_, err = authService.RefreshGrant(ctx, orgCtx, resp.RefreshToken, "", issuer, "")
+	var oauthErr *service.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, "invalid_grant", oauthErr.Code, "removed members cannot refresh into the org")
+
+	resp, err = authService.SwitchOrg(ctx, orgCtx, resp.RefreshToken, "", issuer, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), activeOrg(resp), "the signing-in org is always allowed")
+}
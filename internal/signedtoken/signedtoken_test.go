@@ -0,0 +1,43 @@
+package signedtoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signer := New("secret")
+	now := time.Unix(1_700_000_000, 0)
+	claims := Claims{Purpose: "magic_link", OrgID: 7, Subject: "42", ID: "abc", ExpiresAt: now.Add(time.Minute).Unix()}
+
+	token, err := signer.Sign(claims)
+	require.NoError(t, err)
+
+	got, err := signer.Verify(token, "magic_link", now)
+	require.NoError(t, err)
+	require.Equal(t, claims, got)
+
+	_, err = signer.Verify(token, "email_verification", now)
+	require.ErrorIs(t, err, ErrInvalid, "purpose must match")
+
+	_, err = signer.Verify(token, "magic_link", now.Add(time.Minute))
+	require.ErrorIs(t, err, ErrExpired)
+
+	_, err = New("other").Verify(token, "magic_link", now)
+	require.ErrorIs(t, err, ErrInvalid, "tokens from another key are rejected")
+
+	body, sig, _ := strings.Cut(token, ".")
+	tampered := body[:len(body)-2] + "xx." + sig
+	_, err = signer.Verify(tampered, "magic_link", now)
+	require.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestDisabledSigner(t *testing.T) {
+	signer := New("  ")
+	require.False(t, signer.Enabled())
+	_, err := signer.Sign(Claims{})
+	require.ErrorIs(t, err, ErrNoKey)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/smallbiznis/railzway-auth/internal/domain"
+	"github.com/smallbiznis/railzway-auth/internal/org"
 	"github.com/smallbiznis/railzway-auth/internal/repository"
 	"go.uber.org/zap"
 )
@@ -45,6 +46,10 @@ func (r *Resolver) Resolve(ctx context.Context, host string) (*Context, error) {
 		zap.L().Error("failed to resolve domain", zap.String("host", cleaned), zap.Error(err))
 		return nil, fmt.Errorf("resolve domain: %w", err)
 	}
+	if !domainRow.Verified {
+		zap.L().Warn("refusing unverified domain", zap.String("host", cleaned), zap.Int64("tenant_id", domainRow.OrgID))
+		return nil, fmt.Errorf("resolve domain %q: %w", cleaned, org.ErrDomainNotVerified)
+	}
 
 	tenantRow, err := r.repo.GetOrg(ctx, domainRow.OrgID)
 	if err != nil {
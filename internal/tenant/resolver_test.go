@@ -3,6 +3,7 @@ package tenant_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -36,7 +37,7 @@ func TestResolverResolveBySlug(t *testing.T) {
 type mockOrgRepo struct{}
 
 func (m *mockOrgRepo) GetDomainByHost(ctx context.Context, host string) (domain.Domain, error) {
-	return domain.Domain{ID: 1, Host: host, OrgID: 1}, nil
+	return domain.Domain{ID: 1, Host: host, OrgID: 1, Verified: true}, nil
 }
 
 func (m *mockOrgRepo) GetOrg(ctx context.Context, orgID int64) (domain.Org, error) {
@@ -81,6 +82,50 @@ func (m *mockOrgRepo) ListOAuthIDPConfigs(ctx context.Context, orgID int64) ([]d
 	return []domain.OAuthIDPConfig{{OrgID: orgID, Provider: "google", ClientID: "id", ClientSecret: "secret", AuthorizationURL: "https://auth", TokenURL: "https://token", UserinfoURL: "https://userinfo", JWKSURL: "https://jwks"}}, nil
 }
 
+func (m *mockOrgRepo) GetMFAConfig(ctx context.Context, orgID int64) (domain.MFAConfig, error) {
+	return domain.MFAConfig{OrgID: orgID, Policy: domain.MFAPolicyOff}, nil
+}
+
+func (m *mockOrgRepo) ListRateLimitOverrides(ctx context.Context, orgID int64) ([]domain.RateLimitOverride, error) {
+	return nil, nil
+}
+
+func (m *mockOrgRepo) Create(ctx context.Context, org domain.Org) (domain.Org, error) {
+	return org, nil
+}
+
+func (m *mockOrgRepo) GetByExternalID(ctx context.Context, externalID string) (domain.Org, error) {
+	return domain.Org{ID: 1, Name: "SmallBiznis", Code: "client", ExternalID: externalID}, nil
+}
+
+func (m *mockOrgRepo) Count(ctx context.Context) (int64, error) {
+	return 1, nil
+}
+
+func (m *mockOrgRepo) List(ctx context.Context, status string, limit, offset int) ([]domain.Org, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockOrgRepo) Update(ctx context.Context, org domain.Org) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) SetStatus(ctx context.Context, orgID int64, status string) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) SoftDelete(ctx context.Context, orgID int64) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) Restore(ctx context.Context, orgID int64, status string) (domain.Org, error) {
+	return domain.Org{}, nil
+}
+
+func (m *mockOrgRepo) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int64, error) {
+	return nil, nil
+}
+
 func strPtr(s string) *string {
 	return &s
 }
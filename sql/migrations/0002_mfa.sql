@@ -0,0 +1,55 @@
+-- ==========================================================
+-- MFA POLICY (PER TENANT)
+-- ==========================================================
+CREATE TABLE IF NOT EXISTS mfa_configs (
+    tenant_id BIGINT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+
+    policy VARCHAR(20) NOT NULL DEFAULT 'off'
+        CHECK (policy IN ('off','optional','required')),
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ==========================================================
+-- USER MFA FACTORS
+-- ==========================================================
+CREATE TABLE IF NOT EXISTS user_mfa_factors (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    factor_type VARCHAR(20) NOT NULL
+        CHECK (factor_type IN ('totp')),
+
+    -- Encrypted at rest (AES-GCM, see internal/secretbox).
+    secret TEXT NOT NULL,
+    confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+
+    confirmed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (tenant_id, user_id, factor_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_mfa_factors_user ON user_mfa_factors(tenant_id, user_id);
+
+-- ==========================================================
+-- MFA RECOVERY CODES
+-- ==========================================================
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (tenant_id, user_id, code_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes(tenant_id, user_id);
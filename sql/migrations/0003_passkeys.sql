@@ -0,0 +1,49 @@
+-- ==========================================================
+-- PASSKEY PROVIDER TYPE
+-- ==========================================================
+ALTER TABLE tenant_auth_providers
+    DROP CONSTRAINT IF EXISTS tenant_auth_providers_provider_type_check;
+
+ALTER TABLE tenant_auth_providers
+    ADD CONSTRAINT tenant_auth_providers_provider_type_check
+        CHECK (provider_type IN (
+            'password',
+            'otp',
+            'passkey',
+            'google',
+            'apple',
+            'github',
+            'microsoft',
+            'oidc',
+            'saml'
+        ));
+
+-- ==========================================================
+-- USER WEBAUTHN CREDENTIALS
+-- ==========================================================
+CREATE TABLE IF NOT EXISTS user_webauthn_credentials (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- RP ID (org primary domain host) the credential was registered for.
+    rp_id VARCHAR(255) NOT NULL,
+    credential_id BYTEA NOT NULL,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(50) NOT NULL DEFAULT 'none',
+    transports TEXT[] NOT NULL DEFAULT '{}',
+    aaguid BYTEA,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (tenant_id, credential_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_webauthn_credentials_user
+    ON user_webauthn_credentials(tenant_id, user_id);
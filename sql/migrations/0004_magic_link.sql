@@ -0,0 +1,22 @@
+-- ==========================================================
+-- MAGIC LINK PROVIDER TYPE
+-- ==========================================================
+-- Magic links themselves are single-use records kept in Redis; only the
+-- per-org enablement lives in Postgres.
+ALTER TABLE tenant_auth_providers
+    DROP CONSTRAINT IF EXISTS tenant_auth_providers_provider_type_check;
+
+ALTER TABLE tenant_auth_providers
+    ADD CONSTRAINT tenant_auth_providers_provider_type_check
+        CHECK (provider_type IN (
+            'password',
+            'otp',
+            'passkey',
+            'magic_link',
+            'google',
+            'apple',
+            'github',
+            'microsoft',
+            'oidc',
+            'saml'
+        ));
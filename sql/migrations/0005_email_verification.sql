@@ -0,0 +1,15 @@
+-- ==========================================================
+-- EMAIL VERIFICATION POLICY (PER TENANT)
+-- ==========================================================
+-- optional: unverified users sign in normally
+-- limited:  unverified users only receive openid/profile/email scopes
+-- required: unverified users cannot sign in
+ALTER TABLE password_configs
+    ADD COLUMN IF NOT EXISTS email_verification VARCHAR(20) NOT NULL DEFAULT 'optional';
+
+ALTER TABLE password_configs
+    DROP CONSTRAINT IF EXISTS password_configs_email_verification_check;
+
+ALTER TABLE password_configs
+    ADD CONSTRAINT password_configs_email_verification_check
+        CHECK (email_verification IN ('optional','limited','required'));
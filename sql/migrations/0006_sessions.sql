@@ -0,0 +1,27 @@
+-- ==========================================================
+-- USER SESSIONS
+-- ==========================================================
+-- Browser sessions created at login. Redis holds the live session keyed by
+-- token hash and enforces the idle timeout; this table keeps the history used
+-- for listing and revocation.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- SHA-256 of the opaque cookie value; the raw token is never stored.
+    token_hash VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+
+    UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user
+    ON user_sessions(tenant_id, user_id)
+    WHERE revoked_at IS NULL;
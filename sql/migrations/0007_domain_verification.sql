@@ -0,0 +1,19 @@
+-- ==========================================================
+-- DOMAIN VERIFICATION
+-- ==========================================================
+-- Unverified hosts are no longer routed. Domains inserted before the
+-- verification workflow existed have no token; treat them as verified by an
+-- operator so existing deployments keep serving them.
+UPDATE domains
+SET verified = TRUE,
+    verification_method = 'manual',
+    verified_at = COALESCE(verified_at, NOW()),
+    provisioning_status = 'active',
+    provisioned_at = COALESCE(provisioned_at, NOW()),
+    updated_at = NOW()
+WHERE COALESCE(verified, FALSE) = FALSE
+  AND verification_code IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_domains_provisioning
+    ON domains(verified_at)
+    WHERE verified = TRUE AND provisioning_status IN ('pending', 'provisioning');
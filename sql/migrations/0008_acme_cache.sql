@@ -0,0 +1,11 @@
+-- ==========================================================
+-- ACME CERTIFICATE CACHE
+-- ==========================================================
+-- Shared storage for the built-in HTTPS listener: the ACME account key,
+-- issued certificates with their private keys, and pending challenge tokens.
+-- Every replica reads and writes the same rows.
+CREATE TABLE IF NOT EXISTS acme_cache (
+    key TEXT PRIMARY KEY,
+    data BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- ==========================================================
+-- ORG LIFECYCLE
+-- ==========================================================
+-- Soft-deleted orgs keep their rows until the retention period ends, then the
+-- tenants row is deleted and ON DELETE CASCADE removes everything else.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tenants_deleted_at
+    ON tenants(deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+-- Statuses are compared in lower case; older rows were written as 'ACTIVE'.
+UPDATE tenants SET status = LOWER(status) WHERE status <> LOWER(status);
@@ -0,0 +1,21 @@
+-- ==========================================================
+-- ORG CONFIG ADMIN API
+-- ==========================================================
+-- Admin writes use updated_at for optimistic concurrency, so it must always
+-- be set.
+UPDATE brandings SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+UPDATE password_configs SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+UPDATE otp_configs SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+UPDATE tenant_auth_providers SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+UPDATE oauth_idp_configs SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+
+-- Social login looks IdP configs up by provider name, so an org has at most
+-- one per provider. Keep the most recently updated row of any duplicates.
+DELETE FROM oauth_idp_configs c
+USING oauth_idp_configs newer
+WHERE c.tenant_id = newer.tenant_id
+  AND c.provider = newer.provider
+  AND (c.updated_at, c.id) < (newer.updated_at, newer.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_idp_configs_tenant_provider
+    ON oauth_idp_configs(tenant_id, provider);
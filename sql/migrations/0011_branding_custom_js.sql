@@ -0,0 +1,6 @@
+-- ==========================================================
+-- BRANDING CUSTOM JS GATE
+-- ==========================================================
+-- Custom JS runs on the login page, so it is only served once a platform
+-- admin has enabled it for the org.
+ALTER TABLE brandings ADD COLUMN IF NOT EXISTS custom_js_enabled BOOLEAN NOT NULL DEFAULT FALSE;
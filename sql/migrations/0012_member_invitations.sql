@@ -0,0 +1,23 @@
+-- ==========================================================
+-- MEMBER INVITATIONS
+-- ==========================================================
+-- Invitations are tenant_users rows with status INVITED. The invitee may not
+-- have an account yet, so user_id is only set once the invitation is
+-- accepted.
+ALTER TABLE tenant_users ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE tenant_users ADD COLUMN IF NOT EXISTS invited_by BIGINT;
+ALTER TABLE tenant_users ADD COLUMN IF NOT EXISTS invite_expires_at TIMESTAMPTZ;
+-- invite_nonce is embedded in the signed invite link. Resending replaces it,
+-- so only the latest link can be accepted.
+ALTER TABLE tenant_users ADD COLUMN IF NOT EXISTS invite_nonce TEXT;
+
+ALTER TABLE tenant_users
+    DROP CONSTRAINT IF EXISTS tenant_users_user_check;
+
+ALTER TABLE tenant_users
+    ADD CONSTRAINT tenant_users_user_check
+        CHECK (user_id IS NOT NULL OR (status = 'INVITED' AND invited_email IS NOT NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_users_pending_invite
+    ON tenant_users(tenant_id, invited_email) WHERE status = 'INVITED';
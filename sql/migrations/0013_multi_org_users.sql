@@ -0,0 +1,10 @@
+-- ==========================================================
+-- MULTI-ORG USERS
+-- ==========================================================
+-- A user signs in at the org that owns the account and can act in any org
+-- where they are an active member (tenant_users). active_tenant_id is the org
+-- the issued tokens are for; NULL means the issuing org (tenant_id).
+ALTER TABLE oauth_tokens ADD COLUMN IF NOT EXISTS active_tenant_id BIGINT REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE oauth_codes ADD COLUMN IF NOT EXISTS active_tenant_id BIGINT REFERENCES tenants(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_tenant_users_user_active ON tenant_users(user_id) WHERE status = 'ACTIVE';
@@ -0,0 +1,8 @@
+-- ==========================================================
+-- PUSHED AUTHORIZATION REQUESTS & REQUEST OBJECTS
+-- ==========================================================
+-- jwks holds the client's public keys (a JSON Web Key Set) for verifying
+-- signed request objects. Clients with require_pushed_authorization_requests
+-- can only start /oauth/authorize with a request_uri from /oauth/par.
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS jwks JSONB;
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS require_pushed_authorization_requests BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,8 @@
+-- ==========================================================
+-- RP-INITIATED & BACK-CHANNEL LOGOUT
+-- ==========================================================
+-- post_logout_redirect_uris lists where /oauth/logout may send the browser
+-- after signing out. backchannel_logout_uri receives a signed logout token
+-- whenever a user with tokens for the client signs out.
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS post_logout_redirect_uris TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS backchannel_logout_uri TEXT;
@@ -0,0 +1,7 @@
+-- ==========================================================
+-- TOKEN EXCHANGE (RFC 8693)
+-- ==========================================================
+-- token_exchange_audiences lists the audiences a client may request when it
+-- exchanges a subject token at /oauth/token. Clients must also list the
+-- urn:ietf:params:oauth:grant-type:token-exchange grant.
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS token_exchange_audiences TEXT[] NOT NULL DEFAULT '{}';
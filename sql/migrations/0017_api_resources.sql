@@ -0,0 +1,29 @@
+-- ==========================================================
+-- API RESOURCES (RFC 8707 RESOURCE INDICATORS)
+-- ==========================================================
+-- api_resources registers the APIs an org protects. Clients name one with the
+-- resource (or audience) parameter; the issued access token is audienced to
+-- its identifier, limited to its scopes and lives for access_token_ttl_seconds
+-- (NULL means the server default).
+CREATE TABLE IF NOT EXISTS api_resources (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+
+    identifier TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    access_token_ttl_seconds INTEGER,
+    signing_alg TEXT NOT NULL DEFAULT 'HS256',
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (tenant_id, identifier)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_resources_tenant_id ON api_resources(tenant_id);
+
+-- The resource a code was requested for, and the resource a token (and its
+-- refreshes) is audienced to. NULL means the org-wide audience.
+ALTER TABLE oauth_codes ADD COLUMN IF NOT EXISTS resource TEXT;
+ALTER TABLE oauth_tokens ADD COLUMN IF NOT EXISTS resource TEXT;
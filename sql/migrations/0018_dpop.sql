@@ -0,0 +1,8 @@
+-- ==========================================================
+-- DPOP SENDER-CONSTRAINED TOKENS (RFC 9449)
+-- ==========================================================
+-- dpop_bound_access_tokens makes /oauth/token refuse requests from the client
+-- that carry no DPoP proof. dpop_jkt records the JWK thumbprint an issued
+-- token is bound to, so its refresh token can only be used with the same key.
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS dpop_bound_access_tokens BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE oauth_tokens ADD COLUMN IF NOT EXISTS dpop_jkt TEXT;
@@ -0,0 +1,20 @@
+-- ==========================================================
+-- OPAQUE ACCESS TOKENS (PER CLIENT)
+-- ==========================================================
+-- jwt:    access tokens are self-contained signed JWTs
+-- opaque: access tokens are random strings resolved through oauth_tokens;
+--         only their SHA-256 hash is stored, in access_token
+ALTER TABLE oauth_clients
+    ADD COLUMN IF NOT EXISTS access_token_format VARCHAR(20) NOT NULL DEFAULT 'jwt';
+
+ALTER TABLE oauth_clients
+    DROP CONSTRAINT IF EXISTS oauth_clients_access_token_format_check;
+
+ALTER TABLE oauth_clients
+    ADD CONSTRAINT oauth_clients_access_token_format_check
+        CHECK (access_token_format IN ('jwt','opaque'));
+
+-- expires_at is the refresh token's expiry on rows that carry one, so the
+-- access token's own expiry is kept separately. Refreshes replace both the
+-- access token and its expiry.
+ALTER TABLE oauth_tokens ADD COLUMN IF NOT EXISTS access_expires_at TIMESTAMPTZ;
@@ -0,0 +1,19 @@
+-- ==========================================================
+-- RATE LIMIT OVERRIDES (PER ORG)
+-- ==========================================================
+-- Replaces the server-wide limit of a named rate limit policy (auth,
+-- auth_identifier, token, token_client) for one org: at most max_requests
+-- requests per window_seconds for each key the policy counts.
+CREATE TABLE IF NOT EXISTS rate_limit_overrides (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+
+    policy VARCHAR(50) NOT NULL,
+    max_requests INTEGER NOT NULL CHECK (max_requests > 0),
+    window_seconds INTEGER NOT NULL CHECK (window_seconds > 0),
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (tenant_id, policy)
+);
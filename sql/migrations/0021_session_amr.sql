@@ -0,0 +1,7 @@
+-- ==========================================================
+-- SESSION AUTHENTICATION METHODS
+-- ==========================================================
+-- amr lists the methods the user completed when the session was opened
+-- (e.g. password, google, mfa), so /oauth/authorize can tell a session that
+-- only passed a first factor from one that satisfies the org's MFA policy.
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS amr TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,14 @@
+-- ==========================================================
+-- DOMAIN HOST CLAIMS
+-- ==========================================================
+-- A host belongs to the org that verifies it. Unverified rows are only
+-- claims, so several orgs may hold one for the same host without blocking
+-- the real owner; each org may claim a host once.
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS domains_host_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_verified_domain_host
+    ON domains(host)
+    WHERE verified = TRUE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_domain_host_per_tenant
+    ON domains(tenant_id, host);
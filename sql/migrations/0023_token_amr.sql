@@ -0,0 +1,7 @@
+-- ==========================================================
+-- TOKEN AUTHENTICATION METHODS
+-- ==========================================================
+-- amr lists the methods the user completed for the grant (e.g. password,
+-- mfa), so refreshed access tokens report how the user actually signed in
+-- rather than the org's current provider list.
+ALTER TABLE oauth_tokens ADD COLUMN IF NOT EXISTS amr TEXT[] NOT NULL DEFAULT '{}';
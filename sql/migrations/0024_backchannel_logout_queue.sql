@@ -0,0 +1,22 @@
+-- ==========================================================
+-- BACK-CHANNEL LOGOUT DELIVERIES
+-- ==========================================================
+-- One row per logout token still to be delivered to a client's
+-- backchannel_logout_uri. Workers claim due rows by pushing next_attempt_at
+-- forward, so a delivery interrupted by a restart is retried once that
+-- lease runs out. Rows are deleted once delivered or given up on.
+CREATE TABLE IF NOT EXISTS backchannel_logout_deliveries (
+    id BIGINT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+
+    client_id TEXT NOT NULL,
+    user_id BIGINT NOT NULL,
+    issuer TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_backchannel_logout_deliveries_due
+    ON backchannel_logout_deliveries(next_attempt_at);
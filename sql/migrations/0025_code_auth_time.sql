@@ -0,0 +1,7 @@
+-- ==========================================================
+-- AUTHORIZATION CODE AUTH TIME
+-- ==========================================================
+-- auth_time is when the user signed in to the session that approved the
+-- code, reported in the ID token so clients can enforce max_age and
+-- prompt=login. NULL for codes issued before this column existed.
+ALTER TABLE oauth_codes ADD COLUMN IF NOT EXISTS auth_time TIMESTAMPTZ;
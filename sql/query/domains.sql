@@ -1,12 +1,13 @@
 -- name: GetDomainByHost :one
 -- name: GetDomainByHost :one
-SELECT id, host, tenant_id
+SELECT id, host, tenant_id, COALESCE(verified, FALSE) AS verified
 FROM domains
 WHERE host = $1
+ORDER BY COALESCE(verified, FALSE) DESC, id ASC
 LIMIT 1;
 
 -- name: GetPrimaryDomain :one
-SELECT id, host, tenant_id
+SELECT id, host, tenant_id, COALESCE(verified, FALSE) AS verified
 FROM domains
 WHERE tenant_id = $1
 ORDER BY is_primary DESC, id ASC
@@ -1,12 +1,12 @@
 -- name: InsertOAuthCode :exec
 INSERT INTO oauth_codes (
-    id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at
+    id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at, active_tenant_id, resource, auth_time
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12
 );
 
 -- name: GetOAuthCode :one
-SELECT id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at, revoked, created_at
+SELECT id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), auth_time
 FROM oauth_codes
 WHERE tenant_id = $1 AND code = $2
 LIMIT 1;
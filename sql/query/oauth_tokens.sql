@@ -1,24 +1,24 @@
 -- name: InsertOAuthToken :one
 INSERT INTO oauth_tokens (
-    id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at
+    id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, active_tenant_id, resource, dpop_jkt, access_expires_at, amr
 ) VALUES (
-    $1, $2, $3, sqlc.narg('user_id'), $5, $6, $7, $8
-) RETURNING id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at;
+    $1, $2, $3, sqlc.narg('user_id'), $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), $12, COALESCE($13::text[], '{}')
+) RETURNING id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr;
 
 -- name: GetOAuthTokenByRefresh :one
-SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at
+SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr
 FROM oauth_tokens
 WHERE tenant_id = $1 AND refresh_token = $2
 LIMIT 1;
 
 -- name: GetOAuthTokenByRefreshValue :one
-SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at
+SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr
 FROM oauth_tokens
 WHERE refresh_token = $1
 LIMIT 1;
 
 -- name: GetOAuthTokenByAccess :one
-SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at
+SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr
 FROM oauth_tokens
 WHERE access_token = $1
 LIMIT 1;
@@ -26,10 +26,28 @@ LIMIT 1;
 -- name: RotateRefreshToken :exec
 UPDATE oauth_tokens
 SET refresh_token = $2,
-    expires_at = $3
+    expires_at = $3,
+    active_tenant_id = $4,
+    access_token = $5,
+    access_expires_at = $6
 WHERE id = $1;
 
 -- name: RevokeOAuthToken :exec
 UPDATE oauth_tokens
 SET revoked = true
 WHERE id = $1;
+
+-- name: RevokeOAuthTokensByUser :exec
+UPDATE oauth_tokens
+SET revoked = true
+WHERE tenant_id = $1 AND user_id = $2 AND revoked = false;
+
+-- name: RevokeOAuthTokensByUserClient :exec
+UPDATE oauth_tokens
+SET revoked = true
+WHERE tenant_id = $1 AND user_id = $2 AND client_id = $3 AND revoked = false;
+
+-- name: ListOAuthTokenClientsByUser :many
+SELECT DISTINCT client_id
+FROM oauth_tokens
+WHERE tenant_id = $1 AND user_id = $2 AND revoked = false;
@@ -9,6 +9,7 @@ SELECT
     allow_password_reset,
     lockout_attempts,
     lockout_duration_seconds,
+    email_verification,
     created_at,
     updated_at
 FROM password_configs
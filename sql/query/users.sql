@@ -9,3 +9,10 @@ SELECT id, tenant_id, email, email_verified, password_hash, name, phone, phone_v
 FROM users
 WHERE tenant_id = $1 AND id = $2
 LIMIT 1;
+
+-- name: GetVerifiedUserByEmail :one
+SELECT id, tenant_id, email, email_verified, password_hash, name, phone, phone_verified, avatar_url, status, created_at, updated_at
+FROM users
+WHERE email = $1 AND email_verified = TRUE AND status = 'ACTIVE'
+ORDER BY created_at ASC, id ASC
+LIMIT 1;
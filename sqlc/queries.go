@@ -34,23 +34,24 @@ type GetDomainByHostRow struct {
 	ID       int64
 	Host     string
 	TenantID int64
+	Verified bool
 }
 
-const getDomainByHostSQL = `SELECT id, host, tenant_id FROM domains WHERE host = $1 LIMIT 1`
+const getDomainByHostSQL = `SELECT id, host, tenant_id, COALESCE(verified, FALSE) FROM domains WHERE host = $1 ORDER BY COALESCE(verified, FALSE) DESC, id ASC LIMIT 1`
 
 func (q *Queries) GetDomainByHost(ctx context.Context, host string) (GetDomainByHostRow, error) {
 	row := q.db.QueryRow(ctx, getDomainByHostSQL, host)
 	var res GetDomainByHostRow
-	err := row.Scan(&res.ID, &res.Host, &res.TenantID)
+	err := row.Scan(&res.ID, &res.Host, &res.TenantID, &res.Verified)
 	return res, err
 }
 
-const getPrimaryDomainSQL = `SELECT id, host, tenant_id FROM domains WHERE tenant_id = $1 ORDER BY is_primary DESC, id ASC LIMIT 1`
+const getPrimaryDomainSQL = `SELECT id, host, tenant_id, COALESCE(verified, FALSE) FROM domains WHERE tenant_id = $1 ORDER BY is_primary DESC, id ASC LIMIT 1`
 
 func (q *Queries) GetPrimaryDomain(ctx context.Context, tenantID int64) (GetDomainByHostRow, error) {
 	row := q.db.QueryRow(ctx, getPrimaryDomainSQL, tenantID)
 	var res GetDomainByHostRow
-	err := row.Scan(&res.ID, &res.Host, &res.TenantID)
+	err := row.Scan(&res.ID, &res.Host, &res.TenantID, &res.Verified)
 	return res, err
 }
 
@@ -169,11 +170,12 @@ type GetPasswordConfigRow struct {
 	AllowPasswordReset     bool
 	LockoutAttempts        int32
 	LockoutDurationSeconds int32
+	EmailVerification      string
 	CreatedAt              time.Time
 	UpdatedAt              time.Time
 }
 
-const getPasswordConfigSQL = `SELECT tenant_id, min_length, require_uppercase, require_number, require_symbol, allow_signup, allow_password_reset, lockout_attempts, lockout_duration_seconds, created_at, updated_at FROM password_configs WHERE tenant_id = $1 LIMIT 1`
+const getPasswordConfigSQL = `SELECT tenant_id, min_length, require_uppercase, require_number, require_symbol, allow_signup, allow_password_reset, lockout_attempts, lockout_duration_seconds, email_verification, created_at, updated_at FROM password_configs WHERE tenant_id = $1 LIMIT 1`
 
 func (q *Queries) GetPasswordConfig(ctx context.Context, tenantID int64) (GetPasswordConfigRow, error) {
 	row := q.db.QueryRow(ctx, getPasswordConfigSQL, tenantID)
@@ -188,6 +190,7 @@ func (q *Queries) GetPasswordConfig(ctx context.Context, tenantID int64) (GetPas
 		&res.AllowPasswordReset,
 		&res.LockoutAttempts,
 		&res.LockoutDurationSeconds,
+		&res.EmailVerification,
 		&res.CreatedAt,
 		&res.UpdatedAt,
 	)
@@ -344,60 +347,89 @@ func (q *Queries) GetUserByID(ctx context.Context, tenantID, userID int64) (GetU
 	return res, err
 }
 
-// OAuth token rows.
-type InsertOAuthTokenRow struct {
-	ID           int64
-	TenantID     int64
-	ClientID     string
-	UserID       sql.NullInt64
-	AccessToken  string
-	RefreshToken sql.NullString
-	Scopes       []string
-	ExpiresAt    time.Time
-	Revoked      bool
-	CreatedAt    time.Time
-}
+const getVerifiedUserByEmailSQL = `SELECT id, tenant_id, email, email_verified, password_hash, name, phone, phone_verified, avatar_url, status, created_at, updated_at FROM users WHERE email = $1 AND email_verified = TRUE AND status = 'ACTIVE' ORDER BY created_at ASC, id ASC LIMIT 1`
 
-const insertOAuthTokenSQL = `INSERT INTO oauth_tokens (id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at`
+func (q *Queries) GetVerifiedUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
+	row := q.db.QueryRow(ctx, getVerifiedUserByEmailSQL, email)
+	var res GetUserByEmailRow
+	err := row.Scan(
+		&res.ID,
+		&res.TenantID,
+		&res.Email,
+		&res.EmailVerified,
+		&res.PasswordHash,
+		&res.Name,
+		&res.Phone,
+		&res.PhoneVerified,
+		&res.AvatarURL,
+		&res.Status,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+	return res, err
+}
 
-func (q *Queries) InsertOAuthToken(ctx context.Context, ID, tenantID int64, clientID string, userID sql.NullInt64, accessToken string, refreshToken sql.NullString, scopes []string, expiresAt time.Time) (InsertOAuthTokenRow, error) {
-	row := q.db.QueryRow(ctx, insertOAuthTokenSQL, ID, tenantID, clientID, userID, accessToken, refreshToken, scopes, expiresAt)
+// OAuth token rows.
+type InsertOAuthTokenRow struct {
+	ID             int64
+	TenantID       int64
+	ClientID       string
+	UserID         sql.NullInt64
+	AccessToken    string
+	RefreshToken   sql.NullString
+	Scopes         []string
+	ExpiresAt      time.Time
+	Revoked        bool
+	CreatedAt      time.Time
+	ActiveTenantID int64
+	Resource       string
+	DPoPJKT        string
+	// AccessExpiresAt is NULL for rows written before access token expiry
+	// was recorded.
+	AccessExpiresAt sql.NullTime
+	AMR             []string
+}
+
+const insertOAuthTokenSQL = `INSERT INTO oauth_tokens (id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, active_tenant_id, resource, dpop_jkt, access_expires_at, amr) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,NULLIF($10, ''),NULLIF($11, ''),$12,COALESCE($13::text[], '{}')) RETURNING id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr`
+
+func (q *Queries) InsertOAuthToken(ctx context.Context, ID, tenantID int64, clientID string, userID sql.NullInt64, accessToken string, refreshToken sql.NullString, scopes []string, expiresAt time.Time, activeTenantID int64, resource, dpopJKT string, accessExpiresAt sql.NullTime, amr []string) (InsertOAuthTokenRow, error) {
+	row := q.db.QueryRow(ctx, insertOAuthTokenSQL, ID, tenantID, clientID, userID, accessToken, refreshToken, scopes, expiresAt, activeTenantID, resource, dpopJKT, accessExpiresAt, amr)
 	var res InsertOAuthTokenRow
-	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt)
+	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt, &res.ActiveTenantID, &res.Resource, &res.DPoPJKT, &res.AccessExpiresAt, &res.AMR)
 	return res, err
 }
 
-const getOAuthTokenByRefreshSQL = `SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at FROM oauth_tokens WHERE tenant_id = $1 AND refresh_token = $2 LIMIT 1`
+const getOAuthTokenByRefreshSQL = `SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr FROM oauth_tokens WHERE tenant_id = $1 AND refresh_token = $2 LIMIT 1`
 
 func (q *Queries) GetOAuthTokenByRefresh(ctx context.Context, tenantID int64, refreshToken string) (InsertOAuthTokenRow, error) {
 	row := q.db.QueryRow(ctx, getOAuthTokenByRefreshSQL, tenantID, refreshToken)
 	var res InsertOAuthTokenRow
-	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt)
+	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt, &res.ActiveTenantID, &res.Resource, &res.DPoPJKT, &res.AccessExpiresAt, &res.AMR)
 	return res, err
 }
 
-const getOAuthTokenByRefreshValueSQL = `SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at FROM oauth_tokens WHERE refresh_token = $1 LIMIT 1`
+const getOAuthTokenByRefreshValueSQL = `SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr FROM oauth_tokens WHERE refresh_token = $1 LIMIT 1`
 
 func (q *Queries) GetOAuthTokenByRefreshValue(ctx context.Context, refreshToken string) (InsertOAuthTokenRow, error) {
 	row := q.db.QueryRow(ctx, getOAuthTokenByRefreshValueSQL, refreshToken)
 	var res InsertOAuthTokenRow
-	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt)
+	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt, &res.ActiveTenantID, &res.Resource, &res.DPoPJKT, &res.AccessExpiresAt, &res.AMR)
 	return res, err
 }
 
-const getOAuthTokenByAccessSQL = `SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at FROM oauth_tokens WHERE access_token = $1 LIMIT 1`
+const getOAuthTokenByAccessSQL = `SELECT id, tenant_id, client_id, user_id, access_token, refresh_token, scopes, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), COALESCE(dpop_jkt, ''), access_expires_at, amr FROM oauth_tokens WHERE access_token = $1 LIMIT 1`
 
 func (q *Queries) GetOAuthTokenByAccess(ctx context.Context, accessToken string) (InsertOAuthTokenRow, error) {
 	row := q.db.QueryRow(ctx, getOAuthTokenByAccessSQL, accessToken)
 	var res InsertOAuthTokenRow
-	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt)
+	err := row.Scan(&res.ID, &res.TenantID, &res.ClientID, &res.UserID, &res.AccessToken, &res.RefreshToken, &res.Scopes, &res.ExpiresAt, &res.Revoked, &res.CreatedAt, &res.ActiveTenantID, &res.Resource, &res.DPoPJKT, &res.AccessExpiresAt, &res.AMR)
 	return res, err
 }
 
-const rotateRefreshTokenSQL = `UPDATE oauth_tokens SET refresh_token = $2, expires_at = $3 WHERE id = $1`
+const rotateRefreshTokenSQL = `UPDATE oauth_tokens SET refresh_token = $2, expires_at = $3, active_tenant_id = $4, access_token = $5, access_expires_at = $6 WHERE id = $1`
 
-func (q *Queries) RotateRefreshToken(ctx context.Context, id int64, refreshToken string, expiresAt time.Time) error {
-	_, err := q.db.Exec(ctx, rotateRefreshTokenSQL, id, refreshToken, expiresAt)
+func (q *Queries) RotateRefreshToken(ctx context.Context, id int64, refreshToken string, expiresAt time.Time, activeTenantID int64, accessToken string, accessExpiresAt time.Time) error {
+	_, err := q.db.Exec(ctx, rotateRefreshTokenSQL, id, refreshToken, expiresAt, activeTenantID, accessToken, accessExpiresAt)
 	return err
 }
 
@@ -408,6 +440,40 @@ func (q *Queries) RevokeOAuthToken(ctx context.Context, id int64) error {
 	return err
 }
 
+const revokeOAuthTokensByUserSQL = `UPDATE oauth_tokens SET revoked = true WHERE tenant_id = $1 AND user_id = $2 AND revoked = false`
+
+func (q *Queries) RevokeOAuthTokensByUser(ctx context.Context, tenantID, userID int64) error {
+	_, err := q.db.Exec(ctx, revokeOAuthTokensByUserSQL, tenantID, userID)
+	return err
+}
+
+const revokeOAuthTokensByUserClientSQL = `UPDATE oauth_tokens SET revoked = true WHERE tenant_id = $1 AND user_id = $2 AND client_id = $3 AND revoked = false`
+
+func (q *Queries) RevokeOAuthTokensByUserClient(ctx context.Context, tenantID, userID int64, clientID string) error {
+	_, err := q.db.Exec(ctx, revokeOAuthTokensByUserClientSQL, tenantID, userID, clientID)
+	return err
+}
+
+const listOAuthTokenClientsByUserSQL = `SELECT DISTINCT client_id FROM oauth_tokens WHERE tenant_id = $1 AND user_id = $2 AND revoked = false`
+
+func (q *Queries) ListOAuthTokenClientsByUser(ctx context.Context, tenantID, userID int64) ([]string, error) {
+	rows, err := q.db.Query(ctx, listOAuthTokenClientsByUserSQL, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var clientID string
+		if err := rows.Scan(&clientID); err != nil {
+			return nil, err
+		}
+		res = append(res, clientID)
+	}
+	return res, rows.Err()
+}
+
 // OAuth code rows.
 type GetOAuthCodeRow struct {
 	ID                  int64
@@ -421,16 +487,19 @@ type GetOAuthCodeRow struct {
 	ExpiresAt           time.Time
 	Revoked             bool
 	CreatedAt           time.Time
+	ActiveTenantID      int64
+	Resource            string
+	AuthTime            sql.NullTime
 }
 
-const insertOAuthCodeSQL = `INSERT INTO oauth_codes (id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+const insertOAuthCodeSQL = `INSERT INTO oauth_codes (id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at, active_tenant_id, resource, auth_time) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,NULLIF($11, ''),$12)`
 
-func (q *Queries) InsertOAuthCode(ctx context.Context, id, tenantID int64, clientID string, userID int64, code, redirectURI string, codeChallenge sql.NullString, codeChallengeMethod sql.NullString, expiresAt time.Time) error {
-	_, err := q.db.Exec(ctx, insertOAuthCodeSQL, id, tenantID, clientID, userID, code, redirectURI, codeChallenge, codeChallengeMethod, expiresAt)
+func (q *Queries) InsertOAuthCode(ctx context.Context, id, tenantID int64, clientID string, userID int64, code, redirectURI string, codeChallenge sql.NullString, codeChallengeMethod sql.NullString, expiresAt time.Time, activeTenantID int64, resource string, authTime sql.NullTime) error {
+	_, err := q.db.Exec(ctx, insertOAuthCodeSQL, id, tenantID, clientID, userID, code, redirectURI, codeChallenge, codeChallengeMethod, expiresAt, activeTenantID, resource, authTime)
 	return err
 }
 
-const getOAuthCodeSQL = `SELECT id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at, revoked, created_at FROM oauth_codes WHERE tenant_id = $1 AND code = $2 LIMIT 1`
+const getOAuthCodeSQL = `SELECT id, tenant_id, client_id, user_id, code, redirect_uri, code_challenge, code_challenge_method, expires_at, revoked, created_at, COALESCE(active_tenant_id, tenant_id), COALESCE(resource, ''), auth_time FROM oauth_codes WHERE tenant_id = $1 AND code = $2 LIMIT 1`
 
 func (q *Queries) GetOAuthCode(ctx context.Context, tenantID int64, code string) (GetOAuthCodeRow, error) {
 	row := q.db.QueryRow(ctx, getOAuthCodeSQL, tenantID, code)
@@ -447,6 +516,9 @@ func (q *Queries) GetOAuthCode(ctx context.Context, tenantID int64, code string)
 		&res.ExpiresAt,
 		&res.Revoked,
 		&res.CreatedAt,
+		&res.ActiveTenantID,
+		&res.Resource,
+		&res.AuthTime,
 	)
 	return res, err
 }
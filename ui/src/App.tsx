@@ -2,12 +2,18 @@ import AuthBrand from './components/AuthBrand'
 import AuthButton from './components/AuthButton'
 import AuthCard from './components/AuthCard'
 import AuthLayout from './components/AuthLayout'
+import AcceptInvite from './pages/AcceptInvite'
 import ErrorPage from './pages/Error'
 import ForgotPassword from './pages/ForgotPassword'
 import Login from './pages/Login'
+import MagicLinkRequest from './pages/MagicLinkRequest'
+import MFAVerify from './pages/MfaVerify'
 import OTPRequest from './pages/OtpRequest'
 import OTPVerify from './pages/OtpVerify'
+import PasskeyLogin from './pages/PasskeyLogin'
 import Register from './pages/Register'
+import SelectOrg from './pages/SelectOrg'
+import VerifyEmail from './pages/VerifyEmail'
 
 function App() {
   const path = window.location.pathname
@@ -32,6 +38,34 @@ function App() {
     return <OTPVerify />
   }
 
+  if (path === '/passkey') {
+    return <PasskeyLogin />
+  }
+
+  if (path === '/magic-link') {
+    return <MagicLinkRequest />
+  }
+
+  if (path === '/verify-email') {
+    return <VerifyEmail />
+  }
+
+  if (path === '/email-verified') {
+    return <VerifyEmail verified />
+  }
+
+  if (path === '/invite') {
+    return <AcceptInvite />
+  }
+
+  if (path === '/select-org') {
+    return <SelectOrg />
+  }
+
+  if (path === '/mfa') {
+    return <MFAVerify />
+  }
+
   if (path === '/error') {
     return <ErrorPage />
   }
@@ -3,6 +3,17 @@ export type APIError = {
   error_description?: string
 }
 
+// APIRequestError keeps the decoded error payload so callers can react to
+// structured errors such as `mfa_required`.
+export class APIRequestError extends Error {
+  payload: APIError & Record<string, unknown>
+
+  constructor(message: string, payload: APIError & Record<string, unknown>) {
+    super(message)
+    this.payload = payload
+  }
+}
+
 export async function postJSON<T>(path: string, body: unknown): Promise<T> {
   const response = await fetch(path, {
     method: 'POST',
@@ -15,7 +26,7 @@ export async function postJSON<T>(path: string, body: unknown): Promise<T> {
   if (!response.ok) {
     const message =
       payload.error_description || payload.error || response.statusText
-    throw new Error(message)
+    throw new APIRequestError(message, payload)
   }
 
   return payload as T
@@ -32,7 +43,7 @@ export async function getJSON<T>(path: string): Promise<T> {
   if (!response.ok) {
     const message =
       payload.error_description || payload.error || response.statusText
-    throw new Error(message)
+    throw new APIRequestError(message, payload)
   }
 
   return payload as T
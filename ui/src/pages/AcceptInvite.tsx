@@ -0,0 +1,166 @@
+import { useEffect, useMemo, useState } from 'react'
+import { getJSON, postJSON } from '../api'
+import AuthBrand from '../components/AuthBrand'
+import AuthButton from '../components/AuthButton'
+import AuthCard from '../components/AuthCard'
+import AuthInput from '../components/AuthInput'
+import AuthLayout from '../components/AuthLayout'
+import {
+  passwordInputPattern,
+  passwordRequirements,
+  validatePassword,
+} from '../utils/password'
+import { getQueryParam } from '../utils/query'
+
+type InvitationPreview = {
+  org: string
+  email: string
+  role: string
+  expires_at: string
+  account_exists: boolean
+}
+
+type AcceptResponse = {
+  user_created: boolean
+}
+
+export default function AcceptInvite() {
+  const token = useMemo(() => getQueryParam('token'), [])
+
+  const [invitation, setInvitation] = useState<InvitationPreview | null>(null)
+  const [name, setName] = useState('')
+  const [password, setPassword] = useState('')
+  const [error, setError] = useState<string | null>(
+    token ? null : 'This invitation link is incomplete.',
+  )
+  const [accepted, setAccepted] = useState(false)
+  const [submitting, setSubmitting] = useState(false)
+
+  useEffect(() => {
+    if (!token) {
+      return
+    }
+    getJSON<InvitationPreview>(
+      `/auth/invitations?token=${encodeURIComponent(token)}`,
+    )
+      .then(setInvitation)
+      .catch((err) =>
+        setError(err instanceof Error ? err.message : 'Invitation is invalid.'),
+      )
+  }, [token])
+
+  async function onSubmit(event: React.FormEvent<HTMLFormElement>) {
+    event.preventDefault()
+    setError(null)
+
+    if (invitation && !invitation.account_exists) {
+      const passwordError = validatePassword(password)
+      if (passwordError) {
+        setError(passwordError)
+        return
+      }
+    }
+
+    setSubmitting(true)
+    try {
+      await postJSON<AcceptResponse>('/auth/invitations/accept', {
+        token,
+        name: name || undefined,
+        password: password || undefined,
+      })
+      setAccepted(true)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Could not accept invitation.')
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  if (accepted) {
+    return (
+      <AuthLayout>
+        <AuthCard>
+          <div className="space-y-6">
+            <AuthBrand />
+            <div className="space-y-2">
+              <h1 className="text-3xl font-semibold tracking-tight text-text-primary">
+                Invitation accepted
+              </h1>
+              <p className="text-sm text-text-muted">
+                You joined {invitation?.org || 'the organization'}. Return to
+                the app and sign in to continue.
+              </p>
+            </div>
+          </div>
+        </AuthCard>
+      </AuthLayout>
+    )
+  }
+
+  return (
+    <AuthLayout>
+      <AuthCard>
+        <div className="space-y-6">
+          <AuthBrand />
+
+          <div className="space-y-2">
+            <h1 className="text-3xl font-semibold tracking-tight text-text-primary">
+              Join {invitation?.org || 'your team'}
+            </h1>
+            {invitation ? (
+              <p className="text-sm text-text-muted">
+                {invitation.email} was invited as {invitation.role}.
+                {invitation.account_exists
+                  ? ' Accept to add the organization to your account.'
+                  : ' Choose a password to create your account.'}
+              </p>
+            ) : null}
+          </div>
+
+          {error ? (
+            <div
+              className="rounded-xl border border-status-error/40 bg-status-error/10 px-4 py-3 text-sm text-status-error"
+              role="alert"
+            >
+              {error}
+            </div>
+          ) : null}
+
+          {invitation ? (
+            <form className="space-y-4" onSubmit={onSubmit}>
+              {!invitation.account_exists ? (
+                <>
+                  <AuthInput
+                    label="Full name"
+                    type="text"
+                    autoComplete="name"
+                    helperText="Optional"
+                    value={name}
+                    onChange={(event) => setName(event.target.value)}
+                  />
+
+                  <AuthInput
+                    label="Password"
+                    type="password"
+                    autoComplete="new-password"
+                    helperText={passwordRequirements()}
+                    minLength={8}
+                    pattern={passwordInputPattern}
+                    title={passwordRequirements()}
+                    required
+                    value={password}
+                    onChange={(event) => setPassword(event.target.value)}
+                  />
+                </>
+              ) : null}
+
+              <AuthButton type="submit" disabled={submitting}>
+                {submitting ? 'Accepting...' : 'Accept invitation'}
+              </AuthButton>
+            </form>
+          ) : null}
+        </div>
+      </AuthCard>
+    </AuthLayout>
+  )
+}
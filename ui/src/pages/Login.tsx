@@ -1,5 +1,5 @@
 import { useEffect, useMemo, useState } from 'react'
-import { getJSON, postJSON } from '../api'
+import { APIRequestError, getJSON, postJSON } from '../api'
 import AuthBrand from '../components/AuthBrand'
 import AuthButton from '../components/AuthButton'
 import AuthCard from '../components/AuthCard'
@@ -10,6 +10,7 @@ import {
   passwordRequirements,
   validatePassword,
 } from '../utils/password'
+import { nextURLAfterSignIn, type UserOrg } from '../utils/orgs'
 import { buildQueryWithCurrent, getQueryParam } from '../utils/query'
 
 type LoginResponse = {
@@ -18,6 +19,7 @@ type LoginResponse = {
   token_type: string
   expires_in: number
   authorize_url?: string
+  orgs?: UserOrg[]
 }
 
 type OAuthProvider = {
@@ -47,7 +49,7 @@ export default function Login() {
   )
   const sharedQuery = useMemo(() => buildQueryWithCurrent(), [])
 
-  const [email, setEmail] = useState('')
+  const [email, setEmail] = useState(() => getQueryParam('login_hint'))
   const [password, setPassword] = useState('')
   const [error, setError] = useState<string | null>(null)
   const [submitting, setSubmitting] = useState(false)
@@ -122,12 +124,33 @@ export default function Login() {
         scope,
         state: authorizeState,
       })
-      if (payload.authorize_url) {
-        window.location.href = payload.authorize_url
+      window.location.href = nextURLAfterSignIn(payload, returnTo)
+    } catch (err) {
+      if (
+        err instanceof APIRequestError &&
+        err.payload.error === 'mfa_required'
+      ) {
+        // Keep the challenge token out of the URL and browser history.
+        sessionStorage.setItem(
+          'mfa_challenge',
+          JSON.stringify({
+            mfa_token: err.payload.mfa_token,
+            enrollment_required: err.payload.enrollment_required === true,
+            factors: Array.isArray(err.payload.factors)
+              ? err.payload.factors
+              : [],
+          }),
+        )
+        window.location.href = `/mfa${sharedQuery}`
+        return
+      }
+      if (
+        err instanceof APIRequestError &&
+        err.payload.error === 'email_not_verified'
+      ) {
+        window.location.href = `/verify-email${buildQueryWithCurrent({ email })}`
         return
       }
-      window.location.href = returnTo
-    } catch (err) {
       setError(err instanceof Error ? err.message : 'Login failed.')
     } finally {
       setSubmitting(false)
@@ -224,6 +247,18 @@ export default function Login() {
           ) : null}
 
           <div className="flex flex-col gap-3 text-xs text-text-muted">
+            <a
+              className="text-text-muted transition duration-fast ease-standard hover:text-text-secondary"
+              href={`/passkey${sharedQuery}`}
+            >
+              Sign in with a passkey
+            </a>
+            <a
+              className="text-text-muted transition duration-fast ease-standard hover:text-text-secondary"
+              href={`/magic-link${sharedQuery}`}
+            >
+              Email me a sign-in link
+            </a>
             <a
               className="text-text-muted transition duration-fast ease-standard hover:text-text-secondary"
               href={`/otp/request${sharedQuery}`}
@@ -0,0 +1,307 @@
+import { useEffect, useMemo, useState } from 'react'
+import { postJSON } from '../api'
+import AuthBrand from '../components/AuthBrand'
+import AuthButton from '../components/AuthButton'
+import AuthCard from '../components/AuthCard'
+import AuthInput from '../components/AuthInput'
+import AuthLayout from '../components/AuthLayout'
+import { nextURLAfterSignIn, type UserOrg } from '../utils/orgs'
+import { buildQueryWithCurrent, getQueryParam } from '../utils/query'
+import {
+  getPasskey,
+  passkeysSupported,
+  type JSONRequestOptions,
+  type PasskeyOptions,
+} from '../utils/webauthn'
+
+type MFAChallenge = {
+  mfa_token: string
+  enrollment_required: boolean
+  factors?: string[]
+}
+
+type TOTPEnrollment = {
+  secret: string
+  otpauth_uri: string
+}
+
+type MFAVerifyResponse = {
+  access_token: string
+  token_type: string
+  expires_in: number
+  authorize_url?: string
+  orgs?: UserOrg[]
+  recovery_codes?: string[]
+}
+
+// Redirect-based logins (such as magic links) pass the challenge in the URL
+// fragment; move it into session storage and out of the address bar.
+function adoptFragmentChallenge() {
+  const params = new URLSearchParams(window.location.hash.slice(1))
+  const token = params.get('mfa_token')
+  if (!token) {
+    return
+  }
+  const factors = (params.get('factors') || '').split(',').filter(Boolean)
+  sessionStorage.setItem(
+    'mfa_challenge',
+    JSON.stringify({
+      mfa_token: token,
+      enrollment_required: params.get('enrollment_required') === 'true',
+      factors,
+    }),
+  )
+  window.history.replaceState(
+    null,
+    '',
+    window.location.pathname + window.location.search,
+  )
+}
+
+function loadChallenge(): MFAChallenge | null {
+  adoptFragmentChallenge()
+  try {
+    const raw = sessionStorage.getItem('mfa_challenge')
+    return raw ? (JSON.parse(raw) as MFAChallenge) : null
+  } catch {
+    return null
+  }
+}
+
+export default function MFAVerify() {
+  const returnTo = useMemo(() => getQueryParam('return_to') || '/', [])
+  const authorizeState = useMemo(() => getQueryParam('state'), [])
+  const sharedQuery = useMemo(() => buildQueryWithCurrent(), [])
+  const challenge = useMemo(loadChallenge, [])
+  const passkeyAvailable = useMemo(
+    () =>
+      passkeysSupported() && (challenge?.factors ?? []).includes('passkey'),
+    [challenge],
+  )
+  const totpAvailable = useMemo(
+    () =>
+      !challenge?.factors ||
+      challenge.factors.length === 0 ||
+      challenge.factors.includes('totp'),
+    [challenge],
+  )
+
+  const [code, setCode] = useState('')
+  const [useRecovery, setUseRecovery] = useState(false)
+  const [enrollment, setEnrollment] = useState<TOTPEnrollment | null>(null)
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([])
+  const [nextURL, setNextURL] = useState('')
+  const [error, setError] = useState<string | null>(null)
+  const [submitting, setSubmitting] = useState(false)
+
+  useEffect(() => {
+    if (!challenge?.enrollment_required) {
+      return
+    }
+    postJSON<TOTPEnrollment>('/auth/mfa/challenge/enroll', {
+      mfa_token: challenge.mfa_token,
+    })
+      .then(setEnrollment)
+      .catch((err) =>
+        setError(err instanceof Error ? err.message : 'Enrollment failed.'),
+      )
+  }, [challenge])
+
+  function finish(payload: MFAVerifyResponse) {
+    sessionStorage.removeItem('mfa_challenge')
+    const target = nextURLAfterSignIn(payload, returnTo)
+    if (payload.recovery_codes && payload.recovery_codes.length > 0) {
+      setRecoveryCodes(payload.recovery_codes)
+      setNextURL(target)
+      return
+    }
+    window.location.href = target
+  }
+
+  async function onPasskey() {
+    setError(null)
+
+    if (!challenge) {
+      setError('Your sign-in session expired. Please sign in again.')
+      return
+    }
+
+    setSubmitting(true)
+    try {
+      const begin = await postJSON<PasskeyOptions<JSONRequestOptions>>(
+        '/auth/mfa/challenge/passkey/begin',
+        { mfa_token: challenge.mfa_token },
+      )
+      const credential = await getPasskey(begin.options)
+      const payload = await postJSON<MFAVerifyResponse>(
+        '/auth/mfa/challenge/passkey/finish',
+        {
+          mfa_token: challenge.mfa_token,
+          session_id: begin.session_id,
+          credential,
+          state: authorizeState || undefined,
+        },
+      )
+      finish(payload)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Verification failed.')
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  async function onSubmit(event: React.FormEvent<HTMLFormElement>) {
+    event.preventDefault()
+    setError(null)
+
+    if (!challenge) {
+      setError('Your sign-in session expired. Please sign in again.')
+      return
+    }
+
+    setSubmitting(true)
+    try {
+      const payload = await postJSON<MFAVerifyResponse>(
+        '/auth/mfa/challenge/verify',
+        {
+          mfa_token: challenge.mfa_token,
+          code: useRecovery ? undefined : code,
+          recovery_code: useRecovery ? code : undefined,
+          state: authorizeState || undefined,
+        },
+      )
+      finish(payload)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Verification failed.')
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  if (recoveryCodes.length > 0) {
+    return (
+      <AuthLayout>
+        <AuthCard>
+          <div className="space-y-6">
+            <AuthBrand />
+            <div className="space-y-2">
+              <h1 className="text-2xl font-semibold text-text-primary">
+                Save your recovery codes
+              </h1>
+              <p className="text-sm text-text-muted">
+                Each code can be used once if you lose access to your
+                authenticator app. They will not be shown again.
+              </p>
+            </div>
+            <ul className="grid grid-cols-2 gap-2 font-mono text-sm text-text-primary">
+              {recoveryCodes.map((item) => (
+                <li key={item}>{item}</li>
+              ))}
+            </ul>
+            <AuthButton onClick={() => (window.location.href = nextURL)}>
+              Continue
+            </AuthButton>
+          </div>
+        </AuthCard>
+      </AuthLayout>
+    )
+  }
+
+  return (
+    <AuthLayout>
+      <AuthCard>
+        <div className="space-y-6">
+          <AuthBrand />
+
+          <div className="space-y-2">
+            <h1 className="text-3xl font-semibold tracking-tight text-text-primary">
+              Two-step verification
+            </h1>
+            <p className="text-sm text-text-muted">
+              {enrollment
+                ? 'Add this account to your authenticator app, then enter the code it shows.'
+                : totpAvailable
+                  ? 'Enter the code from your authenticator app.'
+                  : 'Confirm it is you with your passkey.'}
+            </p>
+          </div>
+
+          {enrollment ? (
+            <div className="space-y-2 rounded-xl border border-border-subtle/60 px-4 py-3 text-sm">
+              <p className="text-text-muted">Setup key</p>
+              <p className="break-all font-mono text-text-primary">
+                {enrollment.secret}
+              </p>
+              <a
+                className="text-text-secondary underline-offset-4 hover:underline"
+                href={enrollment.otpauth_uri}
+              >
+                Open in authenticator app
+              </a>
+            </div>
+          ) : null}
+
+          {totpAvailable ? (
+            <form className="space-y-4" onSubmit={onSubmit}>
+              <AuthInput
+                label={useRecovery ? 'Recovery code' : 'Authentication code'}
+                type="text"
+                inputMode={useRecovery ? 'text' : 'numeric'}
+                autoComplete="one-time-code"
+                required
+                value={code}
+                onChange={(event) => setCode(event.target.value)}
+              />
+
+              <AuthButton type="submit" disabled={submitting}>
+                {submitting ? 'Verifying...' : 'Verify'}
+              </AuthButton>
+            </form>
+          ) : null}
+
+          {passkeyAvailable ? (
+            <AuthButton
+              variant={totpAvailable ? 'secondary' : 'primary'}
+              onClick={onPasskey}
+              disabled={submitting}
+            >
+              Use a passkey
+            </AuthButton>
+          ) : null}
+
+          {error ? (
+            <div
+              className="rounded-xl border border-status-error/40 bg-status-error/10 px-4 py-3 text-sm text-status-error"
+              role="alert"
+            >
+              {error}
+            </div>
+          ) : null}
+
+          <div className="flex items-center justify-between text-xs text-text-muted">
+            {challenge?.enrollment_required || !totpAvailable ? (
+              <span />
+            ) : (
+              <button
+                type="button"
+                className="text-text-secondary transition duration-fast ease-standard hover:text-text-primary"
+                onClick={() => {
+                  setUseRecovery(!useRecovery)
+                  setCode('')
+                }}
+              >
+                {useRecovery ? 'Use authenticator code' : 'Use a recovery code'}
+              </button>
+            )}
+            <a
+              className="text-text-secondary transition duration-fast ease-standard hover:text-text-primary"
+              href={`/login${sharedQuery}`}
+            >
+              Back to sign in
+            </a>
+          </div>
+        </div>
+      </AuthCard>
+    </AuthLayout>
+  )
+}
@@ -0,0 +1,109 @@
+import { useMemo, useState } from 'react'
+import { postJSON } from '../api'
+import AuthBrand from '../components/AuthBrand'
+import AuthButton from '../components/AuthButton'
+import AuthCard from '../components/AuthCard'
+import AuthLayout from '../components/AuthLayout'
+import { nextURLAfterSignIn, type UserOrg } from '../utils/orgs'
+import { buildQueryWithCurrent, getQueryParam } from '../utils/query'
+import {
+  getPasskey,
+  passkeysSupported,
+  type JSONRequestOptions,
+  type PasskeyOptions,
+} from '../utils/webauthn'
+
+type LoginResponse = {
+  access_token: string
+  token_type: string
+  expires_in: number
+  authorize_url?: string
+  orgs?: UserOrg[]
+}
+
+export default function PasskeyLogin() {
+  const returnTo = useMemo(() => getQueryParam('return_to') || '/', [])
+  const authorizeState = useMemo(() => getQueryParam('state'), [])
+  const scope = useMemo(
+    () => getQueryParam('scope') || 'openid email profile',
+    [],
+  )
+  const sharedQuery = useMemo(() => buildQueryWithCurrent(), [])
+  const supported = useMemo(passkeysSupported, [])
+
+  const [error, setError] = useState<string | null>(null)
+  const [submitting, setSubmitting] = useState(false)
+
+  async function onSignIn() {
+    setError(null)
+
+    if (!authorizeState) {
+      setError('Missing state. Please retry from the OAuth authorize flow.')
+      return
+    }
+
+    setSubmitting(true)
+    try {
+      const begin = await postJSON<PasskeyOptions<JSONRequestOptions>>(
+        '/auth/passkey/login/begin',
+        {},
+      )
+      const credential = await getPasskey(begin.options)
+      // UI never stores tokens; the backend sets HttpOnly session cookies.
+      const payload = await postJSON<LoginResponse>('/auth/passkey/login/finish', {
+        session_id: begin.session_id,
+        credential,
+        scope,
+        state: authorizeState,
+      })
+      window.location.href = nextURLAfterSignIn(payload, returnTo)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Passkey sign-in failed.')
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  return (
+    <AuthLayout>
+      <AuthCard>
+        <div className="space-y-6">
+          <AuthBrand />
+
+          <div className="space-y-2">
+            <h1 className="text-3xl font-semibold tracking-tight text-text-primary">
+              Sign in with a passkey
+            </h1>
+            <p className="text-sm text-text-muted">
+              {supported
+                ? 'Use your device screen lock, security key, or phone to continue.'
+                : 'This browser does not support passkeys.'}
+            </p>
+          </div>
+
+          {error ? (
+            <div
+              className="rounded-xl border border-status-error/40 bg-status-error/10 px-4 py-3 text-sm text-status-error"
+              role="alert"
+            >
+              {error}
+            </div>
+          ) : null}
+
+          <AuthButton onClick={onSignIn} disabled={!supported || submitting}>
+            {submitting ? 'Waiting for passkey...' : 'Continue with passkey'}
+          </AuthButton>
+
+          <div className="flex flex-col gap-3 text-xs text-text-muted">
+            <a
+              className="text-text-muted transition duration-fast ease-standard hover:text-text-secondary"
+              href={`/login${sharedQuery}`}
+            >
+              Use your password instead
+            </a>
+          </div>
+        </div>
+      </AuthCard>
+    </AuthLayout>
+  )
+}
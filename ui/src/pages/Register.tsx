@@ -1,5 +1,5 @@
 import { useMemo, useState } from 'react'
-import { postJSON } from '../api'
+import { APIRequestError, postJSON } from '../api'
 import AuthBrand from '../components/AuthBrand'
 import AuthButton from '../components/AuthButton'
 import AuthCard from '../components/AuthCard'
@@ -65,6 +65,13 @@ export default function Register() {
       }
       window.location.href = returnTo
     } catch (err) {
+      if (
+        err instanceof APIRequestError &&
+        err.payload.error === 'email_not_verified'
+      ) {
+        window.location.href = `/verify-email${buildQueryWithCurrent({ email })}`
+        return
+      }
       setError(err instanceof Error ? err.message : 'Registration failed.')
     } finally {
       setSubmitting(false)
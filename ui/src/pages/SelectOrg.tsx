@@ -0,0 +1,61 @@
+import { useMemo } from 'react'
+import AuthBrand from '../components/AuthBrand'
+import AuthButton from '../components/AuthButton'
+import AuthCard from '../components/AuthCard'
+import AuthLayout from '../components/AuthLayout'
+import {
+  authorizeURLForOrg,
+  clearOrgChoice,
+  loadOrgChoice,
+  type UserOrg,
+} from '../utils/orgs'
+
+export default function SelectOrg() {
+  const choice = useMemo(loadOrgChoice, [])
+
+  function onSelect(org: UserOrg) {
+    if (!choice) {
+      return
+    }
+    clearOrgChoice()
+    window.location.href = authorizeURLForOrg(choice.authorize_url, org.id)
+  }
+
+  return (
+    <AuthLayout>
+      <AuthCard>
+        <div className="space-y-6">
+          <AuthBrand />
+
+          <div className="space-y-2">
+            <h1 className="text-3xl font-semibold tracking-tight text-text-primary">
+              Choose an organization
+            </h1>
+            <p className="text-sm text-text-muted">
+              You belong to more than one organization. Pick the one to
+              continue in; you can switch later.
+            </p>
+          </div>
+
+          {choice ? (
+            <div className="space-y-3">
+              {choice.orgs.map((org) => (
+                <AuthButton key={org.id} onClick={() => onSelect(org)}>
+                  {org.name}
+                  {org.role ? ` (${org.role.toLowerCase()})` : ''}
+                </AuthButton>
+              ))}
+            </div>
+          ) : (
+            <div
+              className="rounded-xl border border-status-error/40 bg-status-error/10 px-4 py-3 text-sm text-status-error"
+              role="alert"
+            >
+              Your sign-in expired. Please start again from the app.
+            </div>
+          )}
+        </div>
+      </AuthCard>
+    </AuthLayout>
+  )
+}
@@ -0,0 +1,58 @@
+export type UserOrg = {
+  id: string
+  name: string
+  slug: string
+  role?: string
+  current: boolean
+}
+
+type SignInResult = {
+  authorize_url?: string
+  orgs?: UserOrg[]
+}
+
+const orgChoiceKey = 'org_choice'
+
+export type OrgChoice = {
+  authorize_url: string
+  orgs: UserOrg[]
+}
+
+// nextURLAfterSignIn returns where to go once signed in. Users who belong to
+// several orgs pick one before the authorize flow continues.
+export function nextURLAfterSignIn(
+  payload: SignInResult,
+  fallback: string,
+): string {
+  if (!payload.authorize_url) {
+    return fallback
+  }
+  if (payload.orgs && payload.orgs.length > 1) {
+    const choice: OrgChoice = {
+      authorize_url: payload.authorize_url,
+      orgs: payload.orgs,
+    }
+    sessionStorage.setItem(orgChoiceKey, JSON.stringify(choice))
+    return '/select-org'
+  }
+  return payload.authorize_url
+}
+
+export function loadOrgChoice(): OrgChoice | null {
+  try {
+    const raw = sessionStorage.getItem(orgChoiceKey)
+    return raw ? (JSON.parse(raw) as OrgChoice) : null
+  } catch {
+    return null
+  }
+}
+
+export function clearOrgChoice() {
+  sessionStorage.removeItem(orgChoiceKey)
+}
+
+export function authorizeURLForOrg(authorizeURL: string, orgID: string) {
+  const url = new URL(authorizeURL, window.location.origin)
+  url.searchParams.set('org_id', orgID)
+  return url.pathname + url.search
+}
@@ -1,4 +1,11 @@
-const preservedKeys = ['state', 'return_to', 'client_id', 'scope'] as const
+const preservedKeys = [
+  'state',
+  'return_to',
+  'client_id',
+  'scope',
+  'login_hint',
+  'ui_locales',
+] as const
 
 type QueryValue = string | null | undefined
 
@@ -9,6 +16,15 @@ export function getQueryParam(name: string): string {
   return params.get(name) ?? ''
 }
 
+// applyUILocales sets the page language from the first OIDC ui_locales
+// entry, so the browser and assistive technology use it.
+export function applyUILocales() {
+  const [locale] = getQueryParam('ui_locales').split(' ').filter(Boolean)
+  if (locale) {
+    document.documentElement.lang = locale
+  }
+}
+
 export function buildQueryWithCurrent(overrides: QueryMap = {}): string {
   const current = new URLSearchParams(window.location.search)
   const next = new URLSearchParams()
@@ -0,0 +1,125 @@
+// Helpers translating between the JSON options returned by the backend
+// (base64url-encoded buffers) and the browser WebAuthn API.
+
+export type PasskeyOptions<T> = {
+  session_id: string
+  options: T
+}
+
+type JSONCredentialDescriptor = {
+  type: PublicKeyCredentialType
+  id: string
+  transports?: AuthenticatorTransport[]
+}
+
+type JSONCreationOptions = {
+  publicKey: Omit<
+    PublicKeyCredentialCreationOptions,
+    'challenge' | 'user' | 'excludeCredentials'
+  > & {
+    challenge: string
+    user: Omit<PublicKeyCredentialUserEntity, 'id'> & { id: string }
+    excludeCredentials?: JSONCredentialDescriptor[]
+  }
+}
+
+type JSONRequestOptions = {
+  publicKey: Omit<
+    PublicKeyCredentialRequestOptions,
+    'challenge' | 'allowCredentials'
+  > & {
+    challenge: string
+    allowCredentials?: JSONCredentialDescriptor[]
+  }
+}
+
+export function passkeysSupported(): boolean {
+  return (
+    typeof window !== 'undefined' &&
+    typeof window.PublicKeyCredential !== 'undefined' &&
+    typeof navigator.credentials?.get === 'function'
+  )
+}
+
+function fromBase64URL(value: string): ArrayBuffer {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/')
+  const padded = base64.padEnd(base64.length + ((4 - (base64.length % 4)) % 4), '=')
+  const binary = atob(padded)
+  const bytes = new Uint8Array(binary.length)
+  for (let i = 0; i < binary.length; i += 1) {
+    bytes[i] = binary.charCodeAt(i)
+  }
+  return bytes.buffer
+}
+
+function toBase64URL(buffer: ArrayBuffer | null): string | undefined {
+  if (!buffer) {
+    return undefined
+  }
+  const bytes = new Uint8Array(buffer)
+  let binary = ''
+  bytes.forEach((byte) => {
+    binary += String.fromCharCode(byte)
+  })
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+function toDescriptors(
+  items?: JSONCredentialDescriptor[],
+): PublicKeyCredentialDescriptor[] | undefined {
+  return items?.map((item) => ({ ...item, id: fromBase64URL(item.id) }))
+}
+
+export async function createPasskey(options: JSONCreationOptions) {
+  const { publicKey } = options
+  const credential = (await navigator.credentials.create({
+    publicKey: {
+      ...publicKey,
+      challenge: fromBase64URL(publicKey.challenge),
+      user: { ...publicKey.user, id: fromBase64URL(publicKey.user.id) },
+      excludeCredentials: toDescriptors(publicKey.excludeCredentials),
+    },
+  })) as PublicKeyCredential | null
+  if (!credential) {
+    throw new Error('Passkey creation was cancelled.')
+  }
+  const response = credential.response as AuthenticatorAttestationResponse
+  return {
+    id: credential.id,
+    rawId: toBase64URL(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: toBase64URL(response.clientDataJSON),
+      attestationObject: toBase64URL(response.attestationObject),
+      transports: response.getTransports?.() ?? [],
+    },
+  }
+}
+
+export async function getPasskey(options: JSONRequestOptions) {
+  const { publicKey } = options
+  const credential = (await navigator.credentials.get({
+    publicKey: {
+      ...publicKey,
+      challenge: fromBase64URL(publicKey.challenge),
+      allowCredentials: toDescriptors(publicKey.allowCredentials),
+    },
+  })) as PublicKeyCredential | null
+  if (!credential) {
+    throw new Error('Passkey sign-in was cancelled.')
+  }
+  const response = credential.response as AuthenticatorAssertionResponse
+  return {
+    id: credential.id,
+    rawId: toBase64URL(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: toBase64URL(response.clientDataJSON),
+      authenticatorData: toBase64URL(response.authenticatorData),
+      signature: toBase64URL(response.signature),
+      userHandle: toBase64URL(response.userHandle),
+    },
+  }
+}
+
+export type { JSONCreationOptions, JSONRequestOptions }